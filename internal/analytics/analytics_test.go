@@ -1,11 +1,15 @@
 package analytics
 
 import (
-	"github.com/spf13/cobra"
-	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestConstructQueryParameters(t *testing.T) {
@@ -60,3 +64,27 @@ func TestConstructQueryParameters(t *testing.T) {
 	// Script Collection Used
 	assert.Equal(t, "true", params.GetOrDefault("dimension9", ""), "Expected parameter dimension9 = true")
 }
+
+func TestCollectDataAndSendWithoutTenantHeaders(t *testing.T) {
+	var received http.Header
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer svr.Close()
+
+	httpclnt.SetDefaultHeaders(map[string]string{"X-Api-Key": "secret", "X-Flashpipe-Run-Id": "run-1"})
+	httpclnt.SetDefaultSignCommand("echo X-Signature: signed")
+	httpclnt.SetDefaultTokenCommand("echo token")
+	defer httpclnt.SetDefaultHeaders(nil)
+	defer httpclnt.SetDefaultSignCommand("")
+	defer httpclnt.SetDefaultTokenCommand("")
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	collectDataAndSend(&cobra.Command{Use: "artifact"}, nil, time.Now(), host, "http", port, "2", false)
+
+	if assert.NotNil(t, received, "Analytics should be sent") {
+		for _, name := range []string{"X-Api-Key", "X-Flashpipe-Run-Id", "X-Signature", "Authorization"} {
+			assert.Empty(t, received.Get(name), "%s of the tenant should not be sent to analytics", name)
+		}
+	}
+}
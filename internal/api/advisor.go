@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Types of the guidelines of Integration Advisor, which are not part of packages and are promoted by exporting
+// them from one tenant and importing them into another
+const (
+	GuidelineMIG = "MIG" // Message implementation guideline
+	GuidelineMAG = "MAG" // Mapping guideline
+)
+
+// advisorPaths are the paths of the guidelines in the Integration Advisor API by type
+var advisorPaths = map[string]string{
+	GuidelineMIG: "/api/1.0/migs",
+	GuidelineMAG: "/api/1.0/mags",
+}
+
+// guidelineTypes are the lower case names of the guideline types
+var guidelineTypes = map[string]string{
+	"mig":                            GuidelineMIG,
+	"messageimplementationguideline": GuidelineMIG,
+	"mag":                            GuidelineMAG,
+	"mappingguideline":               GuidelineMAG,
+}
+
+// CanonicalGuidelineType returns MIG or MAG for a guideline type in any case, or an empty string if it is not a
+// type of Integration Advisor
+func CanonicalGuidelineType(guidelineType string) string {
+	return guidelineTypes[strings.ToLower(guidelineType)]
+}
+
+// IntegrationAdvisor reads, exports and imports the MIGs and MAGs of Integration Advisor
+type IntegrationAdvisor struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+// Guideline is a version of a MIG or MAG
+type Guideline struct {
+	VersionId string `json:"VersionId"`
+	Name      string `json:"Name"`
+	Version   string `json:"Version"`
+	Status    string `json:"Status"`
+}
+
+// NewIntegrationAdvisor returns an initialised IntegrationAdvisor instance.
+func NewIntegrationAdvisor(exe *httpclnt.HTTPExecuter) *IntegrationAdvisor {
+	a := new(IntegrationAdvisor)
+	a.exe = exe
+	return a
+}
+
+// List returns the versions of the guidelines of a type
+func (a *IntegrationAdvisor) List(guidelineType string) ([]Guideline, error) {
+	path, err := advisorPath(guidelineType)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msgf("Getting list of %ss", guidelineType)
+	callType := fmt.Sprintf("Get %ss", guidelineType)
+	resp, err := readOnlyCall(path, callType, a.exe)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := a.exe.ReadRespBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	var guidelines []Guideline
+	if err := json.Unmarshal(respBody, &guidelines); err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return nil, errors.Wrap(err, 0)
+	}
+	return guidelines, nil
+}
+
+// Find returns the version of a guideline with the version ID, or the latest version of the guideline with the
+// name, with the highest version. An error is returned if there is none.
+func (a *IntegrationAdvisor) Find(guidelineType string, nameOrVersionId string) (*Guideline, error) {
+	guidelines, err := a.List(guidelineType)
+	if err != nil {
+		return nil, err
+	}
+	var found *Guideline
+	for i := range guidelines {
+		guideline := &guidelines[i]
+		if guideline.VersionId == nameOrVersionId {
+			return guideline, nil
+		}
+		if guideline.Name == nameOrVersionId && (found == nil || compareGuidelineVersions(guideline.Version, found.Version) > 0) {
+			found = guideline
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s %s not found", guidelineType, nameOrVersionId)
+	}
+	return found, nil
+}
+
+// Export returns the export file of a version of a guideline, which can be imported into another tenant
+func (a *IntegrationAdvisor) Export(guidelineType string, versionId string) ([]byte, error) {
+	path, err := advisorPath(guidelineType)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msgf("Exporting %s %s", guidelineType, versionId)
+	callType := fmt.Sprintf("Export %s", guidelineType)
+	resp, err := readOnlyCallWithBody(fmt.Sprintf("%s/%s/export", path, url.PathEscape(versionId)), nil, callType, a.exe)
+	if err != nil {
+		return nil, err
+	}
+	return a.exe.ReadRespBody(resp)
+}
+
+// Import imports the export file of a guideline. A MAG requires its source and target MIGs on the tenant, so MIGs
+// are imported first.
+func (a *IntegrationAdvisor) Import(guidelineType string, content []byte) error {
+	path, err := advisorPath(guidelineType)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Importing %s", guidelineType)
+	callType := fmt.Sprintf("Import %s", guidelineType)
+	return ModifyingCall("POST", path+"/import", content, "application/octet-stream", []int{200, 201, 202}, callType, a.exe)
+}
+
+func advisorPath(guidelineType string) (string, error) {
+	path, ok := advisorPaths[guidelineType]
+	if !ok {
+		return "", fmt.Errorf("invalid guideline type %q (allowed values: %s, %s)", guidelineType, GuidelineMIG, GuidelineMAG)
+	}
+	return path, nil
+}
+
+// compareGuidelineVersions compares versions like 1.0 and 1.10 by their numeric parts
+func compareGuidelineVersions(a string, b string) int {
+	toParts := func(version string) []int {
+		var parts []int
+		for _, part := range strings.Split(version, ".") {
+			n := 0
+			fmt.Sscanf(part, "%d", &n)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+	return slices.Compare(toParts(a), toParts(b))
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationAdvisorMock(t *testing.T) {
+	var imported []byte
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-CSRF-Token", "dummy")
+	})
+	mux.HandleFunc("GET /api/1.0/mags", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[ { "VersionId": "a1", "Name": "Orders_X12_to_IDoc", "Version": "1.9", "Status": "Active" },
+			{ "VersionId": "a2", "Name": "Orders_X12_to_IDoc", "Version": "1.10", "Status": "Draft" },
+			{ "VersionId": "b1", "Name": "Invoice_IDoc_to_X12", "Version": "2.0", "Status": "Active" } ]`))
+	})
+	mux.HandleFunc("GET /api/1.0/mags/a2/export", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("mag export"))
+	})
+	mux.HandleFunc("POST /api/1.0/mags/import", func(w http.ResponseWriter, r *http.Request) {
+		imported, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	})
+	svr := httptest.NewServer(mux)
+
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	advisor := NewIntegrationAdvisor(exe)
+
+	guidelines, err := advisor.List(GuidelineMAG)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(guidelines), "Incorrect number of MAGs")
+
+	guideline, err := advisor.Find(GuidelineMAG, "Orders_X12_to_IDoc")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", guideline.VersionId, "The highest version should be found by name")
+	guideline, err = advisor.Find(GuidelineMAG, "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.9", guideline.Version, "The version should be found by version ID")
+	_, err = advisor.Find(GuidelineMAG, "Unknown")
+	assert.EqualError(t, err, "MAG Unknown not found")
+
+	content, err := advisor.Export(GuidelineMAG, "a2")
+	require.NoError(t, err)
+	assert.Equal(t, "mag export", string(content))
+
+	require.NoError(t, advisor.Import(GuidelineMAG, content))
+	assert.Equal(t, "mag export", string(imported))
+
+	_, err = advisor.List(GuidelineMIG)
+	assert.Error(t, err, "Listing MIGs should fail without a mock response")
+	_, err = advisor.List("XSLT")
+	assert.EqualError(t, err, `invalid guideline type "XSLT" (allowed values: MIG, MAG)`)
+}
+
+func TestCanonicalGuidelineType(t *testing.T) {
+	assert.Equal(t, GuidelineMIG, CanonicalGuidelineType("mig"))
+	assert.Equal(t, GuidelineMAG, CanonicalGuidelineType("MappingGuideline"))
+	assert.Empty(t, CanonicalGuidelineType("IntegrationFlow"))
+}
@@ -4,9 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
 	"github.com/go-errors/errors"
 	"github.com/rs/zerolog/log"
-	"net/url"
+	"strings"
 )
 
 type Configuration struct {
@@ -19,11 +20,8 @@ type ParametersData struct {
 	} `json:"d"`
 }
 
-type ParameterData struct {
-	ParameterKey   string `json:"ParameterKey,omitempty"`
-	ParameterValue string `json:"ParameterValue"`
-	DataType       string `json:"DataType,omitempty"`
-}
+// ParameterData is a configuration parameter as read and written by the OData APIs
+type ParameterData = odata.Configuration
 
 func NewConfiguration(exe *httpclnt.HTTPExecuter) *Configuration {
 	c := new(Configuration)
@@ -33,7 +31,7 @@ func NewConfiguration(exe *httpclnt.HTTPExecuter) *Configuration {
 
 func (c *Configuration) Get(id string, version string) (*ParametersData, error) {
 	log.Info().Msgf("Getting configuration parameters of Integration designtime artifact %v", id)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationDesigntimeArtifacts(Id='%v',Version='%v')/Configurations", id, version)
+	urlPath := odata.IntegrationDesigntimeArtifactConfigurationsPath(id, version)
 
 	callType := "Get configuration parameters"
 	resp, err := readOnlyCall(urlPath, callType, c.exe)
@@ -55,17 +53,91 @@ func (c *Configuration) Get(id string, version string) (*ParametersData, error)
 
 func (c *Configuration) Update(id string, version string, key string, value string) error {
 	log.Info().Msgf("Updating configuration parameter %v of Integration designtime artifact %v", key, id)
-	// Spaces in key needs to be escaped
-	encodedKey := url.PathEscape(key)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationDesigntimeArtifacts(Id='%v',Version='%v')/$links/Configurations('%v')", id, version, encodedKey)
+	urlPath, requestBody, err := ConfigurationUpdateRequest(id, version, key, value)
+	if err != nil {
+		return err
+	}
+
+	return modifyingCall("PUT", urlPath, requestBody, ConfigurationUpdateSuccessCode, fmt.Sprintf("Update configuration parameter %v", key), c.exe)
+}
+
+// Create adds a configuration parameter that does not exist in the Integration designtime artifact. Not all
+// tenants support this, the others reject the request.
+func (c *Configuration) Create(id string, version string, key string, value string) error {
+	log.Info().Msgf("Creating configuration parameter %v of Integration designtime artifact %v", key, id)
+	urlPath := odata.IntegrationDesigntimeArtifactConfigurationsPath(id, version)
+
+	requestBody, err := json.Marshal(&ParameterData{ParameterKey: key, ParameterValue: value, DataType: "xsd:string"})
+	if err != nil {
+		return err
+	}
+	return modifyingCall("POST", urlPath, requestBody, 201, fmt.Sprintf("Create configuration parameter %v", key), c.exe)
+}
+
+// ConfigurationUpdateSuccessCode is the response code of a successful configuration parameter update
+const ConfigurationUpdateSuccessCode = 202
+
+// ConfigurationUpdateRequest returns the path and JSON body of the PUT request that updates a configuration
+// parameter, e.g. to send it later
+func ConfigurationUpdateRequest(id string, version string, key string, value string) (string, []byte, error) {
+	urlPath := odata.IntegrationDesigntimeArtifactConfigurationLinkPath(id, version, key)
 
 	parameterData := &ParameterData{ParameterValue: value}
 	requestBody, err := json.Marshal(parameterData)
 	if err != nil {
-		return err
+		return "", nil, err
+	}
+	return urlPath, requestBody, nil
+}
+
+// BatchConfiguration is the configuration of one artifact read with GetBatch
+type BatchConfiguration struct {
+	Parameters *ParametersData
+	Err        error
+}
+
+// GetBatch reads the configuration parameters of several Integration designtime artifacts with OData $batch
+// requests of at most batchSize artifacts. If fields are given, only these properties are selected. Artifacts
+// that could not be read have Err set, an error is only returned if a batch request fails as a whole.
+func (c *Configuration) GetBatch(ids []string, version string, fields []string, batchSize int) (map[string]*BatchConfiguration, error) {
+	log.Info().Msgf("Getting configuration parameters of %d Integration designtime artifacts in batch", len(ids))
+	batch := c.exe.NewBatchRequest()
+	for i, id := range ids {
+		urlPath := odata.IntegrationDesigntimeArtifactConfigurationsPath(id, version)
+		if len(fields) > 0 {
+			urlPath += "?$select=" + strings.Join(fields, ",")
+		}
+		batch.AddOperation(httpclnt.BatchOperation{
+			Method:    "GET",
+			Path:      urlPath,
+			ContentID: fmt.Sprintf("config_%d", i),
+			Headers:   map[string]string{"Accept": "application/json"},
+			IsQuery:   true,
+		})
+	}
+	resp, err := batch.ExecuteInBatches(batchSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Operations) != len(ids) {
+		return nil, fmt.Errorf("batch response contains %d operations, expected %d", len(resp.Operations), len(ids))
 	}
 
-	return modifyingCall("PUT", urlPath, requestBody, 202, fmt.Sprintf("Update configuration parameter %v", key), c.exe)
+	configurations := map[string]*BatchConfiguration{}
+	for i, op := range resp.Operations {
+		result := &BatchConfiguration{Err: op.Error}
+		if result.Err == nil && op.StatusCode != 200 {
+			result.Err = fmt.Errorf("Get configuration parameters call failed with response code = %d", op.StatusCode)
+		}
+		if result.Err == nil {
+			if err := json.Unmarshal(op.Body, &result.Parameters); err != nil {
+				log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", op.Body)
+				result.Err = errors.Wrap(err, 0)
+			}
+		}
+		configurations[ids[i]] = result
+	}
+	return configurations, nil
 }
 
 func FindParameterByKey(key string, list []*ParameterData) *ParameterData {
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -8,6 +11,7 @@ import (
 	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -113,3 +117,33 @@ func (suite *ConfigurationSuite) TestConfiguration_Update() {
 	parameter2 := FindParameterByKey("Parameter 2", parametersData.Root.Results)
 	assert.Equal(suite.T(), "Value 2 with ${header.Parameter1}", parameter2.ParameterValue, "Parameter 2 should have value Value 2 with ${header.Parameter1} after update")
 }
+
+func TestConfiguration_GetBatchMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	var requestBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/$batch", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requestBody = string(body)
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresponse_1")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("--batchresponse_1\r\nContent-Type: application/http\r\nContent-Transfer-Encoding: binary\r\n\r\n" +
+			"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n" +
+			`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "dev-host", "DataType": "xsd:string" } ] } }` +
+			"\r\n--batchresponse_1\r\nContent-Type: application/http\r\nContent-Transfer-Encoding: binary\r\n\r\n" +
+			"HTTP/1.1 404 Not Found\r\nContent-Type: application/json\r\n\r\n{}" +
+			"\r\n--batchresponse_1--\r\n"))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	configurations, err := NewConfiguration(exe).GetBatch([]string{"FlowA", "FlowB"}, "active", []string{"ParameterKey", "ParameterValue"}, 90)
+	require.NoError(t, err)
+	assert.Contains(t, requestBody, "GET /api/v1/IntegrationDesigntimeArtifacts(Id='FlowB',Version='active')/Configurations?$select=ParameterKey,ParameterValue HTTP/1.1")
+	require.NoError(t, configurations["FlowA"].Err)
+	assert.Equal(t, "dev-host", configurations["FlowA"].Parameters.Root.Results[0].ParameterValue, "Incorrect parameter value")
+	assert.Error(t, configurations["FlowB"].Err, "Artifact not found should be an error")
+}
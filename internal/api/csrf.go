@@ -35,7 +35,7 @@ func (c *Csrf) GetToken() (string, []*http.Cookie, error) {
 			c.csrfCookies = resp.Cookies()
 			log.Debug().Msgf("Received CSRF Token - %v", c.token)
 		} else {
-			_, err = c.exe.LogError(resp, "Get CSRF Token")
+			_, err = responseError(c.exe, resp, "Get CSRF Token")
 			return "", nil, err
 		}
 	}
@@ -46,7 +46,7 @@ func InitHeadersAndCookies(exe *httpclnt.HTTPExecuter) (headers map[string]strin
 	headers = map[string]string{}
 	cookies = []*http.Cookie{}
 
-	if exe.AuthType == "BASIC" {
+	if GetPlatform(exe).RequiresCsrfToken(exe.AuthType) {
 		csrf := NewCsrf(exe)
 		var token string
 		token, cookies, err = csrf.GetToken()
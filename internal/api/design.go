@@ -3,18 +3,20 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+
 	"github.com/engswee/flashpipe/internal/file"
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
 	"github.com/go-errors/errors"
 	"github.com/rs/zerolog/log"
-	"os"
-	"path/filepath"
 )
 
 type DesigntimeArtifact interface {
 	Create(id string, name string, packageId string, artifactDir string) error
 	Update(id string, name string, packageId string, artifactDir string) error
 	Deploy(id string) error
+	DeployVersion(id string, version string) error
 	Delete(id string) error
 	Get(id string, version string) (string, string, bool, error)
 	Download(targetFile string, id string) error
@@ -36,8 +38,36 @@ type designtimeArtifactUpdateData struct {
 	ArtifactContent string `json:"ArtifactContent"`
 }
 
+// artifactTypes maps the lower case artifact types and their aliases to the artifact type
+var artifactTypes = map[string]string{
+	"integration":      "Integration",
+	"integrationflow":  "Integration",
+	"iflow":            "Integration",
+	"messagemapping":   "MessageMapping",
+	"mm":               "MessageMapping",
+	"scriptcollection": "ScriptCollection",
+	"sc":               "ScriptCollection",
+	"valuemapping":     "ValueMapping",
+	"vm":               "ValueMapping",
+}
+
+// UnsupportedArtifactTypeHint returns why a type in any case cannot be handled like the artifacts of packages, e.g.
+// for the MIGs and MAGs of Integration Advisor, or an empty string if there is no explanation
+func UnsupportedArtifactTypeHint(artifactType string) string {
+	if guidelineType := CanonicalGuidelineType(artifactType); guidelineType != "" {
+		return fmt.Sprintf("Integration Advisor %ss are not part of packages, promote them with flashpipe advisor export and advisor import", guidelineType)
+	}
+	return ""
+}
+
+// CanonicalArtifactType returns the artifact type for a type in any case or an alias of it, e.g. iflow for
+// Integration, or an empty string if the type is not supported
+func CanonicalArtifactType(artifactType string) string {
+	return artifactTypes[strings.ToLower(artifactType)]
+}
+
 func NewDesigntimeArtifact(artifactType string, exe *httpclnt.HTTPExecuter) DesigntimeArtifact {
-	switch artifactType {
+	switch CanonicalArtifactType(artifactType) {
 	case "MessageMapping":
 		return NewMessageMapping(exe)
 	case "ScriptCollection":
@@ -75,46 +105,69 @@ func constructUpdateBody(method string, id string, name string, packageId string
 
 func download(targetFile string, id string, artifactType string, exe *httpclnt.HTTPExecuter) error {
 	log.Info().Msgf("Getting content of artifact %v from tenant for comparison", id)
-	content, err := getContent(id, "active", artifactType, exe)
-	if err != nil {
-		return err
-	}
+	urlPath := odata.DesigntimeArtifactValuePath(artifactType, id, "active")
 
-	// Create directory for target file if it doesn't exist yet
-	err = os.MkdirAll(filepath.Dir(targetFile), os.ModePerm)
+	callType := fmt.Sprintf("Download %v designtime artifact", artifactType)
+	resp, err := readOnlyCall(urlPath, callType, exe)
 	if err != nil {
 		return err
 	}
-
-	err = os.WriteFile(targetFile, content, os.ModePerm)
+	// Streamed to the file, as the content of large artifacts can exceed the memory of small CI runners
+	size, err := exe.SaveRespBody(resp, targetFile)
 	if err != nil {
 		return err
 	}
-	log.Info().Msgf("Content of artifact %v downloaded to %v", id, targetFile)
+	log.Info().Msgf("Content of artifact %v downloaded to %v (%d bytes)", id, targetFile, size)
 	return nil
 }
 
 func create(id string, name string, packageId string, artifactDir string, artifactType string, exe *httpclnt.HTTPExecuter) error {
 	log.Info().Msgf("Creating %v designtime artifact %v", artifactType, id)
-	urlPath := fmt.Sprintf("/api/v1/%vDesigntimeArtifacts", artifactType)
+	urlPath := odata.DesigntimeArtifactsPath(artifactType)
 	return upsert(id, name, packageId, artifactDir, "POST", urlPath, 201, artifactType, "Create", exe)
 }
 
 func update(id string, name string, packageId string, artifactDir string, artifactType string, exe *httpclnt.HTTPExecuter) error {
 	log.Info().Msgf("Updating %v designtime artifact %v", artifactType, id)
-	urlPath := fmt.Sprintf("/api/v1/%vDesigntimeArtifacts(Id='%v',Version='active')", artifactType, id)
+	urlPath := odata.DesigntimeArtifactPath(artifactType, id, "active")
 	return upsert(id, name, packageId, artifactDir, "PUT", urlPath, 200, artifactType, "Update", exe)
 }
 
-func deploy(id string, artifactType string, exe *httpclnt.HTTPExecuter) error {
-	log.Info().Msgf("Deploying %v designtime artifact %v", artifactType, id)
-	urlPath := fmt.Sprintf("/api/v1/Deploy%vDesigntimeArtifact?Id='%s'&Version='active'", artifactType, id)
-	return modifyingCall("POST", urlPath, nil, 202, fmt.Sprintf("Deploy %v designtime artifact", artifactType), exe)
+func deploy(id string, version string, artifactType string, exe *httpclnt.HTTPExecuter) error {
+	if version == "" || version == "active" {
+		log.Info().Msgf("Deploying %v designtime artifact %v", artifactType, id)
+	} else {
+		// Deploying a version that does not exist fails asynchronously, so it is checked up front
+		_, _, exists, err := get(id, version, artifactType, exe)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("version %v of %v designtime artifact %v not found", version, artifactType, id)
+		}
+		log.Info().Msgf("Deploying version %v of %v designtime artifact %v", version, artifactType, id)
+	}
+	urlPath := DeployVersionPath(id, artifactType, version)
+	return modifyingCallAccepting("POST", urlPath, nil, "application/json", GetPlatform(exe).DeployAccepted, fmt.Sprintf("Deploy %v designtime artifact", artifactType), exe)
+}
+
+// DeployPath returns the path of the POST request that deploys the active version of a designtime artifact
+func DeployPath(id string, artifactType string) string {
+	return DeployVersionPath(id, artifactType, "active")
+}
+
+// DeployVersionPath returns the path of the POST request that deploys a version of a designtime artifact, the
+// active version if version is empty
+func DeployVersionPath(id string, artifactType string, version string) string {
+	if version == "" {
+		version = "active"
+	}
+	return odata.DeployDesigntimeArtifactPath(artifactType, id, version)
 }
 
 func deleteCall(id string, artifactType string, exe *httpclnt.HTTPExecuter) error {
 	log.Info().Msgf("Deleting %v designtime artifact %v", artifactType, id)
-	urlPath := fmt.Sprintf("/api/v1/%vDesigntimeArtifacts(Id='%v',Version='active')", artifactType, id)
+	urlPath := odata.DesigntimeArtifactPath(artifactType, id, "active")
 	return modifyingCall("DELETE", urlPath, nil, 200, fmt.Sprintf("Delete %v designtime artifact", artifactType), exe)
 }
 
@@ -135,12 +188,12 @@ func upsert(id string, name string, packageId string, artifactDir string, method
 
 func get(id string, version string, artifactType string, exe *httpclnt.HTTPExecuter) (string, string, bool, error) {
 	log.Info().Msgf("Getting details of %v designtime artifact %v", artifactType, id)
-	urlPath := fmt.Sprintf("/api/v1/%vDesigntimeArtifacts(Id='%v',Version='%v')", artifactType, id, version)
+	urlPath := odata.DesigntimeArtifactPath(artifactType, id, version)
 
 	callType := fmt.Sprintf("Get %v designtime artifact", artifactType)
 	resp, err := readOnlyCall(urlPath, callType, exe)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("%v call failed with response code = 404", callType) {
+		if errors.Is(err, ErrNotFound) {
 			return "", "", false, nil
 		} else {
 			return "", "", false, err
@@ -160,27 +213,28 @@ func get(id string, version string, artifactType string, exe *httpclnt.HTTPExecu
 	return jsonData.Root.Version, jsonData.Root.Description, true, nil
 }
 
-func getContent(id string, version string, artifactType string, exe *httpclnt.HTTPExecuter) ([]byte, error) {
-	log.Info().Msgf("Getting content of %v designtime artifact %v", artifactType, id)
-	urlPath := fmt.Sprintf("/api/v1/%vDesigntimeArtifacts(Id='%v',Version='%v')/$value", artifactType, id, version)
+// diffContent compares the hashes of the normalized files of META-INF, src/main/resources and metainfo.prop and
+// logs the files that differ, so that unchanged artifacts are not uploaded again
+func diffContent(firstDir string, secondDir string) (bool, error) {
+	log.Info().Msg("Checking for changes in META-INF, src/main/resources and metainfo.prop")
+	return diffFiles(firstDir, secondDir, "META-INF", "src/main/resources", "metainfo.prop")
+}
 
-	callType := fmt.Sprintf("Download %v designtime artifact", artifactType)
-	resp, err := readOnlyCall(urlPath, callType, exe)
+// diffFiles compares the hashes of the normalized files at paths of both directories and logs the files that differ
+func diffFiles(firstDir string, secondDir string, paths ...string) (bool, error) {
+	changes, err := file.DiffDirectoryHashes(firstDir, secondDir, paths...)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	return exe.ReadRespBody(resp)
-}
-
-func diffContent(firstDir string, secondDir string) bool {
-	log.Info().Msg("Checking for changes in META-INF directory")
-	metaDiffer := file.DiffDirectories(firstDir+"/META-INF", secondDir+"/META-INF")
-	log.Info().Msg("Checking for changes in src/main/resources directory")
-	resourcesDiffer := file.DiffDirectories(firstDir+"/src/main/resources", secondDir+"/src/main/resources")
-	log.Info().Msg("Checking for changes in metainfo.prop")
-	metainfoDiffer := DiffOptionalFile(firstDir, secondDir, "metainfo.prop")
-
-	return metaDiffer || resourcesDiffer || metainfoDiffer
+	for _, change := range changes {
+		log.Info().Msgf("File %v %v", change.Path, change.Kind)
+	}
+	if len(changes) == 0 {
+		log.Info().Msg("No files changed")
+	} else {
+		log.Info().Msgf("%d file(s) changed", len(changes))
+	}
+	return len(changes) > 0, nil
 }
 
 func copyContent(srcDir string, tgtDir string) error {
@@ -202,3 +202,16 @@ func setupArtifact(t *testing.T, artifactId string, packageId string, artifactDi
 		}
 	}
 }
+
+func TestNewDesigntimeArtifactAliases(t *testing.T) {
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", "localhost", "http", 8081, true)
+
+	assert.IsType(t, &Integration{}, NewDesigntimeArtifact("iflow", exe))
+	assert.IsType(t, &Integration{}, NewDesigntimeArtifact("IFlow", exe))
+	assert.IsType(t, &MessageMapping{}, NewDesigntimeArtifact("mm", exe))
+	assert.IsType(t, &ScriptCollection{}, NewDesigntimeArtifact("SC", exe))
+	assert.IsType(t, &ValueMapping{}, NewDesigntimeArtifact("valuemapping", exe))
+	assert.Nil(t, NewDesigntimeArtifact("Destination", exe))
+	assert.Equal(t, "Integration", CanonicalArtifactType("integration"))
+	assert.Equal(t, "", CanonicalArtifactType("Destination"))
+}
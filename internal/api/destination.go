@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Destination reads destination definitions from the SAP BTP Destination service
+type Destination struct {
+	exe   *httpclnt.HTTPExecuter
+	cache map[string]map[string]string
+}
+
+type destinationData struct {
+	DestinationConfiguration map[string]interface{} `json:"destinationConfiguration"`
+}
+
+// NewDestination returns an initialised Destination instance.
+func NewDestination(exe *httpclnt.HTTPExecuter) *Destination {
+	d := new(Destination)
+	d.exe = exe
+	d.cache = map[string]map[string]string{}
+	return d
+}
+
+// InitDestinationHTTPExecuter returns an HTTP executer for the REST API of the Destination service.
+// The Destination service only supports OAuth client credentials.
+func InitDestinationHTTPExecuter(host string, oauthHost string, oauthPath string, clientId string, clientSecret string) *httpclnt.HTTPExecuter {
+	return httpclnt.New(oauthHost, oauthPath, clientId, clientSecret, "", "", host, "https", 443, true)
+}
+
+// Get returns the properties of a destination. Besides the properties of the destination
+// configuration, the derived properties host, port, path and scheme are available when the
+// destination has a URL.
+func (d *Destination) Get(name string) (map[string]string, error) {
+	if properties, ok := d.cache[name]; ok {
+		return properties, nil
+	}
+	log.Info().Msgf("Getting destination %v", name)
+	urlPath := fmt.Sprintf("/destination-configuration/v1/destinations/%v", url.PathEscape(name))
+
+	callType := "Get destination"
+	resp, err := readOnlyCall(urlPath, callType, d.exe)
+	if err != nil {
+		return nil, err
+	}
+	var jsonData *destinationData
+	respBody, err := d.exe.ReadRespBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(respBody, &jsonData)
+	if err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return nil, errors.Wrap(err, 0)
+	}
+	if len(jsonData.DestinationConfiguration) == 0 {
+		return nil, fmt.Errorf("destination %v does not have a destination configuration", name)
+	}
+
+	properties := map[string]string{}
+	for k, v := range jsonData.DestinationConfiguration {
+		properties[k] = fmt.Sprintf("%v", v)
+	}
+	addURLProperties(properties)
+	d.cache[name] = properties
+	return properties, nil
+}
+
+// GetProperty returns a single property of a destination
+func (d *Destination) GetProperty(name string, property string) (string, error) {
+	properties, err := d.Get(name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := properties[property]
+	if !ok {
+		return "", fmt.Errorf("property %v not found in destination %v", property, name)
+	}
+	return value, nil
+}
+
+// ParseDestinationReference splits a reference in the format <name>#<property>
+func ParseDestinationReference(reference string) (name string, property string, err error) {
+	name, property, found := strings.Cut(reference, "#")
+	name = strings.TrimSpace(name)
+	property = strings.TrimSpace(property)
+	if !found || name == "" || property == "" {
+		return "", "", fmt.Errorf("invalid destination reference %q, expected format <name>#<property>", reference)
+	}
+	return name, property, nil
+}
+
+func addURLProperties(properties map[string]string) {
+	rawURL, ok := properties["URL"]
+	if !ok {
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		log.Warn().Msgf("Unable to parse URL %v of destination: %v", rawURL, err)
+		return
+	}
+	derived := map[string]string{
+		"scheme": u.Scheme,
+		"host":   u.Hostname(),
+		"port":   u.Port(),
+		"path":   u.Path,
+	}
+	for k, v := range derived {
+		// Properties maintained in the destination take precedence
+		if _, exists := properties[k]; !exists {
+			properties[k] = v
+		}
+	}
+}
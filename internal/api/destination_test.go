@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDestinationReference(t *testing.T) {
+	name, property, err := ParseDestinationReference(" S4_Backend # host ")
+	require.NoError(t, err)
+	assert.Equal(t, "S4_Backend", name, "Incorrect destination name")
+	assert.Equal(t, "host", property, "Incorrect property")
+
+	for _, reference := range []string{"S4_Backend", "S4_Backend#", "#host", " # "} {
+		_, _, err := ParseDestinationReference(reference)
+		assert.ErrorContains(t, err, "expected format <name>#<property>", "Reference %q should be invalid", reference)
+	}
+}
+
+func TestAddURLProperties(t *testing.T) {
+	properties := map[string]string{"URL": "https://erp.example.com:44300/sap/opu/odata"}
+	addURLProperties(properties)
+	assert.Equal(t, map[string]string{
+		"URL":    "https://erp.example.com:44300/sap/opu/odata",
+		"scheme": "https",
+		"host":   "erp.example.com",
+		"port":   "44300",
+		"path":   "/sap/opu/odata",
+	}, properties)
+
+	// Properties maintained in the destination take precedence over the derived properties
+	properties = map[string]string{"URL": "https://erp.example.com/sap", "host": "erp-internal", "port": "8443"}
+	addURLProperties(properties)
+	assert.Equal(t, "erp-internal", properties["host"], "Maintained host should not be overridden")
+	assert.Equal(t, "8443", properties["port"], "Maintained port should not be overridden")
+	assert.Equal(t, "/sap", properties["path"], "Incorrect derived path")
+
+	properties = map[string]string{"Name": "NoURL"}
+	addURLProperties(properties)
+	assert.Equal(t, map[string]string{"Name": "NoURL"}, properties, "Properties without URL should be unchanged")
+}
+
+func TestDestination_GetMock(t *testing.T) {
+	requests := map[string]int{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/destination-configuration/v1/destinations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		requests[name]++
+		w.Header().Set("Content-Type", "application/json")
+		switch name {
+		case "S4 Backend":
+			w.Write([]byte(`{ "owner": { "SubaccountId": "sub" }, "destinationConfiguration": { "Name": "S4 Backend",
+				"URL": "https://erp.example.com:44300/sap", "Authentication": "BasicAuthentication", "Timeout": 30 } }`))
+		case "Empty":
+			w.Write([]byte(`{ "owner": { "SubaccountId": "sub" } }`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{ "ErrorMessage": "Configuration with the specified name was not found" }`))
+		}
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	d := NewDestination(httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true))
+
+	properties, err := d.Get("S4 Backend")
+	require.NoError(t, err)
+	assert.Equal(t, "BasicAuthentication", properties["Authentication"], "Incorrect property")
+	assert.Equal(t, "30", properties["Timeout"], "Non-string properties should be formatted")
+	assert.Equal(t, "erp.example.com", properties["host"], "Incorrect derived host")
+
+	value, err := d.GetProperty("S4 Backend", "port")
+	require.NoError(t, err)
+	assert.Equal(t, "44300", value, "Incorrect derived port")
+	assert.Equal(t, 1, requests["S4 Backend"], "Destination should be read once and cached")
+
+	_, err = d.GetProperty("S4 Backend", "User")
+	assert.EqualError(t, err, "property User not found in destination S4 Backend")
+
+	_, err = d.Get("Empty")
+	assert.EqualError(t, err, "destination Empty does not have a destination configuration")
+
+	_, err = d.Get("Missing")
+	assert.ErrorContains(t, err, "404")
+}
+
+// redirectTransport sends all requests to a test server, e.g. those of executers for https on port 443
+type redirectTransport struct {
+	host string
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = rt.host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestDestinationWithoutTenantHeaders(t *testing.T) {
+	var received []http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "access_token": "destination-token", "token_type": "bearer", "expires_in": 3600 }`))
+	})
+	mux.HandleFunc("/destination-configuration/v1/destinations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "destinationConfiguration": { "Name": "S4 Backend" } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	httpclnt.SetDefaultHeaders(map[string]string{"X-Api-Key": "secret", "X-Flashpipe-Run-Id": "run-1"})
+	httpclnt.SetDefaultSignCommand("echo X-Signature: signed")
+	defer httpclnt.SetDefaultHeaders(nil)
+	defer httpclnt.SetDefaultSignCommand("")
+	defaultClient := http.DefaultClient
+	http.DefaultClient = &http.Client{Transport: redirectTransport{host: svr.Listener.Addr().String()}}
+	defer func() { http.DefaultClient = defaultClient }()
+
+	d := NewDestination(InitDestinationHTTPExecuter("destination.example.com", "auth.example.com", "/oauth/token", "client", "secret"))
+	_, err := d.Get("S4 Backend")
+	require.NoError(t, err)
+
+	require.Len(t, received, 2, "The token and the destination should be requested")
+	for _, header := range received {
+		for _, name := range []string{"X-Api-Key", "X-Flashpipe-Run-Id", "X-Signature"} {
+			assert.Empty(t, header.Get(name), "%s of the tenant should not be sent to the Destination service", name)
+		}
+	}
+	assert.Equal(t, "Bearer destination-token", received[1].Get("Authorization"))
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+)
+
+// Errors of failed calls by response code, test for them with errors.Is
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	// ErrLocked is returned when the artifact is locked or being edited by another user
+	ErrLocked = httpclnt.ErrLocked
+)
+
+// ODataErrorDetail is a detail of an OData error, e.g. for each invalid property of a request
+type ODataErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Target  string `json:"target,omitempty"`
+}
+
+// Error is the error of a call that failed with an unexpected response code. The code and message of the
+// OData error in the response body, if any, are parsed into Code, Message and Details, the message is
+// included in the error text.
+type Error struct {
+	CallType   string
+	StatusCode int
+	Code       string
+	Message    string
+	Details    []ODataErrorDetail
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%v call failed with response code = %d", e.CallType, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.locked() {
+		msg += ": " + ErrLocked.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the errors matching the response code, for errors.Is
+func (e *Error) Unwrap() []error {
+	var errs []error
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		errs = append(errs, ErrBadRequest)
+	case http.StatusUnauthorized:
+		errs = append(errs, ErrUnauthorized)
+	case http.StatusNotFound:
+		errs = append(errs, ErrNotFound)
+	case http.StatusTooManyRequests:
+		errs = append(errs, ErrRateLimited)
+	}
+	if e.locked() {
+		errs = append(errs, ErrLocked)
+	}
+	return errs
+}
+
+// Retryable returns true if a call that failed with err may succeed when it is sent again, i.e. it failed with a
+// timeout, a connection error or response code 408, 429, 502, 503 or 504. Other errors, e.g. 400 for an invalid
+// value or 404 for an unknown key, are permanent.
+func Retryable(err error) bool {
+	var callErr *Error
+	if errors.As(err, &callErr) {
+		switch callErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return !callErr.locked()
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+func (e *Error) locked() bool {
+	return e.StatusCode == http.StatusLocked || httpclnt.IsLockedResponse(e.Body)
+}
+
+// odataError is the error response of OData V2 (message with lang and value, details in innererror) and
+// V4 (message as string, details in details)
+type odataError struct {
+	Error struct {
+		Code       string             `json:"code"`
+		Message    json.RawMessage    `json:"message"`
+		Details    []ODataErrorDetail `json:"details"`
+		InnerError struct {
+			ErrorDetails []ODataErrorDetail `json:"errordetails"`
+		} `json:"innererror"`
+	} `json:"error"`
+}
+
+// newError returns the error of a call that failed with statusCode, with the OData error parsed from body
+func newError(callType string, statusCode int, body []byte) *Error {
+	e := &Error{CallType: callType, StatusCode: statusCode, Body: body}
+	var data odataError
+	if json.Unmarshal(body, &data) != nil {
+		return e
+	}
+	e.Code = data.Error.Code
+	var message struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(data.Error.Message, &e.Message) != nil && json.Unmarshal(data.Error.Message, &message) == nil {
+		e.Message = message.Value
+	}
+	e.Details = append(data.Error.Details, data.Error.InnerError.ErrorDetails...)
+	return e
+}
+
+// responseError reads and logs the body of a response with an unexpected response code, and returns it with
+// the *Error of the call
+func responseError(exe *httpclnt.HTTPExecuter, resp *http.Response, callType string) ([]byte, error) {
+	body, err := exe.ReadErrorBody(resp)
+	if err != nil {
+		return body, err
+	}
+	return body, newError(callType, resp.StatusCode, body)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	v2 := newError("Update configuration parameter", 400, []byte(`{"error":{"code":"Bad Request","message":{"lang":"en","value":"Parameter Receiver not found"},"innererror":{"errordetails":[{"code":"InvalidKey","message":"Receiver"}]}}}`))
+	assert.Equal(t, "Bad Request", v2.Code)
+	assert.Equal(t, "Parameter Receiver not found", v2.Message)
+	assert.Equal(t, []ODataErrorDetail{{Code: "InvalidKey", Message: "Receiver"}}, v2.Details)
+	assert.EqualError(t, v2, "Update configuration parameter call failed with response code = 400: Parameter Receiver not found")
+	assert.ErrorIs(t, v2, ErrBadRequest)
+
+	v4 := newError("Update configuration parameter", 400, []byte(`{"error":{"code":"400","message":"Invalid value","details":[{"code":"InvalidValue","message":"Timeout","target":"ParameterValue"}]}}`))
+	assert.Equal(t, "Invalid value", v4.Message)
+	assert.Equal(t, []ODataErrorDetail{{Code: "InvalidValue", Message: "Timeout", Target: "ParameterValue"}}, v4.Details)
+
+	plain := newError("Get runtime artifact", 404, []byte("<html>Not Found</html>"))
+	assert.EqualError(t, plain, "Get runtime artifact call failed with response code = 404")
+	assert.ErrorIs(t, plain, ErrNotFound)
+	assert.NotErrorIs(t, plain, ErrBadRequest)
+
+	locked := newError("Update configuration parameter", 409, []byte(`{"error":{"message":{"value":"Artifact is locked by user S0001"}}}`))
+	assert.EqualError(t, locked, "Update configuration parameter call failed with response code = 409: Artifact is locked by user S0001: artifact locked by another user")
+	assert.ErrorIs(t, locked, ErrLocked)
+	assert.ErrorIs(t, locked, httpclnt.ErrLocked)
+
+	assert.ErrorIs(t, newError("Get", 401, nil), ErrUnauthorized)
+	assert.ErrorIs(t, newError("Get", 429, nil), ErrRateLimited)
+}
+
+func TestCallErrors(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("x-csrf-token") == "fetch":
+			w.Header().Set("x-csrf-token", "token")
+		case r.URL.Path == "/api/v1/IntegrationRuntimeArtifacts('Missing')":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/api/v1/IntegrationPackages('Busy')":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"Bad Request","message":{"lang":"en","value":"Invalid request"}}}`))
+		}
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	version, _, err := NewRuntime(exe).Get("Missing")
+	require.NoError(t, err)
+	assert.Equal(t, "NOT_DEPLOYED", version)
+
+	_, _, _, err = NewIntegrationPackage(exe).Get("Busy")
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	err = NewDesigntimeArtifact("Integration", exe).Deploy("IFlow1")
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "Invalid request", apiErr.Message)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestRetryable(t *testing.T) {
+	for _, code := range []int{408, 429, 502, 503, 504} {
+		assert.True(t, Retryable(newError("Update configuration parameter", code, nil)), code)
+	}
+	for _, code := range []int{400, 401, 404, 500} {
+		assert.False(t, Retryable(newError("Update configuration parameter", code, nil)), code)
+	}
+	assert.False(t, Retryable(newError("Update configuration parameter", 503, []byte(`{"error":{"message":{"value":"Artifact is locked by user S0001"}}}`))),
+		"Locked artifacts are retried by the lock retries")
+	assert.True(t, Retryable(fmt.Errorf("update failed: %w", context.DeadlineExceeded)))
+	assert.True(t, Retryable(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	assert.False(t, Retryable(errors.New("invalid parameter")))
+}
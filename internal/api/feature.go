@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+)
+
+// Optional features of a tenant detected by DetectFeatures
+const (
+	FeatureBatch            = "batch"
+	FeatureRuntimeLocations = "runtime-locations"
+	FeaturePartnerDirectory = "partner-directory"
+	FeatureEdge             = "edge"
+)
+
+// Features are the optional APIs a tenant supports. Features that could not be probed are assumed to be
+// supported, so that a failed probe does not disable a feature.
+type Features struct {
+	unsupported []string
+	entitySets  []string
+}
+
+// DetectFeatures probes the optional APIs of the tenant with the service document and a few cheap read requests
+func DetectFeatures(exe *httpclnt.HTTPExecuter) *Features {
+	f := new(Features)
+
+	if document, ok := getJSON(exe, "/api/v1/"); ok {
+		var sets struct {
+			D struct {
+				EntitySets []string `json:"EntitySets"`
+			} `json:"d"`
+		}
+		if json.Unmarshal(document, &sets) == nil && len(sets.D.EntitySets) > 0 {
+			f.entitySets = sets.D.EntitySets
+			if !slices.Contains(f.entitySets, "StringParameters") {
+				f.unsupported = append(f.unsupported, FeaturePartnerDirectory)
+			}
+			if !slices.Contains(f.entitySets, "RuntimeLocations") {
+				f.unsupported = append(f.unsupported, FeatureRuntimeLocations, FeatureEdge)
+			}
+		}
+	}
+
+	// The $batch endpoint only accepts POST, tenants without it answer 404
+	if resp, err := exe.ExecGetRequest("/api/v1/$batch", nil); err == nil {
+		_, _ = exe.ReadRespBody(resp)
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+			f.unsupported = append(f.unsupported, FeatureBatch)
+		}
+	}
+
+	// Edge Integration Cells are runtime locations besides the cloud runtime
+	if f.entitySets != nil && f.Supports(FeatureRuntimeLocations) {
+		if body, ok := getJSON(exe, "/api/v1/RuntimeLocations"); ok {
+			var locations struct {
+				D struct {
+					Results []runtimeLocation `json:"results"`
+				} `json:"d"`
+			}
+			if json.Unmarshal(body, &locations) == nil && !slices.ContainsFunc(locations.D.Results, runtimeLocation.isEdge) {
+				f.unsupported = append(f.unsupported, FeatureEdge)
+			}
+		}
+	}
+
+	log.Debug().Msgf("Unsupported tenant features: %v", f.unsupported)
+	return f
+}
+
+type runtimeLocation struct {
+	Id string `json:"Id"`
+}
+
+func (l runtimeLocation) isEdge() bool {
+	return l.Id != "cloudintegration"
+}
+
+// Supports returns false if the tenant does not support the feature
+func (f *Features) Supports(feature string) bool {
+	return !slices.Contains(f.unsupported, feature)
+}
+
+// Unsupported returns the features the tenant does not support
+func (f *Features) Unsupported() []string {
+	return f.unsupported
+}
+
+func getJSON(exe *httpclnt.HTTPExecuter, path string) ([]byte, bool) {
+	resp, err := exe.ExecGetRequest(path, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return nil, false
+	}
+	body, err := exe.ReadRespBody(resp)
+	return body, err == nil && resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFeatureMock(t *testing.T, entitySets string, batchStatus int, locations string) *httpclnt.HTTPExecuter {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"d": {"EntitySets": [` + entitySets + `]}}`))
+	})
+	mux.HandleFunc("/api/v1/$batch", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(batchStatus)
+	})
+	mux.HandleFunc("/api/v1/RuntimeLocations", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"d": {"results": [` + locations + `]}}`))
+	})
+	svr := httptest.NewServer(mux)
+	t.Cleanup(svr.Close)
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	return httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+}
+
+func TestDetectFeaturesMock(t *testing.T) {
+	exe := newFeatureMock(t, `"IntegrationPackages", "StringParameters", "RuntimeLocations"`, http.StatusMethodNotAllowed,
+		`{"Id": "cloudintegration"}, {"Id": "edge-cell-1"}`)
+	features := DetectFeatures(exe)
+	assert.Empty(t, features.Unsupported(), "All features should be supported")
+
+	exe = newFeatureMock(t, `"IntegrationPackages", "RuntimeLocations"`, http.StatusNotFound, `{"Id": "cloudintegration"}`)
+	features = DetectFeatures(exe)
+	assert.Equal(t, []string{FeaturePartnerDirectory, FeatureBatch, FeatureEdge}, features.Unsupported())
+	assert.False(t, features.Supports(FeatureBatch))
+	assert.True(t, features.Supports(FeatureRuntimeLocations))
+}
+
+func TestDetectFeaturesUnknownMock(t *testing.T) {
+	// Nothing can be probed, so all features are assumed to be supported
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	features := DetectFeatures(httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true))
+	assert.Empty(t, features.Unsupported())
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"github.com/engswee/flashpipe/internal/file"
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
+	"github.com/rs/zerolog/log"
 )
 
 type Integration struct {
@@ -25,7 +27,10 @@ func (int *Integration) Update(id string, name string, packageId string, artifac
 	return update(id, name, packageId, artifactDir, int.typ, int.exe)
 }
 func (int *Integration) Deploy(id string) error {
-	return deploy(id, int.typ, int.exe)
+	return deploy(id, "active", int.typ, int.exe)
+}
+func (int *Integration) DeployVersion(id string, version string) error {
+	return deploy(id, version, int.typ, int.exe)
 }
 func (int *Integration) Delete(id string) error {
 	return deleteCall(id, int.typ, int.exe)
@@ -47,7 +52,10 @@ func (int *Integration) CompareContent(srcDir string, tgtDir string, scriptMap [
 	}
 
 	// Diff directories excluding parameters.prop
-	dirDiffer := diffContent(srcDir, tgtDir)
+	dirDiffer, err := diffContent(srcDir, tgtDir)
+	if err != nil {
+		return false, err
+	}
 
 	// Handling for parameters.prop differences
 	// - Any configured value will remain in IFlow even if the IFlow is replaced and the parameter is no longer used
@@ -61,3 +69,10 @@ func (int *Integration) CompareContent(srcDir string, tgtDir string, scriptMap [
 		return dirDiffer, nil
 	}
 }
+
+// SaveIntegrationAsVersion saves the draft of an integration flow as a new version
+func SaveIntegrationAsVersion(id string, version string, exe *httpclnt.HTTPExecuter) error {
+	log.Info().Msgf("Saving draft of Integration designtime artifact %v as version %v", id, version)
+	urlPath := odata.IntegrationDesigntimeArtifactSaveAsVersionPath(id, version)
+	return modifyingCall("POST", urlPath, nil, 200, "Save Integration designtime artifact as version", exe)
+}
@@ -92,3 +92,34 @@ func TestIntegration_diffParam(t *testing.T) {
 
 	assert.True(t, dirDiffer, "Directory contents do not differ")
 }
+
+func TestIntegration_DeployVersionMock(t *testing.T) {
+	var deployed []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-csrf-token", "dummycsrfToken")
+	})
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='DummyIFlow',Version='1.0.3')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"d": {"Version": "1.0.3"}}`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='DummyIFlow',Version='9.9.9')", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"message": {"value": "Artifact not found"}}}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v1/DeployIntegrationDesigntimeArtifact", func(w http.ResponseWriter, r *http.Request) {
+		deployed = append(deployed, r.URL.Query().Get("Version"))
+		w.WriteHeader(http.StatusAccepted)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	dt := NewIntegration(exe)
+
+	assert.NoError(t, dt.DeployVersion("DummyIFlow", "1.0.3"))
+	assert.NoError(t, dt.DeployVersion("DummyIFlow", ""))
+	assert.EqualError(t, dt.DeployVersion("DummyIFlow", "9.9.9"), "version 9.9.9 of Integration designtime artifact DummyIFlow not found")
+	assert.Equal(t, []string{"'1.0.3'", "'active'"}, deployed, "Versions that do not exist should not be deployed")
+	assert.Equal(t, "/api/v1/DeployValueMappingDesigntimeArtifact?Id='Map'&Version='2.0.0'", DeployVersionPath("Map", "ValueMapping", "2.0.0"))
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
 	"github.com/go-errors/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -49,6 +50,22 @@ type packageMultipleData struct {
 	} `json:"d"`
 }
 
+// PackageDetails is an integration package as returned by the IntegrationPackages API
+type PackageDetails struct {
+	Id           string `json:"Id"`
+	Name         string `json:"Name"`
+	Version      string `json:"Version"`
+	Mode         string `json:"Mode"`
+	ModifiedBy   string `json:"ModifiedBy"`
+	ModifiedDate string `json:"ModifiedDate"`
+}
+
+type packageDetailsData struct {
+	Root struct {
+		Results []*PackageDetails `json:"results"`
+	} `json:"d"`
+}
+
 type ArtifactDetails struct {
 	Id           string
 	Name         string
@@ -67,7 +84,7 @@ func NewIntegrationPackage(exe *httpclnt.HTTPExecuter) *IntegrationPackage {
 func (ip *IntegrationPackage) GetPackagesList() ([]string, error) {
 	// Get the list of packages of the current tenant
 	log.Info().Msg("Getting list of IntegrationPackages")
-	urlPath := "/api/v1/IntegrationPackages"
+	urlPath := odata.IntegrationPackagesPath
 
 	callType := "Get IntegrationPackages list"
 	resp, err := readOnlyCall(urlPath, callType, ip.exe)
@@ -92,14 +109,37 @@ func (ip *IntegrationPackage) GetPackagesList() ([]string, error) {
 	return packageIds, nil
 }
 
+// List returns the details of the packages of the current tenant
+func (ip *IntegrationPackage) List() ([]*PackageDetails, error) {
+	log.Info().Msg("Getting details of IntegrationPackages")
+	urlPath := odata.IntegrationPackagesPath
+
+	callType := "Get IntegrationPackages list"
+	resp, err := readOnlyCall(urlPath, callType, ip.exe)
+	if err != nil {
+		return nil, err
+	}
+	var jsonData *packageDetailsData
+	respBody, err := ip.exe.ReadRespBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(respBody, &jsonData)
+	if err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return nil, errors.Wrap(err, 0)
+	}
+	return jsonData.Root.Results, nil
+}
+
 func (ip *IntegrationPackage) Get(id string) (packageData *PackageSingleData, readOnly bool, exists bool, err error) {
 	log.Info().Msgf("Getting details of integration package %v", id)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationPackages('%v')", id)
+	urlPath := odata.IntegrationPackagePath(id)
 
 	callType := "Get IntegrationPackages by ID"
 	resp, err := readOnlyCall(urlPath, callType, ip.exe)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("%v call failed with response code = 404", callType) {
+		if errors.Is(err, ErrNotFound) {
 			return nil, false, false, nil
 		} else {
 			return nil, false, false, err
@@ -123,7 +163,7 @@ func (ip *IntegrationPackage) Get(id string) (packageData *PackageSingleData, re
 
 func (ip *IntegrationPackage) GetArtifactsData(id string, artifactType string) ([]*ArtifactDetails, error) {
 	log.Info().Msgf("Getting %v designtime artifacts of package %v", artifactType, id)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationPackages('%v')/%vDesigntimeArtifacts", id, artifactType)
+	urlPath := odata.IntegrationPackageDesigntimeArtifactsPath(id, artifactType)
 
 	callType := fmt.Sprintf("Get %v designtime artifacts of IntegrationPackages", artifactType)
 	resp, err := readOnlyCall(urlPath, callType, ip.exe)
@@ -189,7 +229,7 @@ func (ip *IntegrationPackage) GetAllArtifacts(id string) ([]*ArtifactDetails, er
 func (ip *IntegrationPackage) Create(packageData *PackageSingleData) error {
 	packageId := packageData.Root.Id
 	log.Info().Msgf("Creating integration package %v", packageId)
-	urlPath := "/api/v1/IntegrationPackages"
+	urlPath := odata.IntegrationPackagesPath
 
 	requestBody, err := ip.constructBody(packageData)
 	if err != nil {
@@ -202,7 +242,7 @@ func (ip *IntegrationPackage) Create(packageData *PackageSingleData) error {
 func (ip *IntegrationPackage) Update(packageData *PackageSingleData) error {
 	packageId := packageData.Root.Id
 	log.Info().Msgf("Updating integration package %v", packageId)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationPackages('%v')", packageId)
+	urlPath := odata.IntegrationPackagePath(packageId)
 
 	requestBody, err := ip.constructBody(packageData)
 	if err != nil {
@@ -212,9 +252,20 @@ func (ip *IntegrationPackage) Update(packageData *PackageSingleData) error {
 	return modifyingCall("PUT", urlPath, requestBody, 202, "Update integration package", ip.exe)
 }
 
+// UpdateCustomTag sets the value of a custom tag of an integration package. The custom tag must be defined in the
+// settings of the tenant.
+func (ip *IntegrationPackage) UpdateCustomTag(packageId string, name string, value string) error {
+	log.Info().Msgf("Updating custom tag %v of integration package %v", name, packageId)
+	requestBody, err := json.Marshal(&odata.CustomTagUpdate{Value: value})
+	if err != nil {
+		return err
+	}
+	return modifyingCall("PUT", odata.IntegrationPackageCustomTagLinkPath(packageId, name), requestBody, 202, fmt.Sprintf("Update custom tag %v", name), ip.exe)
+}
+
 func (ip *IntegrationPackage) Delete(packageId string) error {
 	log.Info().Msgf("Deleting integration package %v", packageId)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationPackages('%v')", packageId)
+	urlPath := odata.IntegrationPackagePath(packageId)
 	return modifyingCall("DELETE", urlPath, nil, 202, "Delete integration package", ip.exe)
 }
 
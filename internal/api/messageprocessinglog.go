@@ -0,0 +1,235 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type MessageProcessingLog struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+// MPLData is a message processing log
+type MPLData struct {
+	MessageGuid         string `json:"MessageGuid"`
+	CorrelationId       string `json:"CorrelationId"`
+	IntegrationFlowName string `json:"IntegrationFlowName"`
+	Status              string `json:"Status"`
+	LogLevel            string `json:"LogLevel"`
+	LogStart            string `json:"LogStart"`
+	LogEnd              string `json:"LogEnd"`
+}
+
+// MPLAttachmentData is an attachment of a message processing log
+type MPLAttachmentData struct {
+	Id          string `json:"Id"`
+	Name        string `json:"Name"`
+	ContentType string `json:"ContentType"`
+	TimeStamp   string `json:"TimeStamp"`
+}
+
+// MPLRunData is a run of a message processing log
+type MPLRunData struct {
+	Id           string `json:"Id"`
+	LogLevel     string `json:"LogLevel"`
+	OverallState string `json:"OverallState"`
+	RunStart     string `json:"RunStart"`
+	RunStop      string `json:"RunStop"`
+}
+
+// MPLRunStepData is a step of a run of a message processing log
+type MPLRunStepData struct {
+	RunId       string `json:"RunId"`
+	ChildCount  int    `json:"ChildCount"`
+	StepId      string `json:"StepId"`
+	ModelStepId string `json:"ModelStepId"`
+	BranchId    string `json:"BranchId"`
+	Activity    string `json:"Activity"`
+	Status      string `json:"Status"`
+	Error       string `json:"Error"`
+	StepStart   string `json:"StepStart"`
+	StepStop    string `json:"StepStop"`
+}
+
+// TraceMessageData is the message traced at a run step
+type TraceMessageData struct {
+	TraceId     string `json:"TraceId"`
+	ModelStepId string `json:"ModelStepId"`
+	MimeType    string `json:"MimeType"`
+}
+
+type mplData struct {
+	Root *MPLData `json:"d"`
+}
+
+type mplResultsData struct {
+	Root struct {
+		Results []json.RawMessage `json:"results"`
+		Next    string            `json:"__next"`
+	} `json:"d"`
+}
+
+// NewMessageProcessingLog returns an initialised MessageProcessingLog instance.
+func NewMessageProcessingLog(exe *httpclnt.HTTPExecuter) *MessageProcessingLog {
+	m := new(MessageProcessingLog)
+	m.exe = exe
+	return m
+}
+
+// Count returns the number of message processing logs of the integration flow with the given status
+// that ended after since
+func (m *MessageProcessingLog) Count(iflowID string, status string, since time.Time) (int, error) {
+	log.Info().Msgf("Getting number of %v messages of integration flow %v", status, iflowID)
+	filter := fmt.Sprintf("IntegrationArtifact/Id eq '%v' and Status eq '%v' and LogEnd gt datetime'%v'",
+		iflowID, status, since.UTC().Format("2006-01-02T15:04:05"))
+	urlPath := odata.MessageProcessingLogsCountPath + "?$filter=" + url.PathEscape(filter)
+
+	callType := "Get message processing log count"
+	resp, err := readOnlyCallWithBodyAndAcceptType(urlPath, nil, callType, "text/plain", m.exe)
+	if err != nil {
+		return 0, err
+	}
+	respBody, err := m.exe.ReadRespBody(resp)
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(respBody)))
+	if err != nil {
+		log.Error().Msgf("Error parsing response as number. Response body = %s", respBody)
+		return 0, errors.Wrap(err, 0)
+	}
+	return count, nil
+}
+
+// Get returns the message processing log and its response body
+func (m *MessageProcessingLog) Get(messageGuid string) (*MPLData, []byte, error) {
+	log.Info().Msgf("Getting message processing log %v", messageGuid)
+	urlPath := odata.MessageProcessingLogPath(messageGuid)
+
+	respBody, err := m.body(urlPath, "Get message processing log", "application/json")
+	if err != nil {
+		return nil, nil, err
+	}
+	var jsonData *mplData
+	if err := json.Unmarshal(respBody, &jsonData); err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return nil, nil, errors.Wrap(err, 0)
+	}
+	return jsonData.Root, respBody, nil
+}
+
+// Details returns the response body of a navigation property of the message processing log, e.g. AdapterAttributes,
+// CustomHeaderProperties or ErrorInformation/$value
+func (m *MessageProcessingLog) Details(messageGuid string, navigation string) ([]byte, error) {
+	log.Info().Msgf("Getting %v of message processing log %v", navigation, messageGuid)
+	urlPath := odata.MessageProcessingLogPath(messageGuid) + "/" + navigation
+	return m.body(urlPath, "Get message processing log "+navigation, "")
+}
+
+// Attachments returns the attachments of the message processing log
+func (m *MessageProcessingLog) Attachments(messageGuid string) ([]*MPLAttachmentData, error) {
+	log.Info().Msgf("Getting attachments of message processing log %v", messageGuid)
+	urlPath := odata.MessageProcessingLogAttachmentsPath(messageGuid)
+
+	var attachments []*MPLAttachmentData
+	err := m.list(urlPath, "Get message processing log attachments", func(raw json.RawMessage) error {
+		attachment := new(MPLAttachmentData)
+		attachments = append(attachments, attachment)
+		return json.Unmarshal(raw, attachment)
+	})
+	return attachments, err
+}
+
+// AttachmentContent returns the content of the attachment of a message processing log
+func (m *MessageProcessingLog) AttachmentContent(id string) ([]byte, error) {
+	log.Info().Msgf("Getting content of message processing log attachment %v", id)
+	urlPath := odata.MessageProcessingLogAttachmentValuePath(id)
+	return m.body(urlPath, "Get message processing log attachment", "")
+}
+
+// Runs returns the runs of the message processing log
+func (m *MessageProcessingLog) Runs(messageGuid string) ([]*MPLRunData, error) {
+	log.Info().Msgf("Getting runs of message processing log %v", messageGuid)
+	urlPath := odata.MessageProcessingLogRunsPath(messageGuid)
+
+	var runs []*MPLRunData
+	err := m.list(urlPath, "Get message processing log runs", func(raw json.RawMessage) error {
+		run := new(MPLRunData)
+		runs = append(runs, run)
+		return json.Unmarshal(raw, run)
+	})
+	return runs, err
+}
+
+// RunSteps returns the steps of the run of a message processing log
+func (m *MessageProcessingLog) RunSteps(runID string) ([]*MPLRunStepData, error) {
+	log.Info().Msgf("Getting steps of message processing log run %v", runID)
+	urlPath := odata.MessageProcessingLogRunStepsPath(runID)
+
+	var steps []*MPLRunStepData
+	err := m.list(urlPath, "Get message processing log run steps", func(raw json.RawMessage) error {
+		step := new(MPLRunStepData)
+		steps = append(steps, step)
+		return json.Unmarshal(raw, step)
+	})
+	return steps, err
+}
+
+// TraceMessages returns the messages traced at the run step of a message processing log
+func (m *MessageProcessingLog) TraceMessages(runID string, childCount int) ([]*TraceMessageData, error) {
+	urlPath := odata.MessageProcessingLogRunStepTraceMessagesPath(runID, childCount)
+
+	var messages []*TraceMessageData
+	err := m.list(urlPath, "Get trace messages", func(raw json.RawMessage) error {
+		message := new(TraceMessageData)
+		messages = append(messages, message)
+		return json.Unmarshal(raw, message)
+	})
+	return messages, err
+}
+
+// TraceMessageContent returns the payload of the traced message, or with navigation Properties or
+// ExchangeProperties its headers or exchange properties
+func (m *MessageProcessingLog) TraceMessageContent(traceID string, navigation string) ([]byte, error) {
+	urlPath := odata.TraceMessagePath(traceID) + "/" + navigation
+	return m.body(urlPath, "Get trace message "+navigation, "")
+}
+
+func (m *MessageProcessingLog) body(urlPath string, callType string, acceptType string) ([]byte, error) {
+	resp, err := readOnlyCallWithBodyAndAcceptType(urlPath, nil, callType, acceptType, m.exe)
+	if err != nil {
+		return nil, err
+	}
+	return m.exe.ReadRespBody(resp)
+}
+
+// list calls add for each result of all pages of the OData collection
+func (m *MessageProcessingLog) list(urlPath string, callType string, add func(raw json.RawMessage) error) error {
+	for urlPath != "" {
+		respBody, err := m.body(urlPath, callType, "application/json")
+		if err != nil {
+			return err
+		}
+		var jsonData *mplResultsData
+		if err := json.Unmarshal(respBody, &jsonData); err != nil {
+			log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+			return errors.Wrap(err, 0)
+		}
+		for _, raw := range jsonData.Root.Results {
+			if err := add(raw); err != nil {
+				return errors.Wrap(err, 0)
+			}
+		}
+		urlPath = nextPagePath(jsonData.Root.Next)
+	}
+	return nil
+}
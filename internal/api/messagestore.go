@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type MessageStore struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+type messageCountData struct {
+	Root struct {
+		Results []struct {
+			DataStoreName   string `json:"DataStoreName"`
+			IntegrationFlow string `json:"IntegrationFlow"`
+			// Edm.Int64 values are returned as strings
+			NumberOfMessages json.RawMessage `json:"NumberOfMessages"`
+		} `json:"results"`
+	} `json:"d"`
+}
+
+// DataStoreData is a data store of an integration flow with the number of its entries
+type DataStoreData struct {
+	Name            string
+	IntegrationFlow string
+	Entries         int
+}
+
+// NewMessageStore returns an initialised MessageStore instance.
+func NewMessageStore(exe *httpclnt.HTTPExecuter) *MessageStore {
+	m := new(MessageStore)
+	m.exe = exe
+	return m
+}
+
+// QueueMessageCount returns the number of messages in the JMS queue
+func (m *MessageStore) QueueMessageCount(name string) (int, error) {
+	log.Info().Msgf("Getting number of messages in JMS queue %v", name)
+	urlPath := "/api/v1/Queues?$select=NumberOfMessages&$filter=" + url.PathEscape(fmt.Sprintf("Name eq '%v'", name))
+	return m.count(urlPath, "Get JMS queue")
+}
+
+// DataStoreMessageCount returns the number of entries in the data store, summed over all integration
+// flows using a data store with that name
+func (m *MessageStore) DataStoreMessageCount(name string) (int, error) {
+	log.Info().Msgf("Getting number of entries in data store %v", name)
+	urlPath := "/api/v1/DataStores?$select=NumberOfMessages&$filter=" + url.PathEscape(fmt.Sprintf("DataStoreName eq '%v'", name))
+	return m.count(urlPath, "Get data store")
+}
+
+// ArtifactDataStores returns the data stores of the integration flow with the number of their entries
+func (m *MessageStore) ArtifactDataStores(iflowID string) ([]*DataStoreData, error) {
+	log.Info().Msgf("Getting data stores of integration flow %v", iflowID)
+	urlPath := "/api/v1/DataStores?$select=DataStoreName,IntegrationFlow,NumberOfMessages&$filter=" + url.PathEscape(fmt.Sprintf("IntegrationFlow eq '%v'", iflowID))
+	jsonData, err := m.get(urlPath, "Get data stores")
+	if err != nil {
+		return nil, err
+	}
+	var dataStores []*DataStoreData
+	for _, r := range jsonData.Root.Results {
+		n, err := parseMessageCount(r.NumberOfMessages)
+		if err != nil {
+			return nil, err
+		}
+		dataStores = append(dataStores, &DataStoreData{Name: r.DataStoreName, IntegrationFlow: r.IntegrationFlow, Entries: n})
+	}
+	return dataStores, nil
+}
+
+func (m *MessageStore) count(urlPath string, callType string) (int, error) {
+	jsonData, err := m.get(urlPath, callType)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, r := range jsonData.Root.Results {
+		n, err := parseMessageCount(r.NumberOfMessages)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (m *MessageStore) get(urlPath string, callType string) (*messageCountData, error) {
+	resp, err := readOnlyCall(urlPath, callType, m.exe)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := m.exe.ReadRespBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	var jsonData *messageCountData
+	err = json.Unmarshal(respBody, &jsonData)
+	if err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return nil, errors.Wrap(err, 0)
+	}
+	return jsonData, nil
+}
+
+// parseMessageCount returns the number of a count returned as number or as string
+func parseMessageCount(raw json.RawMessage) (int, error) {
+	n, err := strconv.Atoi(strings.Trim(string(raw), `"`))
+	if err != nil {
+		return 0, errors.Wrap(err, 0)
+	}
+	return n, nil
+}
@@ -24,7 +24,10 @@ func (mm *MessageMapping) Update(id string, name string, packageId string, artif
 	return update(id, name, packageId, artifactDir, mm.typ, mm.exe)
 }
 func (mm *MessageMapping) Deploy(id string) (err error) {
-	return deploy(id, mm.typ, mm.exe)
+	return deploy(id, "active", mm.typ, mm.exe)
+}
+func (mm *MessageMapping) DeployVersion(id string, version string) (err error) {
+	return deploy(id, version, mm.typ, mm.exe)
 }
 func (mm *MessageMapping) Delete(id string) (err error) {
 	return deleteCall(id, mm.typ, mm.exe)
@@ -40,5 +43,5 @@ func (mm *MessageMapping) CopyContent(srcDir string, tgtDir string) error {
 }
 func (mm *MessageMapping) CompareContent(srcDir string, tgtDir string, _ []string, _ string) (bool, error) {
 	// Diff directories
-	return diffContent(srcDir, tgtDir), nil
+	return diffContent(srcDir, tgtDir)
 }
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Versions of the OData APIs of SAP Integration Suite
+const (
+	ODataAuto = "auto"
+	ODataV2   = "v2"
+	ODataV4   = "v4"
+)
+
+// ValidateODataVersion returns an error if version is not auto, v2 or v4. An empty version is treated as auto.
+func ValidateODataVersion(version string) error {
+	switch strings.ToLower(version) {
+	case "", ODataAuto, ODataV2, ODataV4:
+		return nil
+	default:
+		return fmt.Errorf("invalid OData version %v (valid values: auto, v2, v4)", version)
+	}
+}
+
+// ParseODataTime returns the time of an OData V2 date, in the format /Date(<milliseconds>)/ or as plain
+// milliseconds, or of an RFC 3339 timestamp. The zero time is returned for an empty or invalid value.
+func ParseODataTime(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "/Date("), ")/")
+	value, _, _ = strings.Cut(value, "+")
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis).UTC()
+}
+
+// DetectODataVersion returns v4 if the tenant exposes the service document of the OData V4 APIs, otherwise v2
+func DetectODataVersion(exe *httpclnt.HTTPExecuter) string {
+	if _, ok := getJSON(exe, "/api/v4/"); ok {
+		return ODataV4
+	}
+	return ODataV2
+}
+
+// ODataVersionOf returns the OData version of the tenant of exe. With auto, the version is detected once and
+// kept in exe for subsequent calls.
+func ODataVersionOf(exe *httpclnt.HTTPExecuter) string {
+	version := strings.ToLower(exe.ODataVersion())
+	if version == ODataV2 || version == ODataV4 {
+		return version
+	}
+	version = DetectODataVersion(exe)
+	log.Debug().Msgf("Using OData %s APIs of %s", version, exe.Host())
+	exe.SetODataVersion(version)
+	return version
+}
+
+// ConfigurationService reads and updates the configuration parameters of Integration designtime artifacts.
+// It is implemented for the OData V2 APIs by Configuration and for the OData V4 APIs by ConfigurationV4.
+type ConfigurationService interface {
+	Get(id string, version string) (*ParametersData, error)
+	Update(id string, version string, key string, value string) error
+	Create(id string, version string, key string, value string) error
+}
+
+// NewConfigurationService returns the configuration client for the OData version of the tenant of exe
+func NewConfigurationService(exe *httpclnt.HTTPExecuter) ConfigurationService {
+	if ODataVersionOf(exe) == ODataV4 {
+		return NewConfigurationV4(exe)
+	}
+	return NewConfiguration(exe)
+}
+
+// ConfigurationV4 reads and updates configuration parameters with the OData V4 APIs
+type ConfigurationV4 struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+type parametersDataV4 struct {
+	Value []*ParameterData `json:"value"`
+}
+
+// NewConfigurationV4 returns an initialised ConfigurationV4 instance.
+func NewConfigurationV4(exe *httpclnt.HTTPExecuter) *ConfigurationV4 {
+	c := new(ConfigurationV4)
+	c.exe = exe
+	return c
+}
+
+// Get returns the configuration parameters in the structure of the OData V2 response, so that callers do not
+// depend on the version
+func (c *ConfigurationV4) Get(id string, version string) (*ParametersData, error) {
+	log.Info().Msgf("Getting configuration parameters of Integration designtime artifact %v", id)
+	urlPath := odata.IntegrationDesigntimeArtifactConfigurationsV4Path(id, version)
+
+	callType := "Get configuration parameters"
+	resp, err := readOnlyCall(urlPath, callType, c.exe)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := c.exe.ReadRespBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	var jsonData *parametersDataV4
+	if err = json.Unmarshal(respBody, &jsonData); err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return nil, errors.Wrap(err, 0)
+	}
+	data := new(ParametersData)
+	data.Root.Results = jsonData.Value
+	return data, nil
+}
+
+// Update sets the value of a configuration parameter with PATCH, which V4 answers with 204
+func (c *ConfigurationV4) Update(id string, version string, key string, value string) error {
+	log.Info().Msgf("Updating configuration parameter %v of Integration designtime artifact %v", key, id)
+	urlPath := odata.IntegrationDesigntimeArtifactConfigurationV4Path(id, version, key)
+
+	requestBody, err := json.Marshal(&ParameterData{ParameterValue: value})
+	if err != nil {
+		return err
+	}
+	return modifyingCall("PATCH", urlPath, requestBody, 204, fmt.Sprintf("Update configuration parameter %v", key), c.exe)
+}
+
+// Create adds a configuration parameter that does not exist in the artifact with POST, where the tenant supports it
+func (c *ConfigurationV4) Create(id string, version string, key string, value string) error {
+	log.Info().Msgf("Creating configuration parameter %v of Integration designtime artifact %v", key, id)
+	urlPath := odata.IntegrationDesigntimeArtifactConfigurationsV4Path(id, version)
+
+	requestBody, err := json.Marshal(&ParameterData{ParameterKey: key, ParameterValue: value, DataType: "xsd:string"})
+	if err != nil {
+		return err
+	}
+	return modifyingCall("POST", urlPath, requestBody, 201, fmt.Sprintf("Create configuration parameter %v", key), c.exe)
+}
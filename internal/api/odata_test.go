@@ -0,0 +1,77 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateODataVersion(t *testing.T) {
+	assert.NoError(t, ValidateODataVersion(""))
+	assert.NoError(t, ValidateODataVersion("V4"))
+	assert.EqualError(t, ValidateODataVersion("v3"), "invalid OData version v3 (valid values: auto, v2, v4)")
+}
+
+func TestParseODataTime(t *testing.T) {
+	expected := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	assert.Equal(t, expected, ParseODataTime("/Date(1709281800000)/"))
+	assert.Equal(t, expected, ParseODataTime("1709281800000"))
+	assert.True(t, expected.Equal(ParseODataTime("2024-03-01T08:30:00Z")))
+	assert.True(t, ParseODataTime("").IsZero())
+}
+
+func TestConfigurationV4Mock(t *testing.T) {
+	var patched string
+	// Set up local server with mock HTTP responses
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case r.URL.Path == "/api/v4/":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{ "value": [ { "name": "IntegrationDesigntimeArtifacts" } ] }`))
+		case r.Method == http.MethodGet && (r.URL.Path == "/api/v4/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations" ||
+			r.URL.Path == "/api/v4/IntegrationDesigntimeArtifacts(Id='Flow''s',Version='active')/Configurations"):
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{ "value": [ { "ParameterKey": "Host", "ParameterValue": "dev-host", "DataType": "xsd:string" } ] }`))
+		case r.Method == http.MethodPatch && (r.URL.Path == "/api/v4/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations('Receiver Host')" ||
+			r.URL.Path == "/api/v4/IntegrationDesigntimeArtifacts(Id='Flow''s',Version='active')/Configurations('Sender''s Host')"):
+			body, _ := io.ReadAll(r.Body)
+			patched = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	configuration := NewConfigurationService(exe)
+	require.IsType(t, &ConfigurationV4{}, configuration, "V4 should be detected from the service document")
+	assert.Equal(t, ODataV4, exe.ODataVersion(), "Detected version should be kept")
+
+	data, err := configuration.Get("Flow", "active")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(data.Root.Results), "Incorrect number of parameters")
+	assert.Equal(t, "dev-host", data.Root.Results[0].ParameterValue)
+
+	require.NoError(t, configuration.Update("Flow", "active", "Receiver Host", "prod-host"))
+	assert.JSONEq(t, `{"ParameterValue":"prod-host"}`, patched, "Incorrect request body")
+
+	// Quotes in the key predicates are doubled
+	data, err = configuration.Get("Flow's", "active")
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(data.Root.Results), "Incorrect number of parameters")
+	require.NoError(t, configuration.Update("Flow's", "active", "Sender's Host", "prod-host"))
+
+	exe.SetODataVersion(ODataV2)
+	assert.IsType(t, &Configuration{}, NewConfigurationService(exe), "Configured version should override detection")
+}
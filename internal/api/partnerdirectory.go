@@ -9,6 +9,7 @@ import (
 	"net/url"
 
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
 	"github.com/rs/zerolog/log"
 )
 
@@ -63,7 +64,7 @@ type BatchResult struct {
 
 // GetStringParameters retrieves all string parameters from partner directory
 func (pd *PartnerDirectory) GetStringParameters(selectFields string) ([]StringParameter, error) {
-	basePath := "/api/v1/StringParameters"
+	basePath := odata.StringParametersPath
 	separator := "?"
 	if selectFields != "" {
 		basePath += "?$select=" + url.QueryEscape(selectFields)
@@ -135,7 +136,7 @@ func (pd *PartnerDirectory) GetStringParameters(selectFields string) ([]StringPa
 
 // GetBinaryParameters retrieves all binary parameters from partner directory
 func (pd *PartnerDirectory) GetBinaryParameters(selectFields string) ([]BinaryParameter, error) {
-	basePath := "/api/v1/BinaryParameters"
+	basePath := odata.BinaryParametersPath
 	separator := "?"
 	if selectFields != "" {
 		basePath += "?$select=" + url.QueryEscape(selectFields)
@@ -207,9 +208,7 @@ func (pd *PartnerDirectory) GetBinaryParameters(selectFields string) ([]BinaryPa
 
 // GetStringParameter retrieves a single string parameter
 func (pd *PartnerDirectory) GetStringParameter(pid, id string) (*StringParameter, error) {
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := odata.StringParameterPath(pid, id)
 
 	log.Debug().Msgf("Getting string parameter %s/%s", pid, id)
 
@@ -246,9 +245,7 @@ func (pd *PartnerDirectory) GetStringParameter(pid, id string) (*StringParameter
 
 // GetBinaryParameter retrieves a single binary parameter
 func (pd *PartnerDirectory) GetBinaryParameter(pid, id string) (*BinaryParameter, error) {
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := odata.BinaryParameterPath(pid, id)
 
 	log.Debug().Msgf("Getting binary parameter %s/%s", pid, id)
 
@@ -285,11 +282,7 @@ func (pd *PartnerDirectory) GetBinaryParameter(pid, id string) (*BinaryParameter
 
 // CreateStringParameter creates a new string parameter
 func (pd *PartnerDirectory) CreateStringParameter(param StringParameter) error {
-	body := map[string]string{
-		"Pid":   param.Pid,
-		"Id":    param.ID,
-		"Value": param.Value,
-	}
+	body := odata.StringParameterCreate{Pid: param.Pid, ID: param.ID, Value: param.Value}
 
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
@@ -298,7 +291,7 @@ func (pd *PartnerDirectory) CreateStringParameter(param StringParameter) error {
 
 	log.Debug().Msgf("Creating string parameter %s/%s", param.Pid, param.ID)
 
-	resp, err := pd.exe.ExecRequestWithCookies("POST", "/api/v1/StringParameters",
+	resp, err := pd.exe.ExecRequestWithCookies("POST", odata.StringParametersPath,
 		bytes.NewReader(bodyJSON), map[string]string{
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
@@ -317,16 +310,14 @@ func (pd *PartnerDirectory) CreateStringParameter(param StringParameter) error {
 
 // UpdateStringParameter updates an existing string parameter
 func (pd *PartnerDirectory) UpdateStringParameter(param StringParameter) error {
-	body := map[string]string{"Value": param.Value}
+	body := odata.StringParameterUpdate{Value: param.Value}
 
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("failed to marshal body: %w", err)
 	}
 
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(param.Pid),
-		url.QueryEscape(param.ID))
+	path := odata.StringParameterPath(param.Pid, param.ID)
 
 	log.Debug().Msgf("Updating string parameter %s/%s", param.Pid, param.ID)
 
@@ -349,9 +340,7 @@ func (pd *PartnerDirectory) UpdateStringParameter(param StringParameter) error {
 
 // DeleteStringParameter deletes a string parameter
 func (pd *PartnerDirectory) DeleteStringParameter(pid, id string) error {
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := odata.StringParameterPath(pid, id)
 
 	log.Debug().Msgf("Deleting string parameter %s/%s", pid, id)
 
@@ -372,12 +361,7 @@ func (pd *PartnerDirectory) DeleteStringParameter(pid, id string) error {
 
 // CreateBinaryParameter creates a new binary parameter
 func (pd *PartnerDirectory) CreateBinaryParameter(param BinaryParameter) error {
-	body := map[string]string{
-		"Pid":         param.Pid,
-		"Id":          param.ID,
-		"Value":       param.Value,
-		"ContentType": param.ContentType,
-	}
+	body := odata.BinaryParameterCreate{Pid: param.Pid, ID: param.ID, Value: param.Value, ContentType: param.ContentType}
 
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
@@ -386,7 +370,7 @@ func (pd *PartnerDirectory) CreateBinaryParameter(param BinaryParameter) error {
 
 	log.Debug().Msgf("Creating binary parameter %s/%s", param.Pid, param.ID)
 
-	resp, err := pd.exe.ExecRequestWithCookies("POST", "/api/v1/BinaryParameters",
+	resp, err := pd.exe.ExecRequestWithCookies("POST", odata.BinaryParametersPath,
 		bytes.NewReader(bodyJSON), map[string]string{
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
@@ -405,19 +389,14 @@ func (pd *PartnerDirectory) CreateBinaryParameter(param BinaryParameter) error {
 
 // UpdateBinaryParameter updates an existing binary parameter
 func (pd *PartnerDirectory) UpdateBinaryParameter(param BinaryParameter) error {
-	body := map[string]string{
-		"Value":       param.Value,
-		"ContentType": param.ContentType,
-	}
+	body := odata.BinaryParameterUpdate{Value: param.Value, ContentType: param.ContentType}
 
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("failed to marshal body: %w", err)
 	}
 
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(param.Pid),
-		url.QueryEscape(param.ID))
+	path := odata.BinaryParameterPath(param.Pid, param.ID)
 
 	log.Debug().Msgf("Updating binary parameter %s/%s", param.Pid, param.ID)
 
@@ -440,9 +419,7 @@ func (pd *PartnerDirectory) UpdateBinaryParameter(param BinaryParameter) error {
 
 // DeleteBinaryParameter deletes a binary parameter
 func (pd *PartnerDirectory) DeleteBinaryParameter(pid, id string) error {
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := odata.BinaryParameterPath(pid, id)
 
 	log.Debug().Msgf("Deleting binary parameter %s/%s", pid, id)
 
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
+	"github.com/rs/zerolog/log"
+)
+
+// Capabilities checked by Permission
+const (
+	CapabilityReadDesigntime     = "read-designtime"
+	CapabilityWriteConfiguration = "write-configuration"
+	CapabilityDeploy             = "deploy"
+	CapabilityReadRuntime        = "read-runtime"
+)
+
+// permissionCheckID is the ID of the artifact the modifying capabilities are probed with. The artifact does not
+// exist, so the probe is answered with 404 when the permission is granted and changes nothing.
+const permissionCheckID = "FlashPipePermissionCheck"
+
+// PermissionCheck is a cheap API call that is rejected with 403 when the credentials lack a capability
+type PermissionCheck struct {
+	Capability  string
+	Description string
+	Role        string // Role template of SAP Integration Suite that grants the capability
+	method      string
+	path        string
+	body        string
+}
+
+// PermissionChecks are the capabilities used by FlashPipe, in the order they are checked
+var PermissionChecks = []PermissionCheck{
+	{
+		Capability:  CapabilityReadDesigntime,
+		Description: "Read integration packages and artifacts",
+		Role:        "WorkspacePackagesRead",
+		method:      http.MethodGet,
+		path:        odata.IntegrationPackagesPath + "?$top=1",
+	},
+	{
+		Capability:  CapabilityWriteConfiguration,
+		Description: "Update configuration parameters",
+		Role:        "WorkspacePackagesConfigure",
+		method:      http.MethodPut,
+		path:        odata.IntegrationDesigntimeArtifactConfigurationLinkPath(permissionCheckID, "active", permissionCheckID),
+		body:        `{"ParameterValue":"","DataType":"xsd:string"}`,
+	},
+	{
+		Capability:  CapabilityDeploy,
+		Description: "Deploy artifacts",
+		Role:        "WorkspaceArtifactsDeploy",
+		method:      http.MethodPost,
+		path:        odata.DeployDesigntimeArtifactPath("Integration", permissionCheckID, "active"),
+	},
+	{
+		Capability:  CapabilityReadRuntime,
+		Description: "Read deployment status of runtime artifacts",
+		Role:        "MonitoringDataRead",
+		method:      http.MethodGet,
+		path:        odata.IntegrationRuntimeArtifactsPath + "?$top=1",
+	},
+}
+
+// PermissionResult is the outcome of a permission check
+type PermissionResult struct {
+	PermissionCheck
+	Granted bool
+	Status  int   // Response code of the check
+	Error   error // Set if the check could not be executed
+}
+
+type Permission struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+// NewPermission returns an initialised Permission instance.
+func NewPermission(exe *httpclnt.HTTPExecuter) *Permission {
+	p := new(Permission)
+	p.exe = exe
+	return p
+}
+
+// Check probes the capabilities, all capabilities if none are given. Only 403 responses mean that a
+// capability is missing. An error is returned if the credentials are rejected.
+func (p *Permission) Check(capabilities ...string) ([]PermissionResult, error) {
+	var results []PermissionResult
+	for _, check := range PermissionChecks {
+		if len(capabilities) > 0 && !slices.Contains(capabilities, check.Capability) {
+			continue
+		}
+		result := p.check(check)
+		if result.Status == http.StatusUnauthorized {
+			return nil, fmt.Errorf("credentials rejected by %v with response code = 401", p.exe.Host())
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (p *Permission) check(check PermissionCheck) PermissionResult {
+	result := PermissionResult{PermissionCheck: check}
+	log.Debug().Msgf("Checking permission %v", check.Capability)
+
+	headers := map[string]string{}
+	var cookies []*http.Cookie
+	if check.method != http.MethodGet {
+		var err error
+		if headers, cookies, err = InitHeadersAndCookies(p.exe); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+	headers["Accept"] = "application/json"
+	var body io.Reader = http.NoBody
+	if check.body != "" {
+		headers["Content-Type"] = "application/json"
+		body = bytes.NewReader([]byte(check.body))
+	}
+
+	resp, err := p.exe.ExecRequestWithCookies(check.method, check.path, body, headers, cookies)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	_, _ = p.exe.ReadRespBody(resp)
+	result.Status = resp.StatusCode
+	result.Granted = resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusUnauthorized
+	return result
+}
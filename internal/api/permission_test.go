@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionCheckMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, deployment is forbidden
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			w.Header().Set("x-csrf-token", "token")
+			w.Write([]byte(`{ "d": { "results": [] } }`))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/DeployIntegrationDesigntimeArtifact"):
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			assert.Equal(t, "token", r.Header.Get("x-csrf-token"), "Modifying check should send CSRF token")
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	results, err := NewPermission(exe).Check()
+	require.NoError(t, err)
+	require.Equal(t, 4, len(results), "All capabilities should be checked")
+	for _, r := range results {
+		assert.Equal(t, r.Capability != CapabilityDeploy, r.Granted, r.Capability)
+	}
+
+	results, err = NewPermission(exe).Check(CapabilityReadRuntime)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(results), "Only the requested capability should be checked")
+}
+
+func TestPermissionCheckUnauthorizedMock(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	_, err := NewPermission(exe).Check(CapabilityReadDesigntime)
+	assert.ErrorContains(t, err, "credentials rejected")
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+)
+
+// Platforms of SAP Integration Suite tenants
+const (
+	PlatformAuto         = "auto"
+	PlatformCloudFoundry = "cf"
+	PlatformNeo          = "neo"
+)
+
+// Platform encapsulates the differences of the OData API between Neo and Cloud Foundry tenants, so that the
+// same calls work on both
+type Platform interface {
+	// Name returns the platform constant
+	Name() string
+	// RequiresCsrfToken returns true if modifying calls need a CSRF token for the authentication type
+	RequiresCsrfToken(authType string) bool
+	// DeployAccepted returns true for the response codes of a successful deploy request
+	DeployAccepted(statusCode int) bool
+	// OAuthPath returns the path of the OAuth token URL of the platform
+	OAuthPath() string
+}
+
+type cloudFoundry struct{}
+
+func (cloudFoundry) Name() string { return PlatformCloudFoundry }
+
+// RequiresCsrfToken is only true for Basic Auth, OAuth clients are exempt from CSRF protection on Cloud Foundry
+func (cloudFoundry) RequiresCsrfToken(authType string) bool { return authType == "BASIC" }
+
+// DeployAccepted is true for 202, deployment is asynchronous and the task ID is returned
+func (cloudFoundry) DeployAccepted(statusCode int) bool { return statusCode == http.StatusAccepted }
+
+func (cloudFoundry) OAuthPath() string { return "/oauth/token" }
+
+type neo struct{}
+
+func (neo) Name() string { return PlatformNeo }
+
+// RequiresCsrfToken is always true, Neo also enforces CSRF protection for OAuth clients
+func (neo) RequiresCsrfToken(string) bool { return true }
+
+// DeployAccepted is true for 200 and 202, as Neo answers deploy requests with 200 and the task ID
+func (neo) DeployAccepted(statusCode int) bool {
+	return statusCode == http.StatusOK || statusCode == http.StatusAccepted
+}
+
+func (neo) OAuthPath() string { return "/oauth2/api/v1/token" }
+
+// NewPlatform returns the platform with the given name, detected from host for auto or an empty name
+func NewPlatform(name string, host string) (Platform, error) {
+	switch strings.ToLower(name) {
+	case "", PlatformAuto:
+		return DetectPlatform(host), nil
+	case PlatformCloudFoundry:
+		return cloudFoundry{}, nil
+	case PlatformNeo:
+		return neo{}, nil
+	default:
+		return nil, fmt.Errorf("invalid platform %v (valid values: auto, cf, neo)", name)
+	}
+}
+
+// DetectPlatform detects the platform from the tenant management host. Neo hosts are of the form
+// <account>-tmn.hci.<region>.hana.ondemand.com, all other hosts are treated as Cloud Foundry.
+func DetectPlatform(host string) Platform {
+	if strings.Contains(host, "-tmn.") || strings.Contains(host, ".hci.") {
+		return neo{}
+	}
+	return cloudFoundry{}
+}
+
+// OAuthPathFor returns the token path of the platform if path is empty or the default path of Cloud Foundry,
+// which is the default of the oauth-path flag
+func OAuthPathFor(platform Platform, path string) string {
+	if path == "" || path == (cloudFoundry{}).OAuthPath() {
+		return platform.OAuthPath()
+	}
+	return path
+}
+
+// GetPlatform returns the platform of the tenant of exe
+func GetPlatform(exe *httpclnt.HTTPExecuter) Platform {
+	if exe.Platform() == PlatformNeo {
+		return neo{}
+	}
+	if exe.Platform() == PlatformCloudFoundry {
+		return cloudFoundry{}
+	}
+	return DetectPlatform(exe.Host())
+}
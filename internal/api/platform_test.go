@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	assert.Equal(t, PlatformNeo, DetectPlatform("abc123-tmn.hci.eu2.hana.ondemand.com").Name())
+	assert.Equal(t, PlatformCloudFoundry, DetectPlatform("abc123.it-cpi018.cfapps.eu10-003.hana.ondemand.com").Name())
+	assert.Equal(t, PlatformCloudFoundry, DetectPlatform("127.0.0.1").Name())
+}
+
+func TestNewPlatform(t *testing.T) {
+	p, err := NewPlatform("auto", "abc123-tmn.hci.eu2.hana.ondemand.com")
+	require.NoError(t, err)
+	assert.Equal(t, PlatformNeo, p.Name())
+
+	p, err = NewPlatform("NEO", "abc123.it-cpi018.cfapps.eu10-003.hana.ondemand.com")
+	require.NoError(t, err)
+	assert.Equal(t, PlatformNeo, p.Name(), "Flag should override detection")
+
+	_, err = NewPlatform("kyma", "")
+	assert.EqualError(t, err, "invalid platform kyma (valid values: auto, cf, neo)")
+}
+
+func TestOAuthPathFor(t *testing.T) {
+	assert.Equal(t, "/oauth2/api/v1/token", OAuthPathFor(neo{}, "/oauth/token"))
+	assert.Equal(t, "/oauth/token", OAuthPathFor(cloudFoundry{}, ""))
+	assert.Equal(t, "/custom/token", OAuthPathFor(neo{}, "/custom/token"))
+}
+
+func TestPlatformDeployMock(t *testing.T) {
+	var csrfRequested bool
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-csrf-token") == "fetch" {
+			csrfRequested = true
+			w.Header().Set("x-csrf-token", "token")
+			return
+		}
+		// Neo answers deploy requests with 200
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	exe.SetPlatform(PlatformCloudFoundry)
+	err := NewDesigntimeArtifact("Integration", exe).Deploy("IFlow1")
+	assert.Error(t, err, "Cloud Foundry only accepts 202")
+
+	exe.SetPlatform(PlatformNeo)
+	err = NewDesigntimeArtifact("Integration", exe).Deploy("IFlow1")
+	assert.NoError(t, err, "Neo accepts 200")
+	assert.True(t, csrfRequested, "CSRF token should be fetched")
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"strings"
+)
+
+// API families whose requests can have their own timeout and retry policy, see httpclnt.RequestPolicy
+const (
+	FamilyDesigntime    = "designtime"
+	FamilyConfiguration = "configuration"
+	FamilyDeployment    = "deployment"
+	FamilyMonitoring    = "monitoring"
+)
+
+// APIFamilies are the API families in the order of the documentation
+var APIFamilies = []string{FamilyDesigntime, FamilyConfiguration, FamilyDeployment, FamilyMonitoring}
+
+// monitoringEntitySets are the entity sets of the message processing logs and message stores
+var monitoringEntitySets = []string{"MessageProcessingLog", "TraceMessages", "DataStores", "Queues", "MessageStore", "LogFiles"}
+
+// APIFamily returns the API family of a request path, empty for requests of no family, e.g. the CSRF token
+// request. $batch requests are configuration calls, as they update configuration parameters in bulk, and the
+// runtime artifacts are deployment calls, as their status is polled after a deployment.
+func APIFamily(path string) string {
+	entitySet := path
+	for _, prefix := range []string{"/api/v1/", "/api/v4/"} {
+		entitySet = strings.TrimPrefix(entitySet, prefix)
+	}
+	if entitySet == path {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(entitySet, "$batch"), strings.Contains(entitySet, "/Configurations"):
+		return FamilyConfiguration
+	case strings.HasPrefix(entitySet, "Deploy"), strings.HasPrefix(entitySet, "BuildAndDeployStatus"), strings.HasPrefix(entitySet, "IntegrationRuntimeArtifacts"):
+		return FamilyDeployment
+	}
+	for _, set := range monitoringEntitySets {
+		if strings.HasPrefix(entitySet, set) {
+			return FamilyMonitoring
+		}
+	}
+	if strings.Contains(entitySet, "Designtime") || strings.HasPrefix(entitySet, "IntegrationPackages") ||
+		strings.HasSuffix(strings.SplitN(entitySet, "(", 2)[0], "ValMaps") {
+		return FamilyDesigntime
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIFamily(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/":       "",
+		"/api/v1/$batch": FamilyConfiguration,
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations":                FamilyConfiguration,
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$links/Configurations('Host')": FamilyConfiguration,
+		"/api/v4/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations('Host')":        FamilyConfiguration,
+		"/api/v1/DeployIntegrationDesigntimeArtifact?Id='Flow'&Version='active'":                           FamilyDeployment,
+		"/api/v1/BuildAndDeployStatus(TaskId='1')":                                                         FamilyDeployment,
+		"/api/v1/IntegrationRuntimeArtifacts('Flow')":                                                      FamilyDeployment,
+		"/api/v1/MessageProcessingLogs?$filter=Status eq 'FAILED'":                                         FamilyMonitoring,
+		"/api/v1/DataStores": FamilyMonitoring,
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')":               FamilyDesigntime,
+		"/api/v1/IntegrationPackages('Orders')/IntegrationDesigntimeArtifacts":             FamilyDesigntime,
+		"/api/v1/ValueMappingDesigntimeArtifacts(Id='Map',Version='active')/UpsertValMaps": FamilyDesigntime,
+		"/api/v1/UserCredentials": "",
+		"/oauth/token":            "",
+	}
+	for path, family := range tests {
+		assert.Equal(t, family, APIFamily(path), path)
+	}
+}
@@ -2,11 +2,11 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"strings"
 
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
 	"github.com/go-errors/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -22,6 +22,30 @@ type runtimeData struct {
 	} `json:"d"`
 }
 
+// RuntimeArtifactData is a deployed artifact as returned by the IntegrationRuntimeArtifacts API
+type RuntimeArtifactData struct {
+	Id         string `json:"Id"`
+	Version    string `json:"Version"`
+	Name       string `json:"Name"`
+	Type       string `json:"Type"`
+	DeployedBy string `json:"DeployedBy"`
+	DeployedOn string `json:"DeployedOn"`
+	Status     string `json:"Status"`
+}
+
+type runtimeArtifactsData struct {
+	Root struct {
+		Results []*RuntimeArtifactData `json:"results"`
+		Next    string                 `json:"__next"`
+	} `json:"d"`
+}
+
+type logLevelCommand struct {
+	ArtifactSymbolicName string `json:"artifactSymbolicName"`
+	MplLogLevel          string `json:"mplLogLevel"`
+	NodeType             string `json:"nodeType"`
+}
+
 type runtimeError struct {
 	Parameter []string `json:"parameter"`
 }
@@ -35,19 +59,19 @@ func NewRuntime(exe *httpclnt.HTTPExecuter) *Runtime {
 
 func (r *Runtime) UnDeploy(id string) error {
 	log.Info().Msgf("Undeploying runtime artifact %v", id)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationRuntimeArtifacts('%v')", id)
+	urlPath := odata.IntegrationRuntimeArtifactPath(id)
 
 	return modifyingCall("DELETE", urlPath, nil, 202, "", r.exe)
 }
 
 func (r *Runtime) Get(id string) (version string, status string, err error) {
 	log.Info().Msgf("Getting details of runtime artifact %v", id)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationRuntimeArtifacts('%v')", id)
+	urlPath := odata.IntegrationRuntimeArtifactPath(id)
 
 	callType := "Get runtime artifact"
 	resp, err := readOnlyCall(urlPath, callType, r.exe)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("%v call failed with response code = 404", callType) { // artifact not deployed to runtime
+		if errors.Is(err, ErrNotFound) { // artifact not deployed to runtime
 			return "NOT_DEPLOYED", "", nil
 		} else {
 			bytes, err := io.ReadAll(resp.Body)
@@ -79,9 +103,51 @@ func (r *Runtime) Get(id string) (version string, status string, err error) {
 	}
 }
 
+// List returns all artifacts deployed on the tenant
+func (r *Runtime) List() ([]*RuntimeArtifactData, error) {
+	log.Info().Msg("Getting list of runtime artifacts")
+	urlPath := odata.IntegrationRuntimeArtifactsPath
+
+	var artifacts []*RuntimeArtifactData
+	callType := "Get runtime artifacts"
+	for urlPath != "" {
+		resp, err := readOnlyCall(urlPath, callType, r.exe)
+		if err != nil {
+			return nil, err
+		}
+		var jsonData *runtimeArtifactsData
+		respBody, err := r.exe.ReadRespBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(respBody, &jsonData)
+		if err != nil {
+			log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+			return nil, errors.Wrap(err, 0)
+		}
+		artifacts = append(artifacts, jsonData.Root.Results...)
+		urlPath = nextPagePath(jsonData.Root.Next)
+	}
+	return artifacts, nil
+}
+
+// SetLogLevel sets the log level of the message processing logs of the deployed integration flow, one of
+// NONE, INFO, DEBUG or TRACE. The OData APIs do not offer this, so the operations command of the monitoring UI is
+// used. The tenant reverts TRACE to the previous level after 10 minutes.
+func (r *Runtime) SetLogLevel(id string, level string) error {
+	log.Info().Msgf("Setting log level of runtime artifact %v to %v", id, level)
+	urlPath := "/Operations/com.sap.it.op.tmn.commands.dashboard.webui.IntegrationComponentSetMplLogLevelCommand"
+
+	requestBody, err := json.Marshal(&logLevelCommand{ArtifactSymbolicName: id, MplLogLevel: level, NodeType: "IFLMAP"})
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return modifyingCall("POST", urlPath, requestBody, 200, "Set log level", r.exe)
+}
+
 func (r *Runtime) GetErrorInfo(id string) (string, error) {
 	log.Info().Msgf("Getting error info of runtime artifact %v", id)
-	urlPath := fmt.Sprintf("/api/v1/IntegrationRuntimeArtifacts('%v')/ErrorInformation/$value", id)
+	urlPath := odata.IntegrationRuntimeArtifactErrorInformationValuePath(id)
 
 	callType := "Get runtime artifact error information"
 	resp, err := readOnlyCall(urlPath, callType, r.exe)
@@ -100,5 +166,8 @@ func (r *Runtime) GetErrorInfo(id string) (string, error) {
 		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
 		return "", errors.Wrap(err, 0)
 	}
+	if len(jsonData.Parameter) == 0 {
+		return "", nil
+	}
 	return jsonData.Parameter[0], nil
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -146,3 +148,32 @@ func tearDownRuntime(t *testing.T, artifactId string, exe *httpclnt.HTTPExecuter
 		}
 	}
 }
+
+func TestRuntime_ListMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("$skiptoken") == "" {
+			w.Write([]byte(`{ "d": { "results": [ { "Id": "FlowOne", "Version": "1.0.0", "Name": "Flow One", "Type": "INTEGRATION_FLOW",
+				"DeployedBy": "user", "DeployedOn": "/Date(1709281800000)/", "Status": "STARTED" } ],
+				"__next": "https://dummy/api/v1/IntegrationRuntimeArtifacts?$skiptoken=1" } }`))
+			return
+		}
+		w.Write([]byte(`{ "d": { "results": [ { "Id": "FlowTwo", "Version": "1.0.1", "Name": "Flow Two", "Type": "INTEGRATION_FLOW", "Status": "ERROR" } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	artifacts, err := NewRuntime(exe).List()
+	if err != nil {
+		t.Fatalf("List runtime artifacts failed with error - %v", err)
+	}
+	assert.Equal(t, 2, len(artifacts), "Incorrect number of runtime artifacts")
+	assert.Equal(t, "user", artifacts[0].DeployedBy, "Incorrect deployer")
+	assert.Equal(t, "ERROR", artifacts[1].Status, "Incorrect status")
+}
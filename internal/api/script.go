@@ -3,7 +3,6 @@ package api
 import (
 	"github.com/engswee/flashpipe/internal/file"
 	"github.com/engswee/flashpipe/internal/httpclnt"
-	"github.com/rs/zerolog/log"
 	"os"
 )
 
@@ -27,7 +26,10 @@ func (sc *ScriptCollection) Update(id string, name string, packageId string, art
 	return update(id, name, packageId, artifactDir, sc.typ, sc.exe)
 }
 func (sc *ScriptCollection) Deploy(id string) (err error) {
-	return deploy(id, sc.typ, sc.exe)
+	return deploy(id, "active", sc.typ, sc.exe)
+}
+func (sc *ScriptCollection) DeployVersion(id string, version string) (err error) {
+	return deploy(id, version, sc.typ, sc.exe)
 }
 func (sc *ScriptCollection) Delete(id string) (err error) {
 	return deleteCall(id, sc.typ, sc.exe)
@@ -69,18 +71,6 @@ func (sc *ScriptCollection) CopyContent(srcDir string, tgtDir string) error {
 	return nil
 }
 func (sc *ScriptCollection) CompareContent(srcDir string, tgtDir string, _ []string, _ string) (bool, error) {
-	// Diff directories
-	log.Info().Msg("Checking for changes in META-INF directory")
-	metaDiffer := file.DiffDirectories(srcDir+"/META-INF", tgtDir+"/META-INF")
-	// It is technically possible to have an empty script collection
-	if file.Exists(srcDir+"/src/main/resources") && file.Exists(tgtDir+"/src/main/resources") {
-		return metaDiffer || diffContent(srcDir, tgtDir), nil
-	} else if !file.Exists(srcDir+"/src/main/resources") && !file.Exists(tgtDir+"/src/main/resources") {
-		log.Warn().Msg("Skipping diff as /src/main/resources does not exist in both source and target")
-		log.Info().Msg("Checking for changes in metainfo.prop")
-		metainfoDiffer := DiffOptionalFile(srcDir, tgtDir, "metainfo.prop")
-		return metaDiffer || metainfoDiffer, nil
-	}
-	log.Info().Msg("Directory /src/main/resources does not exist in either source or target")
-	return true, nil
+	// Diff directories, it is technically possible to have an empty script collection without src/main/resources
+	return diffContent(srcDir, tgtDir)
 }
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// credentialCollections are the security materials that are referenced by a credential name in channels
+var credentialCollections = []string{"UserCredentials", "OAuth2ClientCredentials", "SecureParameters"}
+
+type SecurityMaterial struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+type securityMaterialData struct {
+	Root struct {
+		Results []map[string]any `json:"results"`
+		Next    string           `json:"__next"`
+	} `json:"d"`
+}
+
+// NewSecurityMaterial returns an initialised SecurityMaterial instance.
+func NewSecurityMaterial(exe *httpclnt.HTTPExecuter) *SecurityMaterial {
+	s := new(SecurityMaterial)
+	s.exe = exe
+	return s
+}
+
+// CredentialNames returns the names of the user credentials, OAuth2 client credentials and secure parameters
+func (s *SecurityMaterial) CredentialNames() ([]string, error) {
+	var names []string
+	for _, collection := range credentialCollections {
+		collectionNames, err := s.list("/api/v1/"+collection+"?$select=Name", "Name", "Get "+collection)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, collectionNames...)
+	}
+	return names, nil
+}
+
+// KeystoreAliases returns the aliases of the entries of the tenant keystore
+func (s *SecurityMaterial) KeystoreAliases() ([]string, error) {
+	return s.list("/api/v1/KeystoreEntries?$select=Alias", "Alias", "Get keystore entries")
+}
+
+func (s *SecurityMaterial) list(urlPath string, field string, callType string) ([]string, error) {
+	log.Info().Msgf("Getting list of %v", field)
+	var values []string
+	for urlPath != "" {
+		resp, err := readOnlyCall(urlPath, callType, s.exe)
+		if err != nil {
+			return nil, err
+		}
+		respBody, err := s.exe.ReadRespBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		var jsonData *securityMaterialData
+		err = json.Unmarshal(respBody, &jsonData)
+		if err != nil {
+			log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+			return nil, errors.Wrap(err, 0)
+		}
+		for _, result := range jsonData.Root.Results {
+			if value, ok := result[field].(string); ok {
+				values = append(values, value)
+			}
+		}
+		urlPath = nextPagePath(jsonData.Root.Next)
+	}
+	return values, nil
+}
+
+// CreateUserCredentialPlaceholder creates a user credential with a random password, to be replaced with the
+// actual credentials in the Web UI
+func (s *SecurityMaterial) CreateUserCredentialPlaceholder(name string, description string) error {
+	password := make([]byte, 24)
+	if _, err := rand.Read(password); err != nil {
+		return err
+	}
+	requestBody, err := json.Marshal(&odata.UserCredentialCreate{
+		Name:        name,
+		Kind:        "default",
+		Description: description,
+		User:        "placeholder",
+		Password:    hex.EncodeToString(password),
+	})
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	log.Info().Msgf("Creating placeholder user credential %v", name)
+	return modifyingCall(http.MethodPost, odata.UserCredentialsPath, requestBody, 201, fmt.Sprintf("Create user credential %v", name), s.exe)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityMaterialMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	var created map[string]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-csrf-token", "token")
+	})
+	mux.HandleFunc("/api/v1/UserCredentials", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &created)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if r.URL.Query().Get("$skiptoken") == "" {
+			w.Write([]byte(`{ "d": { "results": [ { "Name": "erp_user" } ], "__next": "https://dummy/api/v1/UserCredentials?$select=Name&$skiptoken=1" } }`))
+			return
+		}
+		w.Write([]byte(`{ "d": { "results": [ { "Name": "crm_user" } ] } }`))
+	})
+	mux.HandleFunc("/api/v1/OAuth2ClientCredentials", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "d": { "results": [ { "Name": "s4_oauth" } ] } }`))
+	})
+	mux.HandleFunc("/api/v1/SecureParameters", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "d": { "results": [] } }`))
+	})
+	mux.HandleFunc("/api/v1/KeystoreEntries", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "d": { "results": [ { "Alias": "sap_cloudintegrationcertificate" } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	sm := NewSecurityMaterial(exe)
+
+	names, err := sm.CredentialNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"erp_user", "crm_user", "s4_oauth"}, names)
+
+	aliases, err := sm.KeystoreAliases()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sap_cloudintegrationcertificate"}, aliases)
+
+	err = sm.CreateUserCredentialPlaceholder("new_user", "Placeholder")
+	require.NoError(t, err)
+	assert.Equal(t, "new_user", created["Name"])
+	assert.Equal(t, "default", created["Kind"])
+	assert.Len(t, created["Password"], 48)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 	"io"
 	"net/http"
+	"slices"
 )
 
 type ServiceDetails struct {
@@ -18,15 +19,19 @@ type ServiceDetails struct {
 	OauthPath         string
 	OauthClientId     string
 	OauthClientSecret string
+	Platform          string // auto, cf or neo, detected from Host if empty
+	ODataVersion      string // auto, v2 or v4, detected from the tenant if empty
 }
 
 func GetServiceDetails(cmd *cobra.Command) *ServiceDetails {
 	oauthHost := config.GetString(cmd, "oauth-host")
 	if oauthHost == "" {
 		return &ServiceDetails{
-			Host:     config.GetString(cmd, "tmn-host"),
-			Userid:   config.GetString(cmd, "tmn-userid"),
-			Password: config.GetString(cmd, "tmn-password"),
+			Host:         config.GetString(cmd, "tmn-host"),
+			Userid:       config.GetString(cmd, "tmn-userid"),
+			Password:     config.GetString(cmd, "tmn-password"),
+			Platform:     config.GetString(cmd, "platform"),
+			ODataVersion: config.GetString(cmd, "odata-version"),
 		}
 	} else {
 		return &ServiceDetails{
@@ -35,12 +40,26 @@ func GetServiceDetails(cmd *cobra.Command) *ServiceDetails {
 			OauthClientId:     config.GetString(cmd, "oauth-clientid"),
 			OauthClientSecret: config.GetString(cmd, "oauth-clientsecret"),
 			OauthPath:         config.GetString(cmd, "oauth-path"),
+			Platform:          config.GetString(cmd, "platform"),
+			ODataVersion:      config.GetString(cmd, "odata-version"),
 		}
 	}
 }
 
 func InitHTTPExecuter(serviceDetails *ServiceDetails) *httpclnt.HTTPExecuter {
-	return httpclnt.New(serviceDetails.OauthHost, serviceDetails.OauthPath, serviceDetails.OauthClientId, serviceDetails.OauthClientSecret, serviceDetails.Userid, serviceDetails.Password, serviceDetails.Host, "https", 443, true)
+	platform, err := NewPlatform(serviceDetails.Platform, serviceDetails.Host)
+	if err != nil {
+		log.Warn().Msgf("%v, detecting platform from host", err)
+		platform = DetectPlatform(serviceDetails.Host)
+	}
+	exe := httpclnt.New(serviceDetails.OauthHost, OAuthPathFor(platform, serviceDetails.OauthPath), serviceDetails.OauthClientId, serviceDetails.OauthClientSecret, serviceDetails.Userid, serviceDetails.Password, serviceDetails.Host, "https", 443, true)
+	exe.UseTenantDefaults()
+	exe.SetPlatform(platform.Name())
+	exe.SetODataVersion(serviceDetails.ODataVersion)
+	exe.SetCsrfFetcher(func() (string, []*http.Cookie, error) {
+		return NewCsrf(exe).GetToken()
+	})
+	return exe
 }
 
 func modifyingCall(method string, urlPath string, content []byte, successCode int, callType string, exe *httpclnt.HTTPExecuter) error {
@@ -48,6 +67,17 @@ func modifyingCall(method string, urlPath string, content []byte, successCode in
 }
 
 func modifyingCallWithContentType(method string, urlPath string, content []byte, contentType string, successCode int, callType string, exe *httpclnt.HTTPExecuter) error {
+	return modifyingCallAccepting(method, urlPath, content, contentType, func(statusCode int) bool { return statusCode == successCode }, callType, exe)
+}
+
+// ModifyingCall executes a modifying call prepared earlier, e.g. from a request bundle, that succeeds with one of
+// successCodes
+func ModifyingCall(method string, urlPath string, content []byte, contentType string, successCodes []int, callType string, exe *httpclnt.HTTPExecuter) error {
+	return modifyingCallAccepting(method, urlPath, content, contentType, func(statusCode int) bool { return slices.Contains(successCodes, statusCode) }, callType, exe)
+}
+
+// modifyingCallAccepting executes a modifying call whose success codes differ, e.g. by platform
+func modifyingCallAccepting(method string, urlPath string, content []byte, contentType string, accepted func(int) bool, callType string, exe *httpclnt.HTTPExecuter) error {
 	headers, cookies, err := InitHeadersAndCookies(exe)
 	if err != nil {
 		return err
@@ -67,8 +97,8 @@ func modifyingCallWithContentType(method string, urlPath string, content []byte,
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != successCode {
-		_, err = exe.LogError(resp, callType)
+	if !accepted(resp.StatusCode) {
+		_, err = responseError(exe, resp, callType)
 		return err
 	}
 	return nil
@@ -100,7 +130,7 @@ func readOnlyCallWithBodyAndAcceptType(urlPath string, content []byte, callType
 		return nil, err
 	}
 	if resp.StatusCode != 200 {
-		resBody, err := exe.LogError(resp, callType)
+		resBody, err := responseError(exe, resp, callType)
 		resp.Body = io.NopCloser(bytes.NewReader(resBody))
 		return resp, err
 	}
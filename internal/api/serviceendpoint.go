@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type ServiceEndpoint struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+type serviceEndpointsData struct {
+	Root struct {
+		Results []*ServiceEndpointData `json:"results"`
+		Next    string                 `json:"__next"`
+	} `json:"d"`
+}
+
+type ServiceEndpointData struct {
+	Name        string `json:"Name"`
+	Id          string `json:"Id"`
+	Title       string `json:"Title"`
+	Version     string `json:"Version"`
+	Summary     string `json:"Summary"`
+	Description string `json:"Description"`
+	LastUpdated string `json:"LastUpdated"`
+	Protocol    string `json:"Protocol"`
+	EntryPoints struct {
+		Results []*EntryPointData `json:"results"`
+	} `json:"EntryPoints"`
+}
+
+type EntryPointData struct {
+	Name                  string `json:"Name"`
+	Url                   string `json:"Url"`
+	Type                  string `json:"Type"`
+	AdditionalInformation string `json:"AdditionalInformation"`
+}
+
+// NewServiceEndpoint returns an initialised ServiceEndpoint instance.
+func NewServiceEndpoint(exe *httpclnt.HTTPExecuter) *ServiceEndpoint {
+	s := new(ServiceEndpoint)
+	s.exe = exe
+	return s
+}
+
+// ArtifactId returns the ID of the runtime artifact that exposes the endpoint.
+// The ID of a service endpoint is in the format <artifact ID>$endpointAddress=<address>
+func (d *ServiceEndpointData) ArtifactId() string {
+	id, _, _ := strings.Cut(d.Id, "$")
+	return id
+}
+
+func (s *ServiceEndpoint) List() ([]*ServiceEndpointData, error) {
+	log.Info().Msg("Getting list of service endpoints")
+	urlPath := "/api/v1/ServiceEndpoints?$expand=EntryPoints"
+
+	var endpoints []*ServiceEndpointData
+	callType := "Get service endpoints"
+	for urlPath != "" {
+		resp, err := readOnlyCall(urlPath, callType, s.exe)
+		if err != nil {
+			return nil, err
+		}
+		var jsonData *serviceEndpointsData
+		respBody, err := s.exe.ReadRespBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(respBody, &jsonData)
+		if err != nil {
+			log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+			return nil, errors.Wrap(err, 0)
+		}
+		endpoints = append(endpoints, jsonData.Root.Results...)
+		urlPath = nextPagePath(jsonData.Root.Next)
+	}
+	return endpoints, nil
+}
+
+// nextPagePath converts the __next link of a paged OData response to a path relative to the tenant host
+func nextPagePath(next string) string {
+	if next == "" {
+		return ""
+	}
+	if idx := strings.Index(next, "/api/v1/"); idx >= 0 {
+		return next[idx:]
+	}
+	return "/api/v1/" + strings.TrimPrefix(next, "/")
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceEndpoint_ListMockPaged(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/ServiceEndpoints", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("$skiptoken") == "" {
+			w.Write([]byte(`{ "d": { "results": [ { "Name": "Flow One", "Id": "FlowOne$endpointAddress=one", "Version": "1.0.0", "Protocol": "REST",
+				"EntryPoints": { "results": [ { "Name": "Flow One", "Url": "https://dummy/http/one", "Type": "PROD" } ] } } ],
+				"__next": "https://dummy/api/v1/ServiceEndpoints?$expand=EntryPoints&$skiptoken=1" } }`))
+			return
+		}
+		w.Write([]byte(`{ "d": { "results": [ { "Name": "Flow Two", "Id": "FlowTwo$endpointAddress=two", "Version": "1.0.1", "Protocol": "SOAP",
+			"EntryPoints": { "results": [ { "Name": "Flow Two", "Url": "https://dummy/cxf/two", "Type": "PROD" } ] } } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	endpoints, err := NewServiceEndpoint(exe).List()
+	if err != nil {
+		t.Fatalf("List service endpoints failed with error - %v", err)
+	}
+	assert.Equal(t, 2, len(endpoints), "Incorrect number of service endpoints")
+	assert.Equal(t, "FlowOne", endpoints[0].ArtifactId(), "Incorrect artifact ID")
+	assert.Equal(t, "https://dummy/cxf/two", endpoints[1].EntryPoints.Results[0].Url, "Incorrect entry point URL")
+}
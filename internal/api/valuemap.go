@@ -31,7 +31,10 @@ func (vm *ValueMapping) Update(id string, name string, packageId string, artifac
 	return create(id, name, packageId, artifactDir, vm.typ, vm.exe)
 }
 func (vm *ValueMapping) Deploy(id string) error {
-	return deploy(id, vm.typ, vm.exe)
+	return deploy(id, "active", vm.typ, vm.exe)
+}
+func (vm *ValueMapping) DeployVersion(id string, version string) error {
+	return deploy(id, version, vm.typ, vm.exe)
 }
 func (vm *ValueMapping) Delete(id string) error {
 	return deleteCall(id, vm.typ, vm.exe)
@@ -63,11 +66,7 @@ func (vm *ValueMapping) CopyContent(srcDir string, tgtDir string) error {
 }
 func (vm *ValueMapping) CompareContent(srcDir string, tgtDir string, _ []string, _ string) (bool, error) {
 	// Diff directories
-	log.Info().Msg("Checking for changes in META-INF directory")
-	metaDiffer := file.DiffDirectories(srcDir+"/META-INF", tgtDir+"/META-INF")
-	log.Info().Msg("Checking for changes in value_mapping.xml")
-	xmlDiffer := file.DiffFile(srcDir+"/value_mapping.xml", tgtDir+"/value_mapping.xml")
+	log.Info().Msg("Checking for changes in META-INF and value_mapping.xml")
 	// TODO - The API for value mapping does not return metainfo.prop, so we can't compare it
-
-	return metaDiffer || xmlDiffer, nil
+	return diffFiles(srcDir, tgtDir, "META-INF", "value_mapping.xml")
 }
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/odata"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ValueMappingSchema is the pair of source and target agency and identifier the entries of a value mapping
+// are grouped by
+type ValueMappingSchema struct {
+	SrcAgency string `json:"SrcAgency"`
+	SrcId     string `json:"SrcId"`
+	TgtAgency string `json:"TgtAgency"`
+	TgtId     string `json:"TgtId"`
+}
+
+func (s ValueMappingSchema) String() string {
+	return fmt.Sprintf("%s/%s -> %s/%s", s.SrcAgency, s.SrcId, s.TgtAgency, s.TgtId)
+}
+
+// ValueMappingEntry maps a source value to a target value of a schema
+type ValueMappingEntry struct {
+	Schema      ValueMappingSchema
+	Id          string // ID of the entry on the tenant, empty for new entries
+	SourceValue string
+	TargetValue string
+}
+
+type valueMappingSchemaData struct {
+	Root struct {
+		Results []*ValueMappingSchema `json:"results"`
+	} `json:"d"`
+}
+
+type valueMappingEntryData struct {
+	Root struct {
+		Results []struct {
+			Id    string `json:"Id"`
+			Value struct {
+				SrcValue string `json:"SrcValue"`
+				TgtValue string `json:"TgtValue"`
+			} `json:"Value"`
+		} `json:"results"`
+	} `json:"d"`
+}
+
+// ValueMappingContent reads and updates the entries of value mappings
+type ValueMappingContent struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+// NewValueMappingContent returns an initialised ValueMappingContent instance.
+func NewValueMappingContent(exe *httpclnt.HTTPExecuter) *ValueMappingContent {
+	c := new(ValueMappingContent)
+	c.exe = exe
+	return c
+}
+
+// GetEntries returns the entries of all schemas of the value mapping
+func (c *ValueMappingContent) GetEntries(id string, version string) ([]*ValueMappingEntry, error) {
+	log.Info().Msgf("Getting entries of value mapping %v", id)
+	var schemas *valueMappingSchemaData
+	if err := c.getJSON(odata.ValueMappingDesigntimeArtifactValMapSchemasPath(id, version), "Get value mapping schemas", &schemas); err != nil {
+		return nil, err
+	}
+
+	var entries []*ValueMappingEntry
+	for _, schema := range schemas.Root.Results {
+		urlPath := odata.ValueMappingDesigntimeArtifactValMapsPath(id, version, schema.SrcAgency, schema.SrcId, schema.TgtAgency, schema.TgtId)
+		var values *valueMappingEntryData
+		if err := c.getJSON(urlPath, "Get value mapping entries", &values); err != nil {
+			return nil, err
+		}
+		for _, v := range values.Root.Results {
+			entries = append(entries, &ValueMappingEntry{Schema: *schema, Id: v.Id, SourceValue: v.Value.SrcValue, TargetValue: v.Value.TgtValue})
+		}
+	}
+	return entries, nil
+}
+
+// Upsert creates the entry, or updates its target value if the entry has an ID
+func (c *ValueMappingContent) Upsert(id string, version string, entry *ValueMappingEntry) error {
+	log.Debug().Msgf("Upserting %v = %v of %v in value mapping %v", entry.SourceValue, entry.TargetValue, entry.Schema, id)
+	urlPath := "/api/v1/UpsertValMaps?" + c.entryQuery(id, version, entry) +
+		fmt.Sprintf("&SrcValue=%s&TgtValue=%s&IsConfigured=true", odata.Parameter(entry.SourceValue), odata.Parameter(entry.TargetValue))
+	return modifyingCall("POST", urlPath, nil, 200, "Upsert value mapping entry", c.exe)
+}
+
+// Delete deletes the entry with the ID of entry
+func (c *ValueMappingContent) Delete(id string, version string, entry *ValueMappingEntry) error {
+	log.Debug().Msgf("Deleting %v of %v in value mapping %v", entry.SourceValue, entry.Schema, id)
+	urlPath := "/api/v1/DeleteValMaps?" + c.entryQuery(id, version, entry)
+	return modifyingCall("POST", urlPath, nil, 200, "Delete value mapping entry", c.exe)
+}
+
+func (c *ValueMappingContent) entryQuery(id string, version string, entry *ValueMappingEntry) string {
+	query := fmt.Sprintf("Id=%s&Version=%s&SrcAgency=%s&SrcId=%s&TgtAgency=%s&TgtId=%s",
+		odata.Parameter(id), odata.Parameter(version), odata.Parameter(entry.Schema.SrcAgency), odata.Parameter(entry.Schema.SrcId),
+		odata.Parameter(entry.Schema.TgtAgency), odata.Parameter(entry.Schema.TgtId))
+	if entry.Id != "" {
+		query += "&ValMapId=" + odata.Parameter(entry.Id)
+	}
+	return query
+}
+
+func (c *ValueMappingContent) getJSON(urlPath string, callType string, v any) error {
+	resp, err := readOnlyCall(urlPath, callType, c.exe)
+	if err != nil {
+		return err
+	}
+	respBody, err := c.exe.ReadRespBody(resp)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(respBody, v); err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueMappingContentMock(t *testing.T) {
+	var upsertQuery string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/ValueMappingDesigntimeArtifacts(Id='Country_Codes',Version='active')/ValMapSchema":
+			w.Write([]byte(`{"d": {"results": [{"SrcAgency": "SAP", "SrcId": "Country", "TgtAgency": "Partner", "TgtId": "Country Code"}]}}`))
+		case "/api/v1/ValueMappingDesigntimeArtifacts(Id='Country_Codes',Version='active')/ValMapSchema(SrcAgency='SAP',SrcId='Country',TgtAgency='Partner',TgtId='Country Code')/ValMaps":
+			w.Write([]byte(`{"d": {"results": [{"Id": "vm1", "Value": {"SrcValue": "DE", "TgtValue": "DEU"}}]}}`))
+		case "/api/v1/UpsertValMaps":
+			upsertQuery = r.URL.RawQuery
+		default:
+			w.Header().Set("x-csrf-token", "token")
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	c := NewValueMappingContent(httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true))
+
+	entries, err := c.GetEntries("Country_Codes", "active")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "SAP/Country -> Partner/Country Code", entries[0].Schema.String())
+	assert.Equal(t, &ValueMappingEntry{Schema: entries[0].Schema, Id: "vm1", SourceValue: "DE", TargetValue: "DEU"}, entries[0])
+
+	entries[0].TargetValue = "O'Land"
+	require.NoError(t, c.Upsert("Country_Codes", "active", entries[0]))
+	assert.Contains(t, upsertQuery, "TgtId='Country%20Code'")
+	assert.Contains(t, upsertQuery, "ValMapId='vm1'")
+	assert.Contains(t, upsertQuery, "TgtValue='O%27%27Land'", "Quotes should be doubled")
+}
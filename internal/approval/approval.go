@@ -0,0 +1,164 @@
+// Package approval provides the approval gate that is checked before artifacts are deployed.
+package approval
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Names of the approval providers
+const (
+	ProviderNone       = "none"
+	ProviderPrompt     = "prompt"
+	ProviderToken      = "token"
+	ProviderServiceNow = "servicenow"
+)
+
+// Request describes the deployment to be approved
+type Request struct {
+	Command   string   // Command requesting the approval
+	Tenant    string   // Host of the tenant deployed to
+	Artifacts []string // IDs of the artifacts to be deployed
+}
+
+// Provider approves a deployment, or returns the reason for rejecting it
+type Provider interface {
+	Approve(req Request) error
+}
+
+// Prompt asks for confirmation on an interactive terminal
+type Prompt struct {
+	In  io.Reader
+	Out io.Writer
+}
+
+// NewPrompt returns an initialised Prompt instance.
+func NewPrompt(in io.Reader, out io.Writer) *Prompt {
+	p := new(Prompt)
+	p.In = in
+	p.Out = out
+	return p
+}
+
+func (p *Prompt) Approve(req Request) error {
+	fmt.Fprintf(p.Out, "\n%v will deploy %d artifact(s) to %v:\n", req.Command, len(req.Artifacts), req.Tenant)
+	for _, id := range req.Artifacts {
+		fmt.Fprintf(p.Out, "  - %v\n", id)
+	}
+	fmt.Fprint(p.Out, "Proceed with deployment? [y/N]: ")
+
+	answer, err := bufio.NewReader(p.In).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("deployment rejected at prompt")
+	}
+}
+
+// Token approves when the token supplied by the pipeline matches the expected token
+type Token struct {
+	supplied string
+	expected string
+}
+
+// NewToken returns an initialised Token instance.
+func NewToken(supplied string, expected string) *Token {
+	t := new(Token)
+	t.supplied = supplied
+	t.expected = expected
+	return t
+}
+
+func (t *Token) Approve(req Request) error {
+	if t.expected == "" {
+		return fmt.Errorf("no expected approval token configured")
+	}
+	if t.supplied == "" {
+		return fmt.Errorf("no approval token supplied")
+	}
+	if subtle.ConstantTimeCompare([]byte(t.supplied), []byte(t.expected)) != 1 {
+		return fmt.Errorf("invalid approval token")
+	}
+	log.Info().Msg("Deployment approved by approval token")
+	return nil
+}
+
+// ServiceNow approves when the change request is approved and in one of the allowed states
+type ServiceNow struct {
+	exe           *httpclnt.HTTPExecuter
+	changeRequest string
+	states        []string
+}
+
+type changeRequestData struct {
+	Result []struct {
+		Number   string `json:"number"`
+		State    string `json:"state"`
+		Approval string `json:"approval"`
+	} `json:"result"`
+}
+
+// NewServiceNow returns an initialised ServiceNow instance. The states are the display values of the
+// change request states in which deployment is allowed, e.g. Scheduled and Implement.
+func NewServiceNow(exe *httpclnt.HTTPExecuter, changeRequest string, states []string) *ServiceNow {
+	s := new(ServiceNow)
+	s.exe = exe
+	s.changeRequest = changeRequest
+	s.states = states
+	return s
+}
+
+func (s *ServiceNow) Approve(req Request) error {
+	if s.changeRequest == "" {
+		return fmt.Errorf("no change request supplied")
+	}
+	log.Info().Msgf("Checking state of ServiceNow change request %v", s.changeRequest)
+	urlPath := fmt.Sprintf("/api/now/table/change_request?sysparm_query=%v&sysparm_fields=number,state,approval&sysparm_display_value=true&sysparm_limit=1",
+		url.QueryEscape("number="+s.changeRequest))
+
+	resp, err := s.exe.ExecGetRequest(urlPath, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 200 {
+		_, err = s.exe.LogError(resp, "Get ServiceNow change request")
+		return err
+	}
+	respBody, err := s.exe.ReadRespBody(resp)
+	if err != nil {
+		return err
+	}
+	var jsonData *changeRequestData
+	err = json.Unmarshal(respBody, &jsonData)
+	if err != nil {
+		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
+		return errors.Wrap(err, 0)
+	}
+	if len(jsonData.Result) == 0 {
+		return fmt.Errorf("change request %v not found", s.changeRequest)
+	}
+
+	change := jsonData.Result[0]
+	if change.Approval != "Approved" {
+		return fmt.Errorf("change request %v is not approved (approval = %v)", change.Number, change.Approval)
+	}
+	if !slices.Contains(s.states, change.State) {
+		return fmt.Errorf("change request %v is in state %v, deployment is allowed in states %v", change.Number, change.State, strings.Join(s.states, ", "))
+	}
+	log.Info().Msgf("Deployment approved by change request %v (state = %v)", change.Number, change.State)
+	return nil
+}
@@ -0,0 +1,51 @@
+package approval
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrompt(t *testing.T) {
+	req := Request{Command: "deploy", Tenant: "tenant", Artifacts: []string{"Flow"}}
+	var out bytes.Buffer
+
+	assert.NoError(t, NewPrompt(strings.NewReader("y\n"), &out).Approve(req), "Deployment should be approved")
+	assert.Contains(t, out.String(), "  - Flow", "Artifacts should be listed")
+	assert.Error(t, NewPrompt(strings.NewReader("\n"), &out).Approve(req), "Deployment should be rejected by default")
+}
+
+func TestToken(t *testing.T) {
+	assert.NoError(t, NewToken("abc", "abc").Approve(Request{}), "Matching token should be approved")
+	assert.EqualError(t, NewToken("abd", "abc").Approve(Request{}), "invalid approval token")
+	assert.EqualError(t, NewToken("", "abc").Approve(Request{}), "no approval token supplied")
+}
+
+func TestServiceNowMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("sysparm_query") {
+		case "number=CHG001":
+			w.Write([]byte(`{"result": [{"number": "CHG001", "state": "Implement", "approval": "Approved"}]}`))
+		case "number=CHG002":
+			w.Write([]byte(`{"result": [{"number": "CHG002", "state": "Assess", "approval": "Requested"}]}`))
+		default:
+			w.Write([]byte(`{"result": []}`))
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	states := []string{"Scheduled", "Implement"}
+
+	assert.NoError(t, NewServiceNow(exe, "CHG001", states).Approve(Request{}), "Approved change should be approved")
+	assert.EqualError(t, NewServiceNow(exe, "CHG002", states).Approve(Request{}), "change request CHG002 is not approved (approval = Requested)")
+	assert.EqualError(t, NewServiceNow(exe, "CHG003", states).Approve(Request{}), "change request CHG003 not found")
+}
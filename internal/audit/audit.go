@@ -0,0 +1,226 @@
+// Package audit records the modifying API calls of a run in an append-only JSON Lines file. With hash
+// chaining, each entry contains the hash of the previous entry, so that removed or altered entries can be
+// detected with Verify.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Options configures the audit log. The audit log is disabled when File is empty.
+type Options struct {
+	// File the entries are appended to, created if it does not exist
+	File string
+	// HashChain links each entry to the previous one with a SHA-256 hash
+	HashChain bool
+}
+
+// Entry is a single modifying API call
+type Entry struct {
+	Time     string `json:"time"`
+	User     string `json:"user"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Artifact string `json:"artifact,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+	PrevHash string `json:"prevHash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// Outcomes of an entry
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+var (
+	mu       sync.Mutex
+	options  Options
+	lastHash string
+)
+
+// Init sets the audit log file. With hash chaining, the chain continues from the last entry of an existing file.
+func Init(opts Options) error {
+	mu.Lock()
+	defer mu.Unlock()
+	options = opts
+	lastHash = ""
+	if opts.File == "" {
+		return nil
+	}
+	f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	_ = f.Close()
+	if opts.HashChain {
+		entries, err := readEntries(opts.File)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			lastHash = entries[len(entries)-1].Hash
+		}
+	}
+	return nil
+}
+
+// Enabled returns true if an audit log file is set
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return options.File != ""
+}
+
+// IsModifying returns true for the HTTP methods that change data on the tenant
+func IsModifying(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, "MERGE":
+		return true
+	}
+	return false
+}
+
+var artifactId = regexp.MustCompile(`(?:\bId=|\()'([^']*)'`)
+
+// ArtifactFromPath returns the ID of the artifact or package an OData path refers to, e.g. Id='Orders' of
+// IntegrationDesigntimeArtifacts(Id='Orders',Version='active') or Orders of IntegrationPackages('Orders')
+func ArtifactFromPath(path string) string {
+	if m := artifactId.FindStringSubmatch(path); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Record appends the entry to the audit log. The time is set if empty.
+func Record(entry Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if options.File == "" {
+		return nil
+	}
+	if entry.Time == "" {
+		entry.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	entry.PrevHash, entry.Hash = "", ""
+	if options.HashChain {
+		entry.PrevHash = lastHash
+		hash, err := hashEntry(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(options.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err = f.Sync(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if options.HashChain {
+		lastHash = entry.Hash
+	}
+	return nil
+}
+
+// hashEntry returns the SHA-256 hash of the JSON of the entry without its own hash. As the JSON contains the
+// hash of the previous entry, the hashes form a chain.
+func hashEntry(entry Entry) (string, error) {
+	entry.Hash = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks the hash chain of an audit log and returns the number of entries, or the number of the
+// first invalid entry. Entries before the first chained entry, e.g. written before hash chaining was enabled,
+// are not checked.
+func Verify(file string) (int, error) {
+	entries, err := readEntries(file)
+	if err != nil {
+		return 0, err
+	}
+	chained := false
+	previous := ""
+	for i, entry := range entries {
+		n := i + 1
+		if entry.Hash == "" {
+			if chained {
+				return n, fmt.Errorf("entry %d is not chained", n)
+			}
+			continue
+		}
+		if !chained && entry.PrevHash != "" {
+			return n, fmt.Errorf("entry %d refers to previous hash %s, entries were removed", n, entry.PrevHash)
+		}
+		if entry.PrevHash != previous {
+			return n, fmt.Errorf("entry %d: previous hash %s does not match hash %s of entry %d, entries were removed or reordered", n, entry.PrevHash, previous, n-1)
+		}
+		hash, err := hashEntry(entry)
+		if err != nil {
+			return n, err
+		}
+		if hash != entry.Hash {
+			return n, fmt.Errorf("entry %d: hash mismatch, entry was altered", n)
+		}
+		chained = true
+		previous = entry.Hash
+	}
+	return len(entries), nil
+}
+
+func readEntries(file string) ([]Entry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var entry Entry
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("audit log %s line %d: %w", file, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactFromPath(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='Orders',Version='active')":     "Orders",
+		"/api/v1/DeployIntegrationDesigntimeArtifact?Id='Orders'&Version='active'": "Orders",
+		"/api/v1/IntegrationPackages('Billing')":                                   "Billing",
+		"/api/v1/StringParameters(Pid='Orders',Id='Region')":                       "Region",
+		"/api/v1/$batch": "",
+	}
+	for path, expected := range tests {
+		assert.Equal(t, expected, ArtifactFromPath(path), path)
+	}
+}
+
+func TestRecordHashChain(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, Init(Options{File: file, HashChain: true}))
+	defer Init(Options{})
+
+	require.NoError(t, Record(Entry{User: "pipeline", Method: "PUT", URL: "https://tenant/api/v1/IntegrationPackages('A')", Artifact: "A", Status: 200, Outcome: OutcomeSuccess}))
+	require.NoError(t, Record(Entry{User: "pipeline", Method: "DELETE", URL: "https://tenant/api/v1/IntegrationPackages('B')", Artifact: "B", Status: 404, Outcome: OutcomeFailure}))
+
+	// A new run continues the chain of the existing file
+	require.NoError(t, Init(Options{File: file, HashChain: true}))
+	require.NoError(t, Record(Entry{User: "pipeline", Method: "POST", URL: "https://tenant/api/v1/$batch", Error: "connection reset", Outcome: OutcomeFailure}))
+
+	n, err := Verify(file)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"time":"`)
+
+	altered := filepath.Join(t.TempDir(), "altered.jsonl")
+	require.NoError(t, os.WriteFile(altered, []byte(strings.Replace(string(data), `"status":404`, `"status":204`, 1)), 0o600))
+	n, err = Verify(altered)
+	assert.ErrorContains(t, err, "entry was altered")
+	assert.Equal(t, 2, n)
+
+	removed := filepath.Join(t.TempDir(), "removed.jsonl")
+	require.NoError(t, os.WriteFile(removed, []byte(lines[0]+"\n"+lines[2]+"\n"), 0o600))
+	_, err = Verify(removed)
+	assert.ErrorContains(t, err, "entries were removed")
+
+	truncated := filepath.Join(t.TempDir(), "truncated.jsonl")
+	require.NoError(t, os.WriteFile(truncated, []byte(lines[1]+"\n"+lines[2]+"\n"), 0o600))
+	_, err = Verify(truncated)
+	assert.ErrorContains(t, err, "entries were removed")
+}
+
+func TestRecordDisabled(t *testing.T) {
+	require.NoError(t, Init(Options{}))
+	assert.False(t, Enabled())
+	assert.NoError(t, Record(Entry{Method: "PUT"}))
+}
+
+func TestIsModifying(t *testing.T) {
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE", "MERGE"} {
+		assert.True(t, IsModifying(method), method)
+	}
+	for _, method := range []string{"GET", "HEAD"} {
+		assert.False(t, IsModifying(method), method)
+	}
+}
@@ -0,0 +1,165 @@
+// Package calm posts deployment events to SAP Cloud ALM, so that its monitoring can correlate runtime incidents
+// of a tenant with the deployments performed by flashpipe. Events are posted with OAuth client credentials of a
+// Cloud ALM service key. Failed posts are logged and do not fail the run.
+package calm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/runid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Options configures the events. Events are disabled when URL is empty.
+type Options struct {
+	URL          string // Events endpoint of the Cloud ALM API
+	TokenURL     string // Token endpoint of the Cloud ALM service key
+	ClientID     string
+	ClientSecret string
+	ServiceID    string // ID of the tenant's service in Cloud ALM the events are assigned to
+}
+
+// Results of a deployment
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Deployment is the deployment of an artifact to a tenant
+type Deployment struct {
+	Tenant       string
+	PackageID    string
+	ArtifactID   string
+	ArtifactType string
+	Version      string
+	Duration     time.Duration
+	Err          error
+}
+
+// Event is the payload posted for a deployment
+type Event struct {
+	EventType   string     `json:"eventType"`
+	Source      string     `json:"source"`
+	ServiceID   string     `json:"serviceId,omitempty"`
+	Timestamp   string     `json:"timestamp"`
+	Severity    string     `json:"severity"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Attributes  Attributes `json:"attributes"`
+}
+
+// Attributes describe the deployment of an event
+type Attributes struct {
+	Tenant       string `json:"tenant"`
+	PackageID    string `json:"packageId,omitempty"`
+	ArtifactID   string `json:"artifactId"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	Version      string `json:"version,omitempty"`
+	Result       string `json:"result"`
+	DurationMs   int64  `json:"durationMs,omitempty"`
+	RunID        string `json:"runId,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	options Options
+	client  *http.Client
+)
+
+// Init configures the events
+func Init(opts Options) error {
+	mu.Lock()
+	defer mu.Unlock()
+	options = opts
+	client = nil
+	if opts.URL == "" {
+		return nil
+	}
+	if opts.TokenURL == "" || opts.ClientID == "" || opts.ClientSecret == "" {
+		return fmt.Errorf("the token URL, client ID and client secret of Cloud ALM are required to post deployment events")
+	}
+	conf := &clientcredentials.Config{ClientID: opts.ClientID, ClientSecret: opts.ClientSecret, TokenURL: opts.TokenURL}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: 30 * time.Second})
+	client = conf.Client(ctx)
+	client.Timeout = 30 * time.Second
+	return nil
+}
+
+// Enabled returns true if deployment events are posted
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return client != nil
+}
+
+// NewEvent returns the event of a deployment
+func NewEvent(d Deployment, serviceID string) Event {
+	event := Event{
+		EventType: "Deployment",
+		Source:    "flashpipe",
+		ServiceID: serviceID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Severity:  "INFO",
+		Attributes: Attributes{
+			Tenant:       d.Tenant,
+			PackageID:    d.PackageID,
+			ArtifactID:   d.ArtifactID,
+			ArtifactType: d.ArtifactType,
+			Version:      d.Version,
+			Result:       ResultSuccess,
+			DurationMs:   d.Duration.Milliseconds(),
+			RunID:        runid.ID(),
+		},
+	}
+	artifact := d.ArtifactID
+	if d.Version != "" {
+		artifact += " " + d.Version
+	}
+	event.Title = fmt.Sprintf("Deployment of %s to %s succeeded", artifact, d.Tenant)
+	if d.Err != nil {
+		event.Severity = "ERROR"
+		event.Title = fmt.Sprintf("Deployment of %s to %s failed", artifact, d.Tenant)
+		event.Description = d.Err.Error()
+		event.Attributes.Result = ResultFailure
+	}
+	return event
+}
+
+// PostDeployment posts the event of a deployment. A failure is logged as a warning.
+func PostDeployment(d Deployment) {
+	mu.Lock()
+	opts, c := options, client
+	mu.Unlock()
+	if c == nil {
+		return
+	}
+	if err := post(c, opts.URL, NewEvent(d, opts.ServiceID)); err != nil {
+		log.Warn().Msgf("Failed to post deployment event of %v to Cloud ALM: %v", d.ArtifactID, err)
+	}
+}
+
+func post(c *http.Client, endpoint string, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("response code = %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package calm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostDeployment(t *testing.T) {
+	var received []Event
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "calm-token", "token_type": "bearer", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer calm-token", r.Header.Get("Authorization"))
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received = append(received, event)
+		w.WriteHeader(http.StatusCreated)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	require.NoError(t, Init(Options{URL: svr.URL + "/events", TokenURL: svr.URL + "/oauth/token", ClientID: "id", ClientSecret: "secret", ServiceID: "svc-1"}))
+	defer Init(Options{})
+	require.True(t, Enabled())
+
+	PostDeployment(Deployment{Tenant: "tenant.example.com", PackageID: "Sales", ArtifactID: "Orders", ArtifactType: "Integration", Version: "1.0.4", Duration: 2 * time.Second})
+	PostDeployment(Deployment{Tenant: "tenant.example.com", ArtifactID: "Billing", Err: errors.New("Artifact deployment unsuccessful, ended with status ERROR")})
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "Deployment", received[0].EventType)
+	assert.Equal(t, "svc-1", received[0].ServiceID)
+	assert.Equal(t, "INFO", received[0].Severity)
+	assert.Equal(t, "Deployment of Orders 1.0.4 to tenant.example.com succeeded", received[0].Title)
+	assert.Equal(t, Attributes{Tenant: "tenant.example.com", PackageID: "Sales", ArtifactID: "Orders", ArtifactType: "Integration",
+		Version: "1.0.4", Result: ResultSuccess, DurationMs: 2000}, received[0].Attributes)
+
+	assert.Equal(t, "ERROR", received[1].Severity)
+	assert.Equal(t, "Deployment of Billing to tenant.example.com failed", received[1].Title)
+	assert.Equal(t, "Artifact deployment unsuccessful, ended with status ERROR", received[1].Description)
+	assert.Equal(t, ResultFailure, received[1].Attributes.Result)
+}
+
+func TestInit(t *testing.T) {
+	defer Init(Options{})
+	require.NoError(t, Init(Options{}))
+	assert.False(t, Enabled())
+	assert.EqualError(t, Init(Options{URL: "https://calm.example.com/events"}),
+		"the token URL, client ID and client secret of Cloud ALM are required to post deployment events")
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewAdvisorCommand() *cobra.Command {
+
+	advisorCmd := &cobra.Command{
+		Use:   "advisor",
+		Short: "Promote Integration Advisor MIGs and MAGs",
+		Long: `List, export and import the message implementation guidelines (MIGs) and
+mapping guidelines (MAGs) of SAP Integration Advisor with its API, so that
+B2B mapping content is promoted in the same pipeline run as the integration
+flows using it. MIGs and MAGs are not part of packages and are not deployed.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'advisor' section. CLI flags override config file settings.`,
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'advisor' key
+	advisorCmd.PersistentFlags().String("type", api.GuidelineMAG, "Type of the guidelines. Allowed values: MIG, MAG (config: advisor.type)")
+
+	return advisorCmd
+}
+
+// guidelineType returns the validated --type of an advisor command
+func guidelineType(cmd *cobra.Command) (string, error) {
+	value := config.GetStringWithFallback(cmd, "type", "advisor.type")
+	guidelineType := api.CanonicalGuidelineType(value)
+	if guidelineType == "" {
+		return "", fmt.Errorf("invalid value for --type = %v (allowed values: %s, %s)", value, api.GuidelineMIG, api.GuidelineMAG)
+	}
+	return guidelineType, nil
+}
+
+func NewAdvisorListCommand() *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List the versions of the MIGs or MAGs",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Example: `  # List the MIGs of the tenant
+  flashpipe advisor list --type MIG`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runAdvisorList(cmd, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+	return listCmd
+}
+
+func NewAdvisorExportCommand() *cobra.Command {
+
+	exportCmd := &cobra.Command{
+		Use:          "export",
+		Short:        "Export a MIG or MAG to a file",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Export a version of a MIG or MAG to a file that advisor import imports
+into another tenant. A guideline given by name is exported in its highest
+version.`,
+		Example: `  # Export a MAG and the MIGs it maps from the development tenant
+  flashpipe advisor export --tenant dev --type MIG --guideline Orders_X12_850 --output-file migs/Orders_X12_850.zip
+  flashpipe advisor export --tenant dev --type MIG --guideline Orders_IDoc --output-file migs/Orders_IDoc.zip
+  flashpipe advisor export --tenant dev --type MAG --guideline Orders_X12_to_IDoc --output-file mags/Orders_X12_to_IDoc.zip`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runAdvisorExport(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	exportCmd.Flags().String("guideline", "", "Name or version ID of the guideline (config: advisor.export.guideline)")
+	exportCmd.Flags().String("output-file", "", "File the export is written to (config: advisor.export.outputFile)")
+
+	return exportCmd
+}
+
+func NewAdvisorImportCommand() *cobra.Command {
+
+	importCmd := &cobra.Command{
+		Use:          "import",
+		Short:        "Import MIGs or MAGs from export files",
+		SilenceUsage: true,
+		Long: `Import the files written by advisor export into the tenant, in the given
+order. A MAG requires its source and target MIGs, so import the MIGs first.`,
+		Example: `  # Promote the guidelines to the production tenant before deploying the flows using them
+  flashpipe advisor import --tenant prod --type MIG --file migs/Orders_X12_850.zip,migs/Orders_IDoc.zip
+  flashpipe advisor import --tenant prod --type MAG --file mags/Orders_X12_to_IDoc.zip`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runAdvisorImport(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	importCmd.Flags().StringSlice("file", nil, "Comma separated list of export files (config: advisor.import.file)")
+	importCmd.Flags().Bool("dry-run", false, "Show the files that would be imported without importing them (config: advisor.import.dryRun)")
+
+	return importCmd
+}
+
+func runAdvisorList(cmd *cobra.Command, out io.Writer) error {
+	guidelineType, err := guidelineType(cmd)
+	if err != nil {
+		return err
+	}
+	exe := api.InitHTTPExecuter(api.GetServiceDetails(cmd))
+	guidelines, err := api.NewIntegrationAdvisor(exe).List(guidelineType)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tSTATUS\tVERSION ID")
+	for _, guideline := range guidelines {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", guideline.Name, guideline.Version, guideline.Status, guideline.VersionId)
+	}
+	return w.Flush()
+}
+
+func runAdvisorExport(cmd *cobra.Command) error {
+	guidelineType, err := guidelineType(cmd)
+	if err != nil {
+		return err
+	}
+	name := config.GetStringWithFallback(cmd, "guideline", "advisor.export.guideline")
+	outputFile := config.GetStringWithFallback(cmd, "output-file", "advisor.export.outputFile")
+	if name == "" {
+		return fmt.Errorf("--guideline is required (set via CLI flag or in config file under 'advisor.export.guideline')")
+	}
+	if outputFile == "" {
+		return fmt.Errorf("--output-file is required (set via CLI flag or in config file under 'advisor.export.outputFile')")
+	}
+
+	advisor := api.NewIntegrationAdvisor(api.InitHTTPExecuter(api.GetServiceDetails(cmd)))
+	guideline, err := advisor.Find(guidelineType, name)
+	if err != nil {
+		return err
+	}
+	content, err := advisor.Export(guidelineType, guideline.VersionId)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, content, 0644); err != nil {
+		return err
+	}
+	log.Info().Msgf("%s %s version %s exported to %s", guidelineType, guideline.Name, guideline.Version, outputFile)
+	return nil
+}
+
+func runAdvisorImport(cmd *cobra.Command) error {
+	guidelineType, err := guidelineType(cmd)
+	if err != nil {
+		return err
+	}
+	files := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "file", "advisor.import.file"))
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "advisor.import.dryRun")
+	if len(files) == 0 {
+		return fmt.Errorf("--file is required (set via CLI flag or in config file under 'advisor.import.file')")
+	}
+
+	// All files are read before the first import, so that a missing file does not leave a partial promotion
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		if contents[i], err = os.ReadFile(file); err != nil {
+			return err
+		}
+	}
+	advisor := api.NewIntegrationAdvisor(api.InitHTTPExecuter(api.GetServiceDetails(cmd)))
+	for i, file := range files {
+		if dryRun {
+			log.Info().Msgf("Dry run: %s of %s would be imported", guidelineType, file)
+			continue
+		}
+		if err := advisor.Import(guidelineType, contents[i]); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		log.Info().Msgf("%s of %s imported", guidelineType, file)
+	}
+	return nil
+}
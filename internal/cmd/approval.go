@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/engswee/flashpipe/internal/approval"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// deploymentApproval is the approval gate checked before artifacts are deployed
+type deploymentApproval struct {
+	provider approval.Provider
+	command  string
+	// Approvals are requested one at a time so that prompts of parallel tenants do not interleave
+	mu sync.Mutex
+}
+
+// addApprovalFlags adds the flags of the approval gate to a command that deploys artifacts
+func addApprovalFlags(cmd *cobra.Command) {
+	cmd.Flags().String("approval", approval.ProviderNone, "Approval required before deployment. Allowed values: none, prompt, token, servicenow (config: approval.provider)")
+	cmd.Flags().String("approval-token", "", "Approval token supplied by the pipeline (config: approval.token)")
+	cmd.Flags().String("approval-expected-token", "", "Token that approves the deployment (config: approval.expectedToken)")
+	cmd.Flags().String("change-request", "", "Number of the ServiceNow change request the deployment belongs to (config: approval.changeRequest)")
+	cmd.Flags().String("servicenow-host", "", "Host of the ServiceNow instance (config: approval.servicenow.host)")
+	cmd.Flags().String("servicenow-user", "", "User of the ServiceNow instance (config: approval.servicenow.user)")
+	cmd.Flags().String("servicenow-password", "", "Password of the ServiceNow instance (config: approval.servicenow.password)")
+	cmd.Flags().StringSlice("servicenow-states", []string{"Scheduled", "Implement"}, "States of the change request in which deployment is allowed (config: approval.servicenow.states)")
+	config.MarkSensitive(cmd.Flags(), "approval-token", "approval-expected-token")
+}
+
+// newDeploymentApproval returns the approval gate configured for the command, or nil if no approval is required
+func newDeploymentApproval(cmd *cobra.Command) (*deploymentApproval, error) {
+	a := &deploymentApproval{command: cmd.Name()}
+	providerName := config.GetStringWithFallback(cmd, "approval", "approval.provider")
+	switch providerName {
+	case "", approval.ProviderNone:
+		return nil, nil
+	case approval.ProviderPrompt:
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return nil, fmt.Errorf("--approval %v requires an interactive terminal", providerName)
+		}
+		a.provider = approval.NewPrompt(os.Stdin, os.Stderr)
+	case approval.ProviderToken:
+		a.provider = approval.NewToken(
+			os.ExpandEnv(config.GetStringWithFallback(cmd, "approval-token", "approval.token")),
+			os.ExpandEnv(config.GetStringWithFallback(cmd, "approval-expected-token", "approval.expectedToken")))
+	case approval.ProviderServiceNow:
+		host := config.GetStringWithFallback(cmd, "servicenow-host", "approval.servicenow.host")
+		user := config.GetStringWithFallback(cmd, "servicenow-user", "approval.servicenow.user")
+		password := config.GetStringWithFallback(cmd, "servicenow-password", "approval.servicenow.password")
+		if host == "" || user == "" || password == "" {
+			return nil, fmt.Errorf("--approval %v requires --servicenow-host, --servicenow-user and --servicenow-password", providerName)
+		}
+		exe := httpclnt.New("", "", "", "", user, os.ExpandEnv(password), host, "https", 443, false)
+		a.provider = approval.NewServiceNow(exe,
+			config.GetStringWithFallback(cmd, "change-request", "approval.changeRequest"),
+			config.GetStringSliceWithFallback(cmd, "servicenow-states", "approval.servicenow.states"))
+	default:
+		return nil, fmt.Errorf("invalid value for --approval = %v", providerName)
+	}
+	return a, nil
+}
+
+// approve returns an error if the deployment of the artifacts to the tenant is not approved
+func (a *deploymentApproval) approve(tenant string, artifacts []string) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	log.Info().Msgf("Requesting approval to deploy %d artifact(s) to %v", len(artifacts), tenant)
+	if err := a.provider.Approve(approval.Request{Command: a.command, Tenant: tenant, Artifacts: artifacts}); err != nil {
+		return fmt.Errorf("deployment not approved: %w", err)
+	}
+	return nil
+}
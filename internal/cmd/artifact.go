@@ -9,6 +9,7 @@ import (
 	"github.com/engswee/flashpipe/internal/config"
 	"github.com/engswee/flashpipe/internal/file"
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/source"
 	"github.com/engswee/flashpipe/internal/str"
 	"github.com/engswee/flashpipe/internal/sync"
 	"github.com/rs/zerolog/log"
@@ -22,6 +23,13 @@ func NewArtifactCommand() *cobra.Command {
 		Long: `Create or update artifacts on the
 SAP Integration Suite tenant.
 
+The contents of the artifact are read from the directory given by
+--dir-artifact, or fetched from a folder of a Git repository
+(git::<url>//<path>?ref=<ref>), a zip file at an HTTP(S) or S3 URL
+(optionally followed by //<path> for a folder within) or the artifact with
+the same ID on another tenant whose credentials are stored with login
+(tenant:<name>).
+
 Configuration:
   Settings can be loaded from the global config file (--config) under the
   'update.artifact' section. CLI flags override config file settings.`,
@@ -31,6 +39,9 @@ Configuration:
 			switch artifactType {
 			case "MessageMapping", "ScriptCollection", "Integration", "ValueMapping":
 			default:
+				if hint := api.UnsupportedArtifactTypeHint(artifactType); hint != "" {
+					return fmt.Errorf("unsupported value for --artifact-type = %v: %v", artifactType, hint)
+				}
 				return fmt.Errorf("invalid value for --artifact-type = %v", artifactType)
 			}
 			return nil
@@ -51,7 +62,7 @@ Configuration:
 	artifactCmd.Flags().String("artifact-name", "", "Name of artifact. Defaults to artifact-id value when not provided (config: update.artifact.artifactName)")
 	artifactCmd.Flags().String("package-id", "", "ID of Integration Package (config: update.artifact.packageId)")
 	artifactCmd.Flags().String("package-name", "", "Name of Integration Package. Defaults to package-id value when not provided (config: update.artifact.packageName)")
-	artifactCmd.Flags().String("dir-artifact", "", "Directory containing contents of designtime artifact (config: update.artifact.dirArtifact)")
+	artifactCmd.Flags().String("dir-artifact", "", "Directory containing contents of designtime artifact, or a source: git::<url>//<path>?ref=<ref>, URL of a zip file or tenant:<name> (config: update.artifact.dirArtifact)")
 	artifactCmd.Flags().String("file-param", "", "Use a different parameters.prop file instead of the default in src/main/resources/ (config: update.artifact.fileParam)")
 	artifactCmd.Flags().String("file-manifest", "", "Use a different MANIFEST.MF file instead of the default in META-INF/ (config: update.artifact.fileManifest)")
 	artifactCmd.Flags().String("dir-work", "/tmp", "Working directory for in-transit files (config: update.artifact.dirWork)")
@@ -80,7 +91,7 @@ func runUpdateArtifact(cmd *cobra.Command) error {
 		log.Info().Msgf("Using package ID %v as package name", packageId)
 		packageName = packageId
 	}
-	artifactDir, err := config.GetStringWithEnvExpandAndFallback(cmd, "dir-artifact", "update.artifact.dirArtifact")
+	artifactLocation, err := config.GetStringWithEnvExpandAndFallback(cmd, "dir-artifact", "update.artifact.dirArtifact")
 	if err != nil {
 		return fmt.Errorf("security alert for --dir-artifact: %w", err)
 	}
@@ -92,6 +103,17 @@ func runUpdateArtifact(cmd *cobra.Command) error {
 	}
 	scriptMap := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "script-collection-map", "update.artifact.scriptCollectionMap"))
 
+	// Fetch the artifact from Git, a zip file or another tenant
+	artifactSource, err := source.Parse(artifactLocation, storedTenantExecuter)
+	if err != nil {
+		return err
+	}
+	defer artifactSource.Close()
+	artifactDir, err := artifactSource.Artifact(artifactId, artifactType)
+	if err != nil {
+		return err
+	}
+
 	defaultParamFile := fmt.Sprintf("%v/src/main/resources/parameters.prop", artifactDir)
 	if parametersFile == "" {
 		parametersFile = defaultParamFile
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// artifactUsage is the package, deployment and references of a designtime artifact to be deleted
+type artifactUsage struct {
+	packageID    string
+	deployed     bool
+	referencedBy []string
+}
+
+func NewArtifactDeleteCommand() *cobra.Command {
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a designtime artifact",
+		Long: `Delete a designtime artifact on the tenant, e.g. to decommission it.
+
+Before the artifact is deleted, it is checked whether it is deployed and,
+for script collections, message mappings and value mappings, whether
+integration flows of its package reference it. The artifact is not
+deleted if a check fails, unless --force is set. With --undeploy, a
+deployed artifact is undeployed before it is deleted.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'artifact.delete' section. CLI flags override config file settings.`,
+		Example: `  # Delete an integration flow that is no longer deployed
+  flashpipe artifact delete --artifact-id Orders_Replicate
+
+  # Undeploy and delete it
+  flashpipe artifact delete --artifact-id Orders_Replicate --undeploy
+
+  # Delete a script collection even if flows still reference it
+  flashpipe artifact delete --artifact-id Common_Scripts --artifact-type ScriptCollection --force`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runArtifactDelete(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	deleteCmd.Flags().String("artifact-id", "", "ID of artifact (config: artifact.delete.artifactId)")
+	deleteCmd.Flags().String("artifact-type", "Integration", "Artifact type. Allowed values: Integration, MessageMapping, ScriptCollection, ValueMapping (config: artifact.delete.artifactType)")
+	deleteCmd.Flags().String("package-id", "", "ID of Integration Package of the artifact, looked up if not set (config: artifact.delete.packageId)")
+	deleteCmd.Flags().Bool("undeploy", false, "Undeploy the artifact before deleting it (config: artifact.delete.undeploy)")
+	deleteCmd.Flags().Bool("force", false, "Delete the artifact even if it is deployed or referenced by other artifacts (config: artifact.delete.force)")
+
+	return deleteCmd
+}
+
+func runArtifactDelete(cmd *cobra.Command) error {
+	artifactId := config.GetStringWithFallback(cmd, "artifact-id", "artifact.delete.artifactId")
+	artifactType := api.CanonicalArtifactType(config.GetStringWithFallback(cmd, "artifact-type", "artifact.delete.artifactType"))
+	packageId := config.GetStringWithFallback(cmd, "package-id", "artifact.delete.packageId")
+	undeploy := config.GetBoolWithFallback(cmd, "undeploy", "artifact.delete.undeploy")
+	force := config.GetBoolWithFallback(cmd, "force", "artifact.delete.force")
+
+	if artifactId == "" {
+		return fmt.Errorf("--artifact-id is required (set via CLI flag or in config file under 'artifact.delete.artifactId')")
+	}
+	if artifactType == "" {
+		value := config.GetStringWithFallback(cmd, "artifact-type", "artifact.delete.artifactType")
+		if hint := api.UnsupportedArtifactTypeHint(value); hint != "" {
+			return fmt.Errorf("unsupported value for --artifact-type = %v: %v", value, hint)
+		}
+		return fmt.Errorf("invalid value for --artifact-type = %v", value)
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	return deleteArtifact(exe, artifactId, artifactType, packageId, undeploy, force)
+}
+
+// deleteArtifact deletes the designtime artifact if it is not deployed, or undeploy is set, and not referenced by
+// other artifacts, or if force is set
+func deleteArtifact(exe *httpclnt.HTTPExecuter, artifactId string, artifactType string, packageId string, undeploy bool, force bool) error {
+	dt := api.NewDesigntimeArtifact(artifactType, exe)
+	_, _, exists, err := dt.Get(artifactId, "active")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%v designtime artifact %v does not exist", artifactType, artifactId)
+	}
+
+	usage, err := checkArtifactUsage(exe, artifactId, artifactType, packageId)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	if usage.deployed && !undeploy {
+		problems = append(problems, "it is deployed")
+	}
+	if len(usage.referencedBy) > 0 {
+		problems = append(problems, fmt.Sprintf("it is referenced by %v", strings.Join(usage.referencedBy, ", ")))
+	}
+	if len(problems) > 0 {
+		if !force {
+			return fmt.Errorf("%v is not deleted as %v, use --force to delete it anyway", artifactId, strings.Join(problems, " and "))
+		}
+		log.Warn().Msgf("⚠️  Deleting %v although %v", artifactId, strings.Join(problems, " and "))
+	}
+
+	if usage.deployed && undeploy {
+		if err := api.NewRuntime(exe).UnDeploy(artifactId); err != nil {
+			return err
+		}
+	}
+	if err := dt.Delete(artifactId); err != nil {
+		return err
+	}
+	log.Info().Msgf("🏆 %v designtime artifact %v deleted", artifactType, artifactId)
+	return nil
+}
+
+// checkArtifactUsage returns whether the artifact is deployed and, unless it is an integration flow, the integration
+// flows of its package that reference it in their model. The package is looked up if packageId is not set.
+func checkArtifactUsage(exe *httpclnt.HTTPExecuter, artifactId string, artifactType string, packageId string) (*artifactUsage, error) {
+	usage := &artifactUsage{packageID: packageId}
+	version, _, err := api.NewRuntime(exe).Get(artifactId)
+	if err != nil {
+		return nil, err
+	}
+	usage.deployed = version != "NOT_DEPLOYED"
+	if artifactType == "Integration" {
+		return usage, nil
+	}
+
+	ip := api.NewIntegrationPackage(exe)
+	if usage.packageID == "" {
+		if usage.packageID, err = findArtifactPackage(ip, artifactId, artifactType); err != nil {
+			return nil, err
+		}
+	}
+	flows, err := ip.GetArtifactsData(usage.packageID, "Integration")
+	if err != nil {
+		return nil, err
+	}
+	if len(flows) == 0 {
+		return usage, nil
+	}
+
+	workDir, err := os.MkdirTemp("", "flashpipe-delete-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	dt := api.NewIntegration(exe)
+	for _, flow := range flows {
+		zipFile := filepath.Join(workDir, flow.Id+".zip")
+		if err := dt.Download(zipFile, flow.Id); err != nil {
+			return nil, err
+		}
+		refs, err := referencedArtifacts(zipFile, []string{artifactId})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read content of %s: %w", flow.Id, err)
+		}
+		if len(refs) > 0 {
+			usage.referencedBy = append(usage.referencedBy, flow.Id)
+		}
+	}
+	return usage, nil
+}
+
+// findArtifactPackage returns the ID of the package that contains the artifact
+func findArtifactPackage(ip *api.IntegrationPackage, artifactId string, artifactType string) (string, error) {
+	packageIds, err := ip.GetPackagesList()
+	if err != nil {
+		return "", err
+	}
+	for _, packageId := range packageIds {
+		artifacts, err := ip.GetArtifactsData(packageId, artifactType)
+		if err != nil {
+			return "", err
+		}
+		if api.FindArtifactById(artifactId, artifacts) != nil {
+			return packageId, nil
+		}
+	}
+	return "", fmt.Errorf("package of %v designtime artifact %v not found", artifactType, artifactId)
+}
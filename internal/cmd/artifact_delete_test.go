@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteArtifactMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, Flow is deployed and references the script collection
+	var deleted []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+			if r.URL.Path == "/api/v1/IntegrationRuntimeArtifacts('Flow')" {
+				w.WriteHeader(http.StatusAccepted)
+			}
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case "/api/v1/ScriptCollectionDesigntimeArtifacts(Id='Scripts',Version='active')",
+			"/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')":
+			w.Write([]byte(`{"d": {"Version": "1.0.0"}}`))
+		case "/api/v1/IntegrationRuntimeArtifacts('Flow')":
+			w.Write([]byte(`{"d": {"Version": "1.0.0", "Status": "STARTED"}}`))
+		case "/api/v1/IntegrationPackages":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Other"}, {"Id": "Common"}]}}`))
+		case "/api/v1/IntegrationPackages('Common')/ScriptCollectionDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Scripts", "Version": "1.0.0"}]}}`))
+		case "/api/v1/IntegrationPackages('Common')/IntegrationDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Flow", "Version": "1.0.0"}]}}`))
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$value":
+			zw := zip.NewWriter(w)
+			f, _ := zw.Create("src/main/resources/scenarioflows/integrationflow/Flow.iflw")
+			f.Write([]byte("<key>scriptBundleId</key><value>Scripts</value>"))
+			zw.Close()
+		case "/api/v1/IntegrationPackages('Other')/ScriptCollectionDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": []}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	err := deleteArtifact(exe, "Scripts", "ScriptCollection", "", false, false)
+	assert.EqualError(t, err, "Scripts is not deleted as it is referenced by Flow, use --force to delete it anyway")
+	assert.Empty(t, deleted)
+
+	assert.NoError(t, deleteArtifact(exe, "Scripts", "ScriptCollection", "", false, true))
+	assert.Equal(t, []string{"/api/v1/ScriptCollectionDesigntimeArtifacts(Id='Scripts',Version='active')"}, deleted)
+
+	deleted = nil
+	err = deleteArtifact(exe, "Flow", "Integration", "", false, false)
+	assert.EqualError(t, err, "Flow is not deleted as it is deployed, use --force to delete it anyway")
+
+	assert.NoError(t, deleteArtifact(exe, "Flow", "Integration", "", true, false))
+	assert.Equal(t, []string{
+		"/api/v1/IntegrationRuntimeArtifacts('Flow')",
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')",
+	}, deleted, "Flow should be undeployed before it is deleted")
+
+	assert.EqualError(t, deleteArtifact(exe, "Missing", "Integration", "", false, false), "Integration designtime artifact Missing does not exist")
+}
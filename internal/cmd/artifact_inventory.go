@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewArtifactInventoryCommand() *cobra.Command {
+
+	inventoryCmd := &cobra.Command{
+		Use:          "inventory",
+		Short:        "List the dependencies of artifacts",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `List the adapters, script collections, mappings, JAR resources and
+credential aliases used by each artifact, by reading the designtime content
+of the artifacts in --dir, e.g. for security reviews.
+
+Artifacts are grouped into packages by the directory that contains the
+artifact directories, as written by snapshot. Externalized credential
+aliases are resolved with the values in parameters.prop.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'artifact.inventory' section. CLI flags override config file settings.`,
+		Example: `  # List the dependencies of the artifacts of a snapshot
+  flashpipe artifact inventory --dir ./snapshot
+
+  # Export for a security review
+  flashpipe artifact inventory --dir ./snapshot --output-format csv --output-file inventory.csv`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runArtifactInventory(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	inventoryCmd.Flags().String("dir", "", "Directory of artifacts grouped into packages, or directory of artifacts of one package (config: artifact.inventory.dir)")
+	inventoryCmd.Flags().String("output-format", "text", "Output format. Allowed values: text, json, csv (config: artifact.inventory.outputFormat)")
+	inventoryCmd.Flags().String("output-file", "", "Write output to file instead of stdout (config: artifact.inventory.outputFile)")
+
+	return inventoryCmd
+}
+
+func runArtifactInventory(cmd *cobra.Command) error {
+	dir := config.GetStringWithFallback(cmd, "dir", "artifact.inventory.dir")
+	format := config.GetStringWithFallback(cmd, "output-format", "artifact.inventory.outputFormat")
+	outputFile := config.GetStringWithFallback(cmd, "output-file", "artifact.inventory.outputFile")
+
+	if dir == "" {
+		return fmt.Errorf("--dir is required (set via CLI flag or in config file under 'artifact.inventory.dir')")
+	}
+	switch format {
+	case "text", "json", "csv":
+	default:
+		return fmt.Errorf("invalid value for --output-format = %v", format)
+	}
+
+	packages, err := designtime.Inventory(dir)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err = writeInventory(out, packages, format); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		log.Info().Msgf("Inventory of %d package(s) written to %v", len(packages), outputFile)
+	}
+	return nil
+}
+
+func writeInventory(out io.Writer, packages []*designtime.PackageInventory, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(packages)
+	case "csv":
+		w := csv.NewWriter(out)
+		_ = w.Write([]string{"Package", "Artifact", "Name", "Version", "Type", "Kind", "Dependency", "Detail", "Channel"})
+		for _, pkg := range packages {
+			for _, a := range pkg.Artifacts {
+				dependencies := a.Dependencies()
+				// Artifacts without dependencies are listed with empty dependency columns
+				if len(dependencies) == 0 {
+					dependencies = []designtime.Dependency{{}}
+				}
+				for _, d := range dependencies {
+					_ = w.Write([]string{pkg.ID, a.ID, a.Name, a.Version, a.Type, d.Kind, d.Name, d.Detail, d.Channel})
+				}
+			}
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PACKAGE\tARTIFACT\tVERSION\tKIND\tDEPENDENCY\tDETAIL")
+		for _, pkg := range packages {
+			for _, a := range pkg.Artifacts {
+				for _, d := range a.Dependencies() {
+					fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", pkg.ID, a.ID, a.Version, d.Kind, d.Name, d.Detail)
+				}
+			}
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewArtifactGroupCommand() *cobra.Command {
+
+	artifactCmd := &cobra.Command{
+		Use:   "artifact",
+		Short: "Inspect and delete designtime artifacts",
+		Long: `Check the content of designtime artifacts in the local repository and
+list their dependencies, without connecting to a tenant, or delete
+designtime artifacts on the tenant.`,
+	}
+	return artifactCmd
+}
+
+func NewArtifactValidateCommand() *cobra.Command {
+
+	validateCmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate the content of artifacts before upload",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Validate the content of designtime artifacts before they are uploaded, to
+catch broken artifacts, e.g. after a merge, without a round-trip to the
+tenant.
+
+--dir is a directory of artifacts as written by sync, the directory of a
+single artifact, or an artifact zip file. The following is checked:
+  structure         META-INF/MANIFEST.MF and one integration flow model exist
+  manifest          Required headers are set and Bundle-Version is major.minor.patch
+  bpmn              The model is well-formed BPMN whose sequence flows connect existing elements
+  missing-resource  Scripts and mappings referenced by steps exist in the artifact
+  merge-conflict    No Git conflict markers are left in files
+
+With --groovy-checks, the lines of Groovy scripts are checked against
+built-in rules for System.exit, starting processes, hard-coded credentials,
+Thread.sleep and console output. A rules file changes the severity (off,
+warning, error), pattern and message of built-in rules and adds custom
+rules. With --output sarif, the findings are written as a SARIF log that
+GitHub code scanning can display.
+
+The command fails if a finding has severity error.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'artifact.validate' section. CLI flags override config file settings.`,
+		Example: `  # Validate all artifacts synced to Git
+  flashpipe artifact validate --dir ./src
+
+  # Validate a downloaded artifact, with JSON output
+  flashpipe artifact validate --dir Orders_Replicate.zip --output json
+
+  # Check Groovy scripts with custom rules, for GitHub code scanning
+  flashpipe artifact validate --dir ./src --groovy-rules groovy-rules.yml --output sarif > validate.sarif`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runArtifactValidate(cmd, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	validateCmd.Flags().String("dir", "", "Directory of artifacts, directory of an artifact or artifact zip file (config: artifact.validate.dir)")
+	validateCmd.Flags().String("output", "text", "Output format: text, json or sarif (config: artifact.validate.output)")
+	validateCmd.Flags().Bool("groovy-checks", false, "Check Groovy scripts against the built-in rules (config: artifact.validate.groovyChecks)")
+	validateCmd.Flags().String("groovy-rules", "", "Rules file with settings of built-in Groovy rules and custom rules, implies --groovy-checks (config: artifact.validate.groovyRules)")
+
+	return validateCmd
+}
+
+func runArtifactValidate(cmd *cobra.Command, out io.Writer) error {
+	dir := config.GetStringWithFallback(cmd, "dir", "artifact.validate.dir")
+	output := config.GetStringWithFallback(cmd, "output", "artifact.validate.output")
+	groovyChecks := config.GetBoolWithFallback(cmd, "groovy-checks", "artifact.validate.groovyChecks")
+	groovyRules := config.GetStringWithFallback(cmd, "groovy-rules", "artifact.validate.groovyRules")
+
+	if dir == "" {
+		return fmt.Errorf("--dir is required (set via CLI flag or in config file under 'artifact.validate.dir')")
+	}
+	if output != "text" && output != "json" && output != "sarif" {
+		return fmt.Errorf("invalid --output %q, must be text, json or sarif", output)
+	}
+
+	var opts designtime.Options
+	if groovyChecks || groovyRules != "" {
+		rules, err := designtime.LoadGroovyRules(groovyRules)
+		if err != nil {
+			return err
+		}
+		opts.GroovyRules = rules
+	}
+	findings, err := designtime.Validate(dir, opts)
+	if err != nil {
+		return err
+	}
+	switch output {
+	case "sarif":
+		if err := designtime.WriteSARIF(out, findings, nil); err != nil {
+			return err
+		}
+	case "json":
+		if findings == nil {
+			findings = []designtime.Finding{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(findings); err != nil {
+			return err
+		}
+	default:
+		for _, f := range findings {
+			fmt.Fprintln(out, f)
+		}
+	}
+
+	if designtime.HasErrors(findings) {
+		return fmt.Errorf("validation of %s failed, see findings with severity error", dir)
+	}
+	log.Info().Msgf("Validation of %s passed with %d warning(s)", dir, len(findings))
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/audit"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewAuditCommand() *cobra.Command {
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit log of modifying API calls",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Inspect the audit log written with --audit-log. Each line of the audit
+log is a JSON entry with the time, user or OAuth client, method, URL,
+artifact and outcome of a modifying API call.`,
+	}
+	return auditCmd
+}
+
+func NewAuditVerifyCommand() *cobra.Command {
+
+	verifyCmd := &cobra.Command{
+		Use:          "verify [file]",
+		Short:        "Verify the hash chain of an audit log",
+		SilenceUsage: true,
+		Long: `Verify the hash chain of an audit log written with --audit-hash-chain.
+The command fails if an entry was altered, or entries were removed or
+reordered. The file defaults to --audit-log.`,
+		Example: `  # Verify the audit log of the pipeline
+  flashpipe audit verify /var/log/flashpipe/audit.jsonl`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runAuditVerify(cmd, args)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+	return verifyCmd
+}
+
+func runAuditVerify(cmd *cobra.Command, args []string) error {
+	file := config.GetStringWithFallback(cmd, "audit-log", "audit.file")
+	if len(args) > 0 {
+		file = args[0]
+	}
+	if file == "" {
+		return fmt.Errorf("no audit log, set the file or --audit-log")
+	}
+	n, err := audit.Verify(file)
+	if err != nil {
+		return fmt.Errorf("audit log %s is invalid: %w", file, err)
+	}
+	log.Info().Msgf("Audit log %s with %d entries verified", file, n)
+	return nil
+}
@@ -0,0 +1,379 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// CI/CD providers of ci scaffold
+const (
+	ciProviderGitHub = "github"
+	ciProviderGitLab = "gitlab"
+	ciProviderAzDO   = "azdo"
+)
+
+// ciPipelineFiles are the default files of the pipeline definitions of the providers
+var ciPipelineFiles = map[string]string{
+	ciProviderGitHub: ".github/workflows/flashpipe.yml",
+	ciProviderGitLab: ".gitlab-ci.yml",
+	ciProviderAzDO:   "azure-pipelines.yml",
+}
+
+// ciScaffold are the settings the pipeline definition is rendered with
+type ciScaffold struct {
+	Image        string
+	ConfigPath   string
+	DirArtifacts string // Artifacts checked with artifact validate, skipped if empty
+	Branch       string
+	HistoryDir   string // Directory of the history database, cached between runs
+}
+
+// Templates of the pipeline definitions use [[ ]] as delimiters, as the providers use {{ }} and ${{ }}
+var ciPipelineTemplates = map[string]string{
+	ciProviderGitHub: `# Generated by flashpipe ci scaffold
+# Secrets: CPI_HOST, CPI_OAUTH_HOST, CPI_CLIENT_ID, CPI_CLIENT_SECRET
+name: FlashPipe
+
+on:
+  pull_request:
+    branches:
+      - [[ .Branch ]]
+  push:
+    branches:
+      - [[ .Branch ]]
+
+env:
+  FLASHPIPE_TMN_HOST: ${{ secrets.CPI_HOST }}
+  FLASHPIPE_OAUTH_HOST: ${{ secrets.CPI_OAUTH_HOST }}
+  FLASHPIPE_OAUTH_CLIENTID: ${{ secrets.CPI_CLIENT_ID }}
+  FLASHPIPE_OAUTH_CLIENTSECRET: ${{ secrets.CPI_CLIENT_SECRET }}
+  FLASHPIPE_RUN_ID: github-${{ github.run_id }}-${{ github.run_attempt }}
+
+jobs:
+  validate:
+    if: github.event_name == 'pull_request'
+    runs-on: ubuntu-latest
+    container: [[ .Image ]]
+    permissions:
+      contents: read
+      security-events: write
+    steps:
+      - uses: actions/checkout@v4
+      - name: Lint configuration
+        run: flashpipe lint --config-path [[ .ConfigPath ]]
+[[- if .DirArtifacts ]]
+      - name: Validate artifacts
+        run: flashpipe artifact validate --dir [[ .DirArtifacts ]] --output sarif > validate.sarif
+      - name: Upload findings
+        if: always()
+        uses: github/codeql-action/upload-sarif@v3
+        with:
+          sarif_file: validate.sarif
+[[- end ]]
+      - name: Preview changes
+        run: flashpipe configure --config-path [[ .ConfigPath ]] --dry-run --report-file flashpipe-report.json
+      - name: Upload report
+        if: always()
+        uses: actions/upload-artifact@v4
+        with:
+          name: flashpipe-preview
+          path: flashpipe-report.json
+          if-no-files-found: ignore
+
+  deploy:
+    if: github.event_name == 'push'
+    runs-on: ubuntu-latest
+    container: [[ .Image ]]
+    concurrency: flashpipe-deploy
+    steps:
+      - uses: actions/checkout@v4
+      - name: Restore run history
+        uses: actions/cache@v4
+        with:
+          path: [[ .HistoryDir ]]
+          key: flashpipe-history-${{ github.run_id }}
+          restore-keys: flashpipe-history-
+      - name: Apply configuration
+        run: >-
+          flashpipe configure --config-path [[ .ConfigPath ]] --exit-report
+          --report-file flashpipe-report.json
+          --changed-artifacts-file changed-artifacts.txt
+          --history-db [[ .HistoryDir ]]/history.db
+      - name: Upload report
+        if: always()
+        uses: actions/upload-artifact@v4
+        with:
+          name: flashpipe-report
+          path: |
+            flashpipe-report.json
+            changed-artifacts.txt
+          if-no-files-found: ignore
+`,
+	ciProviderGitLab: `# Generated by flashpipe ci scaffold
+# CI/CD variables: CPI_HOST, CPI_OAUTH_HOST, CPI_CLIENT_ID, CPI_CLIENT_SECRET (masked)
+variables:
+  FLASHPIPE_TMN_HOST: $CPI_HOST
+  FLASHPIPE_OAUTH_HOST: $CPI_OAUTH_HOST
+  FLASHPIPE_OAUTH_CLIENTID: $CPI_CLIENT_ID
+  FLASHPIPE_OAUTH_CLIENTSECRET: $CPI_CLIENT_SECRET
+  FLASHPIPE_RUN_ID: gitlab-$CI_PIPELINE_ID-$CI_JOB_ID
+
+default:
+  image:
+    name: [[ .Image ]]
+    entrypoint: [""]
+
+stages:
+  - validate
+  - deploy
+
+flashpipe-validate:
+  stage: validate
+  rules:
+    - if: $CI_PIPELINE_SOURCE == "merge_request_event"
+  script:
+    - flashpipe lint --config-path [[ .ConfigPath ]]
+[[- if .DirArtifacts ]]
+    - flashpipe artifact validate --dir [[ .DirArtifacts ]]
+[[- end ]]
+    - flashpipe configure --config-path [[ .ConfigPath ]] --dry-run --report-file flashpipe-report.json
+  artifacts:
+    when: always
+    paths:
+      - flashpipe-report.json
+
+flashpipe-deploy:
+  stage: deploy
+  rules:
+    - if: $CI_COMMIT_BRANCH == "[[ .Branch ]]"
+  resource_group: flashpipe
+  cache:
+    key: flashpipe-history
+    paths:
+      - [[ .HistoryDir ]]/
+  script:
+    - >-
+      flashpipe configure --config-path [[ .ConfigPath ]] --exit-report
+      --report-file flashpipe-report.json
+      --changed-artifacts-file changed-artifacts.txt
+      --history-db [[ .HistoryDir ]]/history.db
+  artifacts:
+    when: always
+    paths:
+      - flashpipe-report.json
+      - changed-artifacts.txt
+`,
+	ciProviderAzDO: `# Generated by flashpipe ci scaffold
+# Variable group flashpipe: CPI_HOST, CPI_OAUTH_HOST, CPI_CLIENT_ID, CPI_CLIENT_SECRET (secret)
+trigger:
+  branches:
+    include:
+      - [[ .Branch ]]
+
+pr:
+  branches:
+    include:
+      - [[ .Branch ]]
+
+pool:
+  vmImage: ubuntu-latest
+
+variables:
+  - group: flashpipe
+
+resources:
+  containers:
+    - container: flashpipe
+      image: [[ .Image ]]
+
+jobs:
+  - job: validate
+    condition: eq(variables['Build.Reason'], 'PullRequest')
+    container: flashpipe
+    steps:
+      - checkout: self
+      - bash: flashpipe lint --config-path [[ .ConfigPath ]]
+        displayName: Lint configuration
+[[- if .DirArtifacts ]]
+      - bash: flashpipe artifact validate --dir [[ .DirArtifacts ]]
+        displayName: Validate artifacts
+[[- end ]]
+      - bash: flashpipe configure --config-path [[ .ConfigPath ]] --dry-run --report-file $(Build.ArtifactStagingDirectory)/flashpipe-report.json
+        displayName: Preview changes
+        env:
+          FLASHPIPE_TMN_HOST: $(CPI_HOST)
+          FLASHPIPE_OAUTH_HOST: $(CPI_OAUTH_HOST)
+          FLASHPIPE_OAUTH_CLIENTID: $(CPI_CLIENT_ID)
+          FLASHPIPE_OAUTH_CLIENTSECRET: $(CPI_CLIENT_SECRET)
+          FLASHPIPE_RUN_ID: azdo-$(Build.BuildId)-$(System.JobAttempt)
+      - publish: $(Build.ArtifactStagingDirectory)
+        artifact: flashpipe-preview
+        condition: always()
+
+  - job: deploy
+    condition: and(succeeded(), ne(variables['Build.Reason'], 'PullRequest'))
+    container: flashpipe
+    steps:
+      - checkout: self
+      - task: Cache@2
+        displayName: Restore run history
+        inputs:
+          key: flashpipe-history | $(Build.BuildId)
+          restoreKeys: flashpipe-history
+          path: [[ .HistoryDir ]]
+      - bash: >-
+          flashpipe configure --config-path [[ .ConfigPath ]] --exit-report
+          --report-file $(Build.ArtifactStagingDirectory)/flashpipe-report.json
+          --changed-artifacts-file $(Build.ArtifactStagingDirectory)/changed-artifacts.txt
+          --history-db [[ .HistoryDir ]]/history.db
+        displayName: Apply configuration
+        env:
+          FLASHPIPE_TMN_HOST: $(CPI_HOST)
+          FLASHPIPE_OAUTH_HOST: $(CPI_OAUTH_HOST)
+          FLASHPIPE_OAUTH_CLIENTID: $(CPI_CLIENT_ID)
+          FLASHPIPE_OAUTH_CLIENTSECRET: $(CPI_CLIENT_SECRET)
+          FLASHPIPE_RUN_ID: azdo-$(Build.BuildId)-$(System.JobAttempt)
+      - publish: $(Build.ArtifactStagingDirectory)
+        artifact: flashpipe-report
+        condition: always()
+`,
+}
+
+func NewCiCommand() *cobra.Command {
+
+	ciCmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Set up CI/CD pipelines",
+		Long: `Set up CI/CD pipelines that run FlashPipe, without connecting to a
+tenant.`,
+	}
+	return ciCmd
+}
+
+func NewCiScaffoldCommand() *cobra.Command {
+
+	scaffoldCmd := &cobra.Command{
+		Use:          "scaffold",
+		Short:        "Generate a pipeline definition for a CI/CD provider",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Generate a ready-to-use pipeline definition that previews and applies the
+configuration of configure, for GitHub Actions (github), GitLab CI/CD
+(gitlab) or Azure Pipelines (azdo).
+
+Pull and merge requests lint the configuration, validate the artifacts of
+--dir-artifacts and preview the changes with configure --dry-run. Commits to
+--branch apply the configuration. Both upload the report of --report-file,
+and the run history of --history-db is cached between runs. Findings of
+artifact validate are uploaded to GitHub code scanning.
+
+The tenant details are read from the secrets or variables CPI_HOST,
+CPI_OAUTH_HOST, CPI_CLIENT_ID and CPI_CLIENT_SECRET of the provider. The
+pipeline runs in the FlashPipe container image of --image.
+
+The definition is written to the default file of the provider, e.g.
+.github/workflows/flashpipe.yml, unless --output is set, - for stdout.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'ci.scaffold' section. CLI flags override config file settings.`,
+		Example: `  # GitHub Actions workflow for the configuration in ./config
+  flashpipe ci scaffold --provider github --config-path ./config --dir-artifacts ./packages
+
+  # Print a GitLab pipeline that applies the configuration on commits to release
+  flashpipe ci scaffold --provider gitlab --branch release --output -`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runCiScaffold(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	scaffoldCmd.Flags().String("provider", "", "CI/CD provider: github, gitlab or azdo (config: ci.scaffold.provider)")
+	scaffoldCmd.Flags().String("config-path", "./config", "Path of the configuration file or folder of configure (config: ci.scaffold.configPath)")
+	scaffoldCmd.Flags().String("dir-artifacts", "", "Directory of the artifacts validated in pull requests, not validated if not set (config: ci.scaffold.dirArtifacts)")
+	scaffoldCmd.Flags().String("branch", "main", "Branch whose commits apply the configuration (config: ci.scaffold.branch)")
+	scaffoldCmd.Flags().String("image", "", "Container image the pipeline runs in, defaults to the image of this FlashPipe version (config: ci.scaffold.image)")
+	scaffoldCmd.Flags().String("output", "", "File to write the pipeline definition to, - for stdout, defaults to the file of the provider (config: ci.scaffold.output)")
+	scaffoldCmd.Flags().Bool("force", false, "Overwrite an existing file (config: ci.scaffold.force)")
+
+	return scaffoldCmd
+}
+
+func runCiScaffold(cmd *cobra.Command) error {
+	log.Info().Msg("Executing ci scaffold command")
+
+	provider := config.GetStringWithFallback(cmd, "provider", "ci.scaffold.provider")
+	output := config.GetStringWithFallback(cmd, "output", "ci.scaffold.output")
+	force := config.GetBoolWithFallback(cmd, "force", "ci.scaffold.force")
+	scaffold := ciScaffold{
+		Image:        config.GetStringWithFallback(cmd, "image", "ci.scaffold.image"),
+		ConfigPath:   config.GetStringWithFallback(cmd, "config-path", "ci.scaffold.configPath"),
+		DirArtifacts: config.GetStringWithFallback(cmd, "dir-artifacts", "ci.scaffold.dirArtifacts"),
+		Branch:       config.GetStringWithFallback(cmd, "branch", "ci.scaffold.branch"),
+		HistoryDir:   ".flashpipe",
+	}
+	if scaffold.Image == "" {
+		scaffold.Image = "engswee/flashpipe:" + ciImageTag(cmd.Root().Version)
+	}
+
+	data, err := renderCiPipeline(provider, scaffold)
+	if err != nil {
+		return err
+	}
+	if output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if output == "" {
+		output = ciPipelineFiles[provider]
+	}
+	if err = writeInitFile(output, data, force); err != nil {
+		return err
+	}
+	log.Info().Msgf("Pipeline definition for %s written to %s", provider, output)
+	return nil
+}
+
+// renderCiPipeline returns the pipeline definition of the provider
+func renderCiPipeline(provider string, scaffold ciScaffold) ([]byte, error) {
+	text, found := ciPipelineTemplates[provider]
+	if !found {
+		providers := make([]string, 0, len(ciPipelineTemplates))
+		for name := range ciPipelineTemplates {
+			providers = append(providers, name)
+		}
+		slices.Sort(providers)
+		return nil, fmt.Errorf("invalid value for --provider = %q (valid values: %s)", provider, strings.Join(providers, ", "))
+	}
+	tmpl, err := template.New(provider).Delims("[[", "]]").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err = tmpl.Execute(&b, scaffold); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ciImageTag returns the tag of the container image of a FlashPipe version, latest for development builds
+func ciImageTag(version string) string {
+	if version == "" || strings.Contains(version, "dev") {
+		return "latest"
+	}
+	return version
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderCiPipeline(t *testing.T) {
+	scaffold := ciScaffold{Image: "engswee/flashpipe:3.7.0", ConfigPath: "./config", DirArtifacts: "./packages", Branch: "release", HistoryDir: ".flashpipe"}
+	for provider := range ciPipelineTemplates {
+		data, err := renderCiPipeline(provider, scaffold)
+		require.NoError(t, err, provider)
+		var pipeline map[string]any
+		require.NoError(t, yaml.Unmarshal(data, &pipeline), "%s pipeline should be valid YAML", provider)
+		text := string(data)
+		assert.Contains(t, text, "engswee/flashpipe:3.7.0", provider)
+		assert.Contains(t, text, "flashpipe configure --config-path ./config --dry-run", provider)
+		assert.Contains(t, text, "flashpipe artifact validate --dir ./packages", provider)
+		assert.Contains(t, text, "--history-db .flashpipe/history.db", provider)
+		assert.Contains(t, text, "release", provider)
+	}
+
+	// Artifacts are only validated with --dir-artifacts
+	data, err := renderCiPipeline(ciProviderGitHub, ciScaffold{Image: "engswee/flashpipe:latest", ConfigPath: "./config", Branch: "main", HistoryDir: ".flashpipe"})
+	require.NoError(t, err)
+	var workflow struct {
+		Jobs map[string]struct {
+			Steps []map[string]any `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &workflow))
+	assert.Len(t, workflow.Jobs["validate"].Steps, 4)
+	assert.NotContains(t, string(data), "upload-sarif")
+	assert.Contains(t, string(data), "${{ secrets.CPI_CLIENT_SECRET }}", "Expressions of the provider should be kept")
+
+	_, err = renderCiPipeline("jenkins", scaffold)
+	assert.EqualError(t, err, `invalid value for --provider = "jenkins" (valid values: azdo, github, gitlab)`)
+}
+
+func TestCiScaffold(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "ci", "flashpipe.yml")
+	cmd := NewCiScaffoldCommand()
+	require.NoError(t, cmd.ParseFlags([]string{"--provider", "gitlab", "--output", output}))
+	require.NoError(t, runCiScaffold(cmd))
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "name: engswee/flashpipe:latest", "Commands without version should use the latest image")
+
+	assert.ErrorContains(t, runCiScaffold(cmd), "already exists", "Existing files should only be overwritten with --force")
+	require.NoError(t, cmd.Flags().Set("force", "true"))
+	assert.NoError(t, runCiScaffold(cmd))
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/engswee/flashpipe/internal/source"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Parts of a tenant copied by clone
+const (
+	ClonePackages             = "packages"
+	CloneConfiguration        = "configuration"
+	CloneSecurityPlaceholders = "security-placeholders"
+)
+
+var cloneParts = []string{ClonePackages, CloneConfiguration, CloneSecurityPlaceholders}
+
+func NewCloneCommand() *cobra.Command {
+
+	cloneCmd := &cobra.Command{
+		Use:          "clone",
+		Short:        "Refresh a tenant with the content of another tenant",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Refresh a tenant, e.g. a sandbox, with the content of another tenant, e.g.
+QA, in one run. The parts copied are selected with --include:
+
+  packages               the editable integration packages and their
+                         artifacts, downloaded like snapshot and uploaded
+                         like restore (artifacts in draft are skipped)
+  configuration          the configured values of the parameters of the
+                         integration flows that exist on both tenants
+  security-placeholders  placeholder user credentials for the credential
+                         names used by the artifacts that are missing on
+                         the target, like credentials check
+                         --create-placeholders
+
+Both tenants are referenced by the names their credentials are stored under
+with login. Without --target, the tenant of the global flags is the target.
+Packages and artifacts that hold customer data, e.g. value mappings of
+customer numbers, are not copied if their IDs are listed in --exclude.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'clone' section. CLI flags override config file settings.`,
+		Example: `  # Refresh the sandbox from QA
+  flashpipe clone --source qa --target sandbox
+
+  # Only copy the configuration, excluding the artifacts listed in a file
+  flashpipe clone --source qa --target sandbox --include configuration --exclude @customer-data.txt
+
+  # Show the packages and artifacts that would be copied
+  flashpipe clone --source qa --target sandbox --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runClone(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Note: These can be set in config file under 'clone' key
+	cloneCmd.Flags().String("source", "", "Name of the tenant to copy from, as stored with login (config: clone.source)")
+	cloneCmd.Flags().String("target", "", "Name of the tenant to copy to, as stored with login, defaults to the tenant of the global flags (config: clone.target)")
+	cloneCmd.Flags().StringSlice("include", cloneParts, "Comma separated list of the parts to copy: packages, configuration, security-placeholders (config: clone.include)")
+	cloneCmd.Flags().String("exclude", "", "Comma-separated list of package and artifact IDs not to copy, e.g. holding customer data, @<file> or - (stdin) for one per line (config: clone.exclude)")
+	cloneCmd.Flags().Bool("dry-run", false, "Show the packages and artifacts that would be copied without changing the target (config: clone.dryRun)")
+
+	return cloneCmd
+}
+
+func runClone(cmd *cobra.Command) error {
+	sourceName := config.GetStringWithFallback(cmd, "source", "clone.source")
+	targetName := config.GetStringWithFallback(cmd, "target", "clone.target")
+	include := config.GetStringSliceWithFallback(cmd, "include", "clone.include")
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "clone.dryRun")
+
+	if sourceName == "" {
+		return fmt.Errorf("--source is required (set via CLI flag or in config file under 'clone.source')")
+	}
+	if sourceName == targetName {
+		return fmt.Errorf("--source and --target must be different tenants")
+	}
+	for _, part := range include {
+		if !slices.Contains(cloneParts, part) {
+			return fmt.Errorf("invalid value for --include = %v, allowed values: %v", part, strings.Join(cloneParts, ", "))
+		}
+	}
+	exclude, err := resolveFilter("--exclude", config.GetStringWithFallback(cmd, "exclude", "clone.exclude"))
+	if err != nil {
+		return err
+	}
+	excludedIds := parseFilter(exclude)
+
+	var targetDetails *api.ServiceDetails
+	if targetName != "" {
+		if targetDetails, err = storedServiceDetails(targetName); err != nil {
+			return err
+		}
+	} else {
+		if targetDetails = api.GetServiceDetails(cmd); targetDetails.Host == "" {
+			return fmt.Errorf("--target or the tenant flags (--tmn-host) are required")
+		}
+		targetName = targetDetails.Host
+	}
+	sourceExe, err := storedTenantExecuter(sourceName)
+	if err != nil {
+		return err
+	}
+	if sourceExe.Host() == targetDetails.Host {
+		return fmt.Errorf("source %v and target %v are the same tenant %v", sourceName, targetName, targetDetails.Host)
+	}
+
+	logger.Rule(logger.SeparatorDashed)
+	log.Info().Msgf("📢 Begin cloning %v from %v to %v", strings.Join(include, ", "), sourceName, targetName)
+
+	// The content of the source is needed for all parts, to know the artifacts and their credentials
+	content := source.NewTenant(sourceName, sourceExe)
+	defer content.Close()
+	if err := cloneTenant(content, sourceExe, targetDetails, api.InitHTTPExecuter(targetDetails), targetName, include, excludedIds, dryRun); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	logger.Rule(logger.SeparatorDashed)
+	log.Info().Msgf("🏆 Completed cloning %v to %v", sourceName, targetName)
+	return nil
+}
+
+// cloneTenant copies the included parts of the packages of content to the target. The packages are restored to
+// the tenant of targetDetails, the other parts are copied with targetExe. With dryRun, the packages and artifacts
+// are only listed and the target is not accessed.
+func cloneTenant(content source.ArtifactSource, sourceExe *httpclnt.HTTPExecuter, targetDetails *api.ServiceDetails, targetExe *httpclnt.HTTPExecuter,
+	targetName string, include []string, excludedIds []string, dryRun bool) error {
+	packagesDir, err := content.Packages(nil, excludedIds)
+	if err != nil {
+		return err
+	}
+	if err := removeExcludedArtifacts(packagesDir, excludedIds); err != nil {
+		return err
+	}
+	packages, err := designtime.Inventory(packagesDir)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		var ids []string
+		for _, a := range pkg.Artifacts {
+			ids = append(ids, a.ID)
+		}
+		log.Info().Msgf("Package %v: %v", pkg.ID, strings.Join(ids, ", "))
+	}
+	if dryRun {
+		log.Info().Msgf("Dry run: %d package(s) would be cloned to %v", len(packages), targetName)
+		return nil
+	}
+
+	if slices.Contains(include, ClonePackages) {
+		workDir, err := os.MkdirTemp("", "flashpipe-clone-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(workDir)
+		if err := restoreSnapshot(targetDetails, packagesDir, workDir, nil, nil, nil); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(include, CloneConfiguration) {
+		if err := cloneConfiguration(sourceExe, targetExe, packages); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(include, CloneSecurityPlaceholders) {
+		logger.Rule(logger.SeparatorDashed)
+		log.Info().Msg("Checking the credentials used by the artifacts on the target")
+		if err := checkCredentials(targetExe, packagesDir, true, os.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeExcludedArtifacts removes the directories of the excluded artifacts from the packages in dir, as
+// written by snapshot
+func removeExcludedArtifacts(dir string, excludedIds []string) error {
+	if len(excludedIds) == 0 {
+		return nil
+	}
+	packageDirs, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packageDirs {
+		if !pkg.IsDir() {
+			continue
+		}
+		artifactDirs, err := os.ReadDir(filepath.Join(dir, pkg.Name()))
+		if err != nil {
+			return err
+		}
+		for _, artifact := range artifactDirs {
+			if !artifact.IsDir() || !slices.Contains(excludedIds, artifact.Name()) {
+				continue
+			}
+			log.Info().Msgf("Skipping artifact %v of package %v (excluded)", artifact.Name(), pkg.Name())
+			if err := os.RemoveAll(filepath.Join(dir, pkg.Name(), artifact.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cloneConfiguration copies the configured parameter values of the integration flows of the packages from the
+// source to the target tenant. Integration flows that do not exist on the target are skipped.
+func cloneConfiguration(sourceExe, targetExe *httpclnt.HTTPExecuter, packages []*designtime.PackageInventory) error {
+	logger.Rule(logger.SeparatorDashed)
+	log.Info().Msg("Copying the configuration of the integration flows")
+	sourceConfigs := newConfigurationReader(api.NewConfigurationService(sourceExe))
+	targetConfigs := newConfigurationReader(api.NewConfigurationService(targetExe))
+	stats := new(ConfigureStats)
+	artifacts := 0
+	for _, pkg := range packages {
+		for _, a := range pkg.Artifacts {
+			if a.Type != "IntegrationFlow" {
+				continue
+			}
+			source, err := sourceConfigs.get(a.ID, "active")
+			if err != nil {
+				return fmt.Errorf("failed to get configuration of %s: %w", a.ID, err)
+			}
+			target, err := targetConfigs.get(a.ID, "active")
+			if err != nil {
+				log.Warn().Msgf("⚠️  Configuration of %s not available on the target, skipping: %v", a.ID, err)
+				continue
+			}
+			parameters, missing := copyParameters(source.Root.Results, target.Root.Results, nil)
+			for _, key := range missing {
+				log.Warn().Msgf("⚠️  Parameter %s not found in %s on the target, skipping", key, a.ID)
+			}
+			if len(parameters) == 0 {
+				continue
+			}
+			if err := updateParametersBatch(targetExe, targetConfigs, a.ID, "active", parameters, httpclnt.DefaultBatchSize, true, flashpipe.BatchFallbackAll, stats, &log.Logger); err != nil {
+				return fmt.Errorf("failed to copy parameters to %s: %w", a.ID, err)
+			}
+			artifacts++
+		}
+	}
+	log.Info().Msgf("Copied %d parameter(s) of %d integration flow(s)", stats.ParametersUpdated.Value(), artifacts)
+	return nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/engswee/flashpipe/internal/keychain"
+	"github.com/engswee/flashpipe/internal/mockcpi"
+	"github.com/engswee/flashpipe/internal/source"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveExcludedArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for _, artifactDir := range []string{"Orders/OrderFlow", "Orders/CustomerNumbers", "Common/Common_Scripts"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, artifactDir, "META-INF"), os.ModePerm))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Orders", "Orders.json"), []byte(`{"d":{"Id":"Orders"}}`), 0644))
+
+	require.NoError(t, removeExcludedArtifacts(dir, []string{"CustomerNumbers", "Orders.json"}))
+	assert.DirExists(t, filepath.Join(dir, "Orders", "OrderFlow"))
+	assert.NoDirExists(t, filepath.Join(dir, "Orders", "CustomerNumbers"))
+	assert.DirExists(t, filepath.Join(dir, "Common", "Common_Scripts"))
+	assert.FileExists(t, filepath.Join(dir, "Orders", "Orders.json"), "Package details should be kept")
+
+	require.NoError(t, removeExcludedArtifacts(filepath.Join(dir, "missing"), nil), "Nothing should be read without exclusions")
+}
+
+func writeCloneSnapshot(t *testing.T) string {
+	dir := t.TempDir()
+	for id, bundleType := range map[string]string{"Orders": "IntegrationFlow", "Invoices": "IntegrationFlow", "Order_Mapping": "MessageMapping"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "Sales", id, "META-INF"), os.ModePerm))
+		manifest := "Manifest-Version: 1.0\nBundle-SymbolicName: " + id + "\nSAP-BundleType: " + bundleType + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Sales", id, "META-INF", "MANIFEST.MF"), []byte(manifest), 0644))
+	}
+	return dir
+}
+
+func newCloneTarget() *mockcpi.Server {
+	return mockcpi.New(&mockcpi.Fixture{Packages: []mockcpi.Package{{ID: "Sales", Artifacts: []mockcpi.Artifact{
+		{ID: "Orders", Type: "Integration", Version: "1.0.0", Parameters: map[string]string{"Receiver Host": "sandbox.example.com", "Timeout": "30"}},
+	}}}})
+}
+
+func TestCloneConfiguration(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&logs)
+	t.Cleanup(func() { log.Logger = logger })
+
+	sourceSvr := mockcpi.New(mockcpi.DefaultFixture())
+	defer sourceSvr.Close()
+	targetSvr := newCloneTarget()
+	defer targetSvr.Close()
+	packages, err := designtime.Inventory(writeCloneSnapshot(t))
+	require.NoError(t, err)
+
+	require.NoError(t, cloneConfiguration(sourceSvr.Executer(), targetSvr.Executer(), packages))
+
+	value, _ := targetSvr.Parameter("Orders", "Receiver Host")
+	assert.Equal(t, "dev.example.com", value, "The value of the source should be copied")
+	value, _ = targetSvr.Parameter("Orders", "Timeout")
+	assert.Equal(t, "30", value)
+	_, ok := targetSvr.Parameter("Orders", "Retries")
+	assert.False(t, ok, "Parameters missing on the target should not be created")
+	assert.Contains(t, logs.String(), "Parameter Retries not found in Orders on the target, skipping")
+	assert.Contains(t, logs.String(), "Configuration of Invoices not available on the target, skipping")
+	assert.Contains(t, logs.String(), "Copied 1 parameter(s) of 1 integration flow(s)")
+	for _, request := range sourceSvr.Requests() {
+		assert.True(t, strings.HasPrefix(request, "GET "), "The source should only be read: %v", request)
+	}
+	assert.Empty(t, targetSvr.Unhandled())
+}
+
+func TestCloneTenantDryRun(t *testing.T) {
+	sourceSvr := mockcpi.New(mockcpi.DefaultFixture())
+	defer sourceSvr.Close()
+	targetSvr := newCloneTarget()
+	defer targetSvr.Close()
+	content := source.NewDirectory(writeCloneSnapshot(t))
+	targetDetails := &api.ServiceDetails{Host: "sandbox.invalid"}
+
+	require.NoError(t, cloneTenant(content, sourceSvr.Executer(), targetDetails, targetSvr.Executer(), "sandbox", cloneParts, nil, true))
+	assert.Empty(t, targetSvr.Requests(), "A dry run should not access the target")
+	value, _ := targetSvr.Parameter("Orders", "Receiver Host")
+	assert.Equal(t, "sandbox.example.com", value)
+
+	require.NoError(t, cloneTenant(content, sourceSvr.Executer(), targetDetails, targetSvr.Executer(), "sandbox", []string{CloneConfiguration}, []string{"Orders"}, false))
+	value, _ = targetSvr.Parameter("Orders", "Receiver Host")
+	assert.Equal(t, "sandbox.example.com", value, "Excluded artifacts should not be copied")
+}
+
+func TestRunCloneValidation(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	defaultStore := keychain.Default
+	keychain.Default = memoryKeychain{
+		"qa":        `{"tmnHost":"qa.hana.ondemand.com","userId":"user","password":"secret"}`,
+		"qa-backup": `{"tmnHost":"qa.hana.ondemand.com","userId":"user","password":"secret"}`,
+	}
+	t.Cleanup(func() { keychain.Default = defaultStore })
+
+	for name, test := range map[string]struct {
+		args []string
+		err  string
+	}{
+		"no source":        {[]string{"--target", "sandbox"}, "--source is required"},
+		"same name":        {[]string{"--source", "qa", "--target", "qa"}, "--source and --target must be different tenants"},
+		"invalid part":     {[]string{"--source", "qa", "--target", "sandbox", "--include", "packages,tenant"}, "invalid value for --include = tenant"},
+		"unknown target":   {[]string{"--source", "qa", "--target", "sandbox", "--dry-run"}, "no credentials of tenant sandbox stored"},
+		"same tenant host": {[]string{"--source", "qa", "--target", "qa-backup", "--dry-run"}, "source qa and target qa-backup are the same tenant qa.hana.ondemand.com"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cloneCmd := NewCloneCommand()
+			require.NoError(t, cloneCmd.ParseFlags(test.args))
+			err := runClone(cloneCmd)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
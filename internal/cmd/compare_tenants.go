@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of differences between two tenants
+const (
+	TenantDiffPackageMissing   = "package-missing"   // The package exists on one tenant only
+	TenantDiffArtifactMissing  = "artifact-missing"  // The artifact exists on one tenant only
+	TenantDiffVersion          = "version"           // The artifact has different versions
+	TenantDiffParameterMissing = "parameter-missing" // The configuration parameter exists on one tenant only
+	TenantDiffParameter        = "parameter"         // The configuration parameter has different values
+)
+
+// TenantDifference is a difference between two tenants. For the missing kinds, the side the package,
+// artifact or parameter is missing on is empty, the other side holds its version or value.
+type TenantDifference struct {
+	Kind         string `json:"kind"`
+	PackageID    string `json:"packageId"`
+	ArtifactID   string `json:"artifactId,omitempty"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	Key          string `json:"key,omitempty"`
+	Left         string `json:"left"`
+	Right        string `json:"right"`
+}
+
+// TenantComparison is the report of compare-tenants
+type TenantComparison struct {
+	Left               string             `json:"left"`
+	Right              string             `json:"right"`
+	PackagesCompared   int                `json:"packagesCompared"`
+	ArtifactsCompared  int                `json:"artifactsCompared"`
+	ParametersCompared int                `json:"parametersCompared"`
+	Differences        []TenantDifference `json:"differences"`
+}
+
+func NewCompareTenantsCommand() *cobra.Command {
+
+	compareCmd := &cobra.Command{
+		Use:          "compare-tenants",
+		Short:        "Compare the content and configuration of two tenants",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Compare the integration packages, the versions of their artifacts and the
+configured parameter values of the integration flows of two tenants, e.g.
+before a cutover to verify that the environments are aligned.
+
+Both tenants are referenced by the names their credentials are stored under
+with login. The differences are written to stdout as a table, or as JSON
+with --output json. Artifacts that only exist on one tenant, or whose
+versions differ, are reported without comparing their configuration.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'compareTenants' section. CLI flags override config file settings.`,
+		Example: `  # Compare dev and QA
+  flashpipe compare-tenants --left dev --right qa
+
+  # Fail a pipeline if the packages of the release differ
+  flashpipe compare-tenants --left qa --right prod --package-filter @release-packages.txt --fail-on-difference --output json > diff.json`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runCompareTenants(cmd, os.Stdout); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Note: These can be set in config file under 'compareTenants' key
+	compareCmd.Flags().String("left", "", "Name of the first tenant, as stored with login (config: compareTenants.left)")
+	compareCmd.Flags().String("right", "", "Name of the second tenant, as stored with login (config: compareTenants.right)")
+	compareCmd.Flags().String("package-filter", "", "Comma-separated list of packages to compare, @<file> or - (stdin) for one per line (config: compareTenants.packageFilter)")
+	compareCmd.Flags().String("artifact-filter", "", "Comma-separated list of artifacts to compare, @<file> or - (stdin) for one per line (config: compareTenants.artifactFilter)")
+	compareCmd.Flags().Bool("skip-parameters", false, "Only compare packages and artifact versions, not the configured parameter values (config: compareTenants.skipParameters)")
+	compareCmd.Flags().String("output", "text", "Output format: text or json (config: compareTenants.output)")
+	compareCmd.Flags().Bool("fail-on-difference", false, "Exit with an error if the tenants differ (config: compareTenants.failOnDifference)")
+
+	return compareCmd
+}
+
+func runCompareTenants(cmd *cobra.Command, out io.Writer) error {
+	left := config.GetStringWithFallback(cmd, "left", "compareTenants.left")
+	right := config.GetStringWithFallback(cmd, "right", "compareTenants.right")
+	skipParameters := config.GetBoolWithFallback(cmd, "skip-parameters", "compareTenants.skipParameters")
+	output := config.GetStringWithFallback(cmd, "output", "compareTenants.output")
+	failOnDifference := config.GetBoolWithFallback(cmd, "fail-on-difference", "compareTenants.failOnDifference")
+
+	if left == "" || right == "" {
+		return fmt.Errorf("--left and --right are required (set via CLI flag or in config file under 'compareTenants')")
+	}
+	if left == right {
+		return fmt.Errorf("--left and --right must be different tenants")
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid --output %q, must be text or json", output)
+	}
+	packageFilter, artifactFilter, err := resolveFilters(
+		config.GetStringWithFallback(cmd, "package-filter", "compareTenants.packageFilter"),
+		config.GetStringWithFallback(cmd, "artifact-filter", "compareTenants.artifactFilter"))
+	if err != nil {
+		return err
+	}
+
+	leftExe, err := storedTenantExecuter(left)
+	if err != nil {
+		return err
+	}
+	rightExe, err := storedTenantExecuter(right)
+	if err != nil {
+		return err
+	}
+	comparison, err := compareTenants(leftExe, rightExe, parseFilter(packageFilter), parseFilter(artifactFilter), !skipParameters)
+	if err != nil {
+		return err
+	}
+	comparison.Left, comparison.Right = left, right
+
+	if err := writeTenantComparison(out, comparison, output); err != nil {
+		return err
+	}
+	log.Info().Msgf("Compared %d package(s), %d artifact(s) and %d parameter(s) of %s and %s: %d difference(s)",
+		comparison.PackagesCompared, comparison.ArtifactsCompared, comparison.ParametersCompared, left, right, len(comparison.Differences))
+	if failOnDifference && len(comparison.Differences) > 0 {
+		return fmt.Errorf("%d difference(s) between %s and %s", len(comparison.Differences), left, right)
+	}
+	return nil
+}
+
+// compareTenants compares the packages, artifact versions and, if parameters is set, the configured parameter
+// values of the integration flows of two tenants. Packages and artifacts are compared in the order of their IDs.
+func compareTenants(left, right *httpclnt.HTTPExecuter, packageFilter, artifactFilter []string, parameters bool) (*TenantComparison, error) {
+	c := &TenantComparison{Differences: []TenantDifference{}}
+	leftPackages, err := packageVersions(left)
+	if err != nil {
+		return nil, err
+	}
+	rightPackages, err := packageVersions(right)
+	if err != nil {
+		return nil, err
+	}
+
+	var leftConfigs, rightConfigs *configurationReader
+	if parameters {
+		leftConfigs = newConfigurationReader(api.NewConfigurationService(left))
+		rightConfigs = newConfigurationReader(api.NewConfigurationService(right))
+	}
+	for _, packageID := range unionKeys(leftPackages, rightPackages) {
+		if len(packageFilter) > 0 && !shouldInclude(packageID, packageFilter) {
+			continue
+		}
+		c.PackagesCompared++
+		leftVersion, onLeft := leftPackages[packageID]
+		rightVersion, onRight := rightPackages[packageID]
+		if !onLeft || !onRight {
+			c.Differences = append(c.Differences, TenantDifference{Kind: TenantDiffPackageMissing, PackageID: packageID,
+				Left: leftVersion, Right: rightVersion})
+			continue
+		}
+
+		leftArtifacts, err := artifactVersions(left, packageID)
+		if err != nil {
+			return nil, err
+		}
+		rightArtifacts, err := artifactVersions(right, packageID)
+		if err != nil {
+			return nil, err
+		}
+		for _, artifactID := range unionKeys(leftArtifacts, rightArtifacts) {
+			if len(artifactFilter) > 0 && !shouldInclude(artifactID, artifactFilter) {
+				continue
+			}
+			c.ArtifactsCompared++
+			l, onLeft := leftArtifacts[artifactID]
+			r, onRight := rightArtifacts[artifactID]
+			d := TenantDifference{PackageID: packageID, ArtifactID: artifactID}
+			switch {
+			case !onLeft:
+				d.Kind, d.ArtifactType, d.Right = TenantDiffArtifactMissing, r.ArtifactType, r.Version
+			case !onRight:
+				d.Kind, d.ArtifactType, d.Left = TenantDiffArtifactMissing, l.ArtifactType, l.Version
+			case l.Version != r.Version:
+				d.Kind, d.ArtifactType, d.Left, d.Right = TenantDiffVersion, l.ArtifactType, l.Version, r.Version
+			}
+			if d.Kind != "" {
+				c.Differences = append(c.Differences, d)
+				continue
+			}
+			if parameters && l.ArtifactType == "Integration" {
+				if err := compareParameters(c, leftConfigs, rightConfigs, packageID, artifactID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return c, nil
+}
+
+// compareParameters adds the differences of the configured parameter values of an integration flow
+func compareParameters(c *TenantComparison, leftConfigs, rightConfigs *configurationReader, packageID, artifactID string) error {
+	leftParameters, err := leftConfigs.get(artifactID, "active")
+	if err != nil {
+		return fmt.Errorf("failed to get configuration of %s: %w", artifactID, err)
+	}
+	rightParameters, err := rightConfigs.get(artifactID, "active")
+	if err != nil {
+		return fmt.Errorf("failed to get configuration of %s: %w", artifactID, err)
+	}
+	leftValues := map[string]string{}
+	for _, p := range leftParameters.Root.Results {
+		leftValues[p.ParameterKey] = p.ParameterValue
+	}
+	rightValues := map[string]string{}
+	for _, p := range rightParameters.Root.Results {
+		rightValues[p.ParameterKey] = p.ParameterValue
+	}
+	for _, key := range unionKeys(leftValues, rightValues) {
+		c.ParametersCompared++
+		l, onLeft := leftValues[key]
+		r, onRight := rightValues[key]
+		d := TenantDifference{PackageID: packageID, ArtifactID: artifactID, ArtifactType: "Integration", Key: key, Left: l, Right: r}
+		switch {
+		case !onLeft || !onRight:
+			d.Kind = TenantDiffParameterMissing
+		case l != r:
+			d.Kind = TenantDiffParameter
+		default:
+			continue
+		}
+		c.Differences = append(c.Differences, d)
+	}
+	return nil
+}
+
+func packageVersions(exe *httpclnt.HTTPExecuter) (map[string]string, error) {
+	packages, err := api.NewIntegrationPackage(exe).List()
+	if err != nil {
+		return nil, err
+	}
+	versions := map[string]string{}
+	for _, p := range packages {
+		versions[p.Id] = p.Version
+	}
+	return versions, nil
+}
+
+func artifactVersions(exe *httpclnt.HTTPExecuter, packageID string) (map[string]*api.ArtifactDetails, error) {
+	artifacts, err := api.NewIntegrationPackage(exe).GetAllArtifacts(packageID)
+	if err != nil {
+		return nil, err
+	}
+	versions := map[string]*api.ArtifactDetails{}
+	for _, a := range artifacts {
+		versions[a.Id] = a
+	}
+	return versions, nil
+}
+
+// unionKeys returns the keys of both maps in sorted order
+func unionKeys[V any](a, b map[string]V) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func writeTenantComparison(out io.Writer, c *TenantComparison, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(c)
+	}
+	if len(c.Differences) == 0 {
+		fmt.Fprintf(out, "No differences between %s and %s\n", c.Left, c.Right)
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "KIND\tPACKAGE\tARTIFACT\tKEY\t%s\t%s\n", c.Left, c.Right)
+	for _, d := range c.Differences {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", d.Kind, d.PackageID, orDash(d.ArtifactID), orDash(d.Key), orDash(d.Left), orDash(d.Right))
+	}
+	return w.Flush()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantMock serves packages, their integration flows and the configuration of the flows from JSON bodies by
+// URL path. Other artifact types have no artifacts.
+func tenantMock(t *testing.T, bodies map[string]string) *httpclnt.HTTPExecuter {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if body, ok := bodies[r.URL.Path]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "DesigntimeArtifacts") {
+			w.Write([]byte(`{ "d": { "results": [] } }`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(svr.Close)
+	host, port := httpclnt.GetHostPort(svr.URL)
+	return httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+}
+
+func TestCompareTenants(t *testing.T) {
+	left := tenantMock(t, map[string]string{
+		"/api/v1/IntegrationPackages": `{ "d": { "results": [ { "Id": "Orders", "Version": "1.0.0" }, { "Id": "Sandbox", "Version": "1.0.0" } ] } }`,
+		"/api/v1/IntegrationPackages('Orders')/IntegrationDesigntimeArtifacts": `{ "d": { "results": [
+			{ "Id": "OrderFlow", "Version": "1.0.1" }, { "Id": "StatusFlow", "Version": "1.0.0" }, { "Id": "NewFlow", "Version": "1.0.0" } ] } }`,
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='OrderFlow',Version='active')/Configurations": `{ "d": { "results": [
+			{ "ParameterKey": "Host", "ParameterValue": "qa.example.com" }, { "ParameterKey": "Port", "ParameterValue": "443" },
+			{ "ParameterKey": "Timeout", "ParameterValue": "60" } ] } }`,
+	})
+	right := tenantMock(t, map[string]string{
+		"/api/v1/IntegrationPackages": `{ "d": { "results": [ { "Id": "Orders", "Version": "1.0.0" } ] } }`,
+		"/api/v1/IntegrationPackages('Orders')/IntegrationDesigntimeArtifacts": `{ "d": { "results": [
+			{ "Id": "OrderFlow", "Version": "1.0.1" }, { "Id": "StatusFlow", "Version": "0.9.0" } ] } }`,
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='OrderFlow',Version='active')/Configurations": `{ "d": { "results": [
+			{ "ParameterKey": "Host", "ParameterValue": "prod.example.com" }, { "ParameterKey": "Port", "ParameterValue": "443" } ] } }`,
+	})
+
+	c, err := compareTenants(left, right, nil, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []TenantDifference{
+		{Kind: TenantDiffArtifactMissing, PackageID: "Orders", ArtifactID: "NewFlow", ArtifactType: "Integration", Left: "1.0.0"},
+		{Kind: TenantDiffParameter, PackageID: "Orders", ArtifactID: "OrderFlow", ArtifactType: "Integration", Key: "Host", Left: "qa.example.com", Right: "prod.example.com"},
+		{Kind: TenantDiffParameterMissing, PackageID: "Orders", ArtifactID: "OrderFlow", ArtifactType: "Integration", Key: "Timeout", Left: "60"},
+		{Kind: TenantDiffVersion, PackageID: "Orders", ArtifactID: "StatusFlow", ArtifactType: "Integration", Left: "1.0.0", Right: "0.9.0"},
+		{Kind: TenantDiffPackageMissing, PackageID: "Sandbox", Left: "1.0.0"},
+	}, c.Differences)
+	assert.Equal(t, 2, c.PackagesCompared)
+	assert.Equal(t, 3, c.ArtifactsCompared)
+	assert.Equal(t, 3, c.ParametersCompared)
+
+	c, err = compareTenants(left, right, []string{"Orders"}, []string{"OrderFlow"}, false)
+	require.NoError(t, err)
+	assert.Empty(t, c.Differences, "Parameters should not be compared with skip-parameters")
+
+	c.Left, c.Right = "qa", "prod"
+	var out bytes.Buffer
+	require.NoError(t, writeTenantComparison(&out, c, "text"))
+	assert.Equal(t, "No differences between qa and prod\n", out.String())
+}
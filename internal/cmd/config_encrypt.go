@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// sensitiveKeySuffixes are the endings of the names of config keys encrypted by default, compared in lower case
+// without dashes and underscores
+var sensitiveKeySuffixes = []string{"password", "secret", "apikey", "token"}
+
+func NewConfigCommand() *cobra.Command {
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the global config file",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Manage the global config file (flashpipe.yaml).`,
+	}
+	return configCmd
+}
+
+func NewConfigEncryptCommand() *cobra.Command {
+
+	encryptCmd := &cobra.Command{
+		Use:          "encrypt",
+		Short:        "Encrypt the sensitive values of the global config file",
+		SilenceUsage: true,
+		Long: `Encrypt the sensitive values of the global config file in place, for teams
+that cannot use an external secret manager. Values are encrypted with
+AES-256-GCM and a key derived from the passphrase in FLASHPIPE_CONFIG_KEY,
+or in the file given by FLASHPIPE_CONFIG_KEY_FILE, and are decrypted
+transparently by all commands with the same passphrase.
+
+The values of keys ending with password, secret, apikey or token, e.g.
+tmn-password and oauth-clientsecret, are encrypted, as well as the keys
+given by --keys. Values that are already encrypted or reference environment
+variables are kept. To change a value, replace it with the plain value and
+encrypt the file again.
+
+Only YAML files are encrypted in place. With --stdin, the value read from
+stdin is encrypted and printed instead, e.g. for JSON or TOML config files.`,
+		Example: `  # Encrypt the credentials of the global config file
+  export FLASHPIPE_CONFIG_KEY_FILE=~/.flashpipe.key
+  flashpipe config encrypt --config ./flashpipe.yaml
+
+  # Encrypt a single value
+  printf '%s' "$SECRET" | flashpipe config encrypt --stdin`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runConfigEncrypt(cmd, os.Stdin, os.Stdout); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	encryptCmd.Flags().StringSlice("keys", nil, "Comma separated list of additional config keys to encrypt, nested keys separated by dots, e.g. configure.webhookUrl")
+	encryptCmd.Flags().Bool("stdin", false, "Encrypt the value read from stdin and print it instead of encrypting the config file")
+
+	return encryptCmd
+}
+
+func runConfigEncrypt(cmd *cobra.Command, in io.Reader, out io.Writer) error {
+	c, err := config.CipherFromEnv()
+	if err != nil {
+		return err
+	}
+
+	if config.GetBool(cmd, "stdin") {
+		value, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		encrypted, err := c.Encrypt(strings.TrimSuffix(string(value), "\n"))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, encrypted)
+		return err
+	}
+
+	file := config.GetString(cmd, "config")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		file = filepath.Join(home, "flashpipe.yaml")
+	}
+	if ext := filepath.Ext(file); ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("only YAML config files can be encrypted in place, use --stdin to encrypt single values")
+	}
+	var keys []string
+	for _, key := range config.GetStringSlice(cmd, "keys") {
+		keys = append(keys, strings.ToLower(key))
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	encrypted, count, err := encryptConfigYAML(data, c, keys)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", file, err)
+	}
+	if count == 0 {
+		log.Info().Msgf("No values to encrypt in %s", file)
+		return nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, encrypted, info.Mode().Perm()); err != nil {
+		return err
+	}
+	log.Info().Msgf("Encrypted %d value(s) in %s", count, file)
+	return nil
+}
+
+// encryptConfigYAML encrypts the sensitive values and the values of keys of the YAML config, keeping its
+// comments, and returns the number of encrypted values
+func encryptConfigYAML(data []byte, c *config.Cipher, keys []string) ([]byte, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	count := 0
+	var walk func(node *yaml.Node, prefix string) error
+	walk = func(node *yaml.Node, prefix string) error {
+		switch node.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range node.Content {
+				if err := walk(child, prefix); err != nil {
+					return err
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := prefix + node.Content[i].Value
+				value := node.Content[i+1]
+				if value.Kind != yaml.ScalarNode {
+					if err := walk(value, key+"."); err != nil {
+						return err
+					}
+					continue
+				}
+				if value.Tag != "!!str" || value.Value == "" || config.IsEncrypted(value.Value) || strings.Contains(value.Value, "${") {
+					continue
+				}
+				if !isSensitiveConfigKey(node.Content[i].Value) && !slices.Contains(keys, strings.ToLower(key)) {
+					continue
+				}
+				encrypted, err := c.Encrypt(value.Value)
+				if err != nil {
+					return err
+				}
+				value.Value = encrypted
+				value.Style = 0
+				count++
+			}
+		}
+		return nil
+	}
+	if err := walk(&doc, ""); err != nil {
+		return nil, 0, err
+	}
+	if count == 0 {
+		return data, 0, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, 0, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), count, nil
+}
+
+func isSensitiveConfigKey(name string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(normalized, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEncryptConfigYAML(t *testing.T) {
+	original := `# Global config
+tmn-host: tenant.hana.ondemand.com
+tmn-password: "s3cret" # service user
+oauth-clientsecret: ${CLIENT_SECRET}
+httpHeaders:
+  X-Api-Key: abc
+configure:
+  webhookUrl: https://hooks.example.com/T000
+  retries: 3
+`
+	c := config.NewCipher("passphrase")
+	encrypted, count, err := encryptConfigYAML([]byte(original), c, []string{"configure.webhookurl"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Contains(t, string(encrypted), "# Global config\n")
+	assert.Contains(t, string(encrypted), "# service user")
+	assert.Contains(t, string(encrypted), "oauth-clientsecret: ${CLIENT_SECRET}\n", "References to environment variables should be kept")
+	assert.NotContains(t, string(encrypted), "s3cret")
+
+	var values struct {
+		Password string            `yaml:"tmn-password"`
+		Headers  map[string]string `yaml:"httpHeaders"`
+		Host     string            `yaml:"tmn-host"`
+	}
+	require.NoError(t, yaml.Unmarshal(encrypted, &values))
+	assert.Equal(t, "tenant.hana.ondemand.com", values.Host)
+	plain, err := c.Decrypt(values.Password)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", plain)
+	plain, err = c.Decrypt(values.Headers["X-Api-Key"])
+	require.NoError(t, err)
+	assert.Equal(t, "abc", plain)
+
+	again, count, err := encryptConfigYAML(encrypted, c, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "Encrypted values should be kept")
+	assert.Equal(t, encrypted, again)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configB64Env is the environment variable with the base64 encoded content of the configuration of configure,
+// used when no configuration path is set, e.g. in Kubernetes Jobs and GitHub composite actions that cannot
+// mount files
+const configB64Env = "FLASHPIPE_CONFIG_B64"
+
+// envConfigPath returns configPath, or without configPath, the path of a temporary file with the configuration
+// of FLASHPIPE_CONFIG_B64. The file is removed by cleanup. Without both, the returned path is empty.
+func envConfigPath(configPath string) (path string, cleanup func(), err error) {
+	cleanup = func() {}
+	encoded := strings.TrimSpace(os.Getenv(configB64Env))
+	if configPath != "" || encoded == "" {
+		return configPath, cleanup, nil
+	}
+	// Line breaks of base64 -w 76 or of secrets written over several lines are ignored
+	encoded = strings.Join(strings.Fields(encoded), "")
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", cleanup, fmt.Errorf("%s is not base64 encoded: %w", configB64Env, err)
+	}
+
+	dir, err := os.MkdirTemp("", "flashpipe-config-")
+	if err != nil {
+		return "", cleanup, err
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	// The format is detected from the extension, JSON starts with an object and TOML with a table or a key
+	// assignment, everything else is YAML
+	name := "config.yml"
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		name = "config.json"
+	case bytes.HasPrefix(trimmed, []byte("[")) || isTOMLAssignment(trimmed):
+		name = "config.toml"
+	}
+	path = filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return path, cleanup, nil
+}
+
+// isTOMLAssignment returns true if the first line that is not a comment is a TOML key assignment, e.g.
+// deploymentPrefix = "DEV"
+func isTOMLAssignment(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, found := strings.Cut(line, "=")
+		return found && !strings.ContainsAny(key, ":\"'") && strings.TrimSpace(key) != ""
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvConfigPath(t *testing.T) {
+	path, cleanup, err := envConfigPath("")
+	require.NoError(t, err)
+	cleanup()
+	assert.Empty(t, path, "Without FLASHPIPE_CONFIG_B64")
+
+	for content, name := range map[string]string{
+		"packages:\n  - integrationSuiteId: Sales\n":      "config.yml",
+		`{"packages": [{"integrationSuiteId": "Sales"}]}`: "config.json",
+		"# Sales\ndeploymentPrefix = \"DEV\"\n":           "config.toml",
+		"[[packages]]\nintegrationSuiteId = \"Sales\"\n":  "config.toml",
+	} {
+		encoded := base64.StdEncoding.EncodeToString([]byte(content))
+		// Line breaks of base64 are ignored
+		t.Setenv(configB64Env, encoded[:10]+"\n"+encoded[10:])
+		path, cleanup, err := envConfigPath("")
+		require.NoError(t, err)
+		assert.Equal(t, name, filepath.Base(path))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+		cleanup()
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "Temporary file should be removed")
+	}
+
+	path, cleanup, err = envConfigPath("./config.yml")
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, "./config.yml", path, "Path wins over FLASHPIPE_CONFIG_B64")
+
+	t.Setenv(configB64Env, "not base64!")
+	_, _, err = envConfigPath("")
+	assert.ErrorContains(t, err, "FLASHPIPE_CONFIG_B64 is not base64 encoded")
+}
+
+func TestEnvPrecedence(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	globalConfig := filepath.Join(t.TempDir(), "flashpipe.yaml")
+	require.NoError(t, os.WriteFile(globalConfig, []byte(`tmn-host: file.hana.ondemand.com
+tmn-userid: file-user
+configure:
+  deploymentPrefix: FILE
+  packageFilter: FilePackage
+  artifactFilter: FileArtifact
+  dryRun: false
+  batchSize: 10
+`), 0o600))
+
+	// Flags of the command line
+	root := NewCmdRoot()
+	configureCmd := NewConfigureCommand()
+	root.AddCommand(configureCmd)
+	require.NoError(t, configureCmd.ParseFlags([]string{"--config", globalConfig, "--artifact-filter", "FlagArtifact"}))
+
+	// Environment variables of flags and of keys of the config file
+	t.Setenv("FLASHPIPE_TMN_HOST", "env.hana.ondemand.com")
+	t.Setenv("FLASHPIPE_TMN_PASSWORD", "env-password")
+	t.Setenv("FLASHPIPE_ARTIFACT_FILTER", "EnvArtifact")
+	t.Setenv("FLASHPIPE_CONFIGURE_ARTIFACTFILTER", "EnvConfigArtifact")
+	t.Setenv("FLASHPIPE_PACKAGE_FILTER", "EnvPackage")
+	t.Setenv("FLASHPIPE_CONFIGURE_PACKAGEFILTER", "EnvConfigPackage")
+	t.Setenv("FLASHPIPE_CONFIGURE_DRYRUN", "true")
+	require.NoError(t, initializeConfig(configureCmd))
+
+	assert.Equal(t, "FlagArtifact", config.GetStringWithFallback(configureCmd, "artifact-filter", "configure.artifactFilter"), "Flag wins")
+	assert.Equal(t, "EnvPackage", config.GetStringWithFallback(configureCmd, "package-filter", "configure.packageFilter"), "Environment variable of the flag wins over the config key")
+	assert.True(t, config.GetBoolWithFallback(configureCmd, "dry-run", "configure.dryRun"), "Environment variable of the config key wins over the config file")
+	assert.Equal(t, "FILE", config.GetStringWithFallback(configureCmd, "deployment-prefix", "configure.deploymentPrefix"), "Config file wins over the default")
+	assert.Equal(t, 10, config.GetIntWithFallback(configureCmd, "batch-size", "configure.batchSize"))
+	assert.Equal(t, "env.hana.ondemand.com", config.GetString(configureCmd, "tmn-host"), "Environment variable wins over the config file")
+	assert.Equal(t, "file-user", config.GetString(configureCmd, "tmn-userid"))
+	assert.Equal(t, "env-password", config.GetString(configureCmd, "tmn-password"))
+}
@@ -62,9 +62,9 @@ Features:
 	configCmd.Flags().String("output", "./001-deploy-config.yml",
 		"Path to output configuration file")
 	configCmd.Flags().StringSlice("package-filter", nil,
-		"Comma separated list of packages to include (e.g., 'Package1,Package2')")
+		"Comma separated list of packages to include (e.g., 'Package1,Package2'), @<file> or - (stdin) for one per line")
 	configCmd.Flags().StringSlice("artifact-filter", nil,
-		"Comma separated list of artifacts to include (e.g., 'Artifact1,Artifact2')")
+		"Comma separated list of artifacts to include (e.g., 'Artifact1,Artifact2'), @<file> or - (stdin) for one per line")
 
 	return configCmd
 }
@@ -72,8 +72,12 @@ Features:
 func runConfigGenerate(cmd *cobra.Command) error {
 	packagesDir := config.GetString(cmd, "packages-dir")
 	outputFile := config.GetString(cmd, "output")
-	packageFilter := config.GetStringSlice(cmd, "package-filter")
-	artifactFilter := config.GetStringSlice(cmd, "artifact-filter")
+	packageFilterStr, artifactFilterStr, err := resolveFilters(strings.Join(config.GetStringSlice(cmd, "package-filter"), ","),
+		strings.Join(config.GetStringSlice(cmd, "artifact-filter"), ","))
+	if err != nil {
+		return err
+	}
+	packageFilter, artifactFilter := parseFilter(packageFilterStr), parseFilter(artifactFilterStr)
 
 	generator := NewConfigGenerator(packagesDir, outputFile, packageFilter, artifactFilter)
 
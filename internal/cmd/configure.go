@@ -1,39 +1,36 @@
 package cmd
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
-	"sync"
+	"runtime/debug"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
 	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/events"
+	"github.com/engswee/flashpipe/internal/history"
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/pipeline"
+	"github.com/engswee/flashpipe/internal/remote"
+	"github.com/engswee/flashpipe/internal/smoketest"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/engswee/flashpipe/internal/telemetry"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 )
 
 // ConfigureStats tracks configuration processing statistics
-type ConfigureStats struct {
-	PackagesProcessed         int
-	PackagesWithErrors        int
-	ArtifactsProcessed        int
-	ArtifactsConfigured       int
-	ArtifactsDeployed         int
-	ArtifactsFailed           int
-	ParametersUpdated         int
-	ParametersFailed          int
-	BatchRequestsExecuted     int
-	IndividualRequestsUsed    int
-	DeploymentTasksQueued     int
-	DeploymentTasksSuccessful int
-	DeploymentTasksFailed     int
-}
+type ConfigureStats = flashpipe.Stats
 
 // ConfigurationTask represents a configuration update task
 type ConfigurationTask struct {
@@ -100,10 +97,79 @@ Operation Modes:
   1. Configure Only: Updates parameters without deployment (default)
   2. Configure + Deploy: Updates parameters then deploys artifacts (when deploy: true)
 
+Value Sources:
+  Instead of a literal value, a parameter can reference a property of a
+  SAP BTP destination which is resolved at apply time:
+
+            - key: "ReceiverHost"
+              valueFrom:
+                destination: "S4_BACKEND#host"   # <name>#<property>
+
+  Besides the destination properties (e.g. URL, User, custom properties),
+  the derived properties host, port, path and scheme are available.
+  Requires the --destination-* flags for the Destination service instance.
+
+Values Files:
+  Parameter values can reference keys of values files passed with --values
+  using Go templates, so that one configuration serves all environments:
+
+            - key: "DatabaseHost"
+              value: "{{ .Values.sql.host }}"
+
+  Templates are resolved when the configuration is loaded. Referencing a
+  key that does not exist in the values files is an error.
+
+Multiple Tenants:
+  A 'targets' block applies the same configuration to several tenants,
+  with optional per-tenant deployment prefix and parameter overrides:
+
+  targets:
+    - name: "emea"
+      host: "emea-tmn.hana.ondemand.com"
+      oauthHost: "emea.authentication.eu10.hana.ondemand.com"
+      clientId: "${EMEA_CLIENT_ID}"
+      clientSecret: "${EMEA_CLIENT_SECRET}"
+      parameters:
+        MyFlow:
+          Region: "EMEA"
+
+  Select targets with --tenants and run them in parallel with
+  --parallel-tenants. A combined summary is printed at the end.
+
+  A 'rollout' block with strategy 'canary' configures the tenants of the
+  steps first, one step at a time. After each step and its pause, the
+  message processing logs of the deployed integration flows are checked
+  for failed messages. A failing step aborts the rollout, and with
+  'rollback: true' the previous parameter values are restored:
+
+  rollout:
+    strategy: canary
+    steps:
+      - tenant: "qa"
+        pauseMinutes: 30
+    healthCheck:
+      maxFailedMessages: 0
+    rollback: true
+
+Scheduled Mode:
+  With --schedule, the command keeps running and applies the configuration
+  whenever the cron expression is due (minute hour day-of-month month
+  day-of-week, or @hourly/@daily/@weekly/@monthly). A failed run does not
+  stop the schedule. /healthz and /metrics (Prometheus) are served on
+  --listen-address. Stop with SIGINT or SIGTERM.
+
 Batch Processing:
   - By default, uses OData $batch for efficient parameter updates
+  - The current configurations of all artifacts are read upfront with
+    $batch GET requests selecting only key, value and data type
   - Configurable batch size (default: 90 parameters per request)
-  - Falls back to individual requests if batch fails
+  - Parameters of an artifact are updated atomically in one changeset,
+    nothing is updated if any update fails
+  - With --disable-changeset, each update has its own changeset and
+    failed batches fall back to individual requests as set by
+    --batch-fallback
+  - Batches are split to stay below 1 MB, and halved and sent again if
+    the server rejects them as too large
   - Can be disabled globally with --disable-batch flag
 
 Configuration:
@@ -118,47 +184,61 @@ Configuration:
   # Dry run to see what would be changed
   flashpipe configure --config-path ./config.yml --dry-run
 
+  # Check parameter values against the data types on the tenant
+  flashpipe configure --config-path ./config.yml --validate-only
+
   # Apply deployment prefix
   flashpipe configure --config-path ./config.yml --deployment-prefix DEV_
 
   # Disable batch processing
-  flashpipe configure --config-path ./config.yml --disable-batch`,
+  flashpipe configure --config-path ./config.yml --disable-batch
+
+  # Resolve templates with environment-specific values
+  flashpipe configure --config-path ./config.yml --values values-prod.yaml
+
+  # Apply the configuration to two of the targets in parallel
+  flashpipe configure --config-path ./config.yml --tenants emea,apj --parallel-tenants 2
+
+  # Apply the configuration every night at 03:00
+  flashpipe configure --config-path ./config.yml --schedule "0 3 * * *"
+
+  # Check the configuration against the artifacts of a repository, without tenant credentials
+  flashpipe configure --config-path ./config.yml --offline --source-dir ./repo
+
+  # Fetch the configuration from a tag of a Git repository
+  flashpipe configure --config-path "git::https://github.com/org/cpi-config.git//configs/prod?ref=v1.4.0"
+
+  # Refuse unsigned or tampered configuration files
+  flashpipe configure --config-path https://config.example.com/prod-config.yml --verify-signature --public-key cosign.pub`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Load from viper config if available (CLI flags override config file)
-			if !cmd.Flags().Changed("config-path") && viper.IsSet("configure.configPath") {
-				configPath = viper.GetString("configure.configPath")
-			}
-			if !cmd.Flags().Changed("deployment-prefix") && viper.IsSet("configure.deploymentPrefix") {
-				deploymentPrefix = viper.GetString("configure.deploymentPrefix")
-			}
-			if !cmd.Flags().Changed("package-filter") && viper.IsSet("configure.packageFilter") {
-				packageFilter = viper.GetString("configure.packageFilter")
+			// CLI flags override the environment and the config file
+			configPath = config.GetStringWithFallback(cmd, "config-path", "configure.configPath")
+			deploymentPrefix = config.GetStringWithFallback(cmd, "deployment-prefix", "configure.deploymentPrefix")
+			packageFilter = config.GetStringWithFallback(cmd, "package-filter", "configure.packageFilter")
+			artifactFilter = config.GetStringWithFallback(cmd, "artifact-filter", "configure.artifactFilter")
+			dryRun = config.GetBoolWithFallback(cmd, "dry-run", "configure.dryRun")
+			deployRetries = config.GetIntWithFallback(cmd, "deploy-retries", "configure.deployRetries")
+			deployDelaySeconds = config.GetIntWithFallback(cmd, "deploy-delay", "configure.deployDelaySeconds")
+			parallelDeployments = config.GetIntWithFallback(cmd, "parallel-deployments", "configure.parallelDeployments")
+			batchSize = config.GetIntWithFallback(cmd, "batch-size", "configure.batchSize")
+			disableBatch = config.GetBoolWithFallback(cmd, "disable-batch", "configure.disableBatch")
+
+			var err error
+			if packageFilter, artifactFilter, err = resolveFilters(packageFilter, artifactFilter); err != nil {
+				return err
 			}
-			if !cmd.Flags().Changed("artifact-filter") && viper.IsSet("configure.artifactFilter") {
-				artifactFilter = viper.GetString("configure.artifactFilter")
-			}
-			if !cmd.Flags().Changed("dry-run") && viper.IsSet("configure.dryRun") {
-				dryRun = viper.GetBool("configure.dryRun")
-			}
-			if !cmd.Flags().Changed("deploy-retries") && viper.IsSet("configure.deployRetries") {
-				deployRetries = viper.GetInt("configure.deployRetries")
-			}
-			if !cmd.Flags().Changed("deploy-delay") && viper.IsSet("configure.deployDelaySeconds") {
-				deployDelaySeconds = viper.GetInt("configure.deployDelaySeconds")
-			}
-			if !cmd.Flags().Changed("parallel-deployments") && viper.IsSet("configure.parallelDeployments") {
-				parallelDeployments = viper.GetInt("configure.parallelDeployments")
-			}
-			if !cmd.Flags().Changed("batch-size") && viper.IsSet("configure.batchSize") {
-				batchSize = viper.GetInt("configure.batchSize")
-			}
-			if !cmd.Flags().Changed("disable-batch") && viper.IsSet("configure.disableBatch") {
-				disableBatch = viper.GetBool("configure.disableBatch")
+
+			// Without path, the configuration can be given as content in the environment
+			envPath, cleanup, err := envConfigPath(configPath)
+			if err != nil {
+				return err
 			}
+			defer cleanup()
+			configPath = envPath
 
 			// Validate required parameters
 			if configPath == "" {
-				return fmt.Errorf("--config-path is required (set via CLI flag or in config file under 'configure.configPath')")
+				return fmt.Errorf("--config-path is required (set via CLI flag, in config file under 'configure.configPath' or as content in %s)", configB64Env)
 			}
 
 			// Set defaults for deployment settings
@@ -175,22 +255,97 @@ Configuration:
 				batchSize = httpclnt.DefaultBatchSize
 			}
 
-			return runConfigure(cmd, configPath, deploymentPrefix, packageFilter, artifactFilter,
-				dryRun, deployRetries, deployDelaySeconds, parallelDeployments, batchSize, disableBatch)
+			run := func() error {
+				return runConfigure(cmd, configPath, deploymentPrefix, packageFilter, artifactFilter,
+					dryRun, deployRetries, deployDelaySeconds, parallelDeployments, batchSize, disableBatch)
+			}
+
+			// Output for config management plugins of Argo CD instead of logs
+			gitopsDiff := config.GetBoolWithFallback(cmd, "gitops-diff", "configure.gitopsDiff")
+			gitopsApply := config.GetBoolWithFallback(cmd, "gitops-apply", "configure.gitopsApply")
+			if gitopsDiff && gitopsApply {
+				return fmt.Errorf("--gitops-diff and --gitops-apply cannot be combined")
+			}
+			if gitopsDiff {
+				return runGitops(cmd, configPath, deploymentPrefix, packageFilter, artifactFilter, nil, os.Stdout)
+			}
+			if gitopsApply {
+				return runGitops(cmd, configPath, deploymentPrefix, packageFilter, artifactFilter, run, os.Stdout)
+			}
+
+			if schedule := config.GetStringWithFallback(cmd, "schedule", "configure.schedule"); schedule != "" {
+				return runScheduled(cmd, "configure", schedule, run)
+			}
+			return run()
 		},
 	}
 
+	// Flags shared with subcommands (e.g. verify)
+	configureCmd.PersistentFlags().StringVarP(&configPath, "config-path", "c", "", "Path or URL (https://, s3://, git::) of configuration YAML, JSON or TOML file or folder (config: configure.configPath)")
+	configureCmd.PersistentFlags().StringVarP(&deploymentPrefix, "deployment-prefix", "p", "", "Deployment prefix for artifact IDs (config: configure.deploymentPrefix)")
+	configureCmd.PersistentFlags().StringVar(&packageFilter, "package-filter", "", "Comma-separated list of packages to include, @<file> or - (stdin) for one per line (config: configure.packageFilter)")
+	configureCmd.PersistentFlags().StringVar(&artifactFilter, "artifact-filter", "", "Comma-separated list of artifacts to include, @<file> or - (stdin) for one per line (config: configure.artifactFilter)")
+	configureCmd.PersistentFlags().String("config-checksum", "", "Expected SHA-256 checksum of the configuration file, as hex optionally prefixed with sha256: (config: configure.configChecksum)")
+	configureCmd.PersistentFlags().Bool("verify-signature", false, "Refuse configuration files without a valid signature for --public-key (config: configure.verifySignature)")
+	configureCmd.PersistentFlags().String("public-key", "", "Public key (cosign, minisign, GPG or Ed25519 PEM) the signatures of the configuration files are verified with (config: configure.publicKey)")
+	configureCmd.PersistentFlags().String("config-signature", "", "Location of the signature of a single configuration file, defaults to the configuration path with .sig (.minisig for minisign) appended (config: configure.configSignature)")
+	configureCmd.PersistentFlags().Bool("recursive", false, "Load the configuration files of subfolders of a configuration folder as well (config: configure.recursive)")
+	configureCmd.PersistentFlags().Bool("template", false, "Render the configuration files as Go templates with sprig functions before parsing them (config: configure.template)")
+	configureCmd.PersistentFlags().String("environment", "", "Name of the environment, e.g. prod, available as .Environment in the when conditions of packages and artifacts (config: configure.environment)")
+	configureCmd.PersistentFlags().StringSlice("tags", nil, "Comma separated list of tags, only the artifacts with at least one of them are included (config: configure.tags)")
+	configureCmd.PersistentFlags().StringSlice("exclude-tags", nil, "Comma separated list of tags, the artifacts with one of them are excluded (config: configure.excludeTags)")
+	configureCmd.PersistentFlags().StringSlice("values", nil, "Comma separated list of values files referenced as {{ .Values.<key> }} in configuration files, later files override earlier ones (config: configure.values)")
+	configureCmd.PersistentFlags().String("on-conflict", flashpipe.ConflictLastWins, "Handling of parameters set to different values for the same artifact in several configuration files: last-wins, first-wins or error (config: configure.onConflict)")
+	configureCmd.PersistentFlags().String("schedule", "", "Cron expression (e.g. \"0 3 * * *\") to keep running on a schedule instead of once (config: configure.schedule)")
+	configureCmd.PersistentFlags().String("listen-address", ":8080", "Address for the /healthz and /metrics endpoints when running on a schedule, empty to disable (config: configure.listenAddress)")
+
 	// Flags
-	configureCmd.Flags().StringVarP(&configPath, "config-path", "c", "", "Path to configuration YAML file (config: configure.configPath)")
-	configureCmd.Flags().StringVarP(&deploymentPrefix, "deployment-prefix", "p", "", "Deployment prefix for artifact IDs (config: configure.deploymentPrefix)")
-	configureCmd.Flags().StringVar(&packageFilter, "package-filter", "", "Comma-separated list of packages to include (config: configure.packageFilter)")
-	configureCmd.Flags().StringVar(&artifactFilter, "artifact-filter", "", "Comma-separated list of artifacts to include (config: configure.artifactFilter)")
 	configureCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without making changes (config: configure.dryRun)")
 	configureCmd.Flags().IntVar(&deployRetries, "deploy-retries", 0, "Number of retries for deployment status checks (config: configure.deployRetries, default: 5)")
 	configureCmd.Flags().IntVar(&deployDelaySeconds, "deploy-delay", 0, "Delay in seconds between deployment status checks (config: configure.deployDelaySeconds, default: 15)")
 	configureCmd.Flags().IntVar(&parallelDeployments, "parallel-deployments", 0, "Number of parallel deployments (config: configure.parallelDeployments, default: 3)")
 	configureCmd.Flags().IntVar(&batchSize, "batch-size", 0, "Number of parameters per batch request (config: configure.batchSize, default: 90)")
 	configureCmd.Flags().BoolVar(&disableBatch, "disable-batch", false, "Disable batch processing, use individual requests (config: configure.disableBatch)")
+	configureCmd.Flags().String("batch-fallback", "", "Handling of batch requests that fail as a whole with --disable-changeset: none (fail the artifact), failed-only (update the parameters of the failed requests individually) or all (update all parameters individually), defaults to batchFallback of the configuration or all (config: configure.batchFallback)")
+	configureCmd.Flags().String("stamp", "", "Record the run ID, Git revision and time of the run on the packages it changed: description (a line of the package description) or tag (a custom tag), defaults to stamp.target of the configuration (config: configure.stamp)")
+	configureCmd.Flags().String("stamp-revision", "", "Git revision recorded by --stamp, defaults to stamp.revision of the configuration or the commit of the CI/CD pipeline run (config: configure.stampRevision)")
+	configureCmd.Flags().Bool("disable-changeset", false, "Send each parameter update of a batch in its own changeset instead of updating the parameters of an artifact atomically, for tenants that do not support changesets (config: configure.disableChangeset)")
+	configureCmd.Flags().Bool("validate-only", false, "Validate the parameters against the data types of the configuration parameters on the tenant without making changes (config: configure.validateOnly)")
+	configureCmd.Flags().Bool("offline", false, "Simulate the configuration against the artifacts of --source-dir instead of a tenant: check the parameters and show the changes without credentials (config: configure.offline)")
+	configureCmd.Flags().String("source-dir", "", "Directory, Git repository (git::) or zip file (https://, s3://) with the contents of the artifacts, e.g. as written by sync or snapshot, for --offline (config: configure.sourceDir)")
+	configureCmd.Flags().String("report-file", "", "File to write the statistics and timings of the run to as JSON (config: configure.reportFile)")
+	configureCmd.Flags().String("changed-artifacts-file", "", "File to write the IDs of the artifacts changed or deployed in the run to, one per line or as JSON for a .json file (config: configure.changedArtifactsFile)")
+	configureCmd.Flags().String("audit-snapshot", "", "File to write the configuration values of the targeted artifacts before and after the run to as JSON, with all changes (config: configure.auditSnapshot)")
+	configureCmd.Flags().String("history-db", "", "SQLite database to record the statistics and per-artifact outcomes and durations of each run in, e.g. ~/.flashpipe/history.db, requires the sqlite3 shell (config: configure.historyDb)")
+	configureCmd.Flags().StringSlice("tenants", nil, "Comma separated list of targets (by name) to apply the configuration to, defaults to all targets (config: configure.tenants)")
+	configureCmd.Flags().Int("deploy-timeout", 0, "Maximum seconds to wait for the deployment of each artifact, 0 to only limit the number of status checks (config: configure.deployTimeoutSeconds)")
+	configureCmd.Flags().Bool("cascade-redeploy", false, "Redeploy the deployed integration flows that reference script collections or value mappings deployed in the run (config: configure.cascadeRedeploy)")
+	configureCmd.Flags().String("unknown-parameters", flashpipe.UnknownParametersWarn, "Handling of parameters that do not exist in the artifact: warn (skip them), error (fail the artifact) or ignore (config: configure.unknownParameters)")
+	configureCmd.Flags().String("draft-handling", draftHandlingDeploy, "Handling of artifacts to be deployed that are in draft version: error, deploy (the draft) or versionFirst (save a version first) (config: configure.draftHandling)")
+	configureCmd.Flags().Bool("skip-unchanged", false, "Skip the update of parameters that are already set to the configured value (config: configure.skipUnchanged)")
+	configureCmd.Flags().Bool("force-deploy", false, "Deploy artifacts even if their designtime version is already running and no parameter changed (config: configure.forceDeploy)")
+	configureCmd.Flags().Bool("preflight", true, "Check the permissions of the credentials on the tenant before starting (config: configure.preflight)")
+	configureCmd.Flags().Int("parallel-tenants", 1, "Number of targets configured in parallel (config: configure.parallelTenants)")
+	configureCmd.Flags().Int("lock-retry", 0, "Number of retries with backoff of artifacts locked by another user, starting after 30 seconds (config: configure.lockRetry)")
+	configureCmd.Flags().Int("max-changes", 0, "Abort before applying anything if more parameters would be changed on a tenant, 0 for no limit (config: configure.maxChanges)")
+	configureCmd.Flags().Int("max-changed-artifacts", 0, "Abort before applying anything if the parameters of more artifacts would be changed on a tenant, 0 for no limit (config: configure.maxChangedArtifacts)")
+	configureCmd.Flags().String("prefix-check", prefixCheckOff, "Check before applying anything that the configured artifacts exist on the tenant with the deployment prefix and report the missing and extra artifacts: off, warn or error (abort if artifacts are missing) (config: configure.prefixCheck)")
+	configureCmd.Flags().Int("parallel-packages", 1, "Number of packages configured in parallel, the messages of each package are written as one block (config: configure.parallelPackages)")
+	configureCmd.Flags().Int("package-delay", 0, "Seconds to pause after a package starts or ends before the next package starts (config: configure.packageDelaySeconds)")
+	configureCmd.Flags().Bool("gitops-diff", false, "Compare the tenant with the configuration and write the outcome as a Kubernetes manifest with health annotations to stdout, for Argo CD config management plugins (config: configure.gitopsDiff)")
+	configureCmd.Flags().Bool("gitops-apply", false, "Apply the configuration, then write the outcome like --gitops-diff. Fails without manifest if the configuration fails (config: configure.gitopsApply)")
+	configureCmd.Flags().String("gitops-name", "", "Name of the manifest of --gitops-diff and --gitops-apply after flashpipe-, defaults to the name of the Argo CD application (config: configure.gitopsName)")
+	configureCmd.Flags().Int("ramp-up", 0, "Seconds over which the packages configured in parallel increase from 1 to --parallel-packages (config: configure.rampUpSeconds)")
+	addApprovalFlags(configureCmd)
+	addImpactFlags(configureCmd)
+	addWindowFlags(configureCmd)
+
+	// Destination service used to resolve valueFrom.destination references
+	configureCmd.PersistentFlags().String("destination-host", "", "Host of Destination service REST API excluding https:// (config: configure.destination.host)")
+	configureCmd.PersistentFlags().String("destination-oauth-host", "", "Host for OAuth token server of Destination service excluding https:// (config: configure.destination.oauthHost)")
+	configureCmd.PersistentFlags().String("destination-oauth-path", "/oauth/token", "Path for OAuth token server of Destination service (config: configure.destination.oauthPath)")
+	configureCmd.PersistentFlags().String("destination-clientid", "", "Client ID of Destination service instance (config: configure.destination.clientId)")
+	configureCmd.PersistentFlags().String("destination-clientsecret", "", "Client Secret of Destination service instance (config: configure.destination.clientSecret)")
 
 	return configureCmd
 }
@@ -211,422 +366,1009 @@ func runConfigure(cmd *cobra.Command, configPath, deploymentPrefix, packageFilte
 	packageFilter := parseFilter(packageFilterStr)
 	artifactFilter := parseFilter(artifactFilterStr)
 
-	// Load configuration from file or folder
-	log.Info().Msgf("Loading configuration from: %s", configPath)
-	configFiles, err := loadConfigureConfigs(configPath)
+	// The impact of the deployment phase is shown by a dry run
+	impact, err := newImpactGate(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
+	}
+	if impact.enabled() {
+		dryRun = true
 	}
 
-	log.Info().Msgf("Loaded %d configuration file(s)", len(configFiles))
 	log.Info().Msgf("Deployment prefix: %s", deploymentPrefix)
 	log.Info().Msgf("Dry run: %v", dryRun)
-	log.Info().Msgf("Batch processing: %v (size: %d)", !disableBatch, batchSize)
+	disableChangeset := config.GetBoolWithFallback(cmd, "disable-changeset", "configure.disableChangeset")
+	log.Info().Msgf("Batch processing: %v (size: %d, atomic changesets: %v)", !disableBatch, batchSize, !disableChangeset)
 
-	// Merge all configurations
-	configData := mergeConfigureConfigs(configFiles, deploymentPrefix)
+	// Load and merge all configurations
+	configData, err := loadConfigureData(cmd, configPath, deploymentPrefix)
+	if err != nil {
+		return err
+	}
 
-	// Apply deployment prefix if specified
-	if deploymentPrefix != "" {
-		configData.DeploymentPrefix = deploymentPrefix
+	// Approval gate checked before the deployment phase
+	validateOnly := config.GetBoolWithFallback(cmd, "validate-only", "configure.validateOnly")
+	offline := config.GetBoolWithFallback(cmd, "offline", "configure.offline")
+	var deployApproval *deploymentApproval
+	if !dryRun && !validateOnly && !offline {
+		if deployApproval, err = newDeploymentApproval(cmd); err != nil {
+			return err
+		}
 	}
 
-	// Initialize stats
-	stats := &ConfigureStats{}
+	// Apply to the tenants of the targets block if present
+	tenants := config.GetStringSliceWithFallback(cmd, "tenants", "configure.tenants")
+	targets, err := selectConfigureTargets(configData.Targets, tenants)
+	if err != nil {
+		return err
+	}
+	if validateOnly {
+		return runValidateOnly(cmd, configData, targets, packageFilter, artifactFilter)
+	}
+	if offline {
+		limits, err := newChangeLimits(cmd)
+		if err != nil {
+			return err
+		}
+		return runOffline(cmd, configData, targets, packageFilter, artifactFilter, limits)
+	}
+	reportFile := config.GetStringWithFallback(cmd, "report-file", "configure.reportFile")
+	historyDb := config.GetStringWithFallback(cmd, "history-db", "configure.historyDb")
+	if historyDb != "" {
+		// Checked before the run, so that its history is not lost at the end
+		if _, err := history.NewStore(historyDb); err != nil {
+			return err
+		}
+	}
+	changedArtifactsFile := config.GetStringWithFallback(cmd, "changed-artifacts-file", "configure.changedArtifactsFile")
+	auditSnapshot := config.GetStringWithFallback(cmd, "audit-snapshot", "configure.auditSnapshot")
+	preflight := config.GetBoolWithFallback(cmd, "preflight", "configure.preflight")
+	forceDeploy := config.GetBoolWithFallback(cmd, "force-deploy", "configure.forceDeploy")
+	skipUnchanged := config.GetBoolWithFallback(cmd, "skip-unchanged", "configure.skipUnchanged")
+	cascadeRedeploy := config.GetBoolWithFallback(cmd, "cascade-redeploy", "configure.cascadeRedeploy")
+	deployTimeout := time.Duration(config.GetIntWithFallback(cmd, "deploy-timeout", "configure.deployTimeoutSeconds")) * time.Second
+	unknownParameters := config.GetStringWithFallback(cmd, "unknown-parameters", "configure.unknownParameters")
+	if err := validateUnknownParameters(unknownParameters); err != nil {
+		return err
+	}
+	draftHandling := config.GetStringWithFallback(cmd, "draft-handling", "configure.draftHandling")
+	if err := validateDraftHandling(draftHandling); err != nil {
+		return err
+	}
+	parallelPackages := config.GetIntWithFallback(cmd, "parallel-packages", "configure.parallelPackages")
+	lockRetries := config.GetIntWithFallback(cmd, "lock-retry", "configure.lockRetry")
+	limits, err := newChangeLimits(cmd)
+	if err != nil {
+		return err
+	}
+	if len(targets) > 0 {
+		parallelTenants := config.GetIntWithFallback(cmd, "parallel-tenants", "configure.parallelTenants")
+		return configureTargets(configData, targets, parallelTenants, tenantOptions{
+			packageFilter:        packageFilter,
+			artifactFilter:       artifactFilter,
+			dryRun:               dryRun,
+			deployRetries:        deployRetries,
+			deployDelaySeconds:   deployDelaySeconds,
+			parallelDeployments:  parallelDeployments,
+			batchSize:            batchSize,
+			disableBatch:         disableBatch,
+			disableChangeset:     disableChangeset,
+			forceDeploy:          forceDeploy,
+			skipUnchanged:        skipUnchanged,
+			cascadeRedeploy:      cascadeRedeploy,
+			unknownParameters:    unknownParameters,
+			draftHandling:        draftHandling,
+			parallelPackages:     parallelPackages,
+			lockRetries:          lockRetries,
+			deployTimeout:        deployTimeout,
+			approval:             deployApproval,
+			impact:               impact,
+			window:               newWindowPolicy(cmd),
+			pacing:               newPacingPolicy(cmd),
+			limits:               limits,
+			reportFile:           reportFile,
+			changedArtifactsFile: changedArtifactsFile,
+			historyDb:            historyDb,
+			auditSnapshot:        auditSnapshot,
+			preflight:            preflight,
+		})
+	}
 
 	// Get service details
 	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
 	exe := api.InitHTTPExecuter(serviceDetails)
+	if preflight {
+		if err := checkPermissions(exe, configureCapabilities(configData, dryRun)...); err != nil {
+			return err
+		}
+	}
+
+	stats, err := withAuditSnapshot(exe, configData, packageFilter, artifactFilter, auditSnapshot, func() (*ConfigureStats, error) {
+		return configureTenant(exe, configData, packageFilter, artifactFilter,
+			dryRun, deployRetries, deployDelaySeconds, parallelDeployments, batchSize, disableBatch, disableChangeset, forceDeploy, skipUnchanged, cascadeRedeploy, unknownParameters, draftHandling, parallelPackages, lockRetries, deployTimeout, deployApproval, impact, newWindowPolicy(cmd), newPacingPolicy(cmd), limits)
+	})
+	if dryRun {
+		printEstimate(stats, tenantOptions{batchSize: batchSize, disableBatch: disableBatch, disableChangeset: disableChangeset,
+			forceDeploy: forceDeploy, skipUnchanged: skipUnchanged, parallelDeployments: parallelDeployments, historyDb: historyDb}, exe.Host())
+	}
+	if err == nil && (stats.ArtifactsFailed.Value() > 0 || stats.DeploymentTasksFailed.Value() > 0 || stats.HooksFailed.Value() > 0) {
+		err = fmt.Errorf("configuration/deployment completed with errors")
+	} else if err == nil && stats.ArtifactsLocked.Value() > 0 {
+		err = fmt.Errorf("%d artifact(s) skipped as locked by another user", stats.ArtifactsLocked.Value())
+	}
+
+	// Record the run in the report file and history database
+	results := []targetResult{{Target: models.ConfigureTarget{Name: exe.Host(), Host: exe.Host()}, Stats: stats, Error: err}}
+	if reportErr := writeConfigureReport(results, reportFile); reportErr != nil {
+		log.Error().Msgf("Failed to write report: %v", reportErr)
+	}
+	if changedErr := writeChangedArtifacts(results, changedArtifactsFile); changedErr != nil {
+		log.Error().Msgf("Failed to write changed artifacts: %v", changedErr)
+	}
+	recordHistory(results, historyDb, dryRun)
+	return err
+}
+
+// configureTenant configures the artifacts on a tenant and deploys them if requested
+func configureTenant(exe *httpclnt.HTTPExecuter, configData *models.ConfigureConfig, packageFilter, artifactFilter []string,
+	dryRun bool, deployRetries, deployDelaySeconds, parallelDeployments, batchSize int, disableBatch, disableChangeset, forceDeploy, skipUnchanged, cascadeRedeploy bool,
+	unknownParameters, draftHandling string, parallelPackages, lockRetries int, deployTimeout time.Duration, approval *deploymentApproval, impact *impactGate, window windowPolicy, pacing pacingPolicy, limits changeLimits) (*ConfigureStats, error) {
+
+	// Initialize stats, latencies of requests sent before the run are not included
+	stats := &ConfigureStats{}
+	start := time.Now()
+	exe.TakeLatencies()
 
 	// Phase 1: Configure all artifacts
-	log.Info().Msg("")
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-	log.Info().Msg("PHASE 1: CONFIGURING ARTIFACTS")
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	logger.Banner(logger.SeparatorDouble, "PHASE 1: CONFIGURING ARTIFACTS")
+
+	if err := runHooks(configData.Hooks, HookContext{Phase: HookPreConfigure, Scope: "run", DryRun: dryRun}); err != nil {
+		return nil, err
+	}
+
+	// Fall back to individual requests once for the run instead of failing each batch
+	if !dryRun && !disableBatch && !api.DetectFeatures(exe).Supports(api.FeatureBatch) {
+		log.Warn().Msgf("%s does not support $batch, parameters are updated with individual requests", exe.Host())
+		stats.AddWarning("%s does not support $batch, parameters were updated with individual requests", exe.Host())
+		disableBatch = true
+	}
 
 	deploymentTasks, err := configureAllArtifacts(exe, configData, packageFilter, artifactFilter,
-		stats, dryRun, batchSize, disableBatch)
+		stats, dryRun, impact.enabled(), batchSize, disableBatch, disableChangeset, forceDeploy, skipUnchanged, unknownParameters, draftHandling, parallelPackages, lockRetries, pacing, limits)
 	if err != nil {
-		return err
+		finishTimings(exe, stats, start)
+		return stats, err
+	}
+	stats.SetConfigureDuration(time.Since(start))
+
+	var configureErr error
+	if stats.ArtifactsFailed.Value() > 0 {
+		configureErr = fmt.Errorf("%d artifact(s) failed to be configured", stats.ArtifactsFailed.Value())
+	}
+	if err := runHooks(configData.Hooks, HookContext{Phase: HookPostConfigure, Scope: "run", DryRun: dryRun, Error: errorString(configureErr)}); err != nil {
+		log.Error().Msg(err.Error())
+		stats.HooksFailed.Inc()
+	}
+
+	// The remaining requests would be rejected as well
+	if err := exe.AbortError(); err != nil {
+		log.Error().Msgf("Run aborted: %v", err)
+		finishTimings(exe, stats, start)
+		printConfigureSummary(stats, dryRun)
+		return stats, err
+	}
+
+	// Impact of the deployment phase of a dry run with --what-if
+	if len(deploymentTasks) > 0 && dryRun && impact.enabled() {
+		if err := showImpact(exe, deploymentTasks, cascadeRedeploy); err != nil {
+			log.Error().Msgf("Impact analysis failed: %v", err)
+			finishTimings(exe, stats, start)
+			printConfigureSummary(stats, dryRun)
+			return stats, err
+		}
 	}
 
 	// Phase 2: Deploy artifacts if requested
 	if len(deploymentTasks) > 0 && !dryRun {
-		log.Info().Msg("")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-		log.Info().Msg("PHASE 2: DEPLOYING CONFIGURED ARTIFACTS")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+		logger.Banner(logger.SeparatorDouble, "PHASE 2: DEPLOYING CONFIGURED ARTIFACTS")
 		log.Info().Msgf("Deploying %d artifacts with max %d parallel deployments per package",
 			len(deploymentTasks), parallelDeployments)
 
-		err := deployConfiguredArtifacts(exe, deploymentTasks, deployRetries, deployDelaySeconds,
-			parallelDeployments, stats)
+		var artifactIDs []string
+		for _, t := range deploymentTasks {
+			artifactIDs = append(artifactIDs, t.ArtifactID)
+		}
+		// Dependent flows are looked up before the approval, so that they are approved as well
+		dependents := &cascade{}
+		if cascadeRedeploy {
+			var err error
+			if dependents, err = findDependentFlows(exe, deploymentTasks); err != nil {
+				log.Error().Msgf("Failed to find integration flows referencing the deployed artifacts: %v", err)
+				stats.DeploymentTasksFailed.Inc()
+				dependents = &cascade{}
+			}
+			for _, id := range dependents.artifactIDs() {
+				if !slices.Contains(artifactIDs, id) {
+					artifactIDs = append(artifactIDs, id)
+				}
+			}
+		}
+		err := impact.check(exe, deploymentTasks, dependents)
+		if err == nil {
+			err = approval.approve(exe.Host(), artifactIDs)
+		}
 		if err != nil {
-			log.Error().Msgf("Deployment phase failed: %v", err)
+			log.Error().Msgf("Deployment phase skipped: %v", err)
+			finishTimings(exe, stats, start)
+			printConfigureSummary(stats, dryRun)
+			return stats, err
+		}
+
+		if err := runHooks(configData.Hooks, HookContext{Phase: HookPreDeploy, Scope: "run"}); err != nil {
+			log.Error().Msgf("Deployment phase skipped: %v", err)
+			stats.HooksFailed.Inc()
+		} else {
+			deployStart := time.Now()
+			hooks := newDeploymentHooks(configData)
+			deployed, err := deployConfiguredArtifacts(exe, deploymentTasks, hooks, deployRetries, deployDelaySeconds,
+				parallelDeployments, deployTimeout, window, stats)
+			if err == nil {
+				if tasks := dependents.triggered(deployed); len(tasks) > 0 {
+					log.Info().Msgf("Redeploying %d integration flow(s) referencing the deployed artifacts", len(tasks))
+					stats.DeploymentTasksQueued.Add(len(tasks))
+					_, err = deployConfiguredArtifacts(exe, tasks, hooks, deployRetries, deployDelaySeconds,
+						parallelDeployments, deployTimeout, window, stats)
+				}
+			}
+			stats.Timings.Deploy = time.Since(deployStart)
+			if err != nil {
+				log.Error().Msgf("Deployment phase failed: %v", err)
+			}
+
+			var deployErr error
+			if stats.DeploymentTasksFailed.Value() > 0 {
+				deployErr = fmt.Errorf("%d deployment(s) failed", stats.DeploymentTasksFailed.Value())
+			}
+			if err := runHooks(configData.Hooks, HookContext{Phase: HookPostDeploy, Scope: "run", Error: errorString(deployErr)}); err != nil {
+				log.Error().Msg(err.Error())
+				stats.HooksFailed.Inc()
+			}
 		}
 	}
 
+	// Record the run on the changed packages
+	if configData.Stamp != nil && !dryRun {
+		stampPackages(exe, configData.Stamp, stats)
+	}
+
 	// Print summary
+	finishTimings(exe, stats, start)
 	printConfigureSummary(stats, dryRun)
 
-	// Return error if there were failures
-	if stats.ArtifactsFailed > 0 || stats.DeploymentTasksFailed > 0 {
-		return fmt.Errorf("configuration/deployment completed with errors")
-	}
-
-	return nil
+	return stats, nil
 }
 
-// ConfigureConfigFile represents a loaded config file with metadata
-type ConfigureConfigFile struct {
-	Config   *models.ConfigureConfig
-	Source   string
-	FileName string
+// finishTimings sets the total duration of the run and the latency of the requests sent during the run
+func finishTimings(exe *httpclnt.HTTPExecuter, stats *ConfigureStats, start time.Time) {
+	latencies := exe.TakeLatencies()
+	stats.Timings.Total = time.Since(start)
+	stats.Timings.APIRequests = len(latencies)
+	stats.Timings.APILatencyP95 = flashpipe.Percentile(latencies, 95)
 }
 
-func loadConfigureConfigs(path string) ([]*ConfigureConfigFile, error) {
-	// Check if path is a file or directory
-	info, err := os.Stat(path)
+// loadConfigureData loads the configuration files at configPath, merges them into a
+// single configuration and resolves parameter values from external sources
+func loadConfigureData(cmd *cobra.Command, configPath, deploymentPrefix string) (*models.ConfigureConfig, error) {
+	configFiles, values, err := loadConfigureFiles(cmd, configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to access path: %w", err)
+		return nil, err
 	}
 
-	if info.IsDir() {
-		return loadConfigureConfigsFromFolder(path)
+	// Resolve parameters set to different values in several files
+	onConflict := config.GetStringWithFallback(cmd, "on-conflict", "configure.onConflict")
+	if err := flashpipe.ResolveConflicts(configFiles, onConflict); err != nil {
+		return nil, err
 	}
-	return loadConfigureConfigFromFile(path)
-}
 
-func loadConfigureConfigFromFile(path string) ([]*ConfigureConfigFile, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
-	}
+	// Merge all configurations
+	configData := flashpipe.MergeConfigs(configFiles, deploymentPrefix)
 
-	var cfg models.ConfigureConfig
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	// Apply deployment prefix if specified
+	if deploymentPrefix != "" {
+		configData.DeploymentPrefix = deploymentPrefix
 	}
 
-	return []*ConfigureConfigFile{
-		{
-			Config:   &cfg,
-			Source:   path,
-			FileName: filepath.Base(path),
-		},
-	}, nil
-}
-
-func loadConfigureConfigsFromFolder(folderPath string) ([]*ConfigureConfigFile, error) {
-	var configFiles []*ConfigureConfigFile
-
-	entries, err := os.ReadDir(folderPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read directory: %w", err)
+	// Skip packages and artifacts whose when condition is false. The conditions are evaluated for each
+	// target when it is configured, and checked for all targets here.
+	configData.Conditions = &models.Conditions{
+		Environment: config.GetStringWithFallback(cmd, "environment", "configure.environment"),
+		Values:      values,
 	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+	if len(configData.Targets) == 0 {
+		if configData, err = flashpipe.ApplyConditions(configData, "", config.GetString(cmd, "tmn-host")); err != nil {
+			return nil, err
 		}
-
-		// Match YAML files (*.yml, *.yaml)
-		name := entry.Name()
-		if !strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml") {
-			continue
+	}
+	for _, target := range configData.Targets {
+		if _, err := flashpipe.ApplyConditions(configData, target.Name, target.Host); err != nil {
+			return nil, fmt.Errorf("target %s: %w", target.Name, err)
 		}
+	}
 
-		filePath := filepath.Join(folderPath, name)
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			log.Warn().Msgf("Failed to read config file %s: %v", name, err)
-			continue
+	// Select the artifacts by their tags and those of their packages
+	tags := config.GetStringSliceWithFallback(cmd, "tags", "configure.tags")
+	excludeTags := config.GetStringSliceWithFallback(cmd, "exclude-tags", "configure.excludeTags")
+	if len(tags) > 0 || len(excludeTags) > 0 {
+		configData = flashpipe.SelectTags(configData, str.TrimSlice(tags), str.TrimSlice(excludeTags))
+		selected := 0
+		for _, pkg := range configData.Packages {
+			selected += len(pkg.Artifacts)
 		}
+		log.Info().Msgf("Selected %d artifact(s) by tags", selected)
+	}
 
-		var cfg models.ConfigureConfig
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
-			log.Warn().Msgf("Failed to parse config file %s: %v", name, err)
-			continue
+	// Replace type aliases and reject invalid maintenance windows, deployment strategies, draft handlings and
+	// parameter modes before anything is changed
+	if err := flashpipe.ResolveArtifactTypes(configData); err != nil {
+		return nil, fmt.Errorf("invalid artifact types: %w", err)
+	}
+	if err := validateWindows(configData); err != nil {
+		return nil, err
+	}
+	if err := validateDeployStrategies(configData); err != nil {
+		return nil, err
+	}
+	if err := validateArtifactDraftHandling(configData); err != nil {
+		return nil, err
+	}
+	if err := validateParameterModes(configData); err != nil {
+		return nil, err
+	}
+	if fallback := config.GetStringWithFallback(cmd, "batch-fallback", "configure.batchFallback"); fallback != "" {
+		configData.BatchFallback = fallback
+	}
+	if err := validateBatchFallbacks(configData); err != nil {
+		return nil, err
+	}
+	target := config.GetStringWithFallback(cmd, "stamp", "configure.stamp")
+	revision := config.GetStringWithFallback(cmd, "stamp-revision", "configure.stampRevision")
+	if target != "" || (revision != "" && configData.Stamp != nil) {
+		stamp := models.ConfigureStamp{}
+		if configData.Stamp != nil {
+			stamp = *configData.Stamp
 		}
+		stamp.Target = cmp.Or(target, stamp.Target)
+		stamp.Revision = cmp.Or(revision, stamp.Revision)
+		configData.Stamp = &stamp
+	}
+	if err := validateStamp(configData); err != nil {
+		return nil, err
+	}
 
-		configFiles = append(configFiles, &ConfigureConfigFile{
-			Config:   &cfg,
-			Source:   filePath,
-			FileName: name,
-		})
+	// Resolve parameter values from external sources (e.g. BTP destinations)
+	if err := resolveParameterValueSources(cmd, configData); err != nil {
+		return nil, fmt.Errorf("failed to resolve parameter values: %w", err)
 	}
 
-	if len(configFiles) == 0 {
-		return nil, fmt.Errorf("no valid configuration files found in folder: %s", folderPath)
+	// Reject malformed values, e.g. of endpoints, before they are written to the tenant
+	if errs := flashpipe.ValidateParameterValues(configData); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid parameter values: %w", errors.Join(errs...))
 	}
 
-	log.Info().Msgf("Loaded %d configuration file(s) from folder", len(configFiles))
-	return configFiles, nil
+	return configData, nil
 }
 
-func mergeConfigureConfigs(configFiles []*ConfigureConfigFile, overridePrefix string) *models.ConfigureConfig {
-	merged := &models.ConfigureConfig{
-		Packages: []models.ConfigurePackage{},
+// loadConfigureFiles fetches and loads the configuration files at configPath, and returns them with the values
+// they were rendered with
+func loadConfigureFiles(cmd *cobra.Command, configPath string) ([]*flashpipe.ConfigFile, map[string]interface{}, error) {
+	// Load values files used for templating of the configuration files
+	valuesFiles := config.GetStringSliceWithFallback(cmd, "values", "configure.values")
+	values, err := flashpipe.LoadValuesFiles(valuesFiles)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load values: %w", err)
 	}
 
-	// Use override prefix if provided, otherwise use first config's prefix
-	if overridePrefix != "" {
-		merged.DeploymentPrefix = overridePrefix
-	} else if len(configFiles) > 0 && configFiles[0].Config.DeploymentPrefix != "" {
-		merged.DeploymentPrefix = configFiles[0].Config.DeploymentPrefix
+	// Fetch remote configuration and verify its checksum and signature
+	publicKey := config.GetStringWithFallback(cmd, "public-key", "configure.publicKey")
+	if config.GetBoolWithFallback(cmd, "verify-signature", "configure.verifySignature") && publicKey == "" {
+		return nil, nil, fmt.Errorf("--verify-signature requires --public-key (set via CLI flag or in config file under 'configure.publicKey')")
+	}
+	recursive := config.GetBoolWithFallback(cmd, "recursive", "configure.recursive")
+	fetchOptions := remote.Options{
+		Checksum:  config.GetStringWithFallback(cmd, "config-checksum", "configure.configChecksum"),
+		PublicKey: publicKey,
+		Signature: config.GetStringWithFallback(cmd, "config-signature", "configure.configSignature"),
+		Recursive: recursive,
+	}
+	localPath, cleanup, err := remote.Fetch(configPath, fetchOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch configuration: %w", err)
+	}
+	defer cleanup()
+	// Files referenced by the configuration are verified like the configuration itself
+	verifyFile, err := remote.ReferencedFileVerifier(fetchOptions)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Merge all packages from all config files
-	for _, configFile := range configFiles {
-		log.Info().Msgf("  Merging packages from: %s", configFile.FileName)
-		merged.Packages = append(merged.Packages, configFile.Config.Packages...)
+	// Load configuration from file or folder
+	log.Info().Msgf("Loading configuration from: %s", localPath)
+	configFiles, err := flashpipe.LoadConfigFilesWithOptions(localPath, values, flashpipe.LoadOptions{
+		Recursive:  recursive,
+		Template:   config.GetBoolWithFallback(cmd, "template", "configure.template"),
+		VerifyFile: verifyFile,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
+	log.Info().Msgf("Loaded %d configuration file(s)", len(configFiles))
 
-	return merged
+	return configFiles, values, nil
 }
 
 func configureAllArtifacts(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig,
-	packageFilter, artifactFilter []string, stats *ConfigureStats, dryRun bool,
-	batchSize int, disableBatch bool) ([]DeploymentTask, error) {
+	packageFilter, artifactFilter []string, stats *ConfigureStats, dryRun, whatIf bool,
+	batchSize int, disableBatch, disableChangeset, forceDeploy, skipUnchanged bool, unknownParameters, draftHandling string, parallelPackages, lockRetries int, pacing pacingPolicy, limits changeLimits) ([]DeploymentTask, error) {
 
 	var deploymentTasks []DeploymentTask
-	configuration := api.NewConfiguration(exe)
+	configs := newConfigurationReader(api.NewConfigurationService(exe))
+	if !dryRun && !disableBatch {
+		configs.prefetch(cfg, packageFilter, artifactFilter, batchSize)
+	}
+	settings := packageSettings{exe: exe, configs: configs, deploymentPrefix: cfg.DeploymentPrefix, artifactFilter: artifactFilter,
+		dryRun: dryRun, whatIf: whatIf, batchSize: batchSize, disableBatch: disableBatch, disableChangeset: disableChangeset,
+		forceDeploy: forceDeploy, skipUnchanged: skipUnchanged, unknownParameters: unknownParameters, draftHandling: draftHandling,
+		createMissing: cfg.CreateMissing, batchFallback: cmp.Or(cfg.BatchFallback, flashpipe.BatchFallbackAll), lockRetries: lockRetries}
 
+	var packages []models.ConfigurePackage
 	for _, pkg := range cfg.Packages {
-		stats.PackagesProcessed++
-
-		// Apply deployment prefix to package ID
-		packageID := pkg.ID
-		if cfg.DeploymentPrefix != "" {
-			packageID = cfg.DeploymentPrefix + packageID
-		}
+		stats.PackagesProcessed.Inc()
 
 		// Apply package filter
 		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
-			log.Info().Msgf("Skipping package %s (filtered out)", packageID)
+			log.Info().Msgf("Skipping package %s (filtered out)", cfg.DeploymentPrefix+pkg.ID)
+			stats.AddWarning("Package %s skipped (filtered out)", cfg.DeploymentPrefix+pkg.ID)
 			continue
 		}
+		packages = append(packages, pkg)
+	}
+	if err := checkPrefix(settings, packages, limits.prefixCheck, stats); err != nil {
+		return nil, err
+	}
+	if err := checkChangeLimits(settings, packages, limits); err != nil {
+		return nil, err
+	}
 
-		log.Info().Msg("")
-		log.Info().Msgf("📦 Processing package: %s", packageID)
-		if pkg.DisplayName != "" {
-			log.Info().Msgf("   Display Name: %s", pkg.DisplayName)
+	if parallelPackages <= 1 {
+		p := newPacer(pacing, 1)
+		for _, pkg := range packages {
+			p.acquire()
+			deploymentTasks = append(deploymentTasks, configurePackage(settings, pkg, stats, &log.Logger)...)
+			p.release()
 		}
+		return deploymentTasks, nil
+	}
 
-		packageHasError := false
+	// Packages are configured concurrently into the shared statistics, the messages of each package are written
+	// as one block when it is done and the deployment tasks are combined in the order of the packages
+	log.Info().Msgf("Configuring %d packages with max %d in parallel", len(packages), parallelPackages)
+	results := make([][]DeploymentTask, len(packages))
+	p := newPacer(pacing, parallelPackages)
+	packageTasks := make([]pipeline.Task, len(packages))
+	for i, pkg := range packages {
+		// Packages can appear in several configuration files, so the ID of a task includes its position
+		packageTasks[i] = pipeline.Task{ID: fmt.Sprintf("%d:%s", i, pkg.ID), Run: func() error {
+			p.acquire()
+			defer p.release()
+
+			l, buffer := logger.NewBuffered()
+			defer buffer.Flush()
+			results[i] = configurePackage(settings, pkg, stats, &l)
+			return nil
+		}}
+	}
+	if _, err := pipeline.Run(packageTasks, pipeline.Options{Parallel: parallelPackages}); err != nil {
+		return nil, err
+	}
 
-		for _, artifact := range pkg.Artifacts {
-			stats.ArtifactsProcessed++
+	for _, tasks := range results {
+		deploymentTasks = append(deploymentTasks, tasks...)
+	}
+	return deploymentTasks, nil
+}
 
-			// Apply deployment prefix to artifact ID
-			artifactID := artifact.ID
-			if cfg.DeploymentPrefix != "" {
-				artifactID = cfg.DeploymentPrefix + artifactID
-			}
+// packageSettings are the settings of a run used to configure the artifacts of each package
+type packageSettings struct {
+	exe               *httpclnt.HTTPExecuter
+	configs           *configurationReader
+	deploymentPrefix  string
+	artifactFilter    []string
+	dryRun            bool
+	whatIf            bool // Dry run queuing the deployment tasks for the impact analysis
+	batchSize         int
+	disableBatch      bool
+	disableChangeset  bool
+	forceDeploy       bool
+	skipUnchanged     bool
+	unknownParameters string
+	draftHandling     string
+	createMissing     bool   // Create parameters not found in the artifacts, see createMissingParameters
+	batchFallback     string // Handling of failed batch requests, see batchFallback
+	lockRetries       int
+}
 
-			// Apply artifact filter
-			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) {
-				log.Info().Msgf("   Skipping artifact %s (filtered out)", artifactID)
-				continue
-			}
+// configurePackage configures the artifacts of a package and returns the deployment tasks of the artifacts
+// to be deployed. Messages are written to l.
+func configurePackage(s packageSettings, pkg models.ConfigurePackage, stats *ConfigureStats, l *zerolog.Logger) []DeploymentTask {
+	var deploymentTasks []DeploymentTask
 
-			log.Info().Msg("")
-			log.Info().Msgf("   🔧 Configuring artifact: %s", artifactID)
-			if artifact.DisplayName != "" {
-				log.Info().Msgf("      Display Name: %s", artifact.DisplayName)
-			}
-			log.Info().Msgf("      Type: %s", artifact.Type)
-			log.Info().Msgf("      Version: %s", artifact.Version)
-			log.Info().Msgf("      Parameters: %d", len(artifact.Parameters))
+	// Apply deployment prefix to package ID
+	packageID := s.deploymentPrefix + pkg.ID
 
-			// Validate artifact type
-			validTypes := []string{"Integration", "MessageMapping", "ScriptCollection", "ValueMapping"}
-			isValidType := false
-			for _, validType := range validTypes {
-				if artifact.Type == validType {
-					isValidType = true
-					break
-				}
+	l.Info().Msg("")
+	l.Info().Msgf("📦 Processing package: %s", packageID)
+	if pkg.DisplayName != "" {
+		l.Info().Msgf("   Display Name: %s", pkg.DisplayName)
+	}
+
+	if err := s.exe.AbortError(); err != nil {
+		l.Error().Msgf("   ❌ Skipping package: %v", err)
+		stats.AddWarning("Package %s skipped: %v", packageID, err)
+		stats.PackagesWithErrors.Inc()
+		return nil
+	}
+
+	packageHasError := false
+
+	packageCtx := HookContext{Scope: "package", PackageID: packageID, DryRun: s.dryRun, logger: l}
+	if err := runHooks(pkg.Hooks, packageCtx.withPhase(HookPreConfigure, nil)); err != nil {
+		l.Error().Msgf("   ❌ Skipping package: %v", err)
+		stats.HooksFailed.Inc()
+		stats.PackagesWithErrors.Inc()
+		return nil
+	}
+
+	for _, artifact := range pkg.Artifacts {
+		stats.ArtifactsProcessed.Inc()
+
+		// Apply deployment prefix to artifact ID
+		artifactID := artifact.ID
+		if s.deploymentPrefix != "" {
+			artifactID = s.deploymentPrefix + artifactID
+		}
+
+		// Apply artifact filter
+		if len(s.artifactFilter) > 0 && !shouldInclude(artifact.ID, s.artifactFilter) {
+			l.Info().Msgf("   Skipping artifact %s (filtered out)", artifactID)
+			stats.AddWarning("Artifact %s skipped (filtered out)", artifactID)
+			continue
+		}
+
+		span := telemetry.StartSpan("configure "+artifactID, "flashpipe.package.id", packageID,
+			"flashpipe.artifact.id", artifactID, "flashpipe.artifact.type", artifact.Type)
+		artifactStart := time.Now()
+		events.Emit(events.Event{Type: events.TypeArtifactStarted, Phase: events.PhaseConfigure, Tenant: s.exe.Host(),
+			PackageID: packageID, ArtifactID: artifactID, ArtifactType: artifact.Type, DryRun: s.dryRun})
+
+		l.Info().Msg("")
+		l.Info().Msgf("   🔧 Configuring artifact: %s", artifactID)
+		if artifact.DisplayName != "" {
+			l.Info().Msgf("      Display Name: %s", artifact.DisplayName)
+		}
+		l.Info().Msgf("      Type: %s", artifact.Type)
+		l.Info().Msgf("      Version: %s", artifact.Version)
+		l.Info().Msgf("      Parameters: %d", len(artifact.Parameters))
+
+		// Validate artifact type
+		validTypes := []string{"Integration", "MessageMapping", "ScriptCollection", "ValueMapping"}
+		isValidType := false
+		for _, validType := range validTypes {
+			if artifact.Type == validType {
+				isValidType = true
+				break
 			}
-			if !isValidType {
-				log.Error().Msgf("      ❌ Invalid artifact type: %s (valid types: %v)", artifact.Type, validTypes)
-				stats.ArtifactsFailed++
-				packageHasError = true
-				continue
+		}
+		if !isValidType {
+			l.Error().Msgf("      ❌ Invalid artifact type: %s (valid types: %v)", artifact.Type, validTypes)
+			stats.ArtifactsFailed.Inc()
+			packageHasError = true
+			recordConfiguredArtifact(stats, span, s.exe.Host(), packageID, artifactID, artifact.Parameters, artifactStart, false, fmt.Errorf("invalid artifact type: %s", artifact.Type))
+			continue
+		}
+
+		artifactCtx := HookContext{Scope: "artifact", PackageID: packageID, ArtifactID: artifactID,
+			ArtifactType: artifact.Type, DryRun: s.dryRun, logger: l}
+		if err := runHooks(artifact.Hooks, artifactCtx.withPhase(HookPreConfigure, nil)); err != nil {
+			l.Error().Msgf("      ❌ Skipping artifact: %v", err)
+			stats.HooksFailed.Inc()
+			stats.ArtifactsFailed.Inc()
+			packageHasError = true
+			recordConfiguredArtifact(stats, span, s.exe.Host(), packageID, artifactID, artifact.Parameters, artifactStart, false, err)
+			continue
+		}
+
+		for _, param := range artifact.Parameters {
+			if param.Source != nil {
+				l.Debug().Msgf("      Parameter %s from %s", param.Key, param.Source)
 			}
+		}
 
-			if dryRun {
-				log.Info().Msg("      [DRY RUN] Would update the following parameters:")
-				for _, param := range artifact.Parameters {
-					log.Info().Msgf("        - %s = %s", param.Key, param.Value)
+		if s.dryRun {
+			l.Info().Msg("      [DRY RUN] Would update the following parameters:")
+			for _, param := range artifact.Parameters {
+				if param.Mode != "" && param.Mode != flashpipe.ParameterModeSet {
+					l.Info().Msgf("        - %s = %s (mode %s)", param.Key, param.Value, param.Mode)
+				} else {
+					l.Info().Msgf("        - %s = %s", param.Key, param.Value)
 				}
-				stats.ArtifactsConfigured++
-				stats.ParametersUpdated += len(artifact.Parameters)
+			}
+			stats.ArtifactsConfigured.Inc()
+			stats.ParametersUpdated.Add(len(artifact.Parameters))
 
-				// Queue for deployment if requested
-				if artifact.Deploy || pkg.Deploy {
-					stats.DeploymentTasksQueued++
-					log.Info().Msgf("      [DRY RUN] Would deploy after configuration")
+			// Queue for deployment if requested
+			if artifact.Deploy || pkg.Deploy {
+				stats.DeploymentTasksQueued.Inc()
+				l.Info().Msgf("      [DRY RUN] Would deploy after configuration")
+				if s.whatIf {
+					deploymentTasks = append(deploymentTasks, DeploymentTask{ArtifactID: artifactID, ArtifactType: artifact.Type,
+						PackageID: packageID, DisplayName: artifact.DisplayName, Version: artifact.DeployVersion,
+						SkipIfDeployed: !s.forceDeploy && !configurationChanged(s.configs, artifactID, artifact.Version, artifact.Parameters, l)})
 				}
-				continue
 			}
+			_ = runHooks(artifact.Hooks, artifactCtx.withPhase(HookPostConfigure, nil))
+			events.Emit(events.Event{Type: events.TypeArtifactConfigured, Phase: events.PhaseConfigure, Tenant: s.exe.Host(),
+				PackageID: packageID, ArtifactID: artifactID, ArtifactType: artifact.Type, DryRun: true})
+			span.End(nil)
+			continue
+		}
 
-			// Determine batch settings
-			useBatch := !disableBatch
-			effectiveBatchSize := batchSize
+		// Determine batch settings
+		useBatch := !s.disableBatch
+		effectiveBatchSize := s.batchSize
+		fallback := s.batchFallback
 
-			if artifact.Batch != nil {
-				useBatch = artifact.Batch.Enabled && !disableBatch
-				if artifact.Batch.BatchSize > 0 {
-					effectiveBatchSize = artifact.Batch.BatchSize
-				}
+		if artifact.Batch != nil {
+			useBatch = artifact.Batch.Enabled && !s.disableBatch
+			if artifact.Batch.BatchSize > 0 {
+				effectiveBatchSize = artifact.Batch.BatchSize
 			}
-
-			// Update configuration parameters
-			var configErr error
-			if useBatch && len(artifact.Parameters) > 0 {
-				configErr = updateParametersBatch(exe, configuration, artifactID, artifact.Version,
-					artifact.Parameters, effectiveBatchSize, stats)
-			} else {
-				configErr = updateParametersIndividual(configuration, artifactID, artifact.Version,
-					artifact.Parameters, stats)
+			if artifact.Batch.Fallback != "" {
+				fallback = artifact.Batch.Fallback
 			}
+		}
 
-			if configErr != nil {
-				log.Error().Msgf("      ❌ Failed to configure artifact: %v", configErr)
-				stats.ArtifactsFailed++
-				packageHasError = true
-				continue
+		// Drafts are handled before the configuration, so that the version saved is the one configured and deployed
+		var configErr error
+		if artifact.Deploy || pkg.Deploy {
+			draftHandling := s.draftHandling
+			if artifact.DraftHandling != "" {
+				draftHandling = artifact.DraftHandling
 			}
+			configErr = handleDraft(s.exe, artifactID, artifact.Type, draftHandling, l)
+		}
 
-			stats.ArtifactsConfigured++
-			log.Info().Msgf("      ✅ Successfully configured %d parameters", len(artifact.Parameters))
+		// Update configuration parameters with the values resulting from their update mode
+		var parameters []models.ConfigurationParameter
+		if configErr == nil {
+			parameters, configErr = expandWildcardParameters(s.configs, artifactID, artifact.Version, artifact.Parameters, stats, l)
+		}
+		if configErr == nil {
+			parameters, configErr = resolveParameterModes(s.exe, s.configs, artifactID, artifact.Version, parameters, l)
+		}
+		if configErr == nil {
+			parameters, configErr = checkUnknownParameters(s.configs, artifactID, artifact.Version, parameters, s.unknownParameters,
+				s.createMissing || artifact.CreateMissing, stats, l)
+		}
+		configChanged := true
+		if configErr == nil {
+			// Compared before the update, as the runtime only picks up changed parameters on deployment. The
+			// configuration was read to check for unknown parameters, so the comparison needs no further request.
+			configChanged = configurationChanged(s.configs, artifactID, artifact.Version, parameters, l)
+			if s.skipUnchanged {
+				parameters = skipUnchangedParameters(s.configs, artifactID, artifact.Version, parameters, stats, l)
+			}
+			configErr = updateParameters(s, artifactID, artifact.Version, parameters, useBatch, effectiveBatchSize, fallback, stats, l)
+		}
+		s.configs.forget(artifactID, artifact.Version)
 
-			// Queue for deployment if requested
-			if artifact.Deploy || pkg.Deploy {
-				deploymentTasks = append(deploymentTasks, DeploymentTask{
-					ArtifactID:   artifactID,
-					ArtifactType: artifact.Type,
-					PackageID:    packageID,
-					DisplayName:  artifact.DisplayName,
-				})
-				stats.DeploymentTasksQueued++
-				log.Info().Msgf("      📋 Queued for deployment")
+		if err := runHooks(artifact.Hooks, artifactCtx.withPhase(HookPostConfigure, configErr)); err != nil {
+			l.Error().Msgf("      ❌ %v", err)
+			stats.HooksFailed.Inc()
+			if configErr == nil {
+				configErr = err
 			}
 		}
 
-		if packageHasError {
-			stats.PackagesWithErrors++
+		if isLocked(configErr) {
+			l.Warn().Msgf("      🔒 Skipping artifact locked by another user: %v", configErr)
+			stats.AddWarning("Artifact %s skipped, locked by another user", artifactID)
+			stats.ArtifactsLocked.Inc()
+			recordConfiguredArtifact(stats, span, s.exe.Host(), packageID, artifactID, artifact.Parameters, artifactStart, false, configErr)
+			continue
+		}
+		if configErr != nil {
+			l.Error().Msgf("      ❌ Failed to configure artifact: %v", configErr)
+			stats.ArtifactsFailed.Inc()
+			packageHasError = true
+			recordConfiguredArtifact(stats, span, s.exe.Host(), packageID, artifactID, artifact.Parameters, artifactStart, false, configErr)
+			continue
+		}
+
+		stats.ArtifactsConfigured.Inc()
+		l.Info().Msgf("      ✅ Successfully configured %d parameters%v", len(artifact.Parameters), logger.Took(time.Since(artifactStart)))
+		recordConfiguredArtifact(stats, span, s.exe.Host(), packageID, artifactID, artifact.Parameters, artifactStart, configChanged, nil)
+
+		// Queue for deployment if requested
+		if artifact.Deploy || pkg.Deploy {
+			deploymentTasks = append(deploymentTasks, DeploymentTask{
+				ArtifactID:   artifactID,
+				ArtifactType: artifact.Type,
+				PackageID:    packageID,
+				DisplayName:  artifact.DisplayName,
+				Version:      artifact.DeployVersion,
+				Window:       effectiveWindow(pkg.Window, artifact.Window),
+				Strategy:     artifact.Strategy,
+				Drain:        artifact.Drain,
+				BlueGreen:    artifact.BlueGreen,
+				// Skipped if the designtime version is already running
+				SkipIfDeployed: !s.forceDeploy && !configChanged,
+			})
+			stats.DeploymentTasksQueued.Inc()
+			l.Info().Msgf("      📋 Queued for deployment")
 		}
 	}
 
-	return deploymentTasks, nil
+	var packageErr error
+	if packageHasError {
+		packageErr = fmt.Errorf("package %s has artifacts that failed to be configured", packageID)
+	}
+	if err := runHooks(pkg.Hooks, packageCtx.withPhase(HookPostConfigure, packageErr)); err != nil {
+		l.Error().Msgf("   ❌ %v", err)
+		stats.HooksFailed.Inc()
+		packageHasError = true
+	}
+
+	if packageHasError {
+		stats.PackagesWithErrors.Inc()
+	}
+
+	return deploymentTasks
+}
+
+func recordConfiguredArtifact(stats *ConfigureStats, span *telemetry.Span, tenant, packageID, artifactID string,
+	parameters []models.ConfigurationParameter, start time.Time, changed bool, err error) {
+	stats.AddConfigurationResult(packageID, artifactID, changed, time.Since(start), err)
+	stats.AddParameterSources(packageID, artifactID, parameters)
+	events.Artifact(events.TypeArtifactConfigured, events.PhaseConfigure, tenant, packageID, artifactID, time.Since(start), err)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	telemetry.IncCounter("flashpipe_configure_artifacts_total", "Number of configured artifacts by result.", 1, "result", result)
+	span.End(err)
 }
 
-func updateParametersBatch(exe *httpclnt.HTTPExecuter, configuration *api.Configuration,
+// updateParametersBatch updates the parameters of an artifact with $batch requests. If atomic, all parameters
+// are sent in one changeset and either all or none of them are updated, otherwise they are sent in chunks
+// of batchSize with one changeset per parameter. If a batch request fails, the parameters are updated with
+// individual requests as set by fallback, see flashpipe.BatchFallbackAll.
+func updateParametersBatch(exe *httpclnt.HTTPExecuter, configs *configurationReader,
 	artifactID, version string, parameters []models.ConfigurationParameter,
-	batchSize int, stats *ConfigureStats) error {
+	batchSize int, atomic bool, fallback string, stats *ConfigureStats, l *zerolog.Logger) error {
 
-	log.Info().Msgf("      Using batch operations (batch size: %d)", batchSize)
+	if atomic {
+		l.Info().Msg("      Using batch operations in one changeset")
+	} else {
+		l.Info().Msgf("      Using batch operations (batch size: %d)", batchSize)
+	}
 
 	// Get current configuration to verify parameters exist
-	currentConfig, err := configuration.Get(artifactID, version)
+	currentConfig, err := configs.get(artifactID, version)
 	if err != nil {
 		return fmt.Errorf("failed to get current configuration: %w", err)
 	}
 
 	// Build batch request
 	batch := exe.NewBatchRequest()
+	batch.SetChangesetPerOperation(!atomic)
 	validParams := 0
+	missingParams := 0
+	var batchParams []models.ConfigurationParameter // Parameters of the operations of the batch
 
 	for _, param := range parameters {
 		// Verify parameter exists
 		existingParam := api.FindParameterByKey(param.Key, currentConfig.Root.Results)
 		if existingParam == nil {
-			log.Warn().Msgf("      ⚠️  Parameter %s not found in artifact, skipping", param.Key)
-			stats.ParametersFailed++
+			l.Warn().Msgf("      ⚠️  Parameter %s not found in artifact, skipping", param.Key)
+			stats.AddWarning("Parameter %s not found in artifact %s, skipped", param.Key, artifactID)
+			stats.ParametersFailed.Inc()
+			missingParams++
 			continue
 		}
 
-		// Add to batch
-		requestBody := fmt.Sprintf(`{"ParameterValue":"%s"}`, escapeJSON(param.Value))
-		urlPath := fmt.Sprintf("/api/v1/IntegrationDesigntimeArtifacts(Id='%s',Version='%s')/$links/Configurations('%s')",
-			artifactID, version, param.Key)
+		// Add to batch, the value is marshalled so that line breaks (e.g. of certificates) are escaped
+		urlPath, requestBody, err := api.ConfigurationUpdateRequest(artifactID, version, param.Key, param.Value)
+		if err != nil {
+			return err
+		}
 
-		log.Debug().Msgf("      Adding batch operation: %s %s", "PUT", urlPath)
+		l.Debug().Msgf("      Adding batch operation: %s %s", "PUT", urlPath)
 
 		batch.AddOperation(httpclnt.BatchOperation{
 			Method:    "PUT",
 			Path:      urlPath,
-			Body:      []byte(requestBody),
+			Body:      requestBody,
 			ContentID: fmt.Sprintf("param_%d", validParams),
 			Headers: map[string]string{
 				"Content-Type": "application/json",
 			},
 		})
+		batchParams = append(batchParams, param)
 		validParams++
 	}
 
 	if validParams == 0 {
 		return fmt.Errorf("no valid parameters to update")
 	}
+	if atomic && missingParams > 0 {
+		stats.ParametersFailed.Add(validParams)
+		return fmt.Errorf("%d parameters not found, no parameters updated", missingParams)
+	}
+
+	telemetry.Observe("flashpipe_batch_operations", "Number of operations per configuration batch.", float64(validParams))
+	if atomic {
+		return executeChangeset(batch, validParams, stats, l)
+	}
 
 	// Execute batch in chunks
-	log.Debug().Msgf("      Executing batch request with %d parameters (batch size: %d)", validParams, batchSize)
+	l.Debug().Msgf("      Executing batch request with %d parameters (batch size: %d)", validParams, batchSize)
 	resp, err := batch.ExecuteInBatches(batchSize)
-	if err != nil {
-		log.Warn().Msgf("      ⚠️  Batch operation failed: %v, falling back to individual requests", err)
-		log.Debug().Msgf("      Batch failure likely due to SAP CPI API compatibility. Consider using --disable-batch flag or batch.enabled=false in config")
-		return updateParametersIndividual(configuration, artifactID, version, parameters, stats)
+	if err != nil && (fallback == flashpipe.BatchFallbackAll || resp == nil) {
+		l.Warn().Msgf("      ⚠️  Batch operation failed: %v, falling back to individual requests", err)
+		stats.AddWarning("Batch update of artifact %s failed, parameters were updated with individual requests: %v", artifactID, err)
+		stats.AddBatchFallback(flashpipe.BatchFallback{ArtifactID: artifactID, Policy: flashpipe.BatchFallbackAll, Parameters: len(parameters), Reason: err.Error()})
+		l.Debug().Msgf("      Batch failure likely due to SAP CPI API compatibility. Consider using --disable-batch flag or batch.enabled=false in config")
+		return updateParametersIndividual(configs.configuration, artifactID, version, parameters, stats, l)
 	}
 
-	stats.BatchRequestsExecuted++
+	stats.BatchRequestsExecuted.Inc()
 
 	// Process batch results
 	successCount := 0
 	failCount := 0
+	lockedCount := 0
+	var notExecuted []models.ConfigurationParameter
 
-	for _, opResp := range resp.Operations {
-		if opResp.Error != nil {
+	for i, opResp := range resp.Operations {
+		if errors.Is(opResp.Error, httpclnt.ErrBatchNotExecuted) {
+			notExecuted = append(notExecuted, batchParams[i])
+		} else if opResp.Error != nil {
 			failCount++
-			stats.ParametersFailed++
+			stats.ParametersFailed.Inc()
 		} else if opResp.StatusCode >= 200 && opResp.StatusCode < 300 {
 			successCount++
-			stats.ParametersUpdated++
+			stats.ParametersUpdated.Inc()
 		} else {
 			failCount++
-			stats.ParametersFailed++
+			stats.ParametersFailed.Inc()
+			if httpclnt.IsLockedResponse(opResp.Body) {
+				lockedCount++
+			}
 		}
 	}
 
+	telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(successCount), "result", "success")
+	telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(failCount), "result", "failure")
+
+	// Parameters of failed batch requests are updated individually or fail
+	var fallbackErr error
+	if len(notExecuted) > 0 && fallback == flashpipe.BatchFallbackNone {
+		l.Error().Msgf("      ❌ Batch operation failed: %v", err)
+		stats.ParametersFailed.Add(len(notExecuted))
+		telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(len(notExecuted)), "result", "failure")
+		fallbackErr = fmt.Errorf("%d parameters not updated as the batch request failed: %w", len(notExecuted), err)
+	} else if len(notExecuted) > 0 {
+		l.Warn().Msgf("      ⚠️  Batch operation failed: %v, updating %d parameters of the failed batches with individual requests", err, len(notExecuted))
+		stats.AddWarning("Batch update of artifact %s failed, %d parameters were updated with individual requests: %v", artifactID, len(notExecuted), err)
+		stats.AddBatchFallback(flashpipe.BatchFallback{ArtifactID: artifactID, Policy: flashpipe.BatchFallbackFailedOnly, Parameters: len(notExecuted), Reason: err.Error()})
+		fallbackErr = updateParametersIndividual(configs.configuration, artifactID, version, notExecuted, stats, l)
+	}
+
+	if failCount > 0 && lockedCount == failCount && (fallbackErr == nil || errors.Is(fallbackErr, httpclnt.ErrLocked)) {
+		return fmt.Errorf("%d parameters failed to update in batch: %w", failCount, httpclnt.ErrLocked)
+	}
 	if failCount > 0 {
-		return fmt.Errorf("%d parameters failed to update in batch", failCount)
+		return errors.Join(fmt.Errorf("%d parameters failed to update in batch", failCount), fallbackErr)
 	}
 
+	return fallbackErr
+}
+
+// validateBatchFallbacks rejects invalid handlings of failed batch requests before anything is changed
+func validateBatchFallbacks(cfg *models.ConfigureConfig) error {
+	if !slices.Contains(flashpipe.BatchFallbacks, cfg.BatchFallback) {
+		return fmt.Errorf("invalid batch fallback %q, expected none, failed-only or all", cfg.BatchFallback)
+	}
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			if artifact.Batch != nil && !slices.Contains(flashpipe.BatchFallbacks, artifact.Batch.Fallback) {
+				return fmt.Errorf("artifact %s: invalid batch fallback %q, expected none, failed-only or all", artifact.ID, artifact.Batch.Fallback)
+			}
+		}
+	}
 	return nil
 }
 
-func updateParametersIndividual(configuration *api.Configuration, artifactID, version string,
-	parameters []models.ConfigurationParameter, stats *ConfigureStats) error {
+// executeChangeset executes a batch request with all parameter updates in one changeset. If the changeset
+// fails, the tenant rolls back all of its operations and a single error response is returned.
+func executeChangeset(batch *httpclnt.BatchRequest, operations int, stats *ConfigureStats, l *zerolog.Logger) error {
+	resp, err := batch.Execute()
+	if err != nil {
+		stats.ParametersFailed.Add(operations)
+		telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(operations), "result", "failure")
+		if errors.Is(err, httpclnt.ErrBatchTooLarge) {
+			return fmt.Errorf("changeset too large, no parameters updated (use --disable-changeset to split the updates into several batches): %w", err)
+		}
+		return fmt.Errorf("changeset failed, no parameters updated (use --disable-changeset if changesets are not supported): %w", err)
+	}
+	stats.BatchRequestsExecuted.Inc()
+
+	failed := len(resp.Operations) != operations
+	locked := false
+	for _, opResp := range resp.Operations {
+		if opResp.Error != nil || opResp.StatusCode < 200 || opResp.StatusCode >= 300 {
+			failed = true
+			locked = locked || httpclnt.IsLockedResponse(opResp.Body)
+			if len(opResp.Body) > 0 {
+				l.Debug().Msgf("      Changeset error response = %s", opResp.Body)
+			}
+		}
+	}
+	if failed {
+		stats.ParametersFailed.Add(operations)
+		telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(operations), "result", "failure")
+		if locked {
+			return fmt.Errorf("changeset rolled back, no parameters updated: %w", httpclnt.ErrLocked)
+		}
+		return fmt.Errorf("changeset rolled back, no parameters updated")
+	}
+	stats.ParametersUpdated.Add(operations)
+	telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(operations), "result", "success")
+	return nil
+}
+
+// updateParametersIndividual updates the parameters with a request each, see updateParameterWithRetry
+func updateParametersIndividual(configuration api.ConfigurationService, artifactID, version string,
+	parameters []models.ConfigurationParameter, stats *ConfigureStats, l *zerolog.Logger) error {
 
-	log.Info().Msgf("      Using individual requests")
+	l.Info().Msgf("      Using individual requests")
 
 	failCount := 0
 	successCount := 0
+	lockedCount := 0
 
 	for _, param := range parameters {
-		err := configuration.Update(artifactID, version, param.Key, param.Value)
+		err := updateParameterWithRetry(configuration, artifactID, version, param.Key, param.Value, stats, l)
 		if err != nil {
-			log.Error().Msgf("      ❌ Failed to update parameter %s: %v", param.Key, err)
-			stats.ParametersFailed++
+			l.Error().Msgf("      ❌ Failed to update parameter %s: %v", param.Key, err)
+			stats.ParametersFailed.Inc()
 			failCount++
+			if errors.Is(err, httpclnt.ErrLocked) {
+				lockedCount++
+			}
 		} else {
-			stats.ParametersUpdated++
-			stats.IndividualRequestsUsed++
+			stats.ParametersUpdated.Inc()
+			stats.IndividualRequestsUsed.Inc()
 			successCount++
 		}
 	}
 
+	telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(successCount), "result", "success")
+	telemetry.IncCounter("flashpipe_parameters_total", "Number of configuration parameter updates by result.", float64(failCount), "result", "failure")
+
+	if failCount > 0 && lockedCount == failCount {
+		return fmt.Errorf("%d parameters failed to update: %w", failCount, httpclnt.ErrLocked)
+	}
 	if failCount > 0 {
 		return fmt.Errorf("%d parameters failed to update", failCount)
 	}
@@ -634,66 +1376,181 @@ func updateParametersIndividual(configuration *api.Configuration, artifactID, ve
 	return nil
 }
 
-func deployConfiguredArtifacts(exe *httpclnt.HTTPExecuter, tasks []DeploymentTask,
-	deployRetries, deployDelaySeconds, parallelDeployments int, stats *ConfigureStats) error {
+func deployConfiguredArtifacts(exe *httpclnt.HTTPExecuter, tasks []DeploymentTask, hooks *deploymentHooks,
+	deployRetries, deployDelaySeconds, parallelDeployments int, deployTimeout time.Duration, window windowPolicy, stats *ConfigureStats) ([]string, error) {
 
 	// Group tasks by package
 	packageTasks := make(map[string][]DeploymentTask)
+	var packageIDs []string
 	for _, task := range tasks {
+		if _, exists := packageTasks[task.PackageID]; !exists {
+			packageIDs = append(packageIDs, task.PackageID)
+		}
 		packageTasks[task.PackageID] = append(packageTasks[task.PackageID], task)
 	}
 
 	log.Info().Msgf("Deploying artifacts across %d packages", len(packageTasks))
 
-	var wg sync.WaitGroup
-	resultsChan := make(chan deployResult, len(tasks))
-
-	// Deploy all artifacts in parallel
-	for packageID, pkgTasks := range packageTasks {
-		log.Info().Msgf("Package %s: deploying %d artifacts", packageID, len(pkgTasks))
-
-		// Process artifacts in this package with controlled parallelism
-		semaphore := make(chan struct{}, parallelDeployments)
-
-		for _, task := range pkgTasks {
-			wg.Add(1)
-			go func(t DeploymentTask) {
-				defer wg.Done()
-				semaphore <- struct{}{}        // Acquire
-				defer func() { <-semaphore }() // Release
-
-				log.Info().Msgf("  Deploying %s (type: %s)", t.ArtifactID, t.ArtifactType)
+	// All packages are deployed in parallel, each between its preDeploy and postDeploy hooks, with at most
+	// parallelDeployments artifacts of a package at a time. The results of the artifacts are recorded by index.
+	var steps []pipeline.Task
+	var results []deployResult
+	resultIndex := make(map[string]int)
+	for _, packageID := range packageIDs {
+		pkgTasks := packageTasks[packageID]
+		packageCtx := HookContext{Scope: "package", PackageID: packageID}
+		preID := "preDeploy:" + packageID
+		var preDeployed atomic.Bool
+		var pkgFailed atomic.Int32
+
+		steps = append(steps, pipeline.Task{ID: preID, Run: func() error {
+			log.Info().Msgf("Package %s: deploying %d artifacts", packageID, len(pkgTasks))
+			if err := runHooks(hooks.packages[packageID], packageCtx.withPhase(HookPreDeploy, nil)); err != nil {
+				stats.HooksFailed.Inc()
+				return err
+			}
+			preDeployed.Store(true)
+			return nil
+		}})
+
+		artifactIDs := make([]string, 0, len(pkgTasks))
+		for _, t := range pkgTasks {
+			i := len(results)
+			id := fmt.Sprintf("deploy:%d", i)
+			results = append(results, deployResult{Task: t})
+			resultIndex[id] = i
+			artifactIDs = append(artifactIDs, id)
+			steps = append(steps, pipeline.Task{ID: id, Group: packageID, DependsOn: []string{preID}, Run: func() error {
+				if t.SkipIfDeployed && deployedUpToDate(exe, t) {
+					results[i].Skipped = true
+					return nil
+				}
 
-				deployErr := deployArtifact(exe, t, deployRetries, deployDelaySeconds)
-				resultsChan <- deployResult{Task: t, Error: deployErr}
-			}(task)
+				deployErr := window.await(t)
+				deployStart := time.Now()
+				if deployErr == nil {
+					events.Emit(events.Event{Type: events.TypeArtifactStarted, Phase: events.PhaseDeploy, Tenant: exe.Host(),
+						PackageID: t.PackageID, ArtifactID: t.ArtifactID, ArtifactType: t.ArtifactType})
+					// Time spent waiting for the maintenance window is excluded from the deadline
+					if deployTimeout > 0 {
+						t.Deadline = time.Now().Add(deployTimeout)
+					}
+					if t.BlueGreen != nil && t.BlueGreen.SmokeTest != nil {
+						t.SmokeTest = new(smoketest.Result)
+						results[i].Task.SmokeTest = t.SmokeTest
+					}
+					deployErr = deployArtifactWithHooks(exe, t, hooks.artifacts[t.ArtifactID], deployRetries, deployDelaySeconds, &stats.HooksFailed)
+				}
+				if deployErr != nil {
+					pkgFailed.Add(1)
+				}
+				results[i].Duration = time.Since(deployStart)
+				return deployErr
+			}})
 		}
-	}
 
-	// Wait for all deployments
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+		steps = append(steps, pipeline.Task{ID: "postDeploy:" + packageID, DependsOn: append(artifactIDs, preID), Finally: true, Run: func() error {
+			if !preDeployed.Load() {
+				return nil
+			}
+			var packageErr error
+			if failed := pkgFailed.Load(); failed > 0 {
+				packageErr = fmt.Errorf("%d deployment(s) failed in package %s", failed, packageID)
+			}
+			if err := runHooks(hooks.packages[packageID], packageCtx.withPhase(HookPostDeploy, packageErr)); err != nil {
+				log.Error().Msg(err.Error())
+				stats.HooksFailed.Inc()
+			}
+			return nil
+		}})
+	}
 
 	// Collect results
-	for result := range resultsChan {
+	var deployed []string
+	collect := func(step pipeline.Result) {
+		i, isArtifact := resultIndex[step.ID]
+		if !isArtifact {
+			return
+		}
+		result := results[i]
+		result.Error = step.Err
+		if result.Skipped {
+			stats.AddSkippedDeployment(result.Task.PackageID, result.Task.ArtifactID, result.Task.ArtifactType)
+		} else {
+			stats.AddDeploymentResult(result.Task.PackageID, result.Task.ArtifactID, result.Task.ArtifactType, result.Duration, result.Error)
+			if result.Task.SmokeTest != nil && result.Task.SmokeTest.Attempts > 0 {
+				stats.AddSmokeTestResult(result.Task.PackageID, result.Task.ArtifactID, result.Task.SmokeTest)
+			}
+		}
+		eventType := events.TypeArtifactDeployed
+		if result.Skipped {
+			eventType = events.TypeArtifactSkipped
+		}
+		events.Artifact(eventType, events.PhaseDeploy, exe.Host(), result.Task.PackageID, result.Task.ArtifactID, result.Duration, result.Error)
+		if !result.Skipped {
+			postDeploymentEvent(exe, result.Task.PackageID, result.Task.ArtifactID, result.Task.ArtifactType, result.Duration, result.Error)
+		}
 		if result.Error != nil {
 			log.Error().Msgf("  ❌ Failed to deploy %s: %v", result.Task.ArtifactID, result.Error)
-			stats.DeploymentTasksFailed++
+			logRemediation(result.Error)
+			stats.DeploymentTasksFailed.Inc()
+		} else if result.Skipped {
+			log.Info().Msgf("  ⏭️  %s is already up to date, skipping deployment", result.Task.ArtifactID)
+			stats.DeploymentsUpToDate.Inc()
+			telemetry.IncCounter("flashpipe_deployments_total", "Number of artifact deployments by result.", 1, "result", "skipped")
 		} else {
-			log.Info().Msgf("  ✅ Successfully deployed %s", result.Task.ArtifactID)
-			stats.DeploymentTasksSuccessful++
-			stats.ArtifactsDeployed++
+			log.Info().Msgf("  ✅ Successfully deployed %s%v", result.Task.ArtifactID, logger.Took(result.Duration))
+			deployed = append(deployed, result.Task.ArtifactID)
+			stats.DeploymentTasksSuccessful.Inc()
+			stats.ArtifactsDeployed.Inc()
 		}
 	}
+	if _, err := pipeline.Run(steps, pipeline.Options{GroupParallel: parallelDeployments, OnDone: collect}); err != nil {
+		return nil, err
+	}
 
-	return nil
+	return deployed, nil
+}
+
+// deployArtifactWithHooks deploys an artifact wrapped by its preDeploy and postDeploy hooks. A panic is
+// recovered and returned as error, so that it only fails this artifact and not the other deployments.
+func deployArtifactWithHooks(exe *httpclnt.HTTPExecuter, t DeploymentTask, hooks *models.ConfigureHooks,
+	deployRetries, deployDelaySeconds int, hooksFailed *flashpipe.Counter) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Msgf("  Deployment of %s panicked: %v\n%s", t.ArtifactID, r, debug.Stack())
+			err = fmt.Errorf("deployment panicked: %v", r)
+		}
+	}()
+
+	artifactCtx := HookContext{Scope: "artifact", PackageID: t.PackageID, ArtifactID: t.ArtifactID, ArtifactType: t.ArtifactType}
+	if err := runHooks(hooks, artifactCtx.withPhase(HookPreDeploy, nil)); err != nil {
+		hooksFailed.Inc()
+		return err
+	}
+
+	log.Info().Msgf("  Deploying %s (type: %s)", t.ArtifactID, t.ArtifactType)
+
+	span := telemetry.StartSpan("deploy "+t.ArtifactID, "flashpipe.package.id", t.PackageID,
+		"flashpipe.artifact.id", t.ArtifactID, "flashpipe.artifact.type", t.ArtifactType)
+	deployErr := deployArtifact(exe, t, deployRetries, deployDelaySeconds)
+	recordDeployment(span, deployErr)
+
+	if err := runHooks(hooks, artifactCtx.withPhase(HookPostDeploy, deployErr)); err != nil {
+		log.Error().Msg(err.Error())
+		hooksFailed.Inc()
+	}
+	return deployErr
 }
 
 func deployArtifact(exe *httpclnt.HTTPExecuter, task DeploymentTask,
 	maxRetries, delaySeconds int) error {
 
+	if task.Strategy == DeployStrategyBlueGreen {
+		return deployBlueGreen(exe, task, maxRetries, delaySeconds)
+	}
+
 	// Initialize designtime artifact based on type
 	dt := api.NewDesigntimeArtifact(task.ArtifactType, exe)
 	if dt == nil {
@@ -703,9 +1560,14 @@ func deployArtifact(exe *httpclnt.HTTPExecuter, task DeploymentTask,
 	// Initialize runtime artifact for status checking
 	rt := api.NewRuntime(exe)
 
+	// Undeploy and wait for drain first with the stopStart strategy
+	if err := stopAndDrain(exe, task); err != nil {
+		return err
+	}
+
 	// Deploy the artifact
 	log.Info().Msgf("    Deploying %s (type: %s)", task.ArtifactID, task.ArtifactType)
-	err := dt.Deploy(task.ArtifactID)
+	err := dt.DeployVersion(task.ArtifactID, task.Version)
 	if err != nil {
 		return fmt.Errorf("failed to initiate deployment: %w", err)
 	}
@@ -713,16 +1575,26 @@ func deployArtifact(exe *httpclnt.HTTPExecuter, task DeploymentTask,
 	log.Info().Msgf("    Deployment triggered for %s", task.ArtifactID)
 
 	// Poll for deployment status
+	status := ""
 	for i := 0; i < maxRetries; i++ {
-		time.Sleep(time.Duration(delaySeconds) * time.Second)
+		delay := time.Duration(delaySeconds) * time.Second
+		if !task.Deadline.IsZero() {
+			remaining := time.Until(task.Deadline)
+			if remaining <= 0 {
+				return fmt.Errorf("deployment timed out after %d status checks, last status %s", i, status)
+			}
+			delay = min(delay, remaining)
+		}
+		time.Sleep(delay)
 
-		version, status, err := rt.Get(task.ArtifactID)
+		version, currentStatus, err := rt.Get(task.ArtifactID)
 		if err != nil {
 			log.Warn().Msgf("    Failed to get deployment status (attempt %d/%d): %v",
 				i+1, maxRetries, err)
 			continue
 		}
 
+		status = currentStatus
 		log.Info().Msgf("    Check %d/%d - Status: %s, Version: %s", i+1, maxRetries, status, version)
 
 		if version == "NOT_DEPLOYED" {
@@ -738,65 +1610,129 @@ func deployArtifact(exe *httpclnt.HTTPExecuter, task DeploymentTask,
 			if err != nil {
 				return fmt.Errorf("deployment failed with status %s: %w", status, err)
 			}
-			return fmt.Errorf("deployment failed with status %s: %s", status, errorMessage)
+			return fmt.Errorf("deployment failed with status %s: %w", status, deploy.ClassifyError(errorMessage))
 		}
 	}
 
 	return fmt.Errorf("deployment status check timed out after %d attempts", maxRetries)
 }
 
+// printPackageResults prints the counts of each package, packages with failed artifacts first, followed by
+// the failed artifacts
+func printPackageResults(stats *ConfigureStats) {
+	packages := stats.PackageResults()
+	if len(packages) == 0 {
+		return
+	}
+	width := len("Package")
+	for _, pkg := range packages {
+		width = max(width, len(pkg.PackageID))
+	}
+	log.Info().Msg("")
+	log.Info().Msg("Packages:")
+	log.Info().Msgf("%-*s  %10s  %6s  %8s  %s", width, "Package", "Configured", "Failed", "Deployed", "Duration")
+	for _, pkg := range packages {
+		line := fmt.Sprintf("%-*s  %10d  %6d  %8d  %v", width, pkg.PackageID, pkg.Configured, pkg.Failed, pkg.Deployed,
+			(time.Duration(pkg.DurationMs) * time.Millisecond).Round(time.Millisecond))
+		if pkg.Failed > 0 {
+			log.Error().Msg(line)
+		} else {
+			log.Info().Msg(line)
+		}
+	}
+
+	failed := stats.FailedArtifacts()
+	if len(failed) == 0 {
+		return
+	}
+	log.Info().Msg("")
+	log.Error().Msg("Failed artifacts:")
+	for _, result := range failed {
+		log.Error().Msgf("  %s (package %s, %s): %s", result.ArtifactID, result.PackageID, result.Phase, result.Error)
+	}
+}
+
+// printWarnings lists all warnings issued during the run before the final status
+func printWarnings(stats *ConfigureStats) {
+	if len(stats.Warnings) == 0 {
+		return
+	}
+	log.Info().Msg("")
+	log.Warn().Msgf("Warnings (%d):", len(stats.Warnings))
+	for _, warning := range stats.Warnings {
+		log.Warn().Msgf("  %s", warning)
+	}
+}
+
 func printConfigureSummary(stats *ConfigureStats, dryRun bool) {
 	log.Info().Msg("")
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	logger.Rule(logger.SeparatorDouble)
 	if dryRun {
 		log.Info().Msg("DRY RUN SUMMARY")
 	} else {
 		log.Info().Msg("CONFIGURATION SUMMARY")
 	}
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-	log.Info().Msgf("Packages processed:          %d", stats.PackagesProcessed)
-	log.Info().Msgf("Packages with errors:        %d", stats.PackagesWithErrors)
-	log.Info().Msgf("Artifacts processed:         %d", stats.ArtifactsProcessed)
-	log.Info().Msgf("Artifacts configured:        %d", stats.ArtifactsConfigured)
-	log.Info().Msgf("Artifacts failed:            %d", stats.ArtifactsFailed)
-	log.Info().Msgf("Parameters updated:          %d", stats.ParametersUpdated)
-	log.Info().Msgf("Parameters failed:           %d", stats.ParametersFailed)
+	logger.Rule(logger.SeparatorDouble)
+	log.Info().Msgf("Packages processed:          %d", stats.PackagesProcessed.Value())
+	log.Info().Msgf("Packages with errors:        %d", stats.PackagesWithErrors.Value())
+	log.Info().Msgf("Artifacts processed:         %d", stats.ArtifactsProcessed.Value())
+	log.Info().Msgf("Artifacts configured:        %d", stats.ArtifactsConfigured.Value())
+	log.Info().Msgf("Artifacts failed:            %d", stats.ArtifactsFailed.Value())
+	if stats.ArtifactsLocked.Value() > 0 {
+		log.Info().Msgf("Artifacts locked:            %d", stats.ArtifactsLocked.Value())
+	}
+	log.Info().Msgf("Parameters updated:          %d", stats.ParametersUpdated.Value())
+	log.Info().Msgf("Parameters failed:           %d", stats.ParametersFailed.Value())
+	if stats.ParametersUnchanged.Value() > 0 {
+		log.Info().Msgf("Parameters unchanged:        %d", stats.ParametersUnchanged.Value())
+	}
+	printPackageResults(stats)
+	printUnknownParameters(stats)
+	printLockedArtifacts(stats)
+	printParameterErrors(stats)
 
 	if !dryRun {
 		log.Info().Msg("")
 		log.Info().Msg("Performance:")
-		log.Info().Msgf("Batch requests executed:     %d", stats.BatchRequestsExecuted)
-		log.Info().Msgf("Individual requests used:    %d", stats.IndividualRequestsUsed)
+		log.Info().Msgf("Batch requests executed:     %d", stats.BatchRequestsExecuted.Value())
+		log.Info().Msgf("Individual requests used:    %d", stats.IndividualRequestsUsed.Value())
+		for _, fallback := range stats.BatchFallbacks {
+			log.Info().Msgf("Batch fallback:              %s (%s, %d parameter(s)): %s", fallback.ArtifactID, fallback.Policy, fallback.Parameters, fallback.Reason)
+		}
 	}
+	log.Info().Msg("")
+	log.Info().Msg("Timings:")
+	log.Info().Msgf("Total duration:              %v", stats.Timings.Total.Round(time.Millisecond))
+	log.Info().Msgf("Configure phase:             %v", stats.Timings.Configure.Round(time.Millisecond))
+	if stats.Timings.Deploy > 0 {
+		log.Info().Msgf("Deploy phase:                %v", stats.Timings.Deploy.Round(time.Millisecond))
+	}
+	log.Info().Msgf("Average per artifact:        %v", stats.Timings.AveragePerArtifact.Round(time.Millisecond))
+	log.Info().Msgf("API requests:                %d (p95 latency: %v)", stats.Timings.APIRequests, stats.Timings.APILatencyP95.Round(time.Millisecond))
 
-	if stats.DeploymentTasksQueued > 0 {
+	if stats.DeploymentTasksQueued.Value() > 0 {
 		log.Info().Msg("")
 		log.Info().Msg("Deployment:")
-		log.Info().Msgf("Deployment tasks queued:     %d", stats.DeploymentTasksQueued)
+		log.Info().Msgf("Deployment tasks queued:     %d", stats.DeploymentTasksQueued.Value())
 		if !dryRun {
-			log.Info().Msgf("Deployments successful:      %d", stats.DeploymentTasksSuccessful)
-			log.Info().Msgf("Deployments failed:          %d", stats.DeploymentTasksFailed)
-			log.Info().Msgf("Artifacts deployed:          %d", stats.ArtifactsDeployed)
+			log.Info().Msgf("Deployments successful:      %d", stats.DeploymentTasksSuccessful.Value())
+			log.Info().Msgf("Deployments failed:          %d", stats.DeploymentTasksFailed.Value())
+			log.Info().Msgf("Already up to date:          %d", stats.DeploymentsUpToDate.Value())
+			log.Info().Msgf("Artifacts deployed:          %d", stats.ArtifactsDeployed.Value())
 		}
 	}
 
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	printWarnings(stats)
+
+	logger.Rule(logger.SeparatorDouble)
 
-	if stats.ArtifactsFailed > 0 || stats.DeploymentTasksFailed > 0 {
+	if stats.ArtifactsFailed.Value() > 0 || stats.DeploymentTasksFailed.Value() > 0 {
 		log.Error().Msg("❌ Configuration/Deployment completed with errors")
+	} else if stats.ArtifactsLocked.Value() > 0 {
+		log.Warn().Msgf("⚠️  Configuration/Deployment completed, %d artifact(s) skipped as locked by another user", stats.ArtifactsLocked.Value())
 	} else if dryRun {
 		log.Info().Msg("✅ Dry run completed successfully")
 	} else {
 		log.Info().Msg("✅ Configuration/Deployment completed successfully")
 	}
 }
-
-func escapeJSON(s string) string {
-	// Simple JSON string escaping
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	s = strings.ReplaceAll(s, "\r", "\\r")
-	s = strings.ReplaceAll(s, "\t", "\\t")
-	return s
-}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// AuditSnapshot is the before/after document of the configuration values of the targeted artifacts written
+// to --audit-snapshot. It lists all changes, including those of parameters that are not in the configuration.
+type AuditSnapshot struct {
+	Tenant             string          `json:"tenant"`
+	Before             time.Time       `json:"before"`
+	After              time.Time       `json:"after"`
+	ParametersChanged  int             `json:"parametersChanged"`
+	UnexpectedChanges  int             `json:"unexpectedChanges"` // Changes of parameters that are not in the configuration
+	Artifacts          []AuditArtifact `json:"artifacts"`
+	configuredArtifact map[string]models.ConfigureArtifact
+}
+
+// AuditArtifact holds the configuration values of an artifact before and after the run
+type AuditArtifact struct {
+	PackageID  string            `json:"packageId"`
+	ArtifactID string            `json:"artifactId"`
+	Version    string            `json:"version"`
+	Error      string            `json:"error,omitempty"` // Values could not be read
+	Before     map[string]string `json:"before"`
+	After      map[string]string `json:"after"`
+	Changes    []AuditChange     `json:"changes"`
+}
+
+// AuditChange is a parameter whose value differs after the run
+type AuditChange struct {
+	Key        string `json:"key"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	Configured bool   `json:"configured"` // Parameter is set in the configuration
+}
+
+// takeAuditSnapshot reads the configuration values of the artifacts targeted by the configuration before
+// the run. Artifacts of other types than Integration are only included if they have parameters.
+func takeAuditSnapshot(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig, packageFilter, artifactFilter []string) *AuditSnapshot {
+	snapshot := &AuditSnapshot{Tenant: exe.Host(), Before: time.Now(), Artifacts: []AuditArtifact{},
+		configuredArtifact: map[string]models.ConfigureArtifact{}}
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		for _, artifact := range pkg.Artifacts {
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) {
+				continue
+			}
+			if artifact.Type != "Integration" && len(artifact.Parameters) == 0 {
+				continue
+			}
+			artifactID := cfg.DeploymentPrefix + artifact.ID
+			snapshot.configuredArtifact[artifactID] = artifact
+			snapshot.Artifacts = append(snapshot.Artifacts, AuditArtifact{PackageID: cfg.DeploymentPrefix + pkg.ID,
+				ArtifactID: artifactID, Version: artifact.Version, Changes: []AuditChange{}})
+		}
+	}
+	log.Info().Msgf("Taking audit snapshot of %d artifact(s)", len(snapshot.Artifacts))
+	snapshot.read(exe, func(a *AuditArtifact, values map[string]string) { a.Before = values })
+	return snapshot
+}
+
+// complete reads the configuration values after the run and compares them with the values before
+func (s *AuditSnapshot) complete(exe *httpclnt.HTTPExecuter) {
+	s.After = time.Now()
+	s.read(exe, func(a *AuditArtifact, values map[string]string) { a.After = values })
+	for i := range s.Artifacts {
+		a := &s.Artifacts[i]
+		keys := make([]string, 0, len(a.Before)+len(a.After))
+		for key := range a.Before {
+			keys = append(keys, key)
+		}
+		for key := range a.After {
+			if _, found := a.Before[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if a.Before[key] == a.After[key] {
+				continue
+			}
+			configured := slices.ContainsFunc(s.configuredArtifact[a.ArtifactID].Parameters,
+				func(p models.ConfigurationParameter) bool { return p.Key == key })
+			a.Changes = append(a.Changes, AuditChange{Key: key, Before: a.Before[key], After: a.After[key], Configured: configured})
+			s.ParametersChanged++
+			if !configured {
+				s.UnexpectedChanges++
+			}
+		}
+	}
+}
+
+// read reads the configuration values of the artifacts, reading them in batches where possible
+func (s *AuditSnapshot) read(exe *httpclnt.HTTPExecuter, set func(a *AuditArtifact, values map[string]string)) {
+	// A new reader for each snapshot, so that no values read before the run are reused
+	configs := newConfigurationReader(api.NewConfigurationService(exe))
+	// IDs in the snapshot already include the deployment prefix
+	pkg := models.ConfigurePackage{}
+	for _, a := range s.Artifacts {
+		pkg.Artifacts = append(pkg.Artifacts, models.ConfigureArtifact{ID: a.ArtifactID, Version: a.Version,
+			Parameters: s.configuredArtifact[a.ArtifactID].Parameters})
+	}
+	cfg := &models.ConfigureConfig{Packages: []models.ConfigurePackage{pkg}}
+	configs.prefetch(cfg, nil, nil, httpclnt.DefaultBatchSize)
+
+	for i := range s.Artifacts {
+		a := &s.Artifacts[i]
+		values := map[string]string{}
+		params, err := configs.get(a.ArtifactID, a.Version)
+		if err != nil {
+			a.Error = err.Error()
+			log.Warn().Msgf("Failed to read configuration of %s for audit snapshot: %v", a.ArtifactID, err)
+		} else {
+			for _, param := range params.Root.Results {
+				values[param.ParameterKey] = param.ParameterValue
+			}
+		}
+		set(a, values)
+	}
+}
+
+// write writes the snapshot as JSON to path
+func (s *AuditSnapshot) write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	if s.UnexpectedChanges > 0 {
+		log.Warn().Msgf("⚠️  Audit snapshot written to %s: %d parameter(s) changed, %d of them not in the configuration",
+			path, s.ParametersChanged, s.UnexpectedChanges)
+	} else {
+		log.Info().Msgf("Audit snapshot written to %s: %d parameter(s) changed, all of them in the configuration", path, s.ParametersChanged)
+	}
+	return nil
+}
+
+// targetAuditSnapshotPath returns the audit snapshot file of a target, with the name of the target appended
+// to the file name, e.g. audit-prod.json
+func targetAuditSnapshotPath(path string, target string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + target + ext
+}
+
+// withAuditSnapshot runs configure between a snapshot of the configuration values before and after the run
+// and writes the differences to path. Without path, only configure is run.
+func withAuditSnapshot(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig, packageFilter, artifactFilter []string,
+	path string, configure func() (*ConfigureStats, error)) (*ConfigureStats, error) {
+	if path == "" {
+		return configure()
+	}
+	snapshot := takeAuditSnapshot(exe, cfg, packageFilter, artifactFilter)
+	stats, err := configure()
+	snapshot.complete(exe)
+	if writeErr := snapshot.write(path); writeErr != nil {
+		log.Error().Msgf("Failed to write audit snapshot: %v", writeErr)
+	}
+	return stats, err
+}
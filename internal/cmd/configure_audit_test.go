@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditSnapshotMock(t *testing.T) {
+	values := `{ "ParameterKey": "Host", "ParameterValue": "dev-host" }, { "ParameterKey": "Timeout", "ParameterValue": "30" }`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/v4/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ ` + values + ` ] } }`))
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	cfg := &models.ConfigureConfig{DeploymentPrefix: "DEV_", Packages: []models.ConfigurePackage{{ID: "Orders", Artifacts: []models.ConfigureArtifact{
+		{ID: "Flow", Type: "Integration", Version: "active", Parameters: []models.ConfigurationParameter{{Key: "Host", Value: "prod-host"}}},
+		{ID: "Mapping", Type: "MessageMapping", Version: "active"},
+	}}}}
+
+	path := filepath.Join(t.TempDir(), "audit.json")
+	_, err := withAuditSnapshot(exe, cfg, nil, nil, path, func() (*ConfigureStats, error) {
+		values = `{ "ParameterKey": "Host", "ParameterValue": "prod-host" }, { "ParameterKey": "Timeout", "ParameterValue": "60" }`
+		return &ConfigureStats{}, nil
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var snapshot AuditSnapshot
+	require.NoError(t, json.Unmarshal(content, &snapshot))
+	require.Len(t, snapshot.Artifacts, 1, "Artifacts without parameters of other types than Integration should be skipped")
+	artifact := snapshot.Artifacts[0]
+	assert.Equal(t, "DEV_Flow", artifact.ArtifactID)
+	assert.Equal(t, map[string]string{"Host": "dev-host", "Timeout": "30"}, artifact.Before)
+	assert.Equal(t, map[string]string{"Host": "prod-host", "Timeout": "60"}, artifact.After)
+	assert.Equal(t, []AuditChange{
+		{Key: "Host", Before: "dev-host", After: "prod-host", Configured: true},
+		{Key: "Timeout", Before: "30", After: "60", Configured: false},
+	}, artifact.Changes)
+	assert.Equal(t, 2, snapshot.ParametersChanged)
+	assert.Equal(t, 1, snapshot.UnexpectedChanges, "Change of a parameter not in the configuration should be unexpected")
+}
+
+func TestTargetAuditSnapshotPath(t *testing.T) {
+	assert.Equal(t, "out/audit-prod.json", targetAuditSnapshotPath("out/audit.json", "prod"))
+	assert.Equal(t, "audit-prod", targetAuditSnapshotPath("audit", "prod"))
+	assert.Equal(t, "", targetAuditSnapshotPath("", "prod"))
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/pipeline"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configureBackupStateFile is the file in the backup directory the progress of the backup is written to. Its
+// extension is not one of a configuration file, so that the directory can be passed to configure as is.
+const configureBackupStateFile = "flashpipe-backup.state"
+
+// Retries of backup requests that were rate limited by the tenant
+var (
+	configureBackupRetries    = 3
+	configureBackupRetryDelay = 10 * time.Second
+)
+
+// ConfigureBackup is the progress of a backup of the configuration parameters of a tenant
+type ConfigureBackup struct {
+	Tenant   string                   `json:"tenant"`
+	Started  time.Time                `json:"started"`
+	Complete bool                     `json:"complete"` // False while backing up and if a package could not be backed up
+	Packages []ConfigureBackupPackage `json:"packages"`
+}
+
+// ConfigureBackupPackage is the backup of the configuration parameters of the artifacts of a package
+type ConfigureBackupPackage struct {
+	ID         string `json:"packageId"`
+	Done       bool   `json:"done"`
+	File       string `json:"file,omitempty"` // Not set for packages without parameters
+	Artifacts  int    `json:"artifacts"`
+	Parameters int    `json:"parameters"`
+	Failure    string `json:"failure,omitempty"` // Why the package could not be backed up
+}
+
+func NewConfigureBackupCommand() *cobra.Command {
+
+	backupCmd := &cobra.Command{
+		Use:          "backup",
+		Short:        "Back up the configuration parameters of all artifacts of the tenant",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Back up the configured values of the parameters of the Integration
+artifacts of the tenant, e.g. before a major upgrade, to one YAML file per
+package in --out. The files are in the configuration format of configure, so
+that the values can be restored with configure --config-path <out>.
+
+Packages are backed up one at a time, at most --rate requests per second.
+Requests rate limited by the tenant are retried. The progress is written to
+flashpipe-backup.state in --out after each package, so that a run that was
+interrupted or could not back up some packages can be continued with
+--resume. Packages already backed up are not read again.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'configure.backup' section. CLI flags override config file settings.`,
+		Example: `  # Back up the configuration of all packages
+  flashpipe configure backup --out backup/
+
+  # Continue after the run was interrupted
+  flashpipe configure backup --out backup/ --resume
+
+  # Restore the values of one package
+  flashpipe configure --config-path backup/Sales.yml`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runConfigureBackup(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	backupCmd.Flags().String("out", "backup", "Directory the configuration files are written to (config: configure.backup.out)")
+	backupCmd.Flags().StringSlice("package-ids", nil, "Comma separated list of package IDs to back up, all packages if not set (config: configure.backup.packageIds)")
+	backupCmd.Flags().Int("rate", 5, "Maximum requests per second, 0 for no limit (config: configure.backup.rate)")
+	backupCmd.Flags().Bool("resume", false, "Continue the backup in --out, skipping the packages already backed up (config: configure.backup.resume)")
+
+	return backupCmd
+}
+
+func runConfigureBackup(cmd *cobra.Command) error {
+	out := config.GetStringWithFallback(cmd, "out", "configure.backup.out")
+	packageIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "package-ids", "configure.backup.packageIds"))
+	rate := config.GetIntWithFallback(cmd, "rate", "configure.backup.rate")
+	resume := config.GetBoolWithFallback(cmd, "resume", "configure.backup.resume")
+
+	if out == "" {
+		return fmt.Errorf("--out is required (set via CLI flag or in config file under 'configure.backup.out')")
+	}
+	if rate < 0 {
+		return fmt.Errorf("invalid value for --rate = %v", rate)
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	var previous *ConfigureBackup
+	if resume {
+		var err error
+		if previous, err = readConfigureBackup(out); err != nil {
+			return err
+		}
+	}
+	backup, err := backupConfiguration(exe, out, packageIds, rate, previous)
+	if err != nil {
+		return err
+	}
+
+	failed, parameters := 0, 0
+	for _, p := range backup.Packages {
+		if p.Failure != "" {
+			failed++
+		}
+		parameters += p.Parameters
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d package(s) could not be backed up, continue with --resume", failed, len(backup.Packages))
+	}
+	log.Info().Msgf("🏆 %d parameter(s) of %d package(s) backed up to %v", parameters, len(backup.Packages), out)
+	return nil
+}
+
+// backupConfiguration writes the configuration parameters of the Integration artifacts of the packages in
+// packageIds, or all packages, to one file per package in dir. Packages backed up in previous are kept without
+// reading them again. The progress is written to the state file of dir after each package.
+func backupConfiguration(exe *httpclnt.HTTPExecuter, dir string, packageIds []string, rate int, previous *ConfigureBackup) (*ConfigureBackup, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	ip := api.NewIntegrationPackage(exe)
+	configuration := api.NewConfigurationService(exe)
+	limiter := newRateLimiter(rate)
+
+	limiter.wait()
+	packages, err := ip.List()
+	if err != nil {
+		return nil, err
+	}
+
+	backedUp := map[string]ConfigureBackupPackage{}
+	if previous != nil && previous.Tenant == exe.Host() {
+		for _, p := range previous.Packages {
+			if p.Done && (p.File == "" || file.Exists(filepath.Join(dir, p.File))) {
+				backedUp[p.ID] = p
+			}
+		}
+	}
+
+	backup := &ConfigureBackup{Tenant: exe.Host(), Started: time.Now(), Packages: []ConfigureBackupPackage{}}
+	var tasks []pipeline.Task
+	for _, pkg := range packages {
+		if len(packageIds) > 0 && !slices.Contains(packageIds, pkg.Id) {
+			continue
+		}
+		i := len(backup.Packages)
+		if p, found := backedUp[pkg.Id]; found {
+			backup.Packages = append(backup.Packages, p)
+			continue
+		}
+		backup.Packages = append(backup.Packages, ConfigureBackupPackage{ID: pkg.Id})
+		tasks = append(tasks, pipeline.Task{ID: pkg.Id, Run: func() error {
+			configPackage, err := readPackageConfiguration(ip, configuration, limiter, pkg)
+			if err != nil {
+				return err
+			}
+			entry := ConfigureBackupPackage{ID: pkg.Id, Done: true, Artifacts: len(configPackage.Artifacts)}
+			for _, artifact := range configPackage.Artifacts {
+				entry.Parameters += len(artifact.Parameters)
+			}
+			if entry.Artifacts > 0 {
+				entry.File = pkg.Id + ".yml"
+				if err := writeBackupFile(filepath.Join(dir, entry.File), exe.Host(), configPackage); err != nil {
+					return err
+				}
+			}
+			backup.Packages[i] = entry
+			return nil
+		}})
+	}
+	if resumed := len(backup.Packages) - len(tasks); resumed > 0 {
+		log.Info().Msgf("Resuming with %d of %d package(s) already backed up", resumed, len(backup.Packages))
+	} else {
+		log.Info().Msgf("Backing up the configuration of %d package(s)", len(backup.Packages))
+	}
+
+	// The state is written after each package, so that an interrupted run can be resumed
+	var writeErr error
+	save := func() {
+		if writeErr == nil {
+			writeErr = writeConfigureBackup(dir, backup)
+		}
+	}
+	save()
+	_, err = pipeline.Run(tasks, pipeline.Options{
+		Parallel:   1,
+		Retries:    configureBackupRetries,
+		RetryDelay: configureBackupRetryDelay,
+		Retryable:  func(err error) bool { return errors.Is(err, api.ErrRateLimited) },
+		OnDone: func(r pipeline.Result) {
+			if r.Err != nil {
+				log.Warn().Msgf("⚠️  Configuration of package %s could not be backed up: %v", r.ID, r.Err)
+				for i := range backup.Packages {
+					if backup.Packages[i].ID == r.ID {
+						backup.Packages[i].Failure = r.Err.Error()
+					}
+				}
+			}
+			save()
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	backup.Complete = true
+	for _, p := range backup.Packages {
+		if p.Failure != "" {
+			backup.Complete = false
+		}
+	}
+	save()
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	return backup, nil
+}
+
+// readPackageConfiguration returns the configuration of the Integration artifacts of the package that have
+// configuration parameters, with their active values
+func readPackageConfiguration(ip *api.IntegrationPackage, configuration api.ConfigurationService, limiter *rateLimiter, pkg *api.PackageDetails) (*models.ConfigurePackage, error) {
+	limiter.wait()
+	artifacts, err := ip.GetArtifactsData(pkg.Id, "Integration")
+	if err != nil {
+		return nil, err
+	}
+	configPackage := &models.ConfigurePackage{ID: pkg.Id, DisplayName: pkg.Name, Artifacts: []models.ConfigureArtifact{}}
+	for _, artifact := range artifacts {
+		limiter.wait()
+		parameters, err := configuration.Get(artifact.Id, "active")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get configuration of %s: %w", artifact.Id, err)
+		}
+		if len(parameters.Root.Results) == 0 {
+			continue
+		}
+		configArtifact := models.ConfigureArtifact{ID: artifact.Id, DisplayName: artifact.Name, Type: "Integration"}
+		for _, p := range parameters.Root.Results {
+			configArtifact.Parameters = append(configArtifact.Parameters, models.ConfigurationParameter{Key: p.ParameterKey, Value: p.ParameterValue})
+		}
+		slices.SortFunc(configArtifact.Parameters, func(a, b models.ConfigurationParameter) int { return strings.Compare(a.Key, b.Key) })
+		configPackage.Artifacts = append(configPackage.Artifacts, configArtifact)
+	}
+	return configPackage, nil
+}
+
+// writeBackupFile writes the configuration of a package to a temporary file that replaces the file, so that the
+// file is complete if the run is killed while writing it
+func writeBackupFile(file string, tenant string, pkg *models.ConfigurePackage) error {
+	var doc yaml.Node
+	if err := doc.Encode(&models.ConfigureConfig{Packages: []models.ConfigurePackage{*pkg}}); err != nil {
+		return err
+	}
+	doc.HeadComment = fmt.Sprintf("Backup of the configuration of package %s on %s by flashpipe configure backup at %s",
+		pkg.ID, tenant, time.Now().UTC().Format(time.RFC3339))
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file+".tmp", buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+// readConfigureBackup returns the progress of the backup in dir, nil if there is none
+func readConfigureBackup(dir string) (*ConfigureBackup, error) {
+	content, err := os.ReadFile(filepath.Join(dir, configureBackupStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backup := new(ConfigureBackup)
+	if err := json.Unmarshal(content, backup); err != nil {
+		return nil, fmt.Errorf("failed to read %v to resume: %w", configureBackupStateFile, err)
+	}
+	return backup, nil
+}
+
+// writeConfigureBackup writes the progress of the backup to the state file of dir
+func writeConfigureBackup(dir string, backup *ConfigureBackup) error {
+	content, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(dir, configureBackupStateFile)
+	if err := os.WriteFile(file+".tmp", content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupConfigurationMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, the first configuration request of Orders is rate limited
+	var mu sync.Mutex
+	requested := map[string]int{}
+	failFinance := true
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requested[r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/v1/IntegrationPackages":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Sales", "Name": "Sales Orders"}, {"Id": "Finance"}, {"Id": "Scripts"}, {"Id": "Sandbox"}]}}`))
+		case "/api/v1/IntegrationPackages('Sales')/IntegrationDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Orders", "Name": "Replicate Orders"}, {"Id": "Empty"}]}}`))
+		case "/api/v1/IntegrationPackages('Finance')/IntegrationDesigntimeArtifacts":
+			if failFinance {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"d": {"results": [{"Id": "Invoices"}]}}`))
+		case "/api/v1/IntegrationPackages('Scripts')/IntegrationDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": []}}`))
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Orders',Version='active')/Configurations":
+			if requested[r.URL.Path] == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"d": {"results": [{"ParameterKey": "Timeout", "ParameterValue": "60"}, {"ParameterKey": "Receiver Host", "ParameterValue": "erp.example.com"}]}}`))
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Empty',Version='active')/Configurations":
+			w.Write([]byte(`{"d": {"results": []}}`))
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Invoices',Version='active')/Configurations":
+			w.Write([]byte(`{"d": {"results": [{"ParameterKey": "Bank", "ParameterValue": "DE"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	configureBackupRetryDelay = time.Millisecond
+	defer func() { configureBackupRetryDelay = 10 * time.Second }()
+	dir := filepath.Join(t.TempDir(), "backup")
+
+	backup, err := backupConfiguration(exe, dir, []string{"Sales", "Finance", "Scripts"}, 0, nil)
+	require.NoError(t, err)
+	assert.False(t, backup.Complete)
+	assert.Equal(t, ConfigureBackupPackage{ID: "Sales", Done: true, File: "Sales.yml", Artifacts: 1, Parameters: 2}, backup.Packages[0],
+		"Artifacts without parameters should not be backed up and rate limited requests should be retried")
+	assert.Contains(t, backup.Packages[1].Failure, "response code = 500")
+	assert.Equal(t, ConfigureBackupPackage{ID: "Scripts", Done: true}, backup.Packages[2])
+
+	// The backup is in the configuration format
+	data, err := os.ReadFile(filepath.Join(dir, "Sales.yml"))
+	require.NoError(t, err)
+	cfg, err := flashpipe.ParseConfig("Sales.yml", data, nil)
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	assert.Equal(t, "Sales Orders", cfg.Packages[0].DisplayName)
+	artifact := cfg.Packages[0].Artifacts[0]
+	assert.Equal(t, "Orders", artifact.ID)
+	assert.Equal(t, "Integration", artifact.Type)
+	assert.Equal(t, "active", artifact.Version)
+	assert.False(t, artifact.Deploy)
+	require.Len(t, artifact.Parameters, 2)
+	assert.Equal(t, "Receiver Host", artifact.Parameters[0].Key, "Parameters should be sorted by key")
+	assert.Equal(t, "erp.example.com", artifact.Parameters[0].Value)
+
+	// Resuming only reads the packages that were not backed up
+	failFinance = false
+	previous, err := readConfigureBackup(dir)
+	require.NoError(t, err)
+	assert.Equal(t, backup.Packages, previous.Packages, "The state should be up to date")
+	backup, err = backupConfiguration(exe, dir, []string{"Sales", "Finance", "Scripts"}, 100, previous)
+	require.NoError(t, err)
+	assert.True(t, backup.Complete)
+	assert.Equal(t, ConfigureBackupPackage{ID: "Finance", Done: true, File: "Finance.yml", Artifacts: 1, Parameters: 1}, backup.Packages[1])
+	assert.Equal(t, 2, requested["/api/v1/IntegrationPackages('Sales')/IntegrationDesigntimeArtifacts"], "Sales should only be read again for the retry")
+	assert.Equal(t, 1, requested["/api/v1/IntegrationPackages('Scripts')/IntegrationDesigntimeArtifacts"])
+	assert.Zero(t, requested["/api/v1/IntegrationPackages('Sandbox')/IntegrationDesigntimeArtifacts"], "Packages not in package IDs should be skipped")
+	assert.FileExists(t, filepath.Join(dir, "Finance.yml"))
+}
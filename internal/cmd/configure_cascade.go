@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+)
+
+// cascadeTypes are the artifact types integration flows only pick up new versions of when they are redeployed
+var cascadeTypes = []string{"ScriptCollection", "ValueMapping"}
+
+// cascade are the deployed integration flows that reference script collections or value mappings of a run
+type cascade struct {
+	tasks    []DeploymentTask
+	triggers map[string][]string // Referenced artifacts by integration flow
+}
+
+// findDependentFlows looks up the deployed integration flows in the packages of the script collections and
+// value mappings to be deployed, that reference them in their integration flow model. Flows deployed in the
+// same run are included, as the artifacts of a package are deployed in parallel.
+func findDependentFlows(exe *httpclnt.HTTPExecuter, tasks []DeploymentTask) (*cascade, error) {
+	c := &cascade{triggers: map[string][]string{}}
+
+	referenced := map[string][]DeploymentTask{}
+	var packageIDs []string
+	for _, t := range tasks {
+		if slices.Contains(cascadeTypes, t.ArtifactType) {
+			if _, ok := referenced[t.PackageID]; !ok {
+				packageIDs = append(packageIDs, t.PackageID)
+			}
+			referenced[t.PackageID] = append(referenced[t.PackageID], t)
+		}
+	}
+	if len(packageIDs) == 0 {
+		return c, nil
+	}
+
+	workDir, err := os.MkdirTemp("", "flashpipe-cascade-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	ip := api.NewIntegrationPackage(exe)
+	rt := api.NewRuntime(exe)
+	dt := api.NewIntegration(exe)
+	for _, packageID := range packageIDs {
+		flows, err := ip.GetArtifactsData(packageID, "Integration")
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, t := range referenced[packageID] {
+			ids = append(ids, t.ArtifactID)
+		}
+		for _, flow := range flows {
+			version, _, err := rt.Get(flow.Id)
+			if err != nil {
+				return nil, err
+			}
+			if version == "NOT_DEPLOYED" {
+				continue
+			}
+			zipFile := filepath.Join(workDir, flow.Id+".zip")
+			if err := dt.Download(zipFile, flow.Id); err != nil {
+				return nil, err
+			}
+			refs, err := referencedArtifacts(zipFile, ids)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read content of %s: %w", flow.Id, err)
+			}
+			if len(refs) == 0 {
+				continue
+			}
+			log.Info().Msgf("Integration flow %s references %s and will be redeployed after them", flow.Id, strings.Join(refs, ", "))
+			c.triggers[flow.Id] = refs
+			c.tasks = append(c.tasks, dependentTask(flow, packageID, tasks, referenced[packageID][0]))
+		}
+	}
+	return c, nil
+}
+
+// dependentTask returns the task of the flow if it is deployed in the run, otherwise a task with the
+// maintenance window of the referenced artifact
+func dependentTask(flow *api.ArtifactDetails, packageID string, tasks []DeploymentTask, trigger DeploymentTask) DeploymentTask {
+	for _, t := range tasks {
+		if t.ArtifactID == flow.Id {
+			t.SkipIfDeployed = false
+			return t
+		}
+	}
+	return DeploymentTask{ArtifactID: flow.Id, ArtifactType: "Integration", PackageID: packageID, DisplayName: flow.Name, Window: trigger.Window}
+}
+
+// triggered returns the flows referencing at least one of the deployed artifacts
+func (c *cascade) triggered(deployed []string) []DeploymentTask {
+	var tasks []DeploymentTask
+	for _, t := range c.tasks {
+		if slices.ContainsFunc(c.triggers[t.ArtifactID], func(id string) bool { return slices.Contains(deployed, id) }) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// artifactIDs returns the IDs of the flows that may be redeployed
+func (c *cascade) artifactIDs() []string {
+	var ids []string
+	for _, t := range c.tasks {
+		ids = append(ids, t.ArtifactID)
+	}
+	return ids
+}
+
+// referencedArtifacts returns the IDs that are property values in the integration flow model of the content,
+// e.g. the scriptBundleId of script steps
+func referencedArtifacts(zipFile string, ids []string) ([]string, error) {
+	r, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var refs []string
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".iflw") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		model, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !slices.Contains(refs, id) && strings.Contains(string(model), "<value>"+id+"</value>") {
+				refs = append(refs, id)
+			}
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferencedArtifacts(t *testing.T) {
+	zipFile := filepath.Join(t.TempDir(), "Flow.zip")
+	f, err := os.Create(zipFile)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	model, err := w.Create("src/main/resources/scenarioflows/integrationflow/Flow.iflw")
+	require.NoError(t, err)
+	_, err = model.Write([]byte(`<ifl:property><key>scriptBundleId</key><value>Common_Scripts</value></ifl:property>`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	refs, err := referencedArtifacts(zipFile, []string{"Common_Scripts", "Common_Scripts_V2", "Country_Codes"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Common_Scripts"}, refs, "Only exact property values should be references")
+}
+
+func TestCascadeTriggered(t *testing.T) {
+	c := &cascade{
+		tasks: []DeploymentTask{{ArtifactID: "FlowA"}, {ArtifactID: "FlowB"}},
+		triggers: map[string][]string{
+			"FlowA": {"Common_Scripts"},
+			"FlowB": {"Country_Codes"},
+		},
+	}
+	assert.Equal(t, []DeploymentTask{{ArtifactID: "FlowA"}}, c.triggered([]string{"Common_Scripts", "FlowC"}))
+	assert.Empty(t, c.triggered(nil), "Nothing should be redeployed if no referenced artifact was deployed")
+	assert.Equal(t, []string{"FlowA", "FlowB"}, c.artifactIDs())
+}
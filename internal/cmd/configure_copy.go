@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigureCopyCommand() *cobra.Command {
+
+	copyCmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy configuration parameters from one artifact to another",
+		Long: `Copy the configured values of the parameters of an Integration artifact to
+the parameters with the same keys of another artifact, e.g. when a flow is
+cloned per region and most of the configuration is shared.
+
+Only parameters that exist in both artifacts and have different values are
+updated, in one changeset. Parameters that do not exist in the target
+artifact are listed and skipped. Use --key-prefix to only copy the
+parameters whose keys start with one of the prefixes.`,
+		Example: `  # Copy the configuration of the EMEA flow to the APJ flow
+  flashpipe configure copy --from-artifact Orders_EMEA --to-artifact Orders_APJ
+
+  # Only copy the parameters of the receiver, showing the changes first
+  flashpipe configure copy --from-artifact Orders_EMEA --to-artifact Orders_APJ --key-prefix Receiver --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runConfigureCopy(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	copyCmd.Flags().String("from-artifact", "", "ID of the artifact to copy the parameters from (config: configure.copy.fromArtifact)")
+	copyCmd.Flags().String("to-artifact", "", "ID of the artifact to copy the parameters to (config: configure.copy.toArtifact)")
+	copyCmd.Flags().StringSlice("key-prefix", nil, "Comma separated list of prefixes, only parameters whose keys start with one of them are copied (config: configure.copy.keyPrefix)")
+	copyCmd.Flags().String("version", "active", "Version of both artifacts (config: configure.copy.version)")
+	copyCmd.Flags().Bool("dry-run", false, "Show the parameters to be copied without updating them (config: configure.copy.dryRun)")
+
+	return copyCmd
+}
+
+func runConfigureCopy(cmd *cobra.Command) error {
+	fromArtifact := config.GetStringWithFallback(cmd, "from-artifact", "configure.copy.fromArtifact")
+	toArtifact := config.GetStringWithFallback(cmd, "to-artifact", "configure.copy.toArtifact")
+	keyPrefixes := config.GetStringSliceWithFallback(cmd, "key-prefix", "configure.copy.keyPrefix")
+	version := config.GetStringWithFallback(cmd, "version", "configure.copy.version")
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "configure.copy.dryRun")
+
+	if fromArtifact == "" || toArtifact == "" {
+		return fmt.Errorf("--from-artifact and --to-artifact are required (set via CLI flag or in config file under 'configure.copy')")
+	}
+	if fromArtifact == toArtifact {
+		return fmt.Errorf("--from-artifact and --to-artifact must be different artifacts")
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+	configs := newConfigurationReader(api.NewConfigurationService(exe))
+
+	source, err := configs.get(fromArtifact, version)
+	if err != nil {
+		return fmt.Errorf("failed to get configuration of %s: %w", fromArtifact, err)
+	}
+	target, err := configs.get(toArtifact, version)
+	if err != nil {
+		return fmt.Errorf("failed to get configuration of %s: %w", toArtifact, err)
+	}
+
+	parameters, missing := copyParameters(source.Root.Results, target.Root.Results, keyPrefixes)
+	for _, key := range missing {
+		log.Warn().Msgf("⚠️  Parameter %s not found in %s, skipping", key, toArtifact)
+	}
+	for _, param := range parameters {
+		existing := api.FindParameterByKey(param.Key, target.Root.Results)
+		log.Info().Msgf("  %s: %s -> %s", param.Key, existing.ParameterValue, param.Value)
+	}
+	if len(parameters) == 0 {
+		log.Info().Msgf("🏆 Configuration of %s already matches %s", toArtifact, fromArtifact)
+		return nil
+	}
+	if dryRun {
+		log.Info().Msgf("Dry run: %d parameter(s) would be copied from %s to %s", len(parameters), fromArtifact, toArtifact)
+		return nil
+	}
+
+	stats := new(ConfigureStats)
+	if err := updateParametersBatch(exe, configs, toArtifact, version, parameters, httpclnt.DefaultBatchSize, true, flashpipe.BatchFallbackAll, stats, &log.Logger); err != nil {
+		return fmt.Errorf("failed to copy parameters to %s: %w", toArtifact, err)
+	}
+	log.Info().Msgf("🏆 Copied %d parameter(s) from %s to %s", stats.ParametersUpdated.Value(), fromArtifact, toArtifact)
+	return nil
+}
+
+// copyParameters returns the parameters of the source whose keys match one of the prefixes and that have a
+// different value in the target, and the keys of the matching parameters that do not exist in the target
+func copyParameters(source, target []*api.ParameterData, keyPrefixes []string) (parameters []models.ConfigurationParameter, missing []string) {
+	for _, param := range source {
+		if !hasKeyPrefix(param.ParameterKey, keyPrefixes) {
+			continue
+		}
+		existing := api.FindParameterByKey(param.ParameterKey, target)
+		switch {
+		case existing == nil:
+			missing = append(missing, param.ParameterKey)
+		case existing.ParameterValue != param.ParameterValue:
+			parameters = append(parameters, models.ConfigurationParameter{Key: param.ParameterKey, Value: param.ParameterValue})
+		}
+	}
+	return
+}
+
+func hasKeyPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyParameters(t *testing.T) {
+	source := []*api.ParameterData{
+		{ParameterKey: "ReceiverHost", ParameterValue: "erp.example.com"},
+		{ParameterKey: "ReceiverPort", ParameterValue: "443"},
+		{ParameterKey: "ReceiverProxy", ParameterValue: "proxy"},
+		{ParameterKey: "Region", ParameterValue: "EMEA"},
+	}
+	target := []*api.ParameterData{
+		{ParameterKey: "ReceiverHost", ParameterValue: "apj-erp.example.com"},
+		{ParameterKey: "ReceiverPort", ParameterValue: "443"},
+		{ParameterKey: "Region", ParameterValue: "APJ"},
+	}
+
+	parameters, missing := copyParameters(source, target, []string{"Receiver"})
+	assert.Equal(t, []models.ConfigurationParameter{{Key: "ReceiverHost", Value: "erp.example.com"}}, parameters, "Incorrect parameters to copy")
+	assert.Equal(t, []string{"ReceiverProxy"}, missing, "Incorrect missing parameters")
+
+	parameters, _ = copyParameters(source, target, nil)
+	assert.Equal(t, 2, len(parameters), "Without prefixes all changed parameters are copied")
+	assert.Equal(t, "Region", parameters[1].Key, "Incorrect parameter to copy")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/sync"
+	"github.com/rs/zerolog"
+)
+
+// Handling of artifacts to be deployed whose designtime version is a draft, i.e. changed in the Web UI
+// without saving a version
+const (
+	draftHandlingError        = "error"
+	draftHandlingDeploy       = "deploy"
+	draftHandlingVersionFirst = "versionFirst"
+)
+
+func validateDraftHandling(policy string) error {
+	switch policy {
+	case draftHandlingError, draftHandlingDeploy, draftHandlingVersionFirst:
+		return nil
+	}
+	return fmt.Errorf("invalid draft handling %q (valid values: %s, %s, %s)", policy,
+		draftHandlingError, draftHandlingDeploy, draftHandlingVersionFirst)
+}
+
+// validateArtifactDraftHandling returns an error for the first artifact with an unknown draft handling
+func validateArtifactDraftHandling(cfg *models.ConfigureConfig) error {
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			if artifact.DraftHandling == "" {
+				continue
+			}
+			if err := validateDraftHandling(artifact.DraftHandling); err != nil {
+				return fmt.Errorf("artifact %s: %w", artifact.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// handleDraft applies the draft handling to an artifact to be deployed. With error, a draft fails the
+// artifact, with deploy the draft is configured and deployed as is, and with versionFirst the draft of an
+// integration flow is saved as the next patch version before it is configured. The version is not read with
+// deploy, which is the behavior without draft handling.
+func handleDraft(exe *httpclnt.HTTPExecuter, artifactID, artifactType, policy string, l *zerolog.Logger) error {
+	if policy == draftHandlingDeploy {
+		return nil
+	}
+	dt := api.NewDesigntimeArtifact(artifactType, exe)
+	version, _, exists, err := dt.Get(artifactID, "active")
+	if err != nil {
+		return fmt.Errorf("failed to get designtime version: %w", err)
+	}
+	// The version of a draft is reported as Active
+	if !exists || !strings.EqualFold(version, "active") {
+		return nil
+	}
+
+	if policy == draftHandlingError {
+		return fmt.Errorf("artifact is in draft version, save a version in the Web UI first or set draftHandling to %s or %s",
+			draftHandlingDeploy, draftHandlingVersionFirst)
+	}
+	if artifactType != "Integration" {
+		return fmt.Errorf("artifact is in draft version, saving a version is only supported for integration flows")
+	}
+	next, err := nextDraftVersion(exe, artifactID)
+	if err != nil {
+		return err
+	}
+	if err := api.SaveIntegrationAsVersion(artifactID, next, exe); err != nil {
+		return fmt.Errorf("failed to save draft as version %s: %w", next, err)
+	}
+	l.Info().Msgf("      💾 Saved draft as version %s", next)
+	return nil
+}
+
+// nextDraftVersion returns the patch version following the Bundle-Version of the draft of an integration
+// flow, which is the last saved version
+func nextDraftVersion(exe *httpclnt.HTTPExecuter, artifactID string) (string, error) {
+	workDir, err := os.MkdirTemp("", "flashpipe-draft-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	zipFile := filepath.Join(workDir, artifactID+".zip")
+	if err := api.NewIntegration(exe).Download(zipFile, artifactID); err != nil {
+		return "", err
+	}
+	contentDir := filepath.Join(workDir, "content")
+	if err := file.UnzipSource(zipFile, contentDir); err != nil {
+		return "", err
+	}
+	headers, err := file.ReadManifest(filepath.Join(contentDir, "META-INF", "MANIFEST.MF"))
+	if err != nil {
+		return "", err
+	}
+	return sync.BumpVersion(headers["Bundle-Version"], sync.BumpPatch)
+}
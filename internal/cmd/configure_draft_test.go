@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDraftMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, the draft was last saved as version 1.0.3
+	var savedVersion string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{ "d": { "Version": "Active" } }`))
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$value":
+			zw := zip.NewWriter(w)
+			f, _ := zw.Create("META-INF/MANIFEST.MF")
+			f.Write([]byte("Manifest-Version: 1.0\r\nBundle-SymbolicName: Flow\r\nBundle-Version: 1.0.3\r\n"))
+			zw.Close()
+		case "/api/v1/IntegrationDesigntimeArtifactSaveAsVersion":
+			savedVersion = r.URL.Query().Get("SaveAsVersion")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	assert.Error(t, handleDraft(exe, "Flow", "Integration", draftHandlingError, &log.Logger), "Draft should be an error")
+	assert.NoError(t, handleDraft(exe, "Flow", "Integration", draftHandlingDeploy, &log.Logger), "Draft should be deployed as is")
+	assert.Empty(t, savedVersion, "Draft should not be saved with deploy")
+
+	require.NoError(t, handleDraft(exe, "Flow", "Integration", draftHandlingVersionFirst, &log.Logger))
+	assert.Equal(t, "'1.0.4'", savedVersion, "Draft should be saved as the next patch version")
+}
+
+func TestValidateDraftHandling(t *testing.T) {
+	assert.NoError(t, validateDraftHandling(draftHandlingVersionFirst))
+	assert.Error(t, validateDraftHandling("skip"), "Invalid draft handling should be an error")
+}
@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/mockcpi"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixtureConfig(t *testing.T) *models.ConfigureConfig {
+	data, err := mockcpi.FixtureFile("configure.yml")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "configure.yml")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	configData, err := loadConfigureData(NewConfigureCommand(), path, "")
+	require.NoError(t, err)
+	return configData
+}
+
+func runFixtureConfig(t *testing.T, svr *mockcpi.Server, disableBatch bool) *ConfigureStats {
+	stats, err := configureTenant(svr.Executer(), loadFixtureConfig(t), nil, nil, false, 3, 0, 2, 10,
+		disableBatch, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+		nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{})
+	require.NoError(t, err)
+	return stats
+}
+
+func TestConfigureMockTenant(t *testing.T) {
+	for name, disableBatch := range map[string]bool{"batch": false, "individual": true} {
+		t.Run(name, func(t *testing.T) {
+			svr := mockcpi.New(mockcpi.DefaultFixture())
+			defer svr.Close()
+
+			stats := runFixtureConfig(t, svr, disableBatch)
+
+			assert.Equal(t, 3, stats.ParametersUpdated.Value())
+			assert.Equal(t, 0, stats.ArtifactsFailed.Value())
+			assert.Equal(t, 3, stats.DeploymentTasksSuccessful.Value())
+			if disableBatch {
+				assert.Equal(t, 0, stats.BatchRequestsExecuted.Value())
+			} else {
+				assert.Positive(t, stats.BatchRequestsExecuted.Value())
+			}
+
+			value, _ := svr.Parameter("Orders", "Receiver Host")
+			assert.Equal(t, "prod.example.com", value)
+			value, _ = svr.Parameter("Orders", "Timeout")
+			assert.Equal(t, "60", value)
+			value, _ = svr.Parameter("Invoices", "Company's Code")
+			assert.Equal(t, "2000", value, "Keys with quotes should be escaped")
+			for _, id := range []string{"Orders", "Invoices", "Order_Mapping"} {
+				if assert.NotNil(t, svr.Runtime(id), id) {
+					assert.Equal(t, "STARTED", svr.Runtime(id).Status, id)
+				}
+			}
+			assert.Empty(t, svr.Unhandled())
+		})
+	}
+}
+
+func TestConfigureMockTenantDeployError(t *testing.T) {
+	fixture := mockcpi.DefaultFixture()
+	fixture.Packages[0].Artifacts[1].DeployError = "Invalid receiver host"
+	svr := mockcpi.New(fixture)
+	defer svr.Close()
+
+	stats := runFixtureConfig(t, svr, false)
+
+	assert.Equal(t, 2, stats.DeploymentTasksSuccessful.Value())
+	assert.Equal(t, 1, stats.DeploymentTasksFailed.Value())
+	if assert.NotNil(t, svr.Runtime("Invoices")) {
+		assert.Equal(t, "ERROR", svr.Runtime("Invoices").Status)
+	}
+	assert.Empty(t, svr.Unhandled())
+}
+
+func TestConfigureMockTenantTags(t *testing.T) {
+	svr := mockcpi.New(mockcpi.DefaultFixture())
+	defer svr.Close()
+	path := filepath.Join(t.TempDir(), "configure.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`packages:
+  - integrationSuiteId: Sales
+    tags: [sales]
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        tags: [critical]
+        parameters:
+          - key: Timeout
+            value: "60"
+      - artifactId: Invoices
+        type: Integration
+        tags: [experimental]
+        parameters:
+          - key: Company's Code
+            value: "2000"
+`), 0644))
+	cmd := NewConfigureCommand()
+	require.NoError(t, cmd.ParseFlags([]string{"--tags", "sales", "--exclude-tags", "experimental"}))
+	configData, err := loadConfigureData(cmd, path, "")
+	require.NoError(t, err)
+
+	_, err = configureTenant(svr.Executer(), configData, nil, nil, false, 3, 0, 2, 10,
+		false, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+		nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{})
+	require.NoError(t, err)
+
+	value, _ := svr.Parameter("Orders", "Timeout")
+	assert.Equal(t, "60", value)
+	value, _ = svr.Parameter("Invoices", "Company's Code")
+	assert.Equal(t, "1000", value, "Artifacts with an excluded tag should not be changed")
+}
+
+func TestConfigureMockTenantBatchFallback(t *testing.T) {
+	for _, fallback := range []string{flashpipe.BatchFallbackNone, flashpipe.BatchFallbackFailedOnly, flashpipe.BatchFallbackAll} {
+		t.Run(fallback, func(t *testing.T) {
+			svr := mockcpi.New(mockcpi.DefaultFixture())
+			defer svr.Close()
+
+			// Batch requests updating the parameter Timeout fail as a whole
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && r.URL.Path == "/api/v1/$batch" {
+					body, _ := io.ReadAll(r.Body)
+					if bytes.Contains(body, []byte("Configurations('Timeout')")) {
+						http.Error(w, "Bad Request", http.StatusBadRequest)
+						return
+					}
+					r.Body = io.NopCloser(bytes.NewReader(body))
+				}
+				svr.Config.Handler.ServeHTTP(w, r)
+			}))
+			defer proxy.Close()
+			host, port := httpclnt.GetHostPort(proxy.URL)
+			exe := httpclnt.New("", "", "", "", "mock", "mock", host, "http", port, true)
+
+			configData := loadFixtureConfig(t)
+			configData.BatchFallback = fallback
+			stats, err := configureTenant(exe, configData, nil, []string{"Orders"}, false, 3, 0, 2, 1,
+				false, true, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+				nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{})
+			require.NoError(t, err)
+
+			host, _ = svr.Parameter("Orders", "Receiver Host")
+			assert.Equal(t, "prod.example.com", host, "Parameters of successful batches should be updated")
+			timeout, _ := svr.Parameter("Orders", "Timeout")
+			switch fallback {
+			case flashpipe.BatchFallbackNone:
+				assert.Equal(t, "30", timeout)
+				assert.Equal(t, 1, stats.ArtifactsFailed.Value())
+				assert.Equal(t, 1, stats.ParametersFailed.Value())
+				assert.Empty(t, stats.BatchFallbacks)
+			case flashpipe.BatchFallbackFailedOnly:
+				assert.Equal(t, "60", timeout)
+				assert.Equal(t, 1, stats.IndividualRequestsUsed.Value())
+				if assert.Len(t, stats.BatchFallbacks, 1) {
+					assert.Equal(t, 1, stats.BatchFallbacks[0].Parameters)
+					assert.Contains(t, stats.BatchFallbacks[0].Reason, "status 400")
+				}
+			case flashpipe.BatchFallbackAll:
+				assert.Equal(t, "60", timeout)
+				assert.Equal(t, 2, stats.IndividualRequestsUsed.Value())
+				if assert.Len(t, stats.BatchFallbacks, 1) {
+					assert.Equal(t, flashpipe.BatchFallback{ArtifactID: "Orders", Policy: flashpipe.BatchFallbackAll, Parameters: 2,
+						Reason: stats.BatchFallbacks[0].Reason}, stats.BatchFallbacks[0])
+				}
+			}
+		})
+	}
+}
+
+func TestConfigureMockTenantDeployAs(t *testing.T) {
+	fixture := mockcpi.DefaultFixture()
+	orders := fixture.Packages[0].Artifacts[0]
+	for _, id := range []string{"DEV_A_Orders", "DEV_B_Orders"} {
+		instance := orders
+		instance.ID = id
+		instance.Parameters = map[string]string{"Receiver Host": "dev.example.com", "Timeout": "30"}
+		fixture.Packages[0].Artifacts = append(fixture.Packages[0].Artifacts, instance)
+	}
+	svr := mockcpi.New(fixture)
+	defer svr.Close()
+	path := filepath.Join(t.TempDir(), "configure.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        deploy: true
+        parameters:
+          - key: Receiver Host
+            value: erp.example.com
+          - key: Timeout
+            value: "60"
+        deployAs:
+          - prefix: A_
+          - prefix: B_
+            parameters:
+              - key: Timeout
+                value: "90"
+`), 0644))
+	configData, err := loadConfigureData(NewConfigureCommand(), path, "DEV_")
+	require.NoError(t, err)
+
+	stats, err := configureTenant(svr.Executer(), configData, nil, nil, false, 3, 0, 2, 10,
+		false, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+		nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, stats.DeploymentTasksSuccessful.Value())
+	for id, timeout := range map[string]string{"DEV_A_Orders": "60", "DEV_B_Orders": "90"} {
+		value, _ := svr.Parameter(id, "Receiver Host")
+		assert.Equal(t, "erp.example.com", value, id)
+		value, _ = svr.Parameter(id, "Timeout")
+		assert.Equal(t, timeout, value, id)
+		if assert.NotNil(t, svr.Runtime(id), id) {
+			assert.Equal(t, "STARTED", svr.Runtime(id).Status, id)
+		}
+	}
+	value, _ := svr.Parameter("Orders", "Timeout")
+	assert.Equal(t, "30", value, "The artifact without prefix should not be changed")
+	assert.Empty(t, svr.Unhandled())
+}
+
+func TestConfigureMockTenantStamp(t *testing.T) {
+	t.Setenv("GITHUB_SHA", "9fceb02")
+	fixture := mockcpi.DefaultFixture()
+	fixture.Packages[0].Description = "Sales interfaces"
+	fixture.CustomTags = []string{"LastApplied"}
+
+	for _, stamp := range []models.ConfigureStamp{{Target: "description"}, {Target: "tag", Tag: "LastApplied"}, {Target: "tag"}} {
+		t.Run(stamp.Target+stamp.Tag, func(t *testing.T) {
+			svr := mockcpi.New(fixture)
+			defer svr.Close()
+			configData := loadFixtureConfig(t)
+			configData.Stamp = &stamp
+
+			stats, err := configureTenant(svr.Executer(), configData, nil, nil, false, 3, 0, 2, 10,
+				false, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+				nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{})
+			require.NoError(t, err)
+
+			value, found := svr.CustomTag("Sales", "LastApplied")
+			switch {
+			case stamp.Target == "description":
+				assert.Regexp(t, `^Sales interfaces\n\n\[flashpipe\] Last applied \d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ, .*revision 9fceb02$`, svr.PackageDescription("Sales"))
+				assert.False(t, found)
+			case stamp.Tag != "":
+				assert.Contains(t, value, "revision 9fceb02")
+				assert.Equal(t, "Sales interfaces", svr.PackageDescription("Sales"))
+			default:
+				assert.Empty(t, value)
+				if assert.Len(t, stats.Warnings, 1) {
+					assert.Contains(t, stats.Warnings[0], "Package Sales not stamped", "Undefined custom tags should not fail the run")
+				}
+			}
+			assert.Empty(t, svr.Unhandled())
+		})
+	}
+}
+
+func TestConfigureMockTenantPrefixCheck(t *testing.T) {
+	fixture := &mockcpi.Fixture{Packages: []mockcpi.Package{{ID: "DEV_Sales", Artifacts: []mockcpi.Artifact{
+		{ID: "DEV_Orders", Type: "Integration", Version: "1.0.0", Parameters: map[string]string{"Timeout": "30"}},
+		{ID: "DEV_Invoices", Type: "Integration", Version: "1.0.0"},
+		{ID: "DEV_Legacy", Type: "Integration", Version: "1.0.0"},
+		{ID: "Orders", Type: "Integration", Version: "1.0.0"},
+	}}}}
+	path := filepath.Join(t.TempDir(), "configure.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        parameters:
+          - key: Timeout
+            value: "60"
+      - artifactId: Invoices
+        type: Integration
+`), 0644))
+
+	t.Run("typo", func(t *testing.T) {
+		svr := mockcpi.New(fixture)
+		defer svr.Close()
+		configData, err := loadConfigureData(NewConfigureCommand(), path, "DEV-")
+		require.NoError(t, err)
+
+		stats, err := configureTenant(svr.Executer(), configData, nil, nil, false, 3, 0, 2, 10,
+			false, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+			nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{prefixCheck: prefixCheckError})
+		require.EqualError(t, err, "prefix check: 2 of 2 configured artifact(s) not found on the tenant, nothing applied (check the deployment prefix)")
+		assert.Equal(t, []flashpipe.PrefixCheckArtifact{
+			{PackageID: "DEV-Sales", ArtifactID: "DEV-Orders", Candidate: "DEV_Orders"},
+			{PackageID: "DEV-Sales", ArtifactID: "DEV-Invoices", Candidate: "DEV_Invoices"},
+		}, stats.PrefixCheck.Missing)
+		assert.Empty(t, stats.PrefixCheck.Extra, "Artifacts of a package under another prefix should not be extra")
+		for _, request := range svr.Requests() {
+			assert.Regexp(t, `^GET `, request, "Nothing should be applied")
+		}
+		assert.Empty(t, svr.Unhandled())
+	})
+
+	t.Run("reconciled", func(t *testing.T) {
+		svr := mockcpi.New(fixture)
+		defer svr.Close()
+		configData, err := loadConfigureData(NewConfigureCommand(), path, "DEV_")
+		require.NoError(t, err)
+
+		stats, err := configureTenant(svr.Executer(), configData, nil, nil, false, 3, 0, 2, 10,
+			false, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+			nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{prefixCheck: prefixCheckError})
+		require.NoError(t, err)
+		assert.Equal(t, &flashpipe.PrefixCheck{Expected: 2, Present: 2,
+			Extra: []flashpipe.PrefixCheckArtifact{{PackageID: "DEV_Sales", ArtifactID: "DEV_Legacy"}}}, stats.PrefixCheck)
+		value, _ := svr.Parameter("DEV_Orders", "Timeout")
+		assert.Equal(t, "60", value)
+		assert.Empty(t, svr.Unhandled())
+	})
+}
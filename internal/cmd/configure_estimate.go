@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/engswee/flashpipe/internal/history"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/rs/zerolog/log"
+)
+
+// Latencies assumed by the estimate of a run without history of the tenant
+const (
+	defaultRequestLatency    = 300 * time.Millisecond
+	defaultDeploymentLatency = time.Minute
+)
+
+// RunEstimate is the number of API calls and the duration of the run that a dry run simulated
+type RunEstimate struct {
+	Gets      int
+	Puts      int
+	Batches   int // $batch requests, including the prefetch of the configurations
+	Deploys   int
+	Configure time.Duration
+	Deploy    time.Duration
+	Basis     *history.Run // Run of the history the durations are based on, nil for the default latencies
+}
+
+// Calls returns the number of API calls in total
+func (e *RunEstimate) Calls() int {
+	return e.Gets + e.Puts + e.Batches + e.Deploys
+}
+
+// estimateRun estimates the API calls of the run simulated by a dry run with stats from the artifacts, parameters
+// and deployments it counted, and the duration from the last run on the tenant recorded in the history, if any.
+// Batch settings of single artifacts are not taken into account.
+func estimateRun(stats *ConfigureStats, o tenantOptions, tenant string) *RunEstimate {
+	artifacts := stats.ArtifactsConfigured.Value()
+	parameters := stats.ParametersUpdated.Value()
+	deployments := stats.DeploymentTasksQueued.Value()
+	batchSize := o.batchSize
+	if batchSize <= 0 {
+		batchSize = httpclnt.DefaultBatchSize
+	}
+
+	e := new(RunEstimate)
+	if o.disableBatch {
+		// One read to check the parameters exist, further reads to compare the values
+		e.Gets = artifacts
+		if o.skipUnchanged {
+			e.Gets += artifacts
+		}
+		if !o.forceDeploy {
+			e.Gets += deployments
+		}
+		e.Puts = parameters
+	} else if artifacts > 0 {
+		// The configurations are prefetched, the updates of an artifact are sent in one changeset or in chunks
+		e.Batches = ceilDiv(artifacts, batchSize) + artifacts
+		if o.disableChangeset {
+			e.Batches = ceilDiv(artifacts, batchSize) + max(artifacts, ceilDiv(parameters, batchSize))
+		}
+	}
+	configureCalls := e.Calls()
+
+	// At least one status check per deployment, and the versions compared unless forced
+	e.Deploys = deployments
+	e.Gets += deployments
+	if !o.forceDeploy {
+		e.Gets += 2 * deployments
+	}
+
+	e.Basis = lastRun(o.historyDb, tenant)
+	if e.Basis != nil && e.Basis.Stats.Timings.AveragePerArtifact > 0 {
+		e.Configure = time.Duration(artifacts) * e.Basis.Stats.Timings.AveragePerArtifact
+	} else {
+		e.Basis = nil
+		e.Configure = time.Duration(configureCalls) * defaultRequestLatency
+	}
+	if deployed := deployedArtifacts(e.Basis); deployed > 0 {
+		e.Deploy = time.Duration(deployments) * (e.Basis.Stats.Timings.Deploy / time.Duration(deployed))
+	} else {
+		e.Deploy = time.Duration(ceilDiv(deployments, max(o.parallelDeployments, 1))) * defaultDeploymentLatency
+	}
+	return e
+}
+
+// lastRun returns the last run on the tenant that was not a dry run from the history database, or the default
+// history database if none is set. A missing or unreadable history has no runs.
+func lastRun(historyDb string, tenant string) *history.Run {
+	store, err := history.NewStore(historyDb)
+	if err != nil {
+		return nil
+	}
+	runs, err := store.Runs()
+	if err != nil {
+		log.Debug().Msgf("History not available for the estimate: %v", err)
+		return nil
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if r := runs[i]; r.Tenant == tenant && !r.DryRun && r.Stats != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// deployedArtifacts returns the number of deployments of the run, 0 without a run
+func deployedArtifacts(r *history.Run) int {
+	if r == nil || r.Stats.Timings.Deploy == 0 {
+		return 0
+	}
+	return r.Stats.DeploymentTasksSuccessful.Value() + r.Stats.DeploymentTasksFailed.Value()
+}
+
+func ceilDiv(n, d int) int {
+	return (n + d - 1) / d
+}
+
+// printEstimate logs the estimate of the run simulated by a dry run on the tenant
+func printEstimate(stats *ConfigureStats, o tenantOptions, tenant string) {
+	if stats == nil {
+		return
+	}
+	e := estimateRun(stats, o, tenant)
+	logger.Banner(logger.SeparatorDouble, "RUN ESTIMATE")
+	log.Info().Msgf("API calls:          %d GET, %d PUT, %d $batch, %d deploy (%d in total)", e.Gets, e.Puts, e.Batches, e.Deploys, e.Calls())
+	log.Info().Msgf("Estimated duration: %v (configure %v, deploy %v)", logger.FormatDuration(e.Configure+e.Deploy),
+		logger.FormatDuration(e.Configure), logger.FormatDuration(e.Deploy))
+	if e.Basis != nil {
+		log.Info().Msgf("Based on:           run %d of %s", e.Basis.ID, e.Basis.Started.Format(time.RFC822))
+	} else {
+		log.Info().Msgf("Based on:           default latencies (%v per request, %v per deployment), no run of %s in the history",
+			defaultRequestLatency, defaultDeploymentLatency, tenant)
+	}
+	logger.Rule(logger.SeparatorDouble)
+}
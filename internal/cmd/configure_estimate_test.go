@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateRun(t *testing.T) {
+	stats := &ConfigureStats{}
+	stats.ArtifactsConfigured.Add(4)
+	stats.ParametersUpdated.Add(10)
+	stats.DeploymentTasksQueued.Add(2)
+	historyDb := filepath.Join(t.TempDir(), "history.db")
+	opts := tenantOptions{batchSize: 90, parallelDeployments: 2, historyDb: historyDb}
+
+	e := estimateRun(stats, opts, "prod")
+	assert.Equal(t, RunEstimate{Gets: 6, Batches: 5, Deploys: 2, Configure: 5 * defaultRequestLatency, Deploy: defaultDeploymentLatency}, *e,
+		"Batches should prefetch the configurations and update each artifact in one changeset")
+
+	opts.disableBatch, opts.forceDeploy = true, true
+	e = estimateRun(stats, opts, "prod")
+	assert.Equal(t, 6, e.Gets, "One read per artifact and one status check per deployment")
+	assert.Equal(t, 10, e.Puts, "One request per parameter")
+	assert.Equal(t, 18, e.Calls())
+
+	// Durations of the last run of the tenant
+	if _, err := exec.LookPath(history.Shell); err != nil {
+		t.Skipf("%s is not on the PATH", history.Shell)
+	}
+	store, err := history.NewStore(historyDb)
+	require.NoError(t, err)
+	run := &ConfigureStats{}
+	run.DeploymentTasksSuccessful.Add(3)
+	run.Timings.AveragePerArtifact = 2 * time.Second
+	run.Timings.Deploy = 90 * time.Second
+	require.NoError(t, store.Append(&history.Run{Tenant: "prod", Stats: run}))
+	require.NoError(t, store.Append(&history.Run{Tenant: "prod", DryRun: true, Stats: &ConfigureStats{}}))
+	require.NoError(t, store.Append(&history.Run{Tenant: "qa", Stats: &ConfigureStats{}}))
+
+	e = estimateRun(stats, opts, "prod")
+	require.NotNil(t, e.Basis)
+	assert.Equal(t, 1, e.Basis.ID)
+	assert.Equal(t, 8*time.Second, e.Configure)
+	assert.Equal(t, 60*time.Second, e.Deploy)
+}
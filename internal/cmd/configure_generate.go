@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func NewConfigureGenerateCommand() *cobra.Command {
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a configuration file from the artifacts of a local repository",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Generate a skeleton configuration file from the artifacts of a local
+repository, e.g. as written by snapshot or sync.
+
+The externalized parameters of each artifact are read from parameters.prop
+and parameters.propdef, and written with their default values grouped by
+package, i.e. by the directory containing the artifact directories. Types
+and required flags of the definitions are added as comments. Artifacts
+without externalized parameters are not written.`,
+		Example: `  # Print the configuration of all artifacts of the repository
+  flashpipe configure generate --dir ./repo
+
+  # Write it to a file to fill in per environment
+  flashpipe configure generate --dir ./repo --output ./configs/prod.yml`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runConfigureGenerate(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	generateCmd.Flags().String("dir", ".", "Directory with the artifacts, one directory per package (config: configure.generate.dir)")
+	generateCmd.Flags().String("output", "", "File to write the configuration to, printed if not set (config: configure.generate.output)")
+	generateCmd.Flags().Bool("force", false, "Overwrite an existing output file (config: configure.generate.force)")
+
+	return generateCmd
+}
+
+func runConfigureGenerate(cmd *cobra.Command) error {
+	dir := config.GetStringWithFallback(cmd, "dir", "configure.generate.dir")
+	output := config.GetStringWithFallback(cmd, "output", "configure.generate.output")
+	force := config.GetBoolWithFallback(cmd, "force", "configure.generate.force")
+
+	if output != "" && !force && file.Exists(output) {
+		return fmt.Errorf("output file %s already exists, use --force to overwrite it", output)
+	}
+	cfg, definitions, err := generateConfiguration(dir)
+	if err != nil {
+		return err
+	}
+	data, err := generatedConfigurationYAML(dir, cfg, definitions)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = io.Copy(cmd.OutOrStdout(), bytes.NewReader(data))
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	log.Info().Msgf("Configuration of %d package(s) written to %s", len(cfg.Packages), output)
+	return nil
+}
+
+// generateConfiguration returns the configuration of the artifacts in dir with their externalized parameters,
+// and the definitions of the parameters by artifact ID and key
+func generateConfiguration(dir string) (*models.ConfigureConfig, map[string]map[string]designtime.ParameterDefinition, error) {
+	packages, err := designtime.Inventory(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &models.ConfigureConfig{}
+	definitions := map[string]map[string]designtime.ParameterDefinition{}
+	for _, pkg := range packages {
+		configPackage := models.ConfigurePackage{ID: pkg.ID}
+		for _, artifact := range pkg.Artifacts {
+			artifactType := api.CanonicalArtifactType(artifact.Type)
+			if artifactType == "" {
+				log.Warn().Msgf("Skipping %s of type %q, which cannot be configured", artifact.ID, artifact.Type)
+				continue
+			}
+			parameters, err := designtime.ArtifactParameters(artifact.Dir)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read parameters of %s: %w", artifact.ID, err)
+			}
+			if len(parameters) == 0 {
+				log.Debug().Msgf("Skipping %s without externalized parameters", artifact.ID)
+				continue
+			}
+
+			configArtifact := models.ConfigureArtifact{ID: artifact.ID, DisplayName: artifact.Name, Type: artifactType}
+			definitions[artifact.ID] = map[string]designtime.ParameterDefinition{}
+			for _, parameter := range parameters {
+				configArtifact.Parameters = append(configArtifact.Parameters, models.ConfigurationParameter{Key: parameter.Key, Value: parameter.Default})
+				definitions[artifact.ID][parameter.Key] = parameter
+			}
+			configPackage.Artifacts = append(configPackage.Artifacts, configArtifact)
+		}
+		if len(configPackage.Artifacts) > 0 {
+			cfg.Packages = append(cfg.Packages, configPackage)
+		}
+	}
+	if len(cfg.Packages) == 0 {
+		return nil, nil, fmt.Errorf("no artifacts with externalized parameters found in %s", dir)
+	}
+	return cfg, definitions, nil
+}
+
+// generatedConfigurationYAML returns the configuration as YAML, with the type, required flag and description of
+// each parameter as a comment
+func generatedConfigurationYAML(dir string, cfg *models.ConfigureConfig, definitions map[string]map[string]designtime.ParameterDefinition) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, err
+	}
+	doc.HeadComment = fmt.Sprintf("Generated by flashpipe configure generate from %s\nSet the values of each environment, and remove the parameters that keep their default value", dir)
+
+	for pi, pkg := range cfg.Packages {
+		artifactNodes := mappingValue(mappingValue(&doc, "packages").Content[pi], "artifacts")
+		for ai, artifact := range pkg.Artifacts {
+			parameterNodes := mappingValue(artifactNodes.Content[ai], "parameters")
+			for i, parameter := range artifact.Parameters {
+				definition := definitions[artifact.ID][parameter.Key]
+				var notes []string
+				if definition.Required {
+					notes = append(notes, "required")
+				}
+				if definition.Type != "" {
+					notes = append(notes, definition.Type)
+				}
+				if definition.Description != "" {
+					notes = append(notes, strings.Join(strings.Fields(definition.Description), " "))
+				}
+				if len(notes) > 0 {
+					mappingValue(parameterNodes.Content[i], "value").LineComment = strings.Join(notes, ", ")
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mappingValue returns the value of key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode {
+		node = node.Content[0]
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
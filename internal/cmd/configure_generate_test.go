@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	writeArtifact := func(pkg, artifact, bundleType string, files map[string]string) {
+		files["META-INF/MANIFEST.MF"] = "Manifest-Version: 1.0\nBundle-SymbolicName: " + artifact + "; singleton:=true\nBundle-Name: " + artifact + " Flow\nSAP-BundleType: " + bundleType + "\n"
+		for name, content := range files {
+			path := filepath.Join(dir, pkg, artifact, filepath.FromSlash(name))
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		}
+	}
+	writeArtifact("Sales", "Orders", "IntegrationFlow", map[string]string{
+		"src/main/resources/parameters.prop": "#Store externalized parameters\nHost=erp.example.com\nReceiver\\ Port=443\n",
+		"src/main/resources/parameters.propdef": `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<parameters>
+  <parameter><name>Host</name><type>xsd:string</type><isRequired>true</isRequired><description>ERP host</description></parameter>
+  <parameter><name>Credential</name><type>xsd:string</type><isRequired>false</isRequired><description></description></parameter>
+</parameters>`,
+	})
+	writeArtifact("Sales", "Common_Scripts", "ScriptCollection", map[string]string{})
+	writeArtifact("Sales", "Orders_API", "ODataService", map[string]string{"src/main/resources/parameters.prop": "Path=/orders\n"})
+
+	cfg, definitions, err := generateConfiguration(dir)
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	assert.Equal(t, "Sales", cfg.Packages[0].ID)
+	require.Len(t, cfg.Packages[0].Artifacts, 1, "Artifacts without parameters and of other types are skipped")
+	artifact := cfg.Packages[0].Artifacts[0]
+	assert.Equal(t, "Orders", artifact.ID)
+	assert.Equal(t, "Integration", artifact.Type)
+	require.Len(t, artifact.Parameters, 3)
+	assert.Equal(t, "Credential", artifact.Parameters[0].Key)
+	assert.Equal(t, "", artifact.Parameters[0].Value)
+	assert.Equal(t, "Receiver Port", artifact.Parameters[2].Key)
+	assert.Equal(t, "443", artifact.Parameters[2].Value)
+	assert.True(t, definitions["Orders"]["Host"].Required)
+
+	data, err := generatedConfigurationYAML(dir, cfg, definitions)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "value: erp.example.com # required, xsd:string, ERP host\n")
+
+	parsed, err := flashpipe.ParseConfig("generated.yml", data, nil)
+	require.NoError(t, err, "The generated configuration can be loaded")
+	require.Len(t, parsed.Packages[0].Artifacts[0].Parameters, 3)
+	assert.Equal(t, "erp.example.com", parsed.Packages[0].Artifacts[0].Parameters[1].Value)
+
+	_, _, err = generateConfiguration(filepath.Join(dir, "Sales", "Common_Scripts"))
+	assert.EqualError(t, err, "no artifacts with externalized parameters found in "+filepath.Join(dir, "Sales", "Common_Scripts"))
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Annotations of the manifest of the GitOps modes, read by the health check of Argo CD
+const (
+	gitopsHealthAnnotation  = "flashpipe.io/health"
+	gitopsMessageAnnotation = "flashpipe.io/health-message"
+	gitopsTenantAnnotation  = "flashpipe.io/tenant"
+)
+
+// gitopsConfigMap is the manifest written by the GitOps modes. Only keys and kinds of deviations are
+// included, parameter values may be secrets.
+type gitopsConfigMap struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name        string            `yaml:"name"`
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Data map[string]string `yaml:"data"`
+}
+
+// runGitops compares the tenant with the configuration and writes the outcome as a Kubernetes manifest to out,
+// for the generate command of an Argo CD config management plugin. Logs go to stderr, so that out only contains
+// the manifest. apply is called before the comparison in the apply mode, its error fails the generation.
+func runGitops(cmd *cobra.Command, configPath, deploymentPrefix, packageFilter, artifactFilter string, apply func() error, out io.Writer) error {
+	if deploymentPrefix != "" {
+		if err := deploy.ValidateDeploymentPrefix(deploymentPrefix); err != nil {
+			return err
+		}
+	}
+	configData, err := loadConfigureData(cmd, configPath, deploymentPrefix)
+	if err != nil {
+		return err
+	}
+	if len(configData.Targets) > 0 {
+		return fmt.Errorf("--gitops-diff and --gitops-apply do not support configurations with targets, use one application per tenant")
+	}
+	if apply != nil {
+		if err := apply(); err != nil {
+			return err
+		}
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+	result := verifyConfiguration(exe, configData, parseFilter(packageFilter), parseFilter(artifactFilter))
+	log.Info().Msgf("Checked %d parameter(s) of %d artifact(s), %d deviation(s)", result.ParametersChecked, result.ArtifactsChecked, len(result.Deviations))
+
+	name := gitopsName(config.GetStringWithFallback(cmd, "gitops-name", "configure.gitopsName"))
+	manifest, err := gitopsManifest(name, serviceDetails.Host, result)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(manifest)
+	return err
+}
+
+// gitopsName returns the name of the manifest, defaulting to the name of the Argo CD application
+func gitopsName(name string) string {
+	if name == "" {
+		name = os.Getenv("ARGOCD_APP_NAME")
+	}
+	if name == "" {
+		name = "configuration"
+	}
+	// Names of Kubernetes objects are lowercase DNS subdomains
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, "flashpipe-"+name)
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.TrimRight(name, "-.")
+}
+
+func gitopsManifest(name string, host string, result *ConfigureVerifyResult) ([]byte, error) {
+	m := gitopsConfigMap{APIVersion: "v1", Kind: "ConfigMap"}
+	m.Metadata.Name = name
+	m.Metadata.Labels = map[string]string{"app.kubernetes.io/managed-by": "flashpipe"}
+	m.Metadata.Annotations = map[string]string{
+		gitopsHealthAnnotation:  "Healthy",
+		gitopsMessageAnnotation: "Tenant matches the configuration",
+		gitopsTenantAnnotation:  host,
+	}
+	m.Data = map[string]string{
+		"artifactsChecked":  strconv.Itoa(result.ArtifactsChecked),
+		"parametersChecked": strconv.Itoa(result.ParametersChecked),
+	}
+	if len(result.Deviations) > 0 {
+		m.Metadata.Annotations[gitopsHealthAnnotation] = "Degraded"
+		m.Metadata.Annotations[gitopsMessageAnnotation] = fmt.Sprintf("%d deviation(s) between tenant and configuration", len(result.Deviations))
+		var deviations strings.Builder
+		for _, d := range result.Deviations {
+			switch d.Kind {
+			case DeviationValueMismatch, DeviationMissingParameter:
+				fmt.Fprintf(&deviations, "%s/%s: %s\n", d.ArtifactID, d.Key, d.Kind)
+			case DeviationNotStarted, DeviationVersionMismatch:
+				fmt.Fprintf(&deviations, "%s: %s (%s)\n", d.ArtifactID, d.Kind, d.Actual)
+			default:
+				fmt.Fprintf(&deviations, "%s: %s (%s)\n", d.ArtifactID, d.Kind, d.Message)
+			}
+		}
+		m.Data["deviations"] = deviations.String()
+	}
+	return yaml.Marshal(m)
+}
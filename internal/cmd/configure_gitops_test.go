@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGitopsName(t *testing.T) {
+	t.Setenv("ARGOCD_APP_NAME", "")
+	assert.Equal(t, "flashpipe-configuration", gitopsName(""))
+	assert.Equal(t, "flashpipe-sales-prd", gitopsName("Sales_PRD"))
+
+	t.Setenv("ARGOCD_APP_NAME", "cpi-prod")
+	assert.Equal(t, "flashpipe-cpi-prod", gitopsName(""))
+}
+
+func TestGitopsManifest(t *testing.T) {
+	data, err := gitopsManifest("flashpipe-sales", "tenant.hana.ondemand.com", &ConfigureVerifyResult{ArtifactsChecked: 2, ParametersChecked: 5})
+	require.NoError(t, err)
+	var m gitopsConfigMap
+	require.NoError(t, yaml.Unmarshal(data, &m))
+	assert.Equal(t, "ConfigMap", m.Kind)
+	assert.Equal(t, "flashpipe-sales", m.Metadata.Name)
+	assert.Equal(t, "Healthy", m.Metadata.Annotations[gitopsHealthAnnotation])
+	assert.Equal(t, map[string]string{"artifactsChecked": "2", "parametersChecked": "5"}, m.Data)
+
+	data, err = gitopsManifest("flashpipe-sales", "tenant.hana.ondemand.com", &ConfigureVerifyResult{ArtifactsChecked: 2, ParametersChecked: 5, Deviations: []ConfigureDeviation{
+		{Kind: DeviationValueMismatch, ArtifactID: "DEV_Orders", Key: "Password", Expected: "secret", Actual: "old-secret"},
+		{Kind: DeviationNotStarted, ArtifactID: "DEV_Orders", Expected: "STARTED", Actual: "ERROR"},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &m))
+	assert.Equal(t, "Degraded", m.Metadata.Annotations[gitopsHealthAnnotation])
+	assert.Equal(t, "2 deviation(s) between tenant and configuration", m.Metadata.Annotations[gitopsMessageAnnotation])
+	assert.Equal(t, "DEV_Orders/Password: value_mismatch\nDEV_Orders: not_started (ERROR)\n", m.Data["deviations"])
+	assert.NotContains(t, string(data), "secret", "Parameter values are not written")
+}
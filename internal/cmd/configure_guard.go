@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// changeLimits are the maximum changes of a run on a tenant, which protect against a mis-scoped configuration,
+// e.g. with a wrong deployment prefix, changing the configuration of the whole tenant
+type changeLimits struct {
+	parameters  int    // Maximum number of changed parameters, 0 for no limit
+	artifacts   int    // Maximum number of artifacts with changed parameters, 0 for no limit
+	prefixCheck string // Handling of configured artifacts not found on the tenant, see checkPrefix
+}
+
+func newChangeLimits(cmd *cobra.Command) (changeLimits, error) {
+	limits := changeLimits{
+		parameters:  config.GetIntWithFallback(cmd, "max-changes", "configure.maxChanges"),
+		artifacts:   config.GetIntWithFallback(cmd, "max-changed-artifacts", "configure.maxChangedArtifacts"),
+		prefixCheck: config.GetStringWithFallback(cmd, "prefix-check", "configure.prefixCheck"),
+	}
+	if limits.parameters < 0 || limits.artifacts < 0 {
+		return limits, fmt.Errorf("--max-changes and --max-changed-artifacts must not be negative")
+	}
+	return limits, validatePrefixCheck(limits.prefixCheck)
+}
+
+// enabled returns true if a limit of the run or of a package is set
+func (c changeLimits) enabled(packages []models.ConfigurePackage) bool {
+	if c.parameters > 0 || c.artifacts > 0 {
+		return true
+	}
+	for _, pkg := range packages {
+		if pkg.MaxChanges > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// plannedChanges are the changes a run would apply, as counted by planChanges
+type plannedChanges struct {
+	parameters int
+	artifacts  int
+	packages   []int // Changed parameters of each package
+}
+
+// planChanges counts the parameters of the packages that would be changed, compared to the current
+// configuration of the artifacts. Parameters of artifacts whose configuration cannot be read, parameters not
+// found in the artifacts and parameters with the delete mode are counted as changed.
+func planChanges(s packageSettings, packages []models.ConfigurePackage) *plannedChanges {
+	plan := &plannedChanges{packages: make([]int, len(packages))}
+	for i, pkg := range packages {
+		for _, artifact := range pkg.Artifacts {
+			if len(s.artifactFilter) > 0 && !shouldInclude(artifact.ID, s.artifactFilter) {
+				continue
+			}
+			changed := len(artifact.Parameters)
+			if current, err := s.configs.get(s.deploymentPrefix+artifact.ID, artifact.Version); err == nil {
+				changed = changedParameters(artifact.Parameters, current.Root.Results)
+			}
+			if changed > 0 {
+				plan.artifacts++
+				plan.parameters += changed
+				plan.packages[i] += changed
+			}
+		}
+	}
+	return plan
+}
+
+// changedParameters returns the number of parameters that would be changed on the current configuration
+func changedParameters(parameters []models.ConfigurationParameter, current []*api.ParameterData) int {
+	expanded, _ := expandParameterKeys(parameters, current)
+	changed := 0
+	for _, param := range expanded {
+		existing := api.FindParameterByKey(param.Key, current)
+		if existing == nil || param.Mode == flashpipe.ParameterModeDelete || !flashpipe.ParameterSatisfied(param, existing.ParameterValue) {
+			changed++
+		}
+	}
+	return changed
+}
+
+// checkChangeLimits returns an error before anything is applied if the run would change more parameters or
+// artifacts than the limits of the run or of a package allow
+func checkChangeLimits(s packageSettings, packages []models.ConfigurePackage, limits changeLimits) error {
+	if !limits.enabled(packages) {
+		return nil
+	}
+	plan := planChanges(s, packages)
+	log.Info().Msgf("Change guard: %d parameter(s) of %d artifact(s) would be changed", plan.parameters, plan.artifacts)
+
+	var exceeded []string
+	if limits.parameters > 0 && plan.parameters > limits.parameters {
+		exceeded = append(exceeded, fmt.Sprintf("%d parameter(s) exceed --max-changes %d", plan.parameters, limits.parameters))
+	}
+	if limits.artifacts > 0 && plan.artifacts > limits.artifacts {
+		exceeded = append(exceeded, fmt.Sprintf("%d artifact(s) exceed --max-changed-artifacts %d", plan.artifacts, limits.artifacts))
+	}
+	for i, pkg := range packages {
+		if pkg.MaxChanges > 0 && plan.packages[i] > pkg.MaxChanges {
+			exceeded = append(exceeded, fmt.Sprintf("%d parameter(s) of package %s exceed its maxChanges %d",
+				plan.packages[i], s.deploymentPrefix+pkg.ID, pkg.MaxChanges))
+		}
+	}
+	if len(exceeded) > 0 {
+		return fmt.Errorf("change guard: %s, nothing applied (check the scope of the configuration, e.g. the deployment prefix, or raise the limit)",
+			strings.Join(exceeded, ", "))
+	}
+	return nil
+}
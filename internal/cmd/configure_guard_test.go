@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckChangeLimitsMock(t *testing.T) {
+	var methods []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_Orders',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "prod-host" }, { "ParameterKey": "Port", "ParameterValue": "443" },
+			{ "ParameterKey": "Receiver_A_Timeout", "ParameterValue": "30" }, { "ParameterKey": "Receiver_B_Timeout", "ParameterValue": "60" } ] } }`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	settings := packageSettings{exe: exe, configs: newConfigurationReader(api.NewConfiguration(exe)), deploymentPrefix: "DEV_"}
+	packages := []models.ConfigurePackage{
+		{ID: "Orders", MaxChanges: 3, Artifacts: []models.ConfigureArtifact{{ID: "Orders", Type: "Integration", Version: "active",
+			Parameters: []models.ConfigurationParameter{
+				{Key: "Host", Value: "prod-host"},
+				{Key: "Port", Value: "8443"},
+				{Key: "Receiver_*_Timeout", Value: "60"},
+			}}}},
+		{ID: "Billing", Artifacts: []models.ConfigureArtifact{{ID: "Billing", Type: "Integration", Version: "active",
+			Parameters: []models.ConfigurationParameter{{Key: "Host", Value: "billing-host"}, {Key: "Port", Value: "443"}}}}},
+	}
+
+	plan := planChanges(settings, packages)
+	assert.Equal(t, &plannedChanges{parameters: 4, artifacts: 2, packages: []int{2, 2}}, plan,
+		"Unchanged parameters should not be counted, parameters of unreadable artifacts should be")
+
+	assert.NoError(t, checkChangeLimits(settings, packages, changeLimits{parameters: 4, artifacts: 2}))
+	assert.NoError(t, checkChangeLimits(settings, packages, changeLimits{}), "The limit of the package should not be exceeded")
+	err := checkChangeLimits(settings, packages, changeLimits{parameters: 3, artifacts: 1})
+	assert.EqualError(t, err, "change guard: 4 parameter(s) exceed --max-changes 3, 2 artifact(s) exceed --max-changed-artifacts 1, "+
+		"nothing applied (check the scope of the configuration, e.g. the deployment prefix, or raise the limit)")
+
+	packages[0].MaxChanges = 1
+	err = checkChangeLimits(settings, packages, changeLimits{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 parameter(s) of package DEV_Orders exceed its maxChanges 1")
+
+	// Nothing is applied if a limit is exceeded
+	cfg := &models.ConfigureConfig{DeploymentPrefix: "DEV_", Packages: packages}
+	_, err = configureAllArtifacts(exe, cfg, nil, nil, &ConfigureStats{}, false, false, 0, true, false, false, false,
+		"", "", 1, 0, pacingPolicy{}, changeLimits{})
+	require.Error(t, err)
+	assert.NotContains(t, methods, http.MethodPut)
+}
+
+func TestPrefixCandidate(t *testing.T) {
+	ids := []string{"Orders", "DEV_Invoices", "DEV-Orders"}
+	assert.Equal(t, "DEV-Orders", prefixCandidate("DEV_Orders", "DEV_", ids))
+	assert.Equal(t, "", prefixCandidate("DEV_Mapping", "DEV_", ids))
+	assert.Equal(t, "DEV_Invoices", prefixCandidate("Invoices", "", ids), "A missing prefix should be detected")
+	assert.Equal(t, "", prefixCandidate("DEV-Orders", "DEV-", []string{"Orders"}), "The artifact without prefix is no candidate")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Lifecycle phases at which hooks are executed
+const (
+	HookPreConfigure  = "preConfigure"
+	HookPostConfigure = "postConfigure"
+	HookPreDeploy     = "preDeploy"
+	HookPostDeploy    = "postDeploy"
+)
+
+// HookContext is passed to hook commands as JSON on stdin and as FLASHPIPE_HOOK_* environment variables
+type HookContext struct {
+	Phase        string `json:"phase"`
+	Scope        string `json:"scope"` // run, package or artifact
+	PackageID    string `json:"packageId,omitempty"`
+	ArtifactID   string `json:"artifactId,omitempty"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	DryRun       bool   `json:"dryRun"`
+	Error        string `json:"error,omitempty"` // Error of the phase, only for post hooks
+
+	logger *zerolog.Logger // Logger of the package being configured, the global logger if nil
+}
+
+// runHooks executes the commands of the hook phase in order. The first failing command stops
+// execution and its error is returned. In dry run mode, commands are only logged.
+func runHooks(hooks *models.ConfigureHooks, hookCtx HookContext) error {
+	commands := hooks.Commands(hookCtx.Phase)
+	if len(commands) == 0 {
+		return nil
+	}
+	l := hookCtx.log()
+	input, err := json.Marshal(hookCtx)
+	if err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		if hookCtx.DryRun {
+			l.Info().Msgf("      [DRY RUN] Would run %v %v hook: %v", hookCtx.Scope, hookCtx.Phase, command)
+			continue
+		}
+		l.Info().Msgf("      🪝 Running %v %v hook: %v", hookCtx.Scope, hookCtx.Phase, command)
+
+		var c *exec.Cmd
+		if runtime.GOOS == "windows" {
+			c = exec.Command("cmd", "/C", command)
+		} else {
+			c = exec.Command("sh", "-c", command)
+		}
+		c.Stdin = bytes.NewReader(input)
+		c.Env = append(os.Environ(), hookEnv(hookCtx)...)
+		output, err := c.CombinedOutput()
+		for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+			if line != "" {
+				l.Info().Msgf("        %v", line)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%v %v hook %q failed: %w", hookCtx.Scope, hookCtx.Phase, command, err)
+		}
+	}
+	return nil
+}
+
+func (c HookContext) log() *zerolog.Logger {
+	if c.logger == nil {
+		return &log.Logger
+	}
+	return c.logger
+}
+
+// withPhase returns a copy of the context for the given phase and outcome of the phase
+func (c HookContext) withPhase(phase string, err error) HookContext {
+	c.Phase = phase
+	c.Error = errorString(err)
+	return c
+}
+
+func hookEnv(hookCtx HookContext) []string {
+	return []string{
+		"FLASHPIPE_HOOK_PHASE=" + hookCtx.Phase,
+		"FLASHPIPE_HOOK_SCOPE=" + hookCtx.Scope,
+		"FLASHPIPE_HOOK_PACKAGE_ID=" + hookCtx.PackageID,
+		"FLASHPIPE_HOOK_ARTIFACT_ID=" + hookCtx.ArtifactID,
+		"FLASHPIPE_HOOK_ARTIFACT_TYPE=" + hookCtx.ArtifactType,
+		"FLASHPIPE_HOOK_DRY_RUN=" + strconv.FormatBool(hookCtx.DryRun),
+		"FLASHPIPE_HOOK_ERROR=" + hookCtx.Error,
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// deploymentHooks looks up package and artifact hooks by their prefixed IDs
+type deploymentHooks struct {
+	packages  map[string]*models.ConfigureHooks
+	artifacts map[string]*models.ConfigureHooks
+}
+
+func newDeploymentHooks(cfg *models.ConfigureConfig) *deploymentHooks {
+	h := &deploymentHooks{
+		packages:  map[string]*models.ConfigureHooks{},
+		artifacts: map[string]*models.ConfigureHooks{},
+	}
+	for _, pkg := range cfg.Packages {
+		h.packages[cfg.DeploymentPrefix+pkg.ID] = pkg.Hooks
+		for _, artifact := range pkg.Artifacts {
+			h.artifacts[cfg.DeploymentPrefix+artifact.ID] = artifact.Hooks
+		}
+	}
+	return h
+}
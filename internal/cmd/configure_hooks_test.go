@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Hook commands in test use sh")
+	}
+	outFile := filepath.Join(t.TempDir(), "hook.out")
+	hooks := &models.ConfigureHooks{
+		PreDeploy: []string{
+			`echo "$FLASHPIPE_HOOK_PHASE $FLASHPIPE_HOOK_ARTIFACT_ID" > ` + outFile,
+			`cat >> ` + outFile,
+		},
+		PostDeploy: []string{"exit 3", "echo never >> " + outFile},
+	}
+	hookCtx := HookContext{Scope: "artifact", PackageID: "DEV_Package", ArtifactID: "DEV_Flow"}
+
+	require.NoError(t, runHooks(hooks, hookCtx.withPhase(HookPreDeploy, nil)))
+	content, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "preDeploy DEV_Flow\n"+`{"phase":"preDeploy","scope":"artifact","packageId":"DEV_Package","artifactId":"DEV_Flow","dryRun":false}`, string(content))
+
+	err = runHooks(hooks, hookCtx.withPhase(HookPostDeploy, nil))
+	assert.Error(t, err, "Failing hook command should return an error")
+	content, _ = os.ReadFile(outFile)
+	assert.NotContains(t, string(content), "never", "Commands after a failing hook command should not run")
+
+	assert.NoError(t, runHooks(nil, hookCtx.withPhase(HookPreConfigure, nil)), "No hooks should not fail")
+}
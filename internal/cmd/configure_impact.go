@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Reasons a deployed artifact is restarted by the deployment phase
+const (
+	RestartDeploy  = "deploy"  // Deployed as requested by the deploy flag of the artifact or its package
+	RestartCascade = "cascade" // Redeployed by --cascade-redeploy as it references an artifact deployed in the run
+)
+
+// pollingSenderPattern matches the adapter types of sender channels in integration flow models
+var pollingSenderPattern = regexp.MustCompile(`ctype::AdapterVariant/cname::[^/<]*/tp::([^/<]+)/mp::[^/<]*/direction::Sender`)
+
+// pollingAdapters are the sender adapters that poll or consume messages, whose processing can be interrupted by a
+// restart. JMS senders are reported with their queues instead.
+var pollingAdapters = []string{"SFTP", "FTP", "Mail", "AMQP", "Kafka", "SuccessFactors", "Ariba", "OData", "JDBC"}
+
+// timerStartPattern matches the timer start events of integration flow models
+var timerStartPattern = regexp.MustCompile(`cname::intermediatetimer/`)
+
+// RestartImpact is a deployed artifact that is restarted by the deployment phase
+type RestartImpact struct {
+	ArtifactID     string   `json:"artifactId"`
+	ArtifactType   string   `json:"artifactType"`
+	PackageID      string   `json:"packageId"`
+	Reason         string   `json:"reason"`
+	TriggeredBy    []string `json:"triggeredBy,omitempty"`
+	RuntimeVersion string   `json:"runtimeVersion"`
+	// Consumers are the JMS queues, polling adapters and timers whose message processing can be interrupted
+	Consumers []string `json:"consumers,omitempty"`
+}
+
+// DeploymentImpact is the outcome of the impact analysis of a deployment phase
+type DeploymentImpact struct {
+	Restarts       []RestartImpact `json:"restarts"`
+	NewDeployments []string        `json:"newDeployments"`
+	Unchanged      []string        `json:"unchanged"`
+}
+
+// interrupting returns the number of restarted artifacts whose message processing can be interrupted
+func (i *DeploymentImpact) interrupting() int {
+	n := 0
+	for _, r := range i.Restarts {
+		if len(r.Consumers) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// impactGate analyses the impact of the deployment phase, and requires confirmation if it restarts more deployed
+// artifacts than the threshold
+type impactGate struct {
+	whatIf    bool
+	threshold int // 0 for no confirmation
+	in        io.Reader
+	out       io.Writer
+	// Confirmation requires an interactive terminal
+	interactive bool
+	// Confirmations are requested one at a time so that prompts of parallel tenants do not interleave
+	mu sync.Mutex
+}
+
+// addImpactFlags adds the flags of the impact analysis to a command that deploys artifacts
+func addImpactFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("what-if", false, "Show the deployed artifacts that would be restarted and the message processing that could be interrupted, without making changes (config: configure.whatIf)")
+	cmd.Flags().Int("impact-threshold", 0, "Require confirmation on an interactive terminal before restarting more than this number of deployed artifacts, 0 for no confirmation (config: configure.impactThreshold)")
+}
+
+// newImpactGate returns the impact analysis configured for the command, or nil if not requested
+func newImpactGate(cmd *cobra.Command) (*impactGate, error) {
+	g := &impactGate{
+		whatIf:    config.GetBoolWithFallback(cmd, "what-if", "configure.whatIf"),
+		threshold: config.GetIntWithFallback(cmd, "impact-threshold", "configure.impactThreshold"),
+		in:        os.Stdin,
+		out:       os.Stderr,
+	}
+	if g.threshold < 0 {
+		return nil, fmt.Errorf("--impact-threshold must not be negative")
+	}
+	if !g.whatIf && g.threshold == 0 {
+		return nil, nil
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		g.interactive = true
+	}
+	return g, nil
+}
+
+// enabled returns true if the run only shows the impact of the deployment phase
+func (g *impactGate) enabled() bool {
+	return g != nil && g.whatIf
+}
+
+// check analyses and logs the impact of deploying the tasks, and returns an error if it is above the threshold
+// and not confirmed
+func (g *impactGate) check(exe *httpclnt.HTTPExecuter, tasks []DeploymentTask, dependents *cascade) error {
+	if g == nil {
+		return nil
+	}
+	impact, err := analyzeImpact(exe, tasks, dependents)
+	if err != nil {
+		return fmt.Errorf("impact analysis failed: %w", err)
+	}
+	logImpact(impact)
+	if g.threshold == 0 || len(impact.Restarts) <= g.threshold {
+		return nil
+	}
+	if !g.interactive {
+		return fmt.Errorf("%d deployed artifact(s) would be restarted on %v, above --impact-threshold %d, confirmation requires an interactive terminal",
+			len(impact.Restarts), exe.Host(), g.threshold)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.confirm(exe.Host(), impact)
+}
+
+func (g *impactGate) confirm(tenant string, impact *DeploymentImpact) error {
+	fmt.Fprintf(g.out, "\nThe deployment restarts %d deployed artifact(s) on %v, above the threshold of %d", len(impact.Restarts), tenant, g.threshold)
+	if n := impact.interrupting(); n > 0 {
+		fmt.Fprintf(g.out, ", message processing of %d can be interrupted", n)
+	}
+	fmt.Fprint(g.out, ".\nProceed with deployment? [y/N]: ")
+
+	answer, err := bufio.NewReader(g.in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("deployment of %d restart(s) rejected at prompt", len(impact.Restarts))
+	}
+}
+
+// showImpact analyses and logs the impact of deploying the tasks of a dry run
+func showImpact(exe *httpclnt.HTTPExecuter, tasks []DeploymentTask, cascadeRedeploy bool) error {
+	dependents := &cascade{}
+	if cascadeRedeploy {
+		var err error
+		if dependents, err = findDependentFlows(exe, tasks); err != nil {
+			return fmt.Errorf("failed to find integration flows referencing the deployed artifacts: %w", err)
+		}
+	}
+	impact, err := analyzeImpact(exe, tasks, dependents)
+	if err != nil {
+		return err
+	}
+	logImpact(impact)
+	return nil
+}
+
+// analyzeImpact looks up which of the artifacts of the tasks, and of the flows redeployed by cascade, are
+// deployed and would be restarted, and the message consumers of the restarted integration flows
+func analyzeImpact(exe *httpclnt.HTTPExecuter, tasks []DeploymentTask, dependents *cascade) (*DeploymentImpact, error) {
+	impact := &DeploymentImpact{}
+	rt := api.NewRuntime(exe)
+	var deployed []string
+	for _, t := range tasks {
+		version, _, err := rt.Get(t.ArtifactID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case version == "NOT_DEPLOYED":
+			impact.NewDeployments = append(impact.NewDeployments, t.ArtifactID)
+			deployed = append(deployed, t.ArtifactID)
+			continue
+		case t.SkipIfDeployed && deployedUpToDate(exe, t):
+			impact.Unchanged = append(impact.Unchanged, t.ArtifactID)
+			continue
+		}
+		deployed = append(deployed, t.ArtifactID)
+		restart, err := restartImpact(exe, t, RestartDeploy, version)
+		if err != nil {
+			return nil, err
+		}
+		impact.Restarts = append(impact.Restarts, *restart)
+	}
+
+	for _, t := range dependents.triggered(deployed) {
+		if slices.Contains(deployed, t.ArtifactID) {
+			continue
+		}
+		version, _, err := rt.Get(t.ArtifactID)
+		if err != nil {
+			return nil, err
+		}
+		restart, err := restartImpact(exe, t, RestartCascade, version)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range dependents.triggers[t.ArtifactID] {
+			if slices.Contains(deployed, id) {
+				restart.TriggeredBy = append(restart.TriggeredBy, id)
+			}
+		}
+		impact.Restarts = append(impact.Restarts, *restart)
+	}
+	return impact, nil
+}
+
+func restartImpact(exe *httpclnt.HTTPExecuter, t DeploymentTask, reason, version string) (*RestartImpact, error) {
+	restart := &RestartImpact{ArtifactID: t.ArtifactID, ArtifactType: t.ArtifactType, PackageID: t.PackageID,
+		Reason: reason, RuntimeVersion: version}
+	if t.ArtifactType != "Integration" {
+		return restart, nil
+	}
+	dt := api.NewIntegration(exe)
+	_, _, exists, err := dt.Get(t.ArtifactID, "active")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		log.Warn().Msgf("⚠️  Integration designtime artifact %s not found, its message consumers are not checked", t.ArtifactID)
+		return restart, nil
+	}
+
+	workDir, err := os.MkdirTemp("", "flashpipe-impact-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+	zipFile := filepath.Join(workDir, t.ArtifactID+".zip")
+	if err := dt.Download(zipFile, t.ArtifactID); err != nil {
+		return nil, err
+	}
+	if restart.Consumers, err = messageConsumers(zipFile); err != nil {
+		return nil, fmt.Errorf("failed to read content of %s: %w", t.ArtifactID, err)
+	}
+	return restart, nil
+}
+
+// messageConsumers returns the JMS queues, polling sender adapters and timers of the integration flow models of
+// the content
+func messageConsumers(zipFile string) ([]string, error) {
+	r, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var consumers []string
+	add := func(consumer string) {
+		if !slices.Contains(consumers, consumer) {
+			consumers = append(consumers, consumer)
+		}
+	}
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".iflw") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		model, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range jmsSenderQueuePattern.FindAllStringSubmatch(string(model), -1) {
+			if name := strings.TrimSpace(match[1]); name != "" {
+				add("JMS queue " + name)
+			}
+		}
+		for _, match := range pollingSenderPattern.FindAllStringSubmatch(string(model), -1) {
+			if slices.Contains(pollingAdapters, match[1]) {
+				add(match[1] + " sender")
+			}
+		}
+		if timerStartPattern.Match(model) {
+			add("Timer")
+		}
+	}
+	return consumers, nil
+}
+
+func logImpact(impact *DeploymentImpact) {
+	logger.Banner(logger.SeparatorDouble, "DEPLOYMENT IMPACT")
+	log.Info().Msgf("Deployed artifacts restarted: %d", len(impact.Restarts))
+	for _, r := range impact.Restarts {
+		reason := "deploy flag"
+		if r.Reason == RestartCascade {
+			reason = "references " + strings.Join(r.TriggeredBy, ", ")
+		}
+		log.Info().Msgf("  🔄 %s (%s, package %s, runtime version %s): %s", r.ArtifactID, r.ArtifactType, r.PackageID, r.RuntimeVersion, reason)
+		if len(r.Consumers) > 0 {
+			log.Warn().Msgf("     ⚠️  Message processing can be interrupted: %s", strings.Join(r.Consumers, ", "))
+		}
+	}
+	if len(impact.NewDeployments) > 0 {
+		log.Info().Msgf("New deployments:              %d (%s)", len(impact.NewDeployments), strings.Join(impact.NewDeployments, ", "))
+	}
+	if len(impact.Unchanged) > 0 {
+		log.Info().Msgf("Already deployed, skipped:    %d (%s)", len(impact.Unchanged), strings.Join(impact.Unchanged, ", "))
+	}
+	if n := impact.interrupting(); n > 0 {
+		log.Warn().Msgf("Message processing can be interrupted in %d restarted integration flow(s)", n)
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const impactModel = `<bpmn2:messageFlow>
+  <ifl:property><key>cmdVariantUri</key><value>ctype::AdapterVariant/cname::sap:JMS/tp::JMS/mp::Not Applicable/direction::Sender/version::1.1.0</value></ifl:property>
+  <ifl:property><key>QueueName_inbound</key><value>Orders</value></ifl:property>
+</bpmn2:messageFlow>
+<bpmn2:messageFlow>
+  <ifl:property><key>cmdVariantUri</key><value>ctype::AdapterVariant/cname::sap:SFTP/tp::SFTP/mp::None/direction::Sender/version::1.11.0</value></ifl:property>
+</bpmn2:messageFlow>
+<bpmn2:messageFlow>
+  <ifl:property><key>cmdVariantUri</key><value>ctype::AdapterVariant/cname::sap:HTTPS/tp::HTTPS/mp::None/direction::Sender/version::1.4.3</value></ifl:property>
+</bpmn2:messageFlow>
+<bpmn2:messageFlow>
+  <ifl:property><key>cmdVariantUri</key><value>ctype::AdapterVariant/cname::sap:SFTP/tp::SFTP/mp::None/direction::Receiver/version::1.11.0</value></ifl:property>
+</bpmn2:messageFlow>
+<bpmn2:startEvent>
+  <ifl:property><key>cmdVariantUri</key><value>ctype::FlowstepVariant/cname::intermediatetimer/version::1.0.5</value></ifl:property>
+</bpmn2:startEvent>`
+
+func impactContent(t *testing.T, model string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("src/main/resources/scenarioflows/integrationflow/Flow.iflw")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(model))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestMessageConsumers(t *testing.T) {
+	zipFile := filepath.Join(t.TempDir(), "Flow.zip")
+	require.NoError(t, os.WriteFile(zipFile, impactContent(t, impactModel), 0644))
+
+	consumers, err := messageConsumers(zipFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"JMS queue Orders", "SFTP sender", "Timer"}, consumers,
+		"HTTPS senders and receiver channels should not be consumers")
+}
+
+func TestAnalyzeImpactMock(t *testing.T) {
+	runtime := map[string]string{"OrderFlow": "1.0.0", "Mappings": "1.0.2", "StatusFlow": "1.0.0"}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v1/IntegrationRuntimeArtifacts('"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/IntegrationRuntimeArtifacts('"), "')")
+			version, ok := runtime[id]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{ "d": { "Id": "` + id + `", "Version": "` + version + `", "Status": "STARTED" } }`))
+		case strings.HasSuffix(r.URL.Path, "/$value"):
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write(impactContent(t, impactModel))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/IntegrationDesigntimeArtifacts(Id='StatusFlow'"):
+			w.Write([]byte(`{ "d": { "Id": "StatusFlow", "Version": "1.0.0" } }`))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/IntegrationDesigntimeArtifacts(Id='OrderFlow'"):
+			w.Write([]byte(`{ "d": { "Id": "OrderFlow", "Version": "1.0.1" } }`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	tasks := []DeploymentTask{
+		{ArtifactID: "Mappings", ArtifactType: "ValueMapping", PackageID: "Common"},
+		{ArtifactID: "Scripts", ArtifactType: "ScriptCollection", PackageID: "Common"},
+		{ArtifactID: "StatusFlow", ArtifactType: "Integration", PackageID: "Orders", SkipIfDeployed: true},
+	}
+	dependents := &cascade{
+		tasks:    []DeploymentTask{{ArtifactID: "OrderFlow", ArtifactType: "Integration", PackageID: "Common"}},
+		triggers: map[string][]string{"OrderFlow": {"Scripts", "Country_Codes"}},
+	}
+
+	impact, err := analyzeImpact(exe, tasks, dependents)
+	require.NoError(t, err)
+	assert.Equal(t, []RestartImpact{
+		{ArtifactID: "Mappings", ArtifactType: "ValueMapping", PackageID: "Common", Reason: RestartDeploy, RuntimeVersion: "1.0.2"},
+		{ArtifactID: "OrderFlow", ArtifactType: "Integration", PackageID: "Common", Reason: RestartCascade, TriggeredBy: []string{"Scripts"},
+			RuntimeVersion: "1.0.0", Consumers: []string{"JMS queue Orders", "SFTP sender", "Timer"}},
+	}, impact.Restarts)
+	assert.Equal(t, []string{"Scripts"}, impact.NewDeployments)
+	assert.Equal(t, []string{"StatusFlow"}, impact.Unchanged, "Flows running their designtime version without changes should be skipped")
+	assert.Equal(t, 1, impact.interrupting())
+}
+
+func TestImpactGateCheck(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "Id": "Mappings", "Version": "1.0.0", "Status": "STARTED" } }`))
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	tasks := []DeploymentTask{{ArtifactID: "Mappings", ArtifactType: "ValueMapping"}, {ArtifactID: "Codes", ArtifactType: "ValueMapping"}}
+
+	var nilGate *impactGate
+	assert.NoError(t, nilGate.check(exe, tasks, &cascade{}), "No gate should allow the deployment")
+
+	below := &impactGate{threshold: 2}
+	assert.NoError(t, below.check(exe, tasks, &cascade{}), "Restarts up to the threshold should not require confirmation")
+
+	unattended := &impactGate{threshold: 1}
+	assert.ErrorContains(t, unattended.check(exe, tasks, &cascade{}), "above --impact-threshold 1")
+
+	var out bytes.Buffer
+	confirmed := &impactGate{threshold: 1, interactive: true, in: strings.NewReader("y\n"), out: &out}
+	assert.NoError(t, confirmed.check(exe, tasks, &cascade{}))
+	assert.Contains(t, out.String(), "restarts 2 deployed artifact(s)")
+
+	rejected := &impactGate{threshold: 1, interactive: true, in: strings.NewReader("\n"), out: &out}
+	assert.ErrorContains(t, rejected.check(exe, tasks, &cascade{}), "rejected at prompt")
+}
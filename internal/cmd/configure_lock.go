@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// lockRetryDelay is the wait before the first retry of an artifact locked by another user, it is doubled
+// for each further retry
+var lockRetryDelay = 30 * time.Second
+
+// updateParameters updates the parameters of an artifact. If the artifact is locked by another user, e.g.
+// opened in edit mode in the Web UI, the update is retried up to s.lockRetries times with backoff before it
+// fails with a deploy.Error of category deploy.ErrorCategoryLocked.
+func updateParameters(s packageSettings, artifactID, version string, parameters []models.ConfigurationParameter,
+	useBatch bool, batchSize int, fallback string, stats *ConfigureStats, l *zerolog.Logger) error {
+
+	delay := lockRetryDelay
+	for attempt := 0; ; attempt++ {
+		// Only the last attempt counts, the statistics of an attempt are added when it is done
+		attemptStats := &ConfigureStats{}
+		var err error
+		if useBatch && len(parameters) > 0 {
+			err = updateParametersBatch(s.exe, s.configs, artifactID, version, parameters, batchSize, !s.disableChangeset, fallback, attemptStats, l)
+		} else {
+			err = updateParametersIndividual(s.configs.configuration, artifactID, version, parameters, attemptStats, l)
+		}
+		if !errors.Is(err, api.ErrLocked) {
+			stats.Merge(attemptStats)
+			return err
+		}
+		if attempt >= s.lockRetries {
+			stats.Merge(attemptStats)
+			return &deploy.Error{Category: deploy.ErrorCategoryLocked, Message: err.Error(),
+				Hint: "Close the artifact in the editor of the other user, or retry with --lock-retry"}
+		}
+		l.Warn().Msgf("      🔒 Artifact locked by another user, retrying in %v (%d/%d)", delay, attempt+1, s.lockRetries)
+		stats.AddWarning("Artifact %s locked by another user, retried (%d/%d)", artifactID, attempt+1, s.lockRetries)
+		s.configs.forget(artifactID, version)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isLocked returns true if err is the error of an artifact locked by another user
+func isLocked(err error) bool {
+	var deployErr *deploy.Error
+	return errors.As(err, &deployErr) && deployErr.Category == deploy.ErrorCategoryLocked
+}
+
+// printLockedArtifacts lists the artifacts skipped as they were locked by another user in the summary
+func printLockedArtifacts(stats *ConfigureStats) {
+	if stats.ArtifactsLocked.Value() == 0 {
+		return
+	}
+	log.Info().Msg("")
+	log.Warn().Msg("Artifacts skipped (locked by another user):")
+	for _, result := range stats.Artifacts {
+		if result.Category == deploy.ErrorCategoryLocked {
+			log.Warn().Msgf("  %s (package %s)", result.ArtifactID, result.PackageID)
+		}
+	}
+}
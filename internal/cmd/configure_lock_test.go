@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateParametersLockRetryMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, the artifact is locked for the first two updates
+	updates := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$links/Configurations('Host')":
+			updates++
+			if updates <= 2 {
+				w.WriteHeader(http.StatusLocked)
+				w.Write([]byte(`{ "error": { "message": { "value": "Artifact Flow is locked by user S0001" } } }`))
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	lockRetryDelay = 0
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	params := []models.ConfigurationParameter{{Key: "Host", Value: "prod-host"}}
+	s := packageSettings{exe: exe, configs: newConfigurationReader(api.NewConfiguration(exe)), lockRetries: 1}
+
+	stats := &ConfigureStats{}
+	err := updateParameters(s, "Flow", "active", params, false, 0, flashpipe.BatchFallbackAll, stats, &log.Logger)
+	require.Error(t, err, "Artifact locked after all retries should be an error")
+	assert.True(t, isLocked(err), "Error should be categorized as locked")
+	assert.Equal(t, 2, updates, "Update should be retried once")
+	assert.Equal(t, 1, stats.ParametersFailed.Value(), "Only the last attempt should be counted")
+	assert.Equal(t, []string{"Artifact Flow locked by another user, retried (1/1)"}, stats.Warnings, "Lock retry should be a warning")
+
+	stats = &ConfigureStats{}
+	err = updateParameters(s, "Flow", "active", params, false, 0, flashpipe.BatchFallbackAll, stats, &log.Logger)
+	require.NoError(t, err, "Update should succeed once the artifact is unlocked")
+	assert.Equal(t, 1, stats.ParametersUpdated.Value())
+	assert.False(t, isLocked(nil))
+}
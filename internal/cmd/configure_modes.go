@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/magiconair/properties"
+	"github.com/rs/zerolog"
+)
+
+// validateParameterModes returns an error for the first parameter with an unknown update mode
+func validateParameterModes(cfg *models.ConfigureConfig) error {
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			for _, param := range artifact.Parameters {
+				if !slices.Contains(flashpipe.ParameterModes, param.Mode) {
+					return fmt.Errorf("invalid mode %s of parameter %s of artifact %s (valid modes: %s, %s, %s, %s)",
+						param.Mode, param.Key, artifact.ID, flashpipe.ParameterModeSet, flashpipe.ParameterModeSetIfEmpty,
+						flashpipe.ParameterModeAppend, flashpipe.ParameterModeDelete)
+				}
+			}
+		}
+	}
+	for _, target := range cfg.Targets {
+		for _, param := range target.TenantDefaults {
+			if !slices.Contains(flashpipe.ParameterModes, param.Mode) {
+				return fmt.Errorf("invalid mode %s of tenant default %s of target %s (valid modes: %s, %s, %s, %s)",
+					param.Mode, param.Key, target.Name, flashpipe.ParameterModeSet, flashpipe.ParameterModeSetIfEmpty,
+					flashpipe.ParameterModeAppend, flashpipe.ParameterModeDelete)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveParameterModes returns the parameters to update with the values resulting from their update mode.
+// Parameters that keep their current value are left out. The current configuration is only retrieved
+// when a parameter has a mode other than set.
+func resolveParameterModes(exe *httpclnt.HTTPExecuter, configs *configurationReader, artifactID string,
+	version string, parameters []models.ConfigurationParameter, l *zerolog.Logger) ([]models.ConfigurationParameter, error) {
+
+	if !slices.ContainsFunc(parameters, func(p models.ConfigurationParameter) bool {
+		return p.Mode != "" && p.Mode != flashpipe.ParameterModeSet
+	}) {
+		return parameters, nil
+	}
+
+	current, err := configs.get(artifactID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current configuration: %w", err)
+	}
+	var defaults map[string]string
+
+	var resolved []models.ConfigurationParameter
+	for _, param := range parameters {
+		existing := api.FindParameterByKey(param.Key, current.Root.Results)
+		if existing == nil {
+			// Reported as not found when updating
+			resolved = append(resolved, param)
+			continue
+		}
+		if param.Mode == flashpipe.ParameterModeDelete && defaults == nil {
+			if defaults, err = designtimeDefaults(exe, artifactID); err != nil {
+				return nil, fmt.Errorf("failed to get design-time defaults: %w", err)
+			}
+		}
+		value, update := flashpipe.ParameterValue(param, existing.ParameterValue, defaults[param.Key])
+		if !update {
+			l.Info().Msgf("      Parameter %s unchanged (mode %s)", param.Key, param.Mode)
+			continue
+		}
+		param.Value = value
+		resolved = append(resolved, param)
+	}
+	return resolved, nil
+}
+
+// designtimeDefaults returns the parameter values in parameters.prop of the content of the integration flow
+func designtimeDefaults(exe *httpclnt.HTTPExecuter, artifactID string) (map[string]string, error) {
+	workDir, err := os.MkdirTemp("", "flashpipe-defaults-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	zipFile := filepath.Join(workDir, artifactID+".zip")
+	if err := api.NewIntegration(exe).Download(zipFile, artifactID); err != nil {
+		return nil, err
+	}
+	return readParametersProp(zipFile)
+}
+
+// readParametersProp reads src/main/resources/parameters.prop from an artifact archive, an archive
+// without the file has no defaults
+func readParametersProp(zipFile string) (map[string]string, error) {
+	reader, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	f, err := reader.Open("src/main/resources/parameters.prop")
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	props, err := properties.Load(data, properties.UTF8)
+	if err != nil {
+		return nil, err
+	}
+	return props.Map(), nil
+}
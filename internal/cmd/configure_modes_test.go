@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveParameterModesMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "ops-host" }, { "ParameterKey": "Port", "ParameterValue": "" },
+			{ "ParameterKey": "Senders", "ParameterValue": "A,B" }, { "ParameterKey": "Timeout", "ParameterValue": "120" } ] } }`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$value", func(w http.ResponseWriter, r *http.Request) {
+		zw := zip.NewWriter(w)
+		f, _ := zw.Create("src/main/resources/parameters.prop")
+		f.Write([]byte("Timeout=60\n"))
+		zw.Close()
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	params := []models.ConfigurationParameter{
+		{Key: "Host", Value: "new-host", Mode: "set-if-empty"},
+		{Key: "Port", Value: "443", Mode: "set-if-empty"},
+		{Key: "Senders", Value: "B,C", Mode: "append"},
+		{Key: "Timeout", Mode: "delete"},
+		{Key: "Path", Value: "/orders"},
+	}
+	resolved, err := resolveParameterModes(exe, newConfigurationReader(api.NewConfiguration(exe)), "Flow", "active", params, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ConfigurationParameter{
+		{Key: "Port", Value: "443", Mode: "set-if-empty"},
+		{Key: "Senders", Value: "A,B,C", Mode: "append"},
+		{Key: "Timeout", Value: "60", Mode: "delete"},
+		{Key: "Path", Value: "/orders"},
+	}, resolved)
+
+	assert.Error(t, validateParameterModes(&models.ConfigureConfig{Packages: []models.ConfigurePackage{{
+		Artifacts: []models.ConfigureArtifact{{ID: "Flow", Parameters: []models.ConfigurationParameter{{Key: "Host", Mode: "replace"}}}},
+	}}}), "Unknown mode should be an error")
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/source"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/magiconair/properties"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// errOffline is returned by the local configuration for changes, which are never applied offline
+var errOffline = errors.New("configuration parameters cannot be changed offline")
+
+// localConfiguration reads the configuration parameters of integration flows from the parameters.prop and
+// parameters.propdef files of their contents in a source directory, e.g. a Git repository written by sync or
+// snapshot, so that configure can be simulated without a tenant. The version of the artifacts is ignored.
+type localConfiguration struct {
+	deploymentPrefix string
+	artifacts        map[string]string // Directory of each artifact by ID
+}
+
+// newLocalConfiguration indexes the artifacts in dir and its subdirectories by the Bundle-SymbolicName of
+// their manifest
+func newLocalConfiguration(dir string, deploymentPrefix string) (*localConfiguration, error) {
+	c := &localConfiguration{deploymentPrefix: deploymentPrefix, artifacts: map[string]string{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || !deploy.FileExists(filepath.Join(path, "META-INF", "MANIFEST.MF")) {
+			return nil
+		}
+		headers, err := deploy.GetManifestHeaders(filepath.Join(path, "META-INF", "MANIFEST.MF"))
+		if err != nil {
+			return err
+		}
+		id, _, _ := strings.Cut(headers["Bundle-SymbolicName"], ";")
+		if id = strings.TrimSpace(id); id == "" {
+			id = d.Name()
+		}
+		c.artifacts[id] = path
+		return fs.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifacts of source directory %v: %w", dir, err)
+	}
+	log.Info().Msgf("Found %d artifact(s) in source directory %v", len(c.artifacts), dir)
+	return c, nil
+}
+
+// propertyDefinitions is the content of parameters.propdef
+type propertyDefinitions struct {
+	Parameters []struct {
+		Key  string `xml:"key"`
+		Type string `xml:"type"`
+	} `xml:"parameter"`
+}
+
+// Get returns the parameters defined in parameters.propdef with their values of parameters.prop. Without
+// parameters.propdef, the parameters of parameters.prop are returned without data type.
+func (c *localConfiguration) Get(id string, _ string) (*api.ParametersData, error) {
+	dir, found := c.artifacts[id]
+	if !found {
+		dir, found = c.artifacts[strings.TrimPrefix(id, c.deploymentPrefix)]
+	}
+	if !found {
+		return nil, fmt.Errorf("artifact %v not found in source directory", id)
+	}
+	values := map[string]string{}
+	paramsFile := deploy.FindParametersFile(dir)
+	if deploy.FileExists(paramsFile) {
+		props, err := properties.LoadFile(paramsFile, properties.UTF8)
+		if err != nil {
+			return nil, err
+		}
+		values = props.Map()
+	}
+
+	data := new(api.ParametersData)
+	defined := map[string]bool{}
+	propdefFile := strings.TrimSuffix(paramsFile, ".prop") + ".propdef"
+	if content, err := os.ReadFile(propdefFile); err == nil {
+		var definitions propertyDefinitions
+		if err := xml.Unmarshal(content, &definitions); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", propdefFile, err)
+		}
+		for _, def := range definitions.Parameters {
+			defined[def.Key] = true
+			data.Root.Results = append(data.Root.Results, &api.ParameterData{ParameterKey: def.Key, ParameterValue: values[def.Key], DataType: def.Type})
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !defined[key] {
+			data.Root.Results = append(data.Root.Results, &api.ParameterData{ParameterKey: key, ParameterValue: values[key]})
+		}
+	}
+	return data, nil
+}
+
+func (c *localConfiguration) Update(string, string, string, string) error {
+	return errOffline
+}
+
+func (c *localConfiguration) Create(string, string, string, string) error {
+	return errOffline
+}
+
+// parameterChange is a parameter whose value would be changed by configure
+type parameterChange struct {
+	key     string
+	current string
+	value   string
+}
+
+// simulateChanges returns the changes of the parameters of an artifact, compared to its current configuration.
+// Parameters that do not exist are not changes, they are reported by validateParameters.
+func simulateChanges(parameters []models.ConfigurationParameter, current []*api.ParameterData) []parameterChange {
+	expanded, _ := expandParameterKeys(parameters, current)
+	var changes []parameterChange
+	for _, param := range expanded {
+		existing := api.FindParameterByKey(param.Key, current)
+		if existing == nil {
+			continue
+		}
+		// The values of the source are the design-time defaults the delete mode resets to
+		if value, update := flashpipe.ParameterValue(param, existing.ParameterValue, existing.ParameterValue); update && value != existing.ParameterValue {
+			changes = append(changes, parameterChange{key: param.Key, current: existing.ParameterValue, value: value})
+		}
+	}
+	return changes
+}
+
+// runOffline simulates configure against the artifacts of --source-dir instead of a tenant, e.g. in pull request
+// pipelines without tenant credentials: parameters are checked for existence and data type, the changes are
+// shown and the change limits are enforced, for each target if the configuration has targets. Nothing is
+// changed or deployed.
+func runOffline(cmd *cobra.Command, cfg *models.ConfigureConfig, targets []models.ConfigureTarget,
+	packageFilter, artifactFilter []string, limits changeLimits) error {
+	location := config.GetStringWithFallback(cmd, "source-dir", "configure.sourceDir")
+	if location == "" {
+		return fmt.Errorf("--source-dir is required with --offline")
+	}
+	src, err := source.Parse(location, func(string) (*httpclnt.HTTPExecuter, error) {
+		return nil, fmt.Errorf("--source-dir must not be a tenant with --offline")
+	})
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dir, err := src.Packages(nil, nil)
+	if err != nil {
+		return err
+	}
+	local, err := newLocalConfiguration(dir, cfg.DeploymentPrefix)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msg("[OFFLINE] Simulating configuration against the source directory, nothing is changed")
+	var problems []error
+	if len(targets) == 0 {
+		problems = simulateOffline(local, cfg, packageFilter, artifactFilter, limits)
+	}
+	for _, target := range targets {
+		log.Info().Msgf("Simulating target %s", target.Name)
+		for _, problem := range simulateOffline(local, applyTargetOverrides(cfg, target), packageFilter, artifactFilter, limits) {
+			problems = append(problems, fmt.Errorf("tenant %s: %w", target.Name, problem))
+		}
+	}
+
+	for _, problem := range problems {
+		log.Error().Msgf("❌ %v", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("offline simulation found %d problem(s)", len(problems))
+	}
+	log.Info().Msg("✅ Offline simulation found no problems")
+	return nil
+}
+
+// simulateOffline shows the changes of the configuration compared to the artifacts of local and returns the
+// problems found
+func simulateOffline(local *localConfiguration, cfg *models.ConfigureConfig, packageFilter, artifactFilter []string, limits changeLimits) []error {
+	configs := newConfigurationReader(&localConfiguration{deploymentPrefix: cfg.DeploymentPrefix, artifacts: local.artifacts})
+	var packages []models.ConfigurePackage
+	changed, artifacts := 0, 0
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		packages = append(packages, pkg)
+		for _, artifact := range pkg.Artifacts {
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) || len(artifact.Parameters) == 0 {
+				continue
+			}
+			artifactID := cfg.DeploymentPrefix + artifact.ID
+			current, err := configs.get(artifactID, artifact.Version)
+			if err != nil {
+				continue
+			}
+			changes := simulateChanges(artifact.Parameters, current.Root.Results)
+			if len(changes) == 0 {
+				log.Info().Msgf("   %s: up to date", artifactID)
+				continue
+			}
+			log.Info().Msgf("   %s: %d parameter(s) would be changed", artifactID, len(changes))
+			for _, change := range changes {
+				log.Info().Msgf("     ~ %s: %q → %q", change.key, change.current, change.value)
+			}
+			changed += len(changes)
+			artifacts++
+		}
+	}
+	log.Info().Msgf("%d parameter(s) of %d artifact(s) would be changed", changed, artifacts)
+
+	problems := validateParameters(configs, cfg, packageFilter, artifactFilter)
+	if err := checkChangeLimits(packageSettings{configs: configs, deploymentPrefix: cfg.DeploymentPrefix, artifactFilter: artifactFilter}, packages, limits); err != nil {
+		problems = append(problems, err)
+	}
+	return problems
+}
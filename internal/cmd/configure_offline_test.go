@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOfflineArtifact(t *testing.T, dir string, id string, prop string, propdef string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "META-INF"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "main", "resources"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "META-INF", "MANIFEST.MF"),
+		[]byte("Manifest-Version: 1.0\nBundle-SymbolicName: "+id+"; singleton:=true\nBundle-Version: 1.0.2\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main", "resources", "parameters.prop"), []byte(prop), 0o644))
+	if propdef != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main", "resources", "parameters.propdef"), []byte(propdef), 0o644))
+	}
+}
+
+func TestOfflineSimulation(t *testing.T) {
+	dir := t.TempDir()
+	writeOfflineArtifact(t, filepath.Join(dir, "Sales", "Orders"), "Orders", "Host=dev-host\nPort=443\nReceiver_A_Timeout=30\n",
+		`<?xml version="1.0" encoding="UTF-8" standalone="no"?><parameters>
+<parameter><key>Host</key><name>Host</name><type>xsd:string</type></parameter>
+<parameter><key>Port</key><name>Port</name><type>xsd:integer</type></parameter>
+<parameter><key>Receiver_A_Timeout</key><name>Receiver_A_Timeout</name><type>xsd:integer</type></parameter>
+<parameter><key>Password</key><name>Password</name><type>xsd:string</type></parameter>
+</parameters>`)
+	writeOfflineArtifact(t, filepath.Join(dir, "Sales", "Billing"), "Billing", "Host=billing-host\n", "")
+
+	local, err := newLocalConfiguration(dir, "DEV_")
+	require.NoError(t, err)
+	assert.Len(t, local.artifacts, 2)
+
+	current, err := local.Get("DEV_Orders", "active")
+	require.NoError(t, err)
+	require.Len(t, current.Root.Results, 4)
+	assert.Equal(t, "xsd:integer", current.Root.Results[1].DataType)
+	assert.Equal(t, "", current.Root.Results[3].ParameterValue, "Parameters without value should be defined as well")
+	_, err = local.Get("DEV_Unknown", "active")
+	assert.EqualError(t, err, "artifact DEV_Unknown not found in source directory")
+	assert.ErrorIs(t, local.Update("DEV_Orders", "active", "Host", "x"), errOffline)
+
+	changes := simulateChanges([]models.ConfigurationParameter{
+		{Key: "Host", Value: "prod-host"},
+		{Key: "Port", Value: "443"},
+		{Key: "Receiver_*_Timeout", Value: "60"},
+		{Key: "Password", Value: "secret", Mode: flashpipe.ParameterModeSetIfEmpty},
+		{Key: "Missing", Value: "x"},
+	}, current.Root.Results)
+	assert.Equal(t, []parameterChange{{key: "Host", current: "dev-host", value: "prod-host"}, {key: "Receiver_A_Timeout", current: "30", value: "60"},
+		{key: "Password", current: "", value: "secret"}}, changes)
+
+	cfg := &models.ConfigureConfig{DeploymentPrefix: "DEV_", Packages: []models.ConfigurePackage{{ID: "Sales", Artifacts: []models.ConfigureArtifact{
+		{ID: "Orders", Type: "Integration", Version: "active", Parameters: []models.ConfigurationParameter{{Key: "Port", Value: "https"}, {Key: "Missing", Value: "x"}}},
+		{ID: "Billing", Type: "Integration", Version: "active", Parameters: []models.ConfigurationParameter{{Key: "Host", Value: "prod-billing"}}},
+	}}}}
+	problems := validateParameters(newConfigurationReader(local), cfg, nil, nil)
+	require.Len(t, problems, 2)
+	assert.EqualError(t, problems[0], `artifact DEV_Orders, parameter Port: value "https" is not a valid xsd:integer`)
+	assert.EqualError(t, problems[1], "artifact DEV_Orders, parameter Missing: not found")
+}
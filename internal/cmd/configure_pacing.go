@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// pacerPoll is the interval in which waiting packages check whether they may start
+const pacerPoll = 100 * time.Millisecond
+
+// pacingPolicy spaces out the packages of a run, so that large runs do not send a burst of requests that
+// gets the user throttled
+type pacingPolicy struct {
+	packageDelay time.Duration // Pause after a package starts or ends before the next one starts
+	rampUp       time.Duration // Time over which the packages configured in parallel increase from 1 to the maximum
+}
+
+func newPacingPolicy(cmd *cobra.Command) pacingPolicy {
+	return pacingPolicy{
+		packageDelay: time.Duration(config.GetIntWithFallback(cmd, "package-delay", "configure.packageDelaySeconds")) * time.Second,
+		rampUp:       time.Duration(config.GetIntWithFallback(cmd, "ramp-up", "configure.rampUpSeconds")) * time.Second,
+	}
+}
+
+// pacer holds back packages according to a pacing policy. The maximum number of packages configured in
+// parallel is enforced by the caller.
+type pacer struct {
+	policy  pacingPolicy
+	workers int
+	start   time.Time
+	mu      sync.Mutex
+	active  int
+	last    time.Time // Last start or end of a package
+}
+
+func newPacer(policy pacingPolicy, workers int) *pacer {
+	p := new(pacer)
+	p.policy = policy
+	p.workers = max(workers, 1)
+	p.start = time.Now()
+	if policy.rampUp > 0 && p.workers > 1 {
+		log.Info().Msgf("Ramping up from 1 to %d packages in parallel over %v", p.workers, policy.rampUp)
+	}
+	return p
+}
+
+// workersAt returns the number of packages that may be configured at a time once elapsed has passed since
+// the start of the run
+func (p *pacer) workersAt(elapsed time.Duration) int {
+	if p.policy.rampUp <= 0 || elapsed >= p.policy.rampUp {
+		return p.workers
+	}
+	return 1 + int(int64(p.workers-1)*int64(elapsed)/int64(p.policy.rampUp))
+}
+
+// acquire waits until the next package may start
+func (p *pacer) acquire() {
+	if p.policy.packageDelay <= 0 && p.policy.rampUp <= 0 {
+		return
+	}
+	for {
+		p.mu.Lock()
+		now := time.Now()
+		var wait time.Duration
+		if !p.last.IsZero() {
+			wait = p.last.Add(p.policy.packageDelay).Sub(now)
+		}
+		if wait <= 0 && p.active < p.workersAt(now.Sub(p.start)) {
+			p.active++
+			p.last = now
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+		if wait <= 0 || wait > pacerPoll {
+			wait = pacerPoll
+		}
+		time.Sleep(wait)
+	}
+}
+
+// release records the end of a package
+func (p *pacer) release() {
+	if p.policy.packageDelay <= 0 && p.policy.rampUp <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active--
+	p.last = time.Now()
+}
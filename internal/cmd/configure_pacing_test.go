@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPacerWorkersAt(t *testing.T) {
+	p := newPacer(pacingPolicy{rampUp: 40 * time.Second}, 5)
+	assert.Equal(t, 1, p.workersAt(0))
+	assert.Equal(t, 1, p.workersAt(9*time.Second))
+	assert.Equal(t, 2, p.workersAt(10*time.Second))
+	assert.Equal(t, 4, p.workersAt(35*time.Second))
+	assert.Equal(t, 5, p.workersAt(40*time.Second))
+	assert.Equal(t, 5, newPacer(pacingPolicy{}, 5).workersAt(0), "Without ramp-up all workers should be used")
+}
+
+func TestPacerPackageDelay(t *testing.T) {
+	p := newPacer(pacingPolicy{packageDelay: 50 * time.Millisecond}, 3)
+	var mu sync.Mutex
+	var starts []time.Time
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.acquire()
+			mu.Lock()
+			starts = append(starts, time.Now())
+			mu.Unlock()
+			p.release()
+		}()
+	}
+	wg.Wait()
+	for i := 1; i < len(starts); i++ {
+		assert.GreaterOrEqual(t, starts[i].Sub(starts[i-1]), 50*time.Millisecond, "Packages should start with a pause in between")
+	}
+}
+
+func TestPacerRampUp(t *testing.T) {
+	p := newPacer(pacingPolicy{rampUp: time.Hour}, 3)
+	p.acquire()
+	acquired := make(chan struct{})
+	go func() {
+		p.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Second package should wait at the start of the ramp-up")
+	case <-time.After(3 * pacerPoll):
+	}
+	p.release()
+	<-acquired
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// configurationFields are the properties of configuration parameters used when comparing and updating them
+var configurationFields = []string{"ParameterKey", "ParameterValue", "DataType"}
+
+// configurationReader returns the current configuration of artifacts. Configurations prefetched with
+// $batch requests are returned from memory, all others are read with one request per artifact. It can be
+// used by packages configured concurrently.
+type configurationReader struct {
+	configuration api.ConfigurationService
+	mu            sync.Mutex
+	prefetched    map[string]*api.BatchConfiguration
+}
+
+func newConfigurationReader(configuration api.ConfigurationService) *configurationReader {
+	r := new(configurationReader)
+	r.configuration = configuration
+	r.prefetched = map[string]*api.BatchConfiguration{}
+	return r
+}
+
+// prefetch reads the configuration of the artifacts with parameters that pass the filters with $batch
+// requests. If a batch request fails, the configurations are read individually later on. $batch requests are
+// only sent with the OData V2 APIs.
+func (r *configurationReader) prefetch(cfg *models.ConfigureConfig, packageFilter, artifactFilter []string, batchSize int) {
+	configuration, ok := r.configuration.(*api.Configuration)
+	if !ok {
+		return
+	}
+	idsByVersion := map[string][]string{}
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		for _, artifact := range pkg.Artifacts {
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) || len(artifact.Parameters) == 0 {
+				continue
+			}
+			idsByVersion[artifact.Version] = append(idsByVersion[artifact.Version], cfg.DeploymentPrefix+artifact.ID)
+		}
+	}
+
+	for version, ids := range idsByVersion {
+		if len(ids) < 2 {
+			continue
+		}
+		configurations, err := configuration.GetBatch(ids, version, configurationFields, batchSize)
+		if err != nil {
+			log.Warn().Msgf("Batch read of configurations failed, reading them individually: %v", err)
+			continue
+		}
+		for id, c := range configurations {
+			r.prefetched[id+"|"+version] = c
+		}
+	}
+}
+
+// get returns the configuration of an artifact, prefetched if available
+func (r *configurationReader) get(id string, version string) (*api.ParametersData, error) {
+	r.mu.Lock()
+	c, ok := r.prefetched[id+"|"+version]
+	r.mu.Unlock()
+	if ok {
+		return c.Parameters, c.Err
+	}
+	return r.configuration.Get(id, version)
+}
+
+// forget drops the prefetched configuration of an artifact after it has been changed
+func (r *configurationReader) forget(id string, version string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.prefetched, id+"|"+version)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+)
+
+// Handling of configured artifacts that are not found on the tenant by the prefix check
+const (
+	prefixCheckOff   = "off"   // No check
+	prefixCheckWarn  = "warn"  // Report the reconciliation and continue
+	prefixCheckError = "error" // Report the reconciliation and abort before anything is applied
+)
+
+func validatePrefixCheck(mode string) error {
+	switch mode {
+	case prefixCheckOff, prefixCheckWarn, prefixCheckError:
+		return nil
+	}
+	return fmt.Errorf("invalid prefix check %q (valid values: %s, %s, %s)", mode, prefixCheckOff, prefixCheckWarn, prefixCheckError)
+}
+
+// reconcilePrefix compares the artifact IDs of the packages, with the deployment prefix, with the artifacts of the
+// packages on the tenant. A configured package that is not on the tenant is looked up under another prefix, e.g.
+// DEV-Sales for DEV_Sales, so that the artifacts with a mistyped prefix are named as candidates.
+func reconcilePrefix(s packageSettings, packages []models.ConfigurePackage) (*flashpipe.PrefixCheck, error) {
+	ip := api.NewIntegrationPackage(s.exe)
+	tenantPackages, err := ip.GetPackagesList()
+	if err != nil {
+		return nil, err
+	}
+
+	check := &flashpipe.PrefixCheck{}
+	configured := map[string][]string{} // Artifact IDs with the prefix by package, including those filtered out
+	var packageIDs []string
+	for _, pkg := range packages {
+		packageID := s.deploymentPrefix + pkg.ID
+		if !slices.Contains(packageIDs, packageID) {
+			packageIDs = append(packageIDs, packageID)
+		}
+		for _, artifact := range pkg.Artifacts {
+			configured[packageID] = append(configured[packageID], s.deploymentPrefix+artifact.ID)
+		}
+	}
+
+	for _, packageID := range packageIDs {
+		// Artifacts of the package on the tenant, or else of a package with the same ID after another prefix
+		tenantPackageID := packageID
+		if !slices.Contains(tenantPackages, packageID) {
+			tenantPackageID = prefixCandidate(packageID, s.deploymentPrefix, tenantPackages)
+		}
+		var tenantArtifacts []string
+		if tenantPackageID != "" {
+			artifacts, err := ip.GetAllArtifacts(tenantPackageID)
+			if err != nil {
+				return nil, err
+			}
+			for _, artifact := range artifacts {
+				tenantArtifacts = append(tenantArtifacts, artifact.Id)
+			}
+		}
+
+		var candidates []string
+		for _, pkg := range packages {
+			if s.deploymentPrefix+pkg.ID != packageID {
+				continue
+			}
+			for _, artifact := range pkg.Artifacts {
+				if len(s.artifactFilter) > 0 && !shouldInclude(artifact.ID, s.artifactFilter) {
+					continue
+				}
+				artifactID := s.deploymentPrefix + artifact.ID
+				check.Expected++
+				if tenantPackageID == packageID && slices.Contains(tenantArtifacts, artifactID) {
+					check.Present++
+					continue
+				}
+				candidate := prefixCandidate(artifactID, s.deploymentPrefix, tenantArtifacts)
+				if candidate != "" {
+					candidates = append(candidates, candidate)
+				}
+				check.Missing = append(check.Missing, flashpipe.PrefixCheckArtifact{PackageID: packageID, ArtifactID: artifactID, Candidate: candidate})
+			}
+		}
+
+		// The artifacts of a package found under another prefix are not extra, they are named as candidates
+		if tenantPackageID != packageID {
+			continue
+		}
+		for _, artifactID := range tenantArtifacts {
+			if strings.HasPrefix(artifactID, s.deploymentPrefix) && !slices.Contains(configured[packageID], artifactID) && !slices.Contains(candidates, artifactID) {
+				check.Extra = append(check.Extra, flashpipe.PrefixCheckArtifact{PackageID: packageID, ArtifactID: artifactID})
+			}
+		}
+	}
+	return check, nil
+}
+
+// prefixCandidate returns the first of ids that is id with another prefix, e.g. DEV-Orders for DEV_Orders, or
+// the empty string. IDs without prefix, the designtime artifacts copied with the prefix, are no candidates.
+func prefixCandidate(id string, prefix string, ids []string) string {
+	unprefixed := strings.TrimPrefix(id, prefix)
+	for _, candidate := range ids {
+		if candidate != id && candidate != unprefixed && strings.HasSuffix(candidate, unprefixed) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// checkPrefix reconciles the configured artifacts with the tenant before anything is applied and reports the
+// artifacts that are missing on the tenant or not configured. With the error mode, missing artifacts abort the
+// run, e.g. after a typo in the deployment prefix.
+func checkPrefix(s packageSettings, packages []models.ConfigurePackage, mode string, stats *ConfigureStats) error {
+	if mode == "" || mode == prefixCheckOff {
+		return nil
+	}
+	check, err := reconcilePrefix(s, packages)
+	if err != nil {
+		if mode == prefixCheckError {
+			return fmt.Errorf("prefix check failed: %w", err)
+		}
+		log.Warn().Msgf("Prefix check failed: %v", err)
+		stats.AddWarning("Prefix check failed: %v", err)
+		return nil
+	}
+	stats.PrefixCheck = check
+
+	log.Info().Msgf("Prefix check: %d artifact(s) expected, %d present, %d missing, %d extra",
+		check.Expected, check.Present, len(check.Missing), len(check.Extra))
+	for _, missing := range check.Missing {
+		if missing.Candidate != "" {
+			log.Warn().Msgf("   Missing: %s/%s, found %s (check the deployment prefix)", missing.PackageID, missing.ArtifactID, missing.Candidate)
+		} else {
+			log.Warn().Msgf("   Missing: %s/%s", missing.PackageID, missing.ArtifactID)
+		}
+	}
+	for _, extra := range check.Extra {
+		log.Info().Msgf("   Extra: %s/%s (not configured)", extra.PackageID, extra.ArtifactID)
+	}
+
+	if len(check.Missing) == 0 {
+		return nil
+	}
+	if mode == prefixCheckError {
+		return fmt.Errorf("prefix check: %d of %d configured artifact(s) not found on the tenant, nothing applied (check the deployment prefix)",
+			len(check.Missing), check.Expected)
+	}
+	stats.AddWarning("Prefix check: %d of %d configured artifact(s) not found on the tenant", len(check.Missing), check.Expected)
+	return nil
+}
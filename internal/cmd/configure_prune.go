@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// orphanedArtifact is a prefixed artifact on the tenant that is not in any configuration file
+type orphanedArtifact struct {
+	packageID    string
+	artifactID   string
+	artifactType string
+	designtime   bool
+	deployed     bool
+}
+
+// orphans are the prefixed artifacts and packages on the tenant that are not in any configuration file
+type orphans struct {
+	artifacts []orphanedArtifact
+	packages  []string
+}
+
+func NewConfigurePruneCommand() *cobra.Command {
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove prefixed artifacts that are no longer configured",
+		Long: `List the artifacts and packages on the tenant whose IDs start with the
+deployment prefix but that are not in any of the configuration files, e.g.
+DEV_ copies left behind after an artifact was renamed or removed from the
+configuration.
+
+All artifacts of the configuration files are kept, regardless of their
+when conditions and the filters. Orphans are only listed, unless
+--undeploy or --delete is set. --undeploy undeploys the orphaned runtime
+artifacts, --delete undeploys them and deletes their designtime artifacts,
+and the orphaned packages without other artifacts. The changes are listed
+and confirmed at a prompt first, unless --yes is set.`,
+		Example: `  # List the orphaned DEV_ artifacts
+  flashpipe configure prune --config-path ./configs --prefix DEV_
+
+  # Show what would be deleted
+  flashpipe configure prune --config-path ./configs --prefix DEV_ --delete --dry-run
+
+  # Delete them in a pipeline
+  flashpipe configure prune --config-path ./configs --prefix DEV_ --delete --yes`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runConfigurePrune(cmd, os.Stdin, os.Stdout); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	pruneCmd.Flags().String("prefix", "", "Prefix of the artifacts to prune, defaults to the deployment prefix (config: configure.prune.prefix)")
+	pruneCmd.Flags().Bool("undeploy", false, "Undeploy the orphaned runtime artifacts (config: configure.prune.undeploy)")
+	pruneCmd.Flags().Bool("delete", false, "Undeploy and delete the orphaned artifacts, and delete orphaned packages without other artifacts (config: configure.prune.delete)")
+	pruneCmd.Flags().Bool("dry-run", false, "Show the changes without making them (config: configure.prune.dryRun)")
+	pruneCmd.Flags().Bool("yes", false, "Make the changes without confirmation at a prompt (config: configure.prune.yes)")
+
+	return pruneCmd
+}
+
+func runConfigurePrune(cmd *cobra.Command, in io.Reader, out io.Writer) error {
+	configPath := config.GetStringWithFallback(cmd, "config-path", "configure.configPath")
+	prefix := config.GetStringWithFallback(cmd, "prefix", "configure.prune.prefix")
+	if prefix == "" {
+		prefix = config.GetStringWithFallback(cmd, "deployment-prefix", "configure.deploymentPrefix")
+	}
+	undeploy := config.GetBoolWithFallback(cmd, "undeploy", "configure.prune.undeploy")
+	remove := config.GetBoolWithFallback(cmd, "delete", "configure.prune.delete")
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "configure.prune.dryRun")
+	yes := config.GetBoolWithFallback(cmd, "yes", "configure.prune.yes")
+
+	configPath, cleanup, err := envConfigPath(configPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if configPath == "" {
+		return fmt.Errorf("--config-path is required (set via CLI flag, in config file under 'configure.configPath' or as content in %s)", configB64Env)
+	}
+	configFiles, _, err := loadConfigureFiles(cmd, configPath)
+	if err != nil {
+		return err
+	}
+	cfg := flashpipe.MergeConfigs(configFiles, "")
+	if prefix == "" {
+		prefix = cfg.DeploymentPrefix
+	}
+	// Without prefix, all artifacts of the tenant that are not configured would be pruned
+	if prefix == "" {
+		return fmt.Errorf("--prefix is required (set via CLI flag, in config file under 'configure.prune.prefix' or as deployment prefix)")
+	}
+	if err := deploy.ValidateDeploymentPrefix(prefix); err != nil {
+		return err
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	found, err := findOrphans(exe, cfg, prefix)
+	if err != nil {
+		return err
+	}
+	if err := writeOrphans(out, found); err != nil {
+		return err
+	}
+	if len(found.artifacts) == 0 && len(found.packages) == 0 {
+		log.Info().Msgf("🏆 No orphaned artifacts with prefix %s", prefix)
+		return nil
+	}
+	if !undeploy && !remove {
+		log.Info().Msgf("Found %d orphaned artifact(s) and %d orphaned package(s), use --undeploy or --delete to remove them", len(found.artifacts), len(found.packages))
+		return nil
+	}
+	if dryRun {
+		log.Info().Msgf("Dry run: %d orphaned artifact(s) and %d orphaned package(s) would be pruned", len(found.artifacts), len(found.packages))
+		return nil
+	}
+	if !yes && !confirmPrune(in, out, exe.Host(), remove) {
+		return fmt.Errorf("prune rejected at prompt, use --yes to prune without confirmation")
+	}
+	return pruneOrphans(exe, found, remove)
+}
+
+// findOrphans returns the artifacts and packages on the tenant with IDs starting with prefix that are not in cfg.
+// Deployed artifacts are found by their runtime artifacts, so that those without designtime artifact are found too.
+func findOrphans(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig, prefix string) (*orphans, error) {
+	configuredPackages := map[string]bool{}
+	configuredArtifacts := map[string]bool{}
+	for _, pkg := range cfg.Packages {
+		configuredPackages[prefix+pkg.ID] = true
+		for _, artifact := range pkg.Artifacts {
+			configuredArtifacts[prefix+artifact.ID] = true
+		}
+	}
+
+	found := &orphans{}
+	ip := api.NewIntegrationPackage(exe)
+	packages, err := ip.GetPackagesList()
+	if err != nil {
+		return nil, err
+	}
+	for _, packageID := range packages {
+		if !strings.HasPrefix(packageID, prefix) {
+			continue
+		}
+		artifacts, err := ip.GetAllArtifacts(packageID)
+		if err != nil {
+			return nil, err
+		}
+		kept := 0
+		for _, artifact := range artifacts {
+			if !strings.HasPrefix(artifact.Id, prefix) || configuredArtifacts[artifact.Id] {
+				kept++
+				continue
+			}
+			found.artifacts = append(found.artifacts, orphanedArtifact{packageID: packageID, artifactID: artifact.Id, artifactType: artifact.ArtifactType, designtime: true})
+		}
+		if !configuredPackages[packageID] && kept == 0 {
+			found.packages = append(found.packages, packageID)
+		}
+	}
+
+	runtimeArtifacts, err := api.NewRuntime(exe).List()
+	if err != nil {
+		return nil, err
+	}
+	for _, runtime := range runtimeArtifacts {
+		if !strings.HasPrefix(runtime.Id, prefix) || configuredArtifacts[runtime.Id] {
+			continue
+		}
+		i := slices.IndexFunc(found.artifacts, func(o orphanedArtifact) bool { return o.artifactID == runtime.Id })
+		if i < 0 {
+			found.artifacts = append(found.artifacts, orphanedArtifact{artifactID: runtime.Id, artifactType: runtime.Type})
+			i = len(found.artifacts) - 1
+		}
+		found.artifacts[i].deployed = true
+	}
+	return found, nil
+}
+
+func writeOrphans(out io.Writer, found *orphans) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tARTIFACT\tTYPE\tDEPLOYED")
+	for _, o := range found.artifacts {
+		packageID := o.packageID
+		if !o.designtime {
+			packageID = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", packageID, o.artifactID, o.artifactType, o.deployed)
+	}
+	for _, packageID := range found.packages {
+		fmt.Fprintf(w, "%v\t-\tPackage\t-\n", packageID)
+	}
+	return w.Flush()
+}
+
+// confirmPrune asks for confirmation of the changes at a prompt
+func confirmPrune(in io.Reader, out io.Writer, host string, remove bool) bool {
+	action := "undeploy"
+	if remove {
+		action = "undeploy and delete"
+	}
+	fmt.Fprintf(out, "Proceed to %v the listed artifacts on %v? [y/N]: ", action, host)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// pruneOrphans undeploys the orphaned runtime artifacts and, with remove, deletes the orphaned designtime artifacts
+// and packages. All orphans are attempted, the failures are returned together.
+func pruneOrphans(exe *httpclnt.HTTPExecuter, found *orphans, remove bool) error {
+	runtime := api.NewRuntime(exe)
+	failed := 0
+	for _, o := range found.artifacts {
+		if o.deployed {
+			if err := runtime.UnDeploy(o.artifactID); err != nil {
+				log.Error().Msgf("Failed to undeploy %s: %v", o.artifactID, err)
+				failed++
+				continue
+			}
+		}
+		if !remove || !o.designtime {
+			continue
+		}
+		dt := api.NewDesigntimeArtifact(o.artifactType, exe)
+		if dt == nil {
+			log.Warn().Msgf("⚠️  %s of type %s cannot be deleted, skipping", o.artifactID, o.artifactType)
+			continue
+		}
+		if err := dt.Delete(o.artifactID); err != nil {
+			log.Error().Msgf("Failed to delete %s: %v", o.artifactID, err)
+			failed++
+		}
+	}
+	if remove {
+		ip := api.NewIntegrationPackage(exe)
+		for _, packageID := range found.packages {
+			if err := ip.Delete(packageID); err != nil {
+				log.Error().Msgf("Failed to delete package %s: %v", packageID, err)
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d orphan(s) failed to be pruned", failed)
+	}
+	log.Info().Msgf("🏆 Pruned %d orphaned artifact(s) and %d orphaned package(s)", len(found.artifacts), len(found.packages))
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneOrphansMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	var mu sync.Mutex
+	var changes []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			changes = append(changes, r.URL.Path)
+			mu.Unlock()
+			if strings.HasPrefix(r.URL.Path, "/api/v1/IntegrationRuntimeArtifacts") || strings.HasPrefix(r.URL.Path, "/api/v1/IntegrationPackages") {
+				w.WriteHeader(http.StatusAccepted)
+			}
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case "/api/v1/IntegrationPackages":
+			w.Write([]byte(`{"d": {"results": [{"Id": "DEV_Sales"}, {"Id": "DEV_Old"}, {"Id": "Sales"}]}}`))
+		case "/api/v1/IntegrationPackages('DEV_Sales')/IntegrationDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "DEV_Orders", "Version": "1.0.0"}, {"Id": "DEV_Orders_Renamed", "Version": "1.0.0"}]}}`))
+		case "/api/v1/IntegrationPackages('DEV_Old')/ValueMappingDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "DEV_Codes", "Version": "1.0.0"}]}}`))
+		case "/api/v1/IntegrationRuntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "DEV_Orders", "Type": "INTEGRATION_FLOW"}, {"Id": "DEV_Orders_Renamed", "Type": "INTEGRATION_FLOW"},
+				{"Id": "DEV_Removed", "Type": "INTEGRATION_FLOW"}, {"Id": "Orders", "Type": "INTEGRATION_FLOW"}]}}`))
+		default:
+			w.Write([]byte(`{"d": {"results": []}}`))
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	cfg := &models.ConfigureConfig{Packages: []models.ConfigurePackage{{
+		ID:        "Sales",
+		Artifacts: []models.ConfigureArtifact{{ID: "Orders", Type: "Integration"}},
+	}}}
+	found, err := findOrphans(exe, cfg, "DEV_")
+	require.NoError(t, err)
+	assert.Equal(t, []orphanedArtifact{
+		{packageID: "DEV_Sales", artifactID: "DEV_Orders_Renamed", artifactType: "Integration", designtime: true, deployed: true},
+		{packageID: "DEV_Old", artifactID: "DEV_Codes", artifactType: "ValueMapping", designtime: true},
+		{artifactID: "DEV_Removed", artifactType: "INTEGRATION_FLOW", deployed: true},
+	}, found.artifacts)
+	assert.Equal(t, []string{"DEV_Old"}, found.packages, "Packages with configured artifacts should be kept")
+
+	var out bytes.Buffer
+	require.NoError(t, writeOrphans(&out, found))
+	assert.Contains(t, out.String(), "DEV_Sales  DEV_Orders_Renamed  Integration       true")
+
+	require.NoError(t, pruneOrphans(exe, found, false))
+	assert.Equal(t, []string{
+		"/api/v1/IntegrationRuntimeArtifacts('DEV_Orders_Renamed')",
+		"/api/v1/IntegrationRuntimeArtifacts('DEV_Removed')",
+	}, changes, "Only deployed orphans should be undeployed")
+
+	changes = nil
+	require.NoError(t, pruneOrphans(exe, found, true))
+	assert.Equal(t, []string{
+		"/api/v1/IntegrationRuntimeArtifacts('DEV_Orders_Renamed')",
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_Orders_Renamed',Version='active')",
+		"/api/v1/ValueMappingDesigntimeArtifacts(Id='DEV_Codes',Version='active')",
+		"/api/v1/IntegrationRuntimeArtifacts('DEV_Removed')",
+		"/api/v1/IntegrationPackages('DEV_Old')",
+	}, changes)
+}
+
+func TestConfirmPrune(t *testing.T) {
+	var out bytes.Buffer
+	assert.True(t, confirmPrune(strings.NewReader("y\n"), &out, "tenant", true))
+	assert.Contains(t, out.String(), "undeploy and delete the listed artifacts on tenant")
+	assert.False(t, confirmPrune(strings.NewReader(""), &out, "tenant", false), "No answer should reject")
+}
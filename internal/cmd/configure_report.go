@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/exitreport"
+	"github.com/engswee/flashpipe/internal/runid"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+)
+
+// ConfigureReport is the machine-readable summary of a configure run, written to --report-file
+type ConfigureReport struct {
+	RunID   string         `json:"runId,omitempty"`
+	Tenants []TenantReport `json:"tenants"`
+}
+
+// TenantReport is the outcome of the configure run on one tenant
+type TenantReport struct {
+	Tenant     string          `json:"tenant"`
+	Host       string          `json:"host"`
+	Error      string          `json:"error,omitempty"`
+	RolledBack []string        `json:"rolledBack,omitempty"` // Integration flows redeployed with their previous version
+	Stats      *ConfigureStats `json:"stats,omitempty"`
+}
+
+// writeConfigureReport writes the results of the tenants as JSON to reportFile, if set, and adds their totals
+// to the exit report
+func writeConfigureReport(results []targetResult, reportFile string) error {
+	for _, r := range results {
+		if len(r.RolledBack) > 0 {
+			exitreport.Add("artifactsRolledBack", len(r.RolledBack))
+		}
+		if r.Stats == nil {
+			continue
+		}
+		exitreport.Add("artifactsConfigured", r.Stats.ArtifactsConfigured.Value())
+		exitreport.Add("artifactsDeployed", r.Stats.DeploymentTasksSuccessful.Value())
+		exitreport.Add("artifactsFailed", r.Stats.ArtifactsFailed.Value()+r.Stats.DeploymentTasksFailed.Value())
+		exitreport.Add("artifactsLocked", r.Stats.ArtifactsLocked.Value())
+		exitreport.Add("parametersUpdated", r.Stats.ParametersUpdated.Value())
+	}
+	if reportFile == "" {
+		return nil
+	}
+	report := ConfigureReport{RunID: runid.ID(), Tenants: []TenantReport{}}
+	for _, r := range results {
+		report.Tenants = append(report.Tenants, TenantReport{
+			Tenant:     r.Target.Name,
+			Host:       r.Target.Host,
+			Error:      errorString(r.Error),
+			RolledBack: r.RolledBack,
+			Stats:      r.Stats,
+		})
+	}
+
+	f, err := os.Create(reportFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	log.Info().Msgf("Report written to %s", reportFile)
+	exitreport.SetReport(reportFile)
+	return nil
+}
+
+// readConfigureReport reads a report written with --report-file
+func readConfigureReport(reportFile string) (*ConfigureReport, error) {
+	content, err := os.ReadFile(reportFile)
+	if err != nil {
+		return nil, err
+	}
+	var report ConfigureReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("invalid report %s: %w", reportFile, err)
+	}
+	return &report, nil
+}
+
+// ChangedArtifacts lists the artifacts changed in a configure run, written to --changed-artifacts-file
+type ChangedArtifacts struct {
+	Artifacts []ChangedArtifact `json:"artifacts"`
+}
+
+// ChangedArtifact is an artifact configured with values other than the current ones, or deployed, on a tenant
+type ChangedArtifact struct {
+	Tenant       string `json:"tenant"`
+	PackageID    string `json:"packageId"`
+	ArtifactID   string `json:"artifactId"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	Configured   bool   `json:"configured"`
+	Deployed     bool   `json:"deployed"`
+}
+
+// changedArtifacts returns the artifacts changed on the tenants, ordered by tenant, package and artifact ID
+func changedArtifacts(results []targetResult) []ChangedArtifact {
+	changed := []ChangedArtifact{}
+	for _, r := range results {
+		if r.Stats == nil {
+			continue
+		}
+		index := map[string]int{}
+		for _, a := range r.Stats.ArtifactResults() {
+			if !a.Changed {
+				continue
+			}
+			key := a.PackageID + "/" + a.ArtifactID
+			i, found := index[key]
+			if !found {
+				i = len(changed)
+				index[key] = i
+				changed = append(changed, ChangedArtifact{Tenant: r.Target.Name, PackageID: a.PackageID, ArtifactID: a.ArtifactID})
+			}
+			if a.Phase == flashpipe.PhaseDeploy {
+				changed[i].Deployed = true
+				changed[i].ArtifactType = a.ArtifactType
+			} else {
+				changed[i].Configured = true
+			}
+		}
+	}
+	slices.SortStableFunc(changed, func(a, b ChangedArtifact) int {
+		return strings.Compare(a.Tenant+"\x00"+a.PackageID+"\x00"+a.ArtifactID, b.Tenant+"\x00"+b.PackageID+"\x00"+b.ArtifactID)
+	})
+	return changed
+}
+
+// writeChangedArtifacts writes the artifacts changed on the tenants to file, if set, for downstream jobs such
+// as regression tests of the affected interfaces. A .json file lists them with tenant, package and what was
+// changed, any other file their IDs one per line, once even if changed on several tenants. The file is
+// written in dry runs as well, with the artifacts that would be changed.
+func writeChangedArtifacts(results []targetResult, file string) error {
+	if file == "" {
+		return nil
+	}
+	changed := changedArtifacts(results)
+	var content []byte
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		var err error
+		if content, err = json.MarshalIndent(ChangedArtifacts{Artifacts: changed}, "", "  "); err != nil {
+			return err
+		}
+		content = append(content, '\n')
+	} else {
+		var ids []string
+		for _, a := range changed {
+			if !slices.Contains(ids, a.ArtifactID) {
+				ids = append(ids, a.ArtifactID)
+			}
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			content = append(content, id+"\n"...)
+		}
+	}
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		return err
+	}
+	log.Info().Msgf("%d changed artifact(s) written to %s", len(changed), file)
+	return nil
+}
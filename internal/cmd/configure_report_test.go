@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteChangedArtifacts(t *testing.T) {
+	qa := &ConfigureStats{}
+	qa.AddConfigurationResult("Orders", "Orders_Replicate", true, time.Second, nil)
+	qa.AddDeploymentResult("Orders", "Orders_Replicate", "Integration", time.Second, nil)
+	qa.AddConfigurationResult("Orders", "Orders_Status", false, time.Second, nil)
+	qa.AddSkippedDeployment("Orders", "Orders_Status", "Integration")
+	prod := &ConfigureStats{}
+	prod.AddConfigurationResult("Orders", "Orders_Replicate", true, time.Second, nil)
+	prod.AddConfigurationResult("Billing", "Billing_Export", false, time.Second, nil)
+	prod.AddDeploymentResult("Billing", "Billing_Export", "Integration", time.Second, nil)
+	results := []targetResult{
+		{Target: models.ConfigureTarget{Name: "qa"}, Stats: qa},
+		{Target: models.ConfigureTarget{Name: "prod"}, Stats: prod},
+		{Target: models.ConfigureTarget{Name: "failed"}},
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, writeChangedArtifacts(results, filepath.Join(dir, "changed.txt")))
+	content, err := os.ReadFile(filepath.Join(dir, "changed.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "Billing_Export\nOrders_Replicate\n", string(content), "IDs changed on several tenants should be listed once")
+
+	require.NoError(t, writeChangedArtifacts(results, filepath.Join(dir, "changed.json")))
+	content, err = os.ReadFile(filepath.Join(dir, "changed.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"artifacts": [
+		{"tenant": "prod", "packageId": "Billing", "artifactId": "Billing_Export", "artifactType": "Integration", "configured": false, "deployed": true},
+		{"tenant": "prod", "packageId": "Orders", "artifactId": "Orders_Replicate", "configured": true, "deployed": false},
+		{"tenant": "qa", "packageId": "Orders", "artifactId": "Orders_Replicate", "artifactType": "Integration", "configured": true, "deployed": true}
+	]}`, string(content))
+
+	require.NoError(t, writeChangedArtifacts(results[2:], filepath.Join(dir, "none.txt")))
+	content, err = os.ReadFile(filepath.Join(dir, "none.txt"))
+	require.NoError(t, err)
+	assert.Empty(t, content, "The file should be written without changes as well")
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/requestbundle"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigurePackageRequestsCommand() *cobra.Command {
+
+	packageCmd := &cobra.Command{
+		Use:   "package-requests",
+		Short: "Write the requests of a configuration to a bundle without executing them",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		SilenceUsage: true,
+		Long: `Write the API calls that configure would make for the configuration files
+into a request bundle (tar) without connecting to the tenant, for
+environments where the pipeline has no access to the tenant. The bundle is
+reviewed and executed later from a host with access, e.g. a jump host,
+with flashpipe execute-requests.
+
+As the tenant is not read, every parameter is updated and every artifact
+flagged for deployment is deployed. Parameters with the modes set-if-empty,
+append and delete depend on the current values on the tenant and are
+rejected, as are configurations with a targets block.
+
+The bundle contains the parameter values in plain text, including values
+resolved with valueFrom. Handle it like the credentials it may contain.`,
+		Example: `  # Write the requests of the production configuration
+  flashpipe configure package-requests --config-path ./config/prod --out requests.tar
+
+  # Review and execute them on the jump host
+  flashpipe execute-requests --file requests.tar --dry-run
+  flashpipe execute-requests --file requests.tar`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runConfigurePackageRequests(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	packageCmd.Flags().String("out", "requests.tar", "File the request bundle is written to (config: configure.packageRequests.out)")
+
+	return packageCmd
+}
+
+func runConfigurePackageRequests(cmd *cobra.Command) error {
+	configPath := config.GetStringWithFallback(cmd, "config-path", "configure.configPath")
+	deploymentPrefix := config.GetStringWithFallback(cmd, "deployment-prefix", "configure.deploymentPrefix")
+	packageFilterStr, artifactFilterStr, err := resolveFilters(config.GetStringWithFallback(cmd, "package-filter", "configure.packageFilter"),
+		config.GetStringWithFallback(cmd, "artifact-filter", "configure.artifactFilter"))
+	if err != nil {
+		return err
+	}
+	packageFilter, artifactFilter := parseFilter(packageFilterStr), parseFilter(artifactFilterStr)
+	out := config.GetStringWithFallback(cmd, "out", "configure.packageRequests.out")
+
+	configPath, cleanup, err := envConfigPath(configPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if configPath == "" {
+		return fmt.Errorf("--config-path is required (set via CLI flag, in config file under 'configure.configPath' or as content in %s)", configB64Env)
+	}
+	if deploymentPrefix != "" {
+		if err := deploy.ValidateDeploymentPrefix(deploymentPrefix); err != nil {
+			return err
+		}
+	}
+
+	configData, err := loadConfigureData(cmd, configPath, deploymentPrefix)
+	if err != nil {
+		return err
+	}
+	requests, err := configureRequests(configData, packageFilter, artifactFilter)
+	if err != nil {
+		return err
+	}
+
+	manifest := &requestbundle.Manifest{
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Command:   cmd.CommandPath(),
+		Tenant:    config.GetString(cmd, "tmn-host"),
+		Requests:  requests,
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := requestbundle.Write(f, manifest); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Info().Msgf("🏆 %d request(s) written to %s, execute them with flashpipe execute-requests --file %s", len(requests), out, out)
+	return nil
+}
+
+// configureRequests returns the requests that update the parameters of the artifacts of the configuration,
+// followed by the requests that deploy them
+func configureRequests(cfg *models.ConfigureConfig, packageFilter, artifactFilter []string) ([]*requestbundle.Request, error) {
+	if len(cfg.Targets) > 0 {
+		return nil, fmt.Errorf("configurations with a targets block cannot be packaged, package the configuration of each tenant separately")
+	}
+	var updates, deployments []*requestbundle.Request
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		for _, artifact := range pkg.Artifacts {
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) {
+				continue
+			}
+			artifactID := cfg.DeploymentPrefix + artifact.ID
+
+			for _, param := range artifact.Parameters {
+				if param.Mode != "" && param.Mode != flashpipe.ParameterModeSet {
+					return nil, fmt.Errorf("parameter %s of artifact %s has mode %s, which depends on the current value on the tenant", param.Key, artifactID, param.Mode)
+				}
+				path, body, err := api.ConfigurationUpdateRequest(artifactID, artifact.Version, param.Key, param.Value)
+				if err != nil {
+					return nil, err
+				}
+				updates = append(updates, &requestbundle.Request{
+					Description:  fmt.Sprintf("Update configuration parameter %s of %s", param.Key, artifactID),
+					Method:       http.MethodPut,
+					Path:         path,
+					ContentType:  "application/json",
+					Body:         body,
+					SuccessCodes: []int{api.ConfigurationUpdateSuccessCode},
+				})
+			}
+
+			if artifact.Deploy || pkg.Deploy {
+				deployments = append(deployments, &requestbundle.Request{
+					Description: fmt.Sprintf("Deploy %s designtime artifact %s", artifact.Type, artifactID),
+					Method:      http.MethodPost,
+					Path:        api.DeployVersionPath(artifactID, artifact.Type, artifact.DeployVersion),
+					// Cloud Foundry answers with 202, Neo with 200 or 202
+					SuccessCodes: []int{http.StatusOK, http.StatusAccepted},
+				})
+			}
+		}
+	}
+	return append(updates, deployments...), nil
+}
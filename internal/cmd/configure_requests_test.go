@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/requestbundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureRequestsMock(t *testing.T) {
+	cfg := &models.ConfigureConfig{
+		DeploymentPrefix: "DEV_",
+		Packages: []models.ConfigurePackage{{
+			ID: "Sales",
+			Artifacts: []models.ConfigureArtifact{
+				{ID: "Orders", Type: "Integration", Version: "active", Deploy: true, Parameters: []models.ConfigurationParameter{
+					{Key: "Receiver Host", Value: "erp.example.com"},
+				}},
+				{ID: "Mapping", Type: "MessageMapping", Version: "active", Deploy: true},
+				{ID: "Invoices", Type: "Integration", Version: "active", Parameters: []models.ConfigurationParameter{{Key: "Timeout", Value: "30"}}},
+			},
+		}},
+	}
+	requests, err := configureRequests(cfg, nil, []string{"Orders", "Mapping"})
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "PUT", requests[0].Method)
+	assert.Equal(t, "/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_Orders',Version='active')/$links/Configurations('Receiver%20Host')", requests[0].Path)
+	assert.JSONEq(t, `{"ParameterValue": "erp.example.com"}`, string(requests[0].Body))
+	assert.Equal(t, "/api/v1/DeployIntegrationDesigntimeArtifact?Id='DEV_Orders'&Version='active'", requests[1].Path, "Deployments should follow the updates")
+	assert.Equal(t, "/api/v1/DeployMessageMappingDesigntimeArtifact?Id='DEV_Mapping'&Version='active'", requests[2].Path)
+
+	cfg.Packages[0].Artifacts[2].Parameters[0].Mode = "append"
+	_, err = configureRequests(cfg, nil, nil)
+	assert.EqualError(t, err, "parameter Timeout of artifact DEV_Invoices has mode append, which depends on the current value on the tenant")
+
+	// Execute the requests on a mock tenant, the deployment of the mapping fails
+	var received []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_Orders',Version='active')/$links/Configurations('Receiver Host')":
+			body, _ := io.ReadAll(r.Body)
+			received = append(received, r.Method+" "+string(body))
+			w.WriteHeader(http.StatusAccepted)
+		case "/api/v1/DeployIntegrationDesigntimeArtifact":
+			received = append(received, r.Method+" "+r.URL.RawQuery)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	manifest := &requestbundle.Manifest{Tenant: host, Requests: requests}
+	err = executeRequests(exe, manifest, false)
+	assert.ErrorContains(t, err, "request 3 (Deploy MessageMapping designtime artifact DEV_Mapping) failed, 0 request(s) not executed")
+	assert.Equal(t, []string{`PUT {"ParameterValue":"erp.example.com"}`, "POST Id='DEV_Orders'&Version='active'"}, received)
+
+	manifest.Tenant = "other.hana.ondemand.com"
+	assert.EqualError(t, executeRequests(exe, manifest, false), "request bundle was written for tenant other.hana.ondemand.com, not "+host)
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Resolutions of drifted parameters chosen with configure verify --interactive-resolve
+const (
+	ResolutionKeepTenant = "keep-tenant" // The value of the tenant is recorded in the configuration file
+	ResolutionApplyYAML  = "apply-yaml"  // The value of the configuration file is kept, to be applied by configure
+	ResolutionEditValue  = "edit-value"  // A new value is recorded in the configuration file
+	ResolutionSkipped    = "skipped"
+)
+
+// driftResolver asks the operator how to resolve each drifted parameter and records the decisions in the
+// YAML configuration files the parameters are defined in
+type driftResolver struct {
+	in     *bufio.Reader
+	out    io.Writer
+	files  []*flashpipe.ConfigFile
+	prefix string
+	docs   map[string]*yaml.Node // Parsed configuration files by path, written when changed
+	dirty  map[string]bool
+}
+
+func newDriftResolver(in io.Reader, out io.Writer, files []*flashpipe.ConfigFile, prefix string) *driftResolver {
+	return &driftResolver{in: bufio.NewReader(in), out: out, files: files, prefix: prefix,
+		docs: map[string]*yaml.Node{}, dirty: map[string]bool{}}
+}
+
+// resolve sets the Resolution of the value mismatches of deviations and returns the number of them that were
+// not resolved. Input ending early skips the remaining deviations.
+func (r *driftResolver) resolve(deviations []ConfigureDeviation) (int, error) {
+	var mismatches []int
+	for i, d := range deviations {
+		if d.Kind == DeviationValueMismatch {
+			mismatches = append(mismatches, i)
+		}
+	}
+	unresolved := 0
+	for n, i := range mismatches {
+		d := &deviations[i]
+		node, source, err := r.findValue(d)
+		if err != nil {
+			return 0, err
+		}
+		fmt.Fprintf(r.out, "\n[%d/%d] %s/%s %s\n", n+1, len(mismatches), d.PackageID, d.ArtifactID, d.Key)
+		fmt.Fprintf(r.out, "  yaml:   %s\n  tenant: %s\n", d.Expected, d.Actual)
+		if node == nil {
+			fmt.Fprintf(r.out, "  %s, resolve it in the configuration files\n", source)
+			d.Resolution = ResolutionSkipped
+			unresolved++
+			continue
+		}
+		choice, value, err := r.ask(d)
+		if err != nil {
+			return 0, err
+		}
+		d.Resolution = choice
+		switch choice {
+		case ResolutionKeepTenant, ResolutionEditValue:
+			if choice == ResolutionKeepTenant {
+				value = d.Actual
+			}
+			node.Value, node.Tag, node.Style = value, "!!str", 0
+			r.dirty[source] = true
+			fmt.Fprintf(r.out, "  Recorded %q in %s\n", value, source)
+		case ResolutionSkipped:
+			unresolved++
+		}
+	}
+	return unresolved, nil
+}
+
+// ask reads the decision for a deviation, and the new value for ResolutionEditValue
+func (r *driftResolver) ask(d *ConfigureDeviation) (string, string, error) {
+	for {
+		fmt.Fprint(r.out, "Keep [t]enant value, apply [y]aml value, [e]dit value or [s]kip? ")
+		answer, err := r.readLine()
+		if err == io.EOF {
+			return ResolutionSkipped, "", nil
+		} else if err != nil {
+			return "", "", err
+		}
+		switch strings.ToLower(answer) {
+		case "t", ResolutionKeepTenant:
+			return ResolutionKeepTenant, "", nil
+		case "y", ResolutionApplyYAML:
+			return ResolutionApplyYAML, "", nil
+		case "e", ResolutionEditValue:
+			fmt.Fprintf(r.out, "New value for %s: ", d.Key)
+			value, err := r.readLine()
+			if err == io.EOF {
+				return ResolutionSkipped, "", nil
+			} else if err != nil {
+				return "", "", err
+			}
+			return ResolutionEditValue, value, nil
+		case "s", ResolutionSkipped:
+			return ResolutionSkipped, "", nil
+		}
+	}
+}
+
+func (r *driftResolver) readLine() (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
+
+// findValue returns the value node of the parameter of the deviation in the last configuration file that sets
+// it, as later files take precedence, with the path of the file. If the value cannot be recorded, the node is
+// nil and the reason is returned instead of the path.
+func (r *driftResolver) findValue(d *ConfigureDeviation) (*yaml.Node, string, error) {
+	packageID := strings.TrimPrefix(d.PackageID, r.prefix)
+	artifactID := strings.TrimPrefix(d.ArtifactID, r.prefix)
+	for i := len(r.files) - 1; i >= 0; i-- {
+		source := r.files[i].Source
+		ext := strings.ToLower(filepath.Ext(source))
+		if ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+		doc, err := r.parse(source)
+		if err != nil {
+			return nil, "", err
+		}
+		parameter := findParameterNode(doc, packageID, artifactID, d.Key)
+		if parameter == nil {
+			continue
+		}
+		value := mappingValue(parameter, "value")
+		switch {
+		case mappingValue(parameter, "valueFrom") != nil || mappingValue(parameter, "fromFile") != nil:
+			return nil, fmt.Sprintf("Value read from an external source in %s", source), nil
+		case value == nil || value.Kind != yaml.ScalarNode:
+			return nil, fmt.Sprintf("No value set in %s", source), nil
+		case strings.Contains(value.Value, "{{") || strings.Contains(value.Value, "${"):
+			return nil, fmt.Sprintf("Value templated in %s", source), nil
+		}
+		return value, source, nil
+	}
+	return nil, "Parameter not set in a YAML configuration file, e.g. set by a parameter group or a target", nil
+}
+
+func (r *driftResolver) parse(source string) (*yaml.Node, error) {
+	if doc, ok := r.docs[source]; ok {
+		return doc, nil
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	doc := new(yaml.Node)
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	r.docs[source] = doc
+	return doc, nil
+}
+
+// findParameterNode returns the mapping node of the parameter key of an artifact in a configuration file
+func findParameterNode(doc *yaml.Node, packageID, artifactID, key string) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	var found *yaml.Node
+	for _, pkg := range sequenceItems(mappingValue(doc, "packages")) {
+		if scalarValue(pkg, "integrationSuiteId") != packageID {
+			continue
+		}
+		for _, artifact := range sequenceItems(mappingValue(pkg, "artifacts")) {
+			if scalarValue(artifact, "artifactId") != artifactID {
+				continue
+			}
+			for _, parameter := range sequenceItems(mappingValue(artifact, "parameters")) {
+				if scalarValue(parameter, "key") == key {
+					found = parameter
+				}
+			}
+		}
+	}
+	return found
+}
+
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+func scalarValue(node *yaml.Node, key string) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	if value := mappingValue(node, key); value != nil {
+		return value.Value
+	}
+	return ""
+}
+
+// write writes the configuration files with recorded decisions
+func (r *driftResolver) write() error {
+	for _, file := range r.files {
+		if !r.dirty[file.Source] {
+			continue
+		}
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(r.docs[file.Source]); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.Source, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		log.Info().Msgf("Updated %s", file.Source)
+		delete(r.dirty, file.Source)
+	}
+	return nil
+}
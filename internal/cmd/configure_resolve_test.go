@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftResolver(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	require.NoError(t, os.WriteFile(base, []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        parameters:
+          - key: Host
+            value: erp.example.com
+`), 0644))
+	prod := filepath.Join(dir, "prod.yml")
+	require.NoError(t, os.WriteFile(prod, []byte(`# Production overrides
+packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        parameters:
+          - key: Host
+            value: erp-prd.example.com # primary
+          - key: Timeout
+            value: "30"
+          - key: Token
+            value: "{{ .Values.token }}"
+`), 0644))
+	files := []*flashpipe.ConfigFile{{Source: base, FileName: "base.yml"}, {Source: prod, FileName: "prod.yml"}}
+
+	deviations := []ConfigureDeviation{
+		{Kind: DeviationValueMismatch, PackageID: "PRD_Sales", ArtifactID: "PRD_Orders", Key: "Host", Expected: "erp-prd.example.com", Actual: "erp-hotfix.example.com"},
+		{Kind: DeviationValueMismatch, PackageID: "PRD_Sales", ArtifactID: "PRD_Orders", Key: "Timeout", Expected: "30", Actual: "90"},
+		{Kind: DeviationValueMismatch, PackageID: "PRD_Sales", ArtifactID: "PRD_Orders", Key: "Token", Expected: "secret", Actual: "other"},
+		{Kind: DeviationNotStarted, PackageID: "PRD_Sales", ArtifactID: "PRD_Orders"},
+	}
+	var out bytes.Buffer
+	resolver := newDriftResolver(strings.NewReader("x\nt\ne\n60\n"), &out, files, "PRD_")
+	unresolved, err := resolver.resolve(deviations)
+	require.NoError(t, err)
+	require.NoError(t, resolver.write())
+
+	assert.Equal(t, 1, unresolved, "Templated value cannot be recorded")
+	assert.Equal(t, ResolutionKeepTenant, deviations[0].Resolution)
+	assert.Equal(t, ResolutionEditValue, deviations[1].Resolution)
+	assert.Equal(t, ResolutionSkipped, deviations[2].Resolution)
+	assert.Empty(t, deviations[3].Resolution)
+	assert.Contains(t, out.String(), "Value templated in "+prod)
+
+	data, err := os.ReadFile(prod)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "# Production overrides")
+	assert.Contains(t, content, "value: erp-hotfix.example.com # primary")
+	assert.Contains(t, content, `value: "60"`)
+	assert.Contains(t, content, `value: "{{ .Values.token }}"`)
+
+	data, err = os.ReadFile(base)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "value: erp.example.com", "Overridden file is unchanged")
+}
+
+func TestDriftResolverEndOfInput(t *testing.T) {
+	deviations := []ConfigureDeviation{{Kind: DeviationValueMismatch, PackageID: "Sales", ArtifactID: "Orders", Key: "Host"}}
+	resolver := newDriftResolver(strings.NewReader("y\n"), &bytes.Buffer{}, nil, "")
+	unresolved, err := resolver.resolve(deviations)
+	require.NoError(t, err)
+	assert.Equal(t, 1, unresolved, "Parameters not set in a file are skipped")
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(file, []byte("packages:\n  - integrationSuiteId: Sales\n    artifacts:\n      - artifactId: Orders\n        parameters:\n          - key: Host\n            value: a\n          - key: Port\n            value: b\n"), 0644))
+	deviations = []ConfigureDeviation{
+		{Kind: DeviationValueMismatch, PackageID: "Sales", ArtifactID: "Orders", Key: "Host"},
+		{Kind: DeviationValueMismatch, PackageID: "Sales", ArtifactID: "Orders", Key: "Port"},
+	}
+	resolver = newDriftResolver(strings.NewReader("y"), &bytes.Buffer{}, []*flashpipe.ConfigFile{{Source: file}}, "")
+	unresolved, err = resolver.resolve(deviations)
+	require.NoError(t, err)
+	assert.Equal(t, ResolutionApplyYAML, deviations[0].Resolution)
+	assert.Equal(t, ResolutionSkipped, deviations[1].Resolution)
+	assert.Equal(t, 1, unresolved)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// parameterRetries is the number of retries of an individual parameter update that failed with a retryable error
+const parameterRetries = 3
+
+// parameterRetryDelay is the wait before the first retry of an individual parameter update, it is doubled for each
+// further retry
+var parameterRetryDelay = 2 * time.Second
+
+// updateParameterWithRetry updates a single parameter. Updates that failed with a retryable error, e.g. a timeout
+// or status 429 or 503, are retried up to parameterRetries times with backoff, permanent errors such as an invalid
+// value or an unknown key are returned at once. Each parameter that failed at least once is recorded in stats
+// with the class of its error.
+func updateParameterWithRetry(configuration api.ConfigurationService, artifactID, version, key, value string,
+	stats *ConfigureStats, l *zerolog.Logger) error {
+
+	delay := parameterRetryDelay
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		err := configuration.Update(artifactID, version, key, value)
+		if err == nil {
+			if lastErr != nil {
+				stats.AddParameterError(flashpipe.ParameterError{ArtifactID: artifactID, Key: key, Class: flashpipe.ParameterErrorRetryable,
+					Attempts: attempt, Error: lastErr.Error(), Recovered: true})
+			}
+			return nil
+		}
+		lastErr = err
+		if !api.Retryable(err) {
+			stats.AddParameterError(flashpipe.ParameterError{ArtifactID: artifactID, Key: key, Class: flashpipe.ParameterErrorPermanent,
+				Attempts: attempt, Error: err.Error()})
+			return err
+		}
+		if attempt > parameterRetries {
+			stats.AddParameterError(flashpipe.ParameterError{ArtifactID: artifactID, Key: key, Class: flashpipe.ParameterErrorRetryable,
+				Attempts: attempt, Error: err.Error()})
+			return err
+		}
+		l.Warn().Msgf("      ⏳ Update of parameter %s failed with a retryable error, retrying in %v (%d/%d): %v", key, delay, attempt, parameterRetries, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// printParameterErrors lists the failed individual parameter updates with the class of their errors in the summary
+func printParameterErrors(stats *ConfigureStats) {
+	if len(stats.ParameterErrors) == 0 {
+		return
+	}
+	log.Info().Msg("")
+	log.Warn().Msg("Parameter errors:")
+	for _, e := range stats.ParameterErrors {
+		outcome := "failed"
+		if e.Recovered {
+			outcome = "updated"
+		}
+		log.Warn().Msgf("  %s/%s: %s after %d attempt(s), %s: %s", e.ArtifactID, e.Key, outcome, e.Attempts, e.Class, e.Error)
+	}
+}
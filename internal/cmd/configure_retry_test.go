@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateParametersIndividualRetryMock(t *testing.T) {
+	// Host is rate limited twice, Timeout has an invalid value and Queue is unavailable for all attempts
+	updates := map[string]int{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/" {
+			w.Header().Set("x-csrf-token", "token")
+			return
+		}
+		prefix := "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$links/Configurations('"
+		key := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix), "')")
+		updates[key]++
+		switch {
+		case key == "Host" && updates[key] <= 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case key == "Timeout":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":{"value":"Invalid value"}}}`))
+		case key == "Queue":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer svr.Close()
+
+	parameterRetryDelay = 0
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	params := []models.ConfigurationParameter{{Key: "Host", Value: "prod-host"}, {Key: "Timeout", Value: "abc"}, {Key: "Queue", Value: "ORDERS"}}
+
+	stats := &ConfigureStats{}
+	err := updateParametersIndividual(api.NewConfiguration(exe), "Flow", "active", params, stats, &log.Logger)
+	require.EqualError(t, err, "2 parameters failed to update")
+	assert.Equal(t, map[string]int{"Host": 3, "Timeout": 1, "Queue": parameterRetries + 1}, updates, "Only retryable errors should be retried")
+	assert.Equal(t, 1, stats.ParametersUpdated.Value())
+	assert.Equal(t, 2, stats.ParametersFailed.Value())
+	if assert.Len(t, stats.ParameterErrors, 3) {
+		assert.Equal(t, flashpipe.ParameterError{ArtifactID: "Flow", Key: "Host", Class: flashpipe.ParameterErrorRetryable, Attempts: 3,
+			Error: "Update configuration parameter Host call failed with response code = 429", Recovered: true}, stats.ParameterErrors[0])
+		assert.Equal(t, flashpipe.ParameterError{ArtifactID: "Flow", Key: "Timeout", Class: flashpipe.ParameterErrorPermanent, Attempts: 1,
+			Error: "Update configuration parameter Timeout call failed with response code = 400: Invalid value"}, stats.ParameterErrors[1])
+		assert.Equal(t, flashpipe.ParameterError{ArtifactID: "Flow", Key: "Queue", Class: flashpipe.ParameterErrorRetryable, Attempts: 4,
+			Error: "Update configuration parameter Queue call failed with response code = 503"}, stats.ParameterErrors[2])
+	}
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Rollout strategies
+const (
+	RolloutAll    = "all"
+	RolloutCanary = "canary"
+)
+
+// Handlings of a failed health check
+const (
+	HealthCheckKeep     = "keep"
+	HealthCheckRollback = "rollback"
+)
+
+// rolloutStage is a group of targets that is configured before the next group is started
+type rolloutStage struct {
+	targets      []models.ConfigureTarget
+	canary       bool
+	pauseMinutes int
+}
+
+// targetSnapshot holds the parameter values of a target before it was configured
+type targetSnapshot struct {
+	target   models.ConfigureTarget
+	previous *models.ConfigureConfig
+}
+
+// rolloutStages splits the targets into stages: one per canary step, followed by the remaining targets
+func rolloutStages(rollout *models.ConfigureRollout, targets []models.ConfigureTarget) ([]rolloutStage, error) {
+	if rollout == nil || rollout.Strategy == "" || rollout.Strategy == RolloutAll {
+		return []rolloutStage{{targets: targets}}, nil
+	}
+	if rollout.Strategy != RolloutCanary {
+		return nil, fmt.Errorf("invalid rollout strategy %s (valid strategies: %s, %s)", rollout.Strategy, RolloutAll, RolloutCanary)
+	}
+	if len(rollout.Steps) == 0 {
+		return nil, fmt.Errorf("rollout strategy %s requires at least one step", RolloutCanary)
+	}
+	if hc := rollout.HealthCheck; hc != nil && hc.OnFailure != "" && hc.OnFailure != HealthCheckKeep && hc.OnFailure != HealthCheckRollback {
+		return nil, fmt.Errorf("invalid healthCheck onFailure %s (valid values: %s, %s)", hc.OnFailure, HealthCheckKeep, HealthCheckRollback)
+	}
+
+	var stages []rolloutStage
+	remaining := slices.Clone(targets)
+	for _, step := range rollout.Steps {
+		i := slices.IndexFunc(remaining, func(t models.ConfigureTarget) bool { return t.Name == step.Tenant })
+		if i < 0 {
+			return nil, fmt.Errorf("canary tenant %s is not a selected target or is used in more than one step", step.Tenant)
+		}
+		stages = append(stages, rolloutStage{
+			targets:      []models.ConfigureTarget{remaining[i]},
+			canary:       true,
+			pauseMinutes: step.PauseMinutes,
+		})
+		remaining = slices.Delete(remaining, i, i+1)
+	}
+	if len(remaining) > 0 {
+		stages = append(stages, rolloutStage{targets: remaining})
+	}
+	return stages, nil
+}
+
+// rollout configures the stages one after the other. Canary stages are followed by a health check, and a
+// failing stage aborts the rollout, restoring the previous parameter values if rollback is enabled.
+func rollout(cfg *models.ConfigureConfig, stages []rolloutStage, parallelTenants int, opts tenantOptions) ([]targetResult, error) {
+	rolloutCfg := cfg.Rollout
+	if rolloutCfg == nil {
+		rolloutCfg = &models.ConfigureRollout{Strategy: RolloutAll}
+	}
+	rollback := rolloutCfg.Rollback && !opts.dryRun
+
+	var results []targetResult
+	var snapshots []targetSnapshot
+	var abortErr error
+	for i, stage := range stages {
+		if abortErr != nil {
+			for _, target := range stage.targets {
+				results = append(results, targetResult{Target: target, Error: fmt.Errorf("skipped, rollout aborted")})
+			}
+			continue
+		}
+		if len(stages) > 1 {
+			log.Info().Msg("")
+			log.Info().Msgf("🚦 Rollout stage %d of %d: %s", i+1, len(stages), targetNames(stage.targets))
+		}
+
+		if rollback {
+			stageSnapshots, err := snapshotTargets(cfg, stage.targets, opts)
+			if err != nil {
+				abortErr = fmt.Errorf("rollout aborted: %w", err)
+				for _, target := range stage.targets {
+					results = append(results, targetResult{Target: target, Error: err})
+				}
+				continue
+			}
+			snapshots = append(snapshots, stageSnapshots...)
+		}
+
+		// The versions running before the stage are redeployed if its health check fails
+		var running map[string]map[string]string
+		if stage.canary && rollbackOnFailure(rolloutCfg.HealthCheck) && !opts.dryRun {
+			running = runningVersionsOfTargets(cfg, stage.targets, rolloutCfg.HealthCheck, opts)
+		}
+
+		stageStart := time.Now()
+		stageResults := configureTargetGroup(cfg, stage.targets, parallelTenants, opts)
+		if stage.canary {
+			checkStageHealth(cfg, stage, stageResults, stageStart, rolloutCfg.HealthCheck, running, opts)
+			pinRolledBackVersions(snapshots, stageResults, running)
+		}
+		results = append(results, stageResults...)
+
+		for _, r := range stageResults {
+			if r.Error != nil {
+				abortErr = fmt.Errorf("rollout failed at tenant %s: %w", r.Target.Name, r.Error)
+				log.Error().Msgf("❌ %v", abortErr)
+				break
+			}
+		}
+	}
+
+	if abortErr != nil && rollback {
+		rollbackTargets(snapshots, opts)
+	}
+	return results, abortErr
+}
+
+// checkStageHealth pauses and then checks the health of each successfully configured target of the stage.
+// Unhealthy targets with running versions are rolled back to these versions.
+func checkStageHealth(cfg *models.ConfigureConfig, stage rolloutStage, results []targetResult, since time.Time,
+	healthCheck *models.ConfigureHealthCheck, running map[string]map[string]string, opts tenantOptions) {
+
+	if opts.dryRun {
+		log.Info().Msgf("[DRY RUN] Would pause %d minute(s) and check health of %s", stage.pauseMinutes, targetNames(stage.targets))
+		return
+	}
+	if stage.pauseMinutes > 0 {
+		log.Info().Msgf("⏸️  Pausing %d minute(s) before health check of %s", stage.pauseMinutes, targetNames(stage.targets))
+		time.Sleep(time.Duration(stage.pauseMinutes) * time.Minute)
+	}
+	for i := range results {
+		if results[i].Error != nil {
+			continue
+		}
+		target := results[i].Target
+		log.Info().Msgf("🩺 Checking health of tenant %s", target.Name)
+		exe := newTargetExecuter(target)
+		err := checkTargetHealth(exe, applyTargetOverrides(cfg, target), healthCheck,
+			opts.packageFilter, opts.artifactFilter, since)
+		if err != nil {
+			log.Error().Msgf("❌ Tenant %s is unhealthy: %v", target.Name, err)
+			results[i].Error = err
+			if versions, found := running[target.Name]; found {
+				results[i].RolledBack = redeployRunningVersions(exe, versions, opts)
+			}
+			continue
+		}
+		log.Info().Msgf("✅ Tenant %s is healthy", target.Name)
+	}
+}
+
+// checkTargetHealth returns an error if an integration flow of the configuration failed more messages since
+// the given time than tolerated by the health check
+func checkTargetHealth(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig, healthCheck *models.ConfigureHealthCheck,
+	packageFilter, artifactFilter []string, since time.Time) error {
+
+	if healthCheck == nil {
+		healthCheck = &models.ConfigureHealthCheck{}
+	}
+	mpl := api.NewMessageProcessingLog(exe)
+	var unhealthy []string
+	for _, id := range healthCheckedFlows(cfg, healthCheck, packageFilter, artifactFilter) {
+		failed, err := mpl.Count(id, "FAILED", since)
+		if err != nil {
+			return fmt.Errorf("health check of %s failed: %w", id, err)
+		}
+		log.Info().Msgf("   %s: %d failed message(s)", id, failed)
+		if failed > healthCheck.MaxFailedMessages {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (%d failed messages)", id, failed))
+		}
+	}
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("health check failed for %s", strings.Join(unhealthy, ", "))
+	}
+	return nil
+}
+
+// healthCheckedFlows returns the IDs of the integration flows checked by the health check, by default the
+// integration flows deployed by the configuration
+func healthCheckedFlows(cfg *models.ConfigureConfig, healthCheck *models.ConfigureHealthCheck, packageFilter, artifactFilter []string) []string {
+	var iflows []string
+	if healthCheck != nil && len(healthCheck.Artifacts) > 0 {
+		for _, id := range healthCheck.Artifacts {
+			iflows = append(iflows, cfg.DeploymentPrefix+id)
+		}
+		return iflows
+	}
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		for _, artifact := range pkg.Artifacts {
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) {
+				continue
+			}
+			if artifact.Type == "Integration" && (artifact.Deploy || pkg.Deploy) {
+				iflows = append(iflows, cfg.DeploymentPrefix+artifact.ID)
+			}
+		}
+	}
+	return iflows
+}
+
+func rollbackOnFailure(healthCheck *models.ConfigureHealthCheck) bool {
+	return healthCheck != nil && healthCheck.OnFailure == HealthCheckRollback
+}
+
+// runningVersionsOfTargets returns the versions of the health checked integration flows running on each target
+func runningVersionsOfTargets(cfg *models.ConfigureConfig, targets []models.ConfigureTarget, healthCheck *models.ConfigureHealthCheck,
+	opts tenantOptions) map[string]map[string]string {
+	running := map[string]map[string]string{}
+	for _, target := range targets {
+		iflows := healthCheckedFlows(applyTargetOverrides(cfg, target), healthCheck, opts.packageFilter, opts.artifactFilter)
+		running[target.Name] = runningVersions(newTargetExecuter(target), iflows)
+	}
+	return running
+}
+
+// runningVersions returns the started runtime versions of the integration flows. Flows that are not started
+// or whose version cannot be read are left out, as there is no version to roll back to.
+func runningVersions(exe *httpclnt.HTTPExecuter, iflows []string) map[string]string {
+	rt := api.NewRuntime(exe)
+	versions := map[string]string{}
+	for _, id := range iflows {
+		version, status, err := rt.Get(id)
+		if err != nil {
+			log.Warn().Msgf("Failed to read the running version of %s, it cannot be rolled back: %v", id, err)
+			continue
+		}
+		if status == "STARTED" {
+			versions[id] = version
+		}
+	}
+	return versions
+}
+
+// redeployRunningVersions deploys the versions that were running before the deployment again, if another
+// version is running now, and returns the IDs of the integration flows rolled back
+func redeployRunningVersions(exe *httpclnt.HTTPExecuter, versions map[string]string, opts tenantOptions) []string {
+	rt := api.NewRuntime(exe)
+	var rolledBack []string
+	for _, id := range slices.Sorted(maps.Keys(versions)) {
+		if current, status, err := rt.Get(id); err == nil && status == "STARTED" && current == versions[id] {
+			continue
+		}
+		log.Info().Msgf("↩️  Redeploying version %s of %s", versions[id], id)
+		task := DeploymentTask{ArtifactID: id, ArtifactType: "Integration", Version: versions[id]}
+		if err := deployArtifact(exe, task, opts.deployRetries, opts.deployDelaySeconds); err != nil {
+			log.Error().Msgf("❌ Rollback of %s to version %s failed: %v", id, versions[id], err)
+			continue
+		}
+		rolledBack = append(rolledBack, id)
+	}
+	return rolledBack
+}
+
+// pinRolledBackVersions sets the deployVersion of the flows rolled back after a failed health check in the
+// snapshots of their targets, so that restoring the parameter values does not deploy the active version again
+func pinRolledBackVersions(snapshots []targetSnapshot, results []targetResult, running map[string]map[string]string) {
+	for _, r := range results {
+		for _, s := range snapshots {
+			if s.target.Name != r.Target.Name {
+				continue
+			}
+			for pi := range s.previous.Packages {
+				for ai := range s.previous.Packages[pi].Artifacts {
+					artifact := &s.previous.Packages[pi].Artifacts[ai]
+					if id := s.previous.DeploymentPrefix + artifact.ID; slices.Contains(r.RolledBack, id) {
+						artifact.DeployVersion = running[r.Target.Name][id]
+					}
+				}
+			}
+		}
+	}
+}
+
+// snapshotTargets reads the current parameter values of each target
+func snapshotTargets(cfg *models.ConfigureConfig, targets []models.ConfigureTarget, opts tenantOptions) ([]targetSnapshot, error) {
+	var snapshots []targetSnapshot
+	for _, target := range targets {
+		previous, err := snapshotParameters(newTargetExecuter(target), applyTargetOverrides(cfg, target),
+			opts.packageFilter, opts.artifactFilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read current parameters of tenant %s: %w", target.Name, err)
+		}
+		snapshots = append(snapshots, targetSnapshot{target: target, previous: previous})
+	}
+	return snapshots, nil
+}
+
+// snapshotParameters returns the configuration with the parameter values currently on the tenant, without hooks.
+// Applying it restores the state before the configuration was applied.
+func snapshotParameters(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig, packageFilter, artifactFilter []string) (*models.ConfigureConfig, error) {
+	configuration := api.NewConfigurationService(exe)
+	previous := *cfg
+	previous.Hooks = nil
+	previous.Packages = nil
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		pkg.Hooks = nil
+		pkg.Artifacts = slices.Clone(pkg.Artifacts)
+		for ai := range pkg.Artifacts {
+			artifact := &pkg.Artifacts[ai]
+			artifact.Hooks = nil
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) {
+				continue
+			}
+			current, err := configuration.Get(cfg.DeploymentPrefix+artifact.ID, artifact.Version)
+			if err != nil {
+				return nil, err
+			}
+			var params []models.ConfigurationParameter
+			for _, param := range artifact.Parameters {
+				i := slices.IndexFunc(current.Root.Results, func(p *api.ParameterData) bool { return p.ParameterKey == param.Key })
+				if i >= 0 {
+					params = append(params, models.ConfigurationParameter{Key: param.Key, Value: current.Root.Results[i].ParameterValue})
+				}
+			}
+			artifact.Parameters = params
+		}
+		previous.Packages = append(previous.Packages, pkg)
+	}
+	return &previous, nil
+}
+
+// rollbackTargets restores the parameter values of the snapshots, most recently configured target first
+func rollbackTargets(snapshots []targetSnapshot, opts tenantOptions) {
+	logger.Banner(logger.SeparatorDouble, "ROLLBACK")
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		s := snapshots[i]
+		log.Info().Msgf("↩️  Rolling back tenant %s", s.target.Name)
+		rollbackOpts := opts
+		rollbackOpts.auditSnapshot = targetAuditSnapshotPath(opts.auditSnapshot, s.target.Name+"-rollback")
+		if _, err := rollbackOpts.configure(newTargetExecuter(s.target), s.previous); err != nil {
+			log.Error().Msgf("❌ Rollback of tenant %s failed: %v", s.target.Name, err)
+			continue
+		}
+		log.Info().Msgf("✅ Tenant %s rolled back", s.target.Name)
+	}
+}
+
+func targetNames(targets []models.ConfigureTarget) string {
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolloutStages(t *testing.T) {
+	targets := []models.ConfigureTarget{{Name: "qa"}, {Name: "emea"}, {Name: "apj"}}
+
+	stages, err := rolloutStages(nil, targets)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(stages), "All targets should be configured in one stage")
+
+	stages, err = rolloutStages(&models.ConfigureRollout{
+		Strategy: RolloutCanary,
+		Steps:    []models.ConfigureRolloutStep{{Tenant: "qa", PauseMinutes: 30}},
+	}, targets)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(stages), "Incorrect number of stages")
+	assert.Equal(t, "qa", targetNames(stages[0].targets), "Incorrect canary stage")
+	assert.True(t, stages[0].canary, "First stage should be a canary")
+	assert.Equal(t, 30, stages[0].pauseMinutes, "Incorrect pause")
+	assert.Equal(t, "emea, apj", targetNames(stages[1].targets), "Incorrect remaining stage")
+
+	_, err = rolloutStages(&models.ConfigureRollout{
+		Strategy: RolloutCanary,
+		Steps:    []models.ConfigureRolloutStep{{Tenant: "us"}},
+	}, targets)
+	assert.Error(t, err, "Unknown canary tenant should be an error")
+}
+
+func TestCheckTargetHealthMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/MessageProcessingLogs/$count", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("$filter"), "Id eq 'QA_Bad'") {
+			w.Write([]byte("3"))
+			return
+		}
+		w.Write([]byte("0"))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	cfg := &models.ConfigureConfig{
+		DeploymentPrefix: "QA_",
+		Packages: []models.ConfigurePackage{{
+			ID:     "Package",
+			Deploy: true,
+			Artifacts: []models.ConfigureArtifact{
+				{ID: "Good", Type: "Integration"},
+				{ID: "Bad", Type: "Integration"},
+				{ID: "Map", Type: "ValueMapping"},
+			},
+		}},
+	}
+
+	err := checkTargetHealth(exe, cfg, nil, nil, []string{"Good"}, time.Now())
+	assert.NoError(t, err, "Flow without failed messages should be healthy")
+
+	err = checkTargetHealth(exe, cfg, nil, nil, nil, time.Now())
+	assert.EqualError(t, err, "health check failed for QA_Bad (3 failed messages)")
+
+	err = checkTargetHealth(exe, cfg, &models.ConfigureHealthCheck{MaxFailedMessages: 5}, nil, nil, time.Now())
+	assert.NoError(t, err, "Failed messages within the tolerance should be healthy")
+}
+
+func TestRedeployRunningVersionsMock(t *testing.T) {
+	// QA_Orders runs version 1.0.4 after the deployment, QA_Billing was not changed and QA_New was not deployed before
+	runtime := map[string]string{"QA_Orders": "1.0.3", "QA_Billing": "2.0.0"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-csrf-token", "dummycsrfToken")
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('QA_Orders')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"d": {"Version": "` + runtime["QA_Orders"] + `", "Status": "STARTED"}}`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('QA_Billing')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"d": {"Version": "2.0.0", "Status": "STARTED"}}`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('QA_New')", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='QA_Orders',Version='1.0.3')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"d": {"Version": "1.0.3"}}`))
+	})
+	mux.HandleFunc("/api/v1/DeployIntegrationDesigntimeArtifact", func(w http.ResponseWriter, r *http.Request) {
+		runtime[strings.Trim(r.URL.Query().Get("Id"), "'")] = strings.Trim(r.URL.Query().Get("Version"), "'")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	versions := runningVersions(exe, []string{"QA_Orders", "QA_Billing", "QA_New"})
+	assert.Equal(t, map[string]string{"QA_Orders": "1.0.3", "QA_Billing": "2.0.0"}, versions, "Flows that are not deployed should be left out")
+
+	runtime["QA_Orders"] = "1.0.4"
+	rolledBack := redeployRunningVersions(exe, versions, tenantOptions{deployRetries: 1})
+	assert.Equal(t, []string{"QA_Orders"}, rolledBack, "Only flows running another version should be redeployed")
+	assert.Equal(t, "1.0.3", runtime["QA_Orders"])
+}
+
+func TestRolloutStagesOnFailure(t *testing.T) {
+	_, err := rolloutStages(&models.ConfigureRollout{
+		Strategy:    RolloutCanary,
+		Steps:       []models.ConfigureRolloutStep{{Tenant: "qa"}},
+		HealthCheck: &models.ConfigureHealthCheck{OnFailure: "revert"},
+	}, []models.ConfigureTarget{{Name: "qa"}})
+	assert.EqualError(t, err, "invalid healthCheck onFailure revert (valid values: keep, rollback)")
+}
+
+func TestPinRolledBackVersions(t *testing.T) {
+	previous := &models.ConfigureConfig{DeploymentPrefix: "QA_", Packages: []models.ConfigurePackage{{
+		ID:        "Package",
+		Artifacts: []models.ConfigureArtifact{{ID: "Orders", Deploy: true}, {ID: "Billing", Deploy: true}},
+	}}}
+	snapshots := []targetSnapshot{{target: models.ConfigureTarget{Name: "qa"}, previous: previous}}
+	results := []targetResult{{Target: models.ConfigureTarget{Name: "qa"}, RolledBack: []string{"QA_Orders"}}}
+
+	pinRolledBackVersions(snapshots, results, map[string]map[string]string{"qa": {"QA_Orders": "1.0.3", "QA_Billing": "2.0.0"}})
+	assert.Equal(t, "1.0.3", previous.Packages[0].Artifacts[0].DeployVersion, "Restoring the parameters should deploy the rolled back version")
+	assert.Empty(t, previous.Packages[0].Artifacts[1].DeployVersion, "Flows that were not rolled back should deploy the active version")
+}
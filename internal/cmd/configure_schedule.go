@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/schedule"
+	"github.com/engswee/flashpipe/internal/telemetry"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// scheduleState holds the outcome of scheduled runs, exposed via the health and metrics endpoints
+type scheduleState struct {
+	mu           sync.Mutex
+	command      string
+	expression   string
+	runsSuccess  int
+	runsFailure  int
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+	nextRun      time.Time
+}
+
+// runScheduled runs the given function whenever the schedule is due, until the process receives
+// SIGINT or SIGTERM. Failed runs are logged and reported but do not stop the schedule.
+func runScheduled(cmd *cobra.Command, command string, expression string, run func() error) error {
+	cron, err := schedule.Parse(expression)
+	if err != nil {
+		return err
+	}
+	listenAddress := config.GetStringWithFallback(cmd, "listen-address", "configure.listenAddress")
+
+	state := &scheduleState{command: command, expression: expression}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var server *http.Server
+	if listenAddress != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", state.handleHealth)
+		mux.HandleFunc("/metrics", state.handleMetrics)
+		server = &http.Server{Addr: listenAddress, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			log.Info().Msgf("Serving /healthz and /metrics on %v", listenAddress)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error().Msgf("HTTP server for health and metrics failed: %v", err)
+				stop()
+			}
+		}()
+	}
+
+	log.Info().Msgf("Running %v on schedule %q", command, expression)
+	for {
+		next := cron.Next(time.Now())
+		if next.IsZero() {
+			err = fmt.Errorf("schedule %q does not have any upcoming run", expression)
+			break
+		}
+		state.setNextRun(next)
+		log.Info().Msgf("Next %v run at %v", command, next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+			start := time.Now()
+			telemetry.StartRun(command, "flashpipe.schedule", expression)
+			runErr := run()
+			telemetry.EndRun(runErr)
+			state.recordRun(start, time.Since(start), runErr)
+			if runErr != nil {
+				log.Error().Msgf("Scheduled %v run failed: %v", command, runErr)
+			}
+			if err := telemetry.Flush(); err != nil {
+				log.Warn().Msg(err.Error())
+			}
+			continue
+		}
+		break
+	}
+
+	if server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}
+	log.Info().Msgf("Stopped scheduled %v runs", command)
+	return err
+}
+
+func (s *scheduleState) setNextRun(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextRun = next
+}
+
+func (s *scheduleState) recordRun(start time.Time, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRun = start
+	s.lastDuration = duration
+	if err != nil {
+		s.runsFailure++
+		s.lastError = err.Error()
+	} else {
+		s.runsSuccess++
+		s.lastError = ""
+	}
+}
+
+func (s *scheduleState) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	health := map[string]interface{}{
+		"status":      "UP",
+		"command":     s.command,
+		"schedule":    s.expression,
+		"nextRun":     s.nextRun.Format(time.RFC3339),
+		"runsSuccess": s.runsSuccess,
+		"runsFailure": s.runsFailure,
+	}
+	if !s.lastRun.IsZero() {
+		health["lastRun"] = s.lastRun.Format(time.RFC3339)
+		health["lastRunSuccess"] = s.lastError == ""
+	}
+	if s.lastError != "" {
+		health["lastError"] = s.lastError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(health)
+}
+
+func (s *scheduleState) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	lastSuccess := 0
+	if !s.lastRun.IsZero() && s.lastError == "" {
+		lastSuccess = 1
+	}
+	lastRun := 0.0
+	if !s.lastRun.IsZero() {
+		lastRun = float64(s.lastRun.Unix())
+	}
+	fmt.Fprintf(w, "# HELP flashpipe_scheduled_runs_total Number of scheduled runs by result.\n")
+	fmt.Fprintf(w, "# TYPE flashpipe_scheduled_runs_total counter\n")
+	fmt.Fprintf(w, "flashpipe_scheduled_runs_total{command=%q,result=\"success\"} %d\n", s.command, s.runsSuccess)
+	fmt.Fprintf(w, "flashpipe_scheduled_runs_total{command=%q,result=\"failure\"} %d\n", s.command, s.runsFailure)
+	fmt.Fprintf(w, "# HELP flashpipe_last_run_success Whether the last scheduled run succeeded.\n")
+	fmt.Fprintf(w, "# TYPE flashpipe_last_run_success gauge\n")
+	fmt.Fprintf(w, "flashpipe_last_run_success{command=%q} %d\n", s.command, lastSuccess)
+	fmt.Fprintf(w, "# HELP flashpipe_last_run_timestamp_seconds Start time of the last scheduled run.\n")
+	fmt.Fprintf(w, "# TYPE flashpipe_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(w, "flashpipe_last_run_timestamp_seconds{command=%q} %g\n", s.command, lastRun)
+	fmt.Fprintf(w, "# HELP flashpipe_last_run_duration_seconds Duration of the last scheduled run.\n")
+	fmt.Fprintf(w, "# TYPE flashpipe_last_run_duration_seconds gauge\n")
+	fmt.Fprintf(w, "flashpipe_last_run_duration_seconds{command=%q} %g\n", s.command, s.lastDuration.Seconds())
+	fmt.Fprintf(w, "# HELP flashpipe_next_run_timestamp_seconds Time of the next scheduled run.\n")
+	fmt.Fprintf(w, "# TYPE flashpipe_next_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(w, "flashpipe_next_run_timestamp_seconds{command=%q} %d\n", s.command, s.nextRun.Unix())
+
+	// Metrics of the runs, e.g. API calls and deployments
+	_ = telemetry.WritePrometheus(w)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewConfigureSetCommand() *cobra.Command {
+
+	setCmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set configuration parameters of a single artifact",
+		Long: `Set configuration parameters of a single artifact given on the command line,
+without a configuration file, e.g. for an emergency parameter change.
+
+The parameters are updated and the artifact is deployed with --deploy in
+the same way as by configure. Parameters that do not exist in the artifact
+fail the command without updating any parameter.`,
+		Example: `  # Point the receiver of an integration flow to the fallback host and deploy it
+  flashpipe configure set --artifact-id Orders_Replicate --param ReceiverHost=erp-fallback.example.com --param ReceiverPort=443 --deploy
+
+  # Show the change first
+  flashpipe configure set --artifact-id Orders_Replicate --param ReceiverHost=erp-fallback.example.com --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runConfigureSet(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	setCmd.Flags().String("artifact-id", "", "ID of the artifact (config: configure.set.artifactId)")
+	setCmd.Flags().String("artifact-type", "Integration", "Artifact type. Allowed values: Integration, MessageMapping, ScriptCollection, ValueMapping or an alias (config: configure.set.artifactType)")
+	setCmd.Flags().String("version", "active", "Version of the artifact (config: configure.set.version)")
+	setCmd.Flags().StringArray("param", nil, "Parameter to set as Key=Value, can be repeated (config: configure.set.param)")
+	setCmd.Flags().Bool("deploy", false, "Deploy the artifact after the parameters are updated (config: configure.set.deploy)")
+	setCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes (config: configure.set.dryRun)")
+	setCmd.Flags().Int("deploy-retries", 5, "Number of retries for deployment status checks (config: configure.set.deployRetries)")
+	setCmd.Flags().Int("deploy-delay", 15, "Delay in seconds between deployment status checks (config: configure.set.deployDelaySeconds)")
+	setCmd.Flags().Int("lock-retry", 0, "Number of retries with backoff if the artifact is locked by another user, starting after 30 seconds (config: configure.set.lockRetry)")
+
+	return setCmd
+}
+
+func runConfigureSet(cmd *cobra.Command) error {
+	artifactID := config.GetStringWithFallback(cmd, "artifact-id", "configure.set.artifactId")
+	artifactType := config.GetStringWithFallback(cmd, "artifact-type", "configure.set.artifactType")
+	version := config.GetStringWithFallback(cmd, "version", "configure.set.version")
+	// Values can contain commas, so the flag is not split like the string slices of other flags
+	params, _ := cmd.Flags().GetStringArray("param")
+	if !cmd.Flags().Changed("param") && viper.IsSet("configure.set.param") {
+		params = viper.GetStringSlice("configure.set.param")
+	}
+	deploy := config.GetBoolWithFallback(cmd, "deploy", "configure.set.deploy")
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "configure.set.dryRun")
+	deployRetries := config.GetIntWithFallback(cmd, "deploy-retries", "configure.set.deployRetries")
+	deployDelaySeconds := config.GetIntWithFallback(cmd, "deploy-delay", "configure.set.deployDelaySeconds")
+	lockRetries := config.GetIntWithFallback(cmd, "lock-retry", "configure.set.lockRetry")
+
+	if artifactID == "" {
+		return fmt.Errorf("--artifact-id is required (set via CLI flag or in config file under 'configure.set.artifactId')")
+	}
+	cfg, err := singleArtifactConfig(artifactID, artifactType, version, params, deploy)
+	if err != nil {
+		return err
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+	stats, err := configureTenant(exe, cfg, nil, nil, dryRun, deployRetries, deployDelaySeconds, 1, httpclnt.DefaultBatchSize,
+		false, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, lockRetries, 0, nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{})
+	if err != nil {
+		return err
+	}
+	if stats.ArtifactsFailed.Value() > 0 || stats.DeploymentTasksFailed.Value() > 0 {
+		return fmt.Errorf("failed to set parameters of %s", artifactID)
+	}
+	if stats.ArtifactsLocked.Value() > 0 {
+		return fmt.Errorf("%s skipped as locked by another user", artifactID)
+	}
+	return nil
+}
+
+// singleArtifactConfig returns the configuration of one artifact with the parameters given as Key=Value
+func singleArtifactConfig(artifactID, artifactType, version string, params []string, deploy bool) (*models.ConfigureConfig, error) {
+	if len(params) == 0 && !deploy {
+		return nil, fmt.Errorf("at least one --param or --deploy is required")
+	}
+	artifact := models.ConfigureArtifact{ID: artifactID, Type: artifactType, Version: version, Deploy: deploy}
+	for _, param := range params {
+		key, value, found := strings.Cut(param, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --param %q, expected Key=Value", param)
+		}
+		artifact.Parameters = append(artifact.Parameters, models.ConfigurationParameter{Key: strings.TrimSpace(key), Value: value})
+	}
+	cfg := &models.ConfigureConfig{Packages: []models.ConfigurePackage{{Artifacts: []models.ConfigureArtifact{artifact}}}}
+	if err := flashpipe.ResolveArtifactTypes(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleArtifactConfig(t *testing.T) {
+	cfg, err := singleArtifactConfig("Orders_Replicate", "iflow", "active", []string{"ReceiverHost=erp.example.com", "Query=$filter=a eq 'b',c"}, true)
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	require.Len(t, cfg.Packages[0].Artifacts, 1)
+	artifact := cfg.Packages[0].Artifacts[0]
+	assert.Equal(t, "Integration", artifact.Type, "Type alias should be resolved")
+	assert.True(t, artifact.Deploy)
+	assert.Equal(t, []models.ConfigurationParameter{
+		{Key: "ReceiverHost", Value: "erp.example.com"},
+		{Key: "Query", Value: "$filter=a eq 'b',c"},
+	}, artifact.Parameters, "Values should only be split at the first =")
+
+	_, err = singleArtifactConfig("Orders_Replicate", "Integration", "active", []string{"ReceiverHost"}, false)
+	assert.EqualError(t, err, `invalid --param "ReceiverHost", expected Key=Value`)
+	_, err = singleArtifactConfig("Orders_Replicate", "Integration", "active", nil, false)
+	assert.Error(t, err, "Nothing to do should be an error")
+	_, err = singleArtifactConfig("Orders_Replicate", "Flow", "active", []string{"A=B"}, false)
+	assert.ErrorContains(t, err, `invalid type "Flow"`)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// resolveParameterValueSources replaces the value of parameters that reference an external
+// source (valueFrom) with the value retrieved from that source
+func resolveParameterValueSources(cmd *cobra.Command, cfg *models.ConfigureConfig) error {
+	return resolveDestinationValues(cfg, func() (*api.Destination, error) {
+		return newDestinationFromConfig(cmd)
+	})
+}
+
+// resolveDestinationValues replaces the value of parameters with valueFrom.destination with the property of the
+// destination. The Destination service client is created with newDestination for the first such parameter.
+func resolveDestinationValues(cfg *models.ConfigureConfig, newDestination func() (*api.Destination, error)) error {
+	var destination *api.Destination
+
+	for pi := range cfg.Packages {
+		for ai := range cfg.Packages[pi].Artifacts {
+			artifact := &cfg.Packages[pi].Artifacts[ai]
+			for i := range artifact.Parameters {
+				param := &artifact.Parameters[i]
+				if param.ValueFrom == nil || param.ValueFrom.Destination == "" {
+					continue
+				}
+
+				name, property, err := api.ParseDestinationReference(param.ValueFrom.Destination)
+				if err != nil {
+					return fmt.Errorf("parameter %s of artifact %s: %w", param.Key, artifact.ID, err)
+				}
+
+				// Initialise Destination service client only when it is needed
+				if destination == nil {
+					destination, err = newDestination()
+					if err != nil {
+						return err
+					}
+				}
+
+				value, err := destination.GetProperty(name, property)
+				if err != nil {
+					return fmt.Errorf("parameter %s of artifact %s: %w", param.Key, artifact.ID, err)
+				}
+				log.Debug().Msgf("Resolved parameter %s of artifact %s from destination %s", param.Key, artifact.ID, param.ValueFrom.Destination)
+				param.Value = value
+				source := flashpipe.ValueSource{Kind: flashpipe.ValueSourceDestination, Ref: param.ValueFrom.Destination}
+				if param.Source != nil {
+					source.File, source.Line = param.Source.File, param.Source.Line
+				}
+				param.Source = &source
+			}
+		}
+	}
+	return nil
+}
+
+func newDestinationFromConfig(cmd *cobra.Command) (*api.Destination, error) {
+	host := config.GetStringWithFallback(cmd, "destination-host", "configure.destination.host")
+	oauthHost := config.GetStringWithFallback(cmd, "destination-oauth-host", "configure.destination.oauthHost")
+	oauthPath := config.GetStringWithFallback(cmd, "destination-oauth-path", "configure.destination.oauthPath")
+	clientId := config.GetStringWithFallback(cmd, "destination-clientid", "configure.destination.clientId")
+	clientSecret := config.GetStringWithFallback(cmd, "destination-clientsecret", "configure.destination.clientSecret")
+
+	if host == "" || oauthHost == "" || clientId == "" || clientSecret == "" {
+		return nil, fmt.Errorf("valueFrom.destination requires --destination-host, --destination-oauth-host, --destination-clientid and --destination-clientsecret")
+	}
+
+	exe := api.InitDestinationHTTPExecuter(host, oauthHost, oauthPath, clientId, clientSecret)
+	return api.NewDestination(exe), nil
+}
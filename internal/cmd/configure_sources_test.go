@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveDestinationValues(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "destinationConfiguration": { "Name": "S4", "URL": "https://erp.example.com:44300/sap", "sap-client": "100" } }`))
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	created := 0
+	newDestination := func() (*api.Destination, error) {
+		created++
+		return api.NewDestination(httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)), nil
+	}
+
+	cfg := &models.ConfigureConfig{Packages: []models.ConfigurePackage{{ID: "Package", Artifacts: []models.ConfigureArtifact{{
+		ID: "Flow",
+		Parameters: []models.ConfigurationParameter{
+			{Key: "Host", ValueFrom: &models.ValueFromSource{Destination: "S4#host"}, Source: &flashpipe.ValueSource{File: "config.yml", Line: 7}},
+			{Key: "Client", ValueFrom: &models.ValueFromSource{Destination: "S4#sap-client"}},
+			{Key: "Timeout", Value: "30"},
+		},
+	}}}}}
+	require.NoError(t, resolveDestinationValues(cfg, newDestination))
+	params := cfg.Packages[0].Artifacts[0].Parameters
+	assert.Equal(t, "erp.example.com", params[0].Value, "Derived host should be resolved")
+	assert.Equal(t, &flashpipe.ValueSource{Kind: flashpipe.ValueSourceDestination, Ref: "S4#host", File: "config.yml", Line: 7}, params[0].Source)
+	assert.Equal(t, "100", params[1].Value)
+	assert.Equal(t, "30", params[2].Value, "Parameters without valueFrom should be unchanged")
+	assert.Equal(t, 1, created, "The client should be created once")
+
+	// Without references the Destination service is not needed
+	created = 0
+	require.NoError(t, resolveDestinationValues(&models.ConfigureConfig{Packages: []models.ConfigurePackage{{ID: "Package", Artifacts: []models.ConfigureArtifact{{ID: "Flow",
+		Parameters: []models.ConfigurationParameter{{Key: "Timeout", Value: "30"}}}}}}}, newDestination))
+	assert.Equal(t, 0, created)
+
+	cfg.Packages[0].Artifacts[0].Parameters = []models.ConfigurationParameter{{Key: "Host", ValueFrom: &models.ValueFromSource{Destination: "S4"}}}
+	assert.EqualError(t, resolveDestinationValues(cfg, newDestination), `parameter Host of artifact Flow: invalid destination reference "S4", expected format <name>#<property>`)
+
+	cfg.Packages[0].Artifacts[0].Parameters = []models.ConfigurationParameter{{Key: "User", ValueFrom: &models.ValueFromSource{Destination: "S4#User"}}}
+	assert.EqualError(t, resolveDestinationValues(cfg, newDestination), "parameter User of artifact Flow: property User not found in destination S4")
+
+	// The credentials of the Destination service are required once a parameter references a destination
+	err := resolveParameterValueSources(&cobra.Command{}, cfg)
+	assert.ErrorContains(t, err, "valueFrom.destination requires --destination-host")
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/runid"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+)
+
+// stampLinePrefix starts the line of the package description managed by the stamp
+const stampLinePrefix = "[flashpipe] "
+
+// revisionEnvs are the environment variables with the commit of the CI/CD pipeline run, of GitHub Actions, GitLab,
+// Azure Pipelines, Bitbucket Pipelines and Jenkins
+var revisionEnvs = []string{"GITHUB_SHA", "CI_COMMIT_SHA", "BUILD_SOURCEVERSION", "BITBUCKET_COMMIT", "GIT_COMMIT"}
+
+// validateStamp rejects an invalid stamp target before anything is changed
+func validateStamp(cfg *models.ConfigureConfig) error {
+	if cfg.Stamp != nil && !slices.Contains(flashpipe.StampTargets, cfg.Stamp.Target) {
+		return fmt.Errorf("invalid stamp target %q, expected description or tag", cfg.Stamp.Target)
+	}
+	return nil
+}
+
+// stampRevision returns the revision of the stamp, or else the commit of the CI/CD pipeline run
+func stampRevision(stamp *models.ConfigureStamp) string {
+	if stamp.Revision != "" {
+		return stamp.Revision
+	}
+	for _, env := range revisionEnvs {
+		if revision := os.Getenv(env); revision != "" {
+			return revision
+		}
+	}
+	return ""
+}
+
+// stampText describes the run, e.g. 2026-10-16T10:15:00Z, run 20261016T101500Z-3f9a2c1b, revision 9fceb02
+func stampText(stamp *models.ConfigureStamp, now time.Time) string {
+	parts := []string{now.UTC().Format(time.RFC3339)}
+	if id := runid.ID(); id != "" {
+		parts = append(parts, "run "+id)
+	}
+	if revision := stampRevision(stamp); revision != "" {
+		parts = append(parts, "revision "+revision)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// stampDescription returns the description with its managed line replaced by one with text, or appended if the
+// description has none
+func stampDescription(description string, text string) string {
+	line := stampLinePrefix + "Last applied " + text
+	lines := strings.Split(description, "\n")
+	if i := slices.IndexFunc(lines, func(l string) bool { return strings.HasPrefix(l, stampLinePrefix) }); i >= 0 {
+		lines[i] = line
+		return strings.Join(lines, "\n")
+	}
+	if strings.TrimSpace(description) == "" {
+		return line
+	}
+	return strings.TrimRight(description, "\n") + "\n\n" + line
+}
+
+// stampPackages records the run on the packages with artifacts changed or deployed in the run. Packages that cannot
+// be stamped are reported as warnings, they do not fail the run.
+func stampPackages(exe *httpclnt.HTTPExecuter, stamp *models.ConfigureStamp, stats *ConfigureStats) {
+	var packageIDs []string
+	for _, result := range stats.Artifacts {
+		if result.Changed && !slices.Contains(packageIDs, result.PackageID) {
+			packageIDs = append(packageIDs, result.PackageID)
+		}
+	}
+	if len(packageIDs) == 0 {
+		return
+	}
+	slices.Sort(packageIDs)
+
+	text := stampText(stamp, time.Now())
+	ip := api.NewIntegrationPackage(exe)
+	for _, packageID := range packageIDs {
+		var err error
+		switch stamp.Target {
+		case flashpipe.StampTargetTag:
+			err = ip.UpdateCustomTag(packageID, cmp.Or(stamp.Tag, flashpipe.DefaultStampTag), text)
+		case flashpipe.StampTargetDescription:
+			err = stampPackageDescription(ip, packageID, text)
+		}
+		if err != nil {
+			log.Warn().Msgf("Failed to stamp package %s: %v", packageID, err)
+			stats.AddWarning("Package %s not stamped: %v", packageID, err)
+			continue
+		}
+		log.Info().Msgf("Stamped package %s: %s", packageID, text)
+	}
+}
+
+// stampPackageDescription replaces the managed line of the description of a package
+func stampPackageDescription(ip *api.IntegrationPackage, packageID string, text string) error {
+	packageData, readOnly, exists, err := ip.Get(packageID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("package not found")
+	}
+	if readOnly {
+		return fmt.Errorf("package is read only")
+	}
+	packageData.Root.Description = stampDescription(packageData.Root.Description, text)
+	return ip.Update(packageData)
+}
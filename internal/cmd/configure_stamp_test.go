@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/runid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStampDescription(t *testing.T) {
+	text := "2026-10-16T10:15:00Z, run 42"
+	for description, expected := range map[string]string{
+		"":                   "[flashpipe] Last applied 2026-10-16T10:15:00Z, run 42",
+		"Sales interfaces\n": "Sales interfaces\n\n[flashpipe] Last applied 2026-10-16T10:15:00Z, run 42",
+		"Sales interfaces\n\n[flashpipe] Last applied 2026-10-01T08:00:00Z, run 41\nOwner: Sales IT": "Sales interfaces\n\n[flashpipe] Last applied 2026-10-16T10:15:00Z, run 42\nOwner: Sales IT",
+	} {
+		assert.Equal(t, expected, stampDescription(description, text), description)
+	}
+}
+
+func TestStampText(t *testing.T) {
+	defer runid.Set("")
+	require.NoError(t, runid.Set("pipeline-7"))
+	for _, env := range revisionEnvs {
+		t.Setenv(env, "")
+	}
+	now := time.Date(2026, 10, 16, 12, 15, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	assert.Equal(t, "2026-10-16T10:15:00Z, run pipeline-7", stampText(&models.ConfigureStamp{}, now))
+	t.Setenv("CI_COMMIT_SHA", "9fceb02")
+	assert.Equal(t, "2026-10-16T10:15:00Z, run pipeline-7, revision 9fceb02", stampText(&models.ConfigureStamp{}, now))
+	assert.Equal(t, "2026-10-16T10:15:00Z, run pipeline-7, revision v1.4.0", stampText(&models.ConfigureStamp{Revision: "v1.4.0"}, now))
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/pipeline"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+)
+
+// targetResult is the outcome of applying the configuration to one target
+type targetResult struct {
+	Target     models.ConfigureTarget
+	Stats      *ConfigureStats
+	Error      error
+	RolledBack []string // Integration flows redeployed with their previous version after a failed health check
+}
+
+// selectConfigureTargets returns the targets with the given names, or all targets when no names are given
+func selectConfigureTargets(targets []models.ConfigureTarget, names []string) ([]models.ConfigureTarget, error) {
+	seen := map[string]bool{}
+	for _, t := range targets {
+		if t.Name == "" || t.Host == "" {
+			return nil, fmt.Errorf("targets require name and host")
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("duplicate target %s", t.Name)
+		}
+		if err := api.ValidateODataVersion(t.ODataVersion); err != nil {
+			return nil, fmt.Errorf("target %s: %w", t.Name, err)
+		}
+		seen[t.Name] = true
+	}
+	if len(names) == 0 {
+		return targets, nil
+	}
+
+	var selected []models.ConfigureTarget
+	for _, name := range names {
+		i := slices.IndexFunc(targets, func(t models.ConfigureTarget) bool { return t.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("tenant %s not found in targets of configuration", name)
+		}
+		selected = append(selected, targets[i])
+	}
+	return selected, nil
+}
+
+// applyTargetOverrides returns a copy of the configuration with the deployment prefix, parameter overrides and tenant
+// defaults of the target, without the packages and artifacts whose when condition is false for the target
+func applyTargetOverrides(cfg *models.ConfigureConfig, target models.ConfigureTarget) *models.ConfigureConfig {
+	// Errors of the conditions are reported when the configuration is loaded
+	if conditional, err := flashpipe.ApplyConditions(cfg, target.Name, target.Host); err == nil {
+		cfg = conditional
+	}
+	targetCfg := *cfg
+	if target.DeploymentPrefix != "" {
+		targetCfg.DeploymentPrefix = target.DeploymentPrefix
+	}
+	targetCfg.Packages = make([]models.ConfigurePackage, len(cfg.Packages))
+	for pi, pkg := range cfg.Packages {
+		pkg.Artifacts = slices.Clone(pkg.Artifacts)
+		for ai := range pkg.Artifacts {
+			artifact := &pkg.Artifacts[ai]
+			artifact.Parameters = slices.Clone(artifact.Parameters)
+			flashpipe.ApplyTenantDefaults(artifact, target.TenantDefaults)
+			overrides := target.Parameters[artifact.ID]
+			for _, key := range slices.Sorted(maps.Keys(overrides)) {
+				value := overrides[key]
+				i := slices.IndexFunc(artifact.Parameters, func(p models.ConfigurationParameter) bool { return p.Key == key })
+				if i < 0 {
+					artifact.Parameters = append(artifact.Parameters, models.ConfigurationParameter{Key: key, Value: value})
+				} else {
+					artifact.Parameters[i].Value = value
+					artifact.Parameters[i].Optional = false
+				}
+			}
+		}
+		targetCfg.Packages[pi] = pkg
+	}
+	return &targetCfg
+}
+
+// tenantOptions are the settings used to configure each target
+type tenantOptions struct {
+	packageFilter        []string
+	artifactFilter       []string
+	dryRun               bool
+	deployRetries        int
+	deployDelaySeconds   int
+	parallelDeployments  int
+	batchSize            int
+	disableBatch         bool
+	disableChangeset     bool
+	forceDeploy          bool
+	skipUnchanged        bool
+	cascadeRedeploy      bool
+	unknownParameters    string
+	draftHandling        string
+	parallelPackages     int
+	lockRetries          int
+	deployTimeout        time.Duration
+	approval             *deploymentApproval
+	impact               *impactGate
+	window               windowPolicy
+	pacing               pacingPolicy
+	limits               changeLimits
+	reportFile           string
+	changedArtifactsFile string
+	historyDb            string
+	auditSnapshot        string
+	preflight            bool
+}
+
+// configure configures a target and returns an error if any artifact, deployment or hook failed
+func (o tenantOptions) configure(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig) (*ConfigureStats, error) {
+	if o.preflight {
+		if err := checkPermissions(exe, configureCapabilities(cfg, o.dryRun)...); err != nil {
+			return nil, err
+		}
+	}
+	stats, err := withAuditSnapshot(exe, cfg, o.packageFilter, o.artifactFilter, o.auditSnapshot, func() (*ConfigureStats, error) {
+		return configureTenant(exe, cfg, o.packageFilter, o.artifactFilter, o.dryRun, o.deployRetries,
+			o.deployDelaySeconds, o.parallelDeployments, o.batchSize, o.disableBatch, o.disableChangeset, o.forceDeploy, o.skipUnchanged, o.cascadeRedeploy, o.unknownParameters, o.draftHandling, o.parallelPackages, o.lockRetries, o.deployTimeout, o.approval, o.impact, o.window, o.pacing, o.limits)
+	})
+	if err == nil && (stats.ArtifactsFailed.Value() > 0 || stats.DeploymentTasksFailed.Value() > 0 || stats.HooksFailed.Value() > 0) {
+		err = fmt.Errorf("configuration/deployment completed with errors")
+	} else if err == nil && stats.ArtifactsLocked.Value() > 0 {
+		err = fmt.Errorf("%d artifact(s) skipped as locked by another user", stats.ArtifactsLocked.Value())
+	}
+	return stats, err
+}
+
+// configureTargets applies the configuration to the targets in the order of the rollout, with at most
+// parallelTenants at a time
+func configureTargets(cfg *models.ConfigureConfig, targets []models.ConfigureTarget, parallelTenants int, opts tenantOptions) error {
+	for _, target := range targets {
+		if target.DeploymentPrefix != "" {
+			if err := deploy.ValidateDeploymentPrefix(target.DeploymentPrefix); err != nil {
+				return fmt.Errorf("target %s: %w", target.Name, err)
+			}
+		}
+	}
+	stages, err := rolloutStages(cfg.Rollout, targets)
+	if err != nil {
+		return err
+	}
+	if parallelTenants < 1 {
+		parallelTenants = 1
+	}
+	log.Info().Msgf("Applying configuration to %d tenant(s) with max %d in parallel", len(targets), parallelTenants)
+
+	results, rolloutErr := rollout(cfg, stages, parallelTenants, opts)
+
+	failed := printTargetsSummary(results)
+	if err := writeConfigureReport(results, opts.reportFile); err != nil {
+		log.Error().Msgf("Failed to write report: %v", err)
+	}
+	if err := writeChangedArtifacts(results, opts.changedArtifactsFile); err != nil {
+		log.Error().Msgf("Failed to write changed artifacts: %v", err)
+	}
+	recordHistory(results, opts.historyDb, opts.dryRun)
+	if rolloutErr != nil {
+		return rolloutErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("configuration failed on %d of %d tenant(s)", failed, len(targets))
+	}
+	return nil
+}
+
+// configureTargetGroup applies the configuration to each target with at most parallelTenants at a time
+func configureTargetGroup(cfg *models.ConfigureConfig, targets []models.ConfigureTarget, parallelTenants int, opts tenantOptions) []targetResult {
+	results := make([]targetResult, len(targets))
+	tasks := make([]pipeline.Task, len(targets))
+	for i, target := range targets {
+		tasks[i] = pipeline.Task{ID: fmt.Sprintf("%d:%s", i, target.Name), Run: func() error {
+			log.Info().Msg("")
+			log.Info().Msgf("🌐 Tenant: %s (%s)", target.Name, target.Host)
+
+			targetOpts := opts
+			targetOpts.auditSnapshot = targetAuditSnapshotPath(opts.auditSnapshot, target.Name)
+			stats, err := targetOpts.configure(newTargetExecuter(target), applyTargetOverrides(cfg, target))
+			if opts.dryRun {
+				printEstimate(stats, opts, target.Name)
+			}
+			results[i] = targetResult{Target: target, Stats: stats, Error: err}
+			return nil
+		}}
+	}
+	if _, err := pipeline.Run(tasks, pipeline.Options{Parallel: parallelTenants}); err != nil {
+		log.Error().Msgf("Failed to apply the configuration to the tenants: %v", err)
+	}
+	return results
+}
+
+func newTargetExecuter(target models.ConfigureTarget) *httpclnt.HTTPExecuter {
+	return api.InitHTTPExecuter(&api.ServiceDetails{
+		Host:              target.Host,
+		Userid:            os.ExpandEnv(target.UserID),
+		Password:          os.ExpandEnv(target.Password),
+		OauthHost:         target.OAuthHost,
+		OauthPath:         targetOAuthPath(target),
+		OauthClientId:     os.ExpandEnv(target.ClientID),
+		OauthClientSecret: os.ExpandEnv(target.ClientSecret),
+		ODataVersion:      target.ODataVersion,
+	})
+}
+
+func targetOAuthPath(target models.ConfigureTarget) string {
+	if target.OAuthPath == "" {
+		return "/oauth/token"
+	}
+	return target.OAuthPath
+}
+
+func printTargetsSummary(results []targetResult) (failed int) {
+	logger.Banner(logger.SeparatorDouble, "TENANTS SUMMARY")
+	log.Info().Msgf("%-20s %-10s %-10s %-10s %-10s %s", "Tenant", "Configured", "Failed", "Params", "Deployed", "Status")
+	for _, r := range results {
+		status := "✅ SUCCESS"
+		if r.Error != nil {
+			status = "❌ " + r.Error.Error()
+			failed++
+		}
+		stats := r.Stats
+		if stats == nil {
+			stats = &ConfigureStats{}
+		}
+		log.Info().Msgf("%-20s %-10d %-10d %-10d %-10d %s", r.Target.Name, stats.ArtifactsConfigured.Value(),
+			stats.ArtifactsFailed.Value(), stats.ParametersUpdated.Value(), stats.ArtifactsDeployed.Value(), status)
+	}
+	logger.Rule(logger.SeparatorDouble)
+	return failed
+}
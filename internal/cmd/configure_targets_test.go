@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTargetOverrides(t *testing.T) {
+	cfg := &models.ConfigureConfig{
+		DeploymentPrefix: "DEV_",
+		Packages: []models.ConfigurePackage{{
+			ID: "Package",
+			Artifacts: []models.ConfigureArtifact{{
+				ID:         "Flow",
+				Parameters: []models.ConfigurationParameter{{Key: "Region", Value: "default"}},
+			}},
+		}},
+	}
+	target := models.ConfigureTarget{
+		Name:             "apj",
+		DeploymentPrefix: "APJ_",
+		Parameters:       map[string]map[string]string{"Flow": {"Region": "APJ", "Timeout": "30"}},
+	}
+
+	targetCfg := applyTargetOverrides(cfg, target)
+	assert.Equal(t, "APJ_", targetCfg.DeploymentPrefix, "Incorrect deployment prefix")
+	params := targetCfg.Packages[0].Artifacts[0].Parameters
+	require.Equal(t, 2, len(params), "Incorrect number of parameters")
+	assert.Equal(t, "APJ", params[0].Value, "Parameter not overridden")
+	assert.Equal(t, "default", cfg.Packages[0].Artifacts[0].Parameters[0].Value, "Original configuration should not change")
+
+	// Tenant defaults of the target replace those of the configuration, but not the parameters of the artifact
+	cfg.Packages[0].Artifacts[0].Parameters = append(cfg.Packages[0].Artifacts[0].Parameters,
+		models.ConfigurationParameter{Key: "LogLevel", Value: "INFO", Optional: true})
+	target.TenantDefaults = []models.ConfigurationParameter{{Key: "LogLevel", Value: "ERROR"}, {Key: "Region", Value: "ASIA"}, {Key: "ProxyType", Value: "none"}}
+	params = applyTargetOverrides(cfg, target).Packages[0].Artifacts[0].Parameters
+	assert.Equal(t, []models.ConfigurationParameter{
+		{Key: "Region", Value: "APJ"},
+		{Key: "LogLevel", Value: "ERROR", Optional: true},
+		{Key: "ProxyType", Value: "none", Optional: true},
+		{Key: "Timeout", Value: "30"},
+	}, params)
+	target.TenantDefaults = nil
+
+	cfg.Packages[0].Artifacts = append(cfg.Packages[0].Artifacts, models.ConfigureArtifact{ID: "EMEA_Flow", When: `eq .Target "emea"`})
+	targetCfg = applyTargetOverrides(cfg, target)
+	assert.Len(t, targetCfg.Packages[0].Artifacts, 1, "Artifacts of other targets should be skipped")
+
+	targets := []models.ConfigureTarget{{Name: "emea", Host: "a"}, {Name: "apj", Host: "b"}}
+	selected, err := selectConfigureTargets(targets, []string{"apj"})
+	require.NoError(t, err)
+	assert.Equal(t, "apj", selected[0].Name, "Incorrect target selected")
+	_, err = selectConfigureTargets(targets, []string{"us"})
+	assert.Error(t, err, "Unknown tenant should be an error")
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateParametersBatchAtomicMock(t *testing.T) {
+	var changesets int
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "dev-host" }, { "ParameterKey": "Port", "ParameterValue": "8080" } ] } }`))
+	})
+	mux.HandleFunc("/api/v1/$batch", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		changesets = strings.Count(string(body), "Content-Type: multipart/mixed; boundary=changeset_")
+		// Failed changeset is answered with a single error response
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresponse_1")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("--batchresponse_1\r\nContent-Type: application/http\r\nContent-Transfer-Encoding: binary\r\n\r\n" +
+			"HTTP/1.1 400 Bad Request\r\nContent-Type: application/json\r\n\r\n{}" +
+			"\r\n--batchresponse_1--\r\n"))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	params := []models.ConfigurationParameter{{Key: "Host", Value: "prod-host"}, {Key: "Port", Value: "443"}}
+
+	stats := &ConfigureStats{}
+	err := updateParametersBatch(exe, newConfigurationReader(api.NewConfiguration(exe)), "Flow", "active", params, 1, true, flashpipe.BatchFallbackAll, stats, &log.Logger)
+	require.Error(t, err, "Rolled back changeset should be an error")
+	assert.Equal(t, 1, changesets, "All parameters should be sent in one changeset")
+	assert.Equal(t, 2, stats.ParametersFailed.Value(), "All parameters should be failed")
+	assert.Equal(t, 0, stats.ParametersUpdated.Value(), "No parameter should be updated")
+
+	stats = &ConfigureStats{}
+	err = updateParametersBatch(exe, newConfigurationReader(api.NewConfiguration(exe)), "Flow", "active",
+		append(params, models.ConfigurationParameter{Key: "Path", Value: "/orders"}), 90, true, flashpipe.BatchFallbackAll, stats, &log.Logger)
+	require.Error(t, err, "Missing parameter should be an error")
+	assert.Equal(t, 3, stats.ParametersFailed.Value(), "All parameters should be failed")
+}
+
+func TestDeployArtifactDeadlineMock(t *testing.T) {
+	var checks int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-csrf-token", "token")
+	})
+	mux.HandleFunc("/api/v1/DeployIntegrationDesigntimeArtifact", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('Flow')", func(w http.ResponseWriter, r *http.Request) {
+		checks++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "Id": "Flow", "Version": "1.0.0", "Status": "STARTING" } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	start := time.Now()
+	task := DeploymentTask{ArtifactID: "Flow", ArtifactType: "Integration", Deadline: time.Now().Add(100 * time.Millisecond)}
+	err := deployArtifact(exe, task, 10, 60)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deployment timed out after 1 status checks, last status STARTING")
+	assert.Equal(t, 1, checks, "Status should be checked once at the deadline")
+	assert.Less(t, time.Since(start), 10*time.Second, "Deadline should cut the delay short")
+}
+
+func TestDeployArtifactWithHooksPanic(t *testing.T) {
+	stats := &ConfigureStats{}
+	// Deploying with a nil executer panics
+	err := deployArtifactWithHooks(nil, DeploymentTask{ArtifactID: "Flow", ArtifactType: "Integration"}, nil, 1, 0, &stats.HooksFailed)
+	require.Error(t, err, "Panic should be returned as error")
+	assert.Contains(t, err.Error(), "deployment panicked")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func validateUnknownParameters(policy string) error {
+	switch policy {
+	case flashpipe.UnknownParametersWarn, flashpipe.UnknownParametersError, flashpipe.UnknownParametersIgnore:
+		return nil
+	}
+	return fmt.Errorf("invalid unknown parameter handling %q (valid values: %s, %s, %s)", policy,
+		flashpipe.UnknownParametersWarn, flashpipe.UnknownParametersError, flashpipe.UnknownParametersIgnore)
+}
+
+// checkUnknownParameters returns the parameters that exist in the artifact. Parameters that do not exist,
+// e.g. after they were renamed in the integration flow, are left out with a warning and recorded in stats
+// with warn, fail the artifact without updating any parameter with error, and are left out silently with
+// ignore. With create, they are created first, and those that cannot be created are left out with a warning
+// regardless of the policy. Optional parameters, i.e. tenant defaults, that do not exist are always left out silently.
+func checkUnknownParameters(configs *configurationReader, artifactID, version string,
+	parameters []models.ConfigurationParameter, policy string, create bool, stats *ConfigureStats, l *zerolog.Logger) ([]models.ConfigurationParameter, error) {
+
+	if len(parameters) == 0 {
+		return parameters, nil
+	}
+	current, err := configs.get(artifactID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current configuration: %w", err)
+	}
+	parameters = slices.DeleteFunc(slices.Clone(parameters), func(p models.ConfigurationParameter) bool {
+		if p.Optional && api.FindParameterByKey(p.Key, current.Root.Results) == nil {
+			l.Debug().Msgf("      Tenant default %s not found in artifact, skipping", p.Key)
+			return true
+		}
+		return false
+	})
+
+	var known []models.ConfigurationParameter
+	var unknown []string
+	for _, param := range parameters {
+		if api.FindParameterByKey(param.Key, current.Root.Results) == nil {
+			unknown = append(unknown, param.Key)
+		} else {
+			known = append(known, param)
+		}
+	}
+	if len(unknown) > 0 && create {
+		created := createParameters(configs, artifactID, version, unknown, l)
+		known, unknown = nil, nil
+		for _, param := range parameters {
+			if api.FindParameterByKey(param.Key, current.Root.Results) == nil && !slices.Contains(created, param.Key) {
+				unknown = append(unknown, param.Key)
+			} else {
+				known = append(known, param)
+			}
+		}
+		policy = flashpipe.UnknownParametersWarn
+	}
+	if len(unknown) == 0 {
+		return parameters, nil
+	}
+
+	switch policy {
+	case flashpipe.UnknownParametersIgnore:
+		l.Debug().Msgf("      Ignoring parameters not found in artifact: %s", strings.Join(unknown, ", "))
+	case flashpipe.UnknownParametersError:
+		stats.AddUnknownParameters(artifactID, unknown)
+		stats.ParametersFailed.Add(len(parameters))
+		return nil, fmt.Errorf("%d parameter(s) not found in artifact, no parameters updated: %s", len(unknown), strings.Join(unknown, ", "))
+	default:
+		stats.AddUnknownParameters(artifactID, unknown)
+		for _, key := range unknown {
+			l.Warn().Msgf("      ⚠️  Parameter %s not found in artifact, skipping", key)
+			stats.AddWarning("Parameter %s not found in artifact %s, skipped", key, artifactID)
+		}
+	}
+	return known, nil
+}
+
+// createParameters creates the parameters with an empty value, so that they are set like existing parameters
+// afterwards, e.g. in one changeset, and their change is detected for the deployment. It stops at the first
+// parameter that cannot be created, as tenants that do not support the creation reject all of them, and
+// returns the keys of the parameters created.
+func createParameters(configs *configurationReader, artifactID, version string, keys []string, l *zerolog.Logger) []string {
+	var created []string
+	for _, key := range keys {
+		if err := configs.configuration.Create(artifactID, version, key, ""); err != nil {
+			l.Warn().Msgf("      ⚠️  Parameter %s could not be created, the tenant may not support creating parameters: %v", key, err)
+			break
+		}
+		l.Info().Msgf("      Created parameter %s", key)
+		created = append(created, key)
+	}
+	if len(created) > 0 {
+		configs.forget(artifactID, version)
+	}
+	return created
+}
+
+// printUnknownParameters lists the parameters not found in the artifacts in the summary
+func printUnknownParameters(stats *ConfigureStats) {
+	if len(stats.UnknownParameters) == 0 {
+		return
+	}
+	log.Info().Msg("")
+	log.Warn().Msg("Unknown parameters (not found in artifact):")
+	artifactIDs := make([]string, 0, len(stats.UnknownParameters))
+	for id := range stats.UnknownParameters {
+		artifactIDs = append(artifactIDs, id)
+	}
+	slices.Sort(artifactIDs)
+	for _, id := range artifactIDs {
+		log.Warn().Msgf("  %s: %s", id, strings.Join(stats.UnknownParameters[id], ", "))
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUnknownParametersMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "dev-host" } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	configs := newConfigurationReader(api.NewConfiguration(exe))
+	params := []models.ConfigurationParameter{{Key: "Host", Value: "prod-host"}, {Key: "ReceiverPort", Value: "443"}}
+
+	stats := &ConfigureStats{}
+	known, err := checkUnknownParameters(configs, "Flow", "active", params, flashpipe.UnknownParametersWarn, false, stats, &log.Logger)
+	require.NoError(t, err, "Unknown parameters should only be a warning")
+	assert.Equal(t, params[:1], known, "Unknown parameter should be skipped")
+	assert.Equal(t, map[string][]string{"Flow": {"ReceiverPort"}}, stats.UnknownParameters, "Unknown parameter should be listed")
+	assert.Equal(t, 0, stats.ParametersFailed.Value(), "Skipped parameters should not be failed")
+	assert.Equal(t, []string{"Parameter ReceiverPort not found in artifact Flow, skipped"}, stats.Warnings, "Skipped parameter should be a warning")
+
+	stats = &ConfigureStats{}
+	_, err = checkUnknownParameters(configs, "Flow", "active", params, flashpipe.UnknownParametersError, false, stats, &log.Logger)
+	require.Error(t, err, "Unknown parameters should be an error")
+	assert.Equal(t, 2, stats.ParametersFailed.Value(), "All parameters should be failed")
+	assert.Equal(t, 1, len(stats.UnknownParameters), "Unknown parameter should be listed")
+
+	stats = &ConfigureStats{}
+	known, err = checkUnknownParameters(configs, "Flow", "active", params, flashpipe.UnknownParametersIgnore, false, stats, &log.Logger)
+	require.NoError(t, err, "Unknown parameters should be ignored")
+	assert.Equal(t, 1, len(known), "Unknown parameter should be skipped")
+	assert.Nil(t, stats.UnknownParameters, "Ignored parameters should not be listed")
+	assert.Empty(t, stats.Warnings, "Ignored parameters should not be a warning")
+
+	stats = &ConfigureStats{}
+	defaults := []models.ConfigurationParameter{{Key: "Host", Value: "prod-host", Optional: true}, {Key: "LogLevel", Value: "INFO", Optional: true}}
+	known, err = checkUnknownParameters(configs, "Flow", "active", defaults, flashpipe.UnknownParametersError, true, stats, &log.Logger)
+	require.NoError(t, err, "Tenant defaults not found should be skipped regardless of the policy")
+	assert.Equal(t, defaults[:1], known, "Tenant default not found should be skipped")
+	assert.Nil(t, stats.UnknownParameters, "Skipped tenant defaults should not be listed")
+
+	assert.Error(t, validateUnknownParameters("fail"), "Invalid handling should be an error")
+}
+
+func TestCheckUnknownParametersCreateMock(t *testing.T) {
+	var created []string
+	current := `{ "ParameterKey": "Host", "ParameterValue": "dev-host" }`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-csrf-token", "token")
+	})
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			created = append(created, string(body))
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ ` + current + ` ] } }`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Legacy',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ ` + current + ` ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	configs := newConfigurationReader(api.NewConfiguration(exe))
+	params := []models.ConfigurationParameter{{Key: "Host", Value: "prod-host"}, {Key: "ReceiverPort", Value: "443"}}
+
+	stats := &ConfigureStats{}
+	known, err := checkUnknownParameters(configs, "Flow", "active", params, flashpipe.UnknownParametersError, true, stats, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, params, known, "Created parameter should be set afterwards")
+	assert.Equal(t, []string{`{"ParameterKey":"ReceiverPort","ParameterValue":"","DataType":"xsd:string"}`}, created)
+	assert.Nil(t, stats.UnknownParameters, "Created parameter should not be listed")
+
+	stats = &ConfigureStats{}
+	known, err = checkUnknownParameters(configs, "Legacy", "active", params, flashpipe.UnknownParametersError, true, stats, &log.Logger)
+	require.NoError(t, err, "Parameters that cannot be created should be skipped with a warning")
+	assert.Equal(t, params[:1], known)
+	assert.Equal(t, map[string][]string{"Legacy": {"ReceiverPort"}}, stats.UnknownParameters)
+	assert.Len(t, stats.Warnings, 1)
+}
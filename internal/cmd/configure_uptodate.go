@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// configurationChanged returns true if a parameter is set to a value other than its current one. The runtime
+// only picks up changed parameters when the artifact is deployed again. If the current configuration cannot
+// be read, the configuration is treated as changed.
+func configurationChanged(configs *configurationReader, artifactID, version string, parameters []models.ConfigurationParameter, l *zerolog.Logger) bool {
+	if len(parameters) == 0 {
+		return false
+	}
+	current, err := configs.get(artifactID, version)
+	if err != nil {
+		l.Debug().Msgf("      Current configuration of %s not available: %v", artifactID, err)
+		return true
+	}
+	for _, param := range parameters {
+		existing := api.FindParameterByKey(param.Key, current.Root.Results)
+		if existing == nil || existing.ParameterValue != param.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// skipUnchangedParameters returns the parameters that are set to a value other than their current one and
+// counts the others as unchanged. If the current configuration cannot be read, all parameters are returned.
+func skipUnchangedParameters(configs *configurationReader, artifactID, version string, parameters []models.ConfigurationParameter,
+	stats *ConfigureStats, l *zerolog.Logger) []models.ConfigurationParameter {
+	current, err := configs.get(artifactID, version)
+	if err != nil {
+		l.Debug().Msgf("      Current configuration of %s not available: %v", artifactID, err)
+		return parameters
+	}
+	var changed []models.ConfigurationParameter
+	for _, param := range parameters {
+		existing := api.FindParameterByKey(param.Key, current.Root.Results)
+		if existing != nil && existing.ParameterValue == param.Value {
+			l.Debug().Msgf("      Parameter %s unchanged, skipping", param.Key)
+			stats.ParametersUnchanged.Inc()
+			continue
+		}
+		changed = append(changed, param)
+	}
+	if unchanged := len(parameters) - len(changed); unchanged > 0 {
+		l.Info().Msgf("      ⏭️  %d parameter(s) already set to the configured value, skipping them", unchanged)
+	}
+	return changed
+}
+
+// deployedUpToDate returns true if the designtime version of the artifact is already started on the runtime,
+// so that deploying it again would only restart it. Artifacts whose versions cannot be read are deployed.
+func deployedUpToDate(exe *httpclnt.HTTPExecuter, task DeploymentTask) bool {
+	dt := api.NewDesigntimeArtifact(task.ArtifactType, exe)
+	if dt == nil {
+		return false
+	}
+	version := task.Version
+	if version == "" {
+		version = "active"
+	}
+	designtimeVersion, _, exists, err := dt.Get(task.ArtifactID, version)
+	if err != nil || !exists {
+		return false
+	}
+	runtimeVersion, status, err := api.NewRuntime(exe).Get(task.ArtifactID)
+	if err != nil {
+		log.Warn().Msgf("  Failed to get runtime version of %s, deploying it: %v", task.ArtifactID, err)
+		return false
+	}
+	log.Debug().Msgf("  %s designtime version = %s, runtime version = %s (%s)", task.ArtifactID, designtimeVersion, runtimeVersion, status)
+	return runtimeVersion == designtimeVersion && status == "STARTED"
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationChangedMock(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "prod-host" } ] } }`))
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	configs := newConfigurationReader(api.NewConfiguration(exe))
+
+	assert.False(t, configurationChanged(configs, "Flow", "active", nil, &log.Logger), "No parameters should be unchanged")
+	assert.False(t, configurationChanged(configs, "Flow", "active", []models.ConfigurationParameter{{Key: "Host", Value: "prod-host"}}, &log.Logger))
+	assert.True(t, configurationChanged(configs, "Flow", "active", []models.ConfigurationParameter{{Key: "Host", Value: "dev-host"}}, &log.Logger))
+	assert.True(t, configurationChanged(configs, "Flow", "active", []models.ConfigurationParameter{{Key: "Port", Value: "443"}}, &log.Logger), "Missing parameter should be changed")
+}
+
+func TestSkipUnchangedParametersMock(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "prod-host" }, { "ParameterKey": "Port", "ParameterValue": "443" } ] } }`))
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	configs := newConfigurationReader(api.NewConfiguration(exe))
+	stats := &ConfigureStats{}
+
+	parameters := skipUnchangedParameters(configs, "Flow", "active", []models.ConfigurationParameter{
+		{Key: "Host", Value: "prod-host"},
+		{Key: "Port", Value: "8443"},
+		{Key: "Path", Value: "/orders"},
+	}, stats, &log.Logger)
+	assert.Equal(t, []models.ConfigurationParameter{{Key: "Port", Value: "8443"}, {Key: "Path", Value: "/orders"}}, parameters,
+		"Changed and unknown parameters should be kept")
+	assert.Equal(t, 1, stats.ParametersUnchanged.Value())
+}
+
+func TestDeployedUpToDateMock(t *testing.T) {
+	runtimeVersion := "1.0.1"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "Id": "Flow", "Version": "1.0.1" } }`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('Flow')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "Id": "Flow", "Version": "` + runtimeVersion + `", "Status": "STARTED" } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	task := DeploymentTask{ArtifactID: "Flow", ArtifactType: "Integration"}
+
+	assert.True(t, deployedUpToDate(exe, task), "Same version should be up to date")
+	runtimeVersion = "1.0.0"
+	assert.False(t, deployedUpToDate(exe, task), "Different version should be deployed")
+	assert.False(t, deployedUpToDate(exe, DeploymentTask{ArtifactID: "Other", ArtifactType: "Integration"}), "Missing artifact should be deployed")
+}
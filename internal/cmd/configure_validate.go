@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// validateParameterValues checks the parameters of the configuration against the metadata of the configuration
+// parameters on the tenant and returns all problems found: parameters that do not exist and values that do
+// not match the data type of the parameter
+func validateParameterValues(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig, packageFilter, artifactFilter []string) []error {
+	configs := newConfigurationReader(api.NewConfigurationService(exe))
+	configs.prefetch(cfg, packageFilter, artifactFilter, httpclnt.DefaultBatchSize)
+	return validateParameters(configs, cfg, packageFilter, artifactFilter)
+}
+
+// validateParameters checks the parameters of the configuration against the configuration parameters read
+// with configs, see validateParameterValues
+func validateParameters(configs *configurationReader, cfg *models.ConfigureConfig, packageFilter, artifactFilter []string) []error {
+	var problems []error
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		for _, artifact := range pkg.Artifacts {
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) || len(artifact.Parameters) == 0 {
+				continue
+			}
+			artifactID := cfg.DeploymentPrefix + artifact.ID
+			current, err := configs.get(artifactID, artifact.Version)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("artifact %s: %w", artifactID, err))
+				continue
+			}
+			parameters, unmatched := expandParameterKeys(artifact.Parameters, current.Root.Results)
+			for _, key := range unmatched {
+				problems = append(problems, fmt.Errorf("artifact %s, parameter %s: no parameter matches", artifactID, key))
+			}
+			for _, param := range parameters {
+				existing := api.FindParameterByKey(param.Key, current.Root.Results)
+				if existing == nil && param.Optional {
+					continue
+				}
+				if existing == nil {
+					problems = append(problems, fmt.Errorf("artifact %s, parameter %s: not found", artifactID, param.Key))
+					continue
+				}
+				if param.Mode == flashpipe.ParameterModeDelete {
+					continue
+				}
+				if err := checkParameterType(existing.DataType, param.Value); err != nil {
+					problems = append(problems, fmt.Errorf("artifact %s, parameter %s: %w", artifactID, param.Key, err))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// checkParameterType returns an error if the value is not valid for the data type of a configuration
+// parameter. Data types without restrictions on the value, e.g. xsd:string, accept any value.
+func checkParameterType(dataType string, value string) error {
+	var err error
+	switch dataType {
+	case "xsd:integer", "xsd:int", "xsd:long", "xsd:short":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "xsd:double", "xsd:decimal", "xsd:float":
+		_, err = strconv.ParseFloat(value, 64)
+	case "xsd:boolean":
+		if value != "true" && value != "false" {
+			err = fmt.Errorf("expected true or false")
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("value %q is not a valid %s", value, dataType)
+	}
+	return nil
+}
+
+// runValidateOnly validates the parameter values against each target, or the tenant of the
+// connection flags if the configuration has no targets, without changing anything
+func runValidateOnly(cmd *cobra.Command, cfg *models.ConfigureConfig, targets []models.ConfigureTarget,
+	packageFilter, artifactFilter []string) error {
+
+	var problems []error
+	if len(targets) == 0 {
+		exe := api.InitHTTPExecuter(getServiceDetailsFromViperOrCmd(cmd))
+		problems = validateParameterValues(exe, cfg, packageFilter, artifactFilter)
+	}
+	for _, target := range targets {
+		log.Info().Msgf("Validating parameters against tenant %s", target.Name)
+		for _, problem := range validateParameterValues(newTargetExecuter(target), applyTargetOverrides(cfg, target), packageFilter, artifactFilter) {
+			problems = append(problems, fmt.Errorf("tenant %s: %w", target.Name, problem))
+		}
+	}
+
+	for _, problem := range problems {
+		log.Error().Msgf("❌ %v", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("validation found %d problem(s)", len(problems))
+	}
+	log.Info().Msg("✅ All parameter values are valid")
+	return nil
+}
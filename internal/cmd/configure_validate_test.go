@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateParameterValuesMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "MaxRetries", "ParameterValue": "3", "DataType": "xsd:integer" },
+			{ "ParameterKey": "Enabled", "ParameterValue": "true", "DataType": "xsd:boolean" },
+			{ "ParameterKey": "Host", "ParameterValue": "dev-host", "DataType": "xsd:string" } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	cfg := &models.ConfigureConfig{
+		Packages: []models.ConfigurePackage{{
+			ID: "Package",
+			Artifacts: []models.ConfigureArtifact{{
+				ID:      "Flow",
+				Type:    "Integration",
+				Version: "active",
+				Parameters: []models.ConfigurationParameter{
+					{Key: "MaxRetries", Value: "five"},
+					{Key: "Enabled", Value: "yes"},
+					{Key: "Host", Value: "prod-host"},
+					{Key: "Path", Value: "/orders"},
+				},
+			}},
+		}},
+	}
+
+	problems := validateParameterValues(exe, cfg, nil, nil)
+	require.Len(t, problems, 3, "Incorrect number of problems")
+	assert.Equal(t, `artifact Flow, parameter MaxRetries: value "five" is not a valid xsd:integer`, problems[0].Error())
+	assert.Equal(t, `artifact Flow, parameter Enabled: value "yes" is not a valid xsd:boolean`, problems[1].Error())
+	assert.Equal(t, "artifact Flow, parameter Path: not found", problems[2].Error())
+}
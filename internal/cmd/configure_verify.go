@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/remote"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Deviation kinds reported by configure verify
+const (
+	DeviationMissingParameter = "missing_parameter"
+	DeviationValueMismatch    = "value_mismatch"
+	DeviationNotStarted       = "not_started"
+	DeviationVersionMismatch  = "version_mismatch"
+	DeviationError            = "error"
+)
+
+// ConfigureDeviation is a single difference between the configuration files and the tenant
+type ConfigureDeviation struct {
+	Kind       string `json:"kind"`
+	PackageID  string `json:"packageId"`
+	ArtifactID string `json:"artifactId"`
+	Key        string `json:"key,omitempty"`
+	Expected   string `json:"expected,omitempty"`
+	Actual     string `json:"actual,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Resolution string `json:"resolution,omitempty"` // Decision taken with --interactive-resolve
+}
+
+// ConfigureVerifyResult is the machine-readable output of configure verify
+type ConfigureVerifyResult struct {
+	ArtifactsChecked  int                  `json:"artifactsChecked"`
+	ParametersChecked int                  `json:"parametersChecked"`
+	Deviations        []ConfigureDeviation `json:"deviations"`
+}
+
+func NewConfigureVerifyCommand() *cobra.Command {
+
+	verifyCmd := &cobra.Command{
+		Use:         "verify",
+		Short:       "Verify tenant configuration against configuration files",
+		Annotations: map[string]string{annotationReadOnly: "true"},
+		Long: `Verify that the configuration parameters on the tenant match the YAML
+configuration files without making any changes.
+
+Only read operations are performed. The command exits with a non-zero code if
+any parameter deviates from the configuration files, or if an artifact flagged
+for deployment (deploy: true) is not in STARTED state on the runtime.
+
+Deviations are written as JSON to stdout or to --output-file.
+
+With --interactive-resolve, the operator decides for each parameter whose
+value deviates, e.g. after a hotfix made directly on the tenant, whether to
+keep the value of the tenant, apply the value of the configuration files
+with the next configure run, or edit the value. Kept and edited values are
+recorded in the YAML configuration files that set the parameters. The
+command then only fails for deviations that were skipped or cannot be
+resolved.`,
+		Example: `  # Verify tenant against the configuration files
+  flashpipe configure verify --config-path ./config/prod
+
+  # Write deviations to a file for a nightly compliance job
+  flashpipe configure verify --config-path ./config/prod --output-file deviations.json
+
+  # Reconcile the configuration files after hotfixes made on the tenant
+  flashpipe configure verify --config-path ./config/prod --interactive-resolve --output-file deviations.json
+
+  # Keep running in a container and verify every night at 03:00
+  flashpipe configure verify --config-path ./config/prod --schedule "0 3 * * *"`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			run := func() error { return runConfigureVerify(cmd) }
+			if schedule := config.GetStringWithFallback(cmd, "schedule", "configure.schedule"); schedule != "" {
+				err = runScheduled(cmd, "configure verify", schedule, run)
+			} else {
+				err = run()
+			}
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	verifyCmd.Flags().String("output-file", "", "File to write the deviations to, defaults to stdout (config: configure.verify.outputFile)")
+	verifyCmd.Flags().Bool("interactive-resolve", false, "Decide for each drifted parameter whether to keep the tenant value, apply the YAML value or edit the value, and record the decisions in the YAML files")
+
+	return verifyCmd
+}
+
+func runConfigureVerify(cmd *cobra.Command) error {
+	log.Info().Msg("Verifying artifact configuration")
+
+	configPath := config.GetStringWithFallback(cmd, "config-path", "configure.configPath")
+	deploymentPrefix := config.GetStringWithFallback(cmd, "deployment-prefix", "configure.deploymentPrefix")
+	packageFilterStr := config.GetStringWithFallback(cmd, "package-filter", "configure.packageFilter")
+	artifactFilterStr := config.GetStringWithFallback(cmd, "artifact-filter", "configure.artifactFilter")
+	outputFile := config.GetStringWithFallback(cmd, "output-file", "configure.verify.outputFile")
+	interactive := config.GetBool(cmd, "interactive-resolve")
+
+	if interactive {
+		if remote.IsRemote(configPath) || configPath == "" {
+			return fmt.Errorf("--interactive-resolve requires local configuration files in --config-path")
+		}
+		if config.GetStringWithFallback(cmd, "schedule", "configure.schedule") != "" {
+			return fmt.Errorf("--interactive-resolve cannot be combined with --schedule")
+		}
+		if packageFilterStr == "-" || artifactFilterStr == "-" {
+			return fmt.Errorf("--interactive-resolve reads the decisions from stdin, filters cannot be read from stdin")
+		}
+	}
+	packageFilterStr, artifactFilterStr, err := resolveFilters(packageFilterStr, artifactFilterStr)
+	if err != nil {
+		return err
+	}
+	packageFilter, artifactFilter := parseFilter(packageFilterStr), parseFilter(artifactFilterStr)
+
+	configPath, cleanup, err := envConfigPath(configPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if configPath == "" {
+		return fmt.Errorf("--config-path is required (set via CLI flag, in config file under 'configure.configPath' or as content in %s)", configB64Env)
+	}
+	if deploymentPrefix != "" {
+		if err := deploy.ValidateDeploymentPrefix(deploymentPrefix); err != nil {
+			return err
+		}
+	}
+
+	configData, err := loadConfigureData(cmd, configPath, deploymentPrefix)
+	if err != nil {
+		return err
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	result := verifyConfiguration(exe, configData, packageFilter, artifactFilter)
+
+	failed := len(result.Deviations)
+	if interactive && failed > 0 {
+		if failed, err = resolveDrift(cmd, configPath, configData.DeploymentPrefix, result); err != nil {
+			return err
+		}
+	}
+
+	if err = writeVerifyResult(result, outputFile); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Checked %d parameter(s) of %d artifact(s)", result.ParametersChecked, result.ArtifactsChecked)
+	if failed > 0 {
+		return fmt.Errorf("verification failed with %d deviation(s)", failed)
+	}
+	log.Info().Msg("🏆 Tenant configuration matches the configuration files")
+	return nil
+}
+
+func verifyConfiguration(exe *httpclnt.HTTPExecuter, cfg *models.ConfigureConfig, packageFilter, artifactFilter []string) *ConfigureVerifyResult {
+	result := &ConfigureVerifyResult{Deviations: []ConfigureDeviation{}}
+	configs := newConfigurationReader(api.NewConfigurationService(exe))
+	configs.prefetch(cfg, packageFilter, artifactFilter, httpclnt.DefaultBatchSize)
+	runtime := api.NewRuntime(exe)
+
+	for _, pkg := range cfg.Packages {
+		if len(packageFilter) > 0 && !shouldInclude(pkg.ID, packageFilter) {
+			continue
+		}
+		packageID := cfg.DeploymentPrefix + pkg.ID
+
+		for _, artifact := range pkg.Artifacts {
+			if len(artifactFilter) > 0 && !shouldInclude(artifact.ID, artifactFilter) {
+				continue
+			}
+			artifactID := cfg.DeploymentPrefix + artifact.ID
+			result.ArtifactsChecked++
+
+			deviation := ConfigureDeviation{PackageID: packageID, ArtifactID: artifactID}
+
+			if len(artifact.Parameters) > 0 {
+				params, err := configs.get(artifactID, artifact.Version)
+				if err != nil {
+					deviation.Kind = DeviationError
+					deviation.Message = err.Error()
+					result.Deviations = append(result.Deviations, deviation)
+					continue
+				}
+				// Patterns without match are not reported, as on apply
+				parameters, _ := expandParameterKeys(artifact.Parameters, params.Root.Results)
+				for _, param := range parameters {
+					actual := api.FindParameterByKey(param.Key, params.Root.Results)
+					if actual == nil && param.Optional {
+						// Tenant defaults are only set on the artifacts that have the parameter
+						continue
+					}
+					result.ParametersChecked++
+					d := deviation
+					d.Key = param.Key
+					d.Expected = param.Value
+					if actual == nil {
+						d.Kind = DeviationMissingParameter
+						result.Deviations = append(result.Deviations, d)
+					} else if !flashpipe.ParameterSatisfied(param, actual.ParameterValue) {
+						d.Kind = DeviationValueMismatch
+						d.Actual = actual.ParameterValue
+						result.Deviations = append(result.Deviations, d)
+					}
+				}
+			}
+
+			if artifact.Deploy || pkg.Deploy {
+				version, status, err := runtime.Get(artifactID)
+				if err != nil {
+					deviation.Kind = DeviationError
+					deviation.Message = err.Error()
+					result.Deviations = append(result.Deviations, deviation)
+					continue
+				}
+				if status != "STARTED" {
+					if version == "NOT_DEPLOYED" {
+						status = version
+					}
+					deviation.Kind = DeviationNotStarted
+					deviation.Expected = "STARTED"
+					deviation.Actual = status
+					result.Deviations = append(result.Deviations, deviation)
+				} else if artifact.DeployVersion != "" && version != artifact.DeployVersion {
+					deviation.Kind = DeviationVersionMismatch
+					deviation.Expected = artifact.DeployVersion
+					deviation.Actual = version
+					result.Deviations = append(result.Deviations, deviation)
+				}
+			}
+		}
+	}
+	return result
+}
+
+func writeVerifyResult(result *ConfigureVerifyResult, outputFile string) error {
+	var w io.Writer = os.Stdout
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// resolveDrift lets the operator resolve the value mismatches of result interactively and records the
+// decisions in the configuration files. It returns the number of deviations that remain unresolved.
+func resolveDrift(cmd *cobra.Command, configPath, prefix string, result *ConfigureVerifyResult) (int, error) {
+	configFiles, _, err := loadConfigureFiles(cmd, configPath)
+	if err != nil {
+		return 0, err
+	}
+	resolver := newDriftResolver(os.Stdin, os.Stderr, configFiles, prefix)
+	unresolved, err := resolver.resolve(result.Deviations)
+	if err != nil {
+		return 0, err
+	}
+	if err := resolver.write(); err != nil {
+		return 0, err
+	}
+	unresolved += countUnresolvable(result.Deviations)
+	log.Info().Msgf("%d of %d deviation(s) resolved", len(result.Deviations)-unresolved, len(result.Deviations))
+	return unresolved, nil
+}
+
+// countUnresolvable returns the number of deviations that cannot be resolved interactively: missing
+// parameters, artifacts not started and errors
+func countUnresolvable(deviations []ConfigureDeviation) int {
+	count := 0
+	for _, d := range deviations {
+		if d.Kind != DeviationValueMismatch {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyConfigurationMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_Flow',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "dev-host" }, { "ParameterKey": "Port", "ParameterValue": "8080" } ] } }`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('DEV_Flow')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "Id": "DEV_Flow", "Version": "1.0.0", "Status": "ERROR" } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	cfg := &models.ConfigureConfig{
+		DeploymentPrefix: "DEV_",
+		Packages: []models.ConfigurePackage{{
+			ID: "Package",
+			Artifacts: []models.ConfigureArtifact{{
+				ID:      "Flow",
+				Type:    "Integration",
+				Version: "active",
+				Deploy:  true,
+				Parameters: []models.ConfigurationParameter{
+					{Key: "Host", Value: "dev-host"},
+					{Key: "Port", Value: "443"},
+					{Key: "Path", Value: "/orders"},
+				},
+			}},
+		}},
+	}
+
+	result := verifyConfiguration(exe, cfg, nil, nil)
+	assert.Equal(t, 1, result.ArtifactsChecked, "Incorrect number of artifacts checked")
+	assert.Equal(t, 3, result.ParametersChecked, "Incorrect number of parameters checked")
+	require.Equal(t, 3, len(result.Deviations), "Incorrect number of deviations")
+	assert.Equal(t, ConfigureDeviation{Kind: DeviationValueMismatch, PackageID: "DEV_Package", ArtifactID: "DEV_Flow", Key: "Port", Expected: "443", Actual: "8080"}, result.Deviations[0])
+	assert.Equal(t, DeviationMissingParameter, result.Deviations[1].Kind, "Incorrect deviation kind")
+	assert.Equal(t, DeviationNotStarted, result.Deviations[2].Kind, "Incorrect deviation kind")
+	assert.Equal(t, "ERROR", result.Deviations[2].Actual, "Incorrect runtime status")
+}
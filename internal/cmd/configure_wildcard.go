@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog"
+)
+
+// isWildcardKey returns whether the parameter key is a pattern, in which * matches any characters and ? one
+func isWildcardKey(key string) bool {
+	return strings.ContainsAny(key, "*?")
+}
+
+// wildcardPattern returns the regular expression matching the keys of the pattern
+func wildcardPattern(key string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(key)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	return regexp.MustCompile("^" + pattern + "$")
+}
+
+// expandParameterKeys replaces the parameters whose key is a pattern such as Receiver_*_Timeout with one
+// parameter per matching key of the current configuration, in the order of the configuration. Keys set
+// explicitly take precedence over patterns, and earlier patterns over later ones. The patterns that match no
+// key are returned as unmatched.
+func expandParameterKeys(parameters []models.ConfigurationParameter, current []*api.ParameterData) ([]models.ConfigurationParameter, []string) {
+	if !slices.ContainsFunc(parameters, func(p models.ConfigurationParameter) bool { return isWildcardKey(p.Key) }) {
+		return parameters, nil
+	}
+
+	set := map[string]bool{}
+	for _, param := range parameters {
+		if !isWildcardKey(param.Key) {
+			set[param.Key] = true
+		}
+	}
+	var expanded []models.ConfigurationParameter
+	var unmatched []string
+	for _, param := range parameters {
+		if !isWildcardKey(param.Key) {
+			expanded = append(expanded, param)
+			continue
+		}
+		pattern := wildcardPattern(param.Key)
+		matched := false
+		for _, existing := range current {
+			if !pattern.MatchString(existing.ParameterKey) {
+				continue
+			}
+			matched = true
+			if set[existing.ParameterKey] {
+				continue
+			}
+			set[existing.ParameterKey] = true
+			p := param
+			p.Key = existing.ParameterKey
+			expanded = append(expanded, p)
+		}
+		if !matched {
+			unmatched = append(unmatched, param.Key)
+		}
+	}
+	return expanded, unmatched
+}
+
+// expandWildcardParameters expands the parameters with a pattern as key against the current configuration of
+// the artifact. Patterns that match no key are left out with a warning, as the artifact may not have the
+// generated parameters in every environment. The configuration is only retrieved if there is a pattern.
+func expandWildcardParameters(configs *configurationReader, artifactID, version string,
+	parameters []models.ConfigurationParameter, stats *ConfigureStats, l *zerolog.Logger) ([]models.ConfigurationParameter, error) {
+
+	if !slices.ContainsFunc(parameters, func(p models.ConfigurationParameter) bool { return isWildcardKey(p.Key) }) {
+		return parameters, nil
+	}
+	current, err := configs.get(artifactID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current configuration: %w", err)
+	}
+	expanded, unmatched := expandParameterKeys(parameters, current.Root.Results)
+	for _, key := range unmatched {
+		l.Warn().Msgf("      ⚠️  No parameter of the artifact matches %s, skipping", key)
+		stats.AddWarning("No parameter of artifact %s matches %s, skipped", artifactID, key)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandParameterKeys(t *testing.T) {
+	current := []*api.ParameterData{
+		{ParameterKey: "Receiver_Orders_Timeout"},
+		{ParameterKey: "Receiver_Billing_Timeout"},
+		{ParameterKey: "Receiver_Orders_Host"},
+		{ParameterKey: "Sender.Timeout"},
+	}
+	parameters := []models.ConfigurationParameter{
+		{Key: "Receiver_*_Timeout", Value: "60"},
+		{Key: "Receiver_Billing_Timeout", Value: "120"},
+		{Key: "Receiver_*", Value: "x", Mode: "set-if-empty"},
+		{Key: "Sender?Timeout", Value: "30"},
+		{Key: "Legacy_*", Value: "1"},
+	}
+
+	expanded, unmatched := expandParameterKeys(parameters, current)
+	assert.Equal(t, []models.ConfigurationParameter{
+		{Key: "Receiver_Orders_Timeout", Value: "60"},
+		{Key: "Receiver_Billing_Timeout", Value: "120"},
+		{Key: "Receiver_Orders_Host", Value: "x", Mode: "set-if-empty"},
+		{Key: "Sender.Timeout", Value: "30"},
+	}, expanded, "Explicit keys and earlier patterns should take precedence")
+	assert.Equal(t, []string{"Legacy_*"}, unmatched)
+
+	explicit := []models.ConfigurationParameter{{Key: "Receiver_Orders_Host", Value: "host"}}
+	expanded, unmatched = expandParameterKeys(explicit, current)
+	assert.Equal(t, explicit, expanded, "Parameters without pattern should be returned as they are")
+	assert.Empty(t, unmatched)
+}
+
+func TestWildcardPattern(t *testing.T) {
+	assert.True(t, wildcardPattern("Receiver_*_Timeout").MatchString("Receiver_Orders_Timeout"))
+	assert.True(t, wildcardPattern("Receiver_*_Timeout").MatchString("Receiver__Timeout"))
+	assert.False(t, wildcardPattern("Receiver_*_Timeout").MatchString("Receiver_Orders_Timeout_ms"))
+	assert.False(t, wildcardPattern("Address(*)").MatchString("Address"), "Regular expression characters should be literal")
+	assert.True(t, wildcardPattern("Address(*)").MatchString("Address(/orders)"))
+}
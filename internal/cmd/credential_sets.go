@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// annotationReadOnly marks commands that only read from the tenant, e.g. configure verify, which therefore use
+// the read credentials of the credentials section of the config file
+const annotationReadOnly = "flashpipe_read_only"
+
+// Credential sets of the credentials section of the config file, selected by what the command does on the tenant
+const (
+	credentialSetRead  = "read"
+	credentialSetWrite = "write"
+)
+
+// readOnlyFlags are flags with which commands that change the tenant only read from it, e.g. configure --dry-run
+var readOnlyFlags = []string{"dry-run", "validate-only", "gitops-diff"}
+
+// credentialSetFlags are the flags of the authentication that a credential set replaces
+var credentialSetFlags = []string{"tmn-userid", "tmn-password", "oauth-host", "oauth-path", "oauth-clientid", "oauth-clientsecret", "token-command"}
+
+// credentialSet returns the credential set of the command: read for commands that only read from the tenant,
+// write for all others
+func credentialSet(cmd *cobra.Command) string {
+	if cmd.Annotations[annotationReadOnly] == "true" {
+		return credentialSetRead
+	}
+	for _, name := range readOnlyFlags {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Value.Type() == "bool" && config.GetBoolWithFallback(cmd, name, config.ConfigKey(f)) {
+			return credentialSetRead
+		}
+	}
+	return credentialSetWrite
+}
+
+// credentialValue is the value of a credential flag of a set and where it came from
+type credentialValue struct {
+	value  string
+	source string
+	key    string
+}
+
+// applyCredentialSet sets the authentication flags of the command from the credential set of the command in the
+// credentials section of the config file, e.g.
+//
+//	credentials:
+//	  read:
+//	    oauth-clientid: drift-check
+//	    oauth-clientsecret: ${READ_SECRET}
+//	  write:
+//	    tenant: prod-deployer
+//
+// A set has the authentication flags, or the name of a tenant whose credentials are stored with login, or
+// both. The set replaces the authentication of the tenant flags, except for the OAuth token server of a set of
+// an OAuth client that does not have its own. Commands without a set, and commands with credentials given on
+// the command line, use the tenant flags.
+func applyCredentialSet(cmd *cobra.Command) error {
+	if cmd.Flags().Lookup("tmn-userid") == nil || isTenantOptional(cmd) {
+		return nil
+	}
+	for _, name := range append(credentialSetFlags, "tenant") {
+		if name != "oauth-path" && config.Resolve(cmd, name, "").Source == config.SourceFlag {
+			return nil
+		}
+	}
+
+	set := credentialSet(cmd)
+	prefix := "credentials." + set
+	values := map[string]credentialValue{}
+	if key := prefix + ".tenant"; viper.IsSet(key) {
+		tenant := viper.GetString(key)
+		credentials, err := readStoredCredentials(tenant)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		for name, value := range credentials.flags() {
+			// The set authenticates at the tenant of the tenant flags
+			if name != "tmn-host" && value != "" {
+				values[name] = credentialValue{value: value, source: config.SourceKeychain, key: tenant}
+			}
+		}
+	}
+	for _, name := range credentialSetFlags {
+		if key := prefix + "." + name; viper.IsSet(key) {
+			source, from := config.KeySource(key)
+			values[name] = credentialValue{value: fmt.Sprintf("%v", viper.Get(key)), source: source, key: from}
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	oauth := values["oauth-clientid"].value != ""
+	source, from := config.KeySource(prefix)
+	for _, name := range credentialSetFlags {
+		value, found := values[name]
+		if !found {
+			if name == "oauth-path" || (oauth && name == "oauth-host") {
+				continue
+			}
+			// Authentication flags that are not in the set are cleared, e.g. the OAuth client of the tenant flags
+			// for a set of a user
+			value = credentialValue{source: source, key: from}
+		}
+		if err := config.SetFlag(cmd, name, value.value, value.source, value.key); err != nil {
+			return err
+		}
+	}
+	log.Debug().Msgf("Using the %s credentials of the config file for %s", set, cmd.CommandPath())
+	return nil
+}
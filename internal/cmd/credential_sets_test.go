@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/keychain"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCredentialSet(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(func() {
+		viper.Reset()
+		config.ResetBindings()
+	})
+	defaultStore := keychain.Default
+	keychain.Default = memoryKeychain{"deployer": `{"tmnHost": "other.hana.ondemand.com", "userId": "deployer", "password": "deploy-pw"}`}
+	t.Cleanup(func() { keychain.Default = defaultStore })
+	t.Setenv("FLASHPIPE_CREDENTIALS_READ_OAUTH_CLIENTSECRET", "read-secret")
+
+	configFile := filepath.Join(t.TempDir(), "flashpipe.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`tmn-host: dev.hana.ondemand.com
+oauth-host: dev.authentication.hana.ondemand.com
+oauth-clientid: admin
+oauth-clientsecret: admin-secret
+credentials:
+  read:
+    oauth-clientid: reader
+  write:
+    tenant: deployer
+`), 0o644))
+
+	// The flags of the root command are shared by its subcommands, so each run gets its own root
+	newCommand := func(cmd *cobra.Command) *cobra.Command {
+		config.ResetBindings()
+		NewCmdRoot().AddCommand(cmd)
+		return cmd
+	}
+
+	// Read-only commands use the read set with the OAuth token server of the tenant flags
+	endpointsCmd := newCommand(NewEndpointsListCommand())
+	require.NoError(t, endpointsCmd.ParseFlags([]string{"--config", configFile}))
+	require.NoError(t, initializeConfig(endpointsCmd))
+	assert.Equal(t, "dev.authentication.hana.ondemand.com", config.GetString(endpointsCmd, "oauth-host"))
+	assert.Equal(t, "reader", config.GetString(endpointsCmd, "oauth-clientid"))
+	assert.Equal(t, "read-secret", config.GetString(endpointsCmd, "oauth-clientsecret"))
+	assert.Equal(t, config.Setting{Flag: "oauth-clientsecret", Value: "read-secret", Source: config.SourceEnv, Key: "FLASHPIPE_CREDENTIALS_READ_OAUTH_CLIENTSECRET"},
+		config.Resolve(endpointsCmd, "oauth-clientsecret", ""))
+
+	// Other commands use the write set, whose user replaces the OAuth client of the tenant flags
+	deployCmd := newCommand(NewDeployCommand())
+	require.NoError(t, deployCmd.ParseFlags([]string{"--config", configFile, "--artifact-ids", "Flow"}))
+	require.NoError(t, initializeConfig(deployCmd))
+	assert.Equal(t, "dev.hana.ondemand.com", config.GetString(deployCmd, "tmn-host"), "The host of the stored credentials should not be used")
+	assert.Equal(t, "deployer", config.GetString(deployCmd, "tmn-userid"))
+	assert.Equal(t, "deploy-pw", config.GetString(deployCmd, "tmn-password"))
+	assert.Empty(t, config.GetString(deployCmd, "oauth-host"))
+	assert.Empty(t, config.GetString(deployCmd, "oauth-clientid"))
+	assert.Equal(t, config.SourceKeychain, config.Resolve(deployCmd, "tmn-userid", "").Source)
+
+	// Credentials given on the command line take precedence over the sets
+	deployCmd = newCommand(NewDeployCommand())
+	require.NoError(t, deployCmd.ParseFlags([]string{"--config", configFile, "--oauth-clientid", "cli", "--oauth-clientsecret", "cli-secret"}))
+	require.NoError(t, initializeConfig(deployCmd))
+	assert.Equal(t, "cli", config.GetString(deployCmd, "oauth-clientid"))
+	assert.Empty(t, config.GetString(deployCmd, "tmn-userid"))
+}
+
+func TestCredentialSet(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("dry-run", false, "Dry run (config: configure.dryRun)")
+	assert.Equal(t, credentialSetWrite, credentialSet(cmd))
+	viper.Set("configure.dryRun", true)
+	assert.Equal(t, credentialSetRead, credentialSet(cmd), "Dry runs should use the read credentials")
+	assert.Equal(t, credentialSetRead, credentialSet(NewConfigureVerifyCommand()))
+}
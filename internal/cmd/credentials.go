@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewCredentialsCommand() *cobra.Command {
+
+	credentialsCmd := &cobra.Command{
+		Use:   "credentials",
+		Short: "Check credentials used by artifacts",
+		Long: `Check the credentials and key aliases used by designtime artifacts against
+the security material of the tenant.`,
+	}
+	return credentialsCmd
+}
+
+func NewCredentialsCheckCommand() *cobra.Command {
+
+	checkCmd := &cobra.Command{
+		Use:          "check",
+		Short:        "Report credential aliases missing on the tenant",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Read the credential names and key aliases referenced by the channels and
+steps of the artifacts in --dir, resolving externalized values with
+parameters.prop, and report the ones that do not exist in the security
+material of the tenant, before the artifacts are deployed.
+
+Credential names are looked up in the user credentials, OAuth2 client
+credentials and secure parameters, key aliases in the keystore entries.
+With --create-placeholders, missing credential names are created as user
+credentials with a random password, to be maintained in the Web UI.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'credentials' section. CLI flags override config file settings.`,
+		Example: `  # Check the credentials of the artifacts synced to Git
+  flashpipe credentials check --dir ./src
+
+  # Create placeholders for missing credentials
+  flashpipe credentials check --dir ./src --create-placeholders`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runCredentialsCheck(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	checkCmd.Flags().String("dir", "", "Directory of artifacts grouped into packages, or directory of artifacts of one package (config: credentials.dir)")
+	checkCmd.Flags().Bool("create-placeholders", false, "Create missing credential names as placeholder user credentials (config: credentials.createPlaceholders)")
+
+	return checkCmd
+}
+
+func runCredentialsCheck(cmd *cobra.Command) error {
+	dir := config.GetStringWithFallback(cmd, "dir", "credentials.dir")
+	createPlaceholders := config.GetBoolWithFallback(cmd, "create-placeholders", "credentials.createPlaceholders")
+
+	if dir == "" {
+		return fmt.Errorf("--dir is required (set via CLI flag or in config file under 'credentials.dir')")
+	}
+
+	serviceDetails := api.GetServiceDetails(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+	return checkCredentials(exe, dir, createPlaceholders, os.Stdout)
+}
+
+// missingCredential is a credential or key alias used by an artifact that does not exist on the tenant
+type missingCredential struct {
+	artifact   string
+	dependency designtime.Dependency
+}
+
+func (m missingCredential) isKeyAlias() bool {
+	return strings.Contains(strings.ToLower(m.dependency.Detail), "alias")
+}
+
+func checkCredentials(exe *httpclnt.HTTPExecuter, dir string, createPlaceholders bool, out io.Writer) error {
+	packages, err := designtime.Inventory(dir)
+	if err != nil {
+		return err
+	}
+
+	sm := api.NewSecurityMaterial(exe)
+	names, err := sm.CredentialNames()
+	if err != nil {
+		return err
+	}
+	aliases, err := sm.KeystoreAliases()
+	if err != nil {
+		return err
+	}
+
+	var missing []missingCredential
+	checked := 0
+	for _, pkg := range packages {
+		for _, a := range pkg.Artifacts {
+			for _, d := range a.Credentials {
+				checked++
+				m := missingCredential{artifact: a.ID, dependency: d}
+				if m.isKeyAlias() && !slices.Contains(aliases, d.Name) || !m.isKeyAlias() && !slices.Contains(names, d.Name) {
+					missing = append(missing, m)
+				}
+			}
+		}
+	}
+	if len(missing) == 0 {
+		log.Info().Msgf("All %d credential reference(s) exist on the tenant", checked)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ARTIFACT\tCHANNEL\tPROPERTY\tPARAMETER\tMISSING")
+	for _, m := range missing {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", m.artifact, m.dependency.Channel, m.dependency.Detail, m.dependency.Parameter, m.dependency.Name)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if createPlaceholders {
+		var created []string
+		missingAliases := 0
+		for _, m := range missing {
+			if m.isKeyAlias() {
+				log.Warn().Msgf("Key alias %v cannot be created as placeholder, upload the key pair or certificate to the keystore", m.dependency.Name)
+				missingAliases++
+				continue
+			}
+			if slices.Contains(created, m.dependency.Name) {
+				continue
+			}
+			description := fmt.Sprintf("Placeholder created by FlashPipe for %v", m.artifact)
+			if err = sm.CreateUserCredentialPlaceholder(m.dependency.Name, description); err != nil {
+				return err
+			}
+			created = append(created, m.dependency.Name)
+		}
+		log.Info().Msgf("Created %d placeholder user credential(s), maintain the actual credentials before deployment", len(created))
+		if missingAliases == 0 {
+			return nil
+		}
+		return fmt.Errorf("%d key alias reference(s) missing on the tenant", missingAliases)
+	}
+	return fmt.Errorf("%d credential reference(s) missing on the tenant", len(missing))
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const credentialsModel = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn2:definitions xmlns:bpmn2="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:ifl="http:///com.sap.ifl.model/Ifl.xsd" id="Definitions_1">
+    <bpmn2:collaboration id="Collaboration_1">
+        <bpmn2:messageFlow id="MessageFlow_1" name="ERP" sourceRef="ServiceTask_1" targetRef="Participant_1">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTP</value></ifl:property>
+                <ifl:property><key>credentialName</key><value>{{ERP Credential}}</value></ifl:property>
+                <ifl:property><key>privateKeyAlias</key><value>signing</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+        <bpmn2:messageFlow id="MessageFlow_2" name="CRM" sourceRef="ServiceTask_2" targetRef="Participant_2">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTP</value></ifl:property>
+                <ifl:property><key>credentialName</key><value>crm_user</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+    </bpmn2:collaboration>
+</bpmn2:definitions>
+`
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestCheckCredentialsMock(t *testing.T) {
+	dir := t.TempDir()
+	artifactDir := filepath.Join(dir, "Orders")
+	writeTestFile(t, filepath.Join(artifactDir, "META-INF", "MANIFEST.MF"), "Manifest-Version: 1.0\nBundle-SymbolicName: Orders\n")
+	writeTestFile(t, filepath.Join(artifactDir, "src", "main", "resources", "scenarioflows", "integrationflow", "Orders.iflw"), credentialsModel)
+	writeTestFile(t, filepath.Join(artifactDir, "src", "main", "resources", "parameters.prop"), "ERP\\ Credential=erp_user\n")
+
+	// Set up local server with mock HTTP responses
+	var posted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-csrf-token", "token")
+		if r.Method == http.MethodPost {
+			posted = append(posted, r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte(`{ "d": { "results": [] } }`))
+	})
+	mux.HandleFunc("/api/v1/UserCredentials", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posted = append(posted, r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte(`{ "d": { "results": [ { "Name": "crm_user" } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	var out bytes.Buffer
+	err := checkCredentials(exe, dir, false, &out)
+	assert.EqualError(t, err, "2 credential reference(s) missing on the tenant")
+	assert.Contains(t, out.String(), "ERP Credential")
+	assert.Contains(t, out.String(), "erp_user")
+	assert.Contains(t, out.String(), "signing")
+	assert.NotContains(t, out.String(), "crm_user")
+	assert.Empty(t, posted)
+
+	err = checkCredentials(exe, dir, true, &out)
+	assert.EqualError(t, err, "1 key alias reference(s) missing on the tenant")
+	require.Len(t, posted, 1)
+	assert.Equal(t, "/api/v1/UserCredentials", posted[0])
+}
@@ -1,13 +1,21 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/engswee/flashpipe/internal/analytics"
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/events"
+	"github.com/engswee/flashpipe/internal/exitreport"
+	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/engswee/flashpipe/internal/str"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
@@ -21,6 +29,14 @@ func NewDeployCommand() *cobra.Command {
 		Long: `Deploy artifact from designtime to
 runtime of SAP Integration Suite tenant.
 
+With --from-report, the artifacts deployed by a previous configure run are
+taken from its report (--report-file) instead of --artifact-ids, without
+configuring them again. Add --only-failed to re-attempt only the deployments
+that failed.
+
+With --version, a previous designtime version is deployed instead of the
+active one, e.g. to roll back an integration flow.
+
 Configuration:
   Settings can be loaded from the global config file (--config) under the
   'deploy' section. CLI flags override config file settings.`,
@@ -30,6 +46,9 @@ Configuration:
 			switch artifactType {
 			case "MessageMapping", "ScriptCollection", "Integration", "ValueMapping":
 			default:
+				if hint := api.UnsupportedArtifactTypeHint(artifactType); hint != "" {
+					return fmt.Errorf("unsupported value for --artifact-type = %v: %v", artifactType, hint)
+				}
 				return fmt.Errorf("invalid value for --artifact-type = %v", artifactType)
 			}
 			return nil
@@ -51,9 +70,14 @@ Configuration:
 	deployCmd.Flags().Int("max-check-limit", 10, "Max number of times to check for artifact deployment status (config: deploy.maxCheckLimit)")
 	// To set to false, use --compare-versions=false
 	deployCmd.Flags().Bool("compare-versions", true, "Perform version comparison of design time against runtime before deployment (config: deploy.compareVersions)")
+	deployCmd.Flags().String("version", "", "Designtime version to deploy instead of the active version, e.g. 1.0.3 to roll back (config: deploy.version)")
 	deployCmd.Flags().String("artifact-type", "Integration", "Artifact type. Allowed values: Integration, MessageMapping, ScriptCollection, ValueMapping (config: deploy.artifactType)")
+	deployCmd.Flags().String("from-report", "", "Deploy the artifacts deployed in a configure run from its report file instead of --artifact-ids (config: deploy.fromReport)")
+	deployCmd.Flags().Bool("only-failed", false, "With --from-report, only deploy the artifacts whose deployment failed, without comparing versions (config: deploy.onlyFailed)")
+	deployCmd.Flags().Bool("preflight", true, "Check the permissions of the credentials on the tenant before starting (config: deploy.preflight)")
+
+	addApprovalFlags(deployCmd)
 
-	_ = deployCmd.MarkFlagRequired("artifact-ids")
 	return deployCmd
 }
 
@@ -68,15 +92,98 @@ func runDeploy(cmd *cobra.Command) error {
 	delayLength := config.GetIntWithFallback(cmd, "delay-length", "deploy.delayLength")
 	maxCheckLimit := config.GetIntWithFallback(cmd, "max-check-limit", "deploy.maxCheckLimit")
 	compareVersions := config.GetBoolWithFallback(cmd, "compare-versions", "deploy.compareVersions")
+	fromReport := config.GetStringWithFallback(cmd, "from-report", "deploy.fromReport")
+	onlyFailed := config.GetBoolWithFallback(cmd, "only-failed", "deploy.onlyFailed")
+	version := config.GetStringWithFallback(cmd, "version", "deploy.version")
+
+	// Artifact IDs by type, in the order of the types
+	idsByType := map[string][]string{artifactType: artifactIds}
+	switch {
+	case fromReport != "":
+		if len(artifactIds) > 0 {
+			return fmt.Errorf("--artifact-ids and --from-report cannot be used together")
+		}
+		report, err := readConfigureReport(fromReport)
+		if err != nil {
+			return err
+		}
+		idsByType, err = reportDeployments(report, serviceDetails.Host, artifactType, onlyFailed)
+		if err != nil {
+			return err
+		}
+		if len(idsByType) == 0 {
+			log.Info().Msgf("No deployments to re-attempt in %s", fromReport)
+			return nil
+		}
+		// The runtime version of a failed deployment usually matches the designtime version
+		compareVersions = compareVersions && !onlyFailed
+		artifactIds = nil
+		for _, ids := range idsByType {
+			artifactIds = append(artifactIds, ids...)
+		}
+	case onlyFailed:
+		return fmt.Errorf("--only-failed requires --from-report")
+	case len(artifactIds) == 0:
+		return fmt.Errorf("--artifact-ids or --from-report is required (set via CLI flag or in config file under 'deploy.artifactIds')")
+	}
 
-	err := deployArtifacts(artifactIds, artifactType, delayLength, maxCheckLimit, compareVersions, serviceDetails)
+	deployApproval, err := newDeploymentApproval(cmd)
 	if err != nil {
 		return err
 	}
+	if err = deployApproval.approve(serviceDetails.Host, artifactIds); err != nil {
+		return err
+	}
+	if config.GetBoolWithFallback(cmd, "preflight", "deploy.preflight") {
+		err = checkPermissions(api.InitHTTPExecuter(serviceDetails), api.CapabilityReadDesigntime, api.CapabilityDeploy, api.CapabilityReadRuntime)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range slices.Sorted(maps.Keys(idsByType)) {
+		err = deployArtifacts(idsByType[t], t, version, delayLength, maxCheckLimit, compareVersions, serviceDetails)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func deployArtifacts(artifactIds []string, artifactType string, delayLength int, maxCheckLimit int, compareVersions bool, serviceDetails *api.ServiceDetails) error {
+// reportDeployments returns the IDs of the artifacts deployed on host in a configure run by artifact type,
+// only the failed ones with onlyFailed. Deployments recorded without type are of defaultType.
+func reportDeployments(report *ConfigureReport, host string, defaultType string, onlyFailed bool) (map[string][]string, error) {
+	var tenant *TenantReport
+	for i, t := range report.Tenants {
+		if t.Host == host || len(report.Tenants) == 1 {
+			tenant = &report.Tenants[i]
+			break
+		}
+	}
+	if tenant == nil {
+		return nil, fmt.Errorf("report contains no results of tenant %s", host)
+	}
+	if tenant.Stats == nil {
+		return nil, fmt.Errorf("report contains no statistics of tenant %s: %s", tenant.Tenant, tenant.Error)
+	}
+
+	idsByType := map[string][]string{}
+	for _, result := range tenant.Stats.Artifacts {
+		if result.Phase != flashpipe.PhaseDeploy || onlyFailed && result.Error == "" {
+			continue
+		}
+		artifactType := result.ArtifactType
+		if artifactType == "" {
+			artifactType = defaultType
+		}
+		if !slices.Contains(idsByType[artifactType], result.ArtifactID) {
+			idsByType[artifactType] = append(idsByType[artifactType], result.ArtifactID)
+		}
+	}
+	return idsByType, nil
+}
+
+func deployArtifacts(artifactIds []string, artifactType string, version string, delayLength int, maxCheckLimit int, compareVersions bool, serviceDetails *api.ServiceDetails) error {
 
 	// Initialise HTTP executer
 	exe := api.InitHTTPExecuter(serviceDetails)
@@ -90,11 +197,17 @@ func deployArtifacts(artifactIds []string, artifactType string, delayLength int,
 	artifactIds = str.TrimSlice(artifactIds)
 
 	// Loop and deploy each artifact
+	starts := make([]time.Time, len(artifactIds))
 	for i, id := range artifactIds {
+		starts[i] = time.Now()
 		log.Info().Msgf("Processing artifact %d - %v", i+1, id)
-		err := deploySingle(dt, rt, id, compareVersions)
+		events.Emit(events.Event{Type: events.TypeArtifactStarted, Phase: events.PhaseDeploy, Tenant: exe.Host(), ArtifactID: id, ArtifactType: artifactType})
+		err := deploySingle(dt, rt, id, version, compareVersions)
 		// TODO - PRIO1 write error wrapper - https://go.dev/blog/errors-are-values
 		if err != nil {
+			events.Artifact(events.TypeArtifactDeployed, events.PhaseDeploy, exe.Host(), "", id, 0, err)
+			postDeploymentEvent(exe, "", id, artifactType, time.Since(starts[i]), err)
+			exitreport.Add("artifactsFailed", 1)
 			return err
 		}
 	}
@@ -102,23 +215,34 @@ func deployArtifacts(artifactIds []string, artifactType string, delayLength int,
 	// Check deployment status of artifacts
 	for i, id := range artifactIds {
 		err := checkDeploymentStatus(rt, delayLength, maxCheckLimit, id)
+		events.Artifact(events.TypeArtifactDeployed, events.PhaseDeploy, exe.Host(), "", id, time.Since(starts[i]), err)
+		postDeploymentEvent(exe, "", id, artifactType, time.Since(starts[i]), err)
 		if err != nil {
+			exitreport.Add("artifactsFailed", 1)
 			return err
 		}
 		// TODO - PRIO1 write error wrapper - https://go.dev/blog/errors-are-values
 
-		log.Info().Msgf("Artifact %d - %v deployed successfully", i+1, id)
+		log.Info().Msgf("Artifact %d - %v deployed successfully%v", i+1, id, logger.Took(time.Since(starts[i])))
+		exitreport.Add("artifactsDeployed", 1)
 	}
 
 	log.Info().Msg("🏆 Artifact(s) deployment completed successfully")
 	return nil
 }
 
-func deploySingle(artifact api.DesigntimeArtifact, runtime *api.Runtime, id string, compareVersions bool) error {
-	designtimeVer, _, exists, err := artifact.Get(id, "active")
+// deploySingle deploys a version of a designtime artifact, the active version if version is empty
+func deploySingle(artifact api.DesigntimeArtifact, runtime *api.Runtime, id string, version string, compareVersions bool) error {
+	if version == "" {
+		version = "active"
+	}
+	designtimeVer, _, exists, err := artifact.Get(id, version)
 	if err != nil {
 		return err
 	}
+	if !exists && version != "active" {
+		return fmt.Errorf("Version %v of designtime artifact %v does not exist", version, id)
+	}
 	if !exists {
 		return fmt.Errorf("Designtime artifact %v does not exist", id)
 	}
@@ -136,7 +260,7 @@ func deploySingle(artifact api.DesigntimeArtifact, runtime *api.Runtime, id stri
 			log.Info().Msgf("Artifact %v with version %v already deployed. Skipping runtime deployment", id, runtimeVer)
 		} else {
 			log.Info().Msgf("🚀 Artifact previously not deployed, or versions differ. Proceeding to deploy artifact %v with version %v", id, designtimeVer)
-			err = artifact.Deploy(id)
+			err = artifact.DeployVersion(id, version)
 			if err != nil {
 				return err
 			}
@@ -144,7 +268,7 @@ func deploySingle(artifact api.DesigntimeArtifact, runtime *api.Runtime, id stri
 		}
 	} else {
 		log.Info().Msgf("🚀 Proceeding to deploy artifact %v with version %v", id, designtimeVer)
-		err = artifact.Deploy(id)
+		err = artifact.DeployVersion(id, version)
 		if err != nil {
 			return err
 		}
@@ -175,7 +299,9 @@ func checkDeploymentStatus(runtime *api.Runtime, delayLength int, maxCheckLimit
 			if err != nil {
 				return err
 			}
-			return fmt.Errorf("Artifact deployment unsuccessful, ended with status %s. Error message = %s", status, errorMessage)
+			deployErr := deploy.ClassifyError(errorMessage)
+			logRemediation(deployErr)
+			return fmt.Errorf("Artifact deployment unsuccessful, ended with status %s. Error message = %w", status, deployErr)
 		}
 		if i == (maxCheckLimit - 1) {
 			return fmt.Errorf("Artifact status remained in %s after %d checks", status, maxCheckLimit)
@@ -184,3 +310,11 @@ func checkDeploymentStatus(runtime *api.Runtime, delayLength int, maxCheckLimit
 	}
 	return nil
 }
+
+// logRemediation logs the remediation hint of a classified deployment error
+func logRemediation(err error) {
+	var deployErr *deploy.Error
+	if errors.As(err, &deployErr) && deployErr.Hint != "" {
+		log.Info().Msgf("💡 %s: %s", deployErr.Category, deployErr.Hint)
+	}
+}
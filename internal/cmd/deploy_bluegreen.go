@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/smoketest"
+	"github.com/rs/zerolog/log"
+)
+
+// blueGreenEndpointPollInterval is the time between lookups of the endpoint of the temporary copy
+var blueGreenEndpointPollInterval = 10 * time.Second
+
+// deployBlueGreen deploys a temporary copy of an integration flow, smoke tests the HTTP endpoint of the
+// copy and only then redeploys the integration flow itself. The copy is removed again in all cases.
+func deployBlueGreen(exe *httpclnt.HTTPExecuter, task DeploymentTask, maxRetries, delaySeconds int) error {
+	settings := task.BlueGreen
+	if settings == nil || settings.AddressParameter == "" {
+		return fmt.Errorf("deployStrategy %s requires blueGreen.addressParameter", DeployStrategyBlueGreen)
+	}
+	if task.Version != "" {
+		return fmt.Errorf("deployStrategy %s cannot be combined with deployVersion", DeployStrategyBlueGreen)
+	}
+	suffix := settings.TempSuffix
+	if suffix == "" {
+		suffix = "_BG"
+	}
+	tempTask := task
+	tempTask.ArtifactID = task.ArtifactID + suffix
+	tempTask.Strategy = ""
+
+	log.Info().Msgf("    Deploying temporary copy %s (strategy %s)", tempTask.ArtifactID, task.Strategy)
+	if err := createTempCopy(exe, task.ArtifactID, tempTask.ArtifactID, task.PackageID, settings.AddressParameter, suffix); err != nil {
+		return fmt.Errorf("failed to create temporary copy: %w", err)
+	}
+	defer removeTempCopy(exe, tempTask.ArtifactID)
+
+	if err := deployArtifact(exe, tempTask, maxRetries, delaySeconds); err != nil {
+		return fmt.Errorf("temporary copy %s failed to deploy: %w", tempTask.ArtifactID, err)
+	}
+
+	if settings.SmokeTest != nil {
+		endpointURL, err := findEndpointURL(api.NewServiceEndpoint(exe), tempTask.ArtifactID, maxRetries)
+		if err != nil {
+			return err
+		}
+		result, err := smoketest.Run(endpointURL, settings.SmokeTest)
+		if task.SmokeTest != nil {
+			*task.SmokeTest = *result
+		}
+		if err != nil {
+			return fmt.Errorf("smoke test of temporary copy %s failed, %s is not redeployed: %w", tempTask.ArtifactID, task.ArtifactID, err)
+		}
+		log.Info().Msgf("    Smoke test of %s passed", tempTask.ArtifactID)
+	}
+
+	task.Strategy = ""
+	return deployArtifact(exe, task, maxRetries, delaySeconds)
+}
+
+// createTempCopy creates the integration flow tempID from the current content and configuration of id,
+// with the suffix appended to the value of the address parameter so that both can be deployed at once
+func createTempCopy(exe *httpclnt.HTTPExecuter, id string, tempID string, packageID string, addressParameter string, suffix string) error {
+	workDir, err := os.MkdirTemp("", "flashpipe-bluegreen-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	dt := api.NewIntegration(exe)
+	zipFile := filepath.Join(workDir, id+".zip")
+	if err := dt.Download(zipFile, id); err != nil {
+		return err
+	}
+	artifactDir := filepath.Join(workDir, tempID)
+	if err := file.UnzipSource(zipFile, artifactDir); err != nil {
+		return err
+	}
+	manifest := filepath.Join(artifactDir, "META-INF", "MANIFEST.MF")
+	if err := deploy.UpdateManifestBundleName(manifest, tempID, tempID, manifest); err != nil {
+		return err
+	}
+
+	// Remove a copy left over from an earlier run
+	_, _, exists, err := dt.Get(tempID, "active")
+	if err != nil {
+		return err
+	}
+	if exists {
+		removeTempCopy(exe, tempID)
+	}
+	if err := dt.Create(tempID, tempID, packageID, artifactDir); err != nil {
+		return err
+	}
+
+	configuration := api.NewConfiguration(exe)
+	params, err := configuration.Get(id, "active")
+	if err != nil {
+		return err
+	}
+	addressFound := false
+	for _, param := range params.Root.Results {
+		value := param.ParameterValue
+		if param.ParameterKey == addressParameter {
+			value += suffix
+			addressFound = true
+		}
+		if err := configuration.Update(tempID, "active", param.ParameterKey, value); err != nil {
+			return err
+		}
+	}
+	if !addressFound {
+		return fmt.Errorf("address parameter %s not found in configuration of %s", addressParameter, id)
+	}
+	return nil
+}
+
+// removeTempCopy undeploys and deletes the temporary copy, failures are only logged
+func removeTempCopy(exe *httpclnt.HTTPExecuter, tempID string) {
+	rt := api.NewRuntime(exe)
+	version, _, err := rt.Get(tempID)
+	if err != nil {
+		log.Warn().Msgf("    Failed to get status of temporary copy %s: %v", tempID, err)
+	} else if version != "NOT_DEPLOYED" {
+		if err := rt.UnDeploy(tempID); err != nil {
+			log.Warn().Msgf("    Failed to undeploy temporary copy %s: %v", tempID, err)
+		}
+	}
+	if err := api.NewIntegration(exe).Delete(tempID); err != nil {
+		log.Warn().Msgf("    Failed to delete temporary copy %s: %v", tempID, err)
+	}
+}
+
+// findEndpointURL returns the URL of the first entry point of the artifact. Service endpoints are
+// registered shortly after deployment, so the lookup is repeated up to maxRetries times.
+func findEndpointURL(se *api.ServiceEndpoint, artifactID string, maxRetries int) (string, error) {
+	for i := 0; ; i++ {
+		endpoints, err := se.List()
+		if err != nil {
+			return "", err
+		}
+		for _, endpoint := range endpoints {
+			if endpoint.ArtifactId() == artifactID && len(endpoint.EntryPoints.Results) > 0 {
+				return endpoint.EntryPoints.Results[0].Url, nil
+			}
+		}
+		if i >= maxRetries {
+			return "", fmt.Errorf("no HTTP endpoint found for %s", artifactID)
+		}
+		time.Sleep(blueGreenEndpointPollInterval)
+	}
+}
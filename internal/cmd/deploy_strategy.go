@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/smoketest"
+	"github.com/rs/zerolog/log"
+)
+
+// Deployment strategies of artifacts
+const (
+	DeployStrategyInPlace   = "inPlace"
+	DeployStrategyStopStart = "stopStart"
+	DeployStrategyBlueGreen = "blueGreen"
+)
+
+// drainPollInterval is the time between checks whether queues and data stores are drained
+var drainPollInterval = 15 * time.Second
+
+// validateDeployStrategies returns an error for the first artifact with an unknown deployment strategy
+func validateDeployStrategies(cfg *models.ConfigureConfig) error {
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			switch artifact.Strategy {
+			case "", DeployStrategyInPlace, DeployStrategyStopStart:
+			case DeployStrategyBlueGreen:
+				if artifact.Type != "Integration" || artifact.BlueGreen == nil || artifact.BlueGreen.AddressParameter == "" {
+					return fmt.Errorf("deployStrategy %s of artifact %s requires type Integration and blueGreen.addressParameter",
+						artifact.Strategy, artifact.ID)
+				}
+				if artifact.BlueGreen.SmokeTest != nil {
+					if err := smoketest.Validate(artifact.BlueGreen.SmokeTest); err != nil {
+						return fmt.Errorf("artifact %s: %w", artifact.ID, err)
+					}
+				}
+			default:
+				return fmt.Errorf("invalid deployStrategy %s of artifact %s (valid strategies: %s, %s, %s)",
+					artifact.Strategy, artifact.ID, DeployStrategyInPlace, DeployStrategyStopStart, DeployStrategyBlueGreen)
+			}
+		}
+	}
+	return nil
+}
+
+// stopAndDrain undeploys the artifact of a task with the stopStart strategy and waits until its
+// JMS queues and data stores are empty, so that it can be deployed again without losing messages
+func stopAndDrain(exe *httpclnt.HTTPExecuter, task DeploymentTask) error {
+	if task.Strategy != DeployStrategyStopStart {
+		return nil
+	}
+	rt := api.NewRuntime(exe)
+	version, _, err := rt.Get(task.ArtifactID)
+	if err != nil {
+		return err
+	}
+	if version != "NOT_DEPLOYED" {
+		log.Info().Msgf("    Stopping %s before deployment (strategy %s)", task.ArtifactID, task.Strategy)
+		if err := rt.UnDeploy(task.ArtifactID); err != nil {
+			return fmt.Errorf("failed to undeploy: %w", err)
+		}
+	}
+	return waitForDrain(exe, task.ArtifactID, task.Drain)
+}
+
+// waitForDrain polls the queues and data stores of the drain check until they are empty or the timeout is reached
+func waitForDrain(exe *httpclnt.HTTPExecuter, artifactID string, drain *models.DrainCheck) error {
+	if drain == nil || (len(drain.JMSQueues)+len(drain.DataStores) == 0 && !drain.Discover) {
+		return nil
+	}
+	timeout := time.Duration(drain.TimeoutMinutes) * time.Minute
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+
+	queues, err := drainQueues(exe, artifactID, drain.JMSQueues, drain.Discover)
+	if err != nil {
+		return err
+	}
+
+	for {
+		status, err := drainStatus(exe, artifactID, queues, drain.DataStores, drain.Discover)
+		if err != nil {
+			return err
+		}
+		if status.Drained {
+			log.Info().Msgf("    Queues and data stores of %s are drained", artifactID)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not drained within %v, artifact remains undeployed: %s", timeout, status.pending())
+		}
+		log.Info().Msgf("    Waiting for %s to drain", status.pending())
+		time.Sleep(drainPollInterval)
+	}
+}
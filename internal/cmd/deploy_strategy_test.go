@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForDrainMock(t *testing.T) {
+	queueMessages := []string{"2", "1", "0"}
+	calls := 0
+
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/Queues", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "NumberOfMessages": "` + queueMessages[min(calls, 2)] + `" } ] } }`))
+		calls++
+	})
+	mux.HandleFunc("/api/v1/DataStores", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "NumberOfMessages": 0 }, { "NumberOfMessages": "0" } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	drainPollInterval = time.Millisecond
+
+	drain := &models.DrainCheck{JMSQueues: []string{"Orders"}, DataStores: []string{"Pending"}}
+	err := waitForDrain(exe, "Flow", drain)
+	assert.NoError(t, err, "Queue should be drained")
+	assert.Equal(t, 3, calls, "Queue should be checked until empty")
+
+	assert.Error(t, validateDeployStrategies(&models.ConfigureConfig{Packages: []models.ConfigurePackage{{
+		Artifacts: []models.ConfigureArtifact{{ID: "Flow", Strategy: "rolling"}},
+	}}}), "Unknown strategy should be an error")
+	assert.Error(t, validateDeployStrategies(&models.ConfigureConfig{Packages: []models.ConfigurePackage{{
+		Artifacts: []models.ConfigureArtifact{{ID: "Flow", Type: "Integration", Strategy: "blueGreen"}},
+	}}}), "blueGreen without address parameter should be an error")
+	assert.NoError(t, validateDeployStrategies(&models.ConfigureConfig{Packages: []models.ConfigurePackage{{
+		Artifacts: []models.ConfigureArtifact{{ID: "Flow", Type: "Integration", Strategy: "blueGreen",
+			BlueGreen: &models.BlueGreenSettings{AddressParameter: "Address"}}},
+	}}}))
+}
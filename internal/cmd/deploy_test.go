@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportDeployments(t *testing.T) {
+	stats := &ConfigureStats{Artifacts: []flashpipe.ArtifactResult{
+		{ArtifactID: "Flow1", Phase: flashpipe.PhaseConfigure, Error: "update failed"},
+		{ArtifactID: "Flow1", ArtifactType: "Integration", Phase: flashpipe.PhaseDeploy},
+		{ArtifactID: "Flow2", ArtifactType: "Integration", Phase: flashpipe.PhaseDeploy, Error: "deployment failed"},
+		{ArtifactID: "Mapping", ArtifactType: "ValueMapping", Phase: flashpipe.PhaseDeploy, Error: "deployment failed"},
+		{ArtifactID: "Script", Phase: flashpipe.PhaseDeploy, Error: "deployment failed"},
+	}}
+	report := &ConfigureReport{Tenants: []TenantReport{
+		{Tenant: "qa", Host: "qa.example.com", Stats: &ConfigureStats{}},
+		{Tenant: "prod", Host: "prod.example.com", Stats: stats},
+	}}
+
+	ids, err := reportDeployments(report, "prod.example.com", "ScriptCollection", true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"Integration":      {"Flow2"},
+		"ValueMapping":     {"Mapping"},
+		"ScriptCollection": {"Script"},
+	}, ids, "Only failed deployments should be returned, without type as the default type")
+
+	ids, err = reportDeployments(report, "prod.example.com", "Integration", false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"Integration": {"Flow1", "Flow2", "Script"}, "ValueMapping": {"Mapping"}}, ids)
+
+	ids, err = reportDeployments(report, "qa.example.com", "Integration", true)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = reportDeployments(report, "dev.example.com", "Integration", true)
+	assert.EqualError(t, err, "report contains no results of tenant dev.example.com")
+}
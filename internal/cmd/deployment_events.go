@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/calm"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+)
+
+// postDeploymentEvent posts the deployment of an artifact to Cloud ALM, if enabled, with the version of the
+// designtime artifact that was deployed
+func postDeploymentEvent(exe *httpclnt.HTTPExecuter, packageID string, artifactID string, artifactType string, duration time.Duration, err error) {
+	if !calm.Enabled() {
+		return
+	}
+	deployment := calm.Deployment{Tenant: exe.Host(), PackageID: packageID, ArtifactID: artifactID, ArtifactType: artifactType, Duration: duration, Err: err}
+	if version, _, exists, getErr := api.NewDesigntimeArtifact(artifactType, exe).Get(artifactID, "active"); getErr == nil && exists {
+		deployment.Version = version
+	}
+	calm.PostDeployment(deployment)
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// ArtifactDocument is an artifact as passed to the template of docs generate
+type ArtifactDocument struct {
+	Package   string
+	Generated time.Time
+	*designtime.ArtifactDocumentation
+}
+
+const artifactDocumentTemplate = `# {{ with .Name }}{{ md . }}{{ else }}{{ .ID }}{{ end }}
+
+| ID | Type | Version | Package |
+| --- | --- | --- | --- |
+| {{ .ID }} | {{ .Type }} | {{ .Version }} | {{ .Package }} |
+
+Generated: {{ timestamp .Generated }}
+
+## Channels
+{{ if .Channels }}
+| Channel | Direction | System | Adapter | Endpoint |
+| --- | --- | --- | --- | --- |
+{{ range .Channels }}| {{ md .Name }} | {{ .Direction }} | {{ md .System }} | {{ .Adapter }} {{ .AdapterVersion }} | {{ md .Endpoint }}{{ with .Parameter }} ({{ md . }}){{ end }} |
+{{ end }}{{ else }}
+No channels.
+{{ end }}
+## Parameters
+{{ if .Parameters }}
+| Parameter | Value | Type | Required | Used in | Description |
+| --- | --- | --- | --- | --- | --- |
+{{ range .Parameters }}| {{ md .Key }} | {{ md .Default }} | {{ .Type }} | {{ if .Required }}yes{{ end }} | {{ md (join .Channels ", ") }} | {{ md .Description }} |
+{{ end }}{{ else }}
+No externalized parameters.
+{{ end }}`
+
+func NewDocsCommand() *cobra.Command {
+
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate documentation of artifacts",
+		Long: `Generate documentation of artifacts from their designtime content,
+without connecting to a tenant.`,
+	}
+	return docsCmd
+}
+
+func NewDocsGenerateCommand() *cobra.Command {
+
+	generateCmd := &cobra.Command{
+		Use:          "generate",
+		Short:        "Generate Markdown documentation of artifacts",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Generate a Markdown document per artifact from the designtime content
+of the artifacts in --dir, e.g. to publish it with each release.
+
+The document of an artifact lists its sender and receiver channels with
+their adapters and endpoints, and its externalized parameters with their
+current values in parameters.prop and the channels that use them.
+
+Artifacts are grouped into packages by the directory that contains the
+artifact directories, as written by snapshot. Documents are written to
+<output-dir>/<package>/<artifact>.md.
+
+The documents are rendered with a built-in template, or with the Go
+template of --template. Templates get the artifact as an ArtifactDocument,
+see the documentation for its fields and functions.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'docs.generate' section. CLI flags override config file settings.`,
+		Example: `  # Document the artifacts of a package of a snapshot
+  flashpipe docs generate --dir ./snapshot --package Sales --output-dir ./docs
+
+  # Render with a custom template
+  flashpipe docs generate --dir ./snapshot --output-dir ./wiki --template ./confluence.tmpl`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runDocsGenerate(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	generateCmd.Flags().String("dir", "", "Directory of artifacts grouped into packages, or directory of artifacts of one package (config: docs.generate.dir)")
+	generateCmd.Flags().String("package", "", "ID of the package to document, all packages if not set (config: docs.generate.package)")
+	generateCmd.Flags().String("output-dir", "docs", "Directory to write the documents to (config: docs.generate.outputDir)")
+	generateCmd.Flags().String("template", "", "Go template file to render the documents with instead of the built-in template (config: docs.generate.template)")
+
+	return generateCmd
+}
+
+func runDocsGenerate(cmd *cobra.Command) error {
+	log.Info().Msg("Executing docs generate command")
+
+	dir := config.GetStringWithFallback(cmd, "dir", "docs.generate.dir")
+	packageId := config.GetStringWithFallback(cmd, "package", "docs.generate.package")
+	outputDir := config.GetStringWithFallback(cmd, "output-dir", "docs.generate.outputDir")
+	templateFile := config.GetStringWithFallback(cmd, "template", "docs.generate.template")
+
+	if dir == "" {
+		return fmt.Errorf("--dir is required (set via CLI flag or in config file under 'docs.generate.dir')")
+	}
+	text := artifactDocumentTemplate
+	if templateFile != "" {
+		data, err := os.ReadFile(templateFile)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("docs").Funcs(reportFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	packages, err := designtime.Inventory(dir)
+	if err != nil {
+		return err
+	}
+	generated := time.Now()
+	count := 0
+	for _, pkg := range packages {
+		if packageId != "" && pkg.ID != packageId {
+			continue
+		}
+		for _, artifact := range pkg.Artifacts {
+			doc, err := designtime.Documentation(artifact.Dir)
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(outputDir, pkg.ID, doc.ID+".md")
+			if err = writeArtifactDocument(path, tmpl, &ArtifactDocument{Package: pkg.ID, Generated: generated, ArtifactDocumentation: doc}); err != nil {
+				return err
+			}
+			log.Debug().Msgf("Documentation of %v written to %v", doc.ID, path)
+			count++
+		}
+	}
+	if count == 0 {
+		return fmt.Errorf("no artifacts of package %v found in %v", packageId, dir)
+	}
+	log.Info().Msgf("Documentation of %d artifact(s) written to %v", count, outputDir)
+	return nil
+}
+
+// writeArtifactDocument renders the document of an artifact to path, creating its directory
+func writeArtifactDocument(path string, tmpl *template.Template, doc *ArtifactDocument) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = tmpl.Execute(f, doc); err != nil {
+		return fmt.Errorf("failed to render documentation of %v: %w", doc.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDocsGenerate(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"Sales/Orders/META-INF/MANIFEST.MF": "Manifest-Version: 1.0\nBundle-SymbolicName: Orders\nBundle-Name: Orders | Replication\nBundle-Version: 1.2.0\nSAP-BundleType: IntegrationFlow\n",
+		"Sales/Orders/src/main/resources/scenarioflows/integrationflow/Orders.iflw": `<bpmn2:definitions xmlns:bpmn2="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:ifl="http:///com.sap.ifl.model/Ifl.xsd">
+  <bpmn2:collaboration>
+    <bpmn2:participant id="Participant_2" name="ERP"/>
+    <bpmn2:messageFlow name="Post Order" sourceRef="ServiceTask_1" targetRef="Participant_2">
+      <bpmn2:extensionElements>
+        <ifl:property><key>ComponentType</key><value>HTTP</value></ifl:property>
+        <ifl:property><key>direction</key><value>Receiver</value></ifl:property>
+        <ifl:property><key>componentVersion</key><value>1.16</value></ifl:property>
+        <ifl:property><key>httpAddressWithoutQuery</key><value>{{ERP_URL}}</value></ifl:property>
+      </bpmn2:extensionElements>
+    </bpmn2:messageFlow>
+  </bpmn2:collaboration>
+</bpmn2:definitions>`,
+		"Sales/Orders/src/main/resources/parameters.prop":  "ERP_URL=https://erp.example.com/orders\n",
+		"Finance/Invoices/META-INF/MANIFEST.MF":            "Manifest-Version: 1.0\nBundle-SymbolicName: Invoices\nBundle-Version: 1.0.0\nSAP-BundleType: IntegrationFlow\n",
+		"Finance/Invoices/src/main/resources/.placeholder": "",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644))
+	}
+
+	outputDir := t.TempDir()
+	cmd := NewDocsGenerateCommand()
+	require.NoError(t, cmd.Flags().Set("dir", dir))
+	require.NoError(t, cmd.Flags().Set("package", "Sales"))
+	require.NoError(t, cmd.Flags().Set("output-dir", outputDir))
+	require.NoError(t, runDocsGenerate(cmd))
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "Sales", "Orders.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `# Orders \| Replication`)
+	assert.Contains(t, string(content), "| Post Order | Receiver | ERP | HTTP 1.16 | https://erp.example.com/orders (ERP_URL) |")
+	assert.Contains(t, string(content), "| ERP_URL | https://erp.example.com/orders |  |  | Post Order |  |")
+	assert.NoDirExists(t, filepath.Join(outputDir, "Finance"), "Only the given package should be documented")
+
+	require.NoError(t, cmd.Flags().Set("package", "HR"))
+	assert.EqualError(t, runDocsGenerate(cmd), "no artifacts of package HR found in "+dir)
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/sysproxy"
+	"github.com/spf13/cobra"
+)
+
+func NewDoctorCommand() *cobra.Command {
+
+	doctorCmd := &cobra.Command{
+		Use:          "doctor",
+		Short:        "Diagnose connectivity, credentials and permissions on the tenant",
+		SilenceUsage: true,
+		Long: `Check the environment and the connection to the tenant step by step:
+the tenant host, proxy settings, the OAuth token URL, the credentials,
+the API of the tenant, the clock skew to the tenant and which of the
+permissions used by FlashPipe the credentials have. Failed checks are
+listed with a hint how to fix them.
+
+The permissions are checked with requests for an artifact that does not
+exist, so no changes are made on the tenant.`,
+		Example: `  # Check the OAuth client of the pipeline
+  flashpipe doctor --tmn-host tenant.it-cpi018.cfapps.eu10-003.hana.ondemand.com \
+    --oauth-host tenant.authentication.eu10.hana.ondemand.com \
+    --oauth-clientid $CLIENT_ID --oauth-clientsecret $CLIENT_SECRET`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runDoctor(doctorTarget{details: api.GetServiceDetails(cmd), scheme: "https", port: 443}, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+	return doctorCmd
+}
+
+// Status of a doctor check
+const (
+	doctorOK   = "OK"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+)
+
+// maxClockSkew is the clock skew to the tenant above which tokens may be rejected as not yet or no longer valid
+const maxClockSkew = time.Minute
+
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Hint   string
+}
+
+// doctorTarget is the tenant checked by doctor, scheme and port can be changed for tests
+type doctorTarget struct {
+	details *api.ServiceDetails
+	scheme  string
+	port    int
+}
+
+func (t doctorTarget) platform() api.Platform {
+	platform, err := api.NewPlatform(t.details.Platform, t.details.Host)
+	if err != nil {
+		return api.DetectPlatform(t.details.Host)
+	}
+	return platform
+}
+
+func (t doctorTarget) oauthPath() string {
+	return api.OAuthPathFor(t.platform(), t.details.OauthPath)
+}
+
+func (t doctorTarget) executer() *httpclnt.HTTPExecuter {
+	d := t.details
+	exe := httpclnt.New(d.OauthHost, t.oauthPath(), d.OauthClientId, d.OauthClientSecret, d.Userid, d.Password, d.Host, t.scheme, t.port, true)
+	exe.UseTenantDefaults()
+	exe.SetPlatform(t.platform().Name())
+	return exe
+}
+
+func (t doctorTarget) url(host string, path string) string {
+	return fmt.Sprintf("%v://%v:%d%v", t.scheme, host, t.port, path)
+}
+
+func runDoctor(target doctorTarget, out io.Writer) error {
+	checks := doctorChecks(target)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+	for _, c := range checks {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", c.Name, c.Status, c.Detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if c.Status != doctorOK && c.Hint != "" {
+			fmt.Fprintf(out, "\n%v: %v", c.Name, c.Hint)
+		}
+		if c.Status == doctorFail {
+			failed++
+		}
+	}
+	fmt.Fprintln(out)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// doctorChecks runs the checks in the order a request to the tenant depends on them. The tenant is only
+// contacted if the host is valid, and the permissions are only checked if the connection succeeds.
+func doctorChecks(target doctorTarget) []doctorCheck {
+	proxy := checkProxy(target)
+	host := checkHost(target.details.Host, proxy.Detail != "none")
+	checks := []doctorCheck{host, proxy}
+	if host.Status == doctorFail || proxy.Status == doctorFail {
+		return checks
+	}
+	if target.details.OauthHost != "" {
+		checks = append(checks, checkTokenURL(target))
+	}
+
+	exe := target.executer()
+	connection, resp := checkConnection(exe)
+	checks = append(checks, connection)
+	if connection.Status == doctorFail {
+		return checks
+	}
+	checks = append(checks, checkAPI(resp), checkClockSkew(resp), checkFeatures(exe))
+	return append(checks, permissionChecks(exe)...)
+}
+
+// checkHost validates the format of the tenant host and resolves it. Behind a proxy, the host may only be
+// resolvable by the proxy.
+func checkHost(host string, proxied bool) doctorCheck {
+	check := doctorCheck{Name: "tenant host", Status: doctorOK, Detail: host}
+	const hint = "Set --tmn-host to the host of the tenant management node excluding https://, e.g. tenant.it-cpi018.cfapps.eu10-003.hana.ondemand.com"
+	switch {
+	case host == "":
+		check.Status, check.Detail, check.Hint = doctorFail, "not set", hint
+		return check
+	case strings.Contains(host, "://") || strings.ContainsAny(host, "/?#"):
+		check.Status, check.Hint = doctorFail, hint
+		check.Detail = fmt.Sprintf("%v is not a host name", host)
+		return check
+	case strings.Contains(host, "-rt."):
+		check.Status, check.Hint = doctorWarn, hint
+		check.Detail = fmt.Sprintf("%v looks like the runtime host of integration flow endpoints", host)
+		return check
+	}
+	if _, err := net.LookupHost(host); err != nil {
+		check.Status = doctorFail
+		if proxied {
+			check.Status = doctorWarn
+		}
+		check.Detail = fmt.Sprintf("%v cannot be resolved: %v", host, err)
+		check.Hint = "Check the spelling of --tmn-host and the DNS settings"
+	}
+	return check
+}
+
+// checkProxy reports the proxy requests to the tenant are sent through
+func checkProxy(target doctorTarget) doctorCheck {
+	check := doctorCheck{Name: "proxy", Status: doctorOK, Detail: "none"}
+	req, err := http.NewRequest(http.MethodGet, target.url(target.details.Host, "/"), nil)
+	if err != nil {
+		return check
+	}
+	proxyURL, err := sysproxy.Proxy(req)
+	switch {
+	case err != nil:
+		check.Status, check.Detail = doctorFail, err.Error()
+		check.Hint = "Set HTTPS_PROXY to the URL of the proxy, e.g. http://proxy.example.com:3128, or correct the proxy settings of the operating system"
+	case proxyURL != nil:
+		redacted := *proxyURL
+		redacted.User = nil
+		check.Detail = redacted.String()
+	}
+	return check
+}
+
+// checkTokenURL requests a token with the client credentials, to tell an unreachable token URL apart from
+// invalid client credentials
+func checkTokenURL(target doctorTarget) doctorCheck {
+	d := target.details
+	tokenURL := target.url(d.OauthHost, target.oauthPath())
+	check := doctorCheck{Name: "token URL", Status: doctorFail}
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(d.OauthClientId), url.QueryEscape(d.OauthClientSecret))
+
+	client := &http.Client{Timeout: 30 * time.Second, Transport: &http.Transport{Proxy: sysproxy.Proxy}}
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Detail = fmt.Sprintf("%v not reachable: %v", tokenURL, err)
+		check.Hint = "Set --oauth-host to the host of the url of the service key excluding https://, e.g. tenant.authentication.eu10.hana.ondemand.com"
+		return check
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&token)
+	switch {
+	case resp.StatusCode == http.StatusOK && decodeErr == nil && token.AccessToken != "":
+		check.Status = doctorOK
+		check.Detail = fmt.Sprintf("token issued by %v", tokenURL)
+		if token.ExpiresIn > 0 {
+			check.Detail += fmt.Sprintf(", expires in %v", time.Duration(token.ExpiresIn)*time.Second)
+		}
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusBadRequest:
+		check.Detail = fmt.Sprintf("client rejected: response code = %d", resp.StatusCode)
+		check.Hint = "Set --oauth-clientid and --oauth-clientsecret to clientid and clientsecret of the service key"
+	case resp.StatusCode == http.StatusNotFound:
+		check.Detail = fmt.Sprintf("%v not found", tokenURL)
+		check.Hint = "Set --oauth-path to the path of the tokenurl of the service key, usually /oauth/token"
+	default:
+		check.Detail = fmt.Sprintf("%v returned no token: response code = %d", tokenURL, resp.StatusCode)
+		check.Hint = "Check --oauth-host and --oauth-path against the tokenurl of the service key"
+	}
+	return check
+}
+
+// tenantResponse is the response of the service document of the tenant
+type tenantResponse struct {
+	body []byte
+	date string
+}
+
+func checkConnection(exe *httpclnt.HTTPExecuter) (doctorCheck, *tenantResponse) {
+	check := doctorCheck{Name: "connection"}
+	resp, err := exe.ExecGetRequest("/api/v1/", map[string]string{"Accept": "application/json"})
+	if err != nil {
+		check.Status = doctorFail
+		check.Detail = err.Error()
+		check.Hint = "Check the tenant host, proxy and token URL checks above, and whether a firewall blocks outgoing HTTPS"
+		return check, nil
+	}
+	body, _ := exe.ReadRespBody(resp)
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		check.Status = doctorOK
+		check.Detail = fmt.Sprintf("%v reachable", exe.Host())
+	case resp.StatusCode == http.StatusUnauthorized:
+		check.Status = doctorFail
+		check.Detail = "credentials rejected: response code = 401"
+		check.Hint = "Check the user and password, or the client ID and secret of the service key"
+	default:
+		check.Status = doctorFail
+		check.Detail = fmt.Sprintf("response code = %d", resp.StatusCode)
+		check.Hint = "Check --tmn-host, it must be the host of the tenant management node, not of the runtime or the Web UI"
+	}
+	return check, &tenantResponse{body: body, date: resp.Header.Get("Date")}
+}
+
+// checkAPI checks that the service document is the one of the Cloud Integration OData API
+func checkAPI(resp *tenantResponse) doctorCheck {
+	check := doctorCheck{Name: "api", Status: doctorOK}
+	var document struct {
+		D struct {
+			EntitySets []string `json:"EntitySets"`
+		} `json:"d"`
+	}
+	if err := json.Unmarshal(resp.body, &document); err != nil {
+		check.Status = doctorFail
+		check.Detail = "response of /api/v1/ is not an OData service document"
+		check.Hint = "Check --tmn-host, it must be the host of the tenant management node, not of the Web UI"
+		return check
+	}
+	if !slices.Contains(document.D.EntitySets, "IntegrationPackages") {
+		check.Status = doctorFail
+		check.Detail = fmt.Sprintf("OData API v1 without IntegrationPackages (%d entity sets)", len(document.D.EntitySets))
+		check.Hint = "Check --tmn-host, it must be a Cloud Integration tenant; API Management commands use the API portal host"
+		return check
+	}
+	check.Detail = fmt.Sprintf("Cloud Integration OData API v1 with %d entity sets", len(document.D.EntitySets))
+	return check
+}
+
+// checkClockSkew compares the local clock with the Date header of the tenant
+func checkClockSkew(resp *tenantResponse) doctorCheck {
+	check := doctorCheck{Name: "clock skew", Status: doctorOK}
+	date, err := http.ParseTime(resp.date)
+	if err != nil {
+		check.Status = doctorWarn
+		check.Detail = "tenant response has no Date header"
+		return check
+	}
+	skew := time.Since(date).Round(time.Second)
+	check.Detail = skew.String()
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		check.Status = doctorWarn
+		check.Hint = "Synchronise the system clock, e.g. with NTP, tokens may be rejected as not yet or no longer valid"
+	}
+	return check
+}
+
+// checkFeatures lists the optional APIs the tenant does not support, which FlashPipe does without
+func checkFeatures(exe *httpclnt.HTTPExecuter) doctorCheck {
+	check := doctorCheck{Name: "features", Status: doctorOK, Detail: "all optional APIs supported"}
+	if unsupported := api.DetectFeatures(exe).Unsupported(); len(unsupported) > 0 {
+		check.Detail = "not supported: " + strings.Join(unsupported, ", ")
+	}
+	return check
+}
+
+func permissionChecks(exe *httpclnt.HTTPExecuter) []doctorCheck {
+	results, err := api.NewPermission(exe).Check()
+	if err != nil {
+		return []doctorCheck{{Name: "permissions", Status: doctorFail, Detail: err.Error(),
+			Hint: "Check the user and password, or the client ID and secret of the service key"}}
+	}
+	var checks []doctorCheck
+	for _, r := range results {
+		check := doctorCheck{Name: "permission " + r.Capability, Status: doctorOK, Detail: r.Description}
+		switch {
+		case r.Error != nil:
+			check.Status = doctorWarn
+			check.Detail = fmt.Sprintf("could not be checked: %v", r.Error)
+		case !r.Granted:
+			check.Status = doctorFail
+			check.Detail = fmt.Sprintf("%v: response code = %d", r.Description, r.Status)
+			check.Hint = fmt.Sprintf("Assign role %v to the user or the service key", r.Role)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
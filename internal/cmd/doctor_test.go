@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDoctorTarget(url string, details api.ServiceDetails) doctorTarget {
+	host, port := httpclnt.GetHostPort(url)
+	details.Host = host
+	if details.OauthHost != "" {
+		details.OauthHost = host
+	}
+	return doctorTarget{details: &details, scheme: "http", port: port}
+}
+
+func TestDoctorMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, the runtime cannot be read
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "token", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-csrf-token", "token")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"d": {"EntitySets": ["IntegrationPackages", "IntegrationRuntimeArtifacts"]}}`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	var out bytes.Buffer
+	err := runDoctor(newDoctorTarget(svr.URL, api.ServiceDetails{OauthHost: "oauth", OauthPath: "/oauth/token", OauthClientId: "id", OauthClientSecret: "secret"}), &out)
+	assert.EqualError(t, err, "1 of 11 checks failed")
+	assert.Contains(t, out.String(), "expires in 1h0m0s")
+	assert.Contains(t, out.String(), "Cloud Integration OData API v1 with 2 entity sets")
+	assert.Contains(t, out.String(), "not supported: partner-directory, runtime-locations, edge")
+	assert.Contains(t, out.String(), "permission read-runtime: Assign role MonitoringDataRead")
+
+	exe := newDoctorTarget(svr.URL, api.ServiceDetails{Userid: "dummy", Password: "dummy"}).executer()
+	err = checkPermissions(exe, api.CapabilityReadDesigntime, api.CapabilityWriteConfiguration)
+	assert.NoError(t, err)
+	err = checkPermissions(exe, api.CapabilityReadRuntime)
+	assert.ErrorContains(t, err, "read-runtime")
+}
+
+func TestDoctorTokenURLMock(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	check := checkTokenURL(newDoctorTarget(svr.URL, api.ServiceDetails{OauthHost: "oauth", OauthPath: "/oauth/token"}))
+	assert.Equal(t, doctorFail, check.Status)
+	assert.Contains(t, check.Hint, "--oauth-clientid")
+
+	check = checkTokenURL(newDoctorTarget(svr.URL, api.ServiceDetails{OauthHost: "oauth", OauthPath: "/token"}))
+	assert.Contains(t, check.Hint, "--oauth-path")
+}
+
+func TestDoctorConnectionFailedMock(t *testing.T) {
+	svr := httptest.NewServer(http.NotFoundHandler())
+	target := newDoctorTarget(svr.URL, api.ServiceDetails{Userid: "dummy", Password: "dummy"})
+	svr.Close()
+
+	checks := doctorChecks(target)
+	require.Equal(t, 3, len(checks), "Tenant should not be checked further without connection")
+	assert.Equal(t, doctorFail, checks[2].Status)
+}
+
+func TestDoctorHost(t *testing.T) {
+	assert.Equal(t, doctorFail, checkHost("https://tenant.hana.ondemand.com", false).Status)
+	assert.Equal(t, doctorFail, checkHost("tenant.hana.ondemand.com/itspaces", false).Status)
+	assert.Equal(t, doctorWarn, checkHost("tenant.it-cpi018-rt.cfapps.eu10-003.hana.ondemand.com", false).Status)
+	assert.Equal(t, doctorOK, checkHost("127.0.0.1", false).Status)
+}
+
+func TestDoctorAPIAndClockSkew(t *testing.T) {
+	assert.Equal(t, doctorFail, checkAPI(&tenantResponse{body: []byte("<html>Login</html>")}).Status)
+	assert.Equal(t, doctorFail, checkAPI(&tenantResponse{body: []byte(`{"d": {"EntitySets": ["APIProxies"]}}`)}).Status)
+
+	assert.Equal(t, doctorOK, checkClockSkew(&tenantResponse{date: time.Now().UTC().Format(http.TimeFormat)}).Status)
+	assert.Equal(t, doctorWarn, checkClockSkew(&tenantResponse{date: time.Now().Add(-5 * time.Minute).UTC().Format(http.TimeFormat)}).Status)
+}
+
+func TestConfigureCapabilities(t *testing.T) {
+	cfg := &models.ConfigureConfig{Packages: []models.ConfigurePackage{{Artifacts: []models.ConfigureArtifact{{ID: "Flow"}}}}}
+	assert.Equal(t, []string{api.CapabilityReadDesigntime}, configureCapabilities(cfg, true))
+	assert.Equal(t, []string{api.CapabilityReadDesigntime, api.CapabilityWriteConfiguration}, configureCapabilities(cfg, false))
+	cfg.Packages[0].Deploy = true
+	assert.Contains(t, configureCapabilities(cfg, false), api.CapabilityDeploy)
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// EndpointEntry represents a single entry point URL of a deployed artifact
+type EndpointEntry struct {
+	ArtifactId   string `json:"artifactId"`
+	ArtifactName string `json:"artifactName"`
+	Version      string `json:"version"`
+	Protocol     string `json:"protocol"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Url          string `json:"url"`
+}
+
+func NewEndpointsCommand() *cobra.Command {
+
+	endpointsCmd := &cobra.Command{
+		Use:   "endpoints",
+		Short: "Inspect service endpoints of deployed artifacts",
+		Long: `Inspect service endpoints of artifacts deployed on the
+SAP Integration Suite tenant.`,
+	}
+	return endpointsCmd
+}
+
+func NewEndpointsListCommand() *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List entry point URLs of deployed artifacts",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `List all entry point URLs per deployed artifact using the
+ServiceEndpoints API of the SAP Integration Suite tenant.
+
+The output can be used to generate environment-specific API
+documentation or to wire up API tests.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'endpoints.list' section. CLI flags override config file settings.`,
+		Example: `  # List all endpoints
+  flashpipe endpoints list
+
+  # List only REST and SOAP endpoints as JSON
+  flashpipe endpoints list --protocols REST,SOAP --output-format json
+
+  # Write CSV inventory for specific artifacts to a file
+  flashpipe endpoints list --artifact-ids MyFlow1,MyFlow2 --output-format csv --output-file endpoints.csv`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			format := config.GetStringWithFallback(cmd, "output-format", "endpoints.list.outputFormat")
+			switch format {
+			case "text", "json", "csv":
+			default:
+				return fmt.Errorf("invalid value for --output-format = %v", format)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runEndpointsList(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'endpoints.list' key
+	listCmd.Flags().StringSlice("protocols", nil, "Comma separated list of protocols to include, e.g. REST,SOAP,ODATAV2 (config: endpoints.list.protocols)")
+	listCmd.Flags().StringSlice("artifact-ids", nil, "Comma separated list of artifact IDs to include (config: endpoints.list.artifactIds)")
+	listCmd.Flags().String("output-format", "text", "Output format. Allowed values: text, json, csv (config: endpoints.list.outputFormat)")
+	listCmd.Flags().String("output-file", "", "Write output to file instead of stdout (config: endpoints.list.outputFile)")
+
+	return listCmd
+}
+
+func runEndpointsList(cmd *cobra.Command) error {
+	log.Info().Msg("Executing endpoints list command")
+
+	protocols := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "protocols", "endpoints.list.protocols"))
+	artifactIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "artifact-ids", "endpoints.list.artifactIds"))
+	format := config.GetStringWithFallback(cmd, "output-format", "endpoints.list.outputFormat")
+	outputFile := config.GetStringWithFallback(cmd, "output-file", "endpoints.list.outputFile")
+
+	// Initialise HTTP executer
+	serviceDetails := api.GetServiceDetails(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	endpoints, err := api.NewServiceEndpoint(exe).List()
+	if err != nil {
+		return err
+	}
+
+	entries := collectEndpointEntries(endpoints, protocols, artifactIds)
+	log.Info().Msgf("Found %d entry point(s) across %d service endpoint(s)", len(entries), len(endpoints))
+
+	var out io.Writer = os.Stdout
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err = writeEndpointEntries(out, entries, format); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		log.Info().Msgf("Endpoint inventory written to %v", outputFile)
+	}
+	return nil
+}
+
+func collectEndpointEntries(endpoints []*api.ServiceEndpointData, protocols []string, artifactIds []string) []EndpointEntry {
+	var entries []EndpointEntry
+	for _, endpoint := range endpoints {
+		if len(protocols) > 0 && !slices.ContainsFunc(protocols, func(p string) bool { return strings.EqualFold(p, endpoint.Protocol) }) {
+			continue
+		}
+		artifactId := endpoint.ArtifactId()
+		if len(artifactIds) > 0 && !slices.Contains(artifactIds, artifactId) {
+			continue
+		}
+		for _, entryPoint := range endpoint.EntryPoints.Results {
+			entries = append(entries, EndpointEntry{
+				ArtifactId:   artifactId,
+				ArtifactName: endpoint.Name,
+				Version:      endpoint.Version,
+				Protocol:     endpoint.Protocol,
+				Name:         entryPoint.Name,
+				Type:         entryPoint.Type,
+				Url:          entryPoint.Url,
+			})
+		}
+	}
+	return entries
+}
+
+func writeEndpointEntries(out io.Writer, entries []EndpointEntry, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if entries == nil {
+			entries = []EndpointEntry{}
+		}
+		return encoder.Encode(entries)
+	case "csv":
+		w := csv.NewWriter(out)
+		_ = w.Write([]string{"ArtifactId", "ArtifactName", "Version", "Protocol", "Name", "Type", "Url"})
+		for _, e := range entries {
+			_ = w.Write([]string{e.ArtifactId, e.ArtifactName, e.Version, e.Protocol, e.Name, e.Type, e.Url})
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ARTIFACT ID\tVERSION\tPROTOCOL\tURL")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", e.ArtifactId, e.Version, e.Protocol, e.Url)
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/requestbundle"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewExecuteRequestsCommand() *cobra.Command {
+
+	executeCmd := &cobra.Command{
+		Use:          "execute-requests",
+		Short:        "Execute the requests of a request bundle",
+		SilenceUsage: true,
+		Long: `Execute the requests of a bundle written by configure package-requests,
+in their order, on the tenant. With --dry-run, the requests are listed for
+review without being executed.
+
+The checksums of the request bodies are verified before the first request
+is sent. If the bundle was written for another tenant host, nothing is
+executed. Execution stops at the first failed request unless
+--continue-on-error is set.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'executeRequests' section. CLI flags override config file settings.`,
+		Example: `  # Review the requests of a bundle
+  flashpipe execute-requests --file requests.tar --dry-run
+
+  # Execute them
+  flashpipe execute-requests --file requests.tar`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runExecuteRequests(cmd, os.Stdout); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	executeCmd.Flags().String("file", "", "Request bundle written by configure package-requests (config: executeRequests.file)")
+	executeCmd.Flags().Bool("dry-run", false, "List the requests without executing them (config: executeRequests.dryRun)")
+	executeCmd.Flags().Bool("continue-on-error", false, "Execute the remaining requests after a request failed (config: executeRequests.continueOnError)")
+
+	return executeCmd
+}
+
+func runExecuteRequests(cmd *cobra.Command, out io.Writer) error {
+	file := config.GetStringWithFallback(cmd, "file", "executeRequests.file")
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "executeRequests.dryRun")
+	continueOnError := config.GetBoolWithFallback(cmd, "continue-on-error", "executeRequests.continueOnError")
+
+	if file == "" {
+		return fmt.Errorf("--file is required (set via CLI flag or in config file under 'executeRequests.file')")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	manifest, err := requestbundle.Read(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Request bundle of %s created at %s with %d request(s)", manifest.Command, manifest.CreatedAt.Format(time.RFC3339), len(manifest.Requests))
+
+	if dryRun {
+		return listRequests(out, manifest)
+	}
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+	return executeRequests(exe, manifest, continueOnError)
+}
+
+// listRequests writes the requests of the bundle with their bodies for review
+func listRequests(out io.Writer, manifest *requestbundle.Manifest) error {
+	if manifest.Tenant != "" {
+		fmt.Fprintf(out, "Tenant: %s\n", manifest.Tenant)
+	}
+	for i, request := range manifest.Requests {
+		fmt.Fprintf(out, "%d. %s\n   %s %s\n", i+1, request.Description, request.Method, request.Path)
+		if len(request.Body) > 0 {
+			fmt.Fprintf(out, "   %s\n", strings.ReplaceAll(string(request.Body), "\n", "\n   "))
+		}
+	}
+	return nil
+}
+
+func executeRequests(exe *httpclnt.HTTPExecuter, manifest *requestbundle.Manifest, continueOnError bool) error {
+	if manifest.Tenant != "" && !strings.EqualFold(manifest.Tenant, exe.Host()) {
+		return fmt.Errorf("request bundle was written for tenant %s, not %s", manifest.Tenant, exe.Host())
+	}
+	failed := 0
+	for i, request := range manifest.Requests {
+		log.Info().Msgf("Request %d/%d: %s", i+1, len(manifest.Requests), request.Description)
+		err := api.ModifyingCall(request.Method, request.Path, request.Body, request.ContentType, request.SuccessCodes, request.Description, exe)
+		if err == nil {
+			continue
+		}
+		failed++
+		if !continueOnError {
+			return fmt.Errorf("request %d (%s) failed, %d request(s) not executed: %w", i+1, request.Description, len(manifest.Requests)-i-1, err)
+		}
+		log.Error().Msgf("Request %d (%s) failed: %v", i+1, request.Description, err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d request(s) failed", failed, len(manifest.Requests))
+	}
+	log.Info().Msgf("🏆 %d request(s) executed", len(manifest.Requests))
+	return nil
+}
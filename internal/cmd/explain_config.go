@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// explainConfig prints the effective settings of the command with --explain-config and replaces the command by
+// a no-op, so that the settings can be checked, e.g. why batch processing was disabled, without tenant
+func explainConfig(cmd *cobra.Command) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE\tFROM")
+	for _, s := range config.Explain(cmd) {
+		from := s.Key
+		if s.Source == config.SourceFlag {
+			from = "--" + s.Flag
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", s.Flag, s.Value, s.Source, from)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Required flags are not checked, the command is not run
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		delete(f.Annotations, cobra.BashCompOneRequiredFlag)
+		delete(f.Annotations, "cobra_annotation_required_if_others_set")
+		delete(f.Annotations, "cobra_annotation_one_required")
+		delete(f.Annotations, "cobra_annotation_mutually_exclusive")
+	})
+	cmd.PreRun, cmd.PreRunE, cmd.PostRun, cmd.PostRunE, cmd.Run = nil, nil, nil, nil, nil
+	cmd.RunE = func(*cobra.Command, []string) error { return nil }
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// filterStdin is read by filters given as -
+var filterStdin io.Reader = os.Stdin
+
+// resolveFilters returns the package and artifact filters as comma-separated lists. A filter @<file> is read
+// from the file and a filter - from stdin, see readFilter. Only one of them can be read from stdin.
+func resolveFilters(packageFilter, artifactFilter string) (string, string, error) {
+	if packageFilter == "-" && artifactFilter == "-" {
+		return "", "", fmt.Errorf("only one of --package-filter and --artifact-filter can be read from stdin")
+	}
+	packageFilter, err := resolveFilter("--package-filter", packageFilter)
+	if err != nil {
+		return "", "", err
+	}
+	artifactFilter, err = resolveFilter("--artifact-filter", artifactFilter)
+	if err != nil {
+		return "", "", err
+	}
+	return packageFilter, artifactFilter, nil
+}
+
+func resolveFilter(flag, filter string) (string, error) {
+	var ids []string
+	var err error
+	switch {
+	case filter == "-":
+		ids, err = readFilter(filterStdin)
+		filter = "stdin"
+	case strings.HasPrefix(filter, "@"):
+		filter = strings.TrimPrefix(filter, "@")
+		var f *os.File
+		if f, err = os.Open(filter); err != nil {
+			return "", fmt.Errorf("%v: %w", flag, err)
+		}
+		ids, err = readFilter(f)
+		f.Close()
+	default:
+		return filter, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%v: failed to read %v: %w", flag, filter, err)
+	}
+	// An empty list would include everything, which is unlikely intended for a computed list
+	if len(ids) == 0 {
+		return "", fmt.Errorf("%v: %v contains no IDs", flag, filter)
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// readFilter reads IDs, one per line. Blank lines and comments starting with # are ignored, also at the end
+// of a line.
+func readFilter(r io.Reader) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if id := strings.TrimSpace(line); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, scanner.Err()
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFilters(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "packages.txt")
+	require.NoError(t, os.WriteFile(file, []byte("# affected packages\nSales\n\n  Billing   # changed in PR 42\n"), 0644))
+	filterStdin = strings.NewReader("Orders_Replicate\r\nOrders_Sync\n")
+	defer func() { filterStdin = os.Stdin }()
+
+	packageFilter, artifactFilter, err := resolveFilters("@"+file, "-")
+	require.NoError(t, err)
+	assert.Equal(t, "Sales,Billing", packageFilter)
+	assert.Equal(t, "Orders_Replicate,Orders_Sync", artifactFilter)
+
+	packageFilter, artifactFilter, err = resolveFilters("Sales,Billing", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Sales,Billing", packageFilter)
+	assert.Empty(t, artifactFilter)
+
+	_, _, err = resolveFilters("-", "-")
+	assert.EqualError(t, err, "only one of --package-filter and --artifact-filter can be read from stdin")
+
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(empty, []byte("# nothing affected\n"), 0644))
+	_, _, err = resolveFilters("", "@"+empty)
+	assert.EqualError(t, err, "--artifact-filter: "+empty+" contains no IDs")
+
+	_, _, err = resolveFilters("@missing.txt", "")
+	assert.ErrorContains(t, err, "--package-filter: open missing.txt")
+}
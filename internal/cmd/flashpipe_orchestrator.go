@@ -6,13 +6,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
 	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/exitreport"
+	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/pipeline"
+	"github.com/engswee/flashpipe/internal/smoketest"
 	flashpipeSync "github.com/engswee/flashpipe/internal/sync"
+	"github.com/engswee/flashpipe/internal/telemetry"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -51,8 +56,19 @@ type ProcessingStats struct {
 type DeploymentTask struct {
 	ArtifactID   string
 	ArtifactType string
+	Version      string // Designtime version to deploy, the active version if empty
 	PackageID    string
 	DisplayName  string
+	Window       *models.MaintenanceWindow
+	Strategy     string
+	Drain        *models.DrainCheck
+	BlueGreen    *models.BlueGreenSettings
+	// SkipIfDeployed skips the deployment if the designtime version is already started on the runtime
+	SkipIfDeployed bool
+	// Deadline stops the deployment status checks, zero for no deadline
+	Deadline time.Time
+	// SmokeTest receives the result of the smoke test of a blue-green deployment, if not nil
+	SmokeTest *smoketest.Result
 }
 
 func NewFlashpipeOrchestratorCommand() *cobra.Command {
@@ -132,31 +148,15 @@ Configuration:
 				mode = ModeDeployOnly
 			}
 
-			// Load from viper config if available (CLI flags override config file)
-			if !cmd.Flags().Changed("packages-dir") && viper.IsSet("orchestrator.packagesDir") {
-				packagesDir = viper.GetString("orchestrator.packagesDir")
-			}
-			if !cmd.Flags().Changed("deploy-config") && viper.IsSet("orchestrator.deployConfig") {
-				deployConfig = viper.GetString("orchestrator.deployConfig")
-			}
-			if !cmd.Flags().Changed("deployment-prefix") && viper.IsSet("orchestrator.deploymentPrefix") {
-				deploymentPrefix = viper.GetString("orchestrator.deploymentPrefix")
-			}
-			if !cmd.Flags().Changed("package-filter") && viper.IsSet("orchestrator.packageFilter") {
-				packageFilter = viper.GetString("orchestrator.packageFilter")
-			}
-			if !cmd.Flags().Changed("artifact-filter") && viper.IsSet("orchestrator.artifactFilter") {
-				artifactFilter = viper.GetString("orchestrator.artifactFilter")
-			}
-			if !cmd.Flags().Changed("config-pattern") && viper.IsSet("orchestrator.configPattern") {
-				configPattern = viper.GetString("orchestrator.configPattern")
-			}
-			if !cmd.Flags().Changed("merge-configs") && viper.IsSet("orchestrator.mergeConfigs") {
-				mergeConfigs = viper.GetBool("orchestrator.mergeConfigs")
-			}
-			if !cmd.Flags().Changed("keep-temp") && viper.IsSet("orchestrator.keepTemp") {
-				keepTemp = viper.GetBool("orchestrator.keepTemp")
-			}
+			// CLI flags override the environment and the config file
+			packagesDir = config.GetStringWithFallback(cmd, "packages-dir", "orchestrator.packagesDir")
+			deployConfig = config.GetStringWithFallback(cmd, "deploy-config", "orchestrator.deployConfig")
+			deploymentPrefix = config.GetStringWithFallback(cmd, "deployment-prefix", "orchestrator.deploymentPrefix")
+			packageFilter = config.GetStringWithFallback(cmd, "package-filter", "orchestrator.packageFilter")
+			artifactFilter = config.GetStringWithFallback(cmd, "artifact-filter", "orchestrator.artifactFilter")
+			configPattern = config.GetStringWithFallback(cmd, "config-pattern", "orchestrator.configPattern")
+			mergeConfigs = config.GetBoolWithFallback(cmd, "merge-configs", "orchestrator.mergeConfigs")
+			keepTemp = config.GetBoolWithFallback(cmd, "keep-temp", "orchestrator.keepTemp")
 			if !updateMode && !updateOnlyMode && !deployOnlyMode && viper.IsSet("orchestrator.mode") {
 				switch viper.GetString("orchestrator.mode") {
 				case "update-and-deploy":
@@ -167,20 +167,18 @@ Configuration:
 					mode = ModeDeployOnly
 				}
 			}
-			if !cmd.Flags().Changed("deploy-retries") && viper.IsSet("orchestrator.deployRetries") {
-				deployRetries = viper.GetInt("orchestrator.deployRetries")
-			}
-			if !cmd.Flags().Changed("deploy-delay") && viper.IsSet("orchestrator.deployDelaySeconds") {
-				deployDelaySeconds = viper.GetInt("orchestrator.deployDelaySeconds")
-			}
-			if !cmd.Flags().Changed("parallel-deployments") && viper.IsSet("orchestrator.parallelDeployments") {
-				parallelDeployments = viper.GetInt("orchestrator.parallelDeployments")
-			}
+			deployRetries = config.GetIntWithFallback(cmd, "deploy-retries", "orchestrator.deployRetries")
+			deployDelaySeconds = config.GetIntWithFallback(cmd, "deploy-delay", "orchestrator.deployDelaySeconds")
+			parallelDeployments = config.GetIntWithFallback(cmd, "parallel-deployments", "orchestrator.parallelDeployments")
 
 			// Validate required parameters
 			if deployConfig == "" {
 				return fmt.Errorf("--deploy-config is required (set via CLI flag or in config file under 'orchestrator.deployConfig')")
 			}
+			var err error
+			if packageFilter, artifactFilter, err = resolveFilters(packageFilter, artifactFilter); err != nil {
+				return err
+			}
 
 			// Set defaults for deployment settings
 			if deployRetries == 0 {
@@ -203,8 +201,8 @@ Configuration:
 	orchestratorCmd.Flags().StringVarP(&packagesDir, "packages-dir", "d", "", "Directory containing packages (config: orchestrator.packagesDir)")
 	orchestratorCmd.Flags().StringVarP(&deployConfig, "deploy-config", "c", "", "Path to deployment config file/folder/URL (config: orchestrator.deployConfig)")
 	orchestratorCmd.Flags().StringVarP(&deploymentPrefix, "deployment-prefix", "p", "", "Deployment prefix for package/artifact IDs (config: orchestrator.deploymentPrefix)")
-	orchestratorCmd.Flags().StringVar(&packageFilter, "package-filter", "", "Comma-separated list of packages to include (config: orchestrator.packageFilter)")
-	orchestratorCmd.Flags().StringVar(&artifactFilter, "artifact-filter", "", "Comma-separated list of artifacts to include (config: orchestrator.artifactFilter)")
+	orchestratorCmd.Flags().StringVar(&packageFilter, "package-filter", "", "Comma-separated list of packages to include, @<file> or - (stdin) for one per line (config: orchestrator.packageFilter)")
+	orchestratorCmd.Flags().StringVar(&artifactFilter, "artifact-filter", "", "Comma-separated list of artifacts to include, @<file> or - (stdin) for one per line (config: orchestrator.artifactFilter)")
 	orchestratorCmd.Flags().BoolVar(&keepTemp, "keep-temp", false, "Keep temporary directory after execution (config: orchestrator.keepTemp)")
 	orchestratorCmd.Flags().BoolVar(&debugMode, "debug", false, "Enable debug logging")
 	orchestratorCmd.Flags().StringVar(&configPattern, "config-pattern", "*.y*ml", "File pattern for config files in folders (config: orchestrator.configPattern)")
@@ -215,6 +213,8 @@ Configuration:
 	orchestratorCmd.Flags().IntVar(&deployRetries, "deploy-retries", 0, "Number of retries for deployment status checks (config: orchestrator.deployRetries, default: 5)")
 	orchestratorCmd.Flags().IntVar(&deployDelaySeconds, "deploy-delay", 0, "Delay in seconds between deployment status checks (config: orchestrator.deployDelaySeconds, default: 15)")
 	orchestratorCmd.Flags().IntVar(&parallelDeployments, "parallel-deployments", 0, "Number of parallel deployments per package (config: orchestrator.parallelDeployments, default: 3)")
+	addApprovalFlags(orchestratorCmd)
+	addWindowFlags(orchestratorCmd)
 
 	return orchestratorCmd
 }
@@ -368,6 +368,14 @@ func runOrchestrator(cmd *cobra.Command, mode OperationMode, packagesDir, deploy
 		log.Debug().Msg("  Auth Method: Basic Auth")
 	}
 
+	// Approval gate checked before the deployment phase
+	var deployApproval *deploymentApproval
+	if mode != ModeUpdateOnly {
+		if deployApproval, err = newDeploymentApproval(cmd); err != nil {
+			return err
+		}
+	}
+
 	// Collect all deployment tasks (will be executed in phase 2)
 	var deploymentTasks []DeploymentTask
 
@@ -415,16 +423,23 @@ func runOrchestrator(cmd *cobra.Command, mode OperationMode, packagesDir, deploy
 
 	// Phase 2: Deploy all artifacts in parallel (if not update-only mode)
 	if mode != ModeUpdateOnly && len(deploymentTasks) > 0 {
-		log.Info().Msg("")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-		log.Info().Msg("PHASE 2: DEPLOYING ALL ARTIFACTS IN PARALLEL")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+		logger.Banner(logger.SeparatorDouble, "PHASE 2: DEPLOYING ALL ARTIFACTS IN PARALLEL")
 		log.Info().Msgf("Total artifacts to deploy: %d", len(deploymentTasks))
 		log.Info().Msgf("Max concurrent deployments: %d", parallelDeployments)
 		log.Info().Msg("")
 
+		var artifactIDs []string
+		for _, t := range deploymentTasks {
+			artifactIDs = append(artifactIDs, t.ArtifactID)
+		}
+		if err := deployApproval.approve(serviceDetails.Host, artifactIDs); err != nil {
+			log.Error().Msgf("Deployment phase skipped: %v", err)
+			printSummary(&stats)
+			return err
+		}
+
 		err := deployAllArtifactsParallel(deploymentTasks, parallelDeployments, deployRetries,
-			deployDelaySeconds, &stats, serviceDetails)
+			deployDelaySeconds, newWindowPolicy(cmd), &stats, serviceDetails)
 		if err != nil {
 			log.Error().Msgf("Deployment phase failed: %v", err)
 		}
@@ -449,10 +464,7 @@ func processPackages(config *models.DeployConfig, applyPrefix bool, mode Operati
 
 	// Phase 1: Update all packages and artifacts
 	if mode != ModeDeployOnly {
-		log.Info().Msg("")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-		log.Info().Msg("PHASE 1: UPDATING ALL PACKAGES AND ARTIFACTS")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+		logger.Banner(logger.SeparatorDouble, "PHASE 1: UPDATING ALL PACKAGES AND ARTIFACTS")
 		log.Info().Msg("")
 	}
 
@@ -469,7 +481,7 @@ func processPackages(config *models.DeployConfig, applyPrefix bool, mode Operati
 			continue
 		}
 
-		log.Info().Msgf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		logger.Rule(logger.SeparatorHeavy)
 		log.Info().Msgf("📦 Package: %s", pkg.ID)
 
 		packageDir := filepath.Join(packagesDir, pkg.PackageDir)
@@ -784,6 +796,11 @@ func collectDeploymentTasks(pkg *models.Package, finalPackageID, prefix string,
 			ArtifactType: artifactType,
 			PackageID:    finalPackageID,
 			DisplayName:  artifact.DisplayName,
+			Version:      artifact.DeployVersion,
+			Window:       effectiveWindow(pkg.Window, artifact.Window),
+			Strategy:     artifact.Strategy,
+			Drain:        artifact.Drain,
+			BlueGreen:    artifact.BlueGreen,
 		})
 	}
 
@@ -791,7 +808,7 @@ func collectDeploymentTasks(pkg *models.Package, finalPackageID, prefix string,
 }
 
 func deployAllArtifactsParallel(tasks []DeploymentTask, maxConcurrent int,
-	retries int, delaySeconds int, stats *ProcessingStats, serviceDetails *api.ServiceDetails) error {
+	retries int, delaySeconds int, window windowPolicy, stats *ProcessingStats, serviceDetails *api.ServiceDetails) error {
 
 	// Group tasks by package for better control
 	tasksByPackage := make(map[string][]DeploymentTask)
@@ -801,46 +818,50 @@ func deployAllArtifactsParallel(tasks []DeploymentTask, maxConcurrent int,
 
 	// Process each package's deployments
 	for packageID, packageTasks := range tasksByPackage {
-		log.Info().Msgf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		logger.Rule(logger.SeparatorHeavy)
 		log.Info().Msgf("📦 Deploying %d artifacts for package: %s", len(packageTasks), packageID)
 
-		// Deploy artifacts in parallel with semaphore
-		var wg sync.WaitGroup
-		semaphore := make(chan struct{}, maxConcurrent)
-		resultChan := make(chan deployResult, len(packageTasks))
-
-		for _, task := range packageTasks {
-			wg.Add(1)
-			go func(t DeploymentTask) {
-				defer wg.Done()
-
-				// Acquire semaphore
-				semaphore <- struct{}{}
-				defer func() { <-semaphore }()
+		// Deploy artifacts in parallel with at most maxConcurrent at a time
+		steps := make([]pipeline.Task, len(packageTasks))
+		for i, t := range packageTasks {
+			steps[i] = pipeline.Task{ID: fmt.Sprintf("%d:%s", i, t.ArtifactID), Run: func() error {
+				if err := window.await(t); err != nil {
+					return err
+				}
 
 				// Deploy artifact
 				// Use mapArtifactTypeForSync because deployArtifacts calls api.NewDesigntimeArtifact
 				flashpipeType := mapArtifactTypeForSync(t.ArtifactType)
 				log.Info().Msgf("  → Deploying: %s (type: %s)", t.ArtifactID, t.ArtifactType)
 
-				err := deployArtifacts([]string{t.ArtifactID}, flashpipeType, retries, delaySeconds, true, serviceDetails)
-
-				resultChan <- deployResult{
-					Task:  t,
-					Error: err,
+				span := telemetry.StartSpan("deploy "+t.ArtifactID, "flashpipe.package.id", t.PackageID,
+					"flashpipe.artifact.id", t.ArtifactID, "flashpipe.artifact.type", t.ArtifactType)
+				var err error
+				if t.Strategy == DeployStrategyBlueGreen {
+					bgTask := t
+					bgTask.ArtifactType = flashpipeType
+					exe := api.InitHTTPExecuter(serviceDetails)
+					start := time.Now()
+					err = deployBlueGreen(exe, bgTask, retries, delaySeconds)
+					postDeploymentEvent(exe, t.PackageID, t.ArtifactID, flashpipeType, time.Since(start), err)
+				} else if err = stopAndDrain(api.InitHTTPExecuter(serviceDetails), t); err == nil {
+					err = deployArtifacts([]string{t.ArtifactID}, flashpipeType, t.Version, retries, delaySeconds, true, serviceDetails)
 				}
-			}(task)
+				recordDeployment(span, err)
+				return err
+			}}
+		}
+		results, err := pipeline.Run(steps, pipeline.Options{Parallel: maxConcurrent})
+		if err != nil {
+			return err
 		}
-
-		// Wait for all deployments to complete
-		wg.Wait()
-		close(resultChan)
 
 		// Process results
 		successCount := 0
 		failureCount := 0
 
-		for result := range resultChan {
+		for i, step := range results {
+			result := deployResult{Task: packageTasks[i], Error: step.Err}
 			if result.Error != nil {
 				log.Error().Msgf("  ✗ Deploy failed: %s - %v", result.Task.ArtifactID, result.Error)
 				stats.ArtifactsDeployedFailed++
@@ -868,8 +889,19 @@ func deployAllArtifactsParallel(tasks []DeploymentTask, maxConcurrent int,
 }
 
 type deployResult struct {
-	Task  DeploymentTask
-	Error error
+	Task     DeploymentTask
+	Error    error
+	Duration time.Duration
+	Skipped  bool // Already up to date
+}
+
+func recordDeployment(span *telemetry.Span, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	telemetry.IncCounter("flashpipe_deployments_total", "Number of artifact deployments by result.", 1, "result", result)
+	span.End(err)
 }
 
 // mapArtifactType maps artifact types for deployment API calls
@@ -934,21 +966,24 @@ func shouldInclude(id string, filter []string) bool {
 }
 
 func printSummary(stats *ProcessingStats) {
-	log.Info().Msg("")
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-	log.Info().Msg("📊 DEPLOYMENT SUMMARY")
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	exitreport.Add("packagesUpdated", stats.PackagesUpdated)
+	exitreport.Add("packagesFailed", stats.PackagesFailed)
+	exitreport.Add("artifactsUpdated", len(stats.SuccessfulArtifactUpdates))
+	exitreport.Add("artifactsDeployed", stats.ArtifactsDeployedSuccess)
+	exitreport.Add("artifactsFailed", stats.UpdateFailures+stats.DeployFailures)
+
+	logger.Banner(logger.SeparatorDouble, "📊 DEPLOYMENT SUMMARY")
 	log.Info().Msgf("Packages Updated:   %d", stats.PackagesUpdated)
 	log.Info().Msgf("Packages Deployed:  %d", stats.PackagesDeployed)
 	log.Info().Msgf("Packages Failed:    %d", stats.PackagesFailed)
 	log.Info().Msgf("Packages Filtered:  %d", stats.PackagesFiltered)
-	log.Info().Msg("───────────────────────────────────────────────────────────────────────")
+	logger.Rule(logger.SeparatorLight)
 	log.Info().Msgf("Artifacts Total:         %d", stats.ArtifactsTotal)
 	log.Info().Msgf("Artifacts Updated:       %d", len(stats.SuccessfulArtifactUpdates))
 	log.Info().Msgf("Artifacts Deployed OK:   %d", stats.ArtifactsDeployedSuccess)
 	log.Info().Msgf("Artifacts Deployed Fail: %d", stats.ArtifactsDeployedFailed)
 	log.Info().Msgf("Artifacts Filtered:      %d", stats.ArtifactsFiltered)
-	log.Info().Msg("───────────────────────────────────────────────────────────────────────")
+	logger.Rule(logger.SeparatorLight)
 
 	if stats.UpdateFailures > 0 {
 		log.Warn().Msgf("⚠ Update Failures: %d", stats.UpdateFailures)
@@ -970,5 +1005,5 @@ func printSummary(stats *ProcessingStats) {
 		log.Info().Msg("✓ All operations completed successfully!")
 	}
 
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	logger.Rule(logger.SeparatorDouble)
 }
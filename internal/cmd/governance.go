@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/engswee/flashpipe/internal/governance"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewGovernanceCommand() *cobra.Command {
+
+	governanceCmd := &cobra.Command{
+		Use:   "governance",
+		Short: "Check governance rules on designtime artifacts",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Check the designtime content of artifacts, e.g. downloaded with sync or
+snapshot, against governance rules, without connecting to a tenant.`,
+	}
+	return governanceCmd
+}
+
+func NewGovernanceCheckCommand() *cobra.Command {
+
+	checkCmd := &cobra.Command{
+		Use:          "check",
+		Short:        "Check artifacts against governance rules",
+		SilenceUsage: true,
+		Long: `Check the content of artifacts against governance rules. A rule selects
+the elements of its scope (artifact, flow, channel or step) whose
+properties and attributes match all conditions of match, and reports each
+condition of assert that they fail.
+
+Built-in rules:
+  no-plain-http-receiver  Receiver channels call HTTPS endpoints (error)
+  csrf-https-sender       HTTPS sender channels enable CSRF protection (error)
+  channel-naming          Channel names consist of letters, digits, underscores and dashes (warning)
+
+A rule set file changes the settings of built-in rules, e.g. severity off
+disables them, and adds rules.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'governance' section. CLI flags override config file settings.`,
+		Example: `  # Check the artifacts synced to Git against the built-in rules
+  flashpipe governance check --dir ./src
+
+  # Company rule set, findings for GitHub code scanning
+  flashpipe governance check --dir ./src --rules governance.yml --output sarif > governance.sarif`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runGovernanceCheck(cmd, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	checkCmd.Flags().String("dir", "", "Directory of artifacts, directory of an artifact or artifact zip file (config: governance.dir)")
+	checkCmd.Flags().String("rules", "", "Rule set file with settings of built-in rules and additional rules (config: governance.rules)")
+	checkCmd.Flags().String("fail-on", designtime.SeverityError, "Minimum severity of findings that fail the command: warning or error (config: governance.failOn)")
+	checkCmd.Flags().String("output", "text", "Output format: text, json or sarif (config: governance.output)")
+
+	return checkCmd
+}
+
+func runGovernanceCheck(cmd *cobra.Command, out io.Writer) error {
+	dir := config.GetStringWithFallback(cmd, "dir", "governance.dir")
+	rulesFile := config.GetStringWithFallback(cmd, "rules", "governance.rules")
+	failOn := config.GetStringWithFallback(cmd, "fail-on", "governance.failOn")
+	output := config.GetStringWithFallback(cmd, "output", "governance.output")
+
+	if dir == "" {
+		return fmt.Errorf("--dir is required (set via CLI flag or in config file under 'governance.dir')")
+	}
+	if failOn != designtime.SeverityWarning && failOn != designtime.SeverityError {
+		return fmt.Errorf("invalid --fail-on %q, must be warning or error", failOn)
+	}
+	if output != "text" && output != "json" && output != "sarif" {
+		return fmt.Errorf("invalid --output %q, must be text, json or sarif", output)
+	}
+
+	rules, err := governance.LoadRules(rulesFile)
+	if err != nil {
+		return err
+	}
+	findings, err := governance.Check(dir, rules)
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case "sarif":
+		descriptions := map[string]string{}
+		for _, rule := range rules {
+			if rule.Description != "" {
+				descriptions[governance.CheckGovernance+"/"+rule.ID] = rule.Description
+			}
+		}
+		if err := designtime.WriteSARIF(out, findings, descriptions); err != nil {
+			return err
+		}
+	case "json":
+		if findings == nil {
+			findings = []designtime.Finding{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(findings); err != nil {
+			return err
+		}
+	default:
+		for _, f := range findings {
+			fmt.Fprintln(out, f)
+		}
+	}
+
+	failed := 0
+	for _, f := range findings {
+		if f.Severity == designtime.SeverityError || failOn == designtime.SeverityWarning {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d finding(s) with severity %s or higher", failed, failOn)
+	}
+	log.Info().Msgf("Governance check passed with %d finding(s) below severity %s", len(findings), failOn)
+	return nil
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/history"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewHistoryCommand() *cobra.Command {
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Inspect the run history of configure",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Inspect the statistics and per-artifact outcomes of configure runs
+recorded with configure --history-db in a SQLite database. The database
+is read with the sqlite3 shell, which has to be on the PATH.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'history' section. CLI flags override config file settings.`,
+	}
+	historyCmd.PersistentFlags().String("history-db", "~/"+history.DefaultDatabase, "History database written by configure --history-db (config: history.db)")
+	return historyCmd
+}
+
+func NewHistoryListCommand() *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List recorded configure runs",
+		SilenceUsage: true,
+		Long: `List the recorded configure runs, newest last. With --artifact, the
+outcome and duration of configuring and deploying that artifact is listed
+for each run, to spot an artifact that starts failing or slowing down.`,
+		Example: `  # List the last 20 runs
+  flashpipe history list
+
+  # Outcomes of one artifact on the production tenant
+  flashpipe history list --tenant prod --artifact Orders_Replicate --limit 50`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runHistoryList(cmd, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	listCmd.Flags().Int("limit", 20, "Maximum number of runs listed, 0 for all (config: history.list.limit)")
+	listCmd.Flags().String("tenant", "", "Only list runs on this tenant (config: history.list.tenant)")
+	listCmd.Flags().String("artifact", "", "List the outcomes of this artifact (config: history.list.artifact)")
+
+	return listCmd
+}
+
+func NewHistoryCompareCommand() *cobra.Command {
+
+	compareCmd := &cobra.Command{
+		Use:          "compare <run-id> <run-id>",
+		Short:        "Compare two recorded configure runs",
+		SilenceUsage: true,
+		Long: `Compare the statistics, timings and per-artifact outcomes of two
+recorded configure runs. Artifacts whose outcome differs are marked with !.`,
+		Example: `  # Compare run 41 with run 42
+  flashpipe history compare 41 42`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runHistoryCompare(cmd, args, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+	return compareCmd
+}
+
+func newHistoryStore(cmd *cobra.Command) (*history.Store, error) {
+	return history.NewStore(config.GetStringWithFallback(cmd, "history-db", "history.db"))
+}
+
+func runHistoryList(cmd *cobra.Command, out io.Writer) error {
+	limit := config.GetIntWithFallback(cmd, "limit", "history.list.limit")
+	tenant := config.GetStringWithFallback(cmd, "tenant", "history.list.tenant")
+	artifactID := config.GetStringWithFallback(cmd, "artifact", "history.list.artifact")
+
+	store, err := newHistoryStore(cmd)
+	if err != nil {
+		return err
+	}
+	all, err := store.Runs()
+	if err != nil {
+		return err
+	}
+	var runs []*history.Run
+	for _, run := range all {
+		if tenant == "" || run.Tenant == tenant {
+			runs = append(runs, run)
+		}
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	if len(runs) == 0 {
+		log.Info().Msgf("No runs recorded in %s", store.Path())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if artifactID != "" {
+		configured := history.ArtifactResults(runs, artifactID, flashpipe.PhaseConfigure)
+		deployed := history.ArtifactResults(runs, artifactID, flashpipe.PhaseDeploy)
+		fmt.Fprintln(w, "RUN\tSTARTED\tTENANT\tCONFIGURE\tDEPLOY\tERROR")
+		for i, run := range runs {
+			errorText := ""
+			for _, r := range []*flashpipe.ArtifactResult{configured[i], deployed[i]} {
+				if r != nil && r.Error != "" {
+					errorText = r.Error
+				}
+			}
+			fmt.Fprintf(w, "%d\t%v\t%v\t%v\t%v\t%v\n", run.ID, run.Started.Local().Format(time.DateTime), run.Tenant,
+				formatArtifactResult(configured[i]), formatArtifactResult(deployed[i]), errorText)
+		}
+		return w.Flush()
+	}
+
+	fmt.Fprintln(w, "RUN\tSTARTED\tTENANT\tCONFIGURED\tFAILED\tDEPLOYED\tDURATION\tSTATUS")
+	for _, run := range runs {
+		stats := run.Stats
+		if stats == nil {
+			stats = &ConfigureStats{}
+		}
+		status := "success"
+		if run.Failed() {
+			status = "failed"
+		}
+		if run.DryRun {
+			status += " (dry run)"
+		}
+		fmt.Fprintf(w, "%d\t%v\t%v\t%d\t%d\t%d\t%v\t%v\n", run.ID, run.Started.Local().Format(time.DateTime), run.Tenant,
+			stats.ArtifactsConfigured.Value(), stats.ArtifactsFailed.Value()+stats.DeploymentTasksFailed.Value(), stats.ArtifactsDeployed.Value(),
+			stats.Timings.Total.Round(time.Second), status)
+	}
+	return w.Flush()
+}
+
+func formatArtifactResult(result *flashpipe.ArtifactResult) string {
+	if result == nil {
+		return history.Outcome(result)
+	}
+	return fmt.Sprintf("%s (%v)", history.Outcome(result), time.Duration(result.DurationMs)*time.Millisecond)
+}
+
+func runHistoryCompare(cmd *cobra.Command, args []string, out io.Writer) error {
+	store, err := newHistoryStore(cmd)
+	if err != nil {
+		return err
+	}
+	var runs [2]*history.Run
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid run ID %q", arg)
+		}
+		if runs[i], err = store.Get(id); err != nil {
+			return err
+		}
+	}
+	before, after := runs[0], runs[1]
+	for _, run := range runs {
+		if run.Stats == nil {
+			run.Stats = &ConfigureStats{}
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\tRUN %d\tRUN %d\n", before.ID, after.ID)
+	fmt.Fprintf(w, "Started\t%v\t%v\n", before.Started.Local().Format(time.DateTime), after.Started.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Tenant\t%v\t%v\n", before.Tenant, after.Tenant)
+	counters := []struct {
+		name          string
+		before, after int
+	}{
+		{"Artifacts configured", before.Stats.ArtifactsConfigured.Value(), after.Stats.ArtifactsConfigured.Value()},
+		{"Artifacts failed", before.Stats.ArtifactsFailed.Value(), after.Stats.ArtifactsFailed.Value()},
+		{"Parameters updated", before.Stats.ParametersUpdated.Value(), after.Stats.ParametersUpdated.Value()},
+		{"Deployments failed", before.Stats.DeploymentTasksFailed.Value(), after.Stats.DeploymentTasksFailed.Value()},
+		{"API requests", before.Stats.Timings.APIRequests, after.Stats.Timings.APIRequests},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "%v\t%d\t%d\n", c.name, c.before, c.after)
+	}
+	timings := []struct {
+		name          string
+		before, after time.Duration
+	}{
+		{"Total duration", before.Stats.Timings.Total, after.Stats.Timings.Total},
+		{"Configure phase", before.Stats.Timings.Configure, after.Stats.Timings.Configure},
+		{"Deploy phase", before.Stats.Timings.Deploy, after.Stats.Timings.Deploy},
+		{"Average per artifact", before.Stats.Timings.AveragePerArtifact, after.Stats.Timings.AveragePerArtifact},
+		{"API latency p95", before.Stats.Timings.APILatencyP95, after.Stats.Timings.APILatencyP95},
+	}
+	for _, t := range timings {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", t.name, t.before.Round(time.Millisecond), t.after.Round(time.Millisecond))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "\tARTIFACT\tPHASE\tRUN "+strconv.Itoa(before.ID)+"\tRUN "+strconv.Itoa(after.ID))
+	for _, change := range history.Compare(before, after) {
+		marker := ""
+		if change.OutcomeChanged() {
+			marker = "!"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", marker, change.ArtifactID, change.Phase,
+			formatArtifactResult(change.Before), formatArtifactResult(change.After))
+	}
+	return w.Flush()
+}
+
+// recordHistory adds the results of the tenants of a configure run to the history database, if set
+func recordHistory(results []targetResult, historyDb string, dryRun bool) {
+	if historyDb == "" {
+		return
+	}
+	store, err := history.NewStore(historyDb)
+	if err != nil {
+		log.Error().Msgf("Failed to record run history: %v", err)
+		return
+	}
+	for _, r := range results {
+		run := &history.Run{Started: time.Now(), Command: "configure", Tenant: r.Target.Name, DryRun: dryRun,
+			Error: errorString(r.Error), Stats: r.Stats}
+		if r.Stats != nil {
+			run.Started = run.Started.Add(-r.Stats.Timings.Total)
+		}
+		if err := store.Append(run); err != nil {
+			log.Error().Msgf("Failed to record run history: %v", err)
+			return
+		}
+		log.Info().Msgf("Run %d recorded in %s", run.ID, store.Path())
+	}
+}
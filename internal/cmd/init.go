@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// initSettings are the answers used to create the global config and the starter configuration
+type initSettings struct {
+	Host             string
+	AuthType         string // oauth or basic
+	OAuthHost        string
+	OAuthPath        string
+	GlobalConfig     string // Path of the global config file
+	PackageID        string // Package the starter configuration is created from, empty to skip
+	ConfigPath       string // Path of the starter configuration file
+	DeploymentPrefix string
+}
+
+func NewInitCommand() *cobra.Command {
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a global config and a starter configure file",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		SilenceUsage: true,
+		Long: `Create the global config file (flashpipe.yaml) with the tenant details and
+defaults of the configure command, and a starter configuration for configure
+with the current parameter values of the artifacts of a package on the tenant.
+
+Questions are asked interactively, with the values of the flags as defaults.
+Without a terminal, the flag values are used as they are.
+
+Credentials are not written to the global config. They are referenced by the
+environment variables FLASHPIPE_OAUTH_CLIENTID and FLASHPIPE_OAUTH_CLIENTSECRET
+(OAuth) or FLASHPIPE_TMN_USERID and FLASHPIPE_TMN_PASSWORD (Basic Auth), which
+are also used to read the package from the tenant.`,
+		Example: `  # Answer the questions interactively
+  flashpipe init
+
+  # Create the files without questions, e.g. in a pipeline
+  flashpipe init --tmn-host my-tenant.it-cpi018.cfapps.eu10-003.hana.ondemand.com \
+    --oauth-host my-tenant.authentication.eu10.hana.ondemand.com \
+    --package MyPackage --output ./config/dev.yml < /dev/null`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			info, statErr := os.Stdin.Stat()
+			interactive := statErr == nil && info.Mode()&os.ModeCharDevice != 0
+			err = runInit(cmd, os.Stdin, os.Stderr, interactive)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	initCmd.Flags().String("package", "", "ID of the package on the tenant the starter configuration is created from")
+	initCmd.Flags().String("output", "./configure.yml", "Path of the starter configuration file")
+	initCmd.Flags().String("global-config", "", "Path of the global config file (default is $HOME/flashpipe.yaml)")
+	initCmd.Flags().String("deployment-prefix", "", "Deployment prefix written to the starter configuration")
+	initCmd.Flags().Bool("force", false, "Overwrite existing files")
+
+	return initCmd
+}
+
+func runInit(cmd *cobra.Command, in io.Reader, out io.Writer, interactive bool) error {
+	settings := initSettings{
+		Host:             config.GetString(cmd, "tmn-host"),
+		AuthType:         "oauth",
+		OAuthHost:        config.GetString(cmd, "oauth-host"),
+		OAuthPath:        config.GetString(cmd, "oauth-path"),
+		GlobalConfig:     config.GetString(cmd, "global-config"),
+		PackageID:        config.GetString(cmd, "package"),
+		ConfigPath:       config.GetString(cmd, "output"),
+		DeploymentPrefix: config.GetString(cmd, "deployment-prefix"),
+	}
+	if settings.OAuthHost == "" && config.GetString(cmd, "tmn-userid") != "" {
+		settings.AuthType = "basic"
+	}
+	if settings.GlobalConfig == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		settings.GlobalConfig = filepath.Join(home, "flashpipe.yaml")
+	}
+	force := config.GetBool(cmd, "force")
+
+	if !interactive {
+		log.Info().Msg("No terminal, using the flag values without questions")
+	} else if err := askInitSettings(bufio.NewReader(in), out, &settings); err != nil {
+		return err
+	}
+	if settings.Host == "" {
+		return fmt.Errorf("tenant host is required")
+	}
+
+	if err := writeInitFile(settings.GlobalConfig, []byte(renderGlobalConfig(settings)), force); err != nil {
+		return err
+	}
+	log.Info().Msgf("Global config written to %s", settings.GlobalConfig)
+
+	if settings.PackageID == "" {
+		log.Info().Msg("No package given, skipping the starter configuration")
+		return nil
+	}
+	exe, err := newInitExecuter(cmd, settings)
+	if err != nil {
+		return err
+	}
+	starter, err := starterConfiguration(exe, settings.PackageID)
+	if err != nil {
+		return err
+	}
+	starter.DeploymentPrefix = settings.DeploymentPrefix
+	data, err := yaml.Marshal(starter)
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# Starter configuration for flashpipe configure\n# Generated by: flashpipe init from package %s of %s\n#\n"+
+		"# Remove the parameters that do not differ between environments and set the\n"+
+		"# values of the others. See docs/configure.md for all fields.\n\n", settings.PackageID, settings.Host)
+	if err := writeInitFile(settings.ConfigPath, append([]byte(header), data...), force); err != nil {
+		return err
+	}
+	log.Info().Msgf("Starter configuration written to %s", settings.ConfigPath)
+	log.Info().Msgf("Next: flashpipe configure --config-path %s --dry-run", settings.ConfigPath)
+	return nil
+}
+
+// askInitSettings asks for each setting, keeping the current value when the answer is empty
+func askInitSettings(in *bufio.Reader, out io.Writer, settings *initSettings) error {
+	questions := []struct {
+		prompt string
+		value  *string
+		skip   func() bool
+	}{
+		{"Tenant host (without https://)", &settings.Host, nil},
+		{"Authentication (oauth or basic)", &settings.AuthType, nil},
+		{"OAuth token host (without https://)", &settings.OAuthHost, func() bool { return settings.AuthType != "oauth" }},
+		{"OAuth token path", &settings.OAuthPath, func() bool { return settings.AuthType != "oauth" }},
+		{"Global config file", &settings.GlobalConfig, nil},
+		{"Package for the starter configuration (empty to skip)", &settings.PackageID, nil},
+		{"Starter configuration file", &settings.ConfigPath, func() bool { return settings.PackageID == "" }},
+		{"Deployment prefix", &settings.DeploymentPrefix, func() bool { return settings.PackageID == "" }},
+	}
+	for _, q := range questions {
+		if q.skip != nil && q.skip() {
+			continue
+		}
+		if *q.value != "" {
+			fmt.Fprintf(out, "%s [%s]: ", q.prompt, *q.value)
+		} else {
+			fmt.Fprintf(out, "%s: ", q.prompt)
+		}
+		answer, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if answer = strings.TrimSpace(answer); answer != "" {
+			*q.value = answer
+		}
+		if err == io.EOF {
+			fmt.Fprintln(out)
+		}
+	}
+	if settings.AuthType != "oauth" && settings.AuthType != "basic" {
+		return fmt.Errorf("invalid authentication %q (valid values: oauth, basic)", settings.AuthType)
+	}
+	return nil
+}
+
+// renderGlobalConfig returns the global config file with the tenant details and the defaults of configure.
+// Credentials are left to environment variables, so that the file can be shared and committed.
+func renderGlobalConfig(settings initSettings) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Global config for FlashPipe, generated by flashpipe init\n")
+	fmt.Fprintf(&b, "tmn-host: %s\n", settings.Host)
+	if settings.AuthType == "oauth" {
+		fmt.Fprintf(&b, "oauth-host: %s\n", settings.OAuthHost)
+		fmt.Fprintf(&b, "oauth-path: %s\n", settings.OAuthPath)
+		fmt.Fprintf(&b, "# Credentials are read from the environment variables\n")
+		fmt.Fprintf(&b, "# FLASHPIPE_OAUTH_CLIENTID and FLASHPIPE_OAUTH_CLIENTSECRET\n")
+	} else {
+		fmt.Fprintf(&b, "# Credentials are read from the environment variables\n")
+		fmt.Fprintf(&b, "# FLASHPIPE_TMN_USERID and FLASHPIPE_TMN_PASSWORD\n")
+	}
+	fmt.Fprintf(&b, "\nconfigure:\n")
+	if settings.PackageID != "" {
+		fmt.Fprintf(&b, "  configPath: %s\n", settings.ConfigPath)
+	}
+	if settings.DeploymentPrefix != "" {
+		fmt.Fprintf(&b, "  deploymentPrefix: %s\n", settings.DeploymentPrefix)
+	}
+	fmt.Fprintf(&b, "  deployRetries: 5\n")
+	fmt.Fprintf(&b, "  deployDelaySeconds: 15\n")
+	fmt.Fprintf(&b, "  parallelDeployments: 3\n")
+	fmt.Fprintf(&b, "  batchSize: %d\n", httpclnt.DefaultBatchSize)
+	return b.String()
+}
+
+// newInitExecuter returns an executer for the tenant of the settings with the credentials of the environment
+func newInitExecuter(cmd *cobra.Command, settings initSettings) (*httpclnt.HTTPExecuter, error) {
+	details := &api.ServiceDetails{Host: settings.Host}
+	if settings.AuthType == "oauth" {
+		details.OauthHost = settings.OAuthHost
+		details.OauthPath = settings.OAuthPath
+		details.OauthClientId = config.GetString(cmd, "oauth-clientid")
+		details.OauthClientSecret = config.GetString(cmd, "oauth-clientsecret")
+		if details.OauthClientId == "" || details.OauthClientSecret == "" {
+			return nil, fmt.Errorf("reading package %s requires FLASHPIPE_OAUTH_CLIENTID and FLASHPIPE_OAUTH_CLIENTSECRET", settings.PackageID)
+		}
+	} else {
+		details.Userid = config.GetString(cmd, "tmn-userid")
+		details.Password = config.GetString(cmd, "tmn-password")
+		if details.Userid == "" || details.Password == "" {
+			return nil, fmt.Errorf("reading package %s requires FLASHPIPE_TMN_USERID and FLASHPIPE_TMN_PASSWORD", settings.PackageID)
+		}
+	}
+	return api.InitHTTPExecuter(details), nil
+}
+
+// starterConfiguration returns a configuration with the integration flows of the package and their
+// current parameter values. Other artifact types have no configuration parameters and are skipped.
+func starterConfiguration(exe *httpclnt.HTTPExecuter, packageID string) (*models.ConfigureConfig, error) {
+	packageData, _, exists, err := api.NewIntegrationPackage(exe).Get(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("package %s not found", packageID)
+	}
+	artifacts, err := api.NewIntegrationPackage(exe).GetArtifactsData(packageID, "Integration")
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := models.ConfigurePackage{ID: packageID, DisplayName: packageData.Root.Name}
+	configuration := api.NewConfiguration(exe)
+	for _, artifact := range artifacts {
+		current, err := configuration.Get(artifact.Id, "active")
+		if err != nil {
+			return nil, err
+		}
+		starter := models.ConfigureArtifact{ID: artifact.Id, DisplayName: artifact.Name, Type: "Integration", Version: "active"}
+		for _, param := range current.Root.Results {
+			starter.Parameters = append(starter.Parameters, models.ConfigurationParameter{Key: param.ParameterKey, Value: param.ParameterValue})
+		}
+		pkg.Artifacts = append(pkg.Artifacts, starter)
+	}
+	log.Info().Msgf("Package %s has %d integration flow(s)", packageID, len(pkg.Artifacts))
+	return &models.ConfigureConfig{Packages: []models.ConfigurePackage{pkg}}, nil
+}
+
+// writeInitFile writes a file created by init, refusing to overwrite an existing file unless forced
+func writeInitFile(path string, data []byte, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAskInitSettings(t *testing.T) {
+	settings := initSettings{AuthType: "oauth", OAuthPath: "/oauth/token", GlobalConfig: "flashpipe.yaml", ConfigPath: "./configure.yml"}
+	answers := "tenant.hana.ondemand.com\n\nauth.hana.ondemand.com\n\n\nMyPackage\n./config/dev.yml\n"
+
+	require.NoError(t, askInitSettings(bufio.NewReader(strings.NewReader(answers)), io.Discard, &settings))
+	assert.Equal(t, "tenant.hana.ondemand.com", settings.Host)
+	assert.Equal(t, "oauth", settings.AuthType, "Empty answer keeps the default")
+	assert.Equal(t, "/oauth/token", settings.OAuthPath, "Empty answer keeps the default")
+	assert.Equal(t, "MyPackage", settings.PackageID)
+	assert.Equal(t, "./config/dev.yml", settings.ConfigPath)
+	assert.Equal(t, "", settings.DeploymentPrefix, "Unanswered questions keep the default")
+
+	global := renderGlobalConfig(settings)
+	assert.Contains(t, global, "tmn-host: tenant.hana.ondemand.com\n")
+	assert.Contains(t, global, "oauth-host: auth.hana.ondemand.com\n")
+	assert.Contains(t, global, "  configPath: ./config/dev.yml\n")
+	assert.NotContains(t, global, "clientsecret:", "Credentials should not be written")
+
+	settings = initSettings{}
+	err := askInitSettings(bufio.NewReader(strings.NewReader("tenant\nkerberos\n")), io.Discard, &settings)
+	assert.Error(t, err, "Unknown authentication")
+}
+
+func TestStarterConfigurationMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationPackages('MyPackage')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "Id": "MyPackage", "Name": "My Package" } }`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationPackages('MyPackage')/IntegrationDesigntimeArtifacts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "Id": "Orders", "Name": "Replicate Orders", "Version": "1.0.0" } ] } }`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationDesigntimeArtifacts(Id='Orders',Version='active')/Configurations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "d": { "results": [ { "ParameterKey": "Host", "ParameterValue": "dev-host" } ] } }`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	cfg, err := starterConfiguration(exe, "MyPackage")
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	pkg := cfg.Packages[0]
+	assert.Equal(t, "My Package", pkg.DisplayName)
+	require.Len(t, pkg.Artifacts, 1)
+	assert.Equal(t, "Orders", pkg.Artifacts[0].ID)
+	assert.Equal(t, "Integration", pkg.Artifacts[0].Type)
+	require.Len(t, pkg.Artifacts[0].Parameters, 1)
+	assert.Equal(t, "dev-host", pkg.Artifacts[0].Parameters[0].Value)
+
+	_, err = starterConfiguration(exe, "Unknown")
+	assert.Error(t, err, "Unknown package")
+}
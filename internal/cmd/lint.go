@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/lint"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewLintCommand() *cobra.Command {
+
+	lintCmd := &cobra.Command{
+		Use:          "lint",
+		Short:        "Check configuration files against rules",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Check configuration files of the configure command and the layout of the
+repository against opinionated rules, without connecting to a tenant.
+
+Built-in rules:
+  invalid-config        YAML syntax and the checks of configure (error)
+  artifact-id-naming    Artifact IDs match a naming convention (warning)
+  mandatory-parameters  Parameters have values, keys required for all integration flows are set (error)
+  inline-secret         Secrets are not written in the file (error)
+  prod-deploy           Production configurations deploy the artifacts they configure (warning)
+  parameter-conflict    Parameters set to different values in several files (warning)
+  from-file-missing     Files referenced with fromFile exist (error)
+  artifact-in-repo      Configured artifacts exist in --dir-artifacts (warning)
+
+A rules file changes the severity (off, info, warning, error) and settings of
+built-in rules and adds custom rules. Findings listed in the baseline file are
+not reported, so that a rule can be introduced before all findings are fixed.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'lint' section. CLI flags override config file settings.`,
+		Example: `  # Lint the configuration files of a folder
+  flashpipe lint --config-path ./config
+
+  # Custom rules, fail on warnings
+  flashpipe lint --config-path ./config --rules lint-rules.yml --fail-on warning
+
+  # Accept the current findings, then only report new ones
+  flashpipe lint --config-path ./config --baseline lint-baseline.yml --update-baseline
+  flashpipe lint --config-path ./config --baseline lint-baseline.yml`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runLint(cmd, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	lintCmd.Flags().StringP("config-path", "c", "", "Path to configuration YAML, JSON or TOML file or folder, defaults to configure.configPath (config: lint.configPath)")
+	lintCmd.Flags().String("rules", "", "Rules file with settings of built-in rules and custom rules (config: lint.rules)")
+	lintCmd.Flags().String("baseline", "", "Baseline file of accepted findings (config: lint.baseline)")
+	lintCmd.Flags().Bool("update-baseline", false, "Write the current findings to the baseline file (config: lint.updateBaseline)")
+	lintCmd.Flags().String("fail-on", lint.SeverityError, "Minimum severity of findings that fail the command: info, warning or error (config: lint.failOn)")
+	lintCmd.Flags().String("output", "text", "Output format: text or json (config: lint.output)")
+	lintCmd.Flags().String("dir-artifacts", "", "Directory of the artifacts synced to Git, checked by artifact-in-repo (config: lint.dirArtifacts)")
+
+	return lintCmd
+}
+
+func runLint(cmd *cobra.Command, out io.Writer) error {
+	configPath := config.GetStringWithFallback(cmd, "config-path", "lint.configPath")
+	if configPath == "" {
+		configPath = viper.GetString("configure.configPath")
+	}
+	configPath, cleanup, err := envConfigPath(configPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if configPath == "" {
+		return fmt.Errorf("--config-path is required (set via CLI flag, in config file under 'lint.configPath' or as content in %s)", configB64Env)
+	}
+	rulesFile := config.GetStringWithFallback(cmd, "rules", "lint.rules")
+	baselineFile := config.GetStringWithFallback(cmd, "baseline", "lint.baseline")
+	updateBaseline := config.GetBoolWithFallback(cmd, "update-baseline", "lint.updateBaseline")
+	failOn := config.GetStringWithFallback(cmd, "fail-on", "lint.failOn")
+	output := config.GetStringWithFallback(cmd, "output", "lint.output")
+	artifactsDir := config.GetStringWithFallback(cmd, "dir-artifacts", "lint.dirArtifacts")
+
+	switch failOn {
+	case lint.SeverityInfo, lint.SeverityWarning, lint.SeverityError:
+	default:
+		return fmt.Errorf("invalid --fail-on %q, must be info, warning or error", failOn)
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid --output %q, must be text or json", output)
+	}
+	if updateBaseline && baselineFile == "" {
+		return fmt.Errorf("--update-baseline requires --baseline")
+	}
+
+	var rules *lint.RuleSet
+	if rulesFile != "" {
+		var err error
+		if rules, err = lint.LoadRules(rulesFile); err != nil {
+			return err
+		}
+	}
+	linter, err := lint.NewLinter(rules)
+	if err != nil {
+		return err
+	}
+	findings, err := linter.Lint(configPath, lint.Options{ArtifactsDir: artifactsDir})
+	if err != nil {
+		return err
+	}
+
+	if updateBaseline {
+		if err := lint.WriteBaseline(baselineFile, findings); err != nil {
+			return err
+		}
+		log.Info().Msgf("%d finding(s) written to baseline %s", len(findings), baselineFile)
+		return nil
+	}
+	if baselineFile != "" {
+		baseline, err := lint.LoadBaseline(baselineFile)
+		if err != nil {
+			return err
+		}
+		var suppressed int
+		findings, suppressed = baseline.Filter(findings)
+		if suppressed > 0 {
+			log.Info().Msgf("%d finding(s) suppressed by baseline %s", suppressed, baselineFile)
+		}
+	}
+
+	failed := 0
+	for _, f := range findings {
+		if lint.SeverityAtLeast(f.Severity, failOn) {
+			failed++
+		}
+	}
+	if output == "json" {
+		if findings == nil {
+			findings = []lint.Finding{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Fprintln(out, f)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d finding(s) with severity %s or higher", failed, failOn)
+	}
+	log.Info().Msgf("Lint passed with %d finding(s) below severity %s", len(findings), failOn)
+	return nil
+}
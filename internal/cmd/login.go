@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/keychain"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// annotationManagesKeychain marks commands that manage the credentials stored in the keychain, which are
+// therefore not applied to their flags
+const annotationManagesKeychain = "flashpipe_manages_keychain"
+
+// storedCredentials are the connection details of a tenant stored in the keychain by login
+type storedCredentials struct {
+	Host              string `json:"tmnHost"`
+	OauthHost         string `json:"oauthHost,omitempty"`
+	OauthPath         string `json:"oauthPath,omitempty"`
+	OauthClientId     string `json:"oauthClientId,omitempty"`
+	OauthClientSecret string `json:"oauthClientSecret,omitempty"`
+	Userid            string `json:"userId,omitempty"`
+	Password          string `json:"password,omitempty"`
+	Browser           bool   `json:"browser,omitempty"` // The user logs in in the browser, see --oauth-browser
+}
+
+// flags returns the values of the credentials by the name of their global flag
+func (c storedCredentials) flags() map[string]string {
+	browser := ""
+	if c.Browser {
+		browser = strconv.FormatBool(c.Browser)
+	}
+	return map[string]string{
+		"tmn-host":           c.Host,
+		"oauth-host":         c.OauthHost,
+		"oauth-path":         c.OauthPath,
+		"oauth-clientid":     c.OauthClientId,
+		"oauth-clientsecret": c.OauthClientSecret,
+		"tmn-userid":         c.Userid,
+		"tmn-password":       c.Password,
+		"oauth-browser":      browser,
+	}
+}
+
+func NewLoginCommand() *cobra.Command {
+
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Store the credentials of a tenant in the OS keychain",
+		Annotations: map[string]string{
+			annotationTenantOptional:  "true",
+			annotationManagesKeychain: "true",
+		},
+		SilenceUsage: true,
+		Long: `Store the host and credentials of a tenant under the name given by --tenant
+in the credential store of the operating system: the login keychain on
+macOS, the Secret Service (libsecret, secret-tool) on Linux and files
+encrypted with DPAPI for the current user on Windows.
+
+All commands run with --tenant (or 'tenant' in the global config file) use
+the stored credentials, so that no secrets are kept in config files or the
+shell history. Flags, environment variables and the config file take
+precedence over the stored values.
+
+Values not given by flags are asked interactively, secrets without echo
+except on Windows. Logging in again replaces the stored credentials.
+
+With --oauth-browser, no client secret is stored: you log in with your own
+user in the browser (authorization code flow with PKCE), so that changes are
+made under your identity. The tokens are cached in the keychain and
+refreshed, you are only asked to log in again once they expire.`,
+		Example: `  # Store the OAuth client of the development tenant
+  flashpipe login --tenant dev --tmn-host my-tenant.it-cpi018.cfapps.eu10-003.hana.ondemand.com \
+    --oauth-host my-tenant.authentication.eu10.hana.ondemand.com
+
+  # Log in with your own user in the browser
+  flashpipe login --tenant dev --oauth-browser --tmn-host my-tenant.it-cpi018.cfapps.eu10-003.hana.ondemand.com \
+    --oauth-host my-subaccount.authentication.eu10.hana.ondemand.com --oauth-clientid sb-flashpipe!t123
+
+  # Use the stored credentials
+  flashpipe sync --tenant dev --dir-git-repo ./repo`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			info, statErr := os.Stdin.Stat()
+			interactive := statErr == nil && info.Mode()&os.ModeCharDevice != 0
+			err = runLogin(cmd, os.Stdin, os.Stderr, interactive)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	return loginCmd
+}
+
+func NewLogoutCommand() *cobra.Command {
+
+	logoutCmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Remove the credentials of a tenant from the OS keychain",
+		Annotations: map[string]string{
+			annotationTenantOptional:  "true",
+			annotationManagesKeychain: "true",
+		},
+		SilenceUsage: true,
+		Long:         `Remove the credentials stored by login under the name given by --tenant.`,
+		Example: `  # Remove the credentials of the development tenant
+  flashpipe logout --tenant dev`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runLogout(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	return logoutCmd
+}
+
+func runLogin(cmd *cobra.Command, in io.Reader, out io.Writer, interactive bool) error {
+	tenant := config.GetString(cmd, "tenant")
+	if tenant == "" {
+		return fmt.Errorf("--tenant is required, the name the credentials are stored under")
+	}
+	credentials := storedCredentials{
+		Host:              config.GetString(cmd, "tmn-host"),
+		OauthHost:         config.GetString(cmd, "oauth-host"),
+		OauthPath:         config.GetString(cmd, "oauth-path"),
+		OauthClientId:     config.GetString(cmd, "oauth-clientid"),
+		OauthClientSecret: config.GetString(cmd, "oauth-clientsecret"),
+		Userid:            config.GetString(cmd, "tmn-userid"),
+		Password:          config.GetString(cmd, "tmn-password"),
+		Browser:           config.GetBool(cmd, "oauth-browser"),
+	}
+	if interactive {
+		if err := askLoginCredentials(bufio.NewReader(in), out, &credentials, setTerminalEcho); err != nil {
+			return err
+		}
+	}
+	if credentials.Host == "" {
+		return fmt.Errorf("tenant host is required")
+	}
+	if credentials.Browser {
+		if credentials.OauthHost == "" || credentials.OauthClientId == "" {
+			return fmt.Errorf("OAuth token host and client ID are required for the browser login")
+		}
+		credentials.Userid, credentials.Password = "", ""
+	} else if credentials.OauthHost != "" {
+		if credentials.OauthClientId == "" || credentials.OauthClientSecret == "" {
+			return fmt.Errorf("client ID and client secret are required for OAuth")
+		}
+		credentials.Userid, credentials.Password = "", ""
+	} else {
+		if credentials.Userid == "" || credentials.Password == "" {
+			return fmt.Errorf("user ID and password are required for Basic Auth")
+		}
+		credentials.OauthPath, credentials.OauthClientId, credentials.OauthClientSecret = "", "", ""
+	}
+
+	data, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	if err := keychain.Set(tenant, string(data)); err != nil {
+		return fmt.Errorf("failed to store credentials of tenant %s: %w", tenant, err)
+	}
+	log.Info().Msgf("Credentials of tenant %s stored in the keychain, use them with --tenant %s", tenant, tenant)
+
+	// The user logs in right away, so that later commands use the cached tokens
+	if credentials.Browser {
+		serviceDetails, err := storedServiceDetails(tenant)
+		if err != nil {
+			return err
+		}
+		if _, _, err := api.NewCsrf(api.InitHTTPExecuter(serviceDetails)).GetToken(); err != nil {
+			return fmt.Errorf("browser login to tenant %s failed: %w", tenant, err)
+		}
+	}
+	return nil
+}
+
+// askLoginCredentials asks for the missing connection details and credentials. Secrets are read with echo
+// turned off by setEcho.
+func askLoginCredentials(in *bufio.Reader, out io.Writer, credentials *storedCredentials, setEcho func(on bool)) error {
+	ask := func(prompt string, value *string, secret bool) error {
+		if *value != "" {
+			return nil
+		}
+		fmt.Fprintf(out, "%s: ", prompt)
+		if secret {
+			setEcho(false)
+			defer func() {
+				setEcho(true)
+				fmt.Fprintln(out)
+			}()
+		}
+		answer, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		*value = strings.TrimSpace(answer)
+		return nil
+	}
+
+	if err := ask("Tenant host (without https://)", &credentials.Host, false); err != nil {
+		return err
+	}
+	if credentials.Browser {
+		if err := ask("OAuth token host (without https://)", &credentials.OauthHost, false); err != nil {
+			return err
+		}
+		return ask("Client ID", &credentials.OauthClientId, false)
+	}
+	if credentials.Userid == "" {
+		if err := ask("OAuth token host (without https://, empty for Basic Auth)", &credentials.OauthHost, false); err != nil {
+			return err
+		}
+	}
+	if credentials.OauthHost != "" {
+		if err := ask("Client ID", &credentials.OauthClientId, false); err != nil {
+			return err
+		}
+		return ask("Client secret", &credentials.OauthClientSecret, true)
+	}
+	if err := ask("User ID", &credentials.Userid, false); err != nil {
+		return err
+	}
+	return ask("Password", &credentials.Password, true)
+}
+
+// setTerminalEcho turns the echo of the terminal on or off with stty. Windows consoles keep the echo.
+func setTerminalEcho(on bool) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	stty := exec.Command("stty", arg)
+	stty.Stdin = os.Stdin
+	_ = stty.Run()
+}
+
+func runLogout(cmd *cobra.Command) error {
+	tenant := config.GetString(cmd, "tenant")
+	if tenant == "" {
+		return fmt.Errorf("--tenant is required, the name the credentials are stored under")
+	}
+	// The cached tokens of the browser login are removed as well
+	if credentials, err := readStoredCredentials(tenant); err == nil && credentials.Browser {
+		if err := keychain.Delete(tokenAccount(httpclnt.BrowserTokenKey(credentials.OauthHost, credentials.OauthClientId))); err != nil && !errors.Is(err, keychain.ErrNotFound) {
+			log.Warn().Msgf("Failed to remove the cached tokens of tenant %s: %v", tenant, err)
+		}
+	}
+	if err := keychain.Delete(tenant); errors.Is(err, keychain.ErrNotFound) {
+		return fmt.Errorf("no credentials of tenant %s stored in the keychain", tenant)
+	} else if err != nil {
+		return fmt.Errorf("failed to remove credentials of tenant %s: %w", tenant, err)
+	}
+	log.Info().Msgf("Credentials of tenant %s removed from the keychain", tenant)
+	return nil
+}
+
+// applyStoredCredentials sets the tenant flags that are not set by flags, environment variables or the config
+// file to the credentials stored by login for --tenant
+func applyStoredCredentials(cmd *cobra.Command) error {
+	// history list filters by its own --tenant flag
+	if cmd.Flags().Lookup("tenant") != cmd.Root().PersistentFlags().Lookup("tenant") {
+		return nil
+	}
+	tenant := config.GetString(cmd, "tenant")
+	if tenant == "" || cmd.Annotations[annotationManagesKeychain] == "true" {
+		return nil
+	}
+	credentials, err := readStoredCredentials(tenant)
+	if err != nil {
+		return err
+	}
+	for name, value := range credentials.flags() {
+		if f := cmd.Flags().Lookup(name); f != nil && !f.Changed && value != "" {
+			if err := config.SetFlag(cmd, name, value, config.SourceKeychain, tenant); err != nil {
+				return err
+			}
+		}
+	}
+	log.Debug().Msgf("Using credentials of tenant %s from the keychain", tenant)
+	return nil
+}
+
+// readStoredCredentials returns the credentials stored by login for tenant
+func readStoredCredentials(tenant string) (*storedCredentials, error) {
+	data, err := keychain.Get(tenant)
+	if errors.Is(err, keychain.ErrNotFound) {
+		return nil, fmt.Errorf("no credentials of tenant %s stored in the keychain, store them with flashpipe login --tenant %s", tenant, tenant)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials of tenant %s: %w", tenant, err)
+	}
+	credentials := new(storedCredentials)
+	if err := json.Unmarshal([]byte(data), credentials); err != nil {
+		return nil, fmt.Errorf("invalid credentials of tenant %s in the keychain, log in again: %w", tenant, err)
+	}
+	return credentials, nil
+}
+
+// storedTenantExecuter returns the HTTP executer of the tenant whose credentials are stored by login under name,
+// e.g. for the source tenant of a promotion
+func storedTenantExecuter(name string) (*httpclnt.HTTPExecuter, error) {
+	serviceDetails, err := storedServiceDetails(name)
+	if err != nil {
+		return nil, err
+	}
+	return api.InitHTTPExecuter(serviceDetails), nil
+}
+
+// storedServiceDetails returns the service details of the tenant whose credentials are stored by login under name
+func storedServiceDetails(name string) (*api.ServiceDetails, error) {
+	credentials, err := readStoredCredentials(name)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ServiceDetails{
+		Host:              credentials.Host,
+		OauthHost:         credentials.OauthHost,
+		OauthPath:         credentials.OauthPath,
+		OauthClientId:     credentials.OauthClientId,
+		OauthClientSecret: credentials.OauthClientSecret,
+		Userid:            credentials.Userid,
+		Password:          credentials.Password,
+	}, nil
+}
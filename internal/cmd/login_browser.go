@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/keychain"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// browserLoginTimeout is the time the user has to log in in the browser
+const browserLoginTimeout = 5 * time.Minute
+
+// keychainTokenCache keeps the tokens of the browser login in the keychain, so that the user only logs in again
+// once the refresh token expired
+type keychainTokenCache struct{}
+
+// tokenAccount returns the keychain account of the tokens of a client, which is a valid file name on Windows
+func tokenAccount(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "token-" + hex.EncodeToString(sum[:8])
+}
+
+func (keychainTokenCache) Load(key string) (*oauth2.Token, error) {
+	data, err := keychain.Get(tokenAccount(key))
+	if errors.Is(err, keychain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	token := new(oauth2.Token)
+	if err := json.Unmarshal([]byte(data), token); err != nil {
+		return nil, fmt.Errorf("invalid cached token: %w", err)
+	}
+	return token, nil
+}
+
+func (keychainTokenCache) Save(key string, token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return keychain.Set(tokenAccount(key), string(data))
+}
+
+// browserLogin returns the browser login of --oauth-browser, nil for client credentials
+func browserLogin(cmd *cobra.Command) (*httpclnt.BrowserLogin, error) {
+	if !config.GetBoolWithFallback(cmd, "oauth-browser", "auth.browser") {
+		return nil, nil
+	}
+	port := config.GetIntWithFallback(cmd, "oauth-callback-port", "auth.callbackPort")
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("--oauth-callback-port must be between 0 and 65535")
+	}
+	return &httpclnt.BrowserLogin{Cache: keychainTokenCache{}, CallbackPort: port, Timeout: browserLoginTimeout, Open: openBrowser}, nil
+}
+
+// openBrowser opens the URL in the default browser of the user
+func openBrowser(url string) error {
+	var browser *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		browser = exec.Command("open", url)
+	case "windows":
+		browser = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		browser = exec.Command("xdg-open", url)
+	}
+	return browser.Start()
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/keychain"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// memoryKeychain is a keychain.Store in memory
+type memoryKeychain map[string]string
+
+func (m memoryKeychain) Get(account string) (string, error) {
+	secret, ok := m[account]
+	if !ok {
+		return "", keychain.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (m memoryKeychain) Set(account, secret string) error {
+	m[account] = secret
+	return nil
+}
+
+func (m memoryKeychain) Delete(account string) error {
+	if _, ok := m[account]; !ok {
+		return keychain.ErrNotFound
+	}
+	delete(m, account)
+	return nil
+}
+
+func TestLoginStoredCredentials(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	store := memoryKeychain{}
+	defaultStore := keychain.Default
+	keychain.Default = store
+	t.Cleanup(func() { keychain.Default = defaultStore })
+	// No global config file in the home directory
+	t.Setenv("HOME", t.TempDir())
+
+	root := NewCmdRoot()
+	loginCmd := NewLoginCommand()
+	logoutCmd := NewLogoutCommand()
+	deployCmd := NewDeployCommand()
+	root.AddCommand(loginCmd, logoutCmd, deployCmd)
+
+	require.NoError(t, loginCmd.ParseFlags([]string{"--tenant", "dev", "--tmn-host", "dev.hana.ondemand.com",
+		"--oauth-host", "dev.authentication.hana.ondemand.com", "--oauth-clientid", "client", "--oauth-clientsecret", "secret"}))
+	require.NoError(t, initializeConfig(loginCmd))
+	require.NoError(t, runLogin(loginCmd, strings.NewReader(""), io.Discard, false))
+	assert.JSONEq(t, `{"tmnHost": "dev.hana.ondemand.com", "oauthHost": "dev.authentication.hana.ondemand.com", "oauthPath": "/oauth/token",
+		"oauthClientId": "client", "oauthClientSecret": "secret"}`, store["dev"])
+
+	// The stored credentials are used by all commands with --tenant, flags take precedence
+	require.NoError(t, deployCmd.ParseFlags([]string{"--tenant", "dev", "--oauth-path", "/custom/token"}))
+	require.NoError(t, initializeConfig(deployCmd))
+	assert.Equal(t, "dev.hana.ondemand.com", config.GetString(deployCmd, "tmn-host"))
+	assert.Equal(t, "secret", config.GetString(deployCmd, "oauth-clientsecret"))
+	assert.Equal(t, "/custom/token", config.GetString(deployCmd, "oauth-path"))
+	assert.Equal(t, "", config.GetString(deployCmd, "tmn-userid"))
+
+	require.NoError(t, logoutCmd.ParseFlags([]string{"--tenant", "dev"}))
+	require.NoError(t, runLogout(logoutCmd))
+	assert.Empty(t, store)
+	assert.EqualError(t, runLogout(logoutCmd), "no credentials of tenant dev stored in the keychain")
+
+	deployCmd = NewDeployCommand()
+	root.AddCommand(deployCmd)
+	require.NoError(t, deployCmd.ParseFlags([]string{"--tenant", "dev"}))
+	assert.EqualError(t, initializeConfig(deployCmd), "no credentials of tenant dev stored in the keychain, store them with flashpipe login --tenant dev")
+}
+
+func TestAskLoginCredentials(t *testing.T) {
+	var echo []bool
+	setEcho := func(on bool) { echo = append(echo, on) }
+
+	credentials := storedCredentials{Host: "dev.hana.ondemand.com"}
+	answers := "\nuser\npassword\n"
+	require.NoError(t, askLoginCredentials(bufio.NewReader(strings.NewReader(answers)), io.Discard, &credentials, setEcho))
+	assert.Equal(t, storedCredentials{Host: "dev.hana.ondemand.com", Userid: "user", Password: "password"}, credentials, "Empty OAuth host selects Basic Auth")
+	assert.Equal(t, []bool{false, true}, echo, "Echo should be off while reading the password only")
+
+	credentials = storedCredentials{OauthClientId: "client"}
+	answers = "dev.hana.ondemand.com\ndev.authentication.hana.ondemand.com\nsecret"
+	require.NoError(t, askLoginCredentials(bufio.NewReader(strings.NewReader(answers)), io.Discard, &credentials, setEcho))
+	assert.Equal(t, storedCredentials{Host: "dev.hana.ondemand.com", OauthHost: "dev.authentication.hana.ondemand.com",
+		OauthClientId: "client", OauthClientSecret: "secret"}, credentials)
+	credentials = storedCredentials{Browser: true}
+	echo = nil
+	answers = "dev.hana.ondemand.com\ndev.authentication.hana.ondemand.com\nsb-flashpipe!t1\n"
+	require.NoError(t, askLoginCredentials(bufio.NewReader(strings.NewReader(answers)), io.Discard, &credentials, setEcho))
+	assert.Equal(t, storedCredentials{Host: "dev.hana.ondemand.com", OauthHost: "dev.authentication.hana.ondemand.com",
+		OauthClientId: "sb-flashpipe!t1", Browser: true}, credentials, "The browser login should not ask for a secret")
+	assert.Empty(t, echo)
+}
+
+func TestBrowserLoginTokens(t *testing.T) {
+	store := memoryKeychain{}
+	defaultStore := keychain.Default
+	keychain.Default = store
+	t.Cleanup(func() { keychain.Default = defaultStore })
+
+	key := httpclnt.BrowserTokenKey("dev.authentication.hana.ondemand.com", "sb-flashpipe!t1")
+	token, err := keychainTokenCache{}.Load(key)
+	require.NoError(t, err)
+	assert.Nil(t, token)
+	expiry := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, keychainTokenCache{}.Save(key, &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: expiry}))
+	token, err = keychainTokenCache{}.Load(key)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.True(t, expiry.Equal(token.Expiry))
+
+	// Logout removes the cached tokens with the credentials
+	store["dev"] = `{"tmnHost": "dev.hana.ondemand.com", "oauthHost": "dev.authentication.hana.ondemand.com", "oauthClientId": "sb-flashpipe!t1", "browser": true}`
+	logoutCmd := NewLogoutCommand()
+	NewCmdRoot().AddCommand(logoutCmd)
+	require.NoError(t, logoutCmd.ParseFlags([]string{"--tenant", "dev"}))
+	require.NoError(t, runLogout(logoutCmd))
+	assert.Empty(t, store)
+}
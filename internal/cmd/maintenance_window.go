@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/schedule"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// windowPolicy decides how deployments outside their maintenance window are handled
+type windowPolicy struct {
+	override    bool // Deploy anyway
+	waitMinutes int  // Max time to defer the deployment until the window opens
+}
+
+// addWindowFlags adds the flags of the maintenance window enforcement to a command that deploys artifacts
+func addWindowFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("override-window", false, "Deploy artifacts outside of their maintenance window (config: maintenanceWindow.override)")
+	cmd.Flags().Int("window-wait", 0, "Minutes to wait for a maintenance window to open instead of refusing the deployment (config: maintenanceWindow.waitMinutes)")
+}
+
+func newWindowPolicy(cmd *cobra.Command) windowPolicy {
+	return windowPolicy{
+		override:    config.GetBoolWithFallback(cmd, "override-window", "maintenanceWindow.override"),
+		waitMinutes: config.GetIntWithFallback(cmd, "window-wait", "maintenanceWindow.waitMinutes"),
+	}
+}
+
+// effectiveWindow returns the window of the artifact, or of its package if the artifact has none
+func effectiveWindow(packageWindow, artifactWindow *models.MaintenanceWindow) *models.MaintenanceWindow {
+	if artifactWindow != nil {
+		return artifactWindow
+	}
+	return packageWindow
+}
+
+func parseWindow(w *models.MaintenanceWindow) (*schedule.Window, error) {
+	return schedule.ParseWindow(w.TimeRange, w.Cron, w.Duration, w.Timezone)
+}
+
+// validateWindows returns an error for the first invalid maintenance window of the configuration
+func validateWindows(cfg *models.ConfigureConfig) error {
+	for _, pkg := range cfg.Packages {
+		if pkg.Window != nil {
+			if _, err := parseWindow(pkg.Window); err != nil {
+				return fmt.Errorf("maintenance window of package %s: %w", pkg.ID, err)
+			}
+		}
+		for _, artifact := range pkg.Artifacts {
+			if artifact.Window != nil {
+				if _, err := parseWindow(artifact.Window); err != nil {
+					return fmt.Errorf("maintenance window of artifact %s: %w", artifact.ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// await returns once the deployment of the task is allowed by its maintenance window, deferring it for
+// at most waitMinutes. An error is returned if the deployment is refused.
+func (p windowPolicy) await(task DeploymentTask) error {
+	if task.Window == nil {
+		return nil
+	}
+	w, err := parseWindow(task.Window)
+	if err != nil {
+		return fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	now := time.Now()
+	if w.Contains(now) {
+		return nil
+	}
+	if p.override {
+		log.Warn().Msgf("  Deploying %s outside of its maintenance window %v (window overridden)", task.ArtifactID, w)
+		return nil
+	}
+
+	next := w.NextOpen(now)
+	if !next.IsZero() && next.Sub(now) <= time.Duration(p.waitMinutes)*time.Minute {
+		log.Info().Msgf("  ⏳ Deferring deployment of %s until its maintenance window %v opens at %v", task.ArtifactID, w, next.Format(time.RFC3339))
+		time.Sleep(next.Sub(now))
+		return nil
+	}
+	return fmt.Errorf("outside of maintenance window %v (opens next at %v), use --override-window to deploy anyway",
+		w, next.Format(time.RFC3339))
+}
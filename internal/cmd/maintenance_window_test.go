@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowPolicyAwait(t *testing.T) {
+	// Window opening in two hours
+	now := time.Now().UTC()
+	closed := &models.MaintenanceWindow{
+		TimeRange: fmt.Sprintf("%02d:00-%02d:00", (now.Hour()+2)%24, (now.Hour()+3)%24),
+	}
+	task := DeploymentTask{ArtifactID: "Trading", Window: closed}
+
+	assert.NoError(t, windowPolicy{}.await(DeploymentTask{ArtifactID: "Flow"}), "Task without window should be allowed")
+	assert.ErrorContains(t, windowPolicy{}.await(task), "outside of maintenance window", "Task outside window should be refused")
+	assert.ErrorContains(t, windowPolicy{waitMinutes: 30}.await(task), "outside of maintenance window", "Window opening after max wait should be refused")
+	assert.NoError(t, windowPolicy{override: true}.await(task), "Overridden window should be allowed")
+
+	open := &models.MaintenanceWindow{TimeRange: fmt.Sprintf("%02d:00-%02d:00", now.Hour(), (now.Hour()+1)%24)}
+	assert.NoError(t, windowPolicy{}.await(DeploymentTask{ArtifactID: "Flow", Window: open}), "Task within window should be allowed")
+
+	assert.Equal(t, open, effectiveWindow(closed, open), "Artifact window should override package window")
+	assert.Equal(t, closed, effectiveWindow(closed, nil), "Package window should be used")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/monitor"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewMonitorCommand() *cobra.Command {
+
+	monitorCmd := &cobra.Command{
+		Use:          "monitor",
+		Short:        "Evaluate alerting rules against message processing logs",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Evaluate alerting rules against the message processing logs of the
+tenant, e.g. to alert from a cron job without a monitoring product.
+
+A rule counts the messages of the deployed integration flows matching its
+artifact ID or pattern with a status, FAILED by default, that ended in its
+window, and fires for each flow with more than 'above' or fewer than
+'below' messages. The alerts of fired rules are sent to the webhooks and
+Slack incoming webhooks the rules notify. The command fails if a rule
+fired, so that the scheduler reports it as well.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'monitor' section. CLI flags override config file settings.`,
+		Example: `  # Evaluate the rules every 15 minutes, e.g. in crontab
+  */15 * * * * flashpipe monitor --rules rules.yml
+
+  # Show the alerts without sending notifications
+  flashpipe monitor --rules rules.yml --dry-run --output-format json`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runMonitor(cmd, os.Stdout); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	monitorCmd.Flags().String("rules", "", "Rules file with the alerting rules and their notifications (config: monitor.rules)")
+	monitorCmd.Flags().Bool("dry-run", false, "Evaluate the rules without sending notifications (config: monitor.dryRun)")
+	monitorCmd.Flags().String("output-format", "text", "Output format of the alerts. Allowed values: text, json (config: monitor.outputFormat)")
+
+	return monitorCmd
+}
+
+func runMonitor(cmd *cobra.Command, out io.Writer) error {
+	rulesFile := config.GetStringWithFallback(cmd, "rules", "monitor.rules")
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "monitor.dryRun")
+	format := config.GetStringWithFallback(cmd, "output-format", "monitor.outputFormat")
+
+	if rulesFile == "" {
+		return fmt.Errorf("--rules is required (set via CLI flag or in config file under 'monitor.rules')")
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid value for --output-format = %v", format)
+	}
+	ruleSet, err := monitor.LoadRules(rulesFile)
+	if err != nil {
+		return err
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	runtimeArtifacts, err := api.NewRuntime(exe).List()
+	if err != nil {
+		return err
+	}
+	var deployed []string
+	for _, artifact := range runtimeArtifacts {
+		if artifact.Type == "INTEGRATION_FLOW" {
+			deployed = append(deployed, artifact.Id)
+		}
+	}
+	alerts, err := monitor.Evaluate(ruleSet.Rules, api.NewMessageProcessingLog(exe), deployed, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := writeAlerts(out, alerts, format); err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		log.Info().Msgf("🏆 No rules fired")
+		return nil
+	}
+
+	if dryRun {
+		log.Info().Msg("Dry run: no notifications sent")
+	} else if err := monitor.Notify(ruleSet.Notifications, alerts, exe.Host()); err != nil {
+		log.Error().Msgf("%v", err)
+	}
+	return fmt.Errorf("%d alert(s) fired", len(alerts))
+}
+
+func writeAlerts(out io.Writer, alerts []monitor.Alert, format string) error {
+	if format == "json" {
+		if alerts == nil {
+			alerts = []monitor.Alert{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(alerts)
+	}
+	for _, alert := range alerts {
+		if _, err := fmt.Fprintln(out, alert.Message()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// redactedValue replaces the values of sensitive fields in an incident bundle
+const redactedValue = "***REDACTED***"
+
+// BundleManifest describes the content of an incident bundle
+type BundleManifest struct {
+	MessageGuid     string   `json:"messageGuid"`
+	IntegrationFlow string   `json:"integrationFlow"`
+	Status          string   `json:"status"`
+	Created         string   `json:"created"`
+	RedactedFields  []string `json:"redactedFields,omitempty"`
+	RedactPatterns  int      `json:"redactPatterns,omitempty"`
+	Files           []string `json:"files"`
+	Warnings        []string `json:"warnings,omitempty"`
+}
+
+// redactor replaces sensitive values in the content of an incident bundle
+type redactor struct {
+	fields       []string
+	replacements []redaction
+	patterns     []*regexp.Regexp
+}
+
+// redaction replaces the value of a field
+type redaction struct {
+	re          *regexp.Regexp
+	replacement []byte
+}
+
+func NewMplCommand() *cobra.Command {
+
+	mplCmd := &cobra.Command{
+		Use:   "mpl",
+		Short: "Inspect message processing logs",
+		Long:  `Inspect the message processing logs of the SAP Integration Suite tenant.`,
+	}
+	return mplCmd
+}
+
+func NewMplBundleCommand() *cobra.Command {
+
+	bundleCmd := &cobra.Command{
+		Use:         "bundle",
+		Short:       "Collect a message processing log into an incident bundle",
+		Annotations: map[string]string{annotationReadOnly: "true"},
+		Long: `Collect the message processing log of a message with its error
+information, adapter attributes, custom header properties, attachments,
+run steps and traced payloads into a single zip file, e.g. to attach it
+to an incident ticket.
+
+Traced payloads are only collected for runs with log level TRACE and
+while the tenant keeps them, which is one hour. Parts that cannot be
+retrieved are listed as warnings in bundle.json instead of failing.
+
+The values of the fields in --redact-fields, as JSON fields, XML elements
+and XML attributes, and the matches of the regular expressions in
+--redact-patterns are replaced by ` + redactedValue + ` in all files. If a
+pattern has a capture group, only the group is replaced.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'mpl.bundle' section. CLI flags override config file settings.`,
+		Example: `  # Bundle a failed message
+  flashpipe mpl bundle --message-guid AGX1pXzYH3Lb4a8nO0tDgCLaJwqS --out incident.zip
+
+  # Redact passwords and card numbers
+  flashpipe mpl bundle --message-guid AGX1pXzYH3Lb4a8nO0tDgCLaJwqS --out incident.zip \
+    --redact-fields password,iban --redact-patterns '\b\d{4}-\d{4}-\d{4}-\d{4}\b'`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runMplBundle(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	bundleCmd.Flags().String("message-guid", "", "GUID of the message (config: mpl.bundle.messageGuid)")
+	bundleCmd.Flags().String("out", "", "Path of the zip file, defaults to mpl-<message GUID>.zip (config: mpl.bundle.out)")
+	bundleCmd.Flags().StringSlice("redact-fields", nil, "Comma separated list of JSON fields, XML elements and XML attributes whose values are redacted (config: mpl.bundle.redactFields)")
+	bundleCmd.Flags().StringSlice("redact-patterns", nil, "Comma separated list of regular expressions whose matches are redacted (config: mpl.bundle.redactPatterns)")
+	bundleCmd.Flags().Bool("trace", true, "Collect the traced payloads, headers and exchange properties (config: mpl.bundle.trace)")
+
+	return bundleCmd
+}
+
+func runMplBundle(cmd *cobra.Command) error {
+	messageGuid := config.GetStringWithFallback(cmd, "message-guid", "mpl.bundle.messageGuid")
+	out := config.GetStringWithFallback(cmd, "out", "mpl.bundle.out")
+	fields := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "redact-fields", "mpl.bundle.redactFields"))
+	patterns := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "redact-patterns", "mpl.bundle.redactPatterns"))
+	trace := config.GetBoolWithFallback(cmd, "trace", "mpl.bundle.trace")
+
+	if messageGuid == "" {
+		return fmt.Errorf("--message-guid is required (set via CLI flag or in config file under 'mpl.bundle.messageGuid')")
+	}
+	if out == "" {
+		out = fmt.Sprintf("mpl-%v.zip", messageGuid)
+	}
+	r, err := newRedactor(fields, patterns)
+	if err != nil {
+		return err
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	manifest, err := writeMplBundle(f, exe, messageGuid, r, trace)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out)
+		return err
+	}
+	for _, warning := range manifest.Warnings {
+		log.Warn().Msgf("⚠️  %v", warning)
+	}
+	log.Info().Msgf("🏆 Message processing log %v bundled in %v with %d file(s)", messageGuid, out, len(manifest.Files))
+	return nil
+}
+
+// newRedactor compiles the expressions replacing the values of the fields and the matches of the patterns
+func newRedactor(fields []string, patterns []string) (*redactor, error) {
+	r := &redactor{fields: fields}
+	for _, field := range fields {
+		name := regexp.QuoteMeta(field)
+		r.replacements = append(r.replacements,
+			// JSON field with string, number or literal value
+			redaction{regexp.MustCompile(`("` + name + `"\s*:\s*)(?:"(?:[^"\\]|\\.)*"|[^,}\]\s]+)`), []byte(`${1}"` + redactedValue + `"`)},
+			// XML element with optional namespace prefix
+			redaction{regexp.MustCompile(`(<(?:[\w.-]+:)?` + name + `(?:\s[^>]*)?>)[^<]*(</)`), []byte(`${1}` + redactedValue + `${2}`)},
+			// XML attribute
+			redaction{regexp.MustCompile(`(\s` + name + `\s*=\s*")[^"]*(")`), []byte(`${1}` + redactedValue + `${2}`)})
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for --redact-patterns = %v: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// redact returns the content with the values of the fields and the matches of the patterns replaced
+func (r *redactor) redact(content []byte) []byte {
+	for _, field := range r.replacements {
+		content = field.re.ReplaceAll(content, field.replacement)
+	}
+	for _, re := range r.patterns {
+		var redacted []byte
+		last := 0
+		for _, m := range re.FindAllSubmatchIndex(content, -1) {
+			// Replace the first capture group if the pattern has one, otherwise the match
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			redacted = append(append(redacted, content[last:start]...), redactedValue...)
+			last = end
+		}
+		content = append(redacted, content[last:]...)
+	}
+	return content
+}
+
+// writeMplBundle writes the message processing log with its details, attachments, run steps and, with trace, its
+// traced messages as zip file. Only a missing message processing log is an error, the other parts that cannot be
+// retrieved are listed as warnings.
+func writeMplBundle(out io.Writer, exe *httpclnt.HTTPExecuter, messageGuid string, r *redactor, trace bool) (*BundleManifest, error) {
+	mpl := api.NewMessageProcessingLog(exe)
+	data, body, err := mpl.Get(messageGuid)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &BundleManifest{
+		MessageGuid:     messageGuid,
+		IntegrationFlow: data.IntegrationFlowName,
+		Status:          data.Status,
+		Created:         time.Now().UTC().Format(time.RFC3339),
+		RedactedFields:  r.fields,
+		RedactPatterns:  len(r.patterns),
+		Files:           []string{},
+	}
+
+	zw := zip.NewWriter(out)
+	add := func(name string, content []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(r.redact(content)); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, name)
+		return nil
+	}
+	warn := func(format string, args ...any) {
+		manifest.Warnings = append(manifest.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	if err := add("mpl.json", body); err != nil {
+		return nil, err
+	}
+	if data.Status != "COMPLETED" {
+		if errorInfo, err := mpl.Details(messageGuid, "ErrorInformation/$value"); err != nil {
+			warn("error information not retrieved: %v", err)
+		} else if len(errorInfo) > 0 {
+			if err := add("error.txt", errorInfo); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, navigation := range []string{"AdapterAttributes", "CustomHeaderProperties"} {
+		details, err := mpl.Details(messageGuid, navigation)
+		if err != nil {
+			warn("%v not retrieved: %v", navigation, err)
+			continue
+		}
+		if err := add(navigation+".json", details); err != nil {
+			return nil, err
+		}
+	}
+
+	attachments, err := mpl.Attachments(messageGuid)
+	if err != nil {
+		warn("attachments not retrieved: %v", err)
+	}
+	for i, attachment := range attachments {
+		content, err := mpl.AttachmentContent(attachment.Id)
+		if err != nil {
+			warn("attachment %v not retrieved: %v", attachment.Name, err)
+			continue
+		}
+		if err := add(fmt.Sprintf("attachments/%02d_%v", i+1, bundleFileName(attachment.Name)), content); err != nil {
+			return nil, err
+		}
+	}
+
+	runs, err := mpl.Runs(messageGuid)
+	if err != nil {
+		warn("runs not retrieved: %v", err)
+	}
+	for _, run := range runs {
+		runDir := path.Join("runs", bundleFileName(run.Id))
+		steps, err := mpl.RunSteps(run.Id)
+		if err != nil {
+			warn("steps of run %v not retrieved: %v", run.Id, err)
+			continue
+		}
+		content, err := json.MarshalIndent(steps, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := add(runDir+"/steps.json", content); err != nil {
+			return nil, err
+		}
+		if !trace || run.LogLevel != LogLevelTrace {
+			continue
+		}
+		for _, step := range steps {
+			messages, err := mpl.TraceMessages(run.Id, step.ChildCount)
+			if err != nil {
+				warn("trace of step %v of run %v not retrieved: %v", step.ModelStepId, run.Id, err)
+				continue
+			}
+			for _, message := range messages {
+				traceDir := fmt.Sprintf("%v/trace/%04d_%v", runDir, step.ChildCount, bundleFileName(step.ModelStepId))
+				parts := map[string]string{
+					"$value":             "payload" + payloadExtension(message.MimeType),
+					"Properties":         "headers.json",
+					"ExchangeProperties": "properties.json",
+				}
+				for _, navigation := range []string{"$value", "Properties", "ExchangeProperties"} {
+					content, err := mpl.TraceMessageContent(message.TraceId, navigation)
+					if err != nil {
+						warn("trace %v of step %v not retrieved: %v", parts[navigation], step.ModelStepId, err)
+						continue
+					}
+					if err := add(traceDir+"/"+parts[navigation], content); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	w, err := zw.Create("bundle.json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	return manifest, zw.Close()
+}
+
+// bundleFileName returns the name with characters that are not allowed in file names replaced
+func bundleFileName(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_").Replace(name)
+}
+
+// payloadExtension returns the file extension of a traced payload of the MIME type
+func payloadExtension(mimeType string) string {
+	switch {
+	case strings.Contains(mimeType, "json"):
+		return ".json"
+	case strings.Contains(mimeType, "xml"):
+		return ".xml"
+	case strings.HasPrefix(mimeType, "text/"):
+		return ".txt"
+	default:
+		return ".bin"
+	}
+}
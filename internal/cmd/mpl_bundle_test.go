@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	r, err := newRedactor([]string{"password", "iban"}, []string{`card=(\d+)`, `\d{3}-\d{4}`})
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"user": "sam", "password": "***REDACTED***", "iban": "***REDACTED***"}`,
+		string(r.redact([]byte(`{"user": "sam", "password": "se\"cret", "iban": 123}`))))
+	assert.Equal(t, `<ns0:password>***REDACTED***</ns0:password><login password="***REDACTED***"/>`,
+		string(r.redact([]byte(`<ns0:password>secret</ns0:password><login password="secret"/>`))))
+	assert.Equal(t, `card=***REDACTED*** phone ***REDACTED***`, string(r.redact([]byte(`card=4111 phone 555-1234`))))
+
+	_, err = newRedactor(nil, []string{"("})
+	assert.Error(t, err, "Invalid pattern should be an error")
+}
+
+func TestWriteMplBundleMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, the traced payload has expired
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/MessageProcessingLogs('MSG1')":
+			w.Write([]byte(`{"d": {"MessageGuid": "MSG1", "IntegrationFlowName": "Orders", "Status": "FAILED"}}`))
+		case "/api/v1/MessageProcessingLogs('MSG1')/ErrorInformation/$value":
+			w.Write([]byte("Login failed with password secret"))
+		case "/api/v1/MessageProcessingLogs('MSG1')/AdapterAttributes", "/api/v1/MessageProcessingLogs('MSG1')/CustomHeaderProperties":
+			w.Write([]byte(`{"d": {"results": []}}`))
+		case "/api/v1/MessageProcessingLogs('MSG1')/Attachments":
+			w.Write([]byte(`{"d": {"results": [{"Id": "ATT1", "Name": "Request/Body"}]}}`))
+		case "/api/v1/MessageProcessingLogAttachments('ATT1')/$value":
+			w.Write([]byte(`<order><password>secret</password></order>`))
+		case "/api/v1/MessageProcessingLogs('MSG1')/Runs":
+			w.Write([]byte(`{"d": {"results": [{"Id": "RUN1", "LogLevel": "TRACE"}]}}`))
+		case "/api/v1/MessageProcessingLogRuns('RUN1')/RunSteps":
+			w.Write([]byte(`{"d": {"results": [{"RunId": "RUN1", "ChildCount": 3, "ModelStepId": "CallActivity_1"}]}}`))
+		case "/api/v1/MessageProcessingLogRunSteps(RunId='RUN1',ChildCount=3)/TraceMessages":
+			w.Write([]byte(`{"d": {"results": [{"TraceId": "42", "MimeType": "application/json"}]}}`))
+		case "/api/v1/TraceMessages(42L)/$value":
+			w.Write([]byte(`{"password": "secret"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	r, err := newRedactor([]string{"password"}, []string{`password (\w+)`})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	manifest, err := writeMplBundle(&out, exe, "MSG1", r, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"mpl.json",
+		"error.txt",
+		"AdapterAttributes.json",
+		"CustomHeaderProperties.json",
+		"attachments/01_Request_Body",
+		"runs/RUN1/steps.json",
+		"runs/RUN1/trace/0003_CallActivity_1/payload.json",
+	}, manifest.Files)
+	assert.Len(t, manifest.Warnings, 2, "Missing trace headers and properties should be warnings")
+
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+	content := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		content[f.Name] = string(data)
+	}
+	assert.Equal(t, "Login failed with password ***REDACTED***", content["error.txt"])
+	assert.Equal(t, "<order><password>***REDACTED***</password></order>", content["attachments/01_Request_Body"])
+	assert.Equal(t, `{"password": "***REDACTED***"}`, content["runs/RUN1/trace/0003_CallActivity_1/payload.json"])
+	assert.Contains(t, content, "bundle.json")
+
+	_, err = writeMplBundle(&out, exe, "MISSING", r, true)
+	assert.Error(t, err, "Missing message processing log should be an error")
+}
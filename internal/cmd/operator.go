@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/operator"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewOperatorCommand() *cobra.Command {
+
+	operatorCmd := &cobra.Command{
+		Use:          "operator",
+		Short:        "Reconcile CPIConfiguration resources of Kubernetes against the tenant",
+		SilenceUsage: true,
+		Long: `Run as a Kubernetes controller that reconciles CPIConfiguration custom resources
+against the tenant. Each resource embeds a configure configuration in its spec.
+
+The parameter values on the tenant are compared with the spec every interval and
+whenever the spec changes. In the apply mode (default) drifted parameters are
+updated and artifacts flagged with deploy are deployed, in the detect mode drift
+is only reported. The outcome is written to the status of the resource with the
+conditions Ready and Drifted, so that GitOps tools like Argo CD show the health
+of the tenant configuration.
+
+Inside a cluster the service account of the pod is used. Outside of a cluster,
+point --kube-api-url to 'kubectl proxy'.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'operator' section. CLI flags override config file settings.`,
+		Example: `  # Reconcile the resources of the namespace of the pod
+  flashpipe operator
+
+  # Reconcile once from a workstation through kubectl proxy
+  kubectl proxy --port 8001 &
+  flashpipe operator --kube-api-url http://127.0.0.1:8001 --namespace cpi --once`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runOperator(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	operatorCmd.Flags().String("namespace", "", "Namespace of the CPIConfiguration resources, defaults to the namespace of the pod (config: operator.namespace)")
+	operatorCmd.Flags().Bool("all-namespaces", false, "Reconcile the resources of all namespaces (config: operator.allNamespaces)")
+	operatorCmd.Flags().Int("interval", 60, "Seconds between the checks of the tenant, unless set by intervalSeconds of the resource (config: operator.intervalSeconds)")
+	operatorCmd.Flags().String("kube-api-url", "", "URL of the Kubernetes API, e.g. of kubectl proxy. Defaults to the API server of the cluster (config: operator.kubeApiUrl)")
+	operatorCmd.Flags().Bool("once", false, "Reconcile the resources once and exit (config: operator.once)")
+	operatorCmd.Flags().Int("deploy-retries", 5, "Number of retries for deployment status checks (config: operator.deployRetries)")
+	operatorCmd.Flags().Int("deploy-delay", 15, "Delay in seconds between deployment status checks (config: operator.deployDelaySeconds)")
+
+	return operatorCmd
+}
+
+func runOperator(cmd *cobra.Command) error {
+	interval := config.GetIntWithFallback(cmd, "interval", "operator.intervalSeconds")
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %d, must be greater than 0", interval)
+	}
+	kube, err := operator.NewKubeClient(config.GetStringWithFallback(cmd, "kube-api-url", "operator.kubeApiUrl"))
+	if err != nil {
+		return err
+	}
+	namespace := config.GetStringWithFallback(cmd, "namespace", "operator.namespace")
+	if config.GetBoolWithFallback(cmd, "all-namespaces", "operator.allNamespaces") {
+		namespace = ""
+	} else if namespace == "" {
+		namespace = operator.InClusterNamespace()
+		if namespace == "" {
+			return fmt.Errorf("--namespace or --all-namespaces is required outside of a cluster")
+		}
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	tenant := flashpipe.NewClient(flashpipe.ServiceDetails{
+		Host:         serviceDetails.Host,
+		UserID:       serviceDetails.Userid,
+		Password:     serviceDetails.Password,
+		OAuthHost:    serviceDetails.OauthHost,
+		OAuthPath:    serviceDetails.OauthPath,
+		ClientID:     serviceDetails.OauthClientId,
+		ClientSecret: serviceDetails.OauthClientSecret,
+	})
+	reconciler := operator.NewReconciler(tenant, kube, namespace, time.Duration(interval)*time.Second, flashpipe.ApplyOptions{
+		DeployRetries: config.GetIntWithFallback(cmd, "deploy-retries", "operator.deployRetries"),
+		DeployDelay:   time.Duration(config.GetIntWithFallback(cmd, "deploy-delay", "operator.deployDelaySeconds")) * time.Second,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if config.GetBoolWithFallback(cmd, "once", "operator.once") {
+		return reconciler.ReconcileAll(ctx)
+	}
+	if namespace == "" {
+		log.Info().Msgf("Reconciling CPIConfiguration resources of all namespaces every %d seconds", interval)
+	} else {
+		log.Info().Msgf("Reconciling CPIConfiguration resources of namespace %v every %d seconds", namespace, interval)
+	}
+	return reconciler.Run(ctx)
+}
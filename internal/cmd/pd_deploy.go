@@ -110,6 +110,10 @@ func runPDDeploy(cmd *cobra.Command) error {
 	// Initialise HTTP executer
 	exe := api.InitHTTPExecuter(serviceDetails)
 
+	if !api.DetectFeatures(exe).Supports(api.FeaturePartnerDirectory) {
+		return fmt.Errorf("%s does not support the Partner Directory API", exe.Host())
+	}
+
 	// Initialise Partner Directory API
 	pdAPI := api.NewPartnerDirectory(exe)
 
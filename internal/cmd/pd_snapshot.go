@@ -15,8 +15,9 @@ import (
 func NewPDSnapshotCommand() *cobra.Command {
 
 	pdSnapshotCmd := &cobra.Command{
-		Use:   "pd-snapshot",
-		Short: "Download partner directory parameters from SAP CPI",
+		Use:         "pd-snapshot",
+		Short:       "Download partner directory parameters from SAP CPI",
+		Annotations: map[string]string{annotationReadOnly: "true"},
 		Long: `Download all partner directory parameters from SAP CPI and save them locally.
 
 This command retrieves both string and binary parameters from the SAP CPI Partner Directory
@@ -97,6 +98,10 @@ func runPDSnapshot(cmd *cobra.Command) error {
 	// Initialise HTTP executer
 	exe := api.InitHTTPExecuter(serviceDetails)
 
+	if !api.DetectFeatures(exe).Supports(api.FeaturePartnerDirectory) {
+		return fmt.Errorf("%s does not support the Partner Directory API", exe.Host())
+	}
+
 	// Initialise Partner Directory API
 	pdAPI := api.NewPartnerDirectory(exe)
 
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// configureCapabilities returns the capabilities configure requires for the configuration. Dry runs only read.
+func configureCapabilities(cfg *models.ConfigureConfig, dryRun bool) []string {
+	capabilities := []string{api.CapabilityReadDesigntime}
+	if dryRun {
+		return capabilities
+	}
+	capabilities = append(capabilities, api.CapabilityWriteConfiguration)
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			if pkg.Deploy || artifact.Deploy {
+				return append(capabilities, api.CapabilityDeploy, api.CapabilityReadRuntime)
+			}
+		}
+	}
+	return capabilities
+}
+
+// checkPermissions probes the capabilities on the tenant before a run starts and returns an error listing
+// the missing ones, instead of failing each artifact with 403. Checks that cannot be executed are skipped.
+func checkPermissions(exe *httpclnt.HTTPExecuter, capabilities ...string) error {
+	log.Info().Msgf("Checking permissions on %v", exe.Host())
+	results, err := api.NewPermission(exe).Check(capabilities...)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, r := range results {
+		switch {
+		case r.Error != nil:
+			log.Warn().Msgf("Permission %v could not be checked: %v", r.Capability, r.Error)
+		case !r.Granted:
+			log.Error().Msgf("Missing permission %v (%v), assign role %v to the user or the service key", r.Capability, r.Description, r.Role)
+			missing = append(missing, r.Capability)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("credentials lack permission(s) on %v: %v", exe.Host(), strings.Join(missing, ", "))
+	}
+	return nil
+}
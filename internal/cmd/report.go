@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// InventoryReport is the landscape of a tenant as passed to the templates of report inventory
+type InventoryReport struct {
+	Title     string
+	Tenant    string
+	Generated time.Time
+	Packages  []InventoryPackage
+}
+
+// InventoryPackage is an integration package of the landscape with its artifacts
+type InventoryPackage struct {
+	Id         string
+	Name       string
+	Version    string
+	ModifiedBy string
+	ModifiedAt time.Time
+	Artifacts  []InventoryArtifact
+}
+
+// InventoryArtifact is a designtime artifact with its deployment status and entry point URLs
+type InventoryArtifact struct {
+	Id              string
+	Name            string
+	Type            string
+	Version         string
+	Draft           bool
+	Status          string
+	DeployedVersion string
+	DeployedBy      string
+	DeployedAt      time.Time
+	Endpoints       []EndpointEntry
+}
+
+const reportNotDeployed = "NOT_DEPLOYED"
+
+var reportFuncs = map[string]any{
+	"timestamp": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04 UTC")
+	},
+	"md": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+	},
+	"join": strings.Join,
+}
+
+const inventoryMarkdownTemplate = `# {{ md .Title }}
+
+Tenant: {{ .Tenant }}
+Generated: {{ timestamp .Generated }}
+{{ range .Packages }}
+## {{ md .Name }} ({{ .Id }})
+
+Version {{ .Version }}{{ with timestamp .ModifiedAt }}, last modified {{ . }}{{ end }}{{ with .ModifiedBy }} by {{ . }}{{ end }}
+{{ if .Artifacts }}
+| Artifact | Type | Version | Status | Deployed | Endpoints |
+| --- | --- | --- | --- | --- | --- |
+{{ range .Artifacts }}| {{ md .Name }} ({{ .Id }}) | {{ .Type }} | {{ .Version }}{{ if .Draft }} (draft){{ end }} | {{ .Status }} | {{ .DeployedVersion }}{{ with timestamp .DeployedAt }} on {{ . }}{{ end }}{{ with .DeployedBy }} by {{ . }}{{ end }} | {{ range $i, $e := .Endpoints }}{{ if $i }}<br>{{ end }}{{ $e.Url }}{{ end }} |
+{{ end }}{{ else }}
+No artifacts.
+{{ end }}{{ end }}`
+
+const inventoryHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>Tenant: {{ .Tenant }}<br>Generated: {{ timestamp .Generated }}</p>
+{{ range .Packages }}
+<h2>{{ .Name }} ({{ .Id }})</h2>
+<p>Version {{ .Version }}{{ with timestamp .ModifiedAt }}, last modified {{ . }}{{ end }}{{ with .ModifiedBy }} by {{ . }}{{ end }}</p>
+{{ if .Artifacts }}
+<table>
+<tr><th>Artifact</th><th>Type</th><th>Version</th><th>Status</th><th>Deployed</th><th>Endpoints</th></tr>
+{{ range .Artifacts }}<tr><td>{{ .Name }} ({{ .Id }})</td><td>{{ .Type }}</td><td>{{ .Version }}{{ if .Draft }} (draft){{ end }}</td><td>{{ .Status }}</td><td>{{ .DeployedVersion }}{{ with timestamp .DeployedAt }} on {{ . }}{{ end }}{{ with .DeployedBy }} by {{ . }}{{ end }}</td><td>{{ range $i, $e := .Endpoints }}{{ if $i }}<br>{{ end }}{{ $e.Url }}{{ end }}</td></tr>
+{{ end }}</table>
+{{ else }}
+<p>No artifacts.</p>
+{{ end }}{{ end }}
+</body>
+</html>
+`
+
+func NewReportCommand() *cobra.Command {
+
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate reports of the tenant",
+		Long: `Generate human-readable reports of the SAP Integration Suite tenant,
+e.g. for documentation.`,
+	}
+	return reportCmd
+}
+
+func NewReportInventoryCommand() *cobra.Command {
+
+	inventoryCmd := &cobra.Command{
+		Use:          "inventory",
+		Short:        "Generate a landscape report of packages and artifacts",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Generate a landscape report of the integration packages of the tenant
+with their artifacts, versions, deployment status, entry point URLs and
+last modification, in Markdown or HTML.
+
+The report is rendered with a built-in template, or with the Go template
+of --template. Templates get the report as an InventoryReport, see the
+documentation for its fields and functions.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'report.inventory' section. CLI flags override config file settings.`,
+		Example: `  # Print the landscape as Markdown
+  flashpipe report inventory
+
+  # Write an HTML report of two packages
+  flashpipe report inventory --format html --package-ids Sales,Finance --output-file landscape.html
+
+  # Render with a custom template
+  flashpipe report inventory --template ./confluence.tmpl --output-file landscape.md`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			format := config.GetStringWithFallback(cmd, "format", "report.inventory.format")
+			switch format {
+			case "markdown", "html":
+			default:
+				return fmt.Errorf("invalid value for --format = %v", format)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runReportInventory(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'report.inventory' key
+	inventoryCmd.Flags().String("format", "markdown", "Output format. Allowed values: markdown, html (config: report.inventory.format)")
+	inventoryCmd.Flags().String("template", "", "Go template file to render the report with instead of the built-in template (config: report.inventory.template)")
+	inventoryCmd.Flags().String("title", "Integration Landscape", "Title of the report (config: report.inventory.title)")
+	inventoryCmd.Flags().StringSlice("package-ids", nil, "Comma separated list of package IDs to include (config: report.inventory.packageIds)")
+	inventoryCmd.Flags().String("output-file", "", "Write output to file instead of stdout (config: report.inventory.outputFile)")
+
+	return inventoryCmd
+}
+
+func runReportInventory(cmd *cobra.Command) error {
+	log.Info().Msg("Executing report inventory command")
+
+	format := config.GetStringWithFallback(cmd, "format", "report.inventory.format")
+	templateFile := config.GetStringWithFallback(cmd, "template", "report.inventory.template")
+	title := config.GetStringWithFallback(cmd, "title", "report.inventory.title")
+	packageIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "package-ids", "report.inventory.packageIds"))
+	outputFile := config.GetStringWithFallback(cmd, "output-file", "report.inventory.outputFile")
+
+	text := inventoryMarkdownTemplate
+	if format == "html" {
+		text = inventoryHTMLTemplate
+	}
+	if templateFile != "" {
+		data, err := os.ReadFile(templateFile)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		text = string(data)
+	}
+
+	// Initialise HTTP executer
+	serviceDetails := api.GetServiceDetails(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	report, err := collectInventoryReport(exe, packageIds)
+	if err != nil {
+		return err
+	}
+	report.Title = title
+	report.Tenant = serviceDetails.Host
+
+	var out io.Writer = os.Stdout
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err = writeInventoryReport(out, report, format, text); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		log.Info().Msgf("Inventory report of %d package(s) written to %v", len(report.Packages), outputFile)
+	}
+	return nil
+}
+
+// collectInventoryReport returns the packages of the tenant, or those in packageIds, with their artifacts,
+// deployment status and entry point URLs
+func collectInventoryReport(exe *httpclnt.HTTPExecuter, packageIds []string) (*InventoryReport, error) {
+	ip := api.NewIntegrationPackage(exe)
+	packages, err := ip.List()
+	if err != nil {
+		return nil, err
+	}
+	runtimeArtifacts, err := api.NewRuntime(exe).List()
+	if err != nil {
+		return nil, err
+	}
+	deployed := map[string]*api.RuntimeArtifactData{}
+	for _, artifact := range runtimeArtifacts {
+		deployed[artifact.Id] = artifact
+	}
+	serviceEndpoints, err := api.NewServiceEndpoint(exe).List()
+	if err != nil {
+		return nil, err
+	}
+	endpoints := map[string][]EndpointEntry{}
+	for _, entry := range collectEndpointEntries(serviceEndpoints, nil, nil) {
+		endpoints[entry.ArtifactId] = append(endpoints[entry.ArtifactId], entry)
+	}
+
+	report := &InventoryReport{Generated: time.Now()}
+	for _, pkg := range packages {
+		if len(packageIds) > 0 && !slices.Contains(packageIds, pkg.Id) {
+			continue
+		}
+		artifacts, err := ip.GetAllArtifacts(pkg.Id)
+		if err != nil {
+			return nil, err
+		}
+		reportPackage := InventoryPackage{
+			Id:         pkg.Id,
+			Name:       pkg.Name,
+			Version:    pkg.Version,
+			ModifiedBy: pkg.ModifiedBy,
+			ModifiedAt: api.ParseODataTime(pkg.ModifiedDate),
+		}
+		for _, artifact := range artifacts {
+			reportArtifact := InventoryArtifact{
+				Id:        artifact.Id,
+				Name:      artifact.Name,
+				Type:      artifact.ArtifactType,
+				Version:   artifact.Version,
+				Draft:     artifact.IsDraft,
+				Status:    reportNotDeployed,
+				Endpoints: endpoints[artifact.Id],
+			}
+			if runtime, found := deployed[artifact.Id]; found {
+				reportArtifact.Status = runtime.Status
+				reportArtifact.DeployedVersion = runtime.Version
+				reportArtifact.DeployedBy = runtime.DeployedBy
+				reportArtifact.DeployedAt = api.ParseODataTime(runtime.DeployedOn)
+			}
+			reportPackage.Artifacts = append(reportPackage.Artifacts, reportArtifact)
+		}
+		report.Packages = append(report.Packages, reportPackage)
+	}
+	log.Info().Msgf("Found %d package(s) and %d deployed artifact(s)", len(report.Packages), len(runtimeArtifacts))
+	return report, nil
+}
+
+// writeInventoryReport renders the report with the template text, with html/template for the HTML format so that
+// values are escaped
+func writeInventoryReport(out io.Writer, report *InventoryReport, format string, text string) error {
+	if format == "html" {
+		tmpl, err := htmltemplate.New("report").Funcs(reportFuncs).Parse(text)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		return tmpl.Execute(out, report)
+	}
+	tmpl, err := template.New("report").Funcs(reportFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl.Execute(out, report)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryReportMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationPackages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"d": {"results": [
+			{"Id": "Sales", "Name": "Sales | Orders", "Version": "1.2.0", "ModifiedBy": "jane", "ModifiedDate": "1709281800000"},
+			{"Id": "Finance", "Name": "Finance", "Version": "1.0.0"}]}}`))
+	})
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationPackages('Sales')/IntegrationDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Orders", "Name": "<Orders>", "Version": "1.0.4"}, {"Id": "Audit", "Name": "Audit", "Version": "Active"}]}}`))
+		case "/api/v1/IntegrationPackages('Sales')/ValueMappingDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Codes", "Name": "Codes", "Version": "1.0.0"}]}}`))
+		default:
+			w.Write([]byte(`{"d": {"results": []}}`))
+		}
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"d": {"results": [{"Id": "Orders", "Version": "1.0.3", "Status": "STARTED", "DeployedBy": "jane", "DeployedOn": "/Date(1709281800000)/"}]}}`))
+	})
+	mux.HandleFunc("/api/v1/ServiceEndpoints", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"d": {"results": [{"Id": "Orders$endpointAddress=orders", "Protocol": "REST",
+			"EntryPoints": {"results": [{"Url": "https://dummy/http/orders", "Type": "PROD"}]}}]}}`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	report, err := collectInventoryReport(exe, []string{"Sales"})
+	require.NoError(t, err)
+	require.Len(t, report.Packages, 1, "Packages not in the filter should be skipped")
+	sales := report.Packages[0]
+	assert.Equal(t, "jane", sales.ModifiedBy)
+	assert.Equal(t, int64(1709281800000), sales.ModifiedAt.UnixMilli(), "Incorrect modification time")
+	require.Len(t, sales.Artifacts, 3)
+	orders := sales.Artifacts[0]
+	assert.Equal(t, "STARTED", orders.Status)
+	assert.Equal(t, "1.0.3", orders.DeployedVersion)
+	assert.Equal(t, "https://dummy/http/orders", orders.Endpoints[0].Url)
+	assert.True(t, sales.Artifacts[1].Draft)
+	assert.Equal(t, reportNotDeployed, sales.Artifacts[2].Status)
+	assert.Equal(t, "ValueMapping", sales.Artifacts[2].Type)
+
+	var markdown bytes.Buffer
+	require.NoError(t, writeInventoryReport(&markdown, report, "markdown", inventoryMarkdownTemplate))
+	assert.Contains(t, markdown.String(), "## Sales \\| Orders (Sales)")
+	assert.Contains(t, markdown.String(), "Version 1.2.0, last modified 2024-03-01 08:30 UTC by jane")
+	assert.Contains(t, markdown.String(), "| <Orders> (Orders) | Integration | 1.0.4 | STARTED | 1.0.3 on 2024-03-01 08:30 UTC by jane | https://dummy/http/orders |")
+
+	var html bytes.Buffer
+	require.NoError(t, writeInventoryReport(&html, report, "html", inventoryHTMLTemplate))
+	assert.Contains(t, html.String(), "<td>&lt;Orders&gt; (Orders)</td>", "Values should be escaped in HTML")
+}
+
+func TestWriteInventoryReportCustomTemplate(t *testing.T) {
+	report := &InventoryReport{Title: "Landscape", Packages: []InventoryPackage{{Id: "Sales", Artifacts: []InventoryArtifact{{Id: "Orders", Status: "STARTED"}}}}}
+
+	var out bytes.Buffer
+	err := writeInventoryReport(&out, report, "markdown", `{{ range .Packages }}{{ .Id }}:{{ range .Artifacts }} {{ .Id }}={{ .Status }}{{ end }}{{ end }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "Sales: Orders=STARTED", out.String())
+
+	err = writeInventoryReport(&out, report, "html", `{{ .Unknown }}`)
+	assert.Error(t, err, "Unknown fields should be an error")
+	assert.False(t, strings.Contains(out.String(), "Unknown"))
+}
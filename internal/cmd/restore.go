@@ -11,6 +11,9 @@ import (
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
 	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/engswee/flashpipe/internal/pipeline"
+	"github.com/engswee/flashpipe/internal/source"
 	"github.com/engswee/flashpipe/internal/str"
 	"github.com/engswee/flashpipe/internal/sync"
 	"github.com/go-errors/errors"
@@ -26,6 +29,12 @@ func NewRestoreCommand() *cobra.Command {
 		SilenceUsage: true,
 		Long: `Restore all editable integration packages from a Git repository to SAP Integration Suite tenant.
 
+Instead of a directory, --dir-artifacts can fetch the packages from a folder
+of a Git repository (git::<url>//<path>?ref=<ref>), a zip file at an HTTP(S)
+or S3 URL (optionally followed by //<path> for a folder within) or the
+editable packages of another tenant whose credentials are stored with login
+(tenant:<name>), e.g. to promote content from tenant to tenant.
+
 Configuration:
   Settings can be loaded from the global config file (--config) under the
   'restore' section. CLI flags override config file settings.`,
@@ -43,7 +52,7 @@ Configuration:
 					return fmt.Errorf("security alert for --dir-artifacts: %w", err)
 				}
 				gitRepoDirClean := filepath.Clean(gitRepoDir) + string(os.PathSeparator)
-				if artifactsDir != "" && !strings.HasPrefix(artifactsDir, gitRepoDirClean) {
+				if artifactsDir != "" && source.IsDirectory(artifactsDir) && !strings.HasPrefix(artifactsDir, gitRepoDirClean) {
 					return fmt.Errorf("--dir-artifacts [%v] should be a subdirectory of --dir-git-repo [%v]", artifactsDir, gitRepoDirClean)
 				}
 			}
@@ -59,6 +68,10 @@ Configuration:
 		},
 	}
 
+	// Note: These can be set in config file under 'restore' key
+	restoreCmd.Flags().Bool("bump-version", false, "Increment the Bundle-Version of changed artifacts according to the commit messages before uploading them (config: restore.bumpVersion)")
+	restoreCmd.Flags().Bool("changelog", false, "Add an entry to CHANGELOG.md of artifacts whose version is incremented (config: restore.changelog)")
+
 	return restoreCmd
 }
 
@@ -80,9 +93,27 @@ func runRestore(cmd *cobra.Command) error {
 	}
 	includedIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "ids-include", "restore.idsInclude"))
 	excludedIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "ids-exclude", "restore.idsExclude"))
+	var versionBump *sync.VersionBump
+	if config.GetBoolWithFallback(cmd, "bump-version", "restore.bumpVersion") {
+		if !source.IsDirectory(artifactsBaseDir) {
+			return fmt.Errorf("--bump-version requires the artifacts in a local Git repository, not %v", artifactsBaseDir)
+		}
+		versionBump = &sync.VersionBump{GitRepoDir: gitRepoDir, Changelog: config.GetBoolWithFallback(cmd, "changelog", "restore.changelog")}
+	}
+
+	// Fetch the packages from Git, a zip file or another tenant
+	artifactSource, err := source.Parse(artifactsBaseDir, storedTenantExecuter)
+	if err != nil {
+		return err
+	}
+	defer artifactSource.Close()
+	artifactsBaseDir, err = artifactSource.Packages(includedIds, excludedIds)
+	if err != nil {
+		return err
+	}
 
 	serviceDetails := api.GetServiceDetails(cmd)
-	err = restoreSnapshot(serviceDetails, artifactsBaseDir, workDir, includedIds, excludedIds)
+	err = restoreSnapshot(serviceDetails, artifactsBaseDir, workDir, includedIds, excludedIds, versionBump)
 	if err != nil {
 		return err
 	}
@@ -90,8 +121,8 @@ func runRestore(cmd *cobra.Command) error {
 	return nil
 }
 
-func restoreSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir string, workDir string, includedIds []string, excludedIds []string) error {
-	log.Info().Msg("---------------------------------------------------------------------------------")
+func restoreSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir string, workDir string, includedIds []string, excludedIds []string, versionBump *sync.VersionBump) error {
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msg("📢 Begin restoring snapshot to the tenant")
 
 	// Get directory list
@@ -105,39 +136,50 @@ func restoreSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir string
 	exe := api.InitHTTPExecuter(serviceDetails)
 	packageSynchroniser := sync.NewSyncer("tenant", "CPIPackage", exe)
 	artifactsSynchroniser := sync.New(exe)
+	if versionBump != nil {
+		artifactsSynchroniser.SetVersionBump(versionBump)
+	}
 
 	// Go through each directory and check if there is an integration package details in it, if yes, then proceed to restore integration package and artifacts
+	var tasks []pipeline.Task
 	for _, entry := range entries {
 		packageId := entry.Name()
 		packageDir := fmt.Sprintf("%v/%v", baseSourceDir, packageId)
 		packageFile := fmt.Sprintf("%v/%v.json", packageDir, packageId)
-		if entry.IsDir() {
-			log.Info().Msg("---------------------------------------------------------------------------------")
-			log.Info().Msgf("Processing directory %v", packageDir)
-			if file.Exists(packageFile) {
-				// Filter in/out packages
-				if str.FilterIDs(packageId, includedIds, excludedIds) {
-					continue
-				}
+		if !entry.IsDir() {
+			continue
+		}
+		if !file.Exists(packageFile) {
+			log.Warn().Msgf("Skipping directory as integration package file %v is not found", packageFile)
+			continue
+		}
+		// Filter in/out packages
+		if str.FilterIDs(packageId, includedIds, excludedIds) {
+			continue
+		}
 
-				// 1 - Sync CPI Integration Package
-				err = packageSynchroniser.Exec(sync.Request{ArtifactsDir: packageDir})
-				if err != nil {
-					return err
-				}
+		// 1 - Sync CPI Integration Package
+		tasks = append(tasks, pipeline.Task{ID: "package:" + packageId, Run: func() error {
+			logger.Rule(logger.SeparatorDashed)
+			log.Info().Msgf("Processing directory %v", packageDir)
+			return packageSynchroniser.Exec(sync.Request{ArtifactsDir: packageDir})
+		}})
 
-				// 2 - Sync CPI Artifacts
-				err = artifactsSynchroniser.ArtifactsToTenant(packageId, workDir, packageDir, nil, nil)
-				if err != nil {
-					return err
-				}
-			} else {
-				log.Warn().Msgf("Skipping directory as integration package file %v is not found", packageFile)
-			}
-		}
+		// 2 - Sync CPI Artifacts
+		tasks = append(tasks, pipeline.Task{ID: "artifacts:" + packageId, DependsOn: []string{"package:" + packageId}, Run: func() error {
+			return artifactsSynchroniser.ArtifactsToTenant(packageId, workDir, packageDir, nil, nil)
+		}})
+	}
+	// The packages are restored one after the other and the restore stops at the first failure
+	results, err := pipeline.Run(tasks, pipeline.Options{Parallel: 1, StopOnError: true})
+	if err != nil {
+		return err
+	}
+	if err = pipeline.FirstError(results); err != nil {
+		return err
 	}
 
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msg("🏆 Completed restoring snapshot to the tenant")
 	return nil
 }
@@ -2,11 +2,25 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/audit"
+	"github.com/engswee/flashpipe/internal/calm"
 	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/events"
+	"github.com/engswee/flashpipe/internal/exitreport"
+	"github.com/engswee/flashpipe/internal/httpclnt"
 	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/engswee/flashpipe/internal/runid"
+	"github.com/engswee/flashpipe/internal/sysproxy"
+	"github.com/engswee/flashpipe/internal/telemetry"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -46,16 +60,58 @@ automating time-consuming manual tasks like:
 	rootCmd.PersistentFlags().String("oauth-clientid", "", "Client ID for using OAuth")
 	rootCmd.PersistentFlags().String("oauth-clientsecret", "", "Client Secret for using OAuth")
 	rootCmd.PersistentFlags().String("oauth-path", "/oauth/token", "Path for OAuth token server")
+	rootCmd.PersistentFlags().String("oauth-saml-assertion-command", "", "Command that prints a SAML assertion exchanged for OAuth tokens with the SAML bearer grant instead of client credentials (config: auth.samlAssertionCommand)")
+	rootCmd.PersistentFlags().Bool("oauth-browser", false, "Log in as user in the browser with the authorization code flow and PKCE at --oauth-host instead of client credentials, the tokens are cached in the keychain (config: auth.browser)")
+	rootCmd.PersistentFlags().Int("oauth-callback-port", 0, "Port of the local callback of --oauth-browser, 0 for any free port (config: auth.callbackPort)")
+	rootCmd.PersistentFlags().String("tenant", "", "Name of the tenant whose credentials stored with login are used (config: tenant)")
+	rootCmd.PersistentFlags().String("token-command", "", "Command that prints the bearer token for the tenant, used instead of Basic Auth or OAuth (config: auth.tokenCommand)")
+	rootCmd.PersistentFlags().String("platform", api.PlatformAuto, "Platform of the tenant: auto (detected from tmn-host), cf or neo")
+	rootCmd.PersistentFlags().String("odata-version", api.ODataAuto, "Version of the OData APIs used where the tenant offers both: auto (detected from the tenant), v2 or v4")
 
+	rootCmd.PersistentFlags().Int("max-response-size", 0, "Maximum size in MB of responses from the tenant, e.g. artifact downloads and $batch responses, 0 for no limit")
+	rootCmd.PersistentFlags().Int("circuit-failure-rate", 0, "Percentage of the last 20 requests to a tenant that failed with a connection error, 502, 503 or 504 after which requests are paused, 0 to disable")
+	rootCmd.PersistentFlags().Int("circuit-probe-interval", 30, "Seconds between probe requests while requests are paused")
+	rootCmd.PersistentFlags().Int("circuit-max-pause", 900, "Seconds after which a run fails if the tenant did not recover while requests are paused")
+	rootCmd.PersistentFlags().Bool("detect-maintenance", true, "Pause requests as soon as the tenant responds with 503 and announces maintenance, with Retry-After or --maintenance-pattern, until it is available again (config: maintenance.detect)")
+	rootCmd.PersistentFlags().String("maintenance-status-url", "", "Status endpoint checked before the first request to the tenant and while requests are paused, maintenance is announced with 503 or --maintenance-pattern (config: maintenance.statusUrl)")
+	rootCmd.PersistentFlags().String("maintenance-pattern", "(?i)maintenance", "Regular expression matching the text of 503 responses and of the status endpoint that announces maintenance (config: maintenance.pattern)")
+	rootCmd.PersistentFlags().Bool("system-proxy", true, "Send requests through the proxy of the operating system (Windows Internet settings, macOS network settings or GNOME proxy settings) if no proxy environment variable like HTTPS_PROXY is set (config: systemProxy)")
+	rootCmd.PersistentFlags().StringArray("http-header", nil, "Header sent with every request to the tenant as Name: Value, e.g. an API key of a gateway, can be repeated (config: httpHeaders)")
+	rootCmd.PersistentFlags().String("http-sign-command", "", "Command run before every request to the tenant that prints headers to add as Name: Value, e.g. a signature (config: httpSignCommand)")
+	rootCmd.PersistentFlags().String("batch-diagnostics-dir", "", "Folder the raw request and response of failed $batch requests are written to with secrets redacted, e.g. for SAP support (config: batchDiagnosticsDir)")
+	rootCmd.PersistentFlags().Int("batch-parallelism", httpclnt.DefaultBatchParallelism, "Number of $batch requests sent at a time when the operations of one request are split into several batches, 1 to send them one after the other (config: batchParallelism)")
+	rootCmd.PersistentFlags().Int("max-auth-failures", 3, "Number of consecutive requests rejected with 401 after which no more requests are sent, to avoid locking the user, 0 for no limit")
 	rootCmd.PersistentFlags().Bool("debug", false, "Show debug logs")
+	rootCmd.PersistentFlags().String("log-time-format", logger.TimeFormatDefault, "Format of the timestamps of log messages: default (RFC822) or rfc3339 (RFC3339 with milliseconds) (config: log.timeFormat)")
+	rootCmd.PersistentFlags().Bool("log-durations", false, "Annotate the log messages of configured, deployed and uploaded artifacts with their elapsed time (config: log.durations)")
+	rootCmd.PersistentFlags().Bool("ascii", false, "Replace emoji and box-drawing characters in the output with plain ASCII markers, enabled by default if the locale (LC_ALL, LC_CTYPE, LANG) is not UTF-8 (config: log.ascii)")
 
+	rootCmd.PersistentFlags().String("metrics-textfile", "", "Write run metrics in Prometheus text format to this file, e.g. for the node_exporter textfile collector")
+	rootCmd.PersistentFlags().String("metrics-pushgateway", "", "Push run metrics to this Prometheus Pushgateway URL")
+	rootCmd.PersistentFlags().String("otel-endpoint", "", "Export OpenTelemetry spans to this OTLP/HTTP endpoint, e.g. http://localhost:4318 (defaults to OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentFlags().String("audit-log", "", "Append every modifying API call (POST, PUT, PATCH, DELETE) to this JSON Lines file (config: audit.file)")
+	rootCmd.PersistentFlags().Bool("audit-hash-chain", false, "Chain the audit log entries with SHA-256 hashes, so that removed or altered entries can be detected with audit verify (config: audit.hashChain)")
+	rootCmd.PersistentFlags().String("events-file", "", "Stream the progress of the run as JSON Lines events to this file, or to an inherited file descriptor with fd:<n> (config: events.file)")
+	rootCmd.PersistentFlags().String("run-id", "", "ID of the run included in every log line, the reports and the "+runid.Header+" header of every request to the tenant, e.g. the ID of the CI/CD pipeline run (default is a generated ID) (config: runId)")
+	rootCmd.PersistentFlags().String("calm-events-url", "", "Post an event for every deployed artifact to this events endpoint of the SAP Cloud ALM API (config: cloudALM.eventsUrl)")
+	rootCmd.PersistentFlags().String("calm-token-url", "", "Token endpoint of the SAP Cloud ALM service key (config: cloudALM.tokenUrl)")
+	rootCmd.PersistentFlags().String("calm-client-id", "", "Client ID of the SAP Cloud ALM service key (config: cloudALM.clientId)")
+	rootCmd.PersistentFlags().String("calm-client-secret", "", "Client secret of the SAP Cloud ALM service key (config: cloudALM.clientSecret)")
+	rootCmd.PersistentFlags().String("calm-service-id", "", "ID of the service of the tenant in SAP Cloud ALM the deployment events are assigned to (config: cloudALM.serviceId)")
+	rootCmd.PersistentFlags().Bool("explain-config", false, "Print the effective value of every setting of the command and where it came from (flag, env, config file, keychain or default), then exit without running the command")
+	rootCmd.PersistentFlags().Bool("exit-report", false, "Write the outcome, totals and report file of the command as a final JSON line to stderr, for wrapper scripts (config: exitReport)")
+
+	config.MarkSensitive(rootCmd.PersistentFlags(), "http-header")
 	_ = rootCmd.MarkPersistentFlagRequired("tmn-host")
 	rootCmd.MarkFlagsRequiredTogether("tmn-userid", "tmn-password")
-	rootCmd.MarkFlagsRequiredTogether("oauth-host", "oauth-clientid", "oauth-clientsecret")
+	rootCmd.MarkFlagsRequiredTogether("oauth-host", "oauth-clientid")
 
 	return rootCmd
 }
 
+// optionalCommands are the commands only included with a build tag, e.g. selftest
+var optionalCommands []func() *cobra.Command
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -77,10 +133,118 @@ func Execute() {
 	rootCmd.AddCommand(NewPDDeployCommand())
 	rootCmd.AddCommand(NewConfigGenerateCommand())
 	rootCmd.AddCommand(NewFlashpipeOrchestratorCommand())
-	rootCmd.AddCommand(NewConfigureCommand())
+	configureCmd := NewConfigureCommand()
+	configureCmd.AddCommand(NewConfigureVerifyCommand())
+	configureCmd.AddCommand(NewConfigureCopyCommand())
+	configureCmd.AddCommand(NewConfigureSetCommand())
+	configureCmd.AddCommand(NewConfigureGenerateCommand())
+	configureCmd.AddCommand(NewConfigurePruneCommand())
+	configureCmd.AddCommand(NewConfigurePackageRequestsCommand())
+	configureCmd.AddCommand(NewConfigureBackupCommand())
+	rootCmd.AddCommand(configureCmd)
+	endpointsCmd := NewEndpointsCommand()
+	endpointsCmd.AddCommand(NewEndpointsListCommand())
+	rootCmd.AddCommand(endpointsCmd)
+	reportCmd := NewReportCommand()
+	reportCmd.AddCommand(NewReportInventoryCommand())
+	rootCmd.AddCommand(reportCmd)
+	docsCmd := NewDocsCommand()
+	docsCmd.AddCommand(NewDocsGenerateCommand())
+	rootCmd.AddCommand(docsCmd)
+	ciCmd := NewCiCommand()
+	ciCmd.AddCommand(NewCiScaffoldCommand())
+	rootCmd.AddCommand(ciCmd)
+	rootCmd.AddCommand(NewServeCommand())
+	rootCmd.AddCommand(NewOperatorCommand())
+	rootCmd.AddCommand(NewInitCommand())
+	rootCmd.AddCommand(NewLintCommand())
+	rootCmd.AddCommand(NewSchemaCommand())
+	rootCmd.AddCommand(NewSelfUpdateCommand())
+	artifactCmd := NewArtifactGroupCommand()
+	artifactCmd.AddCommand(NewArtifactValidateCommand())
+	artifactCmd.AddCommand(NewArtifactInventoryCommand())
+	artifactCmd.AddCommand(NewArtifactDeleteCommand())
+	rootCmd.AddCommand(artifactCmd)
+	governanceCmd := NewGovernanceCommand()
+	governanceCmd.AddCommand(NewGovernanceCheckCommand())
+	rootCmd.AddCommand(governanceCmd)
+	credentialsCmd := NewCredentialsCommand()
+	credentialsCmd.AddCommand(NewCredentialsCheckCommand())
+	rootCmd.AddCommand(credentialsCmd)
+	historyCmd := NewHistoryCommand()
+	historyCmd.AddCommand(NewHistoryListCommand())
+	historyCmd.AddCommand(NewHistoryCompareCommand())
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(NewDoctorCommand())
+	auditCmd := NewAuditCommand()
+	auditCmd.AddCommand(NewAuditVerifyCommand())
+	rootCmd.AddCommand(auditCmd)
+	valueMappingCmd := NewValueMappingCommand()
+	valueMappingCmd.AddCommand(NewValueMappingDiffCommand())
+	valueMappingCmd.AddCommand(NewValueMappingApplyCommand())
+	rootCmd.AddCommand(valueMappingCmd)
+	advisorCmd := NewAdvisorCommand()
+	advisorCmd.AddCommand(NewAdvisorListCommand())
+	advisorCmd.AddCommand(NewAdvisorExportCommand())
+	advisorCmd.AddCommand(NewAdvisorImportCommand())
+	rootCmd.AddCommand(advisorCmd)
+	runtimeCmd := NewRuntimeCommand()
+	runtimeCmd.AddCommand(NewRuntimeDrainStatusCommand())
+	runtimeCmd.AddCommand(NewRuntimeErrorsCommand())
+	traceCmd := NewRuntimeTraceCommand()
+	traceCmd.AddCommand(NewRuntimeTraceEnableCommand())
+	traceCmd.AddCommand(NewRuntimeTraceDisableCommand())
+	runtimeCmd.AddCommand(traceCmd)
+	rootCmd.AddCommand(runtimeCmd)
+	mplCmd := NewMplCommand()
+	mplCmd.AddCommand(NewMplBundleCommand())
+	rootCmd.AddCommand(mplCmd)
+	rootCmd.AddCommand(NewMonitorCommand())
+	rootCmd.AddCommand(NewLoginCommand())
+	rootCmd.AddCommand(NewLogoutCommand())
+	configCmd := NewConfigCommand()
+	configCmd.AddCommand(NewConfigEncryptCommand())
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(NewExecuteRequestsCommand())
+	rootCmd.AddCommand(NewCloneCommand())
+	rootCmd.AddCommand(NewCompareTenantsCommand())
+	for _, newCommand := range optionalCommands {
+		rootCmd.AddCommand(newCommand())
+	}
 
+	startTime := time.Now()
 	err := rootCmd.Execute()
 
+	if events.Enabled() {
+		event := events.Event{Type: events.TypeRunFinished, DurationMs: time.Since(startTime).Milliseconds()}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		events.Emit(event)
+		_ = events.Close()
+	}
+	telemetry.EndRun(err)
+	if flushErr := telemetry.Flush(); flushErr != nil {
+		log.Warn().Msg(flushErr.Error())
+	}
+
+	if enabled, _ := rootCmd.PersistentFlags().GetBool("exit-report"); enabled || viper.GetBool("exitReport") {
+		command := rootCmd.Name()
+		if executed, _, findErr := rootCmd.Find(os.Args[1:]); findErr == nil {
+			command = executed.CommandPath()
+		}
+		if err != nil {
+			log.Error().Msg(logger.GetErrorDetails(err))
+		}
+		if writeErr := exitreport.Write(os.Stderr, command, time.Since(startTime), err); writeErr != nil {
+			log.Warn().Msgf("Failed to write exit report: %v", writeErr)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err != nil {
 		// Display stack trace based on type of error
 		msg := logger.GetErrorDetails(err)
@@ -110,41 +274,255 @@ func initializeConfig(cmd *cobra.Command) error {
 			return err
 		}
 	}
+	if err := config.DecryptViper(); err != nil {
+		return err
+	}
 
-	viper.SetEnvPrefix("FLASHPIPE")
+	viper.SetEnvPrefix(config.EnvPrefix)
 
 	// Environment variables can't have dashes in them, so bind them to their equivalent
-	// keys with underscores, e.g. --artifact-id to FLASHPIPE_ARTIFACT_ID
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	// keys with underscores, e.g. --artifact-id to FLASHPIPE_ARTIFACT_ID. Keys of sections of
+	// the config file are bound the same way, e.g. configure.dryRun to FLASHPIPE_CONFIGURE_DRYRUN
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 
 	// Bind to environment variables
 	viper.AutomaticEnv()
 
-	// Bind the current command's flags to viper
-	bindFlags(cmd)
+	// Bind the current command's flags to viper, recording where their values came from
+	config.BindFlags(cmd)
 
 	// Set debug flag from command line to viper
 	if !viper.IsSet("debug") {
 		viper.Set("debug", config.GetBool(cmd, "debug"))
 	}
 
-	if config.GetString(cmd, "oauth-host") == "" && config.GetString(cmd, "tmn-userid") == "" {
-		return fmt.Errorf("required flag \"tmn-userid\" (Basic Auth) or \"oauth-host\" (OAuth) not set")
+	if err := applyCredentialSet(cmd); err != nil {
+		return err
+	}
+	if err := applyStoredCredentials(cmd); err != nil {
+		return err
+	}
+	if config.GetBool(cmd, "explain-config") {
+		return explainConfig(cmd)
 	}
 
+	tokenCommand := config.GetStringWithFallback(cmd, "token-command", "auth.tokenCommand")
+	login, err := browserLogin(cmd)
+	if err != nil {
+		return err
+	}
+	if isTenantOptional(cmd) {
+		relaxTenantFlags(cmd)
+	} else if config.GetString(cmd, "oauth-host") == "" && config.GetString(cmd, "tmn-userid") == "" && tokenCommand == "" {
+		return fmt.Errorf("required flag \"tmn-userid\" (Basic Auth), \"oauth-host\" (OAuth) or \"token-command\" not set")
+	} else if config.GetString(cmd, "oauth-host") != "" && config.GetString(cmd, "oauth-clientsecret") == "" && login == nil {
+		return fmt.Errorf("required flag \"oauth-clientsecret\" not set, or log in as user with \"oauth-browser\"")
+	}
+
+	if err := runid.Set(config.GetStringWithFallback(cmd, "run-id", "runId")); err != nil {
+		return err
+	}
+	if err := logger.SetTimeFormat(config.GetStringWithFallback(cmd, "log-time-format", "log.timeFormat")); err != nil {
+		return err
+	}
+	logger.SetDurations(config.GetBoolWithFallback(cmd, "log-durations", "log.durations"))
+	if cmd.Flags().Changed("ascii") || viper.IsSet("log.ascii") {
+		logger.SetASCII(config.GetBoolWithFallback(cmd, "ascii", "log.ascii"))
+	} else {
+		logger.SetASCII(logger.ASCIIAuto(os.Getenv))
+	}
 	logger.InitConsoleLogger(viper.GetBool("debug"))
 
+	telemetry.Init(telemetry.Options{
+		MetricsTextfile:    config.GetString(cmd, "metrics-textfile"),
+		MetricsPushgateway: config.GetString(cmd, "metrics-pushgateway"),
+		OTLPEndpoint:       config.GetStringWithDefault(cmd, "otel-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")),
+		ServiceName:        os.Getenv("OTEL_SERVICE_NAME"),
+	})
+	telemetry.StartRun(cmd.CommandPath(), "flashpipe.command", cmd.CommandPath())
+
+	if _, err := api.NewPlatform(config.GetString(cmd, "platform"), ""); err != nil {
+		return err
+	}
+	if err := api.ValidateODataVersion(config.GetString(cmd, "odata-version")); err != nil {
+		return err
+	}
+	maxResponseSize := config.GetInt(cmd, "max-response-size")
+	if maxResponseSize < 0 {
+		return fmt.Errorf("--max-response-size must not be negative")
+	}
+	httpclnt.SetDefaultMaxResponseSize(int64(maxResponseSize) << 20)
+	maxAuthFailures := config.GetInt(cmd, "max-auth-failures")
+	if maxAuthFailures < 0 {
+		return fmt.Errorf("--max-auth-failures must not be negative")
+	}
+	httpclnt.SetDefaultMaxAuthFailures(maxAuthFailures)
+	circuitOptions := httpclnt.CircuitOptions{
+		FailureRate:   config.GetInt(cmd, "circuit-failure-rate"),
+		ProbeInterval: time.Duration(config.GetInt(cmd, "circuit-probe-interval")) * time.Second,
+		MaxPause:      time.Duration(config.GetInt(cmd, "circuit-max-pause")) * time.Second,
+	}
+	if circuitOptions.FailureRate < 0 || circuitOptions.FailureRate > 100 {
+		return fmt.Errorf("--circuit-failure-rate must be between 0 and 100")
+	}
+	if circuitOptions.ProbeInterval <= 0 {
+		return fmt.Errorf("--circuit-probe-interval must be positive")
+	}
+	circuitOptions.DetectMaintenance = config.GetBoolWithFallback(cmd, "detect-maintenance", "maintenance.detect")
+	circuitOptions.StatusURL = config.GetStringWithFallback(cmd, "maintenance-status-url", "maintenance.statusUrl")
+	if circuitOptions.StatusURL != "" {
+		if u, err := url.Parse(circuitOptions.StatusURL); err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("--maintenance-status-url must be an absolute http or https URL")
+		}
+	}
+	if pattern := config.GetStringWithFallback(cmd, "maintenance-pattern", "maintenance.pattern"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid --maintenance-pattern: %w", err)
+		}
+		circuitOptions.MaintenancePattern = re
+	}
+	httpclnt.SetDefaultCircuitOptions(circuitOptions)
+	headers, err := httpHeaders(cmd)
+	if err != nil {
+		return err
+	}
+	httpclnt.SetDefaultHeaders(headers)
+	policies, err := requestPolicies()
+	if err != nil {
+		return err
+	}
+	httpclnt.SetDefaultRequestPolicies(policies, api.APIFamily)
+	httpclnt.SetDefaultBatchDiagnosticsDir(config.GetStringWithFallback(cmd, "batch-diagnostics-dir", "batchDiagnosticsDir"))
+	batchParallelism := config.GetIntWithFallback(cmd, "batch-parallelism", "batchParallelism")
+	if batchParallelism < 1 {
+		return fmt.Errorf("--batch-parallelism must be at least 1")
+	}
+	httpclnt.SetDefaultBatchParallelism(batchParallelism)
+	httpclnt.SetDefaultSignCommand(config.GetStringWithFallback(cmd, "http-sign-command", "httpSignCommand"))
+	httpclnt.SetDefaultTokenCommand(tokenCommand)
+	httpclnt.SetDefaultSamlAssertionCommand(config.GetStringWithFallback(cmd, "oauth-saml-assertion-command", "auth.samlAssertionCommand"))
+	httpclnt.SetDefaultBrowserLogin(login)
+	if config.GetBoolWithFallback(cmd, "system-proxy", "systemProxy") {
+		settings, err := sysproxy.Install()
+		switch {
+		case err != nil:
+			log.Warn().Msgf("Failed to discover the system proxy, only proxy environment variables are used: %v", err)
+		case settings != nil:
+			log.Debug().Msgf("Using the system proxy: http %v, https %v, bypass %v", settings.HTTP, settings.HTTPS, settings.Bypass)
+		}
+	}
+
+	if err := calm.Init(calm.Options{
+		URL:          config.GetStringWithFallback(cmd, "calm-events-url", "cloudALM.eventsUrl"),
+		TokenURL:     config.GetStringWithFallback(cmd, "calm-token-url", "cloudALM.tokenUrl"),
+		ClientID:     config.GetStringWithFallback(cmd, "calm-client-id", "cloudALM.clientId"),
+		ClientSecret: config.GetStringWithFallback(cmd, "calm-client-secret", "cloudALM.clientSecret"),
+		ServiceID:    config.GetStringWithFallback(cmd, "calm-service-id", "cloudALM.serviceId"),
+	}); err != nil {
+		return err
+	}
+	if err := audit.Init(audit.Options{
+		File:      config.GetStringWithFallback(cmd, "audit-log", "audit.file"),
+		HashChain: config.GetBoolWithFallback(cmd, "audit-hash-chain", "audit.hashChain"),
+	}); err != nil {
+		return err
+	}
+	if err := events.Init(events.Options{File: config.GetStringWithFallback(cmd, "events-file", "events.file")}); err != nil {
+		return err
+	}
+	events.Emit(events.Event{Type: events.TypeRunStarted, Command: cmd.CommandPath()})
+
 	return nil
 }
 
-// Bind each cobra flag to its associated viper configuration (config file and environment variable)
-func bindFlags(cmd *cobra.Command) {
-	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		configName := f.Name
-		// Apply the viper config value to the flag when the flag is not set and viper has a value
-		if !f.Changed && viper.IsSet(configName) {
-			val := viper.Get(configName)
-			cmd.Flags().Set(configName, fmt.Sprintf("%v", val))
+// httpHeaders returns the headers sent with every request: the run ID, the httpHeaders map of the config file with
+// environment variables expanded in the values, and from --http-header
+func httpHeaders(cmd *cobra.Command) (map[string]string, error) {
+	headers := map[string]string{}
+	if id := runid.ID(); id != "" {
+		headers[runid.Header] = id
+	}
+	for name, value := range viper.GetStringMapString("httpHeaders") {
+		headers[http.CanonicalHeaderKey(name)] = os.ExpandEnv(value)
+	}
+	flagHeaders, _ := cmd.Flags().GetStringArray("http-header")
+	for _, header := range flagHeaders {
+		name, value, err := httpclnt.ParseHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("--http-header: %w", err)
+		}
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	return headers, nil
+}
+
+// requestPolicies returns the timeout and retry policies by API family of the apiPolicies section of the config
+// file, e.g.
+//
+//	apiPolicies:
+//	  configuration:
+//	    timeout: 20s
+//	    retries: 3
+//	    retryDelay: 5s
+func requestPolicies() (map[string]httpclnt.RequestPolicy, error) {
+	policies := map[string]httpclnt.RequestPolicy{}
+	for family := range viper.GetStringMap("apiPolicies") {
+		if !slices.Contains(api.APIFamilies, family) {
+			return nil, fmt.Errorf("invalid API family %v in apiPolicies (valid values: %v)", family, strings.Join(api.APIFamilies, ", "))
+		}
+		key := "apiPolicies." + family
+		var policy httpclnt.RequestPolicy
+		var err error
+		if policy.Timeout, err = durationSetting(key + ".timeout"); err != nil {
+			return nil, err
+		}
+		if policy.RetryDelay, err = durationSetting(key + ".retryDelay"); err != nil {
+			return nil, err
+		}
+		policy.Retries = viper.GetInt(key + ".retries")
+		if policy.Retries < 0 {
+			return nil, fmt.Errorf("%v.retries must not be negative", key)
+		}
+		policies[family] = policy
+	}
+	return policies, nil
+}
+
+// durationSetting returns the duration of the config file setting, e.g. 90s or 5m, 0 if it is not set
+func durationSetting(key string) (time.Duration, error) {
+	value := viper.GetString(key)
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %v for %v, e.g. 90s or 5m", value, key)
+	}
+	return duration, nil
+}
+
+// annotationTenantOptional marks commands that can run without tenant details, e.g. because they only
+// read local files
+const annotationTenantOptional = "flashpipe_tenant_optional"
+
+// isTenantOptional returns true if the command runs without tenant, e.g. configure --offline
+func isTenantOptional(cmd *cobra.Command) bool {
+	if cmd.Flags().Lookup("offline") != nil && config.GetBoolWithFallback(cmd, "offline", "configure.offline") {
+		return true
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[annotationTenantOptional] == "true" {
+			return true
 		}
+	}
+	return false
+}
+
+// relaxTenantFlags removes the required and required together constraints of the tenant flags
+func relaxTenantFlags(cmd *cobra.Command) {
+	_ = cmd.Flags().SetAnnotation("tmn-host", cobra.BashCompOneRequiredFlag, []string{"false"})
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		delete(f.Annotations, "cobra_annotation_required_if_others_set")
 	})
 }
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Types of the entries of a drain check
+const (
+	DrainJMSQueue  = "jmsQueue"
+	DrainDataStore = "dataStore"
+)
+
+// DrainEntry is a JMS queue or data store with the number of its pending messages
+type DrainEntry struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Pending int    `json:"pending"`
+}
+
+// DrainStatus is the outcome of a drain check of an artifact
+type DrainStatus struct {
+	ArtifactID string       `json:"artifactId"`
+	Drained    bool         `json:"drained"`
+	Entries    []DrainEntry `json:"entries"`
+}
+
+// pending returns the entries with pending messages as text
+func (s *DrainStatus) pending() string {
+	var pending []string
+	for _, e := range s.Entries {
+		if e.Pending == 0 {
+			continue
+		}
+		if e.Type == DrainJMSQueue {
+			pending = append(pending, fmt.Sprintf("JMS queue %s (%d messages)", e.Name, e.Pending))
+		} else {
+			pending = append(pending, fmt.Sprintf("data store %s (%d entries)", e.Name, e.Pending))
+		}
+	}
+	return strings.Join(pending, ", ")
+}
+
+// jmsSenderQueuePattern matches the queue names of JMS sender channels in integration flow models
+var jmsSenderQueuePattern = regexp.MustCompile(`<key>QueueName_inbound</key>\s*<value>([^<]*)</value>`)
+
+func NewRuntimeCommand() *cobra.Command {
+
+	runtimeCmd := &cobra.Command{
+		Use:   "runtime",
+		Short: "Inspect runtime artifacts",
+		Long:  `Inspect artifacts deployed on the SAP Integration Suite tenant.`,
+	}
+	return runtimeCmd
+}
+
+func NewRuntimeDrainStatusCommand() *cobra.Command {
+
+	drainCmd := &cobra.Command{
+		Use:          "drain-status",
+		Short:        "Check that no messages are pending for an artifact",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Check that the JMS queues and data stores of an artifact have no pending
+messages, e.g. before it is undeployed or restarted.
+
+The JMS queues read by the JMS sender channels of the integration flow and
+its data stores are checked, together with the queues and data stores of
+--jms-queues and --data-stores. Externalized queue names are resolved with
+the configured values. With --no-discover, only the listed queues and data
+stores are checked. The command fails if messages are pending, after
+waiting up to --wait minutes for them to be processed.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'runtime.drainStatus' section. CLI flags override config file settings.`,
+		Example: `  # Check the queues and data stores of a flow
+  flashpipe runtime drain-status --artifact-id Orders_Process
+
+  # Redeploy a flow once its pending messages are processed
+  flashpipe runtime drain-status --artifact-id Orders_Process --wait 15 && \
+    flashpipe deploy --artifact-ids Orders_Process
+
+  # Check a queue filled by the flow as well, as JSON
+  flashpipe runtime drain-status --artifact-id Orders_Process --jms-queues Orders_Out --output-format json`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runRuntimeDrainStatus(cmd, os.Stdout); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	drainCmd.Flags().String("artifact-id", "", "ID of the integration flow (config: runtime.drainStatus.artifactId)")
+	drainCmd.Flags().StringSlice("jms-queues", nil, "Comma separated list of additional JMS queues to check (config: runtime.drainStatus.jmsQueues)")
+	drainCmd.Flags().StringSlice("data-stores", nil, "Comma separated list of additional data stores to check (config: runtime.drainStatus.dataStores)")
+	drainCmd.Flags().Bool("no-discover", false, "Only check --jms-queues and --data-stores, not the queues and data stores of the artifact (config: runtime.drainStatus.noDiscover)")
+	drainCmd.Flags().Int("wait", 0, "Minutes to wait for pending messages to be processed (config: runtime.drainStatus.waitMinutes)")
+	drainCmd.Flags().String("output-format", "text", "Output format. Allowed values: text, json (config: runtime.drainStatus.outputFormat)")
+
+	return drainCmd
+}
+
+func runRuntimeDrainStatus(cmd *cobra.Command, out io.Writer) error {
+	artifactID := config.GetStringWithFallback(cmd, "artifact-id", "runtime.drainStatus.artifactId")
+	queues := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "jms-queues", "runtime.drainStatus.jmsQueues"))
+	dataStores := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "data-stores", "runtime.drainStatus.dataStores"))
+	noDiscover := config.GetBoolWithFallback(cmd, "no-discover", "runtime.drainStatus.noDiscover")
+	wait := time.Duration(config.GetIntWithFallback(cmd, "wait", "runtime.drainStatus.waitMinutes")) * time.Minute
+	format := config.GetStringWithFallback(cmd, "output-format", "runtime.drainStatus.outputFormat")
+
+	if artifactID == "" {
+		return fmt.Errorf("--artifact-id is required (set via CLI flag or in config file under 'runtime.drainStatus.artifactId')")
+	}
+	switch format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("invalid value for --output-format = %v", format)
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	queues, err := drainQueues(exe, artifactID, queues, !noDiscover)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		status, err := drainStatus(exe, artifactID, queues, dataStores, !noDiscover)
+		if err != nil {
+			return err
+		}
+		if status.Drained || !time.Now().Before(deadline) {
+			if err := writeDrainStatus(out, status, format); err != nil {
+				return err
+			}
+			if !status.Drained {
+				return fmt.Errorf("%s is not drained: %s", artifactID, status.pending())
+			}
+			log.Info().Msgf("🏆 No messages pending for %s", artifactID)
+			return nil
+		}
+		log.Info().Msgf("Waiting for %s to drain", status.pending())
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// drainStatus returns the number of pending messages in the JMS queues and data stores, and in the data stores of
+// the integration flow with artifactDataStores
+func drainStatus(exe *httpclnt.HTTPExecuter, artifactID string, queues []string, dataStores []string, artifactDataStores bool) (*DrainStatus, error) {
+	ms := api.NewMessageStore(exe)
+	status := &DrainStatus{ArtifactID: artifactID, Drained: true, Entries: []DrainEntry{}}
+	add := func(entryType string, name string, pending int) {
+		status.Entries = append(status.Entries, DrainEntry{Type: entryType, Name: name, Pending: pending})
+		if pending > 0 {
+			status.Drained = false
+		}
+	}
+
+	for _, queue := range queues {
+		count, err := ms.QueueMessageCount(queue)
+		if err != nil {
+			return nil, fmt.Errorf("drain check of JMS queue %s failed: %w", queue, err)
+		}
+		add(DrainJMSQueue, queue, count)
+	}
+	if artifactDataStores {
+		stores, err := ms.ArtifactDataStores(artifactID)
+		if err != nil {
+			return nil, fmt.Errorf("drain check of data stores of %s failed: %w", artifactID, err)
+		}
+		for _, store := range stores {
+			if !slices.Contains(dataStores, store.Name) {
+				add(DrainDataStore, store.Name, store.Entries)
+			}
+		}
+	}
+	for _, dataStore := range dataStores {
+		count, err := ms.DataStoreMessageCount(dataStore)
+		if err != nil {
+			return nil, fmt.Errorf("drain check of data store %s failed: %w", dataStore, err)
+		}
+		add(DrainDataStore, dataStore, count)
+	}
+	return status, nil
+}
+
+// drainQueues returns the JMS queues to check, with discover including those read by the integration flow
+func drainQueues(exe *httpclnt.HTTPExecuter, artifactID string, queues []string, discover bool) ([]string, error) {
+	if !discover {
+		return queues, nil
+	}
+	discovered, err := artifactQueues(exe, artifactID)
+	if err != nil {
+		return nil, fmt.Errorf("drain check of JMS queues of %s failed: %w", artifactID, err)
+	}
+	queues = slices.Clone(queues)
+	for _, queue := range discovered {
+		if !slices.Contains(queues, queue) {
+			queues = append(queues, queue)
+		}
+	}
+	return queues, nil
+}
+
+// artifactQueues returns the JMS queues read by the JMS sender channels of the integration flow, with externalized
+// queue names resolved with the configured values
+func artifactQueues(exe *httpclnt.HTTPExecuter, artifactID string) ([]string, error) {
+	dt := api.NewIntegration(exe)
+	_, _, exists, err := dt.Get(artifactID, "active")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		log.Warn().Msgf("⚠️  Integration designtime artifact %s not found, its JMS queues are not checked", artifactID)
+		return nil, nil
+	}
+
+	workDir, err := os.MkdirTemp("", "flashpipe-drain-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+	zipFile := filepath.Join(workDir, artifactID+".zip")
+	if err := dt.Download(zipFile, artifactID); err != nil {
+		return nil, err
+	}
+	names, err := jmsSenderQueues(zipFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content of %s: %w", artifactID, err)
+	}
+
+	var queues []string
+	var parameters *api.ParametersData
+	for _, name := range names {
+		if key, found := strings.CutPrefix(name, "{{"); found {
+			if parameters == nil {
+				if parameters, err = api.NewConfigurationService(exe).Get(artifactID, "active"); err != nil {
+					return nil, err
+				}
+			}
+			key = strings.TrimSuffix(key, "}}")
+			param := api.FindParameterByKey(key, parameters.Root.Results)
+			if param == nil {
+				log.Warn().Msgf("⚠️  Queue name parameter %s of %s not found, the queue is not checked", key, artifactID)
+				continue
+			}
+			name = param.ParameterValue
+		}
+		if !slices.Contains(queues, name) {
+			queues = append(queues, name)
+		}
+	}
+	return queues, nil
+}
+
+// jmsSenderQueues returns the queue names of the JMS sender channels in the integration flow models of the content
+func jmsSenderQueues(zipFile string) ([]string, error) {
+	r, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".iflw") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		model, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range jmsSenderQueuePattern.FindAllStringSubmatch(string(model), -1) {
+			if name := strings.TrimSpace(match[1]); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func writeDrainStatus(out io.Writer, status *DrainStatus, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(status)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tNAME\tPENDING")
+	for _, e := range status.Entries {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", e.Type, e.Name, e.Pending)
+	}
+	return w.Flush()
+}
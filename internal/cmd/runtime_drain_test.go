@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainStatusMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, Flow reads Orders and an externalized queue
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')":
+			w.Write([]byte(`{"d": {"Version": "1.0.0"}}`))
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$value":
+			zw := zip.NewWriter(w)
+			f, _ := zw.Create("src/main/resources/scenarioflows/integrationflow/Flow.iflw")
+			f.Write([]byte(`<key>QueueName_inbound</key><value>Orders</value>
+				<key>QueueName_inbound</key>
+				<value>{{Retry Queue}}</value>`))
+			zw.Close()
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/Configurations":
+			w.Write([]byte(`{"d": {"results": [{"ParameterKey": "Retry Queue", "ParameterValue": "Orders_Retry", "DataType": "xsd:string"}]}}`))
+		case "/api/v1/Queues":
+			if r.URL.Query().Get("$filter") == "Name eq 'Orders_Retry'" {
+				w.Write([]byte(`{"d": {"results": [{"NumberOfMessages": "3"}]}}`))
+				return
+			}
+			w.Write([]byte(`{"d": {"results": [{"NumberOfMessages": "0"}]}}`))
+		case "/api/v1/DataStores":
+			w.Write([]byte(`{"d": {"results": [{"DataStoreName": "Pending", "IntegrationFlow": "Flow", "NumberOfMessages": 1}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	queues, err := drainQueues(exe, "Flow", []string{"Orders"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Orders", "Orders_Retry"}, queues, "Externalized queue names should be resolved")
+
+	status, err := drainStatus(exe, "Flow", queues, nil, true)
+	require.NoError(t, err)
+	assert.False(t, status.Drained)
+	assert.Equal(t, []DrainEntry{
+		{Type: DrainJMSQueue, Name: "Orders", Pending: 0},
+		{Type: DrainJMSQueue, Name: "Orders_Retry", Pending: 3},
+		{Type: DrainDataStore, Name: "Pending", Pending: 1},
+	}, status.Entries)
+	assert.Equal(t, "JMS queue Orders_Retry (3 messages), data store Pending (1 entries)", status.pending())
+
+	var out bytes.Buffer
+	require.NoError(t, writeDrainStatus(&out, status, "json"))
+	assert.Contains(t, out.String(), `"drained": false`)
+
+	queues, err = drainQueues(exe, "Missing", nil, true)
+	require.NoError(t, err, "Artifacts without designtime artifact should be skipped")
+	assert.Empty(t, queues)
+}
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/pipeline"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Retries of error information requests that were rate limited by the tenant
+var (
+	runtimeErrorsRetries    = 3
+	runtimeErrorsRetryDelay = 10 * time.Second
+)
+
+// RuntimeErrors is the error information of the artifacts in ERROR state on a tenant
+type RuntimeErrors struct {
+	Tenant    string              `json:"tenant"`
+	Collected time.Time           `json:"collected"`
+	Complete  bool                `json:"complete"` // False while collecting and if the information of an artifact could not be retrieved
+	Artifacts []RuntimeErrorEntry `json:"artifacts"`
+}
+
+// RuntimeErrorEntry is the error information of a deployed artifact
+type RuntimeErrorEntry struct {
+	ArtifactID       string `json:"artifactId"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	Version          string `json:"version"`
+	DeployedBy       string `json:"deployedBy"`
+	DeployedOn       string `json:"deployedOn"`
+	ErrorInformation string `json:"errorInformation,omitempty"`
+	Failure          string `json:"failure,omitempty"` // Why the error information could not be retrieved
+}
+
+func NewRuntimeErrorsCommand() *cobra.Command {
+
+	errorsCmd := &cobra.Command{
+		Use:          "errors",
+		Short:        "Download the error information of artifacts in ERROR state",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Download the error information of the deployed artifacts in ERROR state,
+e.g. for the triage of failed deployments.
+
+The artifacts are selected by --all, or by --artifact-ids and --filter, a
+pattern of runtime artifact IDs such as Orders_*. The error information is
+requested for one artifact at a time, at most --rate requests per second.
+Requests rate limited by the tenant are retried.
+
+With --out, the file is written after each artifact, so that a run that was
+interrupted or could not retrieve the information of some artifacts can be
+continued with --resume. Artifacts whose information is in the file and
+that were not deployed again since are not requested again.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'runtime.errors' section. CLI flags override config file settings.`,
+		Example: `  # Collect the errors of all artifacts of the tenant
+  flashpipe runtime errors --all --out errors.json
+
+  # Continue after the run was interrupted
+  flashpipe runtime errors --all --out errors.json --resume
+
+  # Print the errors of the order flows
+  flashpipe runtime errors --filter "Orders_*"`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runRuntimeErrors(cmd, os.Stdout); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	errorsCmd.Flags().Bool("all", false, "Collect the errors of all artifacts in ERROR state (config: runtime.errors.all)")
+	errorsCmd.Flags().StringSlice("artifact-ids", nil, "Comma separated list of runtime artifact IDs (config: runtime.errors.artifactIds)")
+	errorsCmd.Flags().String("filter", "", "Pattern of the IDs of the runtime artifacts, e.g. Orders_* (config: runtime.errors.filter)")
+	errorsCmd.Flags().String("out", "", "JSON file the errors are written to, printed to stdout if not set (config: runtime.errors.out)")
+	errorsCmd.Flags().Int("rate", 5, "Maximum error information requests per second, 0 for no limit (config: runtime.errors.rate)")
+	errorsCmd.Flags().Bool("resume", false, "Continue the collection in --out, skipping the artifacts already collected (config: runtime.errors.resume)")
+
+	return errorsCmd
+}
+
+func runRuntimeErrors(cmd *cobra.Command, stdout io.Writer) error {
+	all := config.GetBoolWithFallback(cmd, "all", "runtime.errors.all")
+	ids := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "artifact-ids", "runtime.errors.artifactIds"))
+	pattern := config.GetStringWithFallback(cmd, "filter", "runtime.errors.filter")
+	out := config.GetStringWithFallback(cmd, "out", "runtime.errors.out")
+	rate := config.GetIntWithFallback(cmd, "rate", "runtime.errors.rate")
+	resume := config.GetBoolWithFallback(cmd, "resume", "runtime.errors.resume")
+
+	if !all && len(ids) == 0 && pattern == "" {
+		return fmt.Errorf("--all, --artifact-ids or --filter is required (set via CLI flag or in config file under 'runtime.errors')")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid value for --filter = %v: %w", pattern, err)
+	}
+	if rate < 0 {
+		return fmt.Errorf("invalid value for --rate = %v", rate)
+	}
+	if resume && out == "" {
+		return fmt.Errorf("--resume requires --out")
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	var previous *RuntimeErrors
+	if resume {
+		var err error
+		if previous, err = readRuntimeErrors(out); err != nil {
+			return err
+		}
+	}
+	var write func(*RuntimeErrors) error
+	if out != "" {
+		write = func(report *RuntimeErrors) error { return writeRuntimeErrors(out, report) }
+	}
+	report, err := collectRuntimeErrors(exe, ids, pattern, rate, previous, write)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, a := range report.Artifacts {
+		if a.Failure != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		if out != "" {
+			return fmt.Errorf("error information of %d of %d artifact(s) could not be retrieved, continue with --resume", failed, len(report.Artifacts))
+		}
+		return fmt.Errorf("error information of %d of %d artifact(s) could not be retrieved", failed, len(report.Artifacts))
+	}
+	log.Info().Msgf("🏆 Error information of %d artifact(s) collected", len(report.Artifacts))
+	return nil
+}
+
+// collectRuntimeErrors returns the error information of the artifacts in ERROR state that are in ids, if any,
+// and match the pattern, if any. The entries of previous of artifacts not deployed again since are kept
+// without requesting them again. The report is passed to write, if set, after each artifact.
+func collectRuntimeErrors(exe *httpclnt.HTTPExecuter, ids []string, pattern string, rate int,
+	previous *RuntimeErrors, write func(*RuntimeErrors) error) (*RuntimeErrors, error) {
+
+	rt := api.NewRuntime(exe)
+	artifacts, err := rt.List()
+	if err != nil {
+		return nil, err
+	}
+
+	collected := map[string]RuntimeErrorEntry{}
+	if previous != nil && previous.Tenant == exe.Host() {
+		for _, entry := range previous.Artifacts {
+			if entry.Failure == "" {
+				collected[entry.ArtifactID] = entry
+			}
+		}
+	}
+
+	report := &RuntimeErrors{Tenant: exe.Host(), Collected: time.Now(), Artifacts: []RuntimeErrorEntry{}}
+	var tasks []pipeline.Task
+	resumed := 0
+	limiter := newRateLimiter(rate)
+	for _, a := range artifacts {
+		if a.Status != "ERROR" || (len(ids) > 0 && !slices.Contains(ids, a.Id)) {
+			continue
+		}
+		if matched, _ := path.Match(pattern, a.Id); pattern != "" && !matched {
+			continue
+		}
+		entry := RuntimeErrorEntry{ArtifactID: a.Id, Name: a.Name, Type: a.Type, Version: a.Version, DeployedBy: a.DeployedBy, DeployedOn: a.DeployedOn}
+		if c, found := collected[a.Id]; found && c.DeployedOn == a.DeployedOn && c.Version == a.Version {
+			entry = c
+			resumed++
+		}
+		i := len(report.Artifacts)
+		report.Artifacts = append(report.Artifacts, entry)
+		if entry.ErrorInformation != "" {
+			continue
+		}
+		tasks = append(tasks, pipeline.Task{ID: a.Id, Run: func() error {
+			limiter.wait()
+			info, err := rt.GetErrorInfo(a.Id)
+			report.Artifacts[i].ErrorInformation = info
+			return err
+		}})
+	}
+	if resumed > 0 {
+		log.Info().Msgf("Resuming with %d of %d artifact(s) in ERROR state already collected", resumed, len(report.Artifacts))
+	} else {
+		log.Info().Msgf("Collecting error information of %d artifact(s) in ERROR state", len(report.Artifacts))
+	}
+
+	// The report is written after each artifact, so that an interrupted run can be resumed
+	var writeErr error
+	save := func() {
+		if write != nil && writeErr == nil {
+			writeErr = write(report)
+		}
+	}
+	save()
+	_, err = pipeline.Run(tasks, pipeline.Options{
+		Parallel:   1,
+		Retries:    runtimeErrorsRetries,
+		RetryDelay: runtimeErrorsRetryDelay,
+		Retryable:  func(err error) bool { return errors.Is(err, api.ErrRateLimited) },
+		OnDone: func(r pipeline.Result) {
+			if r.Err != nil {
+				log.Warn().Msgf("⚠️  Error information of %s could not be retrieved: %v", r.ID, r.Err)
+				for i := range report.Artifacts {
+					if report.Artifacts[i].ArtifactID == r.ID {
+						report.Artifacts[i].Failure = r.Err.Error()
+					}
+				}
+			}
+			save()
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Complete = true
+	for _, a := range report.Artifacts {
+		if a.Failure != "" {
+			report.Complete = false
+		}
+	}
+	save()
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	return report, nil
+}
+
+// readRuntimeErrors returns the report of the file, nil if it does not exist
+func readRuntimeErrors(file string) (*RuntimeErrors, error) {
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report := new(RuntimeErrors)
+	if err := json.Unmarshal(content, report); err != nil {
+		return nil, fmt.Errorf("failed to read %v to resume: %w", file, err)
+	}
+	return report, nil
+}
+
+// writeRuntimeErrors writes the report to a temporary file that replaces the file, so that the file is
+// complete if the run is killed while writing it
+func writeRuntimeErrors(file string, report *RuntimeErrors) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file+".tmp", content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+// rateLimiter spaces out requests to at most a number per second
+type rateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// newRateLimiter returns a limiter of perSecond requests, without limit for 0
+func newRateLimiter(perSecond int) *rateLimiter {
+	r := new(rateLimiter)
+	if perSecond > 0 {
+		r.interval = time.Second / time.Duration(perSecond)
+	}
+	return r
+}
+
+// wait waits until the next request may be sent
+func (r *rateLimiter) wait() {
+	if r.interval <= 0 {
+		return
+	}
+	r.mu.Lock()
+	now := time.Now()
+	start := now
+	if r.next.After(now) {
+		start = r.next
+	}
+	r.next = start.Add(r.interval)
+	r.mu.Unlock()
+	time.Sleep(start.Sub(now))
+}
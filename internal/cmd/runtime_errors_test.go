@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectRuntimeErrorsMock(t *testing.T) {
+	// Set up local server with mock HTTP responses, the second page of artifacts is reached by __next and
+	// the first error information request of Orders_Cancel is rate limited
+	var mu sync.Mutex
+	requested := map[string]int{}
+	failInvoices := true
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/api/v1/IntegrationRuntimeArtifacts" && r.URL.Query().Get("$skiptoken") == "":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Orders_Create", "Status": "ERROR", "Version": "1.0.0", "DeployedOn": "/Date(1)/"},
+				{"Id": "Orders_Cancel", "Status": "ERROR", "Version": "1.0.1"}, {"Id": "Orders_Mapping", "Status": "STARTED"}],
+				"__next": "IntegrationRuntimeArtifacts?$skiptoken=3"}}`))
+		case r.URL.Path == "/api/v1/IntegrationRuntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Invoices", "Status": "ERROR", "Version": "2.0.0"}]}}`))
+		case strings.HasSuffix(r.URL.Path, "/ErrorInformation/$value"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/IntegrationRuntimeArtifacts('"), "')/ErrorInformation/$value")
+			requested[id]++
+			if id == "Orders_Cancel" && requested[id] == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			if id == "Invoices" && failInvoices {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"parameter": ["Error of ` + id + `"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	runtimeErrorsRetryDelay = time.Millisecond
+	out := filepath.Join(t.TempDir(), "errors.json")
+	write := func(report *RuntimeErrors) error { return writeRuntimeErrors(out, report) }
+
+	report, err := collectRuntimeErrors(exe, nil, "", 0, nil, write)
+	require.NoError(t, err)
+	require.Len(t, report.Artifacts, 3, "Only artifacts in ERROR state of all pages should be collected")
+	assert.Equal(t, "Error of Orders_Create", report.Artifacts[0].ErrorInformation)
+	assert.Equal(t, "Error of Orders_Cancel", report.Artifacts[1].ErrorInformation, "Rate limited requests should be retried")
+	assert.Contains(t, report.Artifacts[2].Failure, "response code = 500")
+	assert.False(t, report.Complete)
+
+	// Resuming only requests the error information that could not be retrieved
+	failInvoices = false
+	previous, err := readRuntimeErrors(out)
+	require.NoError(t, err)
+	assert.Equal(t, report.Artifacts, previous.Artifacts, "The file should be up to date")
+	report, err = collectRuntimeErrors(exe, nil, "", 100, previous, write)
+	require.NoError(t, err)
+	assert.Equal(t, "Error of Invoices", report.Artifacts[2].ErrorInformation)
+	assert.Empty(t, report.Artifacts[2].Failure)
+	assert.True(t, report.Complete)
+	assert.Equal(t, map[string]int{"Orders_Create": 1, "Orders_Cancel": 2, "Invoices": 2}, requested)
+
+	report, err = collectRuntimeErrors(exe, []string{"Orders_Create", "Invoices"}, "Orders_*", 0, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, report.Artifacts, 1)
+	assert.Equal(t, "Orders_Create", report.Artifacts[0].ArtifactID)
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// Log levels of the message processing logs of integration flows
+const (
+	LogLevelNone  = "NONE"
+	LogLevelInfo  = "INFO"
+	LogLevelDebug = "DEBUG"
+	LogLevelTrace = "TRACE"
+)
+
+// traceRefreshInterval is the time after which the trace level is set again, as the tenant reverts it after 10 minutes
+var traceRefreshInterval = 9 * time.Minute
+
+func NewRuntimeTraceCommand() *cobra.Command {
+
+	traceCmd := &cobra.Command{
+		Use:   "trace",
+		Short: "Set the log level of deployed integration flows",
+		Long: `Set the log level of the message processing logs of deployed integration
+flows, e.g. to trace messages during incident analysis.`,
+	}
+	return traceCmd
+}
+
+func NewRuntimeTraceEnableCommand() *cobra.Command {
+
+	enableCmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Trace deployed integration flows for a duration",
+		Long: `Set the log level of deployed integration flows to TRACE, or DEBUG with
+--log-level, and revert it to --revert-level after --duration.
+
+The command keeps running for the duration. As the tenant reverts the
+TRACE level after 10 minutes, it is set again until the duration has
+passed. The log level is reverted early on SIGINT or SIGTERM. With
+--duration 0, the log level is set and not reverted.
+
+The flows are selected by --artifact-id, or by --filter, a pattern of
+runtime artifact IDs such as Orders_*, and --package-id.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'runtime.trace.enable' section. CLI flags override config file settings.`,
+		Example: `  # Trace a flow for 10 minutes
+  flashpipe runtime trace enable --artifact-id Orders_Process
+
+  # Trace all order flows for 30 minutes
+  flashpipe runtime trace enable --filter "Orders_*" --duration 30m
+
+  # Debug the flows of a package until disabled
+  flashpipe runtime trace enable --package-id Sales --log-level DEBUG --duration 0`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runRuntimeTraceEnable(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	addTraceTargetFlags(enableCmd, "runtime.trace.enable")
+	enableCmd.Flags().String("log-level", LogLevelTrace, "Log level to set. Allowed values: TRACE, DEBUG (config: runtime.trace.enable.logLevel)")
+	enableCmd.Flags().String("duration", "10m", "Time after which the log level is reverted, e.g. 30m or 2h, 0 to not revert it (config: runtime.trace.enable.duration)")
+	enableCmd.Flags().String("revert-level", LogLevelInfo, "Log level set after the duration. Allowed values: NONE, INFO, DEBUG (config: runtime.trace.enable.revertLevel)")
+
+	return enableCmd
+}
+
+func NewRuntimeTraceDisableCommand() *cobra.Command {
+
+	disableCmd := &cobra.Command{
+		Use:   "disable",
+		Short: "Stop tracing deployed integration flows",
+		Long: `Set the log level of deployed integration flows back to INFO, or to
+--log-level, e.g. after tracing them with --duration 0.
+
+The flows are selected by --artifact-id, or by --filter, a pattern of
+runtime artifact IDs such as Orders_*, and --package-id.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'runtime.trace.disable' section. CLI flags override config file settings.`,
+		Example: `  # Stop tracing a flow
+  flashpipe runtime trace disable --artifact-id Orders_Process
+
+  # Switch off the message processing logs of the flows of a package
+  flashpipe runtime trace disable --package-id Monitoring --log-level NONE`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runRuntimeTraceDisable(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	addTraceTargetFlags(disableCmd, "runtime.trace.disable")
+	disableCmd.Flags().String("log-level", LogLevelInfo, "Log level to set. Allowed values: NONE, INFO, DEBUG (config: runtime.trace.disable.logLevel)")
+
+	return disableCmd
+}
+
+// addTraceTargetFlags adds the flags that select the integration flows
+func addTraceTargetFlags(cmd *cobra.Command, section string) {
+	cmd.Flags().String("artifact-id", "", fmt.Sprintf("ID of the integration flow (config: %s.artifactId)", section))
+	cmd.Flags().String("filter", "", fmt.Sprintf("Pattern of the IDs of the deployed integration flows, e.g. Orders_* (config: %s.filter)", section))
+	cmd.Flags().String("package-id", "", fmt.Sprintf("ID of Integration Package whose deployed integration flows are selected (config: %s.packageId)", section))
+}
+
+func runRuntimeTraceEnable(cmd *cobra.Command) error {
+	level := strings.ToUpper(config.GetStringWithFallback(cmd, "log-level", "runtime.trace.enable.logLevel"))
+	revertLevel := strings.ToUpper(config.GetStringWithFallback(cmd, "revert-level", "runtime.trace.enable.revertLevel"))
+	duration, err := time.ParseDuration(config.GetStringWithFallback(cmd, "duration", "runtime.trace.enable.duration"))
+	if err != nil {
+		return fmt.Errorf("invalid value for --duration: %w", err)
+	}
+	if level != LogLevelTrace && level != LogLevelDebug {
+		return fmt.Errorf("invalid value for --log-level = %v", level)
+	}
+	if revertLevel != LogLevelNone && revertLevel != LogLevelInfo && revertLevel != LogLevelDebug {
+		return fmt.Errorf("invalid value for --revert-level = %v", revertLevel)
+	}
+
+	exe, ids, err := traceTargetsFromCmd(cmd, "runtime.trace.enable")
+	if err != nil {
+		return err
+	}
+	if err := setLogLevels(exe, ids, level); err != nil {
+		return err
+	}
+	if duration <= 0 {
+		log.Info().Msgf("🏆 Log level of %d integration flow(s) set to %v", len(ids), level)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return holdLogLevel(ctx, exe, ids, level, revertLevel, duration)
+}
+
+func runRuntimeTraceDisable(cmd *cobra.Command) error {
+	level := strings.ToUpper(config.GetStringWithFallback(cmd, "log-level", "runtime.trace.disable.logLevel"))
+	if level != LogLevelNone && level != LogLevelInfo && level != LogLevelDebug {
+		return fmt.Errorf("invalid value for --log-level = %v", level)
+	}
+
+	exe, ids, err := traceTargetsFromCmd(cmd, "runtime.trace.disable")
+	if err != nil {
+		return err
+	}
+	if err := setLogLevels(exe, ids, level); err != nil {
+		return err
+	}
+	log.Info().Msgf("🏆 Log level of %d integration flow(s) set to %v", len(ids), level)
+	return nil
+}
+
+func traceTargetsFromCmd(cmd *cobra.Command, section string) (*httpclnt.HTTPExecuter, []string, error) {
+	artifactID := config.GetStringWithFallback(cmd, "artifact-id", section+".artifactId")
+	pattern := config.GetStringWithFallback(cmd, "filter", section+".filter")
+	packageID := config.GetStringWithFallback(cmd, "package-id", section+".packageId")
+
+	if artifactID == "" && pattern == "" && packageID == "" {
+		return nil, nil, fmt.Errorf("--artifact-id, --filter or --package-id is required (set via CLI flag or in config file under '%s')", section)
+	}
+	if artifactID != "" && (pattern != "" || packageID != "") {
+		return nil, nil, fmt.Errorf("--artifact-id cannot be combined with --filter or --package-id")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, nil, fmt.Errorf("invalid value for --filter = %v: %w", pattern, err)
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails)
+	ids, err := traceTargets(exe, artifactID, pattern, packageID)
+	return exe, ids, err
+}
+
+// traceTargets returns the artifact, or the deployed integration flows matching the pattern and in the package
+func traceTargets(exe *httpclnt.HTTPExecuter, artifactID string, pattern string, packageID string) ([]string, error) {
+	if artifactID != "" {
+		return []string{artifactID}, nil
+	}
+
+	var packageFlows []string
+	if packageID != "" {
+		flows, err := api.NewIntegrationPackage(exe).GetArtifactsData(packageID, "Integration")
+		if err != nil {
+			return nil, err
+		}
+		for _, flow := range flows {
+			packageFlows = append(packageFlows, flow.Id)
+		}
+	}
+	runtimeArtifacts, err := api.NewRuntime(exe).List()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, artifact := range runtimeArtifacts {
+		if artifact.Type != "INTEGRATION_FLOW" {
+			continue
+		}
+		if pattern != "" {
+			if matched, _ := path.Match(pattern, artifact.Id); !matched {
+				continue
+			}
+		}
+		if packageID != "" && !slices.Contains(packageFlows, artifact.Id) {
+			continue
+		}
+		ids = append(ids, artifact.Id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no deployed integration flows match --filter %q and --package-id %q", pattern, packageID)
+	}
+	return ids, nil
+}
+
+// setLogLevels sets the log level of the integration flows. All flows are attempted, the failures are returned
+// together.
+func setLogLevels(exe *httpclnt.HTTPExecuter, ids []string, level string) error {
+	rt := api.NewRuntime(exe)
+	failed := 0
+	for _, id := range ids {
+		if err := rt.SetLogLevel(id, level); err != nil {
+			log.Error().Msgf("Failed to set log level of %s: %v", id, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("log level of %d integration flow(s) failed to be set to %v", failed, level)
+	}
+	return nil
+}
+
+// holdLogLevel keeps the log level of the integration flows for the duration, setting TRACE again before the tenant
+// reverts it, and then sets the revert level. The revert level is also set when the context is cancelled.
+func holdLogLevel(ctx context.Context, exe *httpclnt.HTTPExecuter, ids []string, level string, revertLevel string, duration time.Duration) error {
+	log.Info().Msgf("Log level of %d integration flow(s) set to %v until %v", len(ids), level, time.Now().Add(duration).Format(time.TimeOnly))
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+	refresh := time.NewTicker(traceRefreshInterval)
+	defer refresh.Stop()
+
+hold:
+	for {
+		select {
+		case <-ctx.Done():
+			log.Warn().Msgf("⚠️  Interrupted, reverting log level to %v", revertLevel)
+			break hold
+		case <-deadline.C:
+			break hold
+		case <-refresh.C:
+			if level != LogLevelTrace {
+				continue
+			}
+			if err := setLogLevels(exe, ids, level); err != nil {
+				log.Error().Msgf("Failed to refresh log level: %v", err)
+			}
+		}
+	}
+
+	if err := setLogLevels(exe, ids, revertLevel); err != nil {
+		return err
+	}
+	log.Info().Msgf("🏆 Log level of %d integration flow(s) reverted to %v", len(ids), revertLevel)
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeTraceMock(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	var mu sync.Mutex
+	var levels []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case "/Operations/com.sap.it.op.tmn.commands.dashboard.webui.IntegrationComponentSetMplLogLevelCommand":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			levels = append(levels, string(body))
+			mu.Unlock()
+		case "/api/v1/IntegrationRuntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Orders_Create", "Type": "INTEGRATION_FLOW"}, {"Id": "Orders_Cancel", "Type": "INTEGRATION_FLOW"},
+				{"Id": "Orders_Mapping", "Type": "MESSAGE_MAPPING"}, {"Id": "Invoices", "Type": "INTEGRATION_FLOW"}]}}`))
+		case "/api/v1/IntegrationPackages('Sales')/IntegrationDesigntimeArtifacts":
+			w.Write([]byte(`{"d": {"results": [{"Id": "Orders_Cancel", "Version": "1.0.0"}, {"Id": "Invoices", "Version": "1.0.0"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	ids, err := traceTargets(exe, "", "Orders_*", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Orders_Create", "Orders_Cancel"}, ids, "Only integration flows should be selected")
+	ids, err = traceTargets(exe, "", "Orders_*", "Sales")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Orders_Cancel"}, ids)
+	_, err = traceTargets(exe, "", "Payments_*", "")
+	assert.Error(t, err, "No matching flows should be an error")
+
+	traceRefreshInterval = 20 * time.Millisecond
+	require.NoError(t, holdLogLevel(context.Background(), exe, []string{"Orders_Cancel"}, LogLevelTrace, LogLevelInfo, 70*time.Millisecond))
+	require.GreaterOrEqual(t, len(levels), 2, "Trace level should be set again during the duration")
+	assert.JSONEq(t, `{"artifactSymbolicName": "Orders_Cancel", "mplLogLevel": "TRACE", "nodeType": "IFLMAP"}`, levels[0])
+	assert.JSONEq(t, `{"artifactSymbolicName": "Orders_Cancel", "mplLogLevel": "INFO", "nodeType": "IFLMAP"}`, levels[len(levels)-1])
+
+	levels = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, holdLogLevel(ctx, exe, []string{"Orders_Cancel"}, LogLevelDebug, LogLevelNone, time.Hour))
+	assert.Len(t, levels, 1, "Log level should be reverted when interrupted")
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/schema"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewSchemaCommand() *cobra.Command {
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema [kind]",
+		Short: "Print the JSON Schema or an annotated example of a config file kind",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		Long: `Print the JSON Schema of a kind of config file, or an annotated example with
+--example. With --output, the schemas and examples of all kinds, or of the
+given kind, are written to a directory.
+
+Supported kinds: ` + strings.Join(schema.KindNames(), ", ") + `
+
+Editors with the YAML language server (e.g. VS Code with the YAML extension)
+offer autocompletion and validation for files that reference the schema in
+their first line:
+
+  # yaml-language-server: $schema=./schemas/configure.schema.json`,
+		Example: `  # Print the schema of the configure config
+  flashpipe schema configure
+
+  # Start a new value mapping file from the annotated example
+  flashpipe schema valuemapping --example > Country_Codes.yml
+
+  # Write the schemas and examples of all kinds to ./schemas
+  flashpipe schema --output ./schemas`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runSchema(cmd, args, os.Stdout)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	schemaCmd.Flags().Bool("example", false, "Print the annotated example instead of the schema (config: schema.example)")
+	schemaCmd.Flags().String("output", "", "Directory the schemas and examples are written to (config: schema.output)")
+
+	return schemaCmd
+}
+
+func runSchema(cmd *cobra.Command, args []string, out io.Writer) error {
+	example := config.GetBoolWithFallback(cmd, "example", "schema.example")
+	outputDir := config.GetStringWithFallback(cmd, "output", "schema.output")
+
+	kinds := schema.Kinds
+	if len(args) > 0 {
+		kind, err := schema.Find(args[0])
+		if err != nil {
+			return err
+		}
+		kinds = []schema.Kind{kind}
+	}
+
+	if outputDir == "" {
+		if len(args) == 0 {
+			return fmt.Errorf("kind or --output is required (supported kinds: %s)", strings.Join(schema.KindNames(), ", "))
+		}
+		content, err := kindContent(kinds[0], example)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(content)
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return err
+	}
+	for _, kind := range kinds {
+		for _, isExample := range []bool{false, true} {
+			content, err := kindContent(kind, isExample)
+			if err != nil {
+				return err
+			}
+			name := kind.SchemaFile()
+			if isExample {
+				name = kind.ExampleFile()
+			}
+			path := filepath.Join(outputDir, name)
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				return err
+			}
+			log.Info().Msgf("Written %s", path)
+		}
+	}
+	return nil
+}
+
+// kindContent returns the schema or the example of a kind
+func kindContent(kind schema.Kind, example bool) ([]byte, error) {
+	if example {
+		return kind.Example()
+	}
+	return kind.Schema()
+}
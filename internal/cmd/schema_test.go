@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSchema(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runSchema(NewSchemaCommand(), []string{"configure"}, &out))
+	assert.Contains(t, out.String(), `"$schema": "http://json-schema.org/draft-07/schema#"`)
+
+	out.Reset()
+	cmd := NewSchemaCommand()
+	require.NoError(t, cmd.Flags().Set("example", "true"))
+	require.NoError(t, runSchema(cmd, []string{"valuemapping"}, &out))
+	assert.Contains(t, out.String(), "# yaml-language-server: $schema=./valuemapping.schema.json")
+
+	assert.ErrorContains(t, runSchema(NewSchemaCommand(), nil, &out), "kind or --output is required")
+	assert.ErrorContains(t, runSchema(NewSchemaCommand(), []string{"partners"}, &out), `unknown kind "partners"`)
+
+	dir := t.TempDir()
+	cmd = NewSchemaCommand()
+	require.NoError(t, cmd.Flags().Set("output", dir))
+	require.NoError(t, runSchema(cmd, nil, &out))
+	for _, name := range []string{"configure.schema.json", "configure.example.yml", "deploy.schema.json", "valuemapping.example.yml"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+	}
+}
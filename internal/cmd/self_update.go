@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/selfupdate"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewSelfUpdateCommand() *cobra.Command {
+
+	selfUpdateCmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update flashpipe to the latest release",
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		SilenceUsage: true,
+		Long: `Replace the flashpipe binary with the latest release on GitHub.
+
+The binary of the release is verified with the SHA-256 checksums published with
+the release (checksums.txt or <asset>.sha256) and is not installed without them.
+With --public-key, the signature of the checksums file (.sig, or .minisig for
+minisign keys) is verified as well.
+
+The stable channel installs the latest release, the edge channel also
+pre-releases. Set GITHUB_TOKEN to avoid the rate limits of the GitHub API.`,
+		Example: `  # Show whether a newer release is available
+  flashpipe self-update --check
+
+  # Install the latest pre-release, verifying the signature of the checksums
+  flashpipe self-update --channel edge --public-key ./release.pub`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runSelfUpdate(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	selfUpdateCmd.Flags().String("channel", selfupdate.ChannelStable, "Release channel: stable or edge, which includes pre-releases (config: selfUpdate.channel)")
+	selfUpdateCmd.Flags().String("repository", "engswee/flashpipe", "GitHub repository of the releases as <owner>/<name> (config: selfUpdate.repository)")
+	selfUpdateCmd.Flags().String("public-key", "", "Public key file the signature of the checksums is verified with (config: selfUpdate.publicKey)")
+	selfUpdateCmd.Flags().Bool("check", false, "Only show whether a newer release is available (config: selfUpdate.check)")
+	selfUpdateCmd.Flags().Bool("force", false, "Install the release even if it is not newer than the current version (config: selfUpdate.force)")
+
+	return selfUpdateCmd
+}
+
+func runSelfUpdate(cmd *cobra.Command) error {
+	opts := selfupdate.Options{
+		Repository: config.GetStringWithFallback(cmd, "repository", "selfUpdate.repository"),
+		Channel:    config.GetStringWithFallback(cmd, "channel", "selfUpdate.channel"),
+		PublicKey:  config.GetStringWithFallback(cmd, "public-key", "selfUpdate.publicKey"),
+	}
+	check := config.GetBoolWithFallback(cmd, "check", "selfUpdate.check")
+	force := config.GetBoolWithFallback(cmd, "force", "selfUpdate.force")
+	current := cmd.Root().Version
+
+	release, err := selfupdate.Latest(opts)
+	if err != nil {
+		return err
+	}
+	newer := selfupdate.Newer(release.Version(), current)
+	if !newer && !force {
+		log.Info().Msgf("flashpipe %s is up to date (latest %s release: %s)", current, channelName(opts.Channel), release.Version())
+		return nil
+	}
+	if check {
+		if newer {
+			log.Info().Msgf("flashpipe %s is available, current version is %s", release.Version(), current)
+		}
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the flashpipe binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to find the flashpipe binary: %w", err)
+	}
+	if err := selfupdate.Install(release, opts, executable); err != nil {
+		return err
+	}
+	log.Info().Msgf("🏆 Updated flashpipe from %s to %s", current, release.Version())
+	return nil
+}
+
+func channelName(channel string) string {
+	if channel == "" {
+		return selfupdate.ChannelStable
+	}
+	return channel
+}
@@ -0,0 +1,145 @@
+//go:build selftest
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/mockcpi"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	optionalCommands = append(optionalCommands, NewSelftestCommand)
+}
+
+func NewSelftestCommand() *cobra.Command {
+
+	selftestCmd := &cobra.Command{
+		Use:          "selftest",
+		Short:        "Configure and deploy artifacts on a built-in mock tenant",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			annotationTenantOptional: "true",
+		},
+		Long: `Start a mock tenant on localhost, apply a configuration file to it with
+the same code as the configure command and check that the parameters are
+set and the artifacts are deployed. No requests are sent to a real tenant.
+
+Without flags the tenant and configuration embedded in the binary are
+used. The command is only available in binaries built with the build tag
+selftest, e.g. go build -tags selftest.`,
+		Example: `  # Run the built-in self test
+  flashpipe selftest
+
+  # Apply an own configuration to an own mock tenant
+  flashpipe selftest --fixture tenant.json --config-path configure.yml`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runSelftest(cmd)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	selftestCmd.Flags().String("fixture", "", "JSON file with the packages, artifacts and deployments of the mock tenant, the built-in tenant is used if not set (config: selftest.fixture)")
+	selftestCmd.Flags().String("config-path", "", "Configuration file applied to the mock tenant, the built-in configuration is used if not set (config: selftest.configPath)")
+
+	return selftestCmd
+}
+
+func runSelftest(cmd *cobra.Command) error {
+	fixturePath := config.GetStringWithFallback(cmd, "fixture", "selftest.fixture")
+	configPath := config.GetStringWithFallback(cmd, "config-path", "selftest.configPath")
+
+	fixture := mockcpi.DefaultFixture()
+	if fixturePath != "" {
+		var err error
+		if fixture, err = mockcpi.LoadFixture(fixturePath); err != nil {
+			return err
+		}
+	}
+	if configPath == "" {
+		data, err := mockcpi.FixtureFile("configure.yml")
+		if err != nil {
+			return err
+		}
+		dir, err := os.MkdirTemp("", "flashpipe-selftest")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		configPath = filepath.Join(dir, "configure.yml")
+		if err := os.WriteFile(configPath, data, 0644); err != nil {
+			return err
+		}
+	}
+	// The configuration is loaded with the defaults of the configure command
+	configData, err := loadConfigureData(NewConfigureCommand(), configPath, "")
+	if err != nil {
+		return err
+	}
+
+	svr := mockcpi.New(fixture)
+	defer svr.Close()
+	stats, err := configureTenant(svr.Executer(), configData, nil, nil, false, 3, 0, 2, 10,
+		false, false, false, false, false, flashpipe.UnknownParametersError, draftHandlingDeploy, 1, 0, 0,
+		nil, nil, windowPolicy{}, pacingPolicy{}, changeLimits{})
+	if err != nil {
+		return err
+	}
+	return checkSelftest(os.Stdout, svr, configData, stats)
+}
+
+// checkSelftest compares the mock tenant with the configuration and prints the outcome of each check
+func checkSelftest(w io.Writer, svr *mockcpi.Server, configData *models.ConfigureConfig, stats *ConfigureStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	failed := 0
+	check := func(name string, ok bool, detail string) {
+		status := doctorOK
+		if !ok {
+			status = doctorFail
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, status, detail)
+	}
+
+	for _, pkg := range configData.Packages {
+		for _, artifact := range pkg.Artifacts {
+			for _, param := range artifact.Parameters {
+				value, found := svr.Parameter(artifact.ID, param.Key)
+				check(fmt.Sprintf("Parameter %s of %s", param.Key, artifact.ID), found && value == param.Value,
+					fmt.Sprintf("%q, expected %q", value, param.Value))
+			}
+			if artifact.Deploy {
+				status := "NOT_DEPLOYED"
+				if runtime := svr.Runtime(artifact.ID); runtime != nil {
+					status = runtime.Status
+				}
+				check("Deployment of "+artifact.ID, status == "STARTED", status)
+			}
+		}
+	}
+	check("Failed artifacts", stats.ArtifactsFailed.Value() == 0, fmt.Sprint(stats.ArtifactsFailed.Value()))
+	check("Failed deployments", stats.DeploymentTasksFailed.Value() == 0, fmt.Sprint(stats.DeploymentTasksFailed.Value()))
+	unhandled := svr.Unhandled()
+	check("Requests not emulated by the mock tenant", len(unhandled) == 0, fmt.Sprint(unhandled))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d self test check(s) failed", failed)
+	}
+	return nil
+}
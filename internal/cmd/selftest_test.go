@@ -0,0 +1,31 @@
+//go:build selftest
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/mockcpi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelftest(t *testing.T) {
+	assert.NoError(t, runSelftest(NewSelftestCommand()))
+}
+
+func TestCheckSelftestFailed(t *testing.T) {
+	fixture := mockcpi.DefaultFixture()
+	fixture.Packages[0].Artifacts[0].DeployError = "Invalid receiver host"
+	svr := mockcpi.New(fixture)
+	defer svr.Close()
+	configData := loadFixtureConfig(t)
+	stats := runFixtureConfig(t, svr, false)
+
+	var out bytes.Buffer
+	err := checkSelftest(&out, svr, configData, stats)
+	require.Error(t, err)
+	assert.Equal(t, "2 self test check(s) failed", err.Error())
+	assert.Regexp(t, `Deployment of Orders\s+FAIL\s+ERROR`, out.String())
+}
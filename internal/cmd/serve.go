@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// maxServeRequestSize is the maximum size of request bodies in bytes, larger requests are refused with status 413
+const maxServeRequestSize = 10 << 20
+
+// ServeRequest is the request body of the validate, plan and apply operations
+type ServeRequest struct {
+	Config           string   `json:"config"` // Configuration YAML in the configure format
+	DeploymentPrefix string   `json:"deploymentPrefix,omitempty"`
+	PackageFilter    []string `json:"packageFilter,omitempty"`
+	ArtifactFilter   []string `json:"artifactFilter,omitempty"`
+	DryRun           bool     `json:"dryRun,omitempty"`
+	Environment      string   `json:"environment,omitempty"` // .Environment of the when conditions
+}
+
+// ServeDeployRequest is the request body of the deploy operation
+type ServeDeployRequest struct {
+	Artifacts []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"artifacts"`
+}
+
+type server struct {
+	exe        *httpclnt.HTTPExecuter
+	host       string // .Host of the when conditions
+	tenant     flashpipe.Tenant
+	apiKeys    []string
+	deployOpts flashpipe.ApplyOptions
+	// Modifying operations are executed one at a time
+	mu sync.Mutex
+}
+
+func NewServeCommand() *cobra.Command {
+
+	serveCmd := &cobra.Command{
+		Use:          "serve",
+		Short:        "Expose configure and deploy operations over a REST API",
+		SilenceUsage: true,
+		Long: `Start an HTTP server that exposes the main operations over a REST API,
+so that a self-service portal can trigger tenant configuration without
+giving users the tenant credentials.
+
+Endpoints (all except /healthz require an API key, passed as
+"Authorization: Bearer <key>" or "X-API-Key: <key>"):
+  POST /api/v1/validate          Validate a configuration
+  POST /api/v1/plan              Show the differences between configuration and tenant
+  POST /api/v1/apply             Update parameters and deploy flagged artifacts
+  POST /api/v1/deploy            Deploy artifacts
+  GET  /api/v1/status/{id}       Runtime status of an artifact
+  GET  /healthz                  Health check
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'serve' section. CLI flags override config file settings.`,
+		Example: `  # Start the server with an API key from the environment
+  FLASHPIPE_API_KEYS=secret-key flashpipe serve --listen-address :8443
+
+  # Validate a configuration
+  curl -H "X-API-Key: secret-key" -d '{"config": "packages: []"}' http://localhost:8443/api/v1/validate`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runServe(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	serveCmd.Flags().String("listen-address", ":8080", "Address the server listens on (config: serve.listenAddress)")
+	serveCmd.Flags().StringSlice("api-keys", nil, "Comma separated list of API keys accepted by the server (config: serve.apiKeys)")
+	serveCmd.Flags().Int("deploy-retries", 5, "Number of retries for deployment status checks (config: serve.deployRetries)")
+	serveCmd.Flags().Int("deploy-delay", 15, "Delay in seconds between deployment status checks (config: serve.deployDelaySeconds)")
+	config.MarkSensitive(serveCmd.Flags(), "api-keys")
+
+	return serveCmd
+}
+
+func runServe(cmd *cobra.Command) error {
+	listenAddress := config.GetStringWithFallback(cmd, "listen-address", "serve.listenAddress")
+	apiKeys := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "api-keys", "serve.apiKeys"))
+	if len(apiKeys) == 0 {
+		return fmt.Errorf("at least one API key is required (set via --api-keys or in config file under 'serve.apiKeys')")
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	s := &server{
+		exe:  api.InitHTTPExecuter(serviceDetails),
+		host: serviceDetails.Host,
+		tenant: flashpipe.NewClient(flashpipe.ServiceDetails{
+			Host:         serviceDetails.Host,
+			UserID:       serviceDetails.Userid,
+			Password:     serviceDetails.Password,
+			OAuthHost:    serviceDetails.OauthHost,
+			OAuthPath:    serviceDetails.OauthPath,
+			ClientID:     serviceDetails.OauthClientId,
+			ClientSecret: serviceDetails.OauthClientSecret,
+		}),
+		apiKeys: apiKeys,
+		deployOpts: flashpipe.ApplyOptions{
+			DeployRetries: config.GetIntWithFallback(cmd, "deploy-retries", "serve.deployRetries"),
+			DeployDelay:   time.Duration(config.GetIntWithFallback(cmd, "deploy-delay", "serve.deployDelaySeconds")) * time.Second,
+		},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: listenAddress, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	errChan := make(chan error, 1)
+	go func() {
+		log.Info().Msgf("Serving FlashPipe API on %v", listenAddress)
+		errChan <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+	log.Info().Msg("Shutting down FlashPipe API")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "UP"})
+	})
+	mux.Handle("POST /api/v1/validate", s.authenticate(s.handleValidate))
+	mux.Handle("POST /api/v1/plan", s.authenticate(s.handlePlan))
+	mux.Handle("POST /api/v1/apply", s.authenticate(s.handleApply))
+	mux.Handle("POST /api/v1/deploy", s.authenticate(s.handleDeploy))
+	mux.Handle("GET /api/v1/status/{id}", s.authenticate(s.handleStatus))
+	return mux
+}
+
+func (s *server) authenticate(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		for _, apiKey := range s.apiKeys {
+			if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+				next(w, r)
+				return
+			}
+		}
+		writeError(w, http.StatusUnauthorized, errors.New("invalid or missing API key"))
+	})
+}
+
+// decodeRequestBody decodes the JSON request body of at most maxServeRequestSize bytes into v
+func decodeRequestBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxServeRequestSize)).Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// requestErrorStatus returns the status of a request that failed with err: 413 if the request body is too large,
+// otherwise 400
+func requestErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// parseServeRequest reads the request body and parses and validates the contained configuration, replacing
+// type aliases with the artifact types and skipping packages and artifacts whose when condition is false
+func (s *server) parseServeRequest(w http.ResponseWriter, r *http.Request) (*ServeRequest, *flashpipe.ConfigureConfig, []error, error) {
+	var req ServeRequest
+	if err := decodeRequestBody(w, r, &req); err != nil {
+		return nil, nil, nil, err
+	}
+	if req.DeploymentPrefix != "" {
+		if err := deploy.ValidateDeploymentPrefix(req.DeploymentPrefix); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	// The configuration of a request must not read files of the server, e.g. the global config with credentials
+	cfg, err := flashpipe.ParseConfigWithOptions("config", []byte(req.Config), nil, flashpipe.LoadOptions{NoFileReferences: true})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if req.DeploymentPrefix != "" {
+		cfg.DeploymentPrefix = req.DeploymentPrefix
+	}
+	cfg.Conditions = &flashpipe.Conditions{Environment: req.Environment}
+	if cfg, err = flashpipe.ApplyConditions(cfg, "", s.host); err != nil {
+		return nil, nil, nil, err
+	}
+	problems := flashpipe.Validate(cfg)
+	if len(problems) == 0 {
+		// All types are valid, so they are resolved without error
+		_ = flashpipe.ResolveArtifactTypes(cfg)
+	}
+	return &req, cfg, problems, nil
+}
+
+func (s *server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	_, _, validationErrs, err := s.parseServeRequest(w, r)
+	if err != nil {
+		writeError(w, requestErrorStatus(err), err)
+		return
+	}
+	messages := []string{}
+	for _, e := range validationErrs {
+		messages = append(messages, e.Error())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"valid": len(messages) == 0, "errors": messages})
+}
+
+func (s *server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	req, cfg, validationErrs, err := s.parseServeRequest(w, r)
+	if err == nil && len(validationErrs) > 0 {
+		err = errors.Join(validationErrs...)
+	}
+	if err != nil {
+		writeError(w, requestErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, verifyConfiguration(s.exe, cfg, req.PackageFilter, req.ArtifactFilter))
+}
+
+func (s *server) handleApply(w http.ResponseWriter, r *http.Request) {
+	req, cfg, validationErrs, err := s.parseServeRequest(w, r)
+	if err == nil && len(validationErrs) > 0 {
+		err = errors.Join(validationErrs...)
+	}
+	if err != nil {
+		writeError(w, requestErrorStatus(err), err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opts := s.deployOpts
+	opts.PackageFilter = req.PackageFilter
+	opts.ArtifactFilter = req.ArtifactFilter
+	opts.DryRun = req.DryRun
+	stats, err := flashpipe.Apply(r.Context(), s.tenant, cfg, opts)
+	response := map[string]interface{}{"stats": stats}
+	if err != nil {
+		response["error"] = err.Error()
+		writeJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *server) handleDeploy(w http.ResponseWriter, r *http.Request) {
+	var req ServeDeployRequest
+	if err := decodeRequestBody(w, r, &req); err != nil {
+		writeError(w, requestErrorStatus(err), err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []map[string]string{}
+	failed := false
+	for _, a := range req.Artifacts {
+		result := map[string]string{"id": a.ID, "status": "STARTED"}
+		if err := flashpipe.Deploy(r.Context(), s.tenant, a.Type, a.ID, s.deployOpts); err != nil {
+			result["status"] = "FAILED"
+			result["error"] = err.Error()
+			failed = true
+		}
+		results = append(results, result)
+	}
+	status := http.StatusOK
+	if failed {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, map[string]interface{}{"artifacts": results})
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, status, err := s.tenant.Status(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if version == "NOT_DEPLOYED" {
+		version, status = "", version
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id": id, "version": version, "status": status})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
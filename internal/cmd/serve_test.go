@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubTenant returns a started runtime artifact for all status requests
+type stubTenant struct{}
+
+func (stubTenant) Parameters(context.Context, string, string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (stubTenant) UpdateParameters(context.Context, string, string, map[string]string) error {
+	return nil
+}
+
+func (stubTenant) Deploy(context.Context, string, string) error {
+	return nil
+}
+
+func (stubTenant) Status(context.Context, string) (string, string, error) {
+	return "1.0.1", "STARTED", nil
+}
+
+func TestServeHandler(t *testing.T) {
+	s := &server{tenant: stubTenant{}, apiKeys: []string{"secret"}}
+	svr := httptest.NewServer(s.handler())
+	defer svr.Close()
+
+	resp, err := http.Post(svr.URL+"/api/v1/validate", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Request without API key should be rejected")
+
+	body := `{"config": "packages:\n  - integrationSuiteId: Package\n    artifacts:\n      - artifactId: Flow\n        type: Unknown\n"}`
+	req, _ := http.NewRequest(http.MethodPost, svr.URL+"/api/v1/validate", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "secret")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	var validation struct {
+		Valid  bool     `json:"valid"`
+		Errors []string `json:"errors"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&validation))
+	assert.False(t, validation.Valid, "Unknown artifact type should be invalid")
+	assert.Equal(t, 1, len(validation.Errors), "Incorrect number of validation errors")
+
+	req, _ = http.NewRequest(http.MethodGet, svr.URL+"/api/v1/status/Flow", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	var status map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, map[string]string{"id": "Flow", "version": "1.0.1", "status": "STARTED"}, status)
+}
+
+func TestServeRejectsFileReferences(t *testing.T) {
+	s := &server{tenant: stubTenant{}, apiKeys: []string{"secret"}}
+	svr := httptest.NewServer(s.handler())
+	defer svr.Close()
+
+	configs := map[string]string{
+		"fromFile":       "packages:\n  - integrationSuiteId: Package\n    artifacts:\n      - artifactId: Flow\n        parameters:\n          - key: Secret\n            fromFile: /etc/passwd\n",
+		"parametersFrom": "packages:\n  - integrationSuiteId: Package\n    artifacts:\n      - artifactId: Flow\n        parametersFrom:\n          - /etc/passwd\n",
+		"partners":       "packages:\n  - integrationSuiteId: Package\n    artifacts:\n      - artifactId: Flow_${partner.id}\n        partners:\n          from: /etc/passwd\n",
+	}
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			for _, path := range []string{"/api/v1/validate", "/api/v1/plan"} {
+				body, _ := json.Marshal(map[string]string{"config": config})
+				req, _ := http.NewRequest(http.MethodPost, svr.URL+path, strings.NewReader(string(body)))
+				req.Header.Set("X-API-Key", "secret")
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				var response map[string]interface{}
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+				resp.Body.Close()
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "%s should refuse %s", path, name)
+				assert.Contains(t, response["error"], "not allowed")
+				encoded, _ := json.Marshal(response)
+				assert.NotContains(t, string(encoded), "root:", "The file content should not be returned")
+			}
+		})
+	}
+}
+
+func TestServeRejectsLargeRequests(t *testing.T) {
+	s := &server{tenant: stubTenant{}, apiKeys: []string{"secret"}}
+	svr := httptest.NewServer(s.handler())
+	defer svr.Close()
+
+	body := `{"config": "` + strings.Repeat("#", maxServeRequestSize) + `"}`
+	for _, path := range []string{"/api/v1/validate", "/api/v1/plan", "/api/v1/apply", "/api/v1/deploy"} {
+		req, _ := http.NewRequest(http.MethodPost, svr.URL+path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "secret")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		var response map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode, "%s should refuse the request", path)
+		assert.Contains(t, response["error"], "request body too large")
+	}
+}
+
+func TestSensitiveFlagsMasked(t *testing.T) {
+	deployCmd := &cobra.Command{Use: "deploy"}
+	addApprovalFlags(deployCmd)
+	serveCmd := NewServeCommand()
+	rootCmd := NewCmdRoot()
+	require.NoError(t, deployCmd.ParseFlags([]string{"--approval-token", "CHG0012345", "--approval-expected-token", "CHG0012345"}))
+	require.NoError(t, serveCmd.ParseFlags([]string{"--api-keys", "key1,key2"}))
+	require.NoError(t, rootCmd.ParseFlags([]string{"--http-header", "X-API-Key: key3"}))
+
+	for _, cmd := range []*cobra.Command{deployCmd, serveCmd, rootCmd} {
+		for _, setting := range config.Explain(cmd) {
+			for _, secret := range []string{"CHG0012345", "key1", "key3"} {
+				assert.NotContains(t, setting.Value, secret, "--%s should be masked", setting.Flag)
+			}
+		}
+	}
+}
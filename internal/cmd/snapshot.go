@@ -10,6 +10,8 @@ import (
 	"github.com/engswee/flashpipe/internal/analytics"
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/logger"
+	"github.com/engswee/flashpipe/internal/pipeline"
 	"github.com/engswee/flashpipe/internal/repo"
 	"github.com/engswee/flashpipe/internal/str"
 	"github.com/engswee/flashpipe/internal/sync"
@@ -22,6 +24,7 @@ func NewSnapshotCommand() *cobra.Command {
 	snapshotCmd := &cobra.Command{
 		Use:          "snapshot",
 		Short:        "Snapshot integration packages from tenant to Git",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
 		SilenceUsage: true,
 		Long: `Snapshot all editable integration packages from SAP Integration Suite
 tenant to a Git repository.
@@ -127,7 +130,7 @@ func runSnapshot(cmd *cobra.Command) error {
 }
 
 func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir string, workDir string, draftHandling string, syncPackageLevelDetails bool, includedIds []string, excludedIds []string) error {
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msg("📢 Begin taking a snapshot of the tenant")
 
 	// Initialise HTTP executer
@@ -145,19 +148,20 @@ func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir stri
 
 	log.Info().Msgf("Processing %d packages", len(ids))
 	synchroniser := sync.New(exe)
+	tasks := make([]pipeline.Task, len(ids))
 	for i, id := range ids {
-		log.Info().Msg("---------------------------------------------------------------------------------")
-		log.Info().Msgf("Processing package %d/%d - ID: %v", i+1, len(ids), id)
-		packageWorkingDir := fmt.Sprintf("%v/%v", workDir, id)
-		packageArtifactsDir := fmt.Sprintf("%v/%v", artifactsBaseDir, id)
-		packageDataFromTenant, readOnly, _, err := synchroniser.VerifyDownloadablePackage(id)
-		if err != nil {
-			return err
-		}
-		if !readOnly {
+		tasks[i] = pipeline.Task{ID: id, Run: func() error {
+			logger.Rule(logger.SeparatorDashed)
+			log.Info().Msgf("Processing package %d/%d - ID: %v", i+1, len(ids), id)
+			packageWorkingDir := fmt.Sprintf("%v/%v", workDir, id)
+			packageArtifactsDir := fmt.Sprintf("%v/%v", artifactsBaseDir, id)
+			packageDataFromTenant, readOnly, _, err := synchroniser.VerifyDownloadablePackage(id)
+			if err != nil {
+				return err
+			}
 			// Filter in/out artifacts
-			if str.FilterIDs(id, includedIds, excludedIds) {
-				continue
+			if readOnly || str.FilterIDs(id, includedIds, excludedIds) {
+				return nil
 			}
 			if syncPackageLevelDetails {
 				err = synchroniser.PackageToGit(packageDataFromTenant, id, packageWorkingDir, packageArtifactsDir)
@@ -165,15 +169,19 @@ func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir stri
 					return err
 				}
 			}
-			err = synchroniser.ArtifactsToGit(id, packageWorkingDir, packageArtifactsDir, nil, nil, draftHandling, "ID", nil)
-			if err != nil {
-				return err
-			}
-
-		}
+			return synchroniser.ArtifactsToGit(id, packageWorkingDir, packageArtifactsDir, nil, nil, draftHandling, "ID", nil)
+		}}
+	}
+	// The packages are processed one after the other and the snapshot stops at the first failure
+	results, err := pipeline.Run(tasks, pipeline.Options{Parallel: 1, StopOnError: true})
+	if err != nil {
+		return err
+	}
+	if err = pipeline.FirstError(results); err != nil {
+		return err
 	}
 
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msg("🏆 Completed taking a snapshot of the tenant")
 	return nil
 }
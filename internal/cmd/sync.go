@@ -96,6 +96,8 @@ Configuration:
 	syncCmd.Flags().StringSlice("script-collection-map", nil, "Comma-separated source-target ID pairs for converting script collection references during sync (config: sync.scriptCollectionMap)")
 	syncCmd.PersistentFlags().Bool("git-skip-commit", false, "Skip committing changes to Git repository (config: sync.gitSkipCommit)")
 	syncCmd.Flags().Bool("sync-package-details", false, "Sync details of Integration Package (config: sync.syncPackageDetails)")
+	syncCmd.Flags().Bool("bump-version", false, "Increment the Bundle-Version of changed artifacts according to the commit messages before uploading them, only for target tenant (config: sync.bumpVersion)")
+	syncCmd.Flags().Bool("changelog", false, "Add an entry to CHANGELOG.md of artifacts whose version is incremented (config: sync.changelog)")
 
 	_ = syncCmd.MarkFlagRequired("package-id")
 	_ = syncCmd.MarkFlagRequired("dir-git-repo")
@@ -132,11 +134,16 @@ func runSync(cmd *cobra.Command) error {
 	skipCommit := config.GetBoolWithFallback(cmd, "git-skip-commit", "sync.gitSkipCommit")
 	syncPackageLevelDetails := config.GetBoolWithFallback(cmd, "sync-package-details", "sync.syncPackageDetails")
 	target := config.GetStringWithFallback(cmd, "target", "sync.target")
+	bumpVersion := config.GetBoolWithFallback(cmd, "bump-version", "sync.bumpVersion")
+	changelog := config.GetBoolWithFallback(cmd, "changelog", "sync.changelog")
 
 	serviceDetails := api.GetServiceDetails(cmd)
 	// Initialise HTTP executer
 	exe := api.InitHTTPExecuter(serviceDetails)
 	synchroniser := sync.New(exe)
+	if bumpVersion {
+		synchroniser.SetVersionBump(&sync.VersionBump{GitRepoDir: gitRepoDir, Changelog: changelog})
+	}
 
 	// Sync from tenant to Git
 	if target == "git" {
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Actions of value mapping changes
+const (
+	valueMappingAdd    = "+"
+	valueMappingUpdate = "~"
+	valueMappingDelete = "-"
+)
+
+// valueMappingCSVHeader are the columns of value mapping CSV files
+var valueMappingCSVHeader = []string{"sourceAgency", "sourceIdentifier", "targetAgency", "targetIdentifier", "sourceValue", "targetValue"}
+
+// valueMappingChange is an entry to be added, updated or deleted on the tenant
+type valueMappingChange struct {
+	Action   string
+	Entry    *api.ValueMappingEntry
+	Previous string // Target value on the tenant of updated entries
+}
+
+func NewValueMappingCommand() *cobra.Command {
+
+	valueMappingCmd := &cobra.Command{
+		Use:   "valuemapping",
+		Short: "Manage the entries of value mappings as code",
+		Long: `Compare and update the entries (agency, identifier and value pairs) of a
+value mapping on the SAP Integration Suite tenant with a YAML or CSV file.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'valuemapping' section. CLI flags override config file settings.`,
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'valuemapping' key
+	valueMappingCmd.PersistentFlags().String("file", "", "YAML or CSV file with the entries of the value mapping (config: valuemapping.file)")
+	valueMappingCmd.PersistentFlags().String("value-mapping-id", "", "ID of the value mapping, defaults to valueMappingId of YAML files and the file name of CSV files (config: valuemapping.valueMappingId)")
+	valueMappingCmd.PersistentFlags().String("version", "active", "Version of the value mapping (config: valuemapping.version)")
+	valueMappingCmd.PersistentFlags().Bool("delete-missing", false, "Delete entries on the tenant that are not in the file (config: valuemapping.deleteMissing)")
+
+	return valueMappingCmd
+}
+
+func NewValueMappingDiffCommand() *cobra.Command {
+
+	diffCmd := &cobra.Command{
+		Use:          "diff",
+		Short:        "Show the differences of the value mapping entries to the file",
+		Annotations:  map[string]string{annotationReadOnly: "true"},
+		SilenceUsage: true,
+		Long: `Show the entries that apply would add (+), update (~) or, with
+--delete-missing, delete (-) on the tenant.`,
+		Example: `  # Compare the country codes of the tenant with the file
+  flashpipe valuemapping diff --file valuemappings/Country_Codes.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			err = runValueMapping(cmd, true)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+	return diffCmd
+}
+
+func NewValueMappingApplyCommand() *cobra.Command {
+
+	applyCmd := &cobra.Command{
+		Use:          "apply",
+		Short:        "Update the value mapping entries from the file",
+		SilenceUsage: true,
+		Long: `Add and update the entries of the value mapping on the tenant to match
+the file, and with --delete-missing delete the entries that are not in the
+file. The value mapping must be deployed afterwards for the runtime to use
+the entries.`,
+		Example: `  # Update the country codes from a CSV file, removing entries not in the file
+  flashpipe valuemapping apply --file Country_Codes.csv --delete-missing
+
+  # Deploy the value mapping afterwards
+  flashpipe deploy --artifact-type ValueMapping --artifact-ids Country_Codes`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			dryRun := config.GetBoolWithFallback(cmd, "dry-run", "valuemapping.dryRun")
+			err = runValueMapping(cmd, dryRun)
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	applyCmd.Flags().Bool("dry-run", false, "Show the changes without applying them (config: valuemapping.dryRun)")
+	return applyCmd
+}
+
+func runValueMapping(cmd *cobra.Command, dryRun bool) error {
+	file := config.GetStringWithFallback(cmd, "file", "valuemapping.file")
+	version := config.GetStringWithFallback(cmd, "version", "valuemapping.version")
+	deleteMissing := config.GetBoolWithFallback(cmd, "delete-missing", "valuemapping.deleteMissing")
+	if file == "" {
+		return fmt.Errorf("no value mapping file, set --file or valuemapping.file")
+	}
+
+	vm, err := loadValueMappingFile(file, config.GetStringWithFallback(cmd, "value-mapping-id", "valuemapping.valueMappingId"))
+	if err != nil {
+		return err
+	}
+	if vm.Version != "" && !cmd.Flags().Changed("version") {
+		version = vm.Version
+	}
+	desired, err := valueMappingEntries(vm)
+	if err != nil {
+		return err
+	}
+
+	serviceDetails := api.GetServiceDetails(cmd)
+	content := api.NewValueMappingContent(api.InitHTTPExecuter(serviceDetails))
+	current, err := content.GetEntries(vm.ID, version)
+	if err != nil {
+		return err
+	}
+
+	changes := diffValueMappings(desired, current, deleteMissing)
+	writeValueMappingChanges(os.Stdout, changes)
+	log.Info().Msgf("Value mapping %v: %v", vm.ID, summarizeValueMappingChanges(changes))
+	if dryRun || len(changes) == 0 {
+		return nil
+	}
+
+	var failed int
+	for _, change := range changes {
+		var err error
+		if change.Action == valueMappingDelete {
+			err = content.Delete(vm.ID, version, change.Entry)
+		} else {
+			err = content.Upsert(vm.ID, version, change.Entry)
+		}
+		if err != nil {
+			log.Error().Msgf("Failed to apply %v %v = %v of %v: %v", change.Action, change.Entry.SourceValue, change.Entry.TargetValue, change.Entry.Schema, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d value mapping changes failed", failed, len(changes))
+	}
+	log.Info().Msgf("🏆 Value mapping %v updated, deploy it for the changes to take effect", vm.ID)
+	return nil
+}
+
+// loadValueMappingFile reads a YAML or CSV value mapping file. The ID defaults to valueMappingId of YAML files
+// and the file name of CSV files.
+func loadValueMappingFile(path string, id string) (*models.ValueMappingFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vm := new(models.ValueMappingFile)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, vm); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", path, err)
+		}
+	case ".csv":
+		if vm.Mappings, err = parseValueMappingCSV(strings.NewReader(string(data))); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", path, err)
+		}
+		vm.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	default:
+		return nil, fmt.Errorf("unsupported value mapping file %v, use .yaml, .yml or .csv", path)
+	}
+	if id != "" {
+		vm.ID = id
+	}
+	if vm.ID == "" {
+		return nil, fmt.Errorf("no value mapping ID in %v, set valueMappingId or --value-mapping-id", path)
+	}
+	return vm, nil
+}
+
+// parseValueMappingCSV groups the rows of a CSV file with valueMappingCSVHeader by agencies and identifiers
+func parseValueMappingCSV(r io.Reader) ([]models.ValueMappingGroup, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || !slices.EqualFunc(records[0], valueMappingCSVHeader, strings.EqualFold) {
+		return nil, fmt.Errorf("first line must be the header %v", strings.Join(valueMappingCSVHeader, ","))
+	}
+	var groups []models.ValueMappingGroup
+	for _, record := range records[1:] {
+		i := slices.IndexFunc(groups, func(g models.ValueMappingGroup) bool {
+			return g.SourceAgency == record[0] && g.SourceIdentifier == record[1] && g.TargetAgency == record[2] && g.TargetIdentifier == record[3]
+		})
+		if i < 0 {
+			groups = append(groups, models.ValueMappingGroup{SourceAgency: record[0], SourceIdentifier: record[1], TargetAgency: record[2], TargetIdentifier: record[3]})
+			i = len(groups) - 1
+		}
+		groups[i].Values = append(groups[i].Values, models.ValueMappingValue{Source: record[4], Target: record[5]})
+	}
+	return groups, nil
+}
+
+// valueMappingEntries returns the entries of the file, a source value may only be mapped once per schema
+func valueMappingEntries(vm *models.ValueMappingFile) ([]*api.ValueMappingEntry, error) {
+	var entries []*api.ValueMappingEntry
+	seen := map[string]bool{}
+	for _, group := range vm.Mappings {
+		schema := api.ValueMappingSchema{SrcAgency: group.SourceAgency, SrcId: group.SourceIdentifier, TgtAgency: group.TargetAgency, TgtId: group.TargetIdentifier}
+		if schema.SrcAgency == "" || schema.SrcId == "" || schema.TgtAgency == "" || schema.TgtId == "" {
+			return nil, fmt.Errorf("mapping %v requires sourceAgency, sourceIdentifier, targetAgency and targetIdentifier", schema)
+		}
+		for _, value := range group.Values {
+			key := schema.String() + "|" + value.Source
+			if seen[key] {
+				return nil, fmt.Errorf("source value %v is mapped more than once in %v", value.Source, schema)
+			}
+			seen[key] = true
+			entries = append(entries, &api.ValueMappingEntry{Schema: schema, SourceValue: value.Source, TargetValue: value.Target})
+		}
+	}
+	return entries, nil
+}
+
+// diffValueMappings returns the changes to the current entries for the desired entries, in the order of the
+// desired entries followed by the deletions
+func diffValueMappings(desired []*api.ValueMappingEntry, current []*api.ValueMappingEntry, deleteMissing bool) []valueMappingChange {
+	find := func(entries []*api.ValueMappingEntry, e *api.ValueMappingEntry) *api.ValueMappingEntry {
+		i := slices.IndexFunc(entries, func(c *api.ValueMappingEntry) bool {
+			return c.Schema == e.Schema && c.SourceValue == e.SourceValue
+		})
+		if i < 0 {
+			return nil
+		}
+		return entries[i]
+	}
+
+	var changes []valueMappingChange
+	for _, e := range desired {
+		existing := find(current, e)
+		switch {
+		case existing == nil:
+			changes = append(changes, valueMappingChange{Action: valueMappingAdd, Entry: e})
+		case existing.TargetValue != e.TargetValue:
+			update := *e
+			update.Id = existing.Id
+			changes = append(changes, valueMappingChange{Action: valueMappingUpdate, Entry: &update, Previous: existing.TargetValue})
+		}
+	}
+	if deleteMissing {
+		for _, c := range current {
+			if find(desired, c) == nil {
+				changes = append(changes, valueMappingChange{Action: valueMappingDelete, Entry: c})
+			}
+		}
+	}
+	return changes
+}
+
+func writeValueMappingChanges(out io.Writer, changes []valueMappingChange) {
+	for _, c := range changes {
+		switch c.Action {
+		case valueMappingUpdate:
+			fmt.Fprintf(out, "%v %v: %v = %v (was %v)\n", c.Action, c.Entry.Schema, c.Entry.SourceValue, c.Entry.TargetValue, c.Previous)
+		default:
+			fmt.Fprintf(out, "%v %v: %v = %v\n", c.Action, c.Entry.Schema, c.Entry.SourceValue, c.Entry.TargetValue)
+		}
+	}
+}
+
+func summarizeValueMappingChanges(changes []valueMappingChange) string {
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+	}
+	return fmt.Sprintf("%d to add, %d to update, %d to delete", counts[valueMappingAdd], counts[valueMappingUpdate], counts[valueMappingDelete])
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadValueMappingFile(t *testing.T) {
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "Country_Codes.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte("sourceAgency,sourceIdentifier,targetAgency,targetIdentifier,sourceValue,targetValue\n"+
+		"SAP,Country,Partner,CountryCode,DE,DEU\nSAP,Country,Partner,CountryCode,AT,AUT\nSAP,Currency,Partner,CurrencyCode,EUR,978\n"), 0644))
+	vm, err := loadValueMappingFile(csvFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Country_Codes", vm.ID, "ID should default to the file name")
+	require.Len(t, vm.Mappings, 2, "Rows should be grouped by schema")
+	assert.Len(t, vm.Mappings[0].Values, 2)
+
+	yamlFile := filepath.Join(dir, "codes.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`valueMappingId: Country_Codes
+mappings:
+  - sourceAgency: SAP
+    sourceIdentifier: Country
+    targetAgency: Partner
+    targetIdentifier: CountryCode
+    values:
+      - source: DE
+        target: DEU
+      - source: DE
+        target: GER
+`), 0644))
+	vm, err = loadValueMappingFile(yamlFile, "Other")
+	require.NoError(t, err)
+	assert.Equal(t, "Other", vm.ID, "Flag should override the ID of the file")
+	_, err = valueMappingEntries(vm)
+	assert.EqualError(t, err, "source value DE is mapped more than once in SAP/Country -> Partner/CountryCode")
+
+	badFile := filepath.Join(dir, "bad.csv")
+	require.NoError(t, os.WriteFile(badFile, []byte("source,target\nDE,DEU\n"), 0644))
+	_, err = loadValueMappingFile(badFile, "")
+	assert.ErrorContains(t, err, "first line must be the header")
+}
+
+func TestDiffValueMappings(t *testing.T) {
+	schema := api.ValueMappingSchema{SrcAgency: "SAP", SrcId: "Country", TgtAgency: "Partner", TgtId: "CountryCode"}
+	desired := []*api.ValueMappingEntry{
+		{Schema: schema, SourceValue: "DE", TargetValue: "DEU"},
+		{Schema: schema, SourceValue: "AT", TargetValue: "AUT"},
+		{Schema: schema, SourceValue: "CH", TargetValue: "CHE"},
+	}
+	current := []*api.ValueMappingEntry{
+		{Schema: schema, Id: "1", SourceValue: "DE", TargetValue: "DEU"},
+		{Schema: schema, Id: "2", SourceValue: "AT", TargetValue: "AUS"},
+		{Schema: schema, Id: "3", SourceValue: "XX", TargetValue: "YYY"},
+	}
+
+	changes := diffValueMappings(desired, current, false)
+	require.Len(t, changes, 2, "Missing entries should only be deleted with deleteMissing")
+	assert.Equal(t, "2", changes[0].Entry.Id, "Updates should use the ID of the current entry")
+
+	changes = diffValueMappings(desired, current, true)
+	var out bytes.Buffer
+	writeValueMappingChanges(&out, changes)
+	assert.Equal(t, "~ SAP/Country -> Partner/CountryCode: AT = AUT (was AUS)\n"+
+		"+ SAP/Country -> Partner/CountryCode: CH = CHE\n"+
+		"- SAP/Country -> Partner/CountryCode: XX = YYY\n", out.String())
+	assert.Equal(t, "1 to add, 1 to update, 1 to delete", summarizeValueMappingChanges(changes))
+}
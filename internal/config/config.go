@@ -79,91 +79,46 @@ func verifyNoSensitiveContent(input string) (bool, error) {
 }
 
 // GetStringWithFallback reads a string value from command flag,
-// falling back to a nested config key if the flag wasn't explicitly set
+// falling back to a nested config key if the flag wasn't explicitly set, see Resolve
 func GetStringWithFallback(cmd *cobra.Command, flagName, configKey string) string {
-	// Check if flag was explicitly set on command line
-	if cmd.Flags().Changed(flagName) {
-		return GetString(cmd, flagName)
-	}
-
-	// Try to get from nested config key
-	if viper.IsSet(configKey) {
+	if Resolve(cmd, flagName, configKey).fallback {
 		return viper.GetString(configKey)
 	}
-
-	// Fall back to flag default
 	return GetString(cmd, flagName)
 }
 
 // GetBoolWithFallback reads a bool value from command flag,
-// falling back to a nested config key if the flag wasn't explicitly set
+// falling back to a nested config key if the flag wasn't explicitly set, see Resolve
 func GetBoolWithFallback(cmd *cobra.Command, flagName, configKey string) bool {
-	// Check if flag was explicitly set on command line
-	if cmd.Flags().Changed(flagName) {
-		return GetBool(cmd, flagName)
-	}
-
-	// Try to get from nested config key
-	if viper.IsSet(configKey) {
+	if Resolve(cmd, flagName, configKey).fallback {
 		return viper.GetBool(configKey)
 	}
-
-	// Fall back to flag default
 	return GetBool(cmd, flagName)
 }
 
 // GetIntWithFallback reads an int value from command flag,
-// falling back to a nested config key if the flag wasn't explicitly set
+// falling back to a nested config key if the flag wasn't explicitly set, see Resolve
 func GetIntWithFallback(cmd *cobra.Command, flagName, configKey string) int {
-	// Check if flag was explicitly set on command line
-	if cmd.Flags().Changed(flagName) {
-		return GetInt(cmd, flagName)
-	}
-
-	// Try to get from nested config key
-	if viper.IsSet(configKey) {
+	if Resolve(cmd, flagName, configKey).fallback {
 		return viper.GetInt(configKey)
 	}
-
-	// Fall back to flag default
 	return GetInt(cmd, flagName)
 }
 
 // GetStringSliceWithFallback reads a string slice value from command flag,
-// falling back to a nested config key if the flag wasn't explicitly set
+// falling back to a nested config key if the flag wasn't explicitly set, see Resolve
 func GetStringSliceWithFallback(cmd *cobra.Command, flagName, configKey string) []string {
-	// Check if flag was explicitly set on command line
-	if cmd.Flags().Changed(flagName) {
-		return GetStringSlice(cmd, flagName)
-	}
-
-	// Try to get from nested config key
-	if viper.IsSet(configKey) {
+	if Resolve(cmd, flagName, configKey).fallback {
 		return viper.GetStringSlice(configKey)
 	}
-
-	// Fall back to flag default
 	return GetStringSlice(cmd, flagName)
 }
 
 // GetStringWithEnvExpandAndFallback reads a string value with environment variable expansion,
 // falling back to a nested config key if the flag wasn't explicitly set
 func GetStringWithEnvExpandAndFallback(cmd *cobra.Command, flagName, configKey string) (string, error) {
-	var val string
-
-	// Check if flag was explicitly set on command line
-	if cmd.Flags().Changed(flagName) {
-		val = GetString(cmd, flagName)
-	} else if viper.IsSet(configKey) {
-		// Try to get from nested config key
-		val = viper.GetString(configKey)
-	} else {
-		// Fall back to flag default
-		val = GetString(cmd, flagName)
-	}
-
 	// Expand environment variables
-	val = os.ExpandEnv(val)
+	val := os.ExpandEnv(GetStringWithFallback(cmd, flagName, configKey))
 
 	isNoSensContFound, err := verifyNoSensitiveContent(val)
 	if !isNoSensContFound {
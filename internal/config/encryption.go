@@ -0,0 +1,175 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Environment variables with the passphrase of encrypted values of the global config file
+const (
+	KeyEnv     = "FLASHPIPE_CONFIG_KEY"      // Passphrase
+	KeyFileEnv = "FLASHPIPE_CONFIG_KEY_FILE" // File containing the passphrase
+)
+
+const (
+	encryptedPrefix = "ENC[v1,"
+	encryptedSuffix = "]"
+	saltSize        = 16
+)
+
+// IsEncrypted returns whether the value was encrypted by Cipher
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+// Cipher encrypts and decrypts values with AES-256-GCM and a key derived from a passphrase with scrypt.
+// Encrypted values have the form ENC[v1,<base64 of salt, nonce and ciphertext>].
+type Cipher struct {
+	passphrase []byte
+	salt       []byte
+	keys       map[string][]byte // Derived keys by salt
+}
+
+// NewCipher returns a cipher for the passphrase
+func NewCipher(passphrase string) *Cipher {
+	return &Cipher{passphrase: []byte(passphrase), keys: map[string][]byte{}}
+}
+
+// CipherFromEnv returns a cipher for the passphrase of FLASHPIPE_CONFIG_KEY or FLASHPIPE_CONFIG_KEY_FILE
+func CipherFromEnv() (*Cipher, error) {
+	passphrase := os.Getenv(KeyEnv)
+	if file := os.Getenv(KeyFileEnv); passphrase == "" && file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", KeyFileEnv, err)
+		}
+		passphrase = strings.TrimSpace(string(data))
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s or %s is required for encrypted values of the config file", KeyEnv, KeyFileEnv)
+	}
+	return NewCipher(passphrase), nil
+}
+
+// Encrypt encrypts the value. All values encrypted by the cipher share the salt, so that the key is only
+// derived once.
+func (c *Cipher) Encrypt(value string) (string, error) {
+	if c.salt == nil {
+		c.salt = make([]byte, saltSize)
+		if _, err := rand.Read(c.salt); err != nil {
+			return "", err
+		}
+	}
+	aead, err := c.aead(c.salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	data := append(append(append([]byte{}, c.salt...), nonce...), aead.Seal(nil, nonce, []byte(value), nil)...)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(data) + encryptedSuffix, nil
+}
+
+// Decrypt decrypts a value encrypted by Encrypt
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("value is not encrypted")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix))
+	if err != nil {
+		return "", fmt.Errorf("encrypted value is not base64 encoded: %w", err)
+	}
+	if len(data) < saltSize {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+	aead, err := c.aead(data[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	data = data[saltSize:]
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value, wrong passphrase?")
+	}
+	return string(plain), nil
+}
+
+func (c *Cipher) aead(salt []byte) (cipher.AEAD, error) {
+	key, ok := c.keys[string(salt)]
+	if !ok {
+		var err error
+		if key, err = scrypt.Key(c.passphrase, salt, 1<<15, 8, 1, 32); err != nil {
+			return nil, err
+		}
+		c.keys[string(salt)] = key
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// DecryptViper decrypts the encrypted values of the config file read by viper. The passphrase is only required
+// when the config file contains encrypted values.
+func DecryptViper() error {
+	var c *Cipher
+	decrypted, err := decryptSettings(viper.AllSettings(), "", func(key, value string) (string, error) {
+		if c == nil {
+			var err error
+			if c, err = CipherFromEnv(); err != nil {
+				return "", err
+			}
+		}
+		plain, err := c.Decrypt(value)
+		if err != nil {
+			return "", fmt.Errorf("config key %s: %w", key, err)
+		}
+		return plain, nil
+	})
+	if err != nil || len(decrypted) == 0 {
+		return err
+	}
+	// The decrypted values replace the encrypted values of the config file, so that flags and environment
+	// variables keep their precedence
+	return viper.MergeConfigMap(decrypted)
+}
+
+// decryptSettings returns the decrypted values of the nested settings, nested the same way
+func decryptSettings(settings map[string]any, prefix string, decrypt func(key, value string) (string, error)) (map[string]any, error) {
+	decrypted := map[string]any{}
+	for name, value := range settings {
+		switch v := value.(type) {
+		case string:
+			if IsEncrypted(v) {
+				plain, err := decrypt(prefix+name, v)
+				if err != nil {
+					return nil, err
+				}
+				decrypted[name] = plain
+			}
+		case map[string]any:
+			nested, err := decryptSettings(v, prefix+name+".", decrypt)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) > 0 {
+				decrypted[name] = nested
+			}
+		}
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipher(t *testing.T) {
+	c := NewCipher("passphrase")
+	encrypted, err := c.Encrypt("s3cret")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "s3cret")
+
+	other, err := c.Encrypt("s3cret")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, other, "Each value should have its own nonce")
+
+	plain, err := NewCipher("passphrase").Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", plain)
+
+	_, err = NewCipher("wrong").Decrypt(encrypted)
+	assert.EqualError(t, err, "failed to decrypt value, wrong passphrase?")
+	_, err = c.Decrypt("ENC[v1,c2hvcnQ=]")
+	assert.EqualError(t, err, "encrypted value is too short")
+}
+
+func TestDecryptViper(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("passphrase\n"), 0o600))
+	t.Setenv(KeyFileEnv, keyFile)
+	t.Setenv(KeyEnv, "")
+	c, err := CipherFromEnv()
+	require.NoError(t, err)
+	password, err := c.Encrypt("file-password")
+	require.NoError(t, err)
+	apiKey, err := c.Encrypt("api-key")
+	require.NoError(t, err)
+
+	globalConfig := filepath.Join(t.TempDir(), "flashpipe.yaml")
+	require.NoError(t, os.WriteFile(globalConfig, []byte("tmn-password: "+password+"\noauth-clientsecret: "+password+"\nhttpHeaders:\n  X-Api-Key: "+apiKey+"\n"), 0o600))
+	viper.SetConfigFile(globalConfig)
+	require.NoError(t, viper.ReadInConfig())
+	viper.SetEnvPrefix("FLASHPIPE")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+	t.Setenv("FLASHPIPE_OAUTH_CLIENTSECRET", "env-secret")
+
+	require.NoError(t, DecryptViper())
+	assert.Equal(t, "file-password", viper.GetString("tmn-password"))
+	assert.Equal(t, "api-key", viper.GetStringMapString("httpHeaders")["x-api-key"])
+	assert.Equal(t, "env-secret", viper.GetString("oauth-clientsecret"), "Environment variable should keep its precedence")
+
+	require.NoError(t, viper.ReadInConfig())
+	t.Setenv(KeyFileEnv, "")
+	assert.EqualError(t, DecryptViper(), "FLASHPIPE_CONFIG_KEY or FLASHPIPE_CONFIG_KEY_FILE is required for encrypted values of the config file")
+}
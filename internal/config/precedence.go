@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Sources of the value of a setting. A flag given on the command line takes precedence over the environment
+// variable and the config file, which take precedence over the default of the flag.
+const (
+	SourceFlag       = "flag"
+	SourceEnv        = "env"
+	SourceConfigFile = "config file"
+	SourceKeychain   = "keychain"
+	SourceDefault    = "default"
+)
+
+// SensitiveAnnotation is the annotation of flags whose values are masked by Explain, set with MarkSensitive
+const SensitiveAnnotation = "flashpipe_sensitive"
+
+// EnvPrefix is the prefix of the environment variables of settings, e.g. FLASHPIPE_TMN_HOST for --tmn-host
+const EnvPrefix = "FLASHPIPE"
+
+// Setting is the effective value of a flag and where it came from
+type Setting struct {
+	Flag   string `json:"flag"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Key    string `json:"key,omitempty"` // Environment variable or config file key the value was read from
+	// fallback is true if the value was read from the config key of the flag instead of the flag
+	fallback bool
+}
+
+var (
+	mu sync.Mutex
+	// bound holds the flags set from the environment, the config file or the keychain by flag name
+	bound = map[string]Setting{}
+)
+
+// configKeyPattern matches the config file key given in the usage of a flag, e.g. (config: configure.dryRun)
+var configKeyPattern = regexp.MustCompile(`\(config: ([A-Za-z0-9_.]+)\)`)
+
+// ConfigKey returns the config file key of a flag given at the end of its usage as (config: key), empty if it
+// has none
+func ConfigKey(f *pflag.Flag) string {
+	if m := configKeyPattern.FindStringSubmatch(f.Usage); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// EnvName returns the name of the environment variable of a flag or config key, e.g. FLASHPIPE_CONFIGURE_DRYRUN
+// for configure.dryRun
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// KeySource returns whether viper read key from the environment or from the config file, and the name of the
+// environment variable or the key
+func KeySource(key string) (string, string) {
+	if env := EnvName(key); os.Getenv(env) != "" {
+		return SourceEnv, env
+	}
+	return SourceConfigFile, key
+}
+
+// BindFlags sets each flag that was not given on the command line from the environment variable or the
+// top-level config file key with the name of the flag, e.g. FLASHPIPE_TMN_HOST or tmn-host, and records where
+// the value came from
+func BindFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || !viper.IsSet(f.Name) {
+			return
+		}
+		source, key := KeySource(f.Name)
+		_ = SetFlag(cmd, f.Name, fmt.Sprintf("%v", viper.Get(f.Name)), source, key)
+	})
+}
+
+// SetFlag sets a flag that was not given on the command line to a value read from source, e.g. the keychain,
+// and records the source. key is the environment variable or config file key the value was read from.
+func SetFlag(cmd *cobra.Command, name, value, source, key string) error {
+	if err := cmd.Flags().Set(name, value); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	bound[name] = Setting{Flag: name, Value: cmd.Flags().Lookup(name).Value.String(), Source: source, Key: key}
+	return nil
+}
+
+// Resolve returns the effective value of a flag with the config file key configKey and where it came from: the
+// flag if it was set, else configKey if it is set in the config file or environment, else the default of the flag
+func Resolve(cmd *cobra.Command, flagName, configKey string) Setting {
+	f := cmd.Flags().Lookup(flagName)
+	s := Setting{Flag: flagName, Source: SourceDefault}
+	if f != nil {
+		s.Value = f.Value.String()
+	}
+	switch {
+	case f != nil && f.Changed:
+		mu.Lock()
+		b, found := bound[flagName]
+		mu.Unlock()
+		// A bound flag that was changed again afterwards counts as set on the command line
+		if found && b.Value == s.Value {
+			s.Source, s.Key = b.Source, b.Key
+		} else {
+			s.Source = SourceFlag
+		}
+	case configKey != "" && viper.IsSet(configKey):
+		s.Value = fmt.Sprintf("%v", viper.Get(configKey))
+		s.Source, s.Key = KeySource(configKey)
+		s.fallback = true
+	}
+	return s
+}
+
+// Explain returns the effective settings of all flags of the command, sorted by flag name. The config file key
+// of a flag is taken from its usage. Values of passwords, secrets and flags marked with MarkSensitive are masked.
+func Explain(cmd *cobra.Command) []Setting {
+	var settings []Setting
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name == "help" {
+			return
+		}
+		s := Resolve(cmd, f.Name, ConfigKey(f))
+		if s.Value != "" && isSensitive(f) {
+			s.Value = "******"
+		}
+		settings = append(settings, s)
+	})
+	slices.SortFunc(settings, func(a, b Setting) int { return strings.Compare(a.Flag, b.Flag) })
+	return settings
+}
+
+// MarkSensitive marks flags of the flag set whose values must not be shown, e.g. tokens and API keys, so that
+// Explain masks them. Flags with password or secret in their name are masked without being marked.
+func MarkSensitive(flags *pflag.FlagSet, names ...string) {
+	for _, name := range names {
+		_ = flags.SetAnnotation(name, SensitiveAnnotation, []string{"true"})
+	}
+}
+
+func isSensitive(f *pflag.Flag) bool {
+	if _, marked := f.Annotations[SensitiveAnnotation]; marked {
+		return true
+	}
+	return strings.Contains(f.Name, "password") || strings.Contains(f.Name, "secret")
+}
+
+// ResetBindings forgets the sources of the flags set by BindFlags and SetFlag, e.g. between tests
+func ResetBindings() {
+	mu.Lock()
+	defer mu.Unlock()
+	bound = map[string]Setting{}
+}
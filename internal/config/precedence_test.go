@@ -0,0 +1,85 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCommand(t *testing.T, yaml string, args ...string) *cobra.Command {
+	t.Cleanup(func() {
+		viper.Reset()
+		ResetBindings()
+	})
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(yaml)))
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+
+	cmd := &cobra.Command{Use: "configure"}
+	cmd.Flags().Int("batch-size", 90, "Batch size (config: configure.batchSize)")
+	cmd.Flags().Bool("disable-batch", false, "Disable batch processing (config: configure.disableBatch)")
+	cmd.Flags().Bool("dry-run", false, "Dry run (config: configure.dryRun)")
+	cmd.Flags().String("tmn-host", "", "Host")
+	cmd.Flags().String("tmn-password", "", "Password")
+	cmd.Flags().StringSlice("package-filter", nil, "Packages (config: configure.packageFilter)")
+	cmd.Flags().String("approval-token", "", "Approval token (config: approval.token)")
+	MarkSensitive(cmd.Flags(), "approval-token")
+	require.NoError(t, cmd.ParseFlags(args))
+	BindFlags(cmd)
+	return cmd
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("FLASHPIPE_CONFIGURE_DRYRUN", "true")
+	t.Setenv("FLASHPIPE_TMN_PASSWORD", "s3cret")
+	t.Setenv("FLASHPIPE_APPROVAL_TOKEN", "CHG0012345")
+	cmd := newTestCommand(t, "tmn-host: tenant.example.com\nconfigure:\n  disableBatch: true\n  batchSize: 50\n  dryRun: false\n", "--batch-size", "20")
+
+	assert.Equal(t, 20, GetIntWithFallback(cmd, "batch-size", "configure.batchSize"), "The flag should take precedence over the config file")
+	assert.True(t, GetBoolWithFallback(cmd, "disable-batch", "configure.disableBatch"))
+	assert.True(t, GetBoolWithFallback(cmd, "dry-run", "configure.dryRun"), "The environment should take precedence over the config file")
+	assert.Equal(t, "tenant.example.com", GetString(cmd, "tmn-host"))
+	assert.Empty(t, GetStringSliceWithFallback(cmd, "package-filter", "configure.packageFilter"))
+
+	assert.Equal(t, []Setting{
+		{Flag: "approval-token", Value: "******", Source: SourceEnv, Key: "FLASHPIPE_APPROVAL_TOKEN"},
+		{Flag: "batch-size", Value: "20", Source: SourceFlag},
+		{Flag: "disable-batch", Value: "true", Source: SourceConfigFile, Key: "configure.disableBatch"},
+		{Flag: "dry-run", Value: "true", Source: SourceEnv, Key: "FLASHPIPE_CONFIGURE_DRYRUN"},
+		{Flag: "package-filter", Value: "[]", Source: SourceDefault},
+		{Flag: "tmn-host", Value: "tenant.example.com", Source: SourceConfigFile, Key: "tmn-host"},
+		{Flag: "tmn-password", Value: "******", Source: SourceEnv, Key: "FLASHPIPE_TMN_PASSWORD"},
+	}, stripFallback(Explain(cmd)))
+}
+
+func TestSetFlag(t *testing.T) {
+	cmd := newTestCommand(t, "")
+	require.NoError(t, SetFlag(cmd, "tmn-host", "stored.example.com", SourceKeychain, "dev"))
+	assert.Equal(t, Setting{Flag: "tmn-host", Value: "stored.example.com", Source: SourceKeychain, Key: "dev"}, Resolve(cmd, "tmn-host", ""))
+
+	require.NoError(t, cmd.Flags().Set("tmn-host", "other.example.com"))
+	assert.Equal(t, SourceFlag, Resolve(cmd, "tmn-host", "").Source, "A bound flag changed afterwards should count as flag")
+}
+
+func TestConfigKey(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("report-file", "", "Write the report to this file (config: configure.reportFile)")
+	cmd.Flags().String("platform", "", "Platform of the tenant")
+	assert.Equal(t, "configure.reportFile", ConfigKey(cmd.Flags().Lookup("report-file")))
+	assert.Empty(t, ConfigKey(cmd.Flags().Lookup("platform")))
+	assert.Equal(t, "FLASHPIPE_CONFIGURE_REPORTFILE", EnvName("configure.reportFile"))
+	assert.Equal(t, "FLASHPIPE_TMN_HOST", EnvName("tmn-host"))
+}
+
+func stripFallback(settings []Setting) []Setting {
+	for i := range settings {
+		settings[i].fallback = false
+	}
+	return settings
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/engswee/flashpipe/internal/models"
 	"gopkg.in/yaml.v3"
 )
@@ -203,7 +204,7 @@ func (cl *ConfigLoader) loadFolder() ([]*DeployConfigFile, error) {
 
 		successCount++
 		if cl.Debug {
-			fmt.Printf("✓ Loaded config file: %s (order: %d)\n", relPath, i)
+			fmt.Print(logger.Plain(fmt.Sprintf("✓ Loaded config file: %s (order: %d)\n", relPath, i)))
 		}
 	}
 
@@ -302,7 +303,7 @@ func (cl *ConfigLoader) loadURL() ([]*DeployConfigFile, error) {
 	}
 
 	if cl.Debug {
-		fmt.Printf("✓ Successfully parsed config from URL\n")
+		fmt.Print(logger.Plain("✓ Successfully parsed config from URL\n"))
 	}
 
 	return []*DeployConfigFile{
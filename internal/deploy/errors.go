@@ -0,0 +1,93 @@
+package deploy
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Categories of deployment errors reported by the runtime
+const (
+	ErrorCategoryCredentialAlias = "missing-credential-alias"
+	ErrorCategoryCertificate     = "certificate-not-found"
+	ErrorCategoryQueueCapacity   = "queue-capacity"
+	ErrorCategoryScriptCompile   = "script-compile-error"
+	ErrorCategoryLocked          = "artifact-locked" // Artifact locked by another user, also when configuring
+	ErrorCategoryNotFound        = "not-found"       // Calls of the tenant API failed, by response code
+	ErrorCategoryUnauthorized    = "unauthorized"
+	ErrorCategoryRateLimited     = "rate-limited"
+	ErrorCategoryBadRequest      = "bad-request"
+	ErrorCategoryUnknown         = "unknown"
+)
+
+// Error is the error information of a failed deployment, classified into a category with a remediation hint
+type Error struct {
+	Category string
+	Message  string // Error information of the runtime artifact
+	Hint     string // Empty for unknown errors
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type errorRule struct {
+	category string
+	pattern  *regexp.Regexp
+	hint     func(name string) string
+}
+
+// namePattern extracts the quoted name of the security material or certificate from the message
+var namePattern = regexp.MustCompile(`(?i)alias\s*[:=]?\s*['"\[]?([\w.\-]+)`)
+
+// errorRules are checked in order, keystore messages also mention aliases so they are checked before credentials
+var errorRules = []errorRule{
+	{
+		category: ErrorCategoryCertificate,
+		pattern:  regexp.MustCompile(`(?i)keystore|key ?pair|certificate[^.]*not (be )?found|no trusted certificate|PKIX path|unable to find valid certification path`),
+		hint: func(name string) string {
+			return withName("Add the certificate or key pair%s to the tenant keystore in Monitor > Manage Keystore, or correct the alias in the configuration", name)
+		},
+	},
+	{
+		category: ErrorCategoryCredentialAlias,
+		pattern:  regexp.MustCompile(`(?i)(credential|secure ?parameter|security material|secure ?store)[^.]*(not (be )?found|not deployed|does not exist|could not be (fetched|retrieved)|missing)|could not fetch (the )?credential`),
+		hint: func(name string) string {
+			return withName("Deploy the user credentials or secure parameter%s in Monitor > Manage Security Material, or correct the credential name in the configuration", name)
+		},
+	},
+	{
+		category: ErrorCategoryQueueCapacity,
+		pattern:  regexp.MustCompile(`(?i)(jms|queue)[^.]*(capacity|limit|quota|exceeded|maximum number)`),
+		hint: func(string) string {
+			return "Delete unused JMS queues in Monitor > Manage Message Queues, or increase the JMS capacity of the tenant with the Enterprise Messaging entitlement"
+		},
+	},
+	{
+		category: ErrorCategoryScriptCompile,
+		pattern:  regexp.MustCompile(`(?i)compilation ?error|compilationfailed|MultipleCompilationErrorsException|unable to resolve class|script[^.]*(compil|syntax)`),
+		hint: func(string) string {
+			return "Fix the Groovy or JavaScript script of the artifact, check the syntax and that imported classes are available on the runtime"
+		},
+	},
+}
+
+func withName(format string, name string) string {
+	if name != "" {
+		name = " " + name
+	}
+	return fmt.Sprintf(format, name)
+}
+
+// ClassifyError classifies the error information of a runtime artifact
+func ClassifyError(message string) *Error {
+	for _, rule := range errorRules {
+		if rule.pattern.MatchString(message) {
+			var name string
+			if m := namePattern.FindStringSubmatch(message); m != nil {
+				name = m[1]
+			}
+			return &Error{Category: rule.category, Message: message, Hint: rule.hint(name)}
+		}
+	}
+	return &Error{Category: ErrorCategoryUnknown, Message: message}
+}
@@ -0,0 +1,37 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		category string
+		hint     string
+	}{
+		{"credential alias", "Could not fetch the credential for alias 'SFTP_User' from the secure store", ErrorCategoryCredentialAlias, "SFTP_User"},
+		{"secure parameter", "Secure Parameter ApiKey not deployed", ErrorCategoryCredentialAlias, "Manage Security Material"},
+		{"keystore entry", "Keystore entry with alias 'partner_cert' not found", ErrorCategoryCertificate, "partner_cert"},
+		{"PKIX", "sun.security.validator.ValidatorException: PKIX path building failed", ErrorCategoryCertificate, "Manage Keystore"},
+		{"queue capacity", "JMS resource limit exceeded: the maximum number of queues is reached", ErrorCategoryQueueCapacity, "JMS queues"},
+		{"script compile", "org.codehaus.groovy.control.MultipleCompilationErrorsException: startup failed: script1.groovy: 3: unable to resolve class Foo", ErrorCategoryScriptCompile, "Groovy"},
+		{"unknown", "Something went wrong", ErrorCategoryUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClassifyError(tt.message)
+			assert.Equal(t, tt.category, err.Category)
+			assert.Equal(t, tt.message, err.Error(), "Message should be unchanged")
+			if tt.hint == "" {
+				assert.Empty(t, err.Hint)
+			} else {
+				assert.Contains(t, err.Hint, tt.hint)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package designtime
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// endpointKeys are the channel properties that hold the endpoint of an adapter, in order of preference, e.g.
+// urlPath of an HTTPS sender or httpAddressWithoutQuery of an HTTP receiver
+var endpointKeys = []string{"urlPath", "address", "httpAddressWithoutQuery", "host", "server", "QueueName_inbound", "QueueName_outbound", "destination"}
+
+// ArtifactDocumentation describes the channels and externalized parameters of an artifact
+type ArtifactDocumentation struct {
+	ID         string
+	Name       string
+	Version    string
+	Type       string
+	Channels   []ChannelDocumentation
+	Parameters []ParameterDocumentation
+}
+
+// ChannelDocumentation is a sender or receiver channel of an integration flow
+type ChannelDocumentation struct {
+	Name             string
+	Direction        string // Sender or Receiver
+	System           string // Name of the sender or receiver participant
+	Adapter          string
+	AdapterVersion   string
+	Endpoint         string // Endpoint with externalized parameters resolved
+	EndpointProperty string // Property the endpoint was read from
+	Parameter        string // Externalized parameter the endpoint is read from
+}
+
+// ParameterDocumentation is an externalized parameter with its current value and the channels that use it
+type ParameterDocumentation struct {
+	ParameterDefinition
+	Channels []string
+}
+
+// Documentation reads the documentation of the artifact in dir from its MANIFEST.MF, integration flow model
+// and parameters. Channels are ordered by direction and name, parameters by key.
+func Documentation(dir string) (*ArtifactDocumentation, error) {
+	inventory, err := ArtifactDependencies(dir)
+	if err != nil {
+		return nil, err
+	}
+	doc := &ArtifactDocumentation{ID: inventory.ID, Name: inventory.Name, Version: inventory.Version, Type: inventory.Type}
+	definitions, err := ArtifactParameters(dir)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, definition := range definitions {
+		values[definition.Key] = definition.Default
+	}
+
+	usage := map[string][]string{}
+	entries, err := os.ReadDir(filepath.Join(dir, integrationFlowDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".iflw") {
+			continue
+		}
+		model := etree.NewDocument()
+		// Models that are not well-formed are reported by artifact validate
+		if err := model.ReadFromFile(filepath.Join(dir, integrationFlowDir, entry.Name())); err != nil || model.Root() == nil {
+			continue
+		}
+		participants := map[string]string{}
+		for _, participant := range model.Root().FindElements("//participant") {
+			participants[participant.SelectAttrValue("id", "")] = participant.SelectAttrValue("name", "")
+		}
+		for _, flow := range model.Root().FindElements("//messageFlow") {
+			properties := elementProperties(flow)
+			if properties["ComponentType"] == "" {
+				continue
+			}
+			channel := ChannelDocumentation{
+				Name:           flow.SelectAttrValue("name", ""),
+				Direction:      properties["direction"],
+				Adapter:        properties["ComponentType"],
+				AdapterVersion: properties["componentVersion"],
+			}
+			if channel.Direction == "Receiver" {
+				channel.System = participants[flow.SelectAttrValue("targetRef", "")]
+			} else {
+				channel.System = participants[flow.SelectAttrValue("sourceRef", "")]
+			}
+			for _, key := range endpointKeys {
+				if properties[key] != "" {
+					channel.EndpointProperty = key
+					channel.Endpoint = resolveParameter(properties[key], values)
+					if match := parameterPattern.FindStringSubmatch(properties[key]); match != nil {
+						channel.Parameter = match[1]
+					}
+					break
+				}
+			}
+			for _, value := range properties {
+				if match := parameterPattern.FindStringSubmatch(value); match != nil && !slices.Contains(usage[match[1]], channel.Name) {
+					usage[match[1]] = append(usage[match[1]], channel.Name)
+				}
+			}
+			doc.Channels = append(doc.Channels, channel)
+		}
+	}
+	slices.SortStableFunc(doc.Channels, func(a, b ChannelDocumentation) int {
+		if a.Direction != b.Direction {
+			// Senders before receivers
+			return strings.Compare(b.Direction, a.Direction)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for _, definition := range definitions {
+		channels := usage[definition.Key]
+		slices.Sort(channels)
+		doc.Parameters = append(doc.Parameters, ParameterDocumentation{ParameterDefinition: definition, Channels: channels})
+	}
+	return doc, nil
+}
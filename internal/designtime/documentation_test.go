@@ -0,0 +1,59 @@
+package designtime
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const documentationModel = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn2:definitions xmlns:bpmn2="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:ifl="http:///com.sap.ifl.model/Ifl.xsd" id="Definitions_1">
+    <bpmn2:collaboration id="Collaboration_1">
+        <bpmn2:participant id="Participant_1" ifl:type="EndpointSender" name="Webshop"/>
+        <bpmn2:participant id="Participant_2" ifl:type="EndpointRecevier" name="ERP"/>
+        <bpmn2:messageFlow id="MessageFlow_2" name="Post Order" sourceRef="ServiceTask_1" targetRef="Participant_2">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTP</value></ifl:property>
+                <ifl:property><key>direction</key><value>Receiver</value></ifl:property>
+                <ifl:property><key>componentVersion</key><value>1.16</value></ifl:property>
+                <ifl:property><key>httpAddressWithoutQuery</key><value>{{ERP_URL}}</value></ifl:property>
+                <ifl:property><key>credentialName</key><value>{{ERP_Credential}}</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+        <bpmn2:messageFlow id="MessageFlow_1" name="Orders In" sourceRef="Participant_1" targetRef="StartEvent_1">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTPS</value></ifl:property>
+                <ifl:property><key>direction</key><value>Sender</value></ifl:property>
+                <ifl:property><key>componentVersion</key><value>1.5</value></ifl:property>
+                <ifl:property><key>urlPath</key><value>/orders</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+    </bpmn2:collaboration>
+</bpmn2:definitions>
+`
+
+func TestDocumentation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Orders")
+	writeFile(t, filepath.Join(dir, "META-INF", "MANIFEST.MF"),
+		"Manifest-Version: 1.0\nBundle-SymbolicName: Orders\nBundle-Name: Orders Replication\nBundle-Version: 1.2.0\nSAP-BundleType: IntegrationFlow\n")
+	writeFile(t, filepath.Join(dir, integrationFlowDir, "Orders.iflw"), documentationModel)
+	writeFile(t, filepath.Join(dir, "src", "main", "resources", "parameters.prop"), "ERP_URL=https://erp.example.com/orders\nERP_Credential=erp_user\nTimeout=60\n")
+
+	doc, err := Documentation(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "Orders", doc.ID)
+	assert.Equal(t, "Orders Replication", doc.Name)
+	assert.Equal(t, "1.2.0", doc.Version)
+	assert.Equal(t, []ChannelDocumentation{
+		{Name: "Orders In", Direction: "Sender", System: "Webshop", Adapter: "HTTPS", AdapterVersion: "1.5", Endpoint: "/orders", EndpointProperty: "urlPath"},
+		{Name: "Post Order", Direction: "Receiver", System: "ERP", Adapter: "HTTP", AdapterVersion: "1.16", Endpoint: "https://erp.example.com/orders",
+			EndpointProperty: "httpAddressWithoutQuery", Parameter: "ERP_URL"},
+	}, doc.Channels, "Senders should be listed before receivers")
+	assert.Equal(t, []ParameterDocumentation{
+		{ParameterDefinition: ParameterDefinition{Key: "ERP_Credential", Default: "erp_user"}, Channels: []string{"Post Order"}},
+		{ParameterDefinition: ParameterDefinition{Key: "ERP_URL", Default: "https://erp.example.com/orders"}, Channels: []string{"Post Order"}},
+		{ParameterDefinition: ParameterDefinition{Key: "Timeout", Default: "60"}},
+	}, doc.Parameters)
+}
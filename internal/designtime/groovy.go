@@ -0,0 +1,171 @@
+package designtime
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SeverityOff disables a Groovy rule
+const SeverityOff = "off"
+
+// GroovyRule reports the lines of Groovy scripts that match its pattern
+type GroovyRule struct {
+	ID       string `yaml:"id"`
+	Pattern  string `yaml:"pattern,omitempty"`  // Regular expression matched against each line
+	Message  string `yaml:"message,omitempty"`  // Explanation of the finding
+	Severity string `yaml:"severity,omitempty"` // off, warning or error, defaults to warning
+	pattern  *regexp.Regexp
+}
+
+// BuiltinGroovyRules are the rules checked by default
+var BuiltinGroovyRules = []GroovyRule{
+	{ID: "system-exit", Severity: SeverityError, Pattern: `\bSystem\s*\.\s*exit\s*\(`,
+		Message: "System.exit stops the runtime of all integration flows on the node"},
+	{ID: "process-execution", Severity: SeverityError, Pattern: `\bRuntime\s*\.\s*getRuntime\s*\(\s*\)\s*\.\s*exec\b|\bnew\s+ProcessBuilder\b|["']\s*\.\s*execute\s*\(\s*\)`,
+		Message: "operating system processes must not be started"},
+	{ID: "hard-coded-credential", Severity: SeverityError, Pattern: `(?i)\b\w*(password|passwd|pwd|secret|token|api_?key)\w*\s*=\s*["'][^"'$]+["']`,
+		Message: "credential written in the script, read it from the secure store instead"},
+	{ID: "thread-sleep", Severity: SeverityWarning, Pattern: `\bThread\s*\.\s*sleep\s*\(`,
+		Message: "Thread.sleep blocks a worker thread, use the retry of the adapter or a timer instead"},
+	{ID: "console-output", Severity: SeverityWarning, Pattern: `\bSystem\s*\.\s*(out|err)\s*\.\s*print|^\s*print(ln|f)?\b`,
+		Message: "console output is not visible, use the message processing log instead"},
+}
+
+// groovyRulesFile is the content of a Groovy rules file
+type groovyRulesFile struct {
+	Rules []GroovyRule `yaml:"rules"`
+}
+
+// LoadGroovyRules returns the built-in rules changed by a rules file, or the built-in rules if path is empty.
+// Rules of the file with the ID of a built-in rule change its settings, e.g. severity off disables it, all
+// others are added.
+func LoadGroovyRules(path string) ([]GroovyRule, error) {
+	rules := slices.Clone(BuiltinGroovyRules)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Groovy rules file: %w", err)
+		}
+		content := new(groovyRulesFile)
+		if err := yaml.Unmarshal(data, content); err != nil {
+			return nil, fmt.Errorf("failed to parse Groovy rules file %s: %w", path, err)
+		}
+		for _, rule := range content.Rules {
+			i := slices.IndexFunc(rules, func(r GroovyRule) bool { return r.ID == rule.ID })
+			if i < 0 {
+				rules = append(rules, rule)
+				continue
+			}
+			if rule.Pattern != "" {
+				rules[i].Pattern = rule.Pattern
+			}
+			if rule.Message != "" {
+				rules[i].Message = rule.Message
+			}
+			if rule.Severity != "" {
+				rules[i].Severity = rule.Severity
+			}
+		}
+	}
+
+	var enabled []GroovyRule
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("Groovy rule without id")
+		}
+		if rule.Severity == "" {
+			rule.Severity = SeverityWarning
+		}
+		switch rule.Severity {
+		case SeverityOff:
+			continue
+		case SeverityWarning, SeverityError:
+		default:
+			return nil, fmt.Errorf("Groovy rule %s: invalid severity %q (valid severities: off, warning, error)", rule.ID, rule.Severity)
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("Groovy rule %s: pattern is required", rule.ID)
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Groovy rule %s: invalid pattern: %w", rule.ID, err)
+		}
+		rule.pattern = pattern
+		enabled = append(enabled, rule)
+	}
+	return enabled, nil
+}
+
+// checkGroovy reports the lines of the Groovy scripts of the artifact that match one of the rules. Comments
+// are not checked.
+func (v *validator) checkGroovy(rules []GroovyRule) {
+	err := filepath.WalkDir(v.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".groovy") && !strings.EqualFold(filepath.Ext(path), ".gsh") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(nil, len(content)+1)
+		inComment := false
+		for line := 1; scanner.Scan(); line++ {
+			var code string
+			code, inComment = stripComments(scanner.Text(), inComment)
+			for _, rule := range rules {
+				if rule.pattern.MatchString(code) {
+					v.findings = append(v.findings, Finding{Check: CheckGroovy, Rule: rule.ID, Severity: rule.Severity, Artifact: v.name,
+						Dir: v.dir, File: v.rel(path), Line: line, Message: rule.Message})
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		v.err = fmt.Errorf("failed to read Groovy scripts of %s: %w", v.name, err)
+	}
+}
+
+// stripComments removes // and /* */ comments from a line of code. Comment markers in strings are not
+// recognized, which can only hide findings.
+func stripComments(line string, inComment bool) (string, bool) {
+	var code strings.Builder
+	for line != "" {
+		if inComment {
+			end := strings.Index(line, "*/")
+			if end < 0 {
+				return code.String(), true
+			}
+			line = line[end+2:]
+			inComment = false
+			continue
+		}
+		lineComment := strings.Index(line, "//")
+		blockComment := strings.Index(line, "/*")
+		switch {
+		case blockComment >= 0 && (lineComment < 0 || blockComment < lineComment):
+			code.WriteString(line[:blockComment])
+			line = line[blockComment+2:]
+			inComment = true
+		case lineComment >= 0:
+			code.WriteString(line[:lineComment])
+			return code.String(), false
+		default:
+			code.WriteString(line)
+			return code.String(), false
+		}
+	}
+	return code.String(), inComment
+}
@@ -0,0 +1,132 @@
+package designtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const script = `import com.sap.gateway.ip.core.customdev.util.Message
+
+def Message processData(Message message) {
+    // System.exit(1) is never called
+    /* Thread.sleep(100)
+       println "commented out" */
+    def apiKey = "abc123"
+    def password = "${message.getProperty('pwd')}"
+    if (message.getBody(String) == null) {
+        System.exit(1)
+    }
+    println "done"; Thread.sleep(1000)
+    return message
+}
+`
+
+func groovyRules(findings []Finding) []string {
+	var result []string
+	for _, f := range findings {
+		result = append(result, f.Rule+":"+f.Severity)
+	}
+	return result
+}
+
+func TestLoadGroovyRules(t *testing.T) {
+	rules, err := LoadGroovyRules("")
+	require.NoError(t, err)
+	assert.Len(t, rules, len(BuiltinGroovyRules))
+
+	dir := t.TempDir()
+	rulesFile := filepath.Join(dir, "groovy-rules.yml")
+	writeFile(t, rulesFile, `rules:
+  - id: console-output
+    severity: off
+  - id: thread-sleep
+    severity: error
+  - id: no-base64
+    pattern: Base64\.decode
+    message: Use the Base64 decoder step
+`)
+	rules, err = LoadGroovyRules(rulesFile)
+	require.NoError(t, err)
+	var ids []string
+	for _, rule := range rules {
+		ids = append(ids, rule.ID+":"+rule.Severity)
+	}
+	assert.Equal(t, []string{"system-exit:error", "process-execution:error", "hard-coded-credential:error", "thread-sleep:error", "no-base64:warning"}, ids)
+
+	writeFile(t, rulesFile, "rules:\n  - id: custom\n")
+	_, err = LoadGroovyRules(rulesFile)
+	assert.EqualError(t, err, "Groovy rule custom: pattern is required")
+
+	writeFile(t, rulesFile, "rules:\n  - id: system-exit\n    severity: fatal\n")
+	_, err = LoadGroovyRules(rulesFile)
+	assert.ErrorContains(t, err, `Groovy rule system-exit: invalid severity "fatal"`)
+}
+
+func TestValidateGroovy(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Orders")
+	writeArtifact(t, dir, "enrich.groovy", "EndEvent_1")
+	writeFile(t, filepath.Join(dir, "src", "main", "resources", "script", "enrich.groovy"), script)
+
+	findings, err := Validate(dir, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	rules, err := LoadGroovyRules("")
+	require.NoError(t, err)
+	findings, err = Validate(dir, Options{GroovyRules: rules})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hard-coded-credential:error", "system-exit:error", "thread-sleep:warning", "console-output:warning"}, groovyRules(findings))
+	assert.Equal(t, []int{7, 10, 12, 12}, []int{findings[0].Line, findings[1].Line, findings[2].Line, findings[3].Line})
+	assert.Equal(t, "src/main/resources/script/enrich.groovy", findings[0].File)
+	assert.Equal(t, "Orders/src/main/resources/script/enrich.groovy:10: error [groovy/system-exit] "+
+		"System.exit stops the runtime of all integration flows on the node", findings[1].String())
+}
+
+func TestStripComments(t *testing.T) {
+	code, inComment := stripComments(`def a = 1 // comment`, false)
+	assert.Equal(t, "def a = 1 ", code)
+	assert.False(t, inComment)
+
+	code, inComment = stripComments(`def a = /* inline */ 1 /* open`, false)
+	assert.Equal(t, "def a =  1 ", code)
+	assert.True(t, inComment)
+
+	code, inComment = stripComments(`still */ def b = 2`, true)
+	assert.Equal(t, " def b = 2", code)
+	assert.False(t, inComment)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	findings := []Finding{
+		{Check: CheckGroovy, Rule: "system-exit", Severity: SeverityError, Artifact: "Orders", Dir: "src/Orders",
+			File: "src/main/resources/script/enrich.groovy", Line: 10, Message: "System.exit stops the runtime"},
+		{Check: CheckManifest, Severity: SeverityWarning, Artifact: "Orders", Dir: "src/Orders",
+			File: "META-INF/MANIFEST.MF", Message: "Bundle-SymbolicName differs"},
+		{Check: CheckGroovy, Rule: "system-exit", Severity: SeverityError, Artifact: "Invoices", Dir: "Invoices.zip",
+			File: "src/main/resources/script/main.groovy", Line: 3, Message: "System.exit stops the runtime"},
+	}
+	var out bytes.Buffer
+	require.NoError(t, WriteSARIF(&out, findings, nil))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, []sarifRule{
+		{ID: "groovy/system-exit", ShortDescription: sarifMessage{Text: "System.exit stops the runtime"}},
+		{ID: "manifest", ShortDescription: sarifMessage{Text: "MANIFEST.MF headers"}},
+	}, run.Tool.Driver.Rules)
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "src/Orders/src/main/resources/script/enrich.groovy", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 10}, run.Results[0].Locations[0].PhysicalLocation.Region)
+	assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "Invoices.zip", run.Results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Nil(t, run.Results[2].Locations[0].PhysicalLocation.Region)
+}
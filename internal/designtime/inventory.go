@@ -0,0 +1,276 @@
+package designtime
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/engswee/flashpipe/internal/file"
+)
+
+// Kinds of dependencies in the inventory
+const (
+	DependencyAdapter          = "adapter"
+	DependencyScriptCollection = "script-collection"
+	DependencyMapping          = "mapping"
+	DependencyJar              = "jar"
+	DependencyCredential       = "credential"
+)
+
+// PackageInventory lists the artifacts of a package with their dependencies
+type PackageInventory struct {
+	ID        string               `json:"package"`
+	Artifacts []*ArtifactInventory `json:"artifacts"`
+}
+
+// ArtifactInventory lists the dependencies of an artifact
+type ArtifactInventory struct {
+	ID                string       `json:"artifact"`
+	Name              string       `json:"name,omitempty"`
+	Version           string       `json:"version,omitempty"`
+	Type              string       `json:"type,omitempty"`
+	Dir               string       `json:"-"` // Directory the artifact was read from
+	Adapters          []Dependency `json:"adapters"`
+	ScriptCollections []Dependency `json:"scriptCollections"`
+	Mappings          []Dependency `json:"mappings"`
+	Jars              []Dependency `json:"jars"`
+	Credentials       []Dependency `json:"credentials"`
+}
+
+// Dependency is an adapter, resource or credential an artifact depends on
+type Dependency struct {
+	Kind      string `json:"-"`
+	Name      string `json:"name"`
+	Detail    string `json:"detail,omitempty"`    // e.g. the direction and version of an adapter
+	Channel   string `json:"channel,omitempty"`   // Channel or step the dependency is used in
+	Parameter string `json:"parameter,omitempty"` // Externalized parameter the name is read from
+}
+
+// Dependencies returns all dependencies of the artifact, ordered by kind
+func (a *ArtifactInventory) Dependencies() []Dependency {
+	return slices.Concat(a.Adapters, a.ScriptCollections, a.Mappings, a.Jars, a.Credentials)
+}
+
+// credentialKeyPattern matches the properties of channels and steps that name a credential or key alias
+var credentialKeyPattern = regexp.MustCompile(`(?i)^(\w*credential_?name|\w*alias|privateKeyAliasForSigning)$`)
+
+// parameterPattern matches externalized values
+var parameterPattern = regexp.MustCompile(`^\{\{(.+)\}\}$`)
+
+// scriptCollectionPattern matches script collections required in MANIFEST.MF
+var scriptCollectionPattern = regexp.MustCompile(`scriptcollection\.([^;,\s]+)`)
+
+// Inventory lists the dependencies of the artifacts in a directory, grouped into packages by the directory
+// that contains the artifact directories, e.g. as written by snapshot. Packages and artifacts are ordered
+// by ID.
+func Inventory(path string) ([]*PackageInventory, error) {
+	packages := map[string]*PackageInventory{}
+	err := filepath.WalkDir(path, func(dir string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || !isArtifactDir(dir) {
+			return nil
+		}
+		packageID := filepath.Base(filepath.Dir(dir))
+		if dir == filepath.Clean(path) {
+			packageID = ""
+		}
+		artifact, err := ArtifactDependencies(dir)
+		if err != nil {
+			return err
+		}
+		if packages[packageID] == nil {
+			packages[packageID] = &PackageInventory{ID: packageID}
+		}
+		packages[packageID].Artifacts = append(packages[packageID].Artifacts, artifact)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifacts in %s: %w", path, err)
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no artifacts found in %s, expected directories with META-INF or src/main/resources", path)
+	}
+
+	var result []*PackageInventory
+	for _, pkg := range packages {
+		slices.SortFunc(pkg.Artifacts, func(a, b *ArtifactInventory) int { return strings.Compare(a.ID, b.ID) })
+		result = append(result, pkg)
+	}
+	slices.SortFunc(result, func(a, b *PackageInventory) int { return strings.Compare(a.ID, b.ID) })
+	return result, nil
+}
+
+// ArtifactDependencies reads the dependencies of the artifact in dir from its MANIFEST.MF, integration flow
+// model, resources and parameters
+func ArtifactDependencies(dir string) (*ArtifactInventory, error) {
+	artifact := &ArtifactInventory{ID: filepath.Base(dir), Dir: dir, Adapters: []Dependency{}, ScriptCollections: []Dependency{},
+		Mappings: []Dependency{}, Jars: []Dependency{}, Credentials: []Dependency{}}
+	if manifestPath := filepath.Join(dir, "META-INF", "MANIFEST.MF"); file.Exists(manifestPath) {
+		headers, err := file.ReadManifest(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		if id, _, _ := strings.Cut(headers["Bundle-SymbolicName"], ";"); strings.TrimSpace(id) != "" {
+			artifact.ID = strings.TrimSpace(id)
+		}
+		artifact.Name = headers["Bundle-Name"]
+		artifact.Version = headers["Bundle-Version"]
+		artifact.Type = headers["SAP-BundleType"]
+		for _, match := range scriptCollectionPattern.FindAllStringSubmatch(headers["Require-Capability"], -1) {
+			artifact.add(Dependency{Kind: DependencyScriptCollection, Name: match[1]})
+		}
+	}
+	parameters, err := readParameters(filepath.Join(dir, "src", "main", "resources", "parameters.prop"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, integrationFlowDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".iflw") {
+			continue
+		}
+		doc := etree.NewDocument()
+		// Models that are not well-formed are reported by artifact validate
+		if err := doc.ReadFromFile(filepath.Join(dir, integrationFlowDir, entry.Name())); err != nil || doc.Root() == nil {
+			continue
+		}
+		for _, channel := range doc.Root().FindElements("//messageFlow") {
+			properties := elementProperties(channel)
+			if properties["ComponentType"] != "" {
+				detail := strings.TrimSpace(properties["direction"] + " " + properties["componentVersion"])
+				artifact.add(Dependency{Kind: DependencyAdapter, Name: properties["ComponentType"], Detail: detail,
+					Channel: channel.SelectAttrValue("name", "")})
+			}
+			artifact.addCredentials(properties, parameters, channel.SelectAttrValue("name", ""))
+		}
+		for _, step := range doc.Root().FindElements("//process/*") {
+			properties := elementProperties(step)
+			name := step.SelectAttrValue("name", "")
+			if properties["scriptBundleId"] != "" {
+				artifact.add(Dependency{Kind: DependencyScriptCollection, Name: resolveParameter(properties["scriptBundleId"], parameters)})
+			}
+			for _, key := range []string{"mappinguri", "mappingpath"} {
+				if mapping := resolveParameter(properties[key], parameters); mapping != "" {
+					artifact.add(Dependency{Kind: DependencyMapping, Name: mappingName(mapping), Detail: properties["mappingType"], Channel: name})
+				}
+			}
+			artifact.addCredentials(properties, parameters, name)
+		}
+	}
+
+	jars, err := filepath.Glob(filepath.Join(dir, "src", "main", "resources", "lib", "*.jar"))
+	if err != nil {
+		return nil, err
+	}
+	for _, jar := range jars {
+		artifact.add(Dependency{Kind: DependencyJar, Name: filepath.Base(jar)})
+	}
+	return artifact, nil
+}
+
+// add adds a dependency unless the same dependency is already listed
+func (a *ArtifactInventory) add(d Dependency) {
+	var list *[]Dependency
+	switch d.Kind {
+	case DependencyAdapter:
+		list = &a.Adapters
+	case DependencyScriptCollection:
+		list = &a.ScriptCollections
+	case DependencyMapping:
+		list = &a.Mappings
+	case DependencyJar:
+		list = &a.Jars
+	default:
+		list = &a.Credentials
+	}
+	if !slices.Contains(*list, d) {
+		*list = append(*list, d)
+	}
+}
+
+func (a *ArtifactInventory) addCredentials(properties map[string]string, parameters map[string]string, channel string) {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !credentialKeyPattern.MatchString(key) {
+			continue
+		}
+		var parameter string
+		if match := parameterPattern.FindStringSubmatch(properties[key]); match != nil {
+			parameter = match[1]
+		}
+		if alias := resolveParameter(properties[key], parameters); alias != "" {
+			a.add(Dependency{Kind: DependencyCredential, Name: alias, Detail: key, Channel: channel, Parameter: parameter})
+		}
+	}
+}
+
+// elementProperties returns the properties of a channel or step of the integration flow model
+func elementProperties(e *etree.Element) map[string]string {
+	properties := map[string]string{}
+	if extensions := e.SelectElement("extensionElements"); extensions != nil {
+		for _, property := range extensions.SelectElements("property") {
+			if key, value := property.SelectElement("key"), property.SelectElement("value"); key != nil && value != nil {
+				properties[key.Text()] = strings.TrimSpace(value.Text())
+			}
+		}
+	}
+	return properties
+}
+
+// resolveParameter returns the value of an externalized parameter, or the value itself if it is not
+// externalized
+func resolveParameter(value string, parameters map[string]string) string {
+	if match := parameterPattern.FindStringSubmatch(value); match != nil {
+		return parameters[match[1]]
+	}
+	return value
+}
+
+// mappingName returns the file name of a mapping of the artifact, or the reference to a mapping of another
+// artifact as is
+func mappingName(mapping string) string {
+	if strings.Contains(mapping, "src/main/resources/") {
+		return filepath.Base(mapping)
+	}
+	return mapping
+}
+
+// readParameters reads the values of externalized parameters from parameters.prop, if it exists
+func readParameters(path string) (map[string]string, error) {
+	parameters := map[string]string{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return parameters, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		if key, value, found := strings.Cut(line, "="); found {
+			// Spaces in keys are escaped in properties files
+			parameters[strings.ReplaceAll(strings.TrimSpace(key), `\ `, " ")] = strings.TrimSpace(value)
+		}
+	}
+	return parameters, scanner.Err()
+}
@@ -0,0 +1,99 @@
+package designtime
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inventoryModel = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn2:definitions xmlns:bpmn2="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:ifl="http:///com.sap.ifl.model/Ifl.xsd" id="Definitions_1">
+    <bpmn2:collaboration id="Collaboration_1">
+        <bpmn2:messageFlow id="MessageFlow_1" name="Orders In" sourceRef="Participant_1" targetRef="StartEvent_1">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTPS</value></ifl:property>
+                <ifl:property><key>direction</key><value>Sender</value></ifl:property>
+                <ifl:property><key>componentVersion</key><value>1.5</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+        <bpmn2:messageFlow id="MessageFlow_2" name="ERP" sourceRef="ServiceTask_1" targetRef="Participant_2">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTP</value></ifl:property>
+                <ifl:property><key>direction</key><value>Receiver</value></ifl:property>
+                <ifl:property><key>componentVersion</key><value>1.16</value></ifl:property>
+                <ifl:property><key>credentialName</key><value>{{ERP Credential}}</value></ifl:property>
+                <ifl:property><key>proxyCredentialName</key><value></value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+    </bpmn2:collaboration>
+    <bpmn2:process id="Process_1">
+        <bpmn2:callActivity id="CallActivity_1" name="Enrich">
+            <bpmn2:extensionElements>
+                <ifl:property><key>scriptBundleId</key><value>Common_Scripts</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:callActivity>
+        <bpmn2:callActivity id="CallActivity_2" name="Map Order">
+            <bpmn2:extensionElements>
+                <ifl:property><key>mappingType</key><value>MessageMapping</value></ifl:property>
+                <ifl:property><key>mappinguri</key><value>dir://mmap/src/main/resources/mapping/Order.mmap</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:callActivity>
+        <bpmn2:callActivity id="CallActivity_3" name="Sign">
+            <bpmn2:extensionElements>
+                <ifl:property><key>privateKeyAlias</key><value>signing</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:callActivity>
+    </bpmn2:process>
+</bpmn2:definitions>
+`
+
+func TestInventory(t *testing.T) {
+	dir := t.TempDir()
+	orders := filepath.Join(dir, "Sales", "Orders")
+	writeFile(t, filepath.Join(orders, "META-INF", "MANIFEST.MF"), `Manifest-Version: 1.0
+Bundle-SymbolicName: Orders; singleton:=true
+Bundle-Name: Orders Replication
+Bundle-Version: 1.2.0
+SAP-BundleType: IntegrationFlow
+Require-Capability: scriptcollection.Common_Scripts;resolution:=optional;bund
+ leType:String="ScriptCollection";source:String="reference"
+`)
+	writeFile(t, filepath.Join(orders, integrationFlowDir, "Orders.iflw"), inventoryModel)
+	writeFile(t, filepath.Join(orders, "src", "main", "resources", "parameters.prop"), "#comment\nERP\\ Credential=erp_user\n")
+	writeFile(t, filepath.Join(orders, "src", "main", "resources", "lib", "json-path-2.9.0.jar"), "jar")
+	writeFile(t, filepath.Join(dir, "Sales", "Common_Scripts", "META-INF", "MANIFEST.MF"),
+		"Manifest-Version: 1.0\nBundle-SymbolicName: Common_Scripts\nBundle-Version: 1.0.0\nSAP-BundleType: ScriptCollection\n")
+	writeFile(t, filepath.Join(dir, "Finance", "Invoices", "META-INF", "MANIFEST.MF"),
+		"Manifest-Version: 1.0\nBundle-SymbolicName: Invoices\nBundle-Version: 1.0.0\nSAP-BundleType: IntegrationFlow\n")
+
+	packages, err := Inventory(dir)
+	require.NoError(t, err)
+	require.Len(t, packages, 2)
+	assert.Equal(t, "Finance", packages[0].ID)
+	assert.Equal(t, "Sales", packages[1].ID)
+	require.Len(t, packages[1].Artifacts, 2)
+	assert.Equal(t, "Common_Scripts", packages[1].Artifacts[0].ID)
+	assert.Empty(t, packages[1].Artifacts[0].Dependencies())
+
+	artifact := packages[1].Artifacts[1]
+	assert.Equal(t, "Orders", artifact.ID)
+	assert.Equal(t, "Orders Replication", artifact.Name)
+	assert.Equal(t, "1.2.0", artifact.Version)
+	assert.Equal(t, "IntegrationFlow", artifact.Type)
+	assert.Equal(t, []Dependency{
+		{Kind: DependencyAdapter, Name: "HTTPS", Detail: "Sender 1.5", Channel: "Orders In"},
+		{Kind: DependencyAdapter, Name: "HTTP", Detail: "Receiver 1.16", Channel: "ERP"},
+		{Kind: DependencyScriptCollection, Name: "Common_Scripts"},
+		{Kind: DependencyMapping, Name: "Order.mmap", Detail: "MessageMapping", Channel: "Map Order"},
+		{Kind: DependencyJar, Name: "json-path-2.9.0.jar"},
+		{Kind: DependencyCredential, Name: "erp_user", Detail: "credentialName", Channel: "ERP", Parameter: "ERP Credential"},
+		{Kind: DependencyCredential, Name: "signing", Detail: "privateKeyAlias", Channel: "Sign"},
+	}, artifact.Dependencies())
+}
+
+func TestInventoryNoArtifacts(t *testing.T) {
+	_, err := Inventory(t.TempDir())
+	assert.ErrorContains(t, err, "no artifacts found")
+}
@@ -0,0 +1,78 @@
+package designtime
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// ParameterDefinition is an externalized parameter of an artifact
+type ParameterDefinition struct {
+	Key         string
+	Default     string // Value in parameters.prop
+	Type        string // Type in parameters.propdef, e.g. xsd:string
+	Required    bool
+	Description string
+}
+
+// ArtifactParameters returns the externalized parameters of the artifact in dir ordered by key, read from
+// parameters.prop with the definitions of parameters.propdef. Parameters that are only defined are returned
+// without a default.
+func ArtifactParameters(dir string) ([]ParameterDefinition, error) {
+	resources := filepath.Join(dir, "src", "main", "resources")
+	values, err := readParameters(filepath.Join(resources, "parameters.prop"))
+	if err != nil {
+		return nil, err
+	}
+	definitions, err := readParameterDefinitions(filepath.Join(resources, "parameters.propdef"))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range values {
+		definition := definitions[key]
+		definition.Key = key
+		definition.Default = value
+		definitions[key] = definition
+	}
+	parameters := make([]ParameterDefinition, 0, len(definitions))
+	for _, definition := range definitions {
+		parameters = append(parameters, definition)
+	}
+	slices.SortFunc(parameters, func(a, b ParameterDefinition) int { return strings.Compare(a.Key, b.Key) })
+	return parameters, nil
+}
+
+// readParameterDefinitions reads the parameter definitions of parameters.propdef by key, if it exists
+func readParameterDefinitions(path string) (map[string]ParameterDefinition, error) {
+	definitions := map[string]ParameterDefinition{}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromFile(path); os.IsNotExist(err) {
+		return definitions, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, parameter := range doc.FindElements("//parameter") {
+		key := strings.TrimSpace(childText(parameter, "name"))
+		if key == "" {
+			continue
+		}
+		definitions[key] = ParameterDefinition{
+			Key:         key,
+			Type:        strings.TrimSpace(childText(parameter, "type")),
+			Required:    strings.EqualFold(strings.TrimSpace(childText(parameter, "isRequired")), "true"),
+			Description: strings.TrimSpace(childText(parameter, "description")),
+		}
+	}
+	return definitions, nil
+}
+
+func childText(e *etree.Element, tag string) string {
+	if child := e.SelectElement(tag); child != nil {
+		return child.Text()
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+package designtime
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// checkDescriptions describe the checks in the rules of SARIF logs
+var checkDescriptions = map[string]string{
+	CheckStructure:     "Artifact structure",
+	CheckManifest:      "MANIFEST.MF headers",
+	CheckBPMN:          "BPMN model of the integration flow",
+	CheckResource:      "Files referenced by integration flow steps",
+	CheckMergeConflict: "Git merge conflict markers",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log, e.g. for GitHub code scanning. File locations are
+// relative to the working directory if the checked path was. Rules are described by descriptions, by
+// rule ID, or else by the checks of artifact validate and the messages of Groovy rules.
+func WriteSARIF(w io.Writer, findings []Finding, descriptions map[string]string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "flashpipe",
+			InformationURI: "https://github.com/engswee/flashpipe",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIDs := map[string]bool{}
+	for _, f := range findings {
+		if !ruleIDs[f.RuleID()] {
+			ruleIDs[f.RuleID()] = true
+			description, ok := descriptions[f.RuleID()]
+			switch {
+			case ok:
+			case f.Rule != "":
+				description = f.Message
+			default:
+				description = checkDescriptions[f.Check]
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: f.RuleID(), ShortDescription: sarifMessage{Text: description}})
+		}
+
+		// Files extracted from a zip file are reported at the zip file
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.Dir)}}}
+		if f.File != "" && !strings.EqualFold(filepath.Ext(f.Dir), ".zip") {
+			location.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(filepath.Join(f.Dir, f.File))
+			if f.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line}
+			}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.RuleID(),
+			Level:     f.Severity,
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,372 @@
+// Package designtime checks the content of designtime artifacts in a local directory or zip file before
+// they are uploaded, e.g. a MANIFEST.MF without version or an integration flow that is no longer well-formed
+// XML after a merge, so that broken artifacts are caught without a round-trip to the tenant.
+package designtime
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/engswee/flashpipe/internal/file"
+)
+
+// Severities of findings
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Checks of the validation
+const (
+	CheckStructure     = "structure"
+	CheckManifest      = "manifest"
+	CheckBPMN          = "bpmn"
+	CheckResource      = "missing-resource"
+	CheckMergeConflict = "merge-conflict"
+	CheckGroovy        = "groovy"
+)
+
+// Finding is a problem found in the content of an artifact
+type Finding struct {
+	Check    string `json:"check"`
+	Rule     string `json:"rule,omitempty"` // Rule of the Groovy checks
+	Severity string `json:"severity"`
+	Artifact string `json:"artifact"`
+	Dir      string `json:"-"` // Directory or zip file of the artifact
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f Finding) String() string {
+	location := f.Artifact
+	if f.File != "" {
+		location = filepath.Join(f.Artifact, f.File)
+	}
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, f.Line)
+	}
+	return fmt.Sprintf("%s: %s [%s] %s", location, f.Severity, f.RuleID(), f.Message)
+}
+
+// RuleID identifies the check and, for Groovy checks, the rule of the finding
+func (f Finding) RuleID() string {
+	if f.Rule != "" {
+		return f.Check + "/" + f.Rule
+	}
+	return f.Check
+}
+
+// Options are the optional checks of a validation
+type Options struct {
+	GroovyRules []GroovyRule // Rules checked against Groovy scripts, no checks if empty
+}
+
+// integrationFlowDir is the directory of the BPMN model of an integration flow
+const integrationFlowDir = "src/main/resources/scenarioflows/integrationflow"
+
+// requiredManifestHeaders must be set in the MANIFEST.MF of all artifacts
+var requiredManifestHeaders = []string{"Manifest-Version", "Bundle-SymbolicName", "Bundle-Version", "Bundle-Name"}
+
+// bundleVersionPattern matches the OSGi versions accepted by the tenant
+var bundleVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(\.[\w-]+)?$`)
+
+// conflictMarkerPattern matches the lines Git adds around conflicting changes
+var conflictMarkerPattern = regexp.MustCompile(`^(<{7}|>{7})( |$)`)
+
+// resourceKeys are the properties of integration flow steps that reference a file of the artifact, with the
+// directory the file is in if only its name is set
+var resourceKeys = map[string]string{
+	"script":      "src/main/resources/script",
+	"mappinguri":  "src/main/resources/mapping",
+	"mappingpath": "src/main/resources/mapping",
+}
+
+// Validate checks the artifacts in a directory, a single artifact directory, or an artifact zip file as
+// downloaded from the tenant, and returns the findings ordered by artifact
+func Validate(path string, opts Options) ([]Finding, error) {
+	return CheckArtifacts(path, func(dir string, name string) ([]Finding, error) {
+		return ValidateArtifact(dir, name, opts)
+	})
+}
+
+// CheckFunc checks the content of the artifact in dir
+type CheckFunc func(dir string, name string) ([]Finding, error)
+
+// CheckArtifacts runs check for the artifacts in a directory, a single artifact directory, or an artifact
+// zip file, and returns the findings ordered by artifact. Zip files are extracted to a temporary directory,
+// the findings refer to the zip file.
+func CheckArtifacts(path string, check CheckFunc) ([]Finding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return checkZip(path, check)
+	}
+	if isArtifactDir(path) {
+		return check(path, filepath.Base(path))
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var findings []Finding
+	found := false
+	for _, entry := range entries {
+		dir := filepath.Join(path, entry.Name())
+		if !entry.IsDir() || !isArtifactDir(dir) {
+			continue
+		}
+		found = true
+		artifactFindings, err := check(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, artifactFindings...)
+	}
+	if !found {
+		return nil, fmt.Errorf("no artifacts found in %s, expected directories with META-INF or src/main/resources", path)
+	}
+	return findings, nil
+}
+
+// isArtifactDir returns true for directories that look like the content of an artifact, including artifacts
+// whose MANIFEST.MF was lost
+func isArtifactDir(dir string) bool {
+	return file.Exists(filepath.Join(dir, "META-INF")) || file.Exists(filepath.Join(dir, "src", "main", "resources"))
+}
+
+func checkZip(zipFile string, check CheckFunc) ([]Finding, error) {
+	if !strings.EqualFold(filepath.Ext(zipFile), ".zip") {
+		return nil, fmt.Errorf("%s is neither a directory nor a zip file", zipFile)
+	}
+	workDir, err := os.MkdirTemp("", "flashpipe-artifact-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := file.UnzipSource(zipFile, workDir); err != nil {
+		return []Finding{{Check: CheckStructure, Severity: SeverityError, Artifact: filepath.Base(zipFile), Dir: zipFile,
+			Message: fmt.Sprintf("not a valid zip file: %v", err)}}, nil
+	}
+	findings, err := check(workDir, strings.TrimSuffix(filepath.Base(zipFile), filepath.Ext(zipFile)))
+	// The extracted files are removed, findings refer to the zip file
+	for i := range findings {
+		findings[i].Dir = zipFile
+	}
+	return findings, err
+}
+
+// ValidateArtifact checks the content of one artifact. The name is used in the findings and compared to
+// the Bundle-SymbolicName.
+func ValidateArtifact(dir string, name string, opts Options) ([]Finding, error) {
+	v := &validator{dir: dir, name: name}
+	headers := v.checkManifest()
+	v.checkMergeConflicts()
+	if headers["SAP-BundleType"] == "IntegrationFlow" || headers["SAP-BundleType"] == "" && file.Exists(filepath.Join(dir, integrationFlowDir)) {
+		v.checkIntegrationFlow()
+	}
+	if len(opts.GroovyRules) > 0 {
+		v.checkGroovy(opts.GroovyRules)
+	}
+	if v.err != nil {
+		return nil, v.err
+	}
+	return v.findings, nil
+}
+
+type validator struct {
+	dir      string
+	name     string
+	findings []Finding
+	err      error
+}
+
+func (v *validator) add(check, severity, file string, line int, format string, a ...any) {
+	v.findings = append(v.findings, Finding{Check: check, Severity: severity, Artifact: v.name, Dir: v.dir, File: file, Line: line,
+		Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) checkManifest() map[string]string {
+	const manifest = "META-INF/MANIFEST.MF"
+	manifestPath := filepath.Join(v.dir, manifest)
+	if !file.Exists(manifestPath) {
+		v.add(CheckStructure, SeverityError, manifest, 0, "MANIFEST.MF not found")
+		return nil
+	}
+	headers, err := file.ReadManifest(manifestPath)
+	if err != nil {
+		v.err = fmt.Errorf("failed to read %s of %s: %w", manifest, v.name, err)
+		return nil
+	}
+	for _, header := range requiredManifestHeaders {
+		if strings.TrimSpace(headers[header]) == "" {
+			v.add(CheckManifest, SeverityError, manifest, 0, "%s is not set", header)
+		}
+	}
+	if version := strings.TrimSpace(headers["Bundle-Version"]); version != "" && !bundleVersionPattern.MatchString(version) {
+		v.add(CheckManifest, SeverityError, manifest, 0, "Bundle-Version %q is not in the format major.minor.patch", version)
+	}
+	symbolicName, _, _ := strings.Cut(headers["Bundle-SymbolicName"], ";")
+	if symbolicName = strings.TrimSpace(symbolicName); symbolicName != "" && symbolicName != v.name {
+		v.add(CheckManifest, SeverityWarning, manifest, 0, "Bundle-SymbolicName %s differs from the artifact directory %s", symbolicName, v.name)
+	}
+	return headers
+}
+
+// checkMergeConflicts reports conflict markers left in text files of the artifact
+func (v *validator) checkMergeConflicts() {
+	err := filepath.WalkDir(v.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// Skip binary files, e.g. archives and keystores
+		if bytes.IndexByte(content, 0) >= 0 {
+			return nil
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(nil, len(content)+1)
+		for line := 1; scanner.Scan(); line++ {
+			if conflictMarkerPattern.MatchString(strings.TrimRight(scanner.Text(), "\r")) {
+				v.add(CheckMergeConflict, SeverityError, v.rel(path), line, "merge conflict marker")
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		v.err = fmt.Errorf("failed to read content of %s: %w", v.name, err)
+	}
+}
+
+// checkIntegrationFlow checks that there is one well-formed BPMN model whose sequence flows connect existing
+// elements, and that the files referenced by its steps exist
+func (v *validator) checkIntegrationFlow() {
+	entries, err := os.ReadDir(filepath.Join(v.dir, integrationFlowDir))
+	if err != nil && !os.IsNotExist(err) {
+		v.err = fmt.Errorf("failed to read %s of %s: %w", integrationFlowDir, v.name, err)
+		return
+	}
+	var models []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".iflw") {
+			models = append(models, entry.Name())
+		}
+	}
+	switch len(models) {
+	case 0:
+		v.add(CheckStructure, SeverityError, integrationFlowDir, 0, "no integration flow model (.iflw) found")
+		return
+	case 1:
+	default:
+		v.add(CheckStructure, SeverityError, integrationFlowDir, 0, "%d integration flow models found, expected one: %s", len(models), strings.Join(models, ", "))
+	}
+	for _, model := range models {
+		v.checkBPMN(integrationFlowDir + "/" + model)
+	}
+}
+
+func (v *validator) checkBPMN(model string) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromFile(filepath.Join(v.dir, model)); err != nil {
+		v.add(CheckBPMN, SeverityError, model, 0, "not well-formed XML: %v", err)
+		return
+	}
+	root := doc.Root()
+	if root == nil || root.Tag != "definitions" {
+		v.add(CheckBPMN, SeverityError, model, 0, "root element is not a BPMN definitions element")
+		return
+	}
+	if len(root.SelectElements("process")) == 0 {
+		v.add(CheckBPMN, SeverityError, model, 0, "no process found")
+	}
+
+	ids := map[string]bool{}
+	for _, element := range root.FindElements("//*[@id]") {
+		ids[element.SelectAttrValue("id", "")] = true
+	}
+	for _, flow := range root.FindElements("//sequenceFlow") {
+		for _, ref := range []string{"sourceRef", "targetRef"} {
+			if target := flow.SelectAttrValue(ref, ""); target == "" || !ids[target] {
+				v.add(CheckBPMN, SeverityError, model, 0, "sequence flow %s: %s %q does not exist", flow.SelectAttrValue("id", ""), ref, target)
+			}
+		}
+	}
+
+	var missing []string
+	for _, step := range root.FindElements("//extensionElements") {
+		properties := map[string]string{}
+		for _, property := range step.SelectElements("property") {
+			if key, value := property.SelectElement("key"), property.SelectElement("value"); key != nil && value != nil {
+				properties[key.Text()] = value.Text()
+			}
+		}
+		// Scripts of a script collection are not part of the artifact
+		if properties["scriptBundleId"] != "" {
+			continue
+		}
+		for key, dir := range resourceKeys {
+			resource := resourcePath(properties[key], dir)
+			if resource != "" && !file.Exists(filepath.Join(v.dir, resource)) && !slices.Contains(missing, resource) {
+				missing = append(missing, resource)
+			}
+		}
+	}
+	slices.Sort(missing)
+	for _, resource := range missing {
+		v.add(CheckResource, SeverityError, model, 0, "referenced file %s not found", resource)
+	}
+}
+
+// resourcePath returns the path of a file referenced by a step relative to the artifact directory. Paths
+// with a scheme like dir:// are made relative, and references to other artifacts, e.g. of message mappings
+// in a package, are left out.
+func resourcePath(value string, dir string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if _, rest, found := strings.Cut(value, "://"); found {
+		if i := strings.Index(rest, "src/main/resources/"); i >= 0 {
+			return rest[i:]
+		}
+		return ""
+	}
+	if strings.Contains(value, "src/main/resources/") {
+		return value[strings.Index(value, "src/main/resources/"):]
+	}
+	if strings.Contains(value, "/") {
+		return ""
+	}
+	return dir + "/" + value
+}
+
+func (v *validator) rel(path string) string {
+	rel, err := filepath.Rel(v.dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// HasErrors returns true if one of the findings has severity error
+func HasErrors(findings []Finding) bool {
+	return slices.ContainsFunc(findings, func(f Finding) bool { return f.Severity == SeverityError })
+}
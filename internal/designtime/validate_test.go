@@ -0,0 +1,140 @@
+package designtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const manifest = `Manifest-Version: 1.0
+Bundle-SymbolicName: Orders; singleton:=true
+Bundle-Name: Orders
+Bundle-Version: 1.0.3
+SAP-BundleType: IntegrationFlow
+`
+
+const model = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn2:definitions xmlns:bpmn2="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:ifl="http:///com.sap.ifl.model/Ifl.xsd" id="Definitions_1">
+    <bpmn2:process id="Process_1">
+        <bpmn2:startEvent id="StartEvent_1"/>
+        <bpmn2:callActivity id="CallActivity_1">
+            <bpmn2:extensionElements>
+                <ifl:property>
+                    <key>script</key>
+                    <value>%s</value>
+                </ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:callActivity>
+        <bpmn2:endEvent id="EndEvent_1"/>
+        <bpmn2:sequenceFlow id="SequenceFlow_1" sourceRef="StartEvent_1" targetRef="CallActivity_1"/>
+        <bpmn2:sequenceFlow id="SequenceFlow_2" sourceRef="CallActivity_1" targetRef="%s"/>
+    </bpmn2:process>
+</bpmn2:definitions>
+`
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func writeArtifact(t *testing.T, dir string, script string, target string) {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, "META-INF", "MANIFEST.MF"), manifest)
+	writeFile(t, filepath.Join(dir, integrationFlowDir, "Orders.iflw"), fmt.Sprintf(model, script, target))
+	writeFile(t, filepath.Join(dir, "src", "main", "resources", "script", "enrich.groovy"), "def Message processData(Message message) { message }\n")
+}
+
+func checks(findings []Finding) []string {
+	var result []string
+	for _, f := range findings {
+		result = append(result, f.Check+":"+f.File)
+	}
+	return result
+}
+
+func TestValidateValidArtifact(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Orders")
+	writeArtifact(t, dir, "enrich.groovy", "EndEvent_1")
+
+	findings, err := Validate(dir, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	// A copy of an artifact whose MANIFEST.MF was not updated
+	copyDir := filepath.Join(filepath.Dir(dir), "Orders_Copy")
+	require.NoError(t, os.Rename(dir, copyDir))
+	findings, err = Validate(copyDir, Options{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityWarning, findings[0].Severity)
+	assert.Contains(t, findings[0].Message, "Bundle-SymbolicName Orders differs from the artifact directory Orders_Copy")
+	assert.False(t, HasErrors(findings))
+}
+
+func TestValidateTestdata(t *testing.T) {
+	findings, err := Validate("../../test/testdata/artifacts/collection/IFlow1", Options{})
+	require.NoError(t, err)
+	assert.False(t, HasErrors(findings), "findings: %v", findings)
+}
+
+func TestValidateBrokenArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	writeArtifact(t, filepath.Join(dir, "Orders"), "missing.groovy", "EndEvent_2")
+
+	writeArtifact(t, filepath.Join(dir, "Invoices"), "enrich.groovy", "EndEvent_1")
+	writeFile(t, filepath.Join(dir, "Invoices", "META-INF", "MANIFEST.MF"), "Manifest-Version: 1.0\nBundle-SymbolicName: Invoices\nBundle-Name: Invoices\nBundle-Version: 1.0\n")
+	writeFile(t, filepath.Join(dir, "Invoices", "src", "main", "resources", "script", "enrich.groovy"),
+		"<<<<<<< HEAD\ndef a = 1\n=======\ndef a = 2\n>>>>>>> feature\n")
+
+	writeArtifact(t, filepath.Join(dir, "Payments"), "enrich.groovy", "EndEvent_1")
+	require.NoError(t, os.Remove(filepath.Join(dir, "Payments", "META-INF", "MANIFEST.MF")))
+	writeFile(t, filepath.Join(dir, "Payments", integrationFlowDir, "Orders.iflw"), "<bpmn2:definitions><bpmn2:process>")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0o755))
+
+	findings, err := Validate(dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"manifest:META-INF/MANIFEST.MF",
+		"merge-conflict:src/main/resources/script/enrich.groovy",
+		"bpmn:" + integrationFlowDir + "/Orders.iflw",
+		"missing-resource:" + integrationFlowDir + "/Orders.iflw",
+		"structure:META-INF/MANIFEST.MF",
+		"bpmn:" + integrationFlowDir + "/Orders.iflw",
+	}, checks(findings))
+	assert.Equal(t, 1, findings[1].Line)
+	assert.Contains(t, findings[0].Message, `Bundle-Version "1.0"`)
+	assert.Contains(t, findings[2].Message, `targetRef "EndEvent_2" does not exist`)
+	assert.Contains(t, findings[3].Message, "src/main/resources/script/missing.groovy")
+	assert.Contains(t, findings[5].Message, "not well-formed XML")
+	assert.True(t, HasErrors(findings))
+}
+
+func TestValidateZip(t *testing.T) {
+	dir := t.TempDir()
+	writeArtifact(t, filepath.Join(dir, "Orders"), "enrich.groovy", "EndEvent_1")
+	zipFile := filepath.Join(dir, "Orders.zip")
+	require.NoError(t, file.ZipDir(filepath.Join(dir, "Orders"), zipFile, false))
+
+	findings, err := Validate(zipFile, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	writeFile(t, filepath.Join(dir, "broken.zip"), "not a zip")
+	findings, err = Validate(filepath.Join(dir, "broken.zip"), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"structure:"}, checks(findings))
+}
+
+func TestResourcePath(t *testing.T) {
+	assert.Equal(t, "src/main/resources/script/a.groovy", resourcePath("a.groovy", "src/main/resources/script"))
+	assert.Equal(t, "src/main/resources/mapping/M.mmap", resourcePath("dir://mmap/src/main/resources/mapping/M.mmap", "src/main/resources/mapping"))
+	assert.Equal(t, "", resourcePath("pd://Orders/MM/1.0.0", "src/main/resources/mapping"))
+	assert.Equal(t, "", resourcePath(" ", "src/main/resources/script"))
+}
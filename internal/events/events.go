@@ -0,0 +1,151 @@
+// Package events streams the progress of a run as newline-delimited JSON, e.g. for dashboards of
+// orchestration tools. Each event is written as soon as it happens, to a file or an inherited file descriptor.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/runid"
+)
+
+// Options configures the event stream. Events are disabled when File is empty.
+type Options struct {
+	// File the events are appended to, or fd:<n> for a file descriptor inherited from the parent process
+	File string
+}
+
+// Types of events
+const (
+	TypeRunStarted         = "run_started"
+	TypeRunFinished        = "run_finished"
+	TypeArtifactStarted    = "artifact_started"
+	TypeArtifactConfigured = "artifact_configured"
+	TypeArtifactDeployed   = "artifact_deployed"
+	TypeArtifactSkipped    = "artifact_skipped"
+	TypeArtifactFailed     = "artifact_failed"
+)
+
+// Phases of artifact events
+const (
+	PhaseConfigure = "configure"
+	PhaseDeploy    = "deploy"
+)
+
+// Event is a single step of a run
+type Event struct {
+	Seq          int64  `json:"seq"`
+	Time         string `json:"time"`
+	RunID        string `json:"runId,omitempty"`
+	Type         string `json:"type"`
+	Command      string `json:"command,omitempty"`
+	Tenant       string `json:"tenant,omitempty"`
+	Phase        string `json:"phase,omitempty"`
+	PackageID    string `json:"packageId,omitempty"`
+	ArtifactID   string `json:"artifactId,omitempty"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	DryRun       bool   `json:"dryRun,omitempty"`
+	DurationMs   int64  `json:"durationMs,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+var (
+	mu  sync.Mutex
+	out *os.File
+	seq int64
+)
+
+// Init opens the event stream, closing a stream opened before
+func Init(opts Options) error {
+	if err := Close(); err != nil {
+		return err
+	}
+	if opts.File == "" {
+		return nil
+	}
+	f, err := open(opts.File)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	out = f
+	seq = 0
+	return nil
+}
+
+func open(file string) (*os.File, error) {
+	if fd, ok := strings.CutPrefix(file, "fd:"); ok {
+		n, err := strconv.Atoi(fd)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid events file descriptor %q, expected fd:<number>", file)
+		}
+		f := os.NewFile(uintptr(n), file)
+		if f == nil {
+			return nil, fmt.Errorf("invalid events file descriptor %q", file)
+		}
+		return f, nil
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open events file: %w", err)
+	}
+	return f, nil
+}
+
+// Enabled returns true if events are written
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return out != nil
+}
+
+// Emit writes the event. The sequence number, time and run ID are set by Emit. Failed writes disable the stream, so
+// that a reader going away does not fail the run.
+func Emit(event Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		return
+	}
+	seq++
+	event.Seq = seq
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	event.RunID = runid.ID()
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if _, err = out.Write(append(line, '\n')); err != nil {
+		_ = out.Close()
+		out = nil
+	}
+}
+
+// Artifact emits an event of an artifact with the outcome err: the given type if err is nil, artifact_failed
+// otherwise
+func Artifact(eventType string, phase string, tenant string, packageID string, artifactID string, duration time.Duration, err error) {
+	event := Event{Type: eventType, Phase: phase, Tenant: tenant, PackageID: packageID, ArtifactID: artifactID, DurationMs: duration.Milliseconds()}
+	if err != nil {
+		event.Type = TypeArtifactFailed
+		event.Error = err.Error()
+	}
+	Emit(event)
+}
+
+// Close closes the event stream
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		return nil
+	}
+	err := out.Close()
+	out = nil
+	return err
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.jsonl")
+	require.NoError(t, Init(Options{File: file}))
+	defer Init(Options{})
+	assert.True(t, Enabled())
+
+	Emit(Event{Type: TypeRunStarted, Command: "flashpipe configure"})
+	Artifact(TypeArtifactConfigured, PhaseConfigure, "tenant", "Sales", "Orders", 1500*time.Millisecond, nil)
+	Artifact(TypeArtifactDeployed, PhaseDeploy, "tenant", "Sales", "Orders", time.Second, errors.New("deployment failed"))
+	require.NoError(t, Close())
+	assert.False(t, Enabled())
+	Emit(Event{Type: TypeRunFinished})
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3, "Events after closing are not written")
+
+	var events []Event
+	for _, line := range lines {
+		var e Event
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		events = append(events, e)
+	}
+	assert.Equal(t, int64(1), events[0].Seq)
+	assert.NotEmpty(t, events[0].Time)
+	assert.Equal(t, Event{Seq: 2, Time: events[1].Time, Type: TypeArtifactConfigured, Phase: PhaseConfigure, Tenant: "tenant",
+		PackageID: "Sales", ArtifactID: "Orders", DurationMs: 1500}, events[1])
+	assert.Equal(t, TypeArtifactFailed, events[2].Type)
+	assert.Equal(t, PhaseDeploy, events[2].Phase)
+	assert.Equal(t, "deployment failed", events[2].Error)
+}
+
+func TestEmitFileDescriptor(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.NoError(t, Init(Options{File: fmt.Sprintf("fd:%d", w.Fd())}))
+	Emit(Event{Type: TypeArtifactStarted, ArtifactID: "Orders"})
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	require.NoError(t, err, "Events are written immediately")
+	assert.Contains(t, line, `"type":"artifact_started","artifactId":"Orders"`)
+	require.NoError(t, Close())
+
+	assert.EqualError(t, Init(Options{File: "fd:three"}), `invalid events file descriptor "fd:three", expected fd:<number>`)
+}
@@ -0,0 +1,80 @@
+// Package exitreport writes a single JSON line describing the outcome of a command as the last line of stderr,
+// so that wrapper scripts can act on the outcome, e.g. with tail -n 1, without parsing the log or the report file.
+package exitreport
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/runid"
+)
+
+// Outcomes of a command
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Report is the outcome of a command
+type Report struct {
+	Type       string         `json:"type"` // Always "exit", to tell the line from log messages
+	Command    string         `json:"command"`
+	RunID      string         `json:"runId,omitempty"`
+	Outcome    string         `json:"outcome"`
+	ExitCode   int            `json:"exitCode"`
+	DurationMs int64          `json:"durationMs"`
+	Counts     map[string]int `json:"counts,omitempty"` // Totals of the command, e.g. artifactsDeployed
+	Report     string         `json:"report,omitempty"` // Path of the full report written by the command
+	Error      string         `json:"error,omitempty"`
+}
+
+var (
+	mu     sync.Mutex
+	counts = map[string]int{}
+	report string
+)
+
+// Add adds n to the total name of the command
+func Add(name string, n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	counts[name] += n
+}
+
+// SetReport records the path of the full report written by the command
+func SetReport(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	report = path
+}
+
+// Write writes the report of command as one JSON line to w. The exit code is 1 if err is not nil.
+func Write(w io.Writer, command string, duration time.Duration, err error) error {
+	mu.Lock()
+	defer mu.Unlock()
+	r := Report{Type: "exit", Command: command, RunID: runid.ID(), Outcome: OutcomeSuccess, DurationMs: duration.Milliseconds(), Report: report}
+	if len(counts) > 0 {
+		r.Counts = counts
+	}
+	if err != nil {
+		r.Outcome = OutcomeFailure
+		r.ExitCode = 1
+		r.Error = err.Error()
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// Reset clears the totals and the report path
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	counts = map[string]int{}
+	report = ""
+}
@@ -0,0 +1,36 @@
+package exitreport
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/runid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	defer Reset()
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, "flashpipe deploy", 1500*time.Millisecond, nil))
+	assert.Equal(t, `{"type":"exit","command":"flashpipe deploy","outcome":"success","exitCode":0,"durationMs":1500}`+"\n", out.String())
+
+	Add("artifactsDeployed", 2)
+	Add("artifactsDeployed", 1)
+	Add("artifactsFailed", 1)
+	SetReport("report.json")
+	out.Reset()
+	require.NoError(t, Write(&out, "flashpipe configure", time.Second, errors.New("configuration/deployment completed with errors")))
+	assert.Equal(t, `{"type":"exit","command":"flashpipe configure","outcome":"failure","exitCode":1,"durationMs":1000,`+
+		`"counts":{"artifactsDeployed":3,"artifactsFailed":1},"report":"report.json","error":"configuration/deployment completed with errors"}`+"\n", out.String())
+}
+
+func TestWriteRunID(t *testing.T) {
+	defer runid.Set("")
+	require.NoError(t, runid.Set("pipeline-42"))
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, "flashpipe deploy", time.Second, nil))
+	assert.Equal(t, `{"type":"exit","command":"flashpipe deploy","runId":"pipeline-42","outcome":"success","exitCode":0,"durationMs":1000}`+"\n", out.String())
+}
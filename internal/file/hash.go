@@ -0,0 +1,126 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/go-errors/errors"
+)
+
+// Kinds of FileChange
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+// FileChange is a file that differs between two directories, by its path relative to them
+type FileChange struct {
+	Path string
+	Kind string
+}
+
+// HashDirectory returns the SHA-256 hashes of the normalized content of the files at paths, relative to dir, by
+// their slash separated path relative to dir. Paths may be files or directories, paths that do not exist are
+// skipped. The content is normalized like DiffDirectories compares it, see NormalizeContent. parameters.prop and
+// .DS_Store are skipped.
+func HashDirectory(dir string, paths ...string) (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, path := range paths {
+		root := filepath.Join(dir, filepath.FromSlash(path))
+		if !Exists(root) {
+			continue
+		}
+		err := filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || d.Name() == "parameters.prop" || d.Name() == ".DS_Store" {
+				return nil
+			}
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(dir, filePath)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(NormalizeContent(d.Name(), content))
+			hashes[filepath.ToSlash(relPath)] = hex.EncodeToString(sum[:])
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, 0)
+		}
+	}
+	return hashes, nil
+}
+
+// NormalizeContent removes the differences of the content of a file that are ignored when comparing artifacts:
+// line endings, white space, blank lines, lines starting with Origin (added to MANIFEST.MF by the tenant) and
+// comment lines of .prop files (containing the timestamp of the download). Binary content is returned unchanged.
+func NormalizeContent(name string, content []byte) []byte {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return content
+	}
+	isProp := strings.HasSuffix(name, ".prop")
+	var normalized bytes.Buffer
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line)
+		if len(line) == 0 || bytes.HasPrefix(line, []byte("Origin")) || (isProp && line[0] == '#') {
+			continue
+		}
+		normalized.Write(line)
+		normalized.WriteByte('\n')
+	}
+	return normalized.Bytes()
+}
+
+// DiffHashes returns the files that are added, removed or modified in the hashes of the source compared to the
+// hashes of the target, sorted by path
+func DiffHashes(source map[string]string, target map[string]string) []FileChange {
+	var changes []FileChange
+	for path, hash := range source {
+		targetHash, ok := target[path]
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{Path: path, Kind: FileAdded})
+		case targetHash != hash:
+			changes = append(changes, FileChange{Path: path, Kind: FileModified})
+		}
+	}
+	for path := range target {
+		if _, ok := source[path]; !ok {
+			changes = append(changes, FileChange{Path: path, Kind: FileRemoved})
+		}
+	}
+	slices.SortFunc(changes, func(a, b FileChange) int { return strings.Compare(a.Path, b.Path) })
+	return changes
+}
+
+// DiffDirectoryHashes returns the files at paths that differ between the source and target directory, comparing
+// the hashes of their normalized content
+func DiffDirectoryHashes(sourceDir string, targetDir string, paths ...string) ([]FileChange, error) {
+	sourceHashes, err := HashDirectory(sourceDir, paths...)
+	if err != nil {
+		return nil, err
+	}
+	targetHashes, err := HashDirectory(targetDir, paths...)
+	if err != nil {
+		return nil, err
+	}
+	return DiffHashes(sourceHashes, targetHashes), nil
+}
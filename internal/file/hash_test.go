@@ -0,0 +1,65 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDirectoryHashes_SameIgnoringOrigin(t *testing.T) {
+	changes, err := DiffDirectoryHashes("../../test/testdata/DiffComparison/Dir1/", "../../test/testdata/DiffComparison/Dir2/", "MANIFEST.MF")
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = DiffDirectoryHashes("../../test/testdata/DiffComparison/Dir1/", "../../test/testdata/DiffComparison/Dir3/", "MANIFEST.MF")
+	require.NoError(t, err)
+	assert.Equal(t, []FileChange{{Path: "MANIFEST.MF", Kind: FileModified}}, changes)
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestDiffDirectoryHashes(t *testing.T) {
+	source := writeFiles(t, map[string]string{
+		"META-INF/MANIFEST.MF":                    "Bundle-Name: Orders\r\nBundle-Version: 1.0.1\r\n",
+		"metainfo.prop":                           "#Wed Oct 14 10:00:00 UTC 2026\ndescription=Orders\n",
+		"src/main/resources/parameters.prop":      "Receiver=https://qa.example.com\n",
+		"src/main/resources/script/map.groovy":    "def map() {\n\n  return 1\n}\n",
+		"src/main/resources/script/new.groovy":    "def added() {}\n",
+		"src/main/resources/scenarioflows/a.iflw": "<bpmn/>",
+		"QA/parameters.prop":                      "ignored",
+	})
+	target := writeFiles(t, map[string]string{
+		"META-INF/MANIFEST.MF":                    "Bundle-Name: Orders\nBundle-Version: 1.0.0\nOrigin-Bundle-Name: Orders\n",
+		"metainfo.prop":                           "#Thu Oct 15 10:00:00 UTC 2026\ndescription=Orders\n",
+		"src/main/resources/parameters.prop":      "Receiver=https://prd.example.com\n",
+		"src/main/resources/script/map.groovy":    "def map() {\n    return 1\n}\n",
+		"src/main/resources/script/old.groovy":    "def removed() {}\n",
+		"src/main/resources/scenarioflows/a.iflw": "<bpmn/>",
+	})
+
+	changes, err := DiffDirectoryHashes(source, target, "META-INF", "src/main/resources", "metainfo.prop", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, []FileChange{
+		{Path: "META-INF/MANIFEST.MF", Kind: FileModified},
+		{Path: "src/main/resources/script/new.groovy", Kind: FileAdded},
+		{Path: "src/main/resources/script/old.groovy", Kind: FileRemoved},
+	}, changes)
+}
+
+func TestNormalizeContent(t *testing.T) {
+	assert.Equal(t, "a=1\n", string(NormalizeContent("metainfo.prop", []byte("# comment\r\n a = 1 \r\n\r\n"))))
+	assert.Equal(t, "#!/bin/sh\n", string(NormalizeContent("run.sh", []byte("#!/bin/sh\n"))))
+	binary := []byte{0x50, 0x4b, 0x00, 0x0a, 0x20}
+	assert.Equal(t, binary, NormalizeContent("lib.jar", binary))
+}
@@ -0,0 +1,139 @@
+package governance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/engswee/flashpipe/internal/file"
+)
+
+// CheckGovernance is the check of the findings of governance rules
+const CheckGovernance = "governance"
+
+const (
+	manifestFile       = "META-INF/MANIFEST.MF"
+	integrationFlowDir = "src/main/resources/scenarioflows/integrationflow"
+)
+
+// element is an element of an artifact that rules are checked against
+type element struct {
+	scope      string
+	name       string
+	file       string
+	properties map[string]string
+	attributes map[string]string
+}
+
+// Check checks the artifacts in a directory, a single artifact directory, or an artifact zip file against
+// the rules, and returns the findings ordered by artifact
+func Check(path string, rules []Rule) ([]designtime.Finding, error) {
+	return designtime.CheckArtifacts(path, func(dir string, name string) ([]designtime.Finding, error) {
+		return CheckArtifact(dir, name, rules)
+	})
+}
+
+// CheckArtifact checks the content of one artifact against the rules
+func CheckArtifact(dir string, name string, rules []Rule) ([]designtime.Finding, error) {
+	elements, err := readElements(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	var findings []designtime.Finding
+	for _, e := range elements {
+		for _, rule := range rules {
+			if e.scope != rule.Scope || !matches(rule.Match, e) {
+				continue
+			}
+			for _, condition := range rule.Assert {
+				failure, ok := condition.check(e)
+				if ok {
+					continue
+				}
+				message := failure
+				if rule.Description != "" {
+					message = fmt.Sprintf("%s (%s)", rule.Description, failure)
+				}
+				findings = append(findings, designtime.Finding{Check: CheckGovernance, Rule: rule.ID, Severity: rule.Severity,
+					Artifact: name, Dir: dir, File: e.file, Message: fmt.Sprintf("%s %s: %s", e.scope, e.name, message)})
+			}
+		}
+	}
+	return findings, nil
+}
+
+func matches(conditions []Condition, e *element) bool {
+	for _, condition := range conditions {
+		if _, ok := condition.check(e); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// readElements returns the artifact, and for integration flows the flow, its channels and steps
+func readElements(dir string, name string) ([]*element, error) {
+	artifact := &element{scope: ScopeArtifact, name: name, file: manifestFile, properties: map[string]string{},
+		attributes: map[string]string{"name": name}}
+	if manifestPath := filepath.Join(dir, manifestFile); file.Exists(manifestPath) {
+		headers, err := file.ReadManifest(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s of %s: %w", manifestFile, name, err)
+		}
+		artifact.properties = headers
+	}
+	elements := []*element{artifact}
+
+	entries, err := os.ReadDir(filepath.Join(dir, integrationFlowDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s of %s: %w", integrationFlowDir, name, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".iflw") {
+			continue
+		}
+		model := integrationFlowDir + "/" + entry.Name()
+		doc := etree.NewDocument()
+		// Models that are not well-formed are reported by artifact validate
+		if err := doc.ReadFromFile(filepath.Join(dir, model)); err != nil || doc.Root() == nil {
+			continue
+		}
+		root := doc.Root()
+		for _, collaboration := range root.SelectElements("collaboration") {
+			elements = append(elements, newElement(ScopeFlow, name, model, collaboration))
+			for _, channel := range collaboration.SelectElements("messageFlow") {
+				elements = append(elements, newElement(ScopeChannel, "", model, channel))
+			}
+		}
+		for _, process := range root.SelectElements("process") {
+			for _, step := range process.ChildElements() {
+				if step.SelectElement("extensionElements") != nil {
+					elements = append(elements, newElement(ScopeStep, "", model, step))
+				}
+			}
+		}
+	}
+	return elements, nil
+}
+
+// newElement returns an element of the integration flow model, named by its name or ID if name is empty
+func newElement(scope string, name string, model string, e *etree.Element) *element {
+	if name == "" {
+		name = e.SelectAttrValue("name", e.SelectAttrValue("id", ""))
+	}
+	result := &element{scope: scope, name: name, file: model, properties: map[string]string{}, attributes: map[string]string{}}
+	for _, attr := range e.Attr {
+		result.attributes[attr.Key] = attr.Value
+	}
+	if extensions := e.SelectElement("extensionElements"); extensions != nil {
+		for _, property := range extensions.SelectElements("property") {
+			if key, value := property.SelectElement("key"), property.SelectElement("value"); key != nil && value != nil {
+				result.properties[key.Text()] = value.Text()
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package governance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/designtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const model = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn2:definitions xmlns:bpmn2="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:ifl="http:///com.sap.ifl.model/Ifl.xsd" id="Definitions_1">
+    <bpmn2:collaboration id="Collaboration_1" name="Default Collaboration">
+        <bpmn2:extensionElements>
+            <ifl:property><key>log</key><value>All events</value></ifl:property>
+        </bpmn2:extensionElements>
+        <bpmn2:messageFlow id="MessageFlow_1" name="Orders In" sourceRef="Participant_1" targetRef="StartEvent_1">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTPS</value></ifl:property>
+                <ifl:property><key>direction</key><value>Sender</value></ifl:property>
+                <ifl:property><key>xsrfProtection</key><value>0</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+        <bpmn2:messageFlow id="MessageFlow_2" name="ERP" sourceRef="ServiceTask_1" targetRef="Participant_2">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>HTTP</value></ifl:property>
+                <ifl:property><key>direction</key><value>Receiver</value></ifl:property>
+                <ifl:property><key>httpAddressWithoutQuery</key><value>http://erp.example.com/orders</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+        <bpmn2:messageFlow id="MessageFlow_3" name="CRM" sourceRef="ServiceTask_2" targetRef="Participant_3">
+            <bpmn2:extensionElements>
+                <ifl:property><key>ComponentType</key><value>SOAP</value></ifl:property>
+                <ifl:property><key>direction</key><value>Receiver</value></ifl:property>
+                <ifl:property><key>address</key><value>https://crm.example.com/soap</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:messageFlow>
+    </bpmn2:collaboration>
+    <bpmn2:process id="Process_1">
+        <bpmn2:callActivity id="CallActivity_1" name="Enrich">
+            <bpmn2:extensionElements>
+                <ifl:property><key>activityType</key><value>Script</value></ifl:property>
+            </bpmn2:extensionElements>
+        </bpmn2:callActivity>
+    </bpmn2:process>
+</bpmn2:definitions>
+`
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func messages(findings []designtime.Finding) []string {
+	var result []string
+	for _, f := range findings {
+		result = append(result, f.Rule+": "+f.Message)
+	}
+	return result
+}
+
+func TestCheckBuiltinRules(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Orders")
+	writeFile(t, filepath.Join(dir, manifestFile), "Manifest-Version: 1.0\nBundle-SymbolicName: Orders\nBundle-Version: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, integrationFlowDir, "Orders.iflw"), model)
+
+	rules, err := LoadRules("")
+	require.NoError(t, err)
+	findings, err := Check(dir, rules)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`csrf-https-sender: channel Orders In: HTTPS sender channels must enable CSRF protection (property xsrfProtection "0" does not match ^(1|true)$)`,
+		`channel-naming: channel Orders In: channel names consist of letters, digits, underscores and dashes (attribute name "Orders In" does not match ^[A-Za-z][A-Za-z0-9_-]*$)`,
+		`no-plain-http-receiver: channel ERP: receiver channels must call HTTPS endpoints (property httpAddressWithoutQuery "http://erp.example.com/orders" matches (?i)^http://)`,
+	}, messages(findings))
+	assert.Equal(t, integrationFlowDir+"/Orders.iflw", findings[0].File)
+	assert.Equal(t, designtime.SeverityError, findings[0].Severity)
+	assert.Equal(t, designtime.SeverityWarning, findings[1].Severity)
+	assert.Equal(t, "governance/csrf-https-sender", findings[0].RuleID())
+}
+
+func TestCheckRuleSet(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Orders")
+	writeFile(t, filepath.Join(dir, manifestFile), "Manifest-Version: 1.0\nBundle-SymbolicName: Orders\nBundle-Version: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, integrationFlowDir, "Orders.iflw"), model)
+	rulesFile := filepath.Join(t.TempDir(), "governance.yml")
+	writeFile(t, rulesFile, `rules:
+  - id: channel-naming
+    severity: off
+  - id: csrf-https-sender
+    severity: warning
+  - id: artifact-prefix
+    scope: artifact
+    assert:
+      - attribute: name
+        pattern: ^Z_
+  - id: no-log-all-events
+    description: Log level All events is only for troubleshooting
+    severity: error
+    scope: flow
+    assert:
+      - property: log
+        notPattern: All events
+  - id: script-naming
+    scope: step
+    match:
+      - property: activityType
+        pattern: ^Script$
+    assert:
+      - attribute: name
+        pattern: ^Script_
+`)
+	rules, err := LoadRules(rulesFile)
+	require.NoError(t, err)
+	findings, err := Check(dir, rules)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`artifact-prefix: artifact Orders: attribute name "Orders" does not match ^Z_`,
+		`no-log-all-events: flow Orders: Log level All events is only for troubleshooting (property log "All events" matches All events)`,
+		`csrf-https-sender: channel Orders In: HTTPS sender channels must enable CSRF protection (property xsrfProtection "0" does not match ^(1|true)$)`,
+		`no-plain-http-receiver: channel ERP: receiver channels must call HTTPS endpoints (property httpAddressWithoutQuery "http://erp.example.com/orders" matches (?i)^http://)`,
+		`script-naming: step Enrich: attribute name "Enrich" does not match ^Script_`,
+	}, messages(findings))
+	assert.Equal(t, manifestFile, findings[0].File)
+	assert.Equal(t, designtime.SeverityWarning, findings[2].Severity)
+
+	// The built-in rules are not changed by rule sets
+	assert.Equal(t, designtime.SeverityError, BuiltinRules[1].Severity)
+}
+
+func TestLoadRulesInvalid(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "governance.yml")
+	for content, expected := range map[string]string{
+		"rules:\n  - id: a\n": "rule a: assert is required",
+		"rules:\n  - id: a\n    scope: package\n    assert: []\n":                    `rule a: invalid scope "package"`,
+		"rules:\n  - id: a\n    assert:\n      - pattern: x\n":                       "rule a: condition must set either property or attribute",
+		"rules:\n  - id: a\n    assert:\n      - property: x\n":                      "rule a: condition on property x must set pattern or notPattern",
+		"rules:\n  - id: a\n    assert:\n      - attribute: x\n        pattern: (\n": "rule a: invalid pattern",
+		"rules:\n  - assert:\n      - property: x\n        pattern: x\n":             "rule without id",
+	} {
+		writeFile(t, rulesFile, content)
+		_, err := LoadRules(rulesFile)
+		assert.ErrorContains(t, err, expected)
+	}
+}
@@ -0,0 +1,197 @@
+// Package governance checks the designtime content of artifacts against governance rules, e.g. that receiver
+// channels do not call plain HTTP endpoints. Rules select the elements of an artifact, like channels or steps,
+// by their properties and assert conditions on them. Rule sets are defined in YAML.
+package governance
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/engswee/flashpipe/internal/designtime"
+	"gopkg.in/yaml.v3"
+)
+
+// Scopes are the elements of an artifact a rule is checked against
+const (
+	ScopeArtifact = "artifact" // The artifact, with the headers of MANIFEST.MF as properties
+	ScopeFlow     = "flow"     // The integration flow, with the properties of the integration flow model
+	ScopeChannel  = "channel"  // Sender and receiver channels
+	ScopeStep     = "step"     // Steps of the processes, e.g. scripts and mappings
+)
+
+var scopes = []string{ScopeArtifact, ScopeFlow, ScopeChannel, ScopeStep}
+
+// RuleSet is the content of a rule set file
+type RuleSet struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule reports the elements of its scope that are selected by all conditions of Match, and fail a
+// condition of Assert
+type Rule struct {
+	ID          string      `yaml:"id"`
+	Description string      `yaml:"description,omitempty"`
+	Severity    string      `yaml:"severity,omitempty"` // off, warning or error, defaults to warning
+	Scope       string      `yaml:"scope,omitempty"`    // artifact, flow, channel or step, defaults to channel
+	Match       []Condition `yaml:"match,omitempty"`
+	Assert      []Condition `yaml:"assert,omitempty"`
+}
+
+// Condition checks a property or an attribute of an element, e.g. the name of a channel. Missing properties
+// and attributes have an empty value.
+type Condition struct {
+	Property   string `yaml:"property,omitempty"`
+	Attribute  string `yaml:"attribute,omitempty"`
+	Pattern    string `yaml:"pattern,omitempty"`    // Regular expression the value must match
+	NotPattern string `yaml:"notPattern,omitempty"` // Regular expression the value must not match
+	pattern    *regexp.Regexp
+	notPattern *regexp.Regexp
+}
+
+// BuiltinRules are the rules checked by default
+var BuiltinRules = []Rule{
+	{ID: "no-plain-http-receiver", Severity: designtime.SeverityError, Scope: ScopeChannel,
+		Description: "receiver channels must call HTTPS endpoints",
+		Match:       []Condition{{Property: "direction", Pattern: `^Receiver$`}},
+		Assert: []Condition{
+			{Property: "httpAddressWithoutQuery", NotPattern: `(?i)^http://`},
+			{Property: "address", NotPattern: `(?i)^http://`},
+		}},
+	{ID: "csrf-https-sender", Severity: designtime.SeverityError, Scope: ScopeChannel,
+		Description: "HTTPS sender channels must enable CSRF protection",
+		Match:       []Condition{{Property: "direction", Pattern: `^Sender$`}, {Property: "ComponentType", Pattern: `^HTTPS$`}},
+		Assert:      []Condition{{Property: "xsrfProtection", Pattern: `^(1|true)$`}}},
+	{ID: "channel-naming", Severity: designtime.SeverityWarning, Scope: ScopeChannel,
+		Description: "channel names consist of letters, digits, underscores and dashes",
+		Assert:      []Condition{{Attribute: "name", Pattern: `^[A-Za-z][A-Za-z0-9_-]*$`}}},
+}
+
+// LoadRules returns the built-in rules changed by a rule set file, or the built-in rules if path is empty.
+// Rules of the file with the ID of a built-in rule replace the settings they set, e.g. severity off disables
+// it, all others are added.
+func LoadRules(path string) ([]Rule, error) {
+	rules := slices.Clone(BuiltinRules)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule set: %w", err)
+		}
+		ruleSet := new(RuleSet)
+		if err := yaml.Unmarshal(data, ruleSet); err != nil {
+			return nil, fmt.Errorf("failed to parse rule set %s: %w", path, err)
+		}
+		for _, rule := range ruleSet.Rules {
+			i := slices.IndexFunc(rules, func(r Rule) bool { return r.ID == rule.ID })
+			if i < 0 {
+				rules = append(rules, rule)
+				continue
+			}
+			builtin := &rules[i]
+			if rule.Description != "" {
+				builtin.Description = rule.Description
+			}
+			if rule.Severity != "" {
+				builtin.Severity = rule.Severity
+			}
+			if rule.Scope != "" {
+				builtin.Scope = rule.Scope
+			}
+			if rule.Match != nil {
+				builtin.Match = rule.Match
+			}
+			if rule.Assert != nil {
+				builtin.Assert = rule.Assert
+			}
+		}
+	}
+
+	var enabled []Rule
+	for _, rule := range rules {
+		if err := compileRule(&rule); err != nil {
+			return nil, err
+		}
+		if rule.Severity != designtime.SeverityOff {
+			enabled = append(enabled, rule)
+		}
+	}
+	return enabled, nil
+}
+
+func compileRule(rule *Rule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("rule without id")
+	}
+	if rule.Severity == "" {
+		rule.Severity = designtime.SeverityWarning
+	}
+	if rule.Scope == "" {
+		rule.Scope = ScopeChannel
+	}
+	switch rule.Severity {
+	case designtime.SeverityOff, designtime.SeverityWarning, designtime.SeverityError:
+	default:
+		return fmt.Errorf("rule %s: invalid severity %q (valid severities: off, warning, error)", rule.ID, rule.Severity)
+	}
+	if !slices.Contains(scopes, rule.Scope) {
+		return fmt.Errorf("rule %s: invalid scope %q (valid scopes: %v)", rule.ID, rule.Scope, scopes)
+	}
+	if len(rule.Assert) == 0 {
+		return fmt.Errorf("rule %s: assert is required", rule.ID)
+	}
+	// Conditions are shared with BuiltinRules until they are copied
+	rule.Match = slices.Clone(rule.Match)
+	rule.Assert = slices.Clone(rule.Assert)
+	for _, conditions := range [][]Condition{rule.Match, rule.Assert} {
+		for i := range conditions {
+			if err := conditions[i].compile(); err != nil {
+				return fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Condition) compile() error {
+	if (c.Property == "") == (c.Attribute == "") {
+		return fmt.Errorf("condition must set either property or attribute")
+	}
+	if c.Pattern == "" && c.NotPattern == "" {
+		return fmt.Errorf("condition on %s must set pattern or notPattern", c.subject())
+	}
+	var err error
+	if c.Pattern != "" {
+		if c.pattern, err = regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if c.NotPattern != "" {
+		if c.notPattern, err = regexp.Compile(c.NotPattern); err != nil {
+			return fmt.Errorf("invalid notPattern: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Condition) subject() string {
+	if c.Attribute != "" {
+		return "attribute " + c.Attribute
+	}
+	return "property " + c.Property
+}
+
+// check returns a description of the failure if the value of the element does not satisfy the condition
+func (c *Condition) check(e *element) (string, bool) {
+	value := e.properties[c.Property]
+	if c.Attribute != "" {
+		value = e.attributes[c.Attribute]
+	}
+	if c.pattern != nil && !c.pattern.MatchString(value) {
+		return fmt.Sprintf("%s %q does not match %s", c.subject(), value, c.Pattern), false
+	}
+	if c.notPattern != nil && c.notPattern.MatchString(value) {
+		return fmt.Sprintf("%s %q matches %s", c.subject(), value, c.NotPattern), false
+	}
+	return "", true
+}
@@ -0,0 +1,367 @@
+// Package history keeps the statistics and per-artifact outcomes of configure runs in a local SQLite database,
+// so that runs can be listed and compared to find artifacts that start failing or slowing down.
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/go-errors/errors"
+)
+
+// DefaultDatabase is the history database used when none is configured, relative to the home directory
+const DefaultDatabase = ".flashpipe/history.db"
+
+// Shell is the SQLite command line shell the database is accessed with, as the module has no SQLite driver
+// that builds without cgo
+const Shell = "sqlite3"
+
+// schema creates the tables of the database. runs holds the statistics of each run as JSON without the artifact
+// results, artifacts the result of each artifact in a phase of a run, with its outcome and duration as columns for
+// trend queries with SQL and the complete result as JSON.
+const schema = `CREATE TABLE IF NOT EXISTS runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  started TEXT NOT NULL,
+  command TEXT NOT NULL,
+  tenant TEXT NOT NULL,
+  dry_run INTEGER NOT NULL,
+  error TEXT,
+  stats TEXT
+);
+CREATE TABLE IF NOT EXISTS artifacts (
+  run_id INTEGER NOT NULL REFERENCES runs (id),
+  package_id TEXT NOT NULL,
+  artifact_id TEXT NOT NULL,
+  phase TEXT NOT NULL,
+  duration_ms INTEGER NOT NULL,
+  error TEXT,
+  result TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS artifacts_artifact_id ON artifacts (artifact_id, phase);
+`
+
+// Run is a configure run on one tenant
+type Run struct {
+	ID      int              `json:"id"`
+	Started time.Time        `json:"started"`
+	Command string           `json:"command"`
+	Tenant  string           `json:"tenant"`
+	DryRun  bool             `json:"dryRun"`
+	Error   string           `json:"error,omitempty"`
+	Stats   *flashpipe.Stats `json:"stats"`
+}
+
+// Failed returns true if the run returned an error
+func (r *Run) Failed() bool {
+	return r.Error != ""
+}
+
+// Store is a SQLite history database. It is accessed with the sqlite3 command line shell, which has to be
+// on the PATH.
+type Store struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewStore returns a Store for the history database at path. A leading ~ is expanded to the home directory. The
+// sqlite3 shell is looked up here, so that a missing shell fails before a run instead of when it is recorded.
+func NewStore(path string) (*Store, error) {
+	if path == "" {
+		path = "~/" + DefaultDatabase
+	}
+	if _, err := exec.LookPath(Shell); err != nil {
+		return nil, fmt.Errorf("history database %s requires the SQLite command line shell %s on the PATH: %w", path, Shell, err)
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrap(err, 0)
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	s := new(Store)
+	s.path = path
+	return s, nil
+}
+
+// Path returns the location of the history database
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append adds the run to the history database, which is created if it does not exist, and sets its ID
+func (s *Store) Append(run *Run) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats, err := statsWithoutArtifacts(run.Stats)
+	if err != nil {
+		return err
+	}
+	var sql strings.Builder
+	sql.WriteString(schema)
+	// The immediate transaction locks the database, so that max(id) is the ID of the run inserted
+	sql.WriteString("BEGIN IMMEDIATE;\n")
+	fmt.Fprintf(&sql, "INSERT INTO runs (started, command, tenant, dry_run, error, stats) VALUES (%s, %s, %s, %d, %s, %s);\n",
+		quote(run.Started.UTC().Format(time.RFC3339Nano)), quote(run.Command), quote(run.Tenant), boolInt(run.DryRun),
+		quoteOrNull(run.Error), quoteOrNull(stats))
+	if run.Stats != nil {
+		for _, r := range run.Stats.Artifacts {
+			result, err := json.Marshal(r)
+			if err != nil {
+				return errors.Wrap(err, 0)
+			}
+			fmt.Fprintf(&sql, "INSERT INTO artifacts (run_id, package_id, artifact_id, phase, duration_ms, error, result) VALUES ((SELECT max(id) FROM runs), %s, %s, %s, %d, %s, %s);\n",
+				quote(r.PackageID), quote(r.ArtifactID), quote(r.Phase), r.DurationMs, quoteOrNull(r.Error), quote(string(result)))
+		}
+	}
+	sql.WriteString("SELECT max(id) AS id FROM runs;\nCOMMIT;\n")
+
+	if err = os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return errors.Wrap(err, 0)
+	}
+	var rows []struct {
+		ID int `json:"id"`
+	}
+	if err = s.query(sql.String(), &rows); err != nil {
+		return err
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("no ID returned for the run recorded in %s", s.path)
+	}
+	run.ID = rows[0].ID
+	return nil
+}
+
+// statsWithoutArtifacts returns the statistics as JSON without the artifact results, which are kept in the artifacts
+// table, or an empty string without statistics
+func statsWithoutArtifacts(stats *flashpipe.Stats) (string, error) {
+	if stats == nil {
+		return "", nil
+	}
+	content, err := json.Marshal(stats)
+	if err != nil {
+		return "", errors.Wrap(err, 0)
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(content, &fields); err != nil {
+		return "", errors.Wrap(err, 0)
+	}
+	delete(fields, "artifacts")
+	if content, err = json.Marshal(fields); err != nil {
+		return "", errors.Wrap(err, 0)
+	}
+	return string(content), nil
+}
+
+// Runs returns all runs of the history database, oldest first. A missing database has no runs.
+func (s *Store) Runs() ([]*Run, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.read("")
+}
+
+// Get returns the run with the given ID
+func (s *Store) Get(id int) (*Run, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	runs, err := s.read("WHERE id = " + strconv.Itoa(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("run %d not found in %s", id, s.path)
+	}
+	return runs[0], nil
+}
+
+// runRow is a row of the runs table as written by the sqlite3 shell in JSON mode
+type runRow struct {
+	ID      int     `json:"id"`
+	Started string  `json:"started"`
+	Command string  `json:"command"`
+	Tenant  string  `json:"tenant"`
+	DryRun  int     `json:"dry_run"`
+	Error   *string `json:"error"`
+	Stats   *string `json:"stats"`
+}
+
+// artifactRow is a row of the artifacts table as written by the sqlite3 shell in JSON mode
+type artifactRow struct {
+	RunID  int    `json:"run_id"`
+	Result string `json:"result"`
+}
+
+// read returns the runs matching the where clause, ordered by ID, with the artifact results of the artifacts table
+func (s *Store) read(where string) ([]*Run, error) {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var rows []runRow
+	if err := s.query(fmt.Sprintf("SELECT id, started, command, tenant, dry_run, error, stats FROM runs %s ORDER BY id;\n", where), &rows); err != nil {
+		return nil, err
+	}
+	runs := make([]*Run, len(rows))
+	byID := map[int]*Run{}
+	for i, row := range rows {
+		started, err := time.Parse(time.RFC3339Nano, row.Started)
+		if err != nil {
+			return nil, fmt.Errorf("%s: run %d: %w", s.path, row.ID, err)
+		}
+		run := &Run{ID: row.ID, Started: started, Command: row.Command, Tenant: row.Tenant, DryRun: row.DryRun != 0}
+		if row.Error != nil {
+			run.Error = *row.Error
+		}
+		if row.Stats != nil {
+			if err := json.Unmarshal([]byte(*row.Stats), &run.Stats); err != nil {
+				return nil, fmt.Errorf("%s: run %d: %w", s.path, row.ID, err)
+			}
+		}
+		runs[i] = run
+		byID[row.ID] = run
+	}
+
+	var artifactRows []artifactRow
+	if err := s.query(fmt.Sprintf("SELECT run_id, result FROM artifacts WHERE run_id IN (SELECT id FROM runs %s) ORDER BY run_id, rowid;\n", where), &artifactRows); err != nil {
+		return nil, err
+	}
+	for _, row := range artifactRows {
+		run := byID[row.RunID]
+		var result flashpipe.ArtifactResult
+		if err := json.Unmarshal([]byte(row.Result), &result); err != nil {
+			return nil, fmt.Errorf("%s: artifact of run %d: %w", s.path, row.RunID, err)
+		}
+		if run.Stats == nil {
+			run.Stats = &flashpipe.Stats{}
+		}
+		run.Stats.Artifacts = append(run.Stats.Artifacts, result)
+	}
+	return runs, nil
+}
+
+// query runs the SQL statements on the database with the sqlite3 shell and unmarshals the rows returned in
+// JSON mode into rows. The shell waits up to 10 seconds for a database locked by another run.
+func (s *Store) query(sql string, rows any) error {
+	cmd := exec.Command(Shell, "-bail", "-json", "-cmd", ".timeout 10000", s.path)
+	cmd.Stdin = strings.NewReader(sql)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s failed: %v: %s", Shell, s.path, err, strings.TrimSpace(stderr.String()))
+	}
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(stdout.Bytes(), rows); err != nil {
+		return fmt.Errorf("invalid output of %s for %s: %w", Shell, s.path, err)
+	}
+	return nil
+}
+
+// quote returns value as SQL string literal
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// quoteOrNull returns value as SQL string literal, or NULL if it is empty
+func quoteOrNull(value string) string {
+	if value == "" {
+		return "NULL"
+	}
+	return quote(value)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ArtifactChange is the outcome of an artifact in a phase of two runs. Before or After is nil if the
+// artifact was not processed in that run.
+type ArtifactChange struct {
+	ArtifactID string
+	Phase      string
+	Before     *flashpipe.ArtifactResult
+	After      *flashpipe.ArtifactResult
+}
+
+// OutcomeChanged returns true if the artifact succeeded in one run and failed or was missing in the other
+func (c ArtifactChange) OutcomeChanged() bool {
+	return Outcome(c.Before) != Outcome(c.After)
+}
+
+// Outcome returns success, failed or - if the artifact was not processed
+func Outcome(result *flashpipe.ArtifactResult) string {
+	switch {
+	case result == nil:
+		return "-"
+	case result.Error != "":
+		return "failed"
+	default:
+		return "success"
+	}
+}
+
+// Compare returns the artifacts of both runs by artifact ID and phase
+func Compare(before *Run, after *Run) []ArtifactChange {
+	type key struct{ artifactID, phase string }
+	changes := map[key]*ArtifactChange{}
+	var order []key
+	add := func(run *Run, isAfter bool) {
+		if run.Stats == nil {
+			return
+		}
+		for i := range run.Stats.Artifacts {
+			result := &run.Stats.Artifacts[i]
+			k := key{result.ArtifactID, result.Phase}
+			change, ok := changes[k]
+			if !ok {
+				change = &ArtifactChange{ArtifactID: result.ArtifactID, Phase: result.Phase}
+				changes[k] = change
+				order = append(order, k)
+			}
+			if isAfter {
+				change.After = result
+			} else {
+				change.Before = result
+			}
+		}
+	}
+	add(before, false)
+	add(after, true)
+
+	result := make([]ArtifactChange, len(order))
+	for i, k := range order {
+		result[i] = *changes[k]
+	}
+	return result
+}
+
+// ArtifactResults returns the results of an artifact in a phase for each run, nil for runs that did not
+// process the artifact
+func ArtifactResults(runs []*Run, artifactID string, phase string) []*flashpipe.ArtifactResult {
+	results := make([]*flashpipe.ArtifactResult, len(runs))
+	for i, run := range runs {
+		if run.Stats == nil {
+			continue
+		}
+		for j := range run.Stats.Artifacts {
+			if r := &run.Stats.Artifacts[j]; r.ArtifactID == artifactID && r.Phase == phase {
+				results[i] = r
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,109 @@
+package history
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRun(tenant string, configureErr error, duration time.Duration) *Run {
+	stats := &flashpipe.Stats{}
+	stats.ArtifactsConfigured.Inc()
+	stats.Timings.Total = 3 * time.Second
+	stats.AddConfigurationResult("Pkg", "Flow1", true, duration, configureErr)
+	stats.AddArtifactResult("Pkg", "Flow1", flashpipe.PhaseDeploy, 20*time.Second, nil)
+	return &Run{Started: time.Date(2024, time.January, 10, 3, 0, 0, 0, time.UTC), Command: "configure", Tenant: tenant, Stats: stats}
+}
+
+// requireShell skips the test if the sqlite3 shell is not installed
+func requireShell(t *testing.T) {
+	if _, err := exec.LookPath(Shell); err != nil {
+		t.Skipf("%s is not on the PATH", Shell)
+	}
+}
+
+func TestStoreAppendAndRuns(t *testing.T) {
+	requireShell(t)
+	store, err := NewStore(filepath.Join(t.TempDir(), "nested", "history.db"))
+	require.NoError(t, err)
+
+	runs, err := store.Runs()
+	require.NoError(t, err)
+	assert.Empty(t, runs, "Missing history database has no runs")
+
+	require.NoError(t, store.Append(newRun("dev", nil, time.Second)))
+	require.NoError(t, store.Append(newRun("qa's tenant", errors.New("parameter 'Host' not found"), 2*time.Second)))
+
+	runs, err = store.Runs()
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, 1, runs[0].ID)
+	assert.Equal(t, 2, runs[1].ID)
+	assert.Equal(t, "qa's tenant", runs[1].Tenant, "Quotes should be escaped")
+	assert.Equal(t, time.Date(2024, time.January, 10, 3, 0, 0, 0, time.UTC), runs[1].Started)
+	assert.Equal(t, 3*time.Second, runs[1].Stats.Timings.Total, "Timings should be read back")
+	assert.Equal(t, "parameter 'Host' not found", runs[1].Stats.Artifacts[0].Error)
+
+	assert.True(t, runs[0].Stats.Artifacts[0].Changed, "Results should be read back completely")
+
+	run, err := store.Get(2)
+	require.NoError(t, err)
+	require.Len(t, run.Stats.Artifacts, 2, "Only the results of the run should be read")
+	assert.Equal(t, int64(2000), run.Stats.Artifacts[0].DurationMs)
+	assert.Equal(t, flashpipe.PhaseDeploy, run.Stats.Artifacts[1].Phase)
+	_, err = store.Get(3)
+	assert.Error(t, err, "Unknown run ID")
+
+	// The outcomes of the artifacts can be queried with SQL
+	out, err := exec.Command(Shell, store.Path(), "SELECT run_id, phase, duration_ms, error FROM artifacts WHERE artifact_id = 'Flow1' ORDER BY run_id, phase").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "1|configure|1000|\n1|deploy|20000|\n2|configure|2000|parameter 'Host' not found\n2|deploy|20000|", strings.TrimSpace(string(out)))
+
+	// The results are read from the artifacts table, not from the statistics of the run
+	out, err = exec.Command(Shell, store.Path(), "UPDATE artifacts SET result = json_set(result, '$.durationMs', 1500) WHERE run_id = 2 AND phase = 'configure'; SELECT stats FROM runs WHERE id = 2").Output()
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), `"artifacts"`)
+	run, err = store.Get(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), run.Stats.Artifacts[0].DurationMs)
+}
+
+func TestStoreWithoutShell(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	_, err := NewStore(filepath.Join(t.TempDir(), "history.db"))
+	assert.ErrorContains(t, err, "requires the SQLite command line shell sqlite3 on the PATH")
+}
+
+func TestStoreHomeDirectory(t *testing.T) {
+	requireShell(t)
+	t.Setenv("HOME", t.TempDir())
+	store, err := NewStore("")
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(store.Path()), "Default path should be expanded")
+	assert.Equal(t, "history.db", filepath.Base(store.Path()))
+}
+
+func TestCompare(t *testing.T) {
+	before := newRun("dev", nil, time.Second)
+	after := newRun("dev", errors.New("parameter not found"), 2*time.Second)
+	after.Stats.AddArtifactResult("Pkg", "Flow2", flashpipe.PhaseConfigure, time.Second, nil)
+
+	changes := Compare(before, after)
+	require.Len(t, changes, 3)
+	assert.Equal(t, "Flow1", changes[0].ArtifactID)
+	assert.Equal(t, flashpipe.PhaseConfigure, changes[0].Phase)
+	assert.True(t, changes[0].OutcomeChanged(), "Flow1 started failing")
+	assert.False(t, changes[1].OutcomeChanged(), "Flow1 deployed in both runs")
+	assert.Nil(t, changes[2].Before, "Flow2 is only in the later run")
+	assert.Equal(t, "-", Outcome(changes[2].Before))
+
+	results := ArtifactResults([]*Run{before, after}, "Flow1", flashpipe.PhaseConfigure)
+	assert.Equal(t, []string{"success", "failed"}, []string{Outcome(results[0]), Outcome(results[1])})
+}
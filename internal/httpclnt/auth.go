@@ -0,0 +1,117 @@
+package httpclnt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrTooManyAuthFailures is returned for all requests of an executer once the tenant rejected the
+// credentials too many times in a row, so that the user is not locked by further attempts
+var ErrTooManyAuthFailures = errors.New("too many authentication failures")
+
+// defaultMaxAuthFailures is the number of consecutive authentication failures of executers created with New
+// after which no more requests are sent
+var defaultMaxAuthFailures = 3
+
+// SetDefaultMaxAuthFailures sets the number of consecutive requests rejected with 401 after which all
+// executers created afterwards stop sending requests, 0 for no limit.
+func SetDefaultMaxAuthFailures(max int) {
+	defaultMaxAuthFailures = max
+}
+
+// SetCsrfFetcher sets the function that fetches a new CSRF token when a modifying request is rejected
+// because its token expired.
+func (e *HTTPExecuter) SetCsrfFetcher(fetch func() (string, []*http.Cookie, error)) {
+	e.csrfFetcher = fetch
+}
+
+// AuthError returns ErrTooManyAuthFailures once no more requests are sent because of authentication
+// failures, nil otherwise.
+func (e *HTTPExecuter) AuthError() error {
+	e.authMutex.Lock()
+	defer e.authMutex.Unlock()
+	if e.maxAuthFailures > 0 && e.authFailures >= e.maxAuthFailures {
+		return fmt.Errorf("%w: %d consecutive requests to %v rejected with response code = 401, check the credentials of %v",
+			ErrTooManyAuthFailures, e.authFailures, e.host, e.userName())
+	}
+	return nil
+}
+
+// AbortError returns the error that stops all requests of the executer, either ErrTooManyAuthFailures or
+// ErrTenantUnavailable, nil if requests are sent.
+func (e *HTTPExecuter) AbortError() error {
+	if err := e.AuthError(); err != nil {
+		return err
+	}
+	return e.circuit.abortError()
+}
+
+// recordAuthResult counts consecutive requests rejected with 401, any other response resets the count
+func (e *HTTPExecuter) recordAuthResult(resp *http.Response) {
+	e.authMutex.Lock()
+	defer e.authMutex.Unlock()
+	if resp.StatusCode == http.StatusUnauthorized {
+		e.authFailures++
+	} else {
+		e.authFailures = 0
+	}
+}
+
+// client returns the HTTP client, which is replaced when a new token is fetched
+func (e *HTTPExecuter) client() *http.Client {
+	e.authMutex.Lock()
+	defer e.authMutex.Unlock()
+	return e.httpClient
+}
+
+// prepareRetry prepares the retry of a request rejected because of an expired OAuth token, token of the token
+// command or CSRF token and
+// returns false if the request cannot be recovered. Requests are only retried once, and only if the body can
+// be sent again.
+func (e *HTTPExecuter) prepareRetry(resp *http.Response, body io.Reader, headers map[string]string, cookies *[]*http.Cookie) bool {
+	if !rewindBody(body) {
+		return false
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized && e.newTokenClient != nil:
+		log.Debug().Msg("Request rejected with response code = 401, fetching a new token")
+		client := e.newTokenClient()
+		e.authMutex.Lock()
+		e.httpClient = client
+		e.authMutex.Unlock()
+		return true
+	case resp.StatusCode == http.StatusForbidden && e.csrfFetcher != nil && csrfRequired(resp, headers):
+		log.Debug().Msg("CSRF token rejected, fetching a new one")
+		token, csrfCookies, err := e.csrfFetcher()
+		if err != nil {
+			log.Warn().Msgf("Failed to fetch a new CSRF token: %v", err)
+			return false
+		}
+		for k := range headers {
+			if strings.EqualFold(k, "x-csrf-token") {
+				headers[k] = token
+			}
+		}
+		*cookies = csrfCookies
+		return true
+	}
+	return false
+}
+
+// csrfRequired returns true if a request sent with a CSRF token was rejected because the token is not valid
+func csrfRequired(resp *http.Response, headers map[string]string) bool {
+	if !strings.EqualFold(resp.Header.Get("x-csrf-token"), "required") {
+		return false
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, "x-csrf-token") && !strings.EqualFold(v, "fetch") {
+			return true
+		}
+	}
+	return false
+}
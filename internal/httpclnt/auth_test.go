@@ -0,0 +1,97 @@
+package httpclnt
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCsrfTokenRefetched(t *testing.T) {
+	posts := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		if r.Header.Get("x-csrf-token") != "new-token" {
+			w.Header().Set("x-csrf-token", "Required")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer svr.Close()
+
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	exe.SetCsrfFetcher(func() (string, []*http.Cookie, error) { return "new-token", nil, nil })
+
+	headers := map[string]string{"x-csrf-token": "expired-token"}
+	resp, err := exe.ExecRequestWithCookies(http.MethodPost, "/api/v1/Flows", strings.NewReader("{}"), headers, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 2, posts, "Request should be retried once with the new token")
+	assert.Equal(t, "expired-token", headers["x-csrf-token"], "Headers of the caller should not be changed")
+}
+
+func TestOAuthTokenRefetched(t *testing.T) {
+	tokens := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(fmt.Sprintf(`{ "access_token": "token%d", "expires_in": 3600 }`, tokens)))
+	})
+	mux.HandleFunc("/api/v1/Flows", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := GetHostPort(svr.URL)
+	exe := New(host, "/oauth/token", "id", "secret", "", "", host, "http", port, true)
+	resp, err := exe.ExecGetRequest("/api/v1/Flows", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Revoked token should be replaced")
+	assert.Equal(t, 2, tokens)
+	assert.NoError(t, exe.AuthError())
+}
+
+func TestAuthFailuresStopRequests(t *testing.T) {
+	requests := 0
+	status := http.StatusUnauthorized
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer svr.Close()
+
+	SetDefaultMaxAuthFailures(2)
+	defer SetDefaultMaxAuthFailures(3)
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "wrong", host, "http", port, true)
+
+	resp, err := exe.ExecGetRequest("/api/v1/Flows", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	status = http.StatusOK
+	_, err = exe.ExecGetRequest("/api/v1/Flows", nil)
+	require.NoError(t, err, "Successful request should reset the count")
+	status = http.StatusUnauthorized
+	_, err = exe.ExecGetRequest("/api/v1/Flows", nil)
+	require.NoError(t, err)
+	_, err = exe.ExecGetRequest("/api/v1/Flows", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, requests, "Basic Auth requests should not be retried")
+
+	_, err = exe.ExecGetRequest("/api/v1/Flows", nil)
+	assert.ErrorIs(t, err, ErrTooManyAuthFailures)
+	assert.ErrorContains(t, err, "2 consecutive requests")
+	assert.Equal(t, 4, requests, "No request should be sent after too many failures")
+}
@@ -3,14 +3,20 @@ package httpclnt
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/engswee/flashpipe/internal/odata"
+	"github.com/engswee/flashpipe/internal/pipeline"
 	"github.com/rs/zerolog/log"
 )
 
@@ -18,11 +24,48 @@ const (
 	// DefaultBatchSize is the default number of operations per batch request
 	DefaultBatchSize = 90
 
+	// DefaultMaxBatchBodySize is the default maximum size in bytes of a batch request body, below the
+	// request size limit of the API gateway
+	DefaultMaxBatchBodySize = 1024 * 1024
+
+	// DefaultBatchParallelism is the default number of batch requests ExecuteInBatches sends at a time
+	DefaultBatchParallelism = 2
+
 	// Batch boundary prefixes (must match OData multipart/mixed format)
 	batchBoundaryPrefix     = "batch_"
 	changesetBoundaryPrefix = "changeset_"
 )
 
+// ErrBatchTooLarge is returned when the server rejects a batch request because its body is too large
+var ErrBatchTooLarge = errors.New("batch request too large")
+
+// ErrBatchNotExecuted is the error of the responses of operations that were not executed by ExecuteInBatches
+// because their batch request failed
+var ErrBatchNotExecuted = errors.New("batch request failed")
+
+// defaultBatchParallelism is the number of batch requests ExecuteInBatches sends at a time for executers created
+// with New
+var defaultBatchParallelism = DefaultBatchParallelism
+
+// SetDefaultBatchParallelism sets the number of batch requests ExecuteInBatches sends at a time for all executers
+// created afterwards, 1 to send them one after the other.
+func SetDefaultBatchParallelism(n int) {
+	defaultBatchParallelism = n
+}
+
+// batchRetryDelay is the time before a batch that failed with a transient error is sent again
+var batchRetryDelay = 2 * time.Second
+
+// transientBatchError is the failure of a batch request that may succeed when it is sent again, e.g. after a
+// connection error or with status 503
+type transientBatchError struct {
+	err error
+}
+
+func (e *transientBatchError) Error() string { return e.err.Error() }
+
+func (e *transientBatchError) Unwrap() error { return e.err }
+
 // BatchOperation represents a single operation in a batch request
 type BatchOperation struct {
 	Method    string            // HTTP method (POST, PUT, DELETE, PATCH, GET)
@@ -47,16 +90,20 @@ type BatchOperationResponse struct {
 	Error      error
 }
 
-// BatchRequest handles building and executing OData $batch requests
+// BatchRequest handles building and executing OData $batch requests. By default, all modifying
+// operations of a request are sent in one changeset, so that they succeed or fail atomically.
 type BatchRequest struct {
-	exe               *HTTPExecuter
-	operations        []BatchOperation
-	batchBoundary     string
-	changesetBoundary string
+	exe                   *HTTPExecuter
+	operations            []BatchOperation
+	batchBoundary         string
+	changesetBoundary     string
+	changesetPerOperation bool
+	maxBodySize           int
+	parallelism           int
 }
 
-// boundaryCounter is used to generate unique boundary strings
-var boundaryCounter = 0
+// boundaryCounter is used to generate unique boundary strings, also for batches sent at the same time
+var boundaryCounter atomic.Int64
 
 // NewBatchRequest creates a new batch request builder
 func (e *HTTPExecuter) NewBatchRequest() *BatchRequest {
@@ -65,9 +112,28 @@ func (e *HTTPExecuter) NewBatchRequest() *BatchRequest {
 		operations:        make([]BatchOperation, 0),
 		batchBoundary:     generateBoundary(batchBoundaryPrefix),
 		changesetBoundary: generateBoundary(changesetBoundaryPrefix),
+		maxBodySize:       DefaultMaxBatchBodySize,
+		parallelism:       e.batchParallelism,
 	}
 }
 
+// SetChangesetPerOperation sends each modifying operation in its own changeset, for APIs that do not
+// support changesets with several operations. The operations then succeed or fail individually.
+func (br *BatchRequest) SetChangesetPerOperation(enabled bool) {
+	br.changesetPerOperation = enabled
+}
+
+// SetMaxBodySize sets the maximum size in bytes of the body of each request sent by ExecuteInBatches
+func (br *BatchRequest) SetMaxBodySize(size int) {
+	br.maxBodySize = size
+}
+
+// SetParallelism sets the number of batch requests ExecuteInBatches sends at a time, 1 to send them one after
+// the other
+func (br *BatchRequest) SetParallelism(n int) {
+	br.parallelism = n
+}
+
 // AddOperation adds an operation to the batch
 func (br *BatchRequest) AddOperation(op BatchOperation) {
 	br.operations = append(br.operations, op)
@@ -94,48 +160,184 @@ func (br *BatchRequest) Execute() (*BatchResponse, error) {
 
 	resp, err := br.exe.ExecRequestWithCookies("POST", "/api/v1/$batch", bytes.NewReader(body), headers, nil)
 	if err != nil {
-		return nil, fmt.Errorf("batch request failed: %w", err)
+		var failure error = fmt.Errorf("batch request failed: %w", err)
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			failure = &transientBatchError{failure}
+		}
+		return nil, br.diagnose(headers, body, nil, nil, failure)
 	}
 	defer resp.Body.Close()
 
+	// The response is kept to be written to the diagnostics folder if the batch fails
+	var respBody []byte
+	if br.exe.batchDiagnosticsDir != "" {
+		if respBody, err = io.ReadAll(br.exe.limitBody(resp.Body)); err != nil {
+			return nil, br.diagnose(headers, body, resp, respBody, fmt.Errorf("failed to read batch response: %w", err))
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	}
+
+	if resp.StatusCode == http.StatusRequestEntityTooLarge {
+		return nil, fmt.Errorf("%w (%d bytes, %d operations)", ErrBatchTooLarge, len(body), len(br.operations))
+	}
 	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("batch request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		if IsLockedResponse(bodyBytes) {
+			return nil, br.diagnose(headers, body, resp, respBody, fmt.Errorf("batch request failed with status %d: %s: %w", resp.StatusCode, string(bodyBytes), ErrLocked))
+		}
+		var failure error = fmt.Errorf("batch request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		if retryableResult(resp, nil) || resp.StatusCode == http.StatusInternalServerError {
+			failure = &transientBatchError{failure}
+		}
+		return nil, br.diagnose(headers, body, resp, respBody, failure)
 	}
 
 	// Parse the multipart response
-	return br.parseBatchResponse(resp)
+	batchResp, err := br.parseBatchResponse(resp)
+	if err != nil {
+		return nil, br.diagnose(headers, body, resp, respBody, err)
+	}
+	if failed := batchResp.failedOperations(); failed > 0 {
+		if prefix := br.saveDiagnostics(headers, body, resp, respBody, nil); prefix != "" {
+			log.Warn().Msgf("%d of %d batch operation(s) failed, request and response saved to %s-*.txt", failed, len(br.operations), prefix)
+		}
+	}
+	return batchResp, nil
 }
 
-// ExecuteInBatches splits operations into batches and executes them
+// failedOperations returns the number of operations that failed
+func (r *BatchResponse) failedOperations() int {
+	failed := 0
+	for _, op := range r.Operations {
+		if op.Error != nil || op.StatusCode >= 400 {
+			failed++
+		}
+	}
+	return failed
+}
+
+// ExecuteInBatches splits operations into batches and executes them, up to the parallelism of the request at a
+// time, see SetParallelism. A batch holds at most batchSize operations and is closed early when its serialized
+// body would exceed the maximum body size. The responses are returned in the order of the operations. If the
+// server rejects a batch as too large, it is sent again in halves, and a batch that failed with a transient error,
+// e.g. a connection error or status 503, is sent again once. If a batch fails, the error of the first failed
+// batch is returned with the responses of all operations, as the other batches may have been executed. The
+// responses of the operations that were not executed have an error wrapping ErrBatchNotExecuted.
 func (br *BatchRequest) ExecuteInBatches(batchSize int) (*BatchResponse, error) {
 	if batchSize <= 0 {
 		batchSize = DefaultBatchSize
 	}
 
 	allOps := br.operations
-	var allResponses []BatchOperationResponse
+	var tasks []pipeline.Task
+	var chunks [][]BatchOperationResponse
+	var chunkOps [][]BatchOperation
+	var chunkErrs []error
+	for i := 0; i < len(allOps); {
+		start, end, chunk := i, br.batchEnd(allOps, i, batchSize), len(tasks)
+		chunks = append(chunks, nil)
+		chunkOps = append(chunkOps, allOps[start:end])
+		chunkErrs = append(chunkErrs, nil)
+		tasks = append(tasks, pipeline.Task{
+			ID: fmt.Sprintf("%d-%d", start, end),
+			Run: func() error {
+				chunks[chunk], chunkErrs[chunk] = br.executeChunk(allOps[start:end], start, batchSize)
+				return chunkErrs[chunk]
+			},
+		})
+		i = end
+	}
+
+	results, err := pipeline.Run(tasks, pipeline.Options{
+		Parallel:   max(br.parallelism, 1),
+		Retries:    1,
+		RetryDelay: batchRetryDelay,
+		Retryable: func(err error) bool {
+			var transient *transientBatchError
+			if !errors.As(err, &transient) {
+				return false
+			}
+			log.Warn().Msgf("%v, sending the batch again in %v", err, batchRetryDelay)
+			return true
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < len(allOps); i += batchSize {
-		end := i + batchSize
-		if end > len(allOps) {
-			end = len(allOps)
+	var allResponses []BatchOperationResponse
+	for i, responses := range chunks {
+		allResponses = append(allResponses, responses...)
+		for _, op := range chunkOps[i][len(responses):] {
+			allResponses = append(allResponses, BatchOperationResponse{ContentID: op.ContentID,
+				Error: fmt.Errorf("%w: %v", ErrBatchNotExecuted, chunkErrs[i])})
 		}
+	}
+	return &BatchResponse{Operations: allResponses}, pipeline.FirstError(results)
+}
+
+// executeChunk executes the operations of a chunk starting at offset of all operations in batches of at most
+// batchSize operations, one after the other. If the server rejects a batch as too large, the batch size is
+// halved and the operations are sent again. If a batch fails, the responses of the batches executed before are
+// returned with the error.
+func (br *BatchRequest) executeChunk(ops []BatchOperation, offset int, batchSize int) ([]BatchOperationResponse, error) {
+	var responses []BatchOperationResponse
+	for i := 0; i < len(ops); {
+		end := br.batchEnd(ops, i, batchSize)
 
 		// Create a batch for this chunk
 		batch := br.exe.NewBatchRequest()
-		batch.operations = allOps[i:end]
+		batch.operations = ops[i:end]
+		batch.changesetPerOperation = br.changesetPerOperation
 
 		// Execute this batch
 		resp, err := batch.Execute()
+		if errors.Is(err, ErrBatchTooLarge) && end-i > 1 {
+			batchSize = (end - i) / 2
+			log.Warn().Msgf("Batch %d-%d rejected as too large, retrying with batch size %d", offset+i, offset+end, batchSize)
+			continue
+		}
 		if err != nil {
-			return nil, fmt.Errorf("batch %d-%d failed: %w", i, end, err)
+			return responses, fmt.Errorf("batch %d-%d failed: %w", offset+i, offset+end, err)
 		}
 
-		allResponses = append(allResponses, resp.Operations...)
+		responses = append(responses, resp.Operations...)
+		i = end
+	}
+	return responses, nil
+}
+
+// batchEnd returns the end of the batch of operations starting at start, with at most batchSize
+// operations and a body not exceeding the maximum body size. A batch has at least one operation.
+func (br *BatchRequest) batchEnd(ops []BatchOperation, start int, batchSize int) int {
+	size := batchOverhead
+	end := start
+	for end < len(ops) && end-start < batchSize {
+		size += br.operationSize(ops[end])
+		if br.maxBodySize > 0 && size > br.maxBodySize && end > start {
+			break
+		}
+		end++
 	}
+	return end
+}
+
+// batchOverhead is the size of the boundaries and headers of a batch and its changeset
+const batchOverhead = 256
 
-	return &BatchResponse{Operations: allResponses}, nil
+// operationSize returns the size of an operation serialized in a batch body
+func (br *BatchRequest) operationSize(op BatchOperation) int {
+	var buf bytes.Buffer
+	if op.IsQuery {
+		fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
+		_ = br.writeQueryOperation(&buf, op)
+	} else if br.changesetPerOperation {
+		_ = br.writeChangeset(&buf, br.changesetBoundary, []BatchOperation{op})
+	} else {
+		_ = br.writeChangesetOperation(&buf, br.changesetBoundary, op)
+	}
+	return buf.Len()
 }
 
 // buildBatchBody constructs the multipart batch request body
@@ -154,34 +356,25 @@ func (br *BatchRequest) buildBatchBody() ([]byte, error) {
 		}
 	}
 
-	// Start batch boundary
-	fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
-
 	// Add query operations (if any) - these go directly in batch, not in changeset
-	if len(queryOps) > 0 {
-		for _, op := range queryOps {
-			if err := br.writeQueryOperation(&buf, op); err != nil {
-				return nil, err
-			}
-			fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
+	for _, op := range queryOps {
+		fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
+		if err := br.writeQueryOperation(&buf, op); err != nil {
+			return nil, err
 		}
 	}
 
 	// Add changeset for modifying operations (POST, PUT, DELETE, PATCH)
-	if len(changesetOps) > 0 {
-		fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n", br.changesetBoundary)
-		fmt.Fprintf(&buf, "\r\n")
-
-		// Add each operation as a changeset part
+	if br.changesetPerOperation {
 		for _, op := range changesetOps {
-			if err := br.writeChangesetOperation(&buf, op); err != nil {
+			if err := br.writeChangeset(&buf, generateBoundary(changesetBoundaryPrefix), []BatchOperation{op}); err != nil {
 				return nil, err
 			}
 		}
-
-		// End changeset boundary
-		fmt.Fprintf(&buf, "--%s--\r\n", br.changesetBoundary)
-		fmt.Fprintf(&buf, "\r\n")
+	} else if len(changesetOps) > 0 {
+		if err := br.writeChangeset(&buf, br.changesetBoundary, changesetOps); err != nil {
+			return nil, err
+		}
 	}
 
 	// End batch boundary
@@ -190,6 +383,25 @@ func (br *BatchRequest) buildBatchBody() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// writeChangeset writes a changeset with the operations to the batch body
+func (br *BatchRequest) writeChangeset(buf *bytes.Buffer, boundary string, ops []BatchOperation) error {
+	fmt.Fprintf(buf, "--%s\r\n", br.batchBoundary)
+	fmt.Fprintf(buf, "Content-Type: multipart/mixed; boundary=%s\r\n", boundary)
+	fmt.Fprintf(buf, "\r\n")
+
+	// Add each operation as a changeset part
+	for _, op := range ops {
+		if err := br.writeChangesetOperation(buf, boundary, op); err != nil {
+			return err
+		}
+	}
+
+	// End changeset boundary
+	fmt.Fprintf(buf, "--%s--\r\n", boundary)
+	fmt.Fprintf(buf, "\r\n")
+	return nil
+}
+
 // writeQueryOperation writes a query (GET) operation to the batch body
 func (br *BatchRequest) writeQueryOperation(buf *bytes.Buffer, op BatchOperation) error {
 	fmt.Fprintf(buf, "Content-Type: application/http\r\n")
@@ -209,15 +421,16 @@ func (br *BatchRequest) writeQueryOperation(buf *bytes.Buffer, op BatchOperation
 		fmt.Fprintf(buf, "%s: %s\r\n", key, value)
 	}
 
-	fmt.Fprintf(buf, "\r\n")
+	// End of headers, followed by the line break that belongs to the next boundary
+	fmt.Fprintf(buf, "\r\n\r\n")
 
 	return nil
 }
 
 // writeChangesetOperation writes a changeset operation to the batch body
-func (br *BatchRequest) writeChangesetOperation(buf *bytes.Buffer, op BatchOperation) error {
+func (br *BatchRequest) writeChangesetOperation(buf *bytes.Buffer, boundary string, op BatchOperation) error {
 	// Changeset part boundary
-	fmt.Fprintf(buf, "--%s\r\n", br.changesetBoundary)
+	fmt.Fprintf(buf, "--%s\r\n", boundary)
 	fmt.Fprintf(buf, "Content-Type: application/http\r\n")
 	fmt.Fprintf(buf, "Content-Transfer-Encoding: binary\r\n")
 
@@ -265,7 +478,7 @@ func (br *BatchRequest) parseBatchResponse(resp *http.Response) (*BatchResponse,
 		return nil, fmt.Errorf("no boundary in multipart response")
 	}
 
-	mr := multipart.NewReader(resp.Body, boundary)
+	mr := multipart.NewReader(br.exe.limitBody(resp.Body), boundary)
 
 	var operations []BatchOperationResponse
 
@@ -421,24 +634,18 @@ func (br *BatchRequest) parseOperationResponseFromPart(part *multipart.Part) (Ba
 
 // generateBoundary generates a unique boundary string
 func generateBoundary(prefix string) string {
-	boundaryCounter++
-	return fmt.Sprintf("%s%d", prefix, boundaryCounter)
+	return fmt.Sprintf("%s%d", prefix, boundaryCounter.Add(1))
 }
 
 // Helper functions for building batch operations
 
 // AddCreateStringParameterOp adds a CREATE operation for a string parameter to the batch
 func AddCreateStringParameterOp(batch *BatchRequest, pid, id, value, contentID string) {
-	body := map[string]string{
-		"Pid":   pid,
-		"Id":    id,
-		"Value": value,
-	}
-	bodyJSON, _ := json.Marshal(body)
+	bodyJSON, _ := json.Marshal(&odata.StringParameterCreate{Pid: pid, ID: id, Value: value})
 
 	batch.AddOperation(BatchOperation{
 		Method:    "POST",
-		Path:      "/api/v1/StringParameters",
+		Path:      odata.StringParametersPath,
 		Body:      bodyJSON,
 		ContentID: contentID,
 		Headers: map[string]string{
@@ -449,16 +656,11 @@ func AddCreateStringParameterOp(batch *BatchRequest, pid, id, value, contentID s
 
 // AddUpdateStringParameterOp adds an UPDATE operation for a string parameter to the batch
 func AddUpdateStringParameterOp(batch *BatchRequest, pid, id, value, contentID string) {
-	body := map[string]string{
-		"Value": value,
-	}
-	bodyJSON, _ := json.Marshal(body)
-
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')", pid, id)
+	bodyJSON, _ := json.Marshal(&odata.StringParameterUpdate{Value: value})
 
 	batch.AddOperation(BatchOperation{
 		Method:    "PUT",
-		Path:      path,
+		Path:      odata.StringParameterPath(pid, id),
 		Body:      bodyJSON,
 		ContentID: contentID,
 		Headers: map[string]string{
@@ -470,11 +672,9 @@ func AddUpdateStringParameterOp(batch *BatchRequest, pid, id, value, contentID s
 
 // AddDeleteStringParameterOp adds a DELETE operation for a string parameter to the batch
 func AddDeleteStringParameterOp(batch *BatchRequest, pid, id, contentID string) {
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')", pid, id)
-
 	batch.AddOperation(BatchOperation{
 		Method:    "DELETE",
-		Path:      path,
+		Path:      odata.StringParameterPath(pid, id),
 		ContentID: contentID,
 		Headers: map[string]string{
 			"If-Match": "*",
@@ -484,17 +684,11 @@ func AddDeleteStringParameterOp(batch *BatchRequest, pid, id, contentID string)
 
 // AddCreateBinaryParameterOp adds a CREATE operation for a binary parameter to the batch
 func AddCreateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType, contentID string) {
-	body := map[string]string{
-		"Pid":         pid,
-		"Id":          id,
-		"Value":       value,
-		"ContentType": contentType,
-	}
-	bodyJSON, _ := json.Marshal(body)
+	bodyJSON, _ := json.Marshal(&odata.BinaryParameterCreate{Pid: pid, ID: id, Value: value, ContentType: contentType})
 
 	batch.AddOperation(BatchOperation{
 		Method:    "POST",
-		Path:      "/api/v1/BinaryParameters",
+		Path:      odata.BinaryParametersPath,
 		Body:      bodyJSON,
 		ContentID: contentID,
 		Headers: map[string]string{
@@ -505,17 +699,11 @@ func AddCreateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType
 
 // AddUpdateBinaryParameterOp adds an UPDATE operation for a binary parameter to the batch
 func AddUpdateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType, contentID string) {
-	body := map[string]string{
-		"Value":       value,
-		"ContentType": contentType,
-	}
-	bodyJSON, _ := json.Marshal(body)
-
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')", pid, id)
+	bodyJSON, _ := json.Marshal(&odata.BinaryParameterUpdate{Value: value, ContentType: contentType})
 
 	batch.AddOperation(BatchOperation{
 		Method:    "PUT",
-		Path:      path,
+		Path:      odata.BinaryParameterPath(pid, id),
 		Body:      bodyJSON,
 		ContentID: contentID,
 		Headers: map[string]string{
@@ -527,11 +715,9 @@ func AddUpdateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType
 
 // AddDeleteBinaryParameterOp adds a DELETE operation for a binary parameter to the batch
 func AddDeleteBinaryParameterOp(batch *BatchRequest, pid, id, contentID string) {
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')", pid, id)
-
 	batch.AddOperation(BatchOperation{
 		Method:    "DELETE",
-		Path:      path,
+		Path:      odata.BinaryParameterPath(pid, id),
 		ContentID: contentID,
 		Headers: map[string]string{
 			"If-Match": "*",
@@ -0,0 +1,90 @@
+package httpclnt
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// defaultBatchDiagnosticsDir is the folder the payloads of failed batch requests of executers created with New
+// are written to, empty to not write them
+var defaultBatchDiagnosticsDir string
+
+// SetDefaultBatchDiagnosticsDir sets the folder the raw request and response of failed $batch requests of all
+// executers created afterwards are written to, empty to not write them.
+func SetDefaultBatchDiagnosticsDir(dir string) {
+	defaultBatchDiagnosticsDir = dir
+}
+
+// sensitiveProperty matches the JSON string properties whose values are redacted in the diagnostics files, e.g.
+// ParameterValue of configuration parameters or Value of Partner Directory parameters
+var sensitiveProperty = regexp.MustCompile(`(?i)("[a-z_]*(?:value|password|secret|token)[a-z_]*"\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+
+// redactPayload replaces the values of sensitive JSON properties by asterisks of the same length, so that content
+// lengths and offsets in the payload stay valid to reproduce boundary and parsing errors
+func redactPayload(payload []byte) []byte {
+	return sensitiveProperty.ReplaceAllFunc(payload, func(match []byte) []byte {
+		parts := sensitiveProperty.FindSubmatch(match)
+		redacted := make([]byte, 0, len(match))
+		redacted = append(redacted, parts[1]...)
+		redacted = append(redacted, bytes.Repeat([]byte("*"), len(parts[2]))...)
+		return append(redacted, parts[3]...)
+	})
+}
+
+// diagnose writes the request and response of a failed batch request to the diagnostics folder and returns
+// failure with a reference to the files. Without diagnostics folder, failure is returned as is.
+func (br *BatchRequest) diagnose(headers map[string]string, body []byte, resp *http.Response, respBody []byte, failure error) error {
+	prefix := br.saveDiagnostics(headers, body, resp, respBody, failure)
+	if prefix == "" {
+		return failure
+	}
+	return fmt.Errorf("%w (request and response saved to %s-*.txt)", failure, prefix)
+}
+
+// saveDiagnostics writes the redacted request and response to <prefix>-request.txt and <prefix>-response.txt
+// in the diagnostics folder and returns the prefix, empty if they were not written
+func (br *BatchRequest) saveDiagnostics(headers map[string]string, body []byte, resp *http.Response, respBody []byte, failure error) string {
+	dir := br.exe.batchDiagnosticsDir
+	if dir == "" {
+		return ""
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Warn().Msgf("Failed to write batch diagnostics to %v: %v", dir, err)
+		return ""
+	}
+	prefix := filepath.Join(dir, fmt.Sprintf("batch-%s-%s", time.Now().Format("20060102-150405"), strings.TrimPrefix(br.batchBoundary, batchBoundaryPrefix)))
+
+	var request bytes.Buffer
+	fmt.Fprintf(&request, "POST /api/v1/$batch HTTP/1.1\r\nHost: %s\r\n", br.exe.host)
+	for k, v := range headers {
+		fmt.Fprintf(&request, "%s: %s\r\n", k, v)
+	}
+	request.WriteString("\r\n")
+	request.Write(redactPayload(body))
+
+	var response bytes.Buffer
+	if resp != nil {
+		fmt.Fprintf(&response, "%s %s\r\n", resp.Proto, resp.Status)
+		_ = resp.Header.Write(&response)
+		response.WriteString("\r\n")
+		response.Write(redactPayload(respBody))
+	} else {
+		fmt.Fprintf(&response, "No response: %v\r\n", failure)
+	}
+
+	for suffix, content := range map[string][]byte{"-request.txt": request.Bytes(), "-response.txt": response.Bytes()} {
+		if err := os.WriteFile(prefix+suffix, content, 0o600); err != nil {
+			log.Warn().Msgf("Failed to write batch diagnostics to %v: %v", prefix+suffix, err)
+			return ""
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,74 @@
+package httpclnt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchDiagnostics(t *testing.T) {
+	// The first batch fails with a parsing error of the tenant, the second one with a failed operation
+	requests := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			http.Error(w, `{"error": {"message": "Unexpected end of multipart body"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresponse_1")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("--batchresponse_1\r\nContent-Type: multipart/mixed; boundary=changesetresponse_1\r\n\r\n" +
+			"--changesetresponse_1\r\nContent-Type: application/http\r\nContent-Transfer-Encoding: binary\r\n\r\n" +
+			"HTTP/1.1 400 Bad Request\r\n\r\n{\"Value\": \"s3cret\"}\r\n--changesetresponse_1--\r\n--batchresponse_1--\r\n"))
+	}))
+	defer svr.Close()
+
+	dir := filepath.Join(t.TempDir(), "diagnostics")
+	SetDefaultBatchDiagnosticsDir(dir)
+	defer SetDefaultBatchDiagnosticsDir("")
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, false)
+
+	batch := exe.NewBatchRequest()
+	AddUpdateStringParameterOp(batch, "Pid", "Password", "s3cret", "op_1")
+	_, err := batch.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "batch request failed with status 400")
+	assert.Contains(t, err.Error(), "request and response saved to "+dir)
+
+	request, err := os.ReadFile(strings.TrimSuffix(strings.SplitN(err.Error(), "saved to ", 2)[1], "*.txt)") + "request.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(request), "POST /api/v1/$batch HTTP/1.1\r\n")
+	assert.Contains(t, string(request), "PUT /api/v1/StringParameters(Pid='Pid',Id='Password') HTTP/1.1\r\n")
+	assert.Contains(t, string(request), `{"Value":"******"}`, "Values should be redacted with the same length")
+	assert.NotContains(t, string(request), "s3cret")
+
+	batch = exe.NewBatchRequest()
+	AddUpdateStringParameterOp(batch, "Pid", "Password", "s3cret", "op_1")
+	resp, err := batch.Execute()
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.Operations[0].StatusCode)
+	assert.Equal(t, `{"Value": "s3cret"}`, string(resp.Operations[0].Body), "The response should be parsed as usual")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-response.txt"))
+	require.NoError(t, err)
+	require.Len(t, files, 2, "Batches with failed operations should be saved as well")
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "400 Bad Request\r\n")
+		assert.NotContains(t, string(content), "s3cret")
+	}
+}
+
+func TestRedactPayload(t *testing.T) {
+	payload := `{"ParameterKey": "Host", "ParameterValue": "pa\"ss", "clientSecret":"abc", "DataType": "xsd:string"}`
+	assert.Equal(t, `{"ParameterKey": "Host", "ParameterValue": "******", "clientSecret":"***", "DataType": "xsd:string"}`,
+		string(redactPayload([]byte(payload))))
+}
@@ -0,0 +1,166 @@
+package httpclnt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMockExecuteInBatchesTooLarge(t *testing.T) {
+	const limit = 3000
+	var accepted, rejected int
+
+	// Set up local server that rejects request bodies above the limit
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/$batch", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > limit {
+			rejected++
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		accepted++
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresponse_1")
+		w.WriteHeader(http.StatusAccepted)
+		for i := 0; i < strings.Count(string(body), "PUT /api/v1/"); i++ {
+			fmt.Fprintf(w, "--batchresponse_1\r\nContent-Type: multipart/mixed; boundary=changesetresponse_%d\r\n\r\n"+
+				"--changesetresponse_%d\r\nContent-Type: application/http\r\nContent-Transfer-Encoding: binary\r\n\r\n"+
+				"HTTP/1.1 204 No Content\r\n\r\n\r\n--changesetresponse_%d--\r\n", i, i, i)
+		}
+		w.Write([]byte("--batchresponse_1--\r\n"))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	newBatch := func() *BatchRequest {
+		batch := exe.NewBatchRequest()
+		batch.SetChangesetPerOperation(true)
+		for i := 0; i < 10; i++ {
+			AddUpdateStringParameterOp(batch, "Pid", fmt.Sprintf("Id%d", i), strings.Repeat("x", 1000), fmt.Sprintf("op_%d", i))
+		}
+		return batch
+	}
+
+	// Rejected batches are halved until they are accepted
+	resp, err := newBatch().ExecuteInBatches(DefaultBatchSize)
+	if err != nil {
+		t.Fatalf("ExecuteInBatches failed with error - %v", err)
+	}
+	if len(resp.Operations) != 10 {
+		t.Fatalf("Expected 10 operation responses, got %d", len(resp.Operations))
+	}
+	if rejected != 2 || accepted != 5 {
+		t.Fatalf("Expected 2 rejected and 5 accepted batches, got %d and %d", rejected, accepted)
+	}
+
+	// Batches are split by the size of their body upfront
+	accepted, rejected = 0, 0
+	batch := newBatch()
+	batch.SetMaxBodySize(limit)
+	resp, err = batch.ExecuteInBatches(DefaultBatchSize)
+	if err != nil {
+		t.Fatalf("ExecuteInBatches failed with error - %v", err)
+	}
+	if len(resp.Operations) != 10 {
+		t.Fatalf("Expected 10 operation responses, got %d", len(resp.Operations))
+	}
+	if rejected != 0 {
+		t.Fatalf("Expected no rejected batches, got %d", rejected)
+	}
+}
+
+func TestMockExecuteInBatchesParallel(t *testing.T) {
+	contentID := regexp.MustCompile(`Content-ID: (op_\w+)`)
+	var inFlight, maxInFlight, failures, invalid atomic.Int32
+
+	// Set up local server that fails the first request of the batch with op_2 as unavailable, and the batch with
+	// op_invalid as bad request
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/$batch", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+		}
+		time.Sleep(50 * time.Millisecond)
+		ids := contentID.FindAllStringSubmatch(string(body), -1)
+		if ids[0][1] == "op_invalid" {
+			invalid.Add(1)
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if ids[0][1] == "op_2" && failures.Add(1) == 1 {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresponse_1")
+		w.WriteHeader(http.StatusAccepted)
+		for i, id := range ids {
+			fmt.Fprintf(w, "--batchresponse_1\r\nContent-Type: multipart/mixed; boundary=changesetresponse_%d\r\n\r\n"+
+				"--changesetresponse_%d\r\nContent-Type: application/http\r\nContent-Transfer-Encoding: binary\r\nContent-ID: %s\r\n\r\n"+
+				"HTTP/1.1 204 No Content\r\n\r\n\r\n--changesetresponse_%d--\r\n", i, i, id[1], i)
+		}
+		w.Write([]byte("--batchresponse_1--\r\n"))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	delay := batchRetryDelay
+	batchRetryDelay = time.Millisecond
+	defer func() { batchRetryDelay = delay }()
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	batch := exe.NewBatchRequest()
+	batch.SetChangesetPerOperation(true)
+	batch.SetParallelism(2)
+	for i := 0; i < 6; i++ {
+		AddUpdateStringParameterOp(batch, "Pid", fmt.Sprintf("Id%d", i), "value", fmt.Sprintf("op_%d", i))
+	}
+
+	resp, err := batch.ExecuteInBatches(2)
+	if err != nil {
+		t.Fatalf("ExecuteInBatches failed with error - %v", err)
+	}
+	if len(resp.Operations) != 6 {
+		t.Fatalf("Expected 6 operation responses, got %d", len(resp.Operations))
+	}
+	for i, op := range resp.Operations {
+		if op.ContentID != fmt.Sprintf("op_%d", i) {
+			t.Fatalf("Expected responses in the order of the operations, got %s at %d", op.ContentID, i)
+		}
+	}
+	if failures.Load() != 2 {
+		t.Fatalf("Expected the unavailable batch to be sent again once, got %d requests", failures.Load())
+	}
+	if maxInFlight.Load() != 2 {
+		t.Fatalf("Expected 2 batches at a time, got %d", maxInFlight.Load())
+	}
+
+	// Batches that fail otherwise are not sent again, the operations of the other batches are executed
+	batch = exe.NewBatchRequest()
+	batch.SetChangesetPerOperation(true)
+	AddUpdateStringParameterOp(batch, "Pid", "Id0", "value", "op_0")
+	AddUpdateStringParameterOp(batch, "Pid", "Invalid", "value", "op_invalid")
+	resp, err = batch.ExecuteInBatches(1)
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Fatalf("Expected the batch to fail with status 400, got %v", err)
+	}
+	if invalid.Load() != 1 {
+		t.Fatalf("Expected the invalid batch to be sent once, got %d requests", invalid.Load())
+	}
+	if len(resp.Operations) != 2 || resp.Operations[0].StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected the response of the executed batch, got %+v", resp.Operations)
+	}
+	if op := resp.Operations[1]; op.ContentID != "op_invalid" || !errors.Is(op.Error, ErrBatchNotExecuted) {
+		t.Fatalf("Expected op_invalid not to be executed, got %+v", op)
+	}
+}
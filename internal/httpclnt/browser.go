@@ -0,0 +1,230 @@
+package httpclnt
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+)
+
+// BrowserLogin configures the interactive login of a user in the browser with the OAuth 2.0 authorization code
+// flow and PKCE, e.g. against the XSUAA of the subaccount, instead of client credentials
+type BrowserLogin struct {
+	Cache        TokenCache             // Tokens of earlier runs, refreshed with their refresh token, nil to log in on every run
+	CallbackPort int                    // Port of the local callback, 0 for any free port
+	Timeout      time.Duration          // Time the user has to log in
+	Open         func(url string) error // Opens the login page in the browser
+}
+
+// TokenCache keeps the tokens of the browser login between runs, by client and token host
+type TokenCache interface {
+	Load(key string) (*oauth2.Token, error) // Returns nil without cached token
+	Save(key string, token *oauth2.Token) error
+}
+
+// defaultBrowserLogin is used by executers created with New with OAuth credentials, nil for client credentials
+var defaultBrowserLogin *BrowserLogin
+
+// SetDefaultBrowserLogin sets the browser login of all executers created afterwards with OAuth credentials, nil to
+// use client credentials
+func SetDefaultBrowserLogin(login *BrowserLogin) {
+	defaultBrowserLogin = login
+}
+
+// callbackPath is the path of the redirect URI of the browser login
+const callbackPath = "/callback"
+
+// browserTokenSource obtains the tokens of a user. Cached tokens are used until they expire and are then refreshed,
+// the user is only asked to log in in the browser if there is no cached token or it cannot be refreshed. The
+// authorization code is received by a callback on localhost.
+type browserTokenSource struct {
+	login BrowserLogin
+	conf  oauth2.Config
+	key   string
+
+	mu   sync.Mutex
+	user string // User of the last token
+}
+
+func newBrowserTokenSource(login BrowserLogin, conf oauth2.Config, key string) *browserTokenSource {
+	return &browserTokenSource{login: login, conf: conf, key: key}
+}
+
+// BrowserTokenKey returns the key of the cached tokens of the browser login of a client at the token host
+func BrowserTokenKey(oauthHost string, clientId string) string {
+	return clientId + "@" + oauthHost
+}
+
+// Token returns the cached token, a refreshed token or the token of a new login
+func (s *browserTokenSource) Token() (*oauth2.Token, error) {
+	cached := s.load()
+	if cached.Valid() {
+		return s.use(cached, false), nil
+	}
+	if cached != nil && cached.RefreshToken != "" {
+		token, err := s.conf.TokenSource(context.Background(), cached).Token()
+		if err == nil {
+			return s.use(token, true), nil
+		}
+		log.Debug().Msgf("Refresh of the token of the browser login failed, logging in again: %v", err)
+	}
+	token, err := s.loginInBrowser()
+	if err != nil {
+		return nil, err
+	}
+	return s.use(token, true), nil
+}
+
+// load returns the cached token, or nil
+func (s *browserTokenSource) load() *oauth2.Token {
+	if s.login.Cache == nil {
+		return nil
+	}
+	token, err := s.login.Cache.Load(s.key)
+	if err != nil {
+		log.Debug().Msgf("No cached token of the browser login: %v", err)
+		return nil
+	}
+	return token
+}
+
+// use records the user of the token and saves a new token in the cache
+func (s *browserTokenSource) use(token *oauth2.Token, save bool) *oauth2.Token {
+	if save && s.login.Cache != nil {
+		if err := s.login.Cache.Save(s.key, token); err != nil {
+			log.Warn().Msgf("Failed to cache the token of the browser login, you will be asked to log in again: %v", err)
+		}
+	}
+	s.mu.Lock()
+	s.user = tokenUser(token.AccessToken)
+	s.mu.Unlock()
+	return token
+}
+
+// userName returns the user of the last token, e.g. for the audit log
+func (s *browserTokenSource) userName() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.user
+}
+
+// loginInBrowser opens the login page and exchanges the authorization code received by the callback for a token
+func (s *browserTokenSource) loginInBrowser() (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", s.login.CallbackPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the callback of the browser login: %w", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	conf := s.conf
+	conf.RedirectURL = fmt.Sprintf("http://localhost:%d%s", port, callbackPath)
+
+	verifier := oauth2.GenerateVerifier()
+	state := oauth2.GenerateVerifier()
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != callbackPath {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		var err error
+		switch {
+		case query.Get("error") != "":
+			err = fmt.Errorf("%s: %s", query.Get("error"), query.Get("error_description"))
+		case query.Get("state") != state:
+			err = fmt.Errorf("invalid state of the callback")
+		case query.Get("code") == "":
+			err = fmt.Errorf("no authorization code in the callback")
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<html><body><p>FlashPipe login failed: %s</p></body></html>", html.EscapeString(err.Error()))
+			select {
+			case errs <- err:
+			default:
+			}
+			return
+		}
+		fmt.Fprint(w, "<html><body><p>FlashPipe login completed, you can close this window.</p></body></html>")
+		select {
+		case codes <- query.Get("code"):
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+	// localhost may resolve to the IPv6 loopback address in the browser
+	if listener6, err := net.Listen("tcp", fmt.Sprintf("[::1]:%d", port)); err == nil {
+		go server.Serve(listener6)
+	}
+
+	url := conf.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+	log.Info().Msgf("Log in to %s in the browser, if it does not open visit %s", s.conf.Endpoint.AuthURL, url)
+	if s.login.Open != nil {
+		if err := s.login.Open(url); err != nil {
+			log.Warn().Msgf("Failed to open the browser: %v", err)
+		}
+	}
+
+	timeout := s.login.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	select {
+	case code := <-codes:
+		token, err := conf.Exchange(context.Background(), code, oauth2.VerifierOption(verifier))
+		if err != nil {
+			return nil, fmt.Errorf("failed to exchange the authorization code of the browser login: %w", err)
+		}
+		log.Info().Msgf("Logged in as %s", cmp.Or(tokenUser(token.AccessToken), "unknown user"))
+		return token, nil
+	case err := <-errs:
+		return nil, fmt.Errorf("browser login failed: %w", err)
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("browser login failed: no login within %v", timeout)
+	}
+}
+
+// tokenUser returns the user of a JWT access token from its user_name or email claim, or the empty string
+func tokenUser(accessToken string) string {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		UserName string `json:"user_name"`
+		Email    string `json:"email"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	if claims.UserName != "" {
+		return claims.UserName
+	}
+	return claims.Email
+}
+
+// authorizeURL returns the authorization endpoint next to the token endpoint, e.g. /oauth/authorize for
+// /oauth/token
+func authorizeURL(tokenURL string) string {
+	if base, ok := strings.CutSuffix(tokenURL, "/token"); ok {
+		return base + "/authorize"
+	}
+	return tokenURL
+}
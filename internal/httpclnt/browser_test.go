@@ -0,0 +1,132 @@
+package httpclnt
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+type memoryTokenCache map[string]*oauth2.Token
+
+func (c memoryTokenCache) Load(key string) (*oauth2.Token, error) {
+	return c[key], nil
+}
+
+func (c memoryTokenCache) Save(key string, token *oauth2.Token) error {
+	c[key] = token
+	return nil
+}
+
+// testJWT returns an unsigned JWT with the user_name claim
+func testJWT(user string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"user_name":%q}`, user)))
+	return "eyJhbGciOiJub25lIn0." + payload + ".sig"
+}
+
+func TestBrowserLogin(t *testing.T) {
+	var challenge string
+	var grants, received []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/authorize":
+			// The user logs in and is redirected to the callback
+			assert.Equal(t, "S256", r.FormValue("code_challenge_method"))
+			challenge = r.FormValue("code_challenge")
+			redirect, _ := url.Parse(r.FormValue("redirect_uri"))
+			assert.Equal(t, "localhost", redirect.Hostname())
+			redirect.RawQuery = url.Values{"code": {"auth-code"}, "state": {r.FormValue("state")}}.Encode()
+			http.Redirect(w, r, redirect.String(), http.StatusFound)
+		case "/oauth/token":
+			grants = append(grants, r.FormValue("grant_type"))
+			switch r.FormValue("grant_type") {
+			case "authorization_code":
+				sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+				assert.Equal(t, challenge, base64.RawURLEncoding.EncodeToString(sum[:]), "Verifier should match the challenge")
+				assert.Equal(t, "auth-code", r.FormValue("code"))
+			case "refresh_token":
+				assert.Equal(t, "refresh-1", r.FormValue("refresh_token"))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token": %q, "token_type": "bearer", "refresh_token": "refresh-1", "expires_in": 3600}`, testJWT("jane.doe@example.com"))
+		default:
+			received = append(received, r.Header.Get("Authorization"))
+		}
+	}))
+	defer svr.Close()
+
+	cache := memoryTokenCache{}
+	opened := 0
+	SetDefaultBrowserLogin(&BrowserLogin{Cache: cache, Timeout: 10 * time.Second, Open: func(loginURL string) error {
+		opened++
+		// The browser follows the redirect to the callback
+		resp, err := http.Get(loginURL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}})
+	defer SetDefaultBrowserLogin(nil)
+
+	host, port := GetHostPort(svr.URL)
+	newExecuter := func() *HTTPExecuter {
+		return New(host, "/oauth/token", "client", "", "", "", host, "http", port, true)
+	}
+	exe := newExecuter()
+	assert.Equal(t, "OAUTH_BROWSER", exe.AuthType)
+	resp, err := exe.ExecGetRequest("/api/v1/", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, opened)
+	assert.Equal(t, "jane.doe@example.com", exe.userName(), "The audit identity should be the user")
+	assert.Len(t, cache, 1)
+
+	// Later runs use the cached token and refresh it once it expired
+	resp, err = newExecuter().ExecGetRequest("/api/v1/", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	for _, token := range cache {
+		token.Expiry = time.Now().Add(-time.Minute)
+	}
+	resp, err = newExecuter().ExecGetRequest("/api/v1/", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, 1, opened, "The user should only log in once")
+	assert.Equal(t, []string{"authorization_code", "refresh_token"}, grants)
+	assert.Len(t, received, 3)
+}
+
+func TestBrowserLoginDenied(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirect, _ := url.Parse(r.FormValue("redirect_uri"))
+		redirect.RawQuery = url.Values{"error": {"access_denied"}, "error_description": {"User denied access"}, "state": {r.FormValue("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	}))
+	defer svr.Close()
+
+	source := newBrowserTokenSource(BrowserLogin{Timeout: 10 * time.Second, Open: func(loginURL string) error {
+		resp, err := http.Get(loginURL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}}, oauth2.Config{ClientID: "client", Endpoint: oauth2.Endpoint{AuthURL: svr.URL + "/oauth/authorize", TokenURL: svr.URL + "/oauth/token"}}, "client@localhost")
+	_, err := source.Token()
+	assert.EqualError(t, err, "browser login failed: access_denied: User denied access")
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	assert.Equal(t, "https://sub.authentication.eu10.hana.ondemand.com/oauth/authorize", authorizeURL("https://sub.authentication.eu10.hana.ondemand.com/oauth/token"))
+	assert.Equal(t, "https://oauthasservices-a1b2c3.hana.ondemand.com/oauth2/api/v1/authorize", authorizeURL("https://oauthasservices-a1b2c3.hana.ondemand.com/oauth2/api/v1/token"))
+	assert.Equal(t, "jane.doe@example.com", tokenUser(testJWT("jane.doe@example.com")))
+	assert.Equal(t, "", tokenUser("opaque-token"))
+}
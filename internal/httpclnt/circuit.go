@@ -0,0 +1,270 @@
+package httpclnt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrTenantUnavailable is returned for all requests of an executer once the tenant did not recover from a
+// series of failed requests within the maximum pause
+var ErrTenantUnavailable = errors.New("tenant unavailable")
+
+const (
+	// circuitWindow is the number of recent requests whose failure rate opens the circuit
+	circuitWindow = 20
+
+	// maintenanceBodySize is the size of the start of a response body that is searched for MaintenancePattern
+	maintenanceBodySize = 64 * 1024
+)
+
+// CircuitOptions configure the circuit breaker that pauses requests while the tenant is unavailable, e.g.
+// when a maintenance window starts during a run.
+type CircuitOptions struct {
+	FailureRate        int            // Percentage of the recent requests that failed which opens the circuit, 0 to disable
+	ProbeInterval      time.Duration  // Time between probe requests while the circuit is open
+	MaxPause           time.Duration  // Time after which all requests fail if the tenant did not recover
+	DetectMaintenance  bool           // Open the circuit at the first 503 response that announces maintenance
+	MaintenancePattern *regexp.Regexp // Text of 503 responses and of the status endpoint that announces maintenance
+	StatusURL          string         // Status endpoint checked before the first request and instead of probe requests
+}
+
+// defaultCircuitOptions are the circuit breaker options of executers created with New
+var defaultCircuitOptions = CircuitOptions{ProbeInterval: 30 * time.Second, MaxPause: 15 * time.Minute}
+
+// SetDefaultCircuitOptions sets the circuit breaker options of all executers created afterwards.
+func SetDefaultCircuitOptions(options CircuitOptions) {
+	defaultCircuitOptions = options
+}
+
+// circuit pauses the requests of an executer once too many recent requests failed, or once the tenant announces
+// maintenance. While it is open, one request at a time is sent as probe every ProbeInterval, the others wait. With
+// StatusURL, the status endpoint is checked instead. The circuit closes when a probe succeeds, and all requests fail
+// with ErrTenantUnavailable if no probe succeeded within MaxPause.
+type circuit struct {
+	options     CircuitOptions
+	host        string
+	mu          sync.Mutex
+	outcomes    []bool // Recent outcomes, true for failed requests
+	open        bool
+	openedAt    time.Time
+	lastProbe   time.Time
+	probing     bool
+	aborted     bool
+	maintenance string // Why the tenant is in maintenance while the circuit is open because of it
+	checkStatus sync.Once
+}
+
+func newCircuit(options CircuitOptions, host string) *circuit {
+	c := new(circuit)
+	c.options = options
+	c.host = host
+	return c
+}
+
+// failed returns true if the outcome of a request indicates that the tenant is unavailable. Responses of
+// the tenant with other codes, e.g. 404, are a matter of the individual request.
+func failed(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// enabled returns true if the circuit pauses requests at all
+func (c *circuit) enabled() bool {
+	return c.options.FailureRate > 0 || c.options.DetectMaintenance || c.options.StatusURL != ""
+}
+
+// allow waits while the circuit is open and returns true if the request is sent as probe
+func (c *circuit) allow() (probe bool, err error) {
+	if !c.enabled() {
+		return false, nil
+	}
+	c.checkStatus.Do(func() {
+		if reason := c.statusMaintenance(); reason != "" {
+			c.mu.Lock()
+			c.pause(reason)
+			c.mu.Unlock()
+		}
+	})
+	for {
+		c.mu.Lock()
+		switch {
+		case c.aborted:
+			c.mu.Unlock()
+			return false, c.err()
+		case !c.open:
+			c.mu.Unlock()
+			return false, nil
+		case time.Since(c.openedAt) >= c.options.MaxPause:
+			c.aborted = true
+			log.Error().Msgf("%v, no more requests are sent", c.err())
+			c.mu.Unlock()
+			return false, c.err()
+		case !c.probing && time.Since(c.lastProbe) >= c.options.ProbeInterval:
+			c.probing = true
+			c.lastProbe = time.Now()
+			c.mu.Unlock()
+			if c.options.StatusURL == "" {
+				return true, nil
+			}
+			reason := c.statusMaintenance()
+			c.mu.Lock()
+			c.probing = false
+			if reason == "" {
+				c.resume()
+			}
+			c.mu.Unlock()
+			continue
+		}
+		c.mu.Unlock()
+		time.Sleep(min(c.options.ProbeInterval, time.Second))
+	}
+}
+
+// record records the outcome of a request and opens or closes the circuit
+func (c *circuit) record(probe bool, resp *http.Response, err error) {
+	if !c.enabled() {
+		return
+	}
+	reason := c.responseMaintenance(resp)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	isFailure := failed(resp, err)
+	if probe {
+		c.probing = false
+		if !isFailure {
+			c.resume()
+		}
+		return
+	}
+	if c.open || c.aborted {
+		// Requests sent before the circuit opened
+		return
+	}
+	if reason != "" {
+		c.pause(reason)
+		return
+	}
+	if c.options.FailureRate <= 0 {
+		return
+	}
+	c.outcomes = append(c.outcomes, isFailure)
+	if len(c.outcomes) > circuitWindow {
+		c.outcomes = c.outcomes[1:]
+	}
+	failures := 0
+	for _, f := range c.outcomes {
+		if f {
+			failures++
+		}
+	}
+	if len(c.outcomes) == circuitWindow && failures*100 >= c.options.FailureRate*circuitWindow {
+		c.open = true
+		c.openedAt = time.Now()
+		c.lastProbe = c.openedAt
+		log.Warn().Msgf("⏸️  %d of the last %d requests to %v failed, pausing requests and probing every %v for up to %v",
+			failures, circuitWindow, c.host, c.options.ProbeInterval, c.options.MaxPause)
+	}
+}
+
+// pause opens the circuit because the tenant is in maintenance, c.mu must be held
+func (c *circuit) pause(reason string) {
+	c.open = true
+	c.openedAt = time.Now()
+	c.lastProbe = c.openedAt
+	c.maintenance = reason
+	log.Warn().Msgf("🛠️  %v is in maintenance (%v), pausing requests and checking every %v for up to %v",
+		c.host, reason, c.options.ProbeInterval, c.options.MaxPause)
+}
+
+// resume closes the circuit after a successful probe, c.mu must be held
+func (c *circuit) resume() {
+	if c.maintenance != "" {
+		log.Info().Msgf("▶️  Maintenance of %v ended after %v, resuming requests", c.host, time.Since(c.openedAt).Round(time.Second))
+	} else {
+		log.Info().Msgf("▶️  %v available again after %v, resuming requests", c.host, time.Since(c.openedAt).Round(time.Second))
+	}
+	c.open = false
+	c.outcomes = nil
+	c.maintenance = ""
+}
+
+// responseMaintenance returns why a response of the tenant announces maintenance, empty if it does not. A 503
+// response announces maintenance with a Retry-After header or a body matching MaintenancePattern. The start of
+// the body is read for the pattern and put back, so that the caller reads the whole body.
+func (c *circuit) responseMaintenance(resp *http.Response) string {
+	if !c.options.DetectMaintenance || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		return ""
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		return fmt.Sprintf("503 with Retry-After %v", retryAfter)
+	}
+	if c.options.MaintenancePattern == nil || resp.Body == nil {
+		return ""
+	}
+	start, _ := io.ReadAll(io.LimitReader(resp.Body, maintenanceBodySize))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(start), resp.Body), resp.Body}
+	if match := c.options.MaintenancePattern.Find(start); match != nil {
+		return fmt.Sprintf("503 with %q", match)
+	}
+	return ""
+}
+
+// statusMaintenance returns why the status endpoint announces maintenance, empty if it does not or there is
+// none. The endpoint announces maintenance with status 503 or a body matching MaintenancePattern. A status
+// endpoint that cannot be reached does not stop the run.
+func (c *circuit) statusMaintenance() string {
+	if c.options.StatusURL == "" {
+		return ""
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(c.options.StatusURL)
+	if err != nil {
+		log.Warn().Msgf("Status endpoint %v not available: %v", c.options.StatusURL, err)
+		return ""
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maintenanceBodySize))
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return "status endpoint responded with 503"
+	}
+	if c.options.MaintenancePattern != nil {
+		if match := c.options.MaintenancePattern.Find(body); match != nil {
+			return fmt.Sprintf("status endpoint reports %q", match)
+		}
+	}
+	return ""
+}
+
+func (c *circuit) err() error {
+	if c.maintenance != "" {
+		return fmt.Errorf("%w: maintenance of %v (%v) did not end within %v", ErrTenantUnavailable, c.host, c.maintenance, c.options.MaxPause)
+	}
+	return fmt.Errorf("%w: %v did not recover from failed requests within %v", ErrTenantUnavailable, c.host, c.options.MaxPause)
+}
+
+// abortError returns ErrTenantUnavailable once all requests fail, nil otherwise
+func (c *circuit) abortError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aborted {
+		return c.err()
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package httpclnt
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCircuitTestServer(t *testing.T, status *atomic.Int32, requests *atomic.Int32) *HTTPExecuter {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(svr.Close)
+	host, port := GetHostPort(svr.URL)
+	return New("", "", "", "", "dummy", "dummy", host, "http", port, false)
+}
+
+func TestCircuitPausesAndResumes(t *testing.T) {
+	SetDefaultCircuitOptions(CircuitOptions{FailureRate: 50, ProbeInterval: 20 * time.Millisecond, MaxPause: 10 * time.Second})
+	defer SetDefaultCircuitOptions(CircuitOptions{ProbeInterval: 30 * time.Second, MaxPause: 15 * time.Minute})
+	var status, requests atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	exe := newCircuitTestServer(t, &status, &requests)
+
+	for i := 0; i < circuitWindow; i++ {
+		resp, err := exe.ExecGetRequest("/api/v1/", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	require.True(t, exe.circuit.open, "Circuit should open after the window of failed requests")
+
+	time.AfterFunc(100*time.Millisecond, func() { status.Store(http.StatusOK) })
+	start := time.Now()
+	resp, err := exe.ExecGetRequest("/api/v1/", nil)
+	for err == nil && resp.StatusCode != http.StatusOK {
+		// Failed probes are returned to the caller
+		resp, err = exe.ExecGetRequest("/api/v1/", nil)
+	}
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "Requests should wait while the circuit is open")
+	assert.False(t, exe.circuit.open, "Successful probe should close the circuit")
+	assert.NoError(t, exe.AbortError())
+}
+
+func TestCircuitAbortsAfterMaxPause(t *testing.T) {
+	SetDefaultCircuitOptions(CircuitOptions{FailureRate: 100, ProbeInterval: 10 * time.Millisecond, MaxPause: 50 * time.Millisecond})
+	defer SetDefaultCircuitOptions(CircuitOptions{ProbeInterval: 30 * time.Second, MaxPause: 15 * time.Minute})
+	var status, requests atomic.Int32
+	status.Store(http.StatusBadGateway)
+	exe := newCircuitTestServer(t, &status, &requests)
+
+	var err error
+	for i := 0; i < 100 && err == nil; i++ {
+		_, err = exe.ExecGetRequest("/api/v1/", nil)
+	}
+	assert.ErrorIs(t, err, ErrTenantUnavailable)
+	assert.ErrorIs(t, exe.AbortError(), ErrTenantUnavailable)
+	sent := requests.Load()
+	_, err = exe.ExecGetRequest("/api/v1/", nil)
+	assert.ErrorIs(t, err, ErrTenantUnavailable)
+	assert.Equal(t, sent, requests.Load(), "No request should be sent once aborted")
+}
+
+func TestCircuitDisabled(t *testing.T) {
+	var status, requests atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	exe := newCircuitTestServer(t, &status, &requests)
+	for i := 0; i < 2*circuitWindow; i++ {
+		_, err := exe.ExecGetRequest("/api/v1/", nil)
+		require.NoError(t, err)
+	}
+	assert.False(t, exe.circuit.open)
+}
+
+func TestCircuitPausesForMaintenance(t *testing.T) {
+	SetDefaultCircuitOptions(CircuitOptions{DetectMaintenance: true, MaintenancePattern: regexp.MustCompile(`(?i)maintenance`),
+		ProbeInterval: 20 * time.Millisecond, MaxPause: 10 * time.Second})
+	defer SetDefaultCircuitOptions(CircuitOptions{ProbeInterval: 30 * time.Second, MaxPause: 15 * time.Minute})
+	var inMaintenance atomic.Bool
+	inMaintenance.Store(true)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inMaintenance.Load() {
+			http.Error(w, "Tenant is under maintenance", http.StatusServiceUnavailable)
+		}
+	}))
+	defer svr.Close()
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, false)
+
+	resp, err := exe.ExecGetRequest("/api/v1/", nil)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "Tenant is under maintenance\n", string(body), "The body of the response should be kept")
+	require.True(t, exe.circuit.open, "Circuit should open at the first response announcing maintenance")
+	assert.Equal(t, `503 with "maintenance"`, exe.circuit.maintenance)
+
+	time.AfterFunc(100*time.Millisecond, func() { inMaintenance.Store(false) })
+	start := time.Now()
+	for resp.StatusCode != http.StatusOK {
+		resp, err = exe.ExecGetRequest("/api/v1/", nil)
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "Requests should wait during the maintenance")
+	assert.False(t, exe.circuit.open, "Circuit should close when the maintenance ended")
+
+	// Without detection, 503 responses are failures like any other
+	SetDefaultCircuitOptions(CircuitOptions{FailureRate: 100, ProbeInterval: 20 * time.Millisecond, MaxPause: 10 * time.Second})
+	inMaintenance.Store(true)
+	exe = New("", "", "", "", "dummy", "dummy", host, "http", port, false)
+	_, err = exe.ExecGetRequest("/api/v1/", nil)
+	require.NoError(t, err)
+	assert.False(t, exe.circuit.open)
+}
+
+func TestCircuitStatusURL(t *testing.T) {
+	var status atomic.Value
+	status.Store(`{"status": "Maintenance"}`)
+	statusSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(status.Load().(string)))
+	}))
+	defer statusSvr.Close()
+	SetDefaultCircuitOptions(CircuitOptions{StatusURL: statusSvr.URL, MaintenancePattern: regexp.MustCompile(`(?i)maintenance`),
+		ProbeInterval: 20 * time.Millisecond, MaxPause: 10 * time.Second})
+	defer SetDefaultCircuitOptions(CircuitOptions{ProbeInterval: 30 * time.Second, MaxPause: 15 * time.Minute})
+	var code, requests atomic.Int32
+	code.Store(http.StatusOK)
+	exe := newCircuitTestServer(t, &code, &requests)
+
+	time.AfterFunc(100*time.Millisecond, func() { status.Store(`{"status": "Operational"}`) })
+	start := time.Now()
+	resp, err := exe.ExecGetRequest("/api/v1/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "The first request should wait until the maintenance ended")
+	assert.Equal(t, int32(1), requests.Load(), "No request should be sent to the tenant during the maintenance")
+
+	// A status endpoint that cannot be reached does not stop the run
+	statusSvr.Close()
+	exe = newCircuitTestServer(t, &code, &requests)
+	_, err = exe.ExecGetRequest("/api/v1/", nil)
+	assert.NoError(t, err)
+	assert.False(t, exe.circuit.open)
+}
@@ -0,0 +1,98 @@
+package httpclnt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// defaultHeaders are set on the requests of tenant executers, e.g. API keys of a gateway in front of the tenant
+var defaultHeaders map[string]string
+
+// defaultSignCommand signs the requests of tenant executers
+var defaultSignCommand string
+
+// SetDefaultHeaders sets the headers sent with every request of all tenant executers created afterwards. Headers
+// of the individual requests take precedence.
+func SetDefaultHeaders(headers map[string]string) {
+	defaultHeaders = headers
+}
+
+// SetDefaultSignCommand sets the command that signs every request of all tenant executers created afterwards,
+// empty to not sign requests. See sign for the interface of the command.
+func SetDefaultSignCommand(command string) {
+	defaultSignCommand = command
+}
+
+// UseTenantDefaults makes the executer a tenant executer: its requests get the default headers and are signed
+// with the default sign command, and without credentials it authenticates with the default token command.
+// Executers of other services, e.g. analytics, ServiceNow or the Destination service, are not tenant
+// executers, so that the headers and tokens meant for the tenant are not sent to them.
+func (e *HTTPExecuter) UseTenantDefaults() {
+	e.headers = defaultHeaders
+	e.signCommand = defaultSignCommand
+	if e.AuthType == "BASIC" && e.basicUserId == "" && defaultTokenCommand != "" {
+		e.useTokenCommand(defaultTokenCommand)
+	}
+}
+
+// ParseHeader parses a header given as "Name: Value"
+func ParseHeader(header string) (string, string, error) {
+	name, value, found := strings.Cut(header, ":")
+	if !found || strings.TrimSpace(name) == "" {
+		return "", "", fmt.Errorf("invalid header %q, expected Name: Value", header)
+	}
+	return strings.TrimSpace(name), strings.TrimSpace(value), nil
+}
+
+// sign runs the sign command for a request and adds the headers it prints, one "Name: Value" per line. The
+// request body is passed on stdin, the method, URL and path in the environment variables
+// FLASHPIPE_REQUEST_METHOD, FLASHPIPE_REQUEST_URL and FLASHPIPE_REQUEST_PATH.
+func (e *HTTPExecuter) sign(req *http.Request, body []byte) error {
+	output, err := runShell("sign", e.signCommand, body,
+		"FLASHPIPE_REQUEST_METHOD="+req.Method,
+		"FLASHPIPE_REQUEST_URL="+req.URL.String(),
+		"FLASHPIPE_REQUEST_PATH="+req.URL.RequestURI(),
+	)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, err := ParseHeader(line)
+		if err != nil {
+			return fmt.Errorf("sign command: %w", err)
+		}
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+// runShell runs the command with the shell of the platform, passing stdin and the environment variables in
+// addition to those of flashpipe, and returns its output. The error of a failed command contains its stderr.
+func runShell(name string, command string, stdin []byte, env ...string) ([]byte, error) {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", command)
+	} else {
+		c = exec.Command("sh", "-c", command)
+	}
+	c.Stdin = bytes.NewReader(stdin)
+	c.Env = append(os.Environ(), env...)
+	output, err := c.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%s command failed: %w: %s", name, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("%s command failed: %w", name, err)
+	}
+	return output, nil
+}
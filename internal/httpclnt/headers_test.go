@@ -0,0 +1,62 @@
+package httpclnt
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultHeadersAndSignCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sign command uses sh")
+	}
+	var received http.Header
+	var body string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		content, _ := io.ReadAll(r.Body)
+		body = string(content)
+	}))
+	defer svr.Close()
+
+	SetDefaultHeaders(map[string]string{"X-Api-Key": "secret", "Accept": "text/plain"})
+	SetDefaultSignCommand(`echo "X-Signature: $FLASHPIPE_REQUEST_METHOD $FLASHPIPE_REQUEST_PATH $(cat)"`)
+	defer SetDefaultHeaders(nil)
+	defer SetDefaultSignCommand("")
+
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	resp, err := exe.ExecGetRequest("/api/v1/Flows", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, received.Get("X-Api-Key"), "Executers other than tenant executers should not send the headers")
+	assert.Empty(t, received.Get("X-Signature"), "Executers other than tenant executers should not sign requests")
+
+	exe.UseTenantDefaults()
+	resp, err = exe.ExecRequestWithCookies(http.MethodPost, "/api/v1/Flows?x=1", strings.NewReader("payload"), map[string]string{"Accept": "application/json"}, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "secret", received.Get("X-Api-Key"))
+	assert.Equal(t, "application/json", received.Get("Accept"), "Headers of the request should take precedence")
+	assert.Equal(t, "POST /api/v1/Flows?x=1 payload", received.Get("X-Signature"))
+	assert.Equal(t, "payload", body, "Body should still be sent after signing")
+
+	SetDefaultSignCommand("echo not a header")
+	exe = New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	exe.UseTenantDefaults()
+	_, err = exe.ExecGetRequest("/api/v1/Flows", nil)
+	assert.EqualError(t, err, `sign command: invalid header "not a header", expected Name: Value`)
+
+	SetDefaultSignCommand("echo denied >&2; exit 1")
+	exe = New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	exe.UseTenantDefaults()
+	_, err = exe.ExecGetRequest("/api/v1/Flows", nil)
+	assert.EqualError(t, err, "sign command failed: exit status 1: denied")
+}
@@ -1,25 +1,51 @@
 package httpclnt
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/engswee/flashpipe/internal/audit"
+	"github.com/engswee/flashpipe/internal/telemetry"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
 
 type HTTPExecuter struct {
-	basicUserId   string
-	basicPassword string
-	host          string
-	scheme        string
-	port          int
-	httpClient    *http.Client
-	AuthType      string
-	showLogs      bool
+	basicUserId         string
+	basicPassword       string
+	user                string
+	host                string
+	scheme              string
+	port                int
+	httpClient          *http.Client
+	AuthType            string
+	platform            string
+	odataVersion        string
+	showLogs            bool
+	latencyMutex        sync.Mutex
+	latencies           []time.Duration
+	maxRespSize         int64 // Maximum size of response bodies in bytes, 0 for no limit
+	headers             map[string]string
+	signCommand         string
+	newTokenClient      func() *http.Client // Returns a client with a new token, nil without token authentication
+	csrfFetcher         func() (string, []*http.Cookie, error)
+	authMutex           sync.Mutex
+	authFailures        int // Consecutive requests rejected with 401
+	maxAuthFailures     int
+	circuit             *circuit
+	policies            map[string]RequestPolicy
+	family              func(path string) string
+	batchDiagnosticsDir string        // Folder the payloads of failed batch requests are written to, empty to not write them
+	batchParallelism    int           // Batch requests sent at a time by ExecuteInBatches
+	tokenUser           func() string // Returns the user of the browser login, nil for other authentication
 }
 
 // New returns an initialised HTTPExecuter instance.
@@ -29,6 +55,14 @@ func New(oauthHost string, oauthPath string, clientId string, clientSecret strin
 	e.scheme = scheme
 	e.port = port
 	e.showLogs = showLogs
+	e.user = userId
+	e.maxRespSize = defaultMaxResponseSize
+	e.maxAuthFailures = defaultMaxAuthFailures
+	e.circuit = newCircuit(defaultCircuitOptions, host)
+	e.policies = defaultPolicies
+	e.family = defaultFamily
+	e.batchDiagnosticsDir = defaultBatchDiagnosticsDir
+	e.batchParallelism = defaultBatchParallelism
 	if oauthHost != "" {
 		if showLogs {
 			log.Debug().Msg("Initialising HTTP client with OAuth 2.0")
@@ -46,9 +80,35 @@ func New(oauthHost string, oauthPath string, clientId string, clientSecret strin
 			TokenURL:     tokenURL,
 		}
 
-		ctx := context.Background()
-		e.httpClient = conf.Client(ctx)
-		e.AuthType = "OAUTH"
+		if defaultBrowserLogin != nil {
+			if showLogs {
+				log.Debug().Msg("Logging in the user in the browser with the authorization code flow")
+			}
+			source := newBrowserTokenSource(*defaultBrowserLogin, oauth2.Config{
+				ClientID:     clientId,
+				ClientSecret: clientSecret,
+				Endpoint:     oauth2.Endpoint{AuthURL: authorizeURL(tokenURL), TokenURL: tokenURL},
+			}, BrowserTokenKey(oauthHost, clientId))
+			e.newTokenClient = func() *http.Client {
+				return oauth2.NewClient(context.Background(), oauth2.ReuseTokenSource(nil, source))
+			}
+			e.tokenUser = source.userName
+			e.AuthType = "OAUTH_BROWSER"
+		} else if defaultSamlAssertionCommand != "" {
+			if showLogs {
+				log.Debug().Msg("Exchanging SAML assertions of the SAML assertion command for OAuth tokens")
+			}
+			source := &samlBearerTokenSource{command: defaultSamlAssertionCommand, host: host, conf: *conf}
+			e.newTokenClient = func() *http.Client {
+				return oauth2.NewClient(context.Background(), oauth2.ReuseTokenSource(nil, source))
+			}
+			e.AuthType = "OAUTH_SAML_BEARER"
+		} else {
+			e.newTokenClient = func() *http.Client { return conf.Client(context.Background()) }
+			e.AuthType = "OAUTH"
+		}
+		e.httpClient = e.newTokenClient()
+		e.user = clientId
 	} else {
 		if showLogs {
 			log.Debug().Msg("Initialising HTTP client with Basic Authentication")
@@ -61,13 +121,73 @@ func New(oauthHost string, oauthPath string, clientId string, clientSecret strin
 	return e
 }
 
+// ExecRequestWithCookies executes a request. A request rejected because of an expired OAuth token or CSRF
+// token is retried once with a new token. Once too many requests in a row are rejected with 401, no more
+// requests are sent and ErrTooManyAuthFailures is returned. Requests wait while the circuit breaker is open,
+// see CircuitOptions. The timeout and retries of the request are those of the policy of its API family, see
+// RequestPolicy.
 func (e *HTTPExecuter) ExecRequestWithCookies(method string, path string, body io.Reader, headers map[string]string, cookies []*http.Cookie) (resp *http.Response, err error) {
+	family, policy := e.policy(path)
+	for attempt := 1; ; attempt++ {
+		resp, err = e.execAttempt(method, path, body, headers, cookies, policy.Timeout)
+		if attempt > policy.Retries || !retryableResult(resp, err) || e.AbortError() != nil || !rewindBody(body) {
+			return resp, err
+		}
+		if err != nil {
+			log.Warn().Msgf("%v %v failed: %v, retrying in %v (%v, attempt %d of %d)", method, path, err, policy.RetryDelay, family, attempt+1, policy.Retries+1)
+		} else {
+			resp.Body.Close()
+			log.Warn().Msgf("%v %v failed with response code = %d, retrying in %v (%v, attempt %d of %d)", method, path, resp.StatusCode, policy.RetryDelay, family, attempt+1, policy.Retries+1)
+		}
+		time.Sleep(policy.RetryDelay)
+	}
+}
+
+// execAttempt executes a request once, apart from the retry with a new token
+func (e *HTTPExecuter) execAttempt(method string, path string, body io.Reader, headers map[string]string, cookies []*http.Cookie, timeout time.Duration) (resp *http.Response, err error) {
+	if err = e.AuthError(); err != nil {
+		return
+	}
+	probe, err := e.circuit.allow()
+	if err != nil {
+		return
+	}
+	resp, err = e.execRequest(method, path, body, headers, cookies, timeout)
+	defer func() { e.circuit.record(probe, resp, err) }()
+	if err == nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+		retryHeaders := maps.Clone(headers)
+		if e.prepareRetry(resp, body, retryHeaders, &cookies) {
+			resp.Body.Close()
+			resp, err = e.execRequest(method, path, body, retryHeaders, cookies, timeout)
+		}
+	}
+	if err == nil {
+		e.recordAuthResult(resp)
+		if resp.StatusCode == http.StatusUnauthorized {
+			if authErr := e.AuthError(); authErr != nil {
+				log.Error().Msgf("%v, no more requests are sent", authErr)
+			}
+		}
+	}
+	return resp, err
+}
+
+func (e *HTTPExecuter) execRequest(method string, path string, body io.Reader, headers map[string]string, cookies []*http.Cookie, timeout time.Duration) (resp *http.Response, err error) {
 
 	url := fmt.Sprintf("%v://%v:%d%v", e.scheme, e.host, e.port, path)
 	if e.showLogs {
 		log.Debug().Msgf("Executing HTTP request: %v %v", method, url)
 	}
 
+	// The body is buffered to be passed to the sign command
+	var content []byte
+	if e.signCommand != "" && body != nil && body != http.NoBody {
+		if content, err = io.ReadAll(body); err != nil {
+			return
+		}
+		body = bytes.NewReader(content)
+	}
+
 	// Create new HTTP request
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
@@ -79,7 +199,10 @@ func (e *HTTPExecuter) ExecRequestWithCookies(method string, path string, body i
 		req.SetBasicAuth(e.basicUserId, e.basicPassword)
 	}
 
-	// Set HTTP headers
+	// Set HTTP headers, the headers of the request take precedence over the configured ones
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
@@ -92,28 +215,152 @@ func (e *HTTPExecuter) ExecRequestWithCookies(method string, path string, body i
 	}
 
 	// Execute HTTP request
-	return e.httpClient.Do(req)
+	span := telemetry.StartSpan("HTTP "+method, "http.request.method", method, "url.path", path, "server.address", e.host)
+	if span != nil {
+		req.Header.Set("traceparent", span.TraceParent())
+	}
+	if e.signCommand != "" {
+		if err = e.sign(req, content); err != nil {
+			span.End(err)
+			return
+		}
+	}
+	client := e.client()
+	if timeout > 0 {
+		withTimeout := *client
+		withTimeout.Timeout = timeout
+		client = &withTimeout
+	}
+	start := time.Now()
+	resp, err = client.Do(req)
+	e.recordLatency(time.Since(start))
+	recordRequest(method, start, resp, err, span)
+	if audit.IsModifying(method) {
+		e.recordAudit(method, url, path, resp, err)
+	}
+	return resp, err
+}
+
+// userName returns the user of the requests, the user of the browser login once it is logged in
+func (e *HTTPExecuter) userName() string {
+	if e.tokenUser != nil {
+		if user := e.tokenUser(); user != "" {
+			return user
+		}
+	}
+	return e.user
+}
+
+// recordAudit appends a modifying request to the audit log. A failure to write the audit log is logged, as
+// the request has already been executed.
+func (e *HTTPExecuter) recordAudit(method string, url string, path string, resp *http.Response, err error) {
+	entry := audit.Entry{
+		User:     e.userName(),
+		Method:   method,
+		URL:      url,
+		Artifact: audit.ArtifactFromPath(path),
+		Outcome:  audit.OutcomeFailure,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Status = resp.StatusCode
+		if resp.StatusCode < 400 {
+			entry.Outcome = audit.OutcomeSuccess
+		}
+	}
+	if auditErr := audit.Record(entry); auditErr != nil {
+		log.Error().Msgf("Audit log: %v", auditErr)
+	}
+}
+
+func recordRequest(method string, start time.Time, resp *http.Response, err error, span *telemetry.Span) {
+	status := "error"
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+		span.SetAttribute("http.response.status_code", status)
+		if err == nil && resp.StatusCode >= 400 {
+			err = fmt.Errorf("response code = %d", resp.StatusCode)
+		}
+	}
+	telemetry.IncCounter("flashpipe_http_requests_total", "Number of HTTP requests to SAP APIs by method and response code.", 1, "method", method, "code", status)
+	telemetry.Observe("flashpipe_http_request_duration_seconds", "Latency of HTTP requests to SAP APIs.", time.Since(start).Seconds(), "method", method)
+	span.End(err)
+}
+
+func (e *HTTPExecuter) recordLatency(latency time.Duration) {
+	e.latencyMutex.Lock()
+	defer e.latencyMutex.Unlock()
+	e.latencies = append(e.latencies, latency)
+}
+
+// TakeLatencies returns the latencies of the requests executed since the last call and clears them.
+func (e *HTTPExecuter) TakeLatencies() []time.Duration {
+	e.latencyMutex.Lock()
+	defer e.latencyMutex.Unlock()
+	latencies := e.latencies
+	e.latencies = nil
+	return latencies
+}
+
+// Host returns the host requests are sent to.
+func (e *HTTPExecuter) Host() string {
+	return e.host
+}
+
+// Platform returns the platform of the tenant, set by SetPlatform.
+func (e *HTTPExecuter) Platform() string {
+	return e.platform
+}
+
+// SetPlatform sets the platform of the tenant (Neo or Cloud Foundry) the api package adapts requests to.
+func (e *HTTPExecuter) SetPlatform(platform string) {
+	e.platform = platform
+}
+
+// ODataVersion returns the version of the OData APIs of the tenant, set by SetODataVersion.
+func (e *HTTPExecuter) ODataVersion() string {
+	return e.odataVersion
+}
+
+// SetODataVersion sets the version of the OData APIs (v2 or v4) the api package sends requests to.
+func (e *HTTPExecuter) SetODataVersion(version string) {
+	e.odataVersion = version
 }
 
 func (e *HTTPExecuter) ExecGetRequest(path string, headers map[string]string) (resp *http.Response, err error) {
 	return e.ExecRequestWithCookies(http.MethodGet, path, http.NoBody, headers, nil)
 }
 
+// ReadRespBody reads the response body into memory, failing with ErrResponseTooLarge if it exceeds the
+// maximum response size. Use SaveRespBody for large content.
 func (e *HTTPExecuter) ReadRespBody(resp *http.Response) ([]byte, error) {
 	defer resp.Body.Close()
 
-	return io.ReadAll(resp.Body)
+	return io.ReadAll(e.limitBody(resp.Body))
 }
 
-func (e *HTTPExecuter) LogError(resp *http.Response, callType string) (resBody []byte, err error) {
-	resBody, err = e.ReadRespBody(resp)
+// ReadErrorBody reads the body of an error response and logs it
+func (e *HTTPExecuter) ReadErrorBody(resp *http.Response) ([]byte, error) {
+	resBody, err := e.ReadRespBody(resp)
 	if err != nil {
-		return
+		return resBody, err
 	}
 
 	if len(resBody) != 0 && e.showLogs {
 		log.Warn().Msgf("Response body = %s", resBody)
 	}
+	return resBody, nil
+}
+
+func (e *HTTPExecuter) LogError(resp *http.Response, callType string) (resBody []byte, err error) {
+	resBody, err = e.ReadErrorBody(resp)
+	if err != nil {
+		return
+	}
 
+	if IsLockedResponse(resBody) {
+		return resBody, fmt.Errorf("%v call failed with response code = %d: %w", callType, resp.StatusCode, ErrLocked)
+	}
 	return resBody, fmt.Errorf("%v call failed with response code = %d", callType, resp.StatusCode)
 }
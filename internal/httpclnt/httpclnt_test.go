@@ -2,11 +2,16 @@ package httpclnt
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/engswee/flashpipe/internal/audit"
 )
 
 func TestMockOauth(t *testing.T) {
@@ -148,6 +153,54 @@ func TestMockBasicAuthIDNotFound(t *testing.T) {
 	}
 }
 
+func TestMockAuditLog(t *testing.T) {
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationPackages('Dummy')", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+	svr := httptest.NewServer(mux)
+
+	defer svr.Close()
+
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := audit.Init(audit.Options{File: auditFile, HashChain: true}); err != nil {
+		t.Fatalf("Audit log initialisation failed with error - %v", err)
+	}
+	defer audit.Init(audit.Options{})
+
+	// Initialise HTTP executer
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummyuser", "dummypassword", host, "http", port, true)
+
+	// Only the modifying request is recorded
+	if _, err := exe.ExecGetRequest("/api/v1/IntegrationPackages('Dummy')", nil); err != nil {
+		t.Fatalf("HTTP call failed with error - %v", err)
+	}
+	if _, err := exe.ExecRequestWithCookies(http.MethodDelete, "/api/v1/IntegrationPackages('Dummy')", http.NoBody, nil, nil); err != nil {
+		t.Fatalf("HTTP call failed with error - %v", err)
+	}
+
+	content, err := os.ReadFile(auditFile)
+	if err != nil {
+		t.Fatalf("Audit log not written - %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Audit log has %d entries instead of 1", len(lines))
+	}
+	for _, expected := range []string{`"user":"dummyuser"`, `"method":"DELETE"`, `"artifact":"Dummy"`, `"status":202`, `"outcome":"success"`} {
+		if !strings.Contains(lines[0], expected) {
+			t.Fatalf("Audit entry %s does not contain %s", lines[0], expected)
+		}
+	}
+	if _, err := audit.Verify(auditFile); err != nil {
+		t.Fatalf("Audit log verification failed with error - %v", err)
+	}
+}
+
 func TestOauth(t *testing.T) {
 	host := os.Getenv("FLASHPIPE_TMN_HOST")
 	oauthHost := os.Getenv("FLASHPIPE_OAUTH_HOST")
@@ -185,3 +238,25 @@ func TestBasicAuth(t *testing.T) {
 		t.Fatalf("HTTP call failed with response code - %v", resp.StatusCode)
 	}
 }
+
+func TestLogErrorLocked(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusLocked)
+		w.Write([]byte(`{ "error": { "message": { "value": "Integration flow is currently being edited by another user" } } }`))
+	}))
+	defer svr.Close()
+
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, false)
+	resp, err := exe.ExecGetRequest("/api/v1/IntegrationDesigntimeArtifacts", nil)
+	if err != nil {
+		t.Fatalf("HTTP call failed with error: %v", err)
+	}
+	_, err = exe.LogError(resp, "Update configuration parameter")
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Error %v should be ErrLocked", err)
+	}
+	if IsLockedResponse([]byte(`{ "error": "Not found" }`)) {
+		t.Fatalf("Other errors should not be locked")
+	}
+}
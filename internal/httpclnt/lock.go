@@ -0,0 +1,17 @@
+package httpclnt
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ErrLocked is returned when a call fails because the artifact is locked or being edited by another user
+var ErrLocked = errors.New("artifact locked by another user")
+
+// lockedPattern matches the error messages of the tenant for artifacts opened in edit mode in the Web UI
+var lockedPattern = regexp.MustCompile(`(?i)locked by|is locked|being edited|edited by another|checked out by`)
+
+// IsLockedResponse returns true if the error response body reports that the artifact is locked by another user
+func IsLockedResponse(body []byte) bool {
+	return lockedPattern.Match(body)
+}
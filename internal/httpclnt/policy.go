@@ -0,0 +1,64 @@
+package httpclnt
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// RequestPolicy is the timeout and retry policy of the requests of an API family, e.g. deployment status polling
+// that tolerates long waits or configuration updates that should fail fast and be retried.
+type RequestPolicy struct {
+	Timeout    time.Duration // Timeout of a request including reading the response, 0 for the timeout of the client
+	Retries    int           // Retries of requests that failed with a connection error, a timeout, 429, 502, 503 or 504
+	RetryDelay time.Duration // Time between retries
+}
+
+// defaultPolicies are the request policies of executers created with New by API family, and defaultFamily
+// returns the API family of a request path
+var (
+	defaultPolicies map[string]RequestPolicy
+	defaultFamily   func(path string) string
+)
+
+// SetDefaultRequestPolicies sets the request policies by API family of all executers created afterwards, and the
+// function that returns the API family of a request path. Requests of families without policy are sent once with
+// the timeout of the client.
+func SetDefaultRequestPolicies(policies map[string]RequestPolicy, family func(path string) string) {
+	defaultPolicies = policies
+	defaultFamily = family
+}
+
+// policy returns the request policy of the path
+func (e *HTTPExecuter) policy(path string) (string, RequestPolicy) {
+	if e.family == nil || len(e.policies) == 0 {
+		return "", RequestPolicy{}
+	}
+	family := e.family(path)
+	return family, e.policies[family]
+}
+
+// retryableResult returns true if a request may succeed when it is sent again
+func retryableResult(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewindBody prepares the body to be sent again and returns false if it cannot be sent again
+func rewindBody(body io.Reader) bool {
+	if body == nil || body == http.NoBody {
+		return true
+	}
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		return false
+	}
+	_, err := seeker.Seek(0, io.SeekStart)
+	return err == nil
+}
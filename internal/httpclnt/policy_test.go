@@ -0,0 +1,72 @@
+package httpclnt
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestPolicies(t *testing.T) {
+	// Batch requests fail twice with 503 before they succeed, status requests answer after 100 ms
+	var batchBodies []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/batch":
+			body, _ := io.ReadAll(r.Body)
+			batchBodies = append(batchBodies, string(body))
+			if len(batchBodies) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/status":
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer svr.Close()
+
+	SetDefaultRequestPolicies(map[string]RequestPolicy{
+		"configuration": {Retries: 2, RetryDelay: time.Millisecond},
+		"deployment":    {Timeout: 20 * time.Millisecond},
+	}, func(path string) string {
+		switch path {
+		case "/batch":
+			return "configuration"
+		case "/status":
+			return "deployment"
+		}
+		return ""
+	})
+	defer SetDefaultRequestPolicies(nil, nil)
+
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, false)
+	resp, err := exe.ExecRequestWithCookies(http.MethodPost, "/batch", bytes.NewReader([]byte("changeset")), nil, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Request should succeed with the second retry")
+	assert.Equal(t, []string{"changeset", "changeset", "changeset"}, batchBodies, "Body should be sent again with each retry")
+
+	_, err = exe.ExecGetRequest("/status", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Client.Timeout exceeded", "Request should fail with the timeout of its family")
+
+	// Bodies that cannot be sent again are not retried
+	batchBodies = nil
+	resp, err = exe.ExecRequestWithCookies(http.MethodPost, "/batch", io.NopCloser(strings.NewReader("changeset")), nil, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Len(t, batchBodies, 1)
+
+	// Requests of other families are sent once with the timeout of the client
+	exe = New("", "", "", "", "dummy", "dummy", host, "http", port, false)
+	resp, err = exe.ExecGetRequest("/other", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
@@ -0,0 +1,91 @@
+package httpclnt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the maximum response size
+var ErrResponseTooLarge = errors.New("response body exceeds maximum size")
+
+// defaultMaxResponseSize is the maximum response size of executers created with New
+var defaultMaxResponseSize int64
+
+// SetDefaultMaxResponseSize sets the maximum size of response bodies in bytes of all executers created
+// afterwards, 0 for no limit.
+func SetDefaultMaxResponseSize(size int64) {
+	defaultMaxResponseSize = size
+}
+
+// SetMaxResponseSize limits the size of response bodies read or saved to size bytes, 0 for no limit.
+func (e *HTTPExecuter) SetMaxResponseSize(size int64) {
+	e.maxRespSize = size
+}
+
+// MaxResponseSize returns the maximum size of response bodies in bytes, 0 for no limit.
+func (e *HTTPExecuter) MaxResponseSize() int64 {
+	return e.maxRespSize
+}
+
+// SaveRespBody streams the response body to targetFile without buffering it in memory. The content is
+// written to a temporary file in the same directory first, so that targetFile is not left incomplete
+// if the download fails or exceeds the maximum response size.
+func (e *HTTPExecuter) SaveRespBody(resp *http.Response, targetFile string) (written int64, err error) {
+	defer resp.Body.Close()
+
+	if err = os.MkdirAll(filepath.Dir(targetFile), os.ModePerm); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(targetFile), "."+filepath.Base(targetFile)+"-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if written, err = io.Copy(tmp, e.limitBody(resp.Body)); err != nil {
+		return written, err
+	}
+	if err = tmp.Close(); err != nil {
+		return written, err
+	}
+	return written, os.Rename(tmp.Name(), targetFile)
+}
+
+// limitBody returns a reader that fails with ErrResponseTooLarge after the maximum response size
+func (e *HTTPExecuter) limitBody(body io.Reader) io.Reader {
+	if e.maxRespSize <= 0 {
+		return body
+	}
+	return &limitedReader{r: body, remaining: e.maxRespSize, max: e.maxRespSize}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w of %d bytes", ErrResponseTooLarge, l.max)
+	}
+	// Read one byte more than allowed to detect bodies that exceed the limit
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), fmt.Errorf("%w of %d bytes", ErrResponseTooLarge, l.max)
+	}
+	return n, err
+}
@@ -0,0 +1,57 @@
+package httpclnt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveRespBodyLimit(t *testing.T) {
+	content := strings.Repeat("x", 1000)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer svr.Close()
+
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+	targetFile := filepath.Join(t.TempDir(), "content", "artifact.zip")
+
+	resp, err := exe.ExecGetRequest("/", nil)
+	if err != nil {
+		t.Fatalf("HTTP call failed with error - %v", err)
+	}
+	written, err := exe.SaveRespBody(resp, targetFile)
+	if err != nil {
+		t.Fatalf("Saving response failed with error - %v", err)
+	}
+	if data, _ := os.ReadFile(targetFile); written != 1000 || string(data) != content {
+		t.Fatalf("Incorrect content saved, %d bytes written", written)
+	}
+
+	exe.SetMaxResponseSize(999)
+	os.Remove(targetFile)
+	resp, err = exe.ExecGetRequest("/", nil)
+	if err != nil {
+		t.Fatalf("HTTP call failed with error - %v", err)
+	}
+	if _, err = exe.SaveRespBody(resp, targetFile); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("Expected ErrResponseTooLarge, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(targetFile)); len(entries) != 0 {
+		t.Fatalf("Incomplete download should be removed, found %d files", len(entries))
+	}
+
+	exe.SetMaxResponseSize(1000)
+	resp, err = exe.ExecGetRequest("/", nil)
+	if err != nil {
+		t.Fatalf("HTTP call failed with error - %v", err)
+	}
+	if body, err := exe.ReadRespBody(resp); err != nil || len(body) != 1000 {
+		t.Fatalf("Response of maximum size should be read, got %d bytes and error %v", len(body), err)
+	}
+}
@@ -0,0 +1,73 @@
+package httpclnt
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// samlBearerGrantType is the grant type of the OAuth 2.0 SAML bearer assertion flow (RFC 7522)
+const samlBearerGrantType = "urn:ietf:params:oauth:grant-type:saml2-bearer"
+
+// defaultSamlAssertionCommand obtains the SAML assertions of executers created with New with OAuth credentials
+var defaultSamlAssertionCommand string
+
+// SetDefaultSamlAssertionCommand sets the command that prints the SAML assertion exchanged for the OAuth tokens of
+// all executers created afterwards with OAuth credentials, empty to use client credentials. See
+// samlBearerTokenSource for the interface of the command.
+func SetDefaultSamlAssertionCommand(command string) {
+	defaultSamlAssertionCommand = command
+}
+
+// samlBearerTokenSource obtains tokens with the SAML bearer assertion flow, for service keys whose client is
+// not allowed to use client credentials, e.g. for principal propagation. The command is run with the tenant
+// host in the environment variable FLASHPIPE_TENANT_HOST and prints the SAML assertion, either as XML or
+// already base64 encoded. A new assertion is requested for every token, as assertions are usually only valid
+// for a few minutes.
+type samlBearerTokenSource struct {
+	command string
+	host    string
+	conf    clientcredentials.Config
+}
+
+// Token runs the command and exchanges the assertion it prints for a token at the token URL
+func (s *samlBearerTokenSource) Token() (*oauth2.Token, error) {
+	output, err := runShell("SAML assertion", s.command, nil, "FLASHPIPE_TENANT_HOST="+s.host)
+	if err != nil {
+		return nil, err
+	}
+	assertion, err := encodeSamlAssertion(output)
+	if err != nil {
+		return nil, err
+	}
+	conf := s.conf
+	conf.EndpointParams = map[string][]string{
+		"grant_type": {samlBearerGrantType},
+		"assertion":  {assertion},
+	}
+	token, err := conf.Token(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange SAML assertion for a token: %w", err)
+	}
+	return token, nil
+}
+
+// encodeSamlAssertion returns the assertion in base64url encoding, as required by the token endpoint. Assertions
+// that are already encoded are returned as they are.
+func encodeSamlAssertion(output []byte) (string, error) {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return "", fmt.Errorf("SAML assertion command printed no assertion")
+	}
+	if strings.HasPrefix(trimmed, "<") {
+		return base64.RawURLEncoding.EncodeToString([]byte(trimmed)), nil
+	}
+	if strings.ContainsAny(trimmed, " \t\r\n") {
+		return "", fmt.Errorf("SAML assertion command printed more than an assertion")
+	}
+	return trimmed, nil
+}
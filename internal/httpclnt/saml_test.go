@@ -0,0 +1,59 @@
+package httpclnt
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamlAssertionCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SAML assertion command uses sh")
+	}
+	var grants, assertions, received []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token" {
+			user, password, _ := r.BasicAuth()
+			assert.Equal(t, "client", user)
+			assert.Equal(t, "secret", password)
+			grants = append(grants, r.FormValue("grant_type"))
+			assertions = append(assertions, r.FormValue("assertion"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token": "saml-token", "token_type": "bearer", "expires_in": 3600}`))
+			return
+		}
+		received = append(received, r.Header.Get("Authorization"))
+	}))
+	defer svr.Close()
+
+	SetDefaultSamlAssertionCommand(`echo "<saml2:Assertion>$FLASHPIPE_TENANT_HOST</saml2:Assertion>"`)
+	defer SetDefaultSamlAssertionCommand("")
+
+	host, port := GetHostPort(svr.URL)
+	exe := New(host, "/oauth/token", "client", "secret", "", "", host, "http", port, true)
+	assert.Equal(t, "OAUTH_SAML_BEARER", exe.AuthType)
+	for range 2 {
+		resp, err := exe.ExecGetRequest("/api/v1/", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, []string{"Bearer saml-token", "Bearer saml-token"}, received, "Token should be reused until it expires")
+	assert.Equal(t, []string{samlBearerGrantType}, grants)
+	assert.Equal(t, []string{base64.RawURLEncoding.EncodeToString([]byte("<saml2:Assertion>" + host + "</saml2:Assertion>"))}, assertions)
+}
+
+func TestEncodeSamlAssertion(t *testing.T) {
+	encoded, err := encodeSamlAssertion([]byte("PHNhbWw+\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "PHNhbWw+", encoded, "Encoded assertions should be passed as they are")
+
+	_, err = encodeSamlAssertion([]byte("  \n"))
+	assert.EqualError(t, err, "SAML assertion command printed no assertion")
+	_, err = encodeSamlAssertion([]byte("Error: no session"))
+	assert.EqualError(t, err, "SAML assertion command printed more than an assertion")
+}
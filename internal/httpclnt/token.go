@@ -0,0 +1,113 @@
+package httpclnt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+)
+
+// defaultTokenCommand obtains the bearer tokens of tenant executers without credentials, see UseTenantDefaults
+var defaultTokenCommand string
+
+// SetDefaultTokenCommand sets the command that prints the bearer token for all tenant executers created
+// afterwards without Basic Auth or OAuth credentials, empty to not use a token command. See commandTokenSource
+// for the interface of the command.
+func SetDefaultTokenCommand(command string) {
+	defaultTokenCommand = command
+}
+
+// useTokenCommand authenticates the requests with the bearer tokens of the token command
+func (e *HTTPExecuter) useTokenCommand(command string) {
+	if e.showLogs {
+		log.Debug().Msg("Initialising HTTP client with tokens of the token command")
+	}
+	source := &commandTokenSource{command: command, host: e.host}
+	e.newTokenClient = func() *http.Client {
+		return oauth2.NewClient(context.Background(), oauth2.ReuseTokenSource(nil, source))
+	}
+	e.httpClient = e.newTokenClient()
+	e.basicUserId = ""
+	e.basicPassword = ""
+	e.AuthType = "TOKEN_COMMAND"
+	e.user = "token command"
+}
+
+// commandTokenSource obtains tokens from a helper command, e.g. for a custom security token service. The
+// command is run with the tenant host in the environment variable FLASHPIPE_TENANT_HOST and prints either the
+// token, or a JSON object with the token in access_token or token and its expiry in expires_in (seconds),
+// expiry or expires_at (RFC 3339). The status of a Kubernetes ExecCredential is accepted as well. Tokens
+// without expiry are used until a request is rejected with 401.
+type commandTokenSource struct {
+	command string
+	host    string
+}
+
+type commandTokenData struct {
+	AccessToken string `json:"access_token"`
+	Token       string `json:"token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Expiry      string `json:"expiry"`
+	ExpiresAt   string `json:"expires_at"`
+	Status      *struct {
+		Token               string `json:"token"`
+		ExpirationTimestamp string `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+// Token runs the command and returns the token it prints
+func (s *commandTokenSource) Token() (*oauth2.Token, error) {
+	output, err := runShell("token", s.command, nil, "FLASHPIPE_TENANT_HOST="+s.host)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommandToken(output)
+}
+
+func parseCommandToken(output []byte) (*oauth2.Token, error) {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return nil, fmt.Errorf("token command printed no token")
+	}
+	if !strings.HasPrefix(trimmed, "{") {
+		if strings.ContainsAny(trimmed, " \t\r\n") {
+			return nil, fmt.Errorf("token command printed more than a token")
+		}
+		return &oauth2.Token{AccessToken: trimmed, TokenType: "Bearer"}, nil
+	}
+
+	var data commandTokenData
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return nil, fmt.Errorf("token command printed invalid JSON: %w", err)
+	}
+	token := &oauth2.Token{AccessToken: data.AccessToken, TokenType: "Bearer"}
+	expiry := data.Expiry
+	if expiry == "" {
+		expiry = data.ExpiresAt
+	}
+	if token.AccessToken == "" {
+		token.AccessToken = data.Token
+	}
+	if data.Status != nil && token.AccessToken == "" {
+		token.AccessToken = data.Status.Token
+		expiry = data.Status.ExpirationTimestamp
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token command printed no access_token or token")
+	}
+	if data.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(data.ExpiresIn) * time.Second)
+	} else if expiry != "" {
+		t, err := time.Parse(time.RFC3339, expiry)
+		if err != nil {
+			return nil, fmt.Errorf("token command printed invalid expiry %q: %w", expiry, err)
+		}
+		token.Expiry = t
+	}
+	return token, nil
+}
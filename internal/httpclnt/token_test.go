@@ -0,0 +1,70 @@
+package httpclnt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("token command uses sh")
+	}
+	// The first token is rejected, so that the retry runs the command again
+	var received []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("Authorization"))
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer svr.Close()
+
+	counter := filepath.Join(t.TempDir(), "count")
+	SetDefaultTokenCommand(`echo x >> ` + counter + `; echo "{\"access_token\": \"token-$(wc -l < ` + counter + ` | tr -d ' ')-$FLASHPIPE_TENANT_HOST\", \"expires_in\": 3600}"`)
+	defer SetDefaultTokenCommand("")
+
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "", "", host, "http", port, true)
+	assert.Equal(t, "BASIC", exe.AuthType, "Executers other than tenant executers should not use the token command")
+	exe.UseTenantDefaults()
+	assert.Equal(t, "TOKEN_COMMAND", exe.AuthType)
+	for range 2 {
+		resp, err := exe.ExecGetRequest("/api/v1/", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, []string{"Bearer token-1-" + host, "Bearer token-2-" + host, "Bearer token-2-" + host}, received,
+		"Token should be fetched again after 401 and reused until it expires")
+
+	content, _ := os.ReadFile(counter)
+	assert.Equal(t, "x\nx\n", string(content))
+
+	exe = New("", "", "", "", "user", "password", host, "http", port, true)
+	assert.Equal(t, "BASIC", exe.AuthType, "Credentials should take precedence over the token command")
+}
+
+func TestParseCommandToken(t *testing.T) {
+	token, err := parseCommandToken([]byte("abc\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", token.AccessToken)
+	assert.True(t, token.Expiry.IsZero())
+
+	token, err = parseCommandToken([]byte(`{"status": {"token": "k8s", "expirationTimestamp": "2030-01-02T03:04:05Z"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "k8s", token.AccessToken)
+	assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), token.Expiry)
+
+	_, err = parseCommandToken([]byte(`{"expires_in": 60}`))
+	assert.EqualError(t, err, "token command printed no access_token or token")
+	_, err = parseCommandToken([]byte("Error: not logged in"))
+	assert.EqualError(t, err, "token command printed more than a token")
+}
@@ -0,0 +1,82 @@
+// Package keychain stores secrets in the credential store of the operating system: the login keychain on macOS,
+// the Secret Service (libsecret) on Linux and files encrypted with DPAPI for the current user on Windows.
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Service is the service the secrets of FlashPipe are stored under
+const Service = "flashpipe"
+
+// ErrNotFound is returned by Get and Delete when no secret is stored for the account
+var ErrNotFound = errors.New("not found in keychain")
+
+// Store is a credential store holding one secret per account
+type Store interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// Default is the credential store of the operating system
+var Default Store = platformStore{}
+
+// Get returns the secret of the account from the default store
+func Get(account string) (string, error) {
+	return Default.Get(account)
+}
+
+// Set stores the secret of the account in the default store, replacing a stored secret
+func Set(account, secret string) error {
+	return Default.Set(account, secret)
+}
+
+// Delete removes the secret of the account from the default store
+func Delete(account string) error {
+	return Default.Delete(account)
+}
+
+// run runs a command of the credential store with stdin and returns its output
+func run(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("keychain requires %s: %w", name, err)
+		}
+		return "", &commandError{name: name, err: err, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.String(), nil
+}
+
+// commandError is a failed command of the credential store
+type commandError struct {
+	name   string
+	err    error
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("%s failed: %v: %s", e.name, e.err, e.stderr)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code of a failed command, or -1
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
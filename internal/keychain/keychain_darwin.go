@@ -0,0 +1,38 @@
+package keychain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// platformStore stores the secrets as generic passwords in the login keychain with the security tool
+type platformStore struct{}
+
+func (platformStore) Get(account string) (string, error) {
+	out, err := run("", "security", "find-generic-password", "-s", Service, "-a", account, "-w")
+	if isNotFound(err) {
+		return "", ErrNotFound
+	}
+	return strings.TrimSuffix(out, "\n"), err
+}
+
+func (platformStore) Set(account, secret string) error {
+	// The secret is passed on stdin in interactive mode, so that it does not show in the process list
+	command := fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n", Service, account, hex.EncodeToString([]byte(secret)))
+	_, err := run(command, "security", "-i")
+	return err
+}
+
+func (platformStore) Delete(account string) error {
+	_, err := run("", "security", "delete-generic-password", "-s", Service, "-a", account)
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// isNotFound returns whether security exited with errSecItemNotFound
+func isNotFound(err error) bool {
+	return exitCode(err) == 44
+}
@@ -0,0 +1,30 @@
+package keychain
+
+import "errors"
+
+// platformStore stores the secrets in the Secret Service, e.g. GNOME Keyring or KWallet, with secret-tool of libsecret
+type platformStore struct{}
+
+func (platformStore) Get(account string) (string, error) {
+	out, err := run("", "secret-tool", "lookup", "service", Service, "account", account)
+	// secret-tool exits with 1 without output when no secret matches
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) && exitCode(err) == 1 && cmdErr.stderr == "" || err == nil && out == "" {
+		return "", ErrNotFound
+	}
+	return out, err
+}
+
+func (platformStore) Set(account, secret string) error {
+	// The secret is read from stdin, so that it does not show in the process list
+	_, err := run(secret, "secret-tool", "store", "--label=FlashPipe "+account, "service", Service, "account", account)
+	return err
+}
+
+func (platformStore) Delete(account string) error {
+	if _, err := (platformStore{}).Get(account); err != nil {
+		return err
+	}
+	_, err := run("", "secret-tool", "clear", "service", Service, "account", account)
+	return err
+}
@@ -0,0 +1,23 @@
+//go:build !darwin && !linux && !windows
+
+package keychain
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// platformStore reports that there is no supported credential store
+type platformStore struct{}
+
+func (platformStore) Get(string) (string, error) {
+	return "", fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+}
+
+func (platformStore) Set(string, string) error {
+	return fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+}
+
+func (platformStore) Delete(string) error {
+	return fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+}
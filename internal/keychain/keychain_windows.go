@@ -0,0 +1,76 @@
+package keychain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// platformStore stores the secrets in files in the user config directory, encrypted with DPAPI for the current user
+type platformStore struct{}
+
+func (platformStore) Get(account string) (string, error) {
+	file, err := secretFile(account)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(blob(data), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return "", err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return string(unsafe.Slice(out.Data, out.Size)), nil
+}
+
+func (platformStore) Set(account, secret string) error {
+	file, err := secretFile(account)
+	if err != nil {
+		return err
+	}
+	var out windows.DataBlob
+	if err := windows.CryptProtectData(blob([]byte(secret)), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(file, unsafe.Slice(out.Data, out.Size), 0600)
+}
+
+func (platformStore) Delete(account string) error {
+	file, err := secretFile(account)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	} else {
+		return err
+	}
+}
+
+func secretFile(account string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, Service, "credentials", filepath.Base(account)), nil
+}
+
+func blob(data []byte) *windows.DataBlob {
+	if len(data) == 0 {
+		return &windows.DataBlob{}
+	}
+	return &windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+}
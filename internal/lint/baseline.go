@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Baseline lists accepted findings that are not reported again. Findings are identified by rule, file,
+// package, artifact and parameter, so that a baseline remains valid when lines move.
+type Baseline struct {
+	Findings []Finding `yaml:"findings"`
+}
+
+// LoadBaseline reads a baseline file. A missing file is an empty baseline.
+func LoadBaseline(path string) (*Baseline, error) {
+	baseline := new(Baseline)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline file %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// WriteBaseline writes the findings as the baseline file
+func WriteBaseline(path string, findings []Finding) error {
+	data, err := yaml.Marshal(&Baseline{Findings: findings})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline file: %w", err)
+	}
+	return nil
+}
+
+// Filter returns the findings that are not in the baseline and the number of findings suppressed
+func (b *Baseline) Filter(findings []Finding) ([]Finding, int) {
+	known := map[string]bool{}
+	for _, f := range b.Findings {
+		known[f.key()] = true
+	}
+	var remaining []Finding
+	for _, f := range findings {
+		if !known[f.key()] {
+			remaining = append(remaining, f)
+		}
+	}
+	return remaining, len(findings) - len(remaining)
+}
@@ -0,0 +1,377 @@
+// Package lint checks configuration files of the configure command and the layout of the repository
+// against opinionated rules, e.g. naming conventions of artifact IDs and secrets written inline. Rules can
+// be tuned and extended with custom rules in a rules file, and known findings suppressed with a baseline.
+package lint
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+)
+
+// Severities of findings, in increasing order. Rules with severity off are not checked.
+const (
+	SeverityOff     = "off"
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+var severities = []string{SeverityOff, SeverityInfo, SeverityWarning, SeverityError}
+
+// SeverityAtLeast returns true if severity is the same as or more severe than threshold
+func SeverityAtLeast(severity string, threshold string) bool {
+	return slices.Index(severities, severity) >= slices.Index(severities, threshold)
+}
+
+// Built-in rules
+const (
+	RuleInvalidConfig       = "invalid-config"
+	RuleArtifactIDNaming    = "artifact-id-naming"
+	RuleMandatoryParameters = "mandatory-parameters"
+	RuleInlineSecret        = "inline-secret"
+	RuleProdDeploy          = "prod-deploy"
+	RuleParameterConflict   = "parameter-conflict"
+	RuleFromFileMissing     = "from-file-missing"
+	RuleArtifactInRepo      = "artifact-in-repo"
+)
+
+// BuiltinRules are the built-in rules with their default settings
+var BuiltinRules = map[string]RuleSettings{
+	RuleInvalidConfig:       {Severity: SeverityError},
+	RuleArtifactIDNaming:    {Severity: SeverityWarning, Pattern: `^[A-Za-z][A-Za-z0-9_]*$`},
+	RuleMandatoryParameters: {Severity: SeverityError},
+	RuleInlineSecret:        {Severity: SeverityError, Pattern: `(?i)(password|passwd|secret|token|api_?key|private_?key|credential)`},
+	RuleProdDeploy:          {Severity: SeverityWarning, Pattern: `(?i)prod`},
+	RuleParameterConflict:   {Severity: SeverityWarning},
+	RuleFromFileMissing:     {Severity: SeverityError},
+	RuleArtifactInRepo:      {Severity: SeverityWarning},
+}
+
+// Finding is a rule violation found in a configuration file or the repository
+type Finding struct {
+	Rule      string `json:"rule" yaml:"rule"`
+	Severity  string `json:"severity" yaml:"-"`
+	File      string `json:"file" yaml:"file"`
+	Line      int    `json:"line,omitempty" yaml:"-"`
+	Package   string `json:"package,omitempty" yaml:"package,omitempty"`
+	Artifact  string `json:"artifact,omitempty" yaml:"artifact,omitempty"`
+	Parameter string `json:"parameter,omitempty" yaml:"parameter,omitempty"`
+	Message   string `json:"message" yaml:"-"`
+}
+
+func (f Finding) String() string {
+	location := f.File
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return fmt.Sprintf("%s: %s [%s] %s", location, f.Severity, f.Rule, f.Message)
+}
+
+// key identifies a finding independent of its line, so that baselines survive unrelated edits
+func (f Finding) key() string {
+	return strings.Join([]string{f.Rule, filepath.ToSlash(f.File), f.Package, f.Artifact, f.Parameter}, "|")
+}
+
+// Options are the inputs of a lint run besides the rules
+type Options struct {
+	ArtifactsDir string // Directory of the artifacts synced to Git, checked by artifact-in-repo if set
+}
+
+// Linter checks configuration files against built-in and custom rules
+type Linter struct {
+	settings map[string]RuleSettings
+	patterns map[string]*regexp.Regexp
+	custom   []*customRule
+}
+
+// NewLinter returns a Linter for the rules of a rules file, nil for the built-in rules only
+func NewLinter(rules *RuleSet) (*Linter, error) {
+	l := new(Linter)
+	l.settings = map[string]RuleSettings{}
+	l.patterns = map[string]*regexp.Regexp{}
+	if rules == nil {
+		rules = new(RuleSet)
+	}
+	for name := range rules.Rules {
+		if _, ok := BuiltinRules[name]; !ok {
+			return nil, fmt.Errorf("unknown rule %q in rules file, custom rules are defined under custom", name)
+		}
+	}
+	for name, defaults := range BuiltinRules {
+		settings := defaults
+		if override, ok := rules.Rules[name]; ok {
+			if override.Severity != "" {
+				settings.Severity = override.Severity
+			}
+			if override.Pattern != "" {
+				settings.Pattern = override.Pattern
+			}
+			settings.Keys = override.Keys
+		}
+		if !slices.Contains(severities, settings.Severity) {
+			return nil, fmt.Errorf("rule %s: invalid severity %q (valid severities: %v)", name, settings.Severity, severities)
+		}
+		if settings.Pattern != "" {
+			pattern, err := regexp.Compile(settings.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid pattern: %w", name, err)
+			}
+			l.patterns[name] = pattern
+		}
+		l.settings[name] = settings
+	}
+	for _, definition := range rules.Custom {
+		if _, ok := BuiltinRules[definition.Name]; ok {
+			return nil, fmt.Errorf("custom rule %s: name of a built-in rule", definition.Name)
+		}
+		rule, err := compileCustomRule(definition)
+		if err != nil {
+			return nil, err
+		}
+		l.custom = append(l.custom, rule)
+	}
+	return l, nil
+}
+
+// Lint checks a configuration file, or all configuration files of a folder like the configure command,
+// and returns the findings ordered by file and line
+func (l *Linter) Lint(path string, opts Options) ([]Finding, error) {
+	paths, err := configFilePaths(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	var configFiles []*flashpipe.ConfigFile
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		// Templates are not rendered, so that values of values files are not mistaken for inline values
+		cfg, err := flashpipe.DecodeConfig(p, data)
+		if err != nil {
+			findings = l.add(findings, Finding{Rule: RuleInvalidConfig, File: p, Message: err.Error()})
+			continue
+		}
+		configFiles = append(configFiles, &flashpipe.ConfigFile{Config: cfg, Source: p, FileName: filepath.Base(p)})
+	}
+
+	// Parameter groups can be used in all files, so they are collected before the files are checked
+	groups := map[string][]models.ConfigurationParameter{}
+	for _, file := range configFiles {
+		for _, name := range slices.Sorted(maps.Keys(file.Config.ParameterGroups)) {
+			if _, exists := groups[name]; exists {
+				findings = l.add(findings, Finding{Rule: RuleInvalidConfig, File: file.Source, Message: fmt.Sprintf("parameter group %s is defined in several files", name)})
+				continue
+			}
+			groups[name] = file.Config.ParameterGroups[name]
+		}
+	}
+	for _, file := range configFiles {
+		findings = append(findings, l.lintConfig(file.Source, file.Config, groups)...)
+	}
+
+	for _, conflict := range flashpipe.FindConflicts(configFiles) {
+		last := conflict.Sources[len(conflict.Sources)-1]
+		findings = l.add(findings, Finding{Rule: RuleParameterConflict, File: last.File, Line: last.Line, Artifact: conflict.ArtifactID,
+			Parameter: conflict.Key, Message: "set to different values in several files: " + conflict.String()})
+	}
+
+	if opts.ArtifactsDir != "" && l.enabled(RuleArtifactInRepo) {
+		repoArtifacts, err := artifactDirectories(opts.ArtifactsDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range configFiles {
+			for _, pkg := range file.Config.Packages {
+				for _, artifact := range pkg.Artifacts {
+					if artifact.ID != "" && !repoArtifacts[artifact.ID] {
+						findings = l.add(findings, Finding{Rule: RuleArtifactInRepo, File: file.Source, Package: pkg.ID, Artifact: artifact.ID,
+							Message: fmt.Sprintf("no artifact directory %s with META-INF/MANIFEST.MF in %s", artifact.ID, opts.ArtifactsDir)})
+					}
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		return a.Line - b.Line
+	})
+	return findings, nil
+}
+
+func (l *Linter) lintConfig(file string, cfg *models.ConfigureConfig, groups map[string][]models.ConfigurationParameter) []Finding {
+	var findings []Finding
+	for _, err := range flashpipe.Validate(cfg) {
+		findings = l.add(findings, Finding{Rule: RuleInvalidConfig, File: file, Message: err.Error()})
+	}
+	for _, target := range cfg.Targets {
+		for _, secret := range []struct{ field, value string }{{"clientSecret", target.ClientSecret}, {"password", target.Password}} {
+			if secret.value != "" && !isReference(secret.value) {
+				findings = l.add(findings, Finding{Rule: RuleInlineSecret, File: file, Parameter: "targets." + target.Name + "." + secret.field,
+					Message: fmt.Sprintf("%s of target %s is written inline, reference an environment variable as $VAR instead", secret.field, target.Name)})
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.ParameterGroups)) {
+		for _, param := range cfg.ParameterGroups[name] {
+			findings = l.lintParameter(findings, Finding{File: file, Line: param.Line, Parameter: param.Key}, file, param)
+		}
+	}
+
+	production := l.isProduction(file, cfg)
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			finding := Finding{File: file, Package: pkg.ID, Artifact: artifact.ID}
+			if pattern := l.patterns[RuleArtifactIDNaming]; artifact.ID != "" && !pattern.MatchString(artifact.ID) {
+				findings = l.add(findings, withRule(finding, RuleArtifactIDNaming,
+					fmt.Sprintf("artifact ID %s does not match the naming convention %s", artifact.ID, pattern)))
+			}
+			if production && len(artifact.Parameters) > 0 && !pkg.Deploy && !artifact.Deploy {
+				findings = l.add(findings, withRule(finding, RuleProdDeploy,
+					"production configuration does not deploy the artifact, the parameters are not active until it is deployed"))
+			}
+			for _, name := range artifact.UseGroups {
+				if _, found := groups[name]; !found {
+					findings = l.add(findings, withRule(finding, RuleInvalidConfig, fmt.Sprintf("parameter group %s is not defined", name)))
+				}
+			}
+			for _, key := range l.settings[RuleMandatoryParameters].Keys {
+				if artifact.Type == "Integration" && !hasParameter(artifact, key, groups) {
+					findings = l.add(findings, withRule(finding, RuleMandatoryParameters, fmt.Sprintf("mandatory parameter %s is missing", key)))
+				}
+			}
+			for _, param := range artifact.Parameters {
+				finding := finding
+				finding.Line = param.Line
+				finding.Parameter = param.Key
+				findings = l.lintParameter(findings, finding, file, param)
+			}
+			for _, rule := range l.custom {
+				findings = append(findings, rule.check(file, production, pkg, artifact, groups)...)
+			}
+		}
+	}
+	return findings
+}
+
+// lintParameter appends the findings of a parameter of an artifact or a parameter group
+func (l *Linter) lintParameter(findings []Finding, finding Finding, file string, param models.ConfigurationParameter) []Finding {
+	if param.Value == "" && param.FromFile == "" && param.ValueFrom == nil &&
+		(param.Mode == "" || param.Mode == flashpipe.ParameterModeSet) {
+		findings = l.add(findings, withRule(finding, RuleMandatoryParameters, "parameter has no value"))
+	}
+	if l.patterns[RuleInlineSecret].MatchString(param.Key) && param.Value != "" && !isReference(param.Value) {
+		findings = l.add(findings, withRule(finding, RuleInlineSecret,
+			"secret written inline, use valueFrom, fromFile or a {{ .Values.<key> }} template instead"))
+	}
+	if param.FromFile != "" {
+		path := param.FromFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			findings = l.add(findings, withRule(finding, RuleFromFileMissing, fmt.Sprintf("fromFile %s not found", param.FromFile)))
+		}
+	}
+	return findings
+}
+
+// add appends the finding with the severity of its rule, unless the rule is off
+func (l *Linter) add(findings []Finding, f Finding) []Finding {
+	if !l.enabled(f.Rule) {
+		return findings
+	}
+	f.Severity = l.settings[f.Rule].Severity
+	return append(findings, f)
+}
+
+func (l *Linter) enabled(rule string) bool {
+	return l.settings[rule].Severity != SeverityOff
+}
+
+// isProduction returns true if the file name or the name of a target matches the pattern of prod-deploy
+func (l *Linter) isProduction(file string, cfg *models.ConfigureConfig) bool {
+	pattern := l.patterns[RuleProdDeploy]
+	if pattern.MatchString(filepath.Base(file)) {
+		return true
+	}
+	return slices.ContainsFunc(cfg.Targets, func(t models.ConfigureTarget) bool { return pattern.MatchString(t.Name) })
+}
+
+func withRule(f Finding, rule string, message string) Finding {
+	f.Rule = rule
+	f.Message = message
+	return f
+}
+
+// hasParameter returns true if the artifact or one of the parameter groups it uses has the parameter
+func hasParameter(artifact models.ConfigureArtifact, key string, groups map[string][]models.ConfigurationParameter) bool {
+	isKey := func(p models.ConfigurationParameter) bool { return p.Key == key }
+	if slices.ContainsFunc(artifact.Parameters, isKey) {
+		return true
+	}
+	return slices.ContainsFunc(artifact.UseGroups, func(name string) bool { return slices.ContainsFunc(groups[name], isKey) })
+}
+
+// isReference returns true if a value references an environment variable or a template value
+func isReference(value string) bool {
+	return strings.HasPrefix(value, "$") || strings.Contains(value, "{{")
+}
+
+func configFilePaths(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access path: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && flashpipe.IsConfigFile(name) {
+			paths = append(paths, filepath.Join(path, name))
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no configuration files found in folder: %s", path)
+	}
+	return paths, nil
+}
+
+// artifactDirectories returns the names of the directories below dir that contain META-INF/MANIFEST.MF,
+// which are the artifact IDs when synced with the default directory naming
+func artifactDirectories(dir string) (map[string]bool, error) {
+	dirs := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == "MANIFEST.MF" && filepath.Base(filepath.Dir(path)) == "META-INF" {
+			dirs[filepath.Base(filepath.Dir(filepath.Dir(path)))] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifacts directory: %w", err)
+	}
+	return dirs, nil
+}
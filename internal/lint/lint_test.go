@@ -0,0 +1,203 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func rules(findings []Finding) []string {
+	var result []string
+	for _, f := range findings {
+		result = append(result, f.Rule+":"+f.Parameter)
+	}
+	return result
+}
+
+const prodConfig = `targets:
+  - name: prod
+    host: tenant
+    clientSecret: $PROD_SECRET
+    password: plain
+packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: Orders-Replicate
+        type: Integration
+        parameters:
+          - key: ApiPassword
+            value: hunter2
+          - key: TokenValue
+            value: "{{ .Values.token }}"
+          - key: Host
+            value: ""
+          - key: Cert
+            fromFile: missing.pem
+      - artifactId: Orders_Notify
+        type: Integration
+        deploy: true
+        parameters:
+          - key: Host
+            value: prod-host
+`
+
+func TestLintBuiltinRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "prod.yml"), prodConfig)
+	writeFile(t, filepath.Join(dir, "shared.yml"), `packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: Orders_Notify
+        type: Integration
+        parameters:
+          - key: Host
+            value: dev-host
+`)
+
+	linter, err := NewLinter(nil)
+	require.NoError(t, err)
+	findings, err := linter.Lint(dir, Options{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"inline-secret:targets.prod.password",
+		"artifact-id-naming:",
+		"prod-deploy:",
+		"inline-secret:ApiPassword",
+		"mandatory-parameters:Host",
+		"from-file-missing:Cert",
+		"parameter-conflict:Host",
+	}, rules(findings))
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.Equal(t, 12, findings[3].Line, "Parameter findings should have the line")
+}
+
+func TestLintParameterGroups(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "groups.yml"), `parameterGroups:
+  erp:
+    - key: Host
+      value: erp.example.com
+    - key: ErpPassword
+      value: hunter2
+packages: []
+`)
+	writeFile(t, filepath.Join(dir, "orders.yml"), `packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: Orders_Replicate
+        type: Integration
+        useGroups: [erp, crm]
+`)
+
+	linter, err := NewLinter(nil)
+	require.NoError(t, err)
+	linter.settings[RuleMandatoryParameters] = RuleSettings{Severity: SeverityError, Keys: []string{"Host"}}
+	findings, err := linter.Lint(dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"inline-secret:ErpPassword", "invalid-config:"}, rules(findings),
+		"Parameters of groups satisfy mandatory parameters")
+	assert.Equal(t, "parameter group crm is not defined", findings[1].Message)
+}
+
+func TestLintRulesFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config", "prod.yml"), prodConfig)
+	writeFile(t, filepath.Join(dir, "repo", "Orders_Notify", "META-INF", "MANIFEST.MF"), "Manifest-Version: 1.0\n")
+	writeFile(t, filepath.Join(dir, "rules.yml"), `rules:
+  artifact-id-naming:
+    pattern: ^[A-Za-z][A-Za-z0-9_-]*$
+  inline-secret:
+    severity: warning
+  mandatory-parameters:
+    severity: off
+  from-file-missing:
+    severity: off
+custom:
+  - name: orders-https
+    message: Orders flows call partners over HTTPS
+    severity: error
+    match:
+      package: ^Orders$
+      type: Integration
+      production: true
+    requireParameters: [Timeout]
+    forbidParameters: [TokenValue]
+    parameterValues:
+      - key: ^Host$
+        value: prod-.*
+`)
+
+	ruleSet, err := LoadRules(filepath.Join(dir, "rules.yml"))
+	require.NoError(t, err)
+	linter, err := NewLinter(ruleSet)
+	require.NoError(t, err)
+	findings, err := linter.Lint(filepath.Join(dir, "config", "prod.yml"), Options{ArtifactsDir: filepath.Join(dir, "repo")})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"inline-secret:targets.prod.password",
+		"prod-deploy:",
+		"inline-secret:ApiPassword",
+		"orders-https:Timeout",
+		"orders-https:TokenValue",
+		"orders-https:Host",
+		"orders-https:Timeout",
+		"artifact-in-repo:",
+	}, rules(findings))
+	for _, f := range findings {
+		if f.Rule == RuleInlineSecret {
+			assert.Equal(t, SeverityWarning, f.Severity, "Severity should be overridden")
+		}
+		if f.Rule == "orders-https" && f.Parameter == "Host" {
+			assert.Contains(t, f.Message, "Orders flows call partners over HTTPS")
+		}
+	}
+
+	_, err = NewLinter(&RuleSet{Rules: map[string]RuleSettings{"unknown": {}}})
+	assert.Error(t, err, "Unknown built-in rule")
+	_, err = NewLinter(&RuleSet{Custom: []CustomRule{{Name: "bad", Match: RuleMatch{Artifact: "("}}}})
+	assert.Error(t, err, "Invalid pattern")
+	_, err = NewLinter(&RuleSet{Custom: []CustomRule{{Name: RuleInlineSecret}}})
+	assert.Error(t, err, "Custom rule with a built-in name")
+}
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "prod.yml")
+	writeFile(t, configPath, prodConfig)
+	linter, err := NewLinter(nil)
+	require.NoError(t, err)
+	findings, err := linter.Lint(configPath, Options{})
+	require.NoError(t, err)
+
+	baselinePath := filepath.Join(dir, "baseline.yml")
+	baseline, err := LoadBaseline(baselinePath)
+	require.NoError(t, err)
+	remaining, suppressed := baseline.Filter(findings)
+	assert.Len(t, remaining, len(findings), "Missing baseline suppresses nothing")
+	assert.Equal(t, 0, suppressed)
+
+	require.NoError(t, WriteBaseline(baselinePath, findings))
+	writeFile(t, configPath, "# Moved down by a comment\n"+prodConfig+`      - artifactId: Orders_Secret
+        type: Integration
+        deploy: true
+        parameters:
+          - key: ClientSecret
+            value: inline
+`)
+	findings, err = linter.Lint(configPath, Options{})
+	require.NoError(t, err)
+	baseline, err = LoadBaseline(baselinePath)
+	require.NoError(t, err)
+	remaining, suppressed = baseline.Filter(findings)
+	assert.Equal(t, []string{"inline-secret:ClientSecret"}, rules(remaining), "Only the new finding should remain")
+	assert.Equal(t, 6, suppressed)
+}
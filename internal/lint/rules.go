@@ -0,0 +1,156 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// RuleSet is the content of a rules file, with settings of the built-in rules and custom rules
+type RuleSet struct {
+	Rules  map[string]RuleSettings `yaml:"rules,omitempty"`  // Settings of built-in rules by name
+	Custom []CustomRule            `yaml:"custom,omitempty"` // Additional rules
+}
+
+// RuleSettings tune a built-in rule
+type RuleSettings struct {
+	Severity string   `yaml:"severity,omitempty"` // off, info, warning or error
+	Pattern  string   `yaml:"pattern,omitempty"`  // Regular expression of artifact-id-naming, inline-secret and prod-deploy
+	Keys     []string `yaml:"keys,omitempty"`     // Parameters every integration flow must set, for mandatory-parameters
+}
+
+// CustomRule checks the artifacts matched by the selectors of Match. All checks set are applied.
+type CustomRule struct {
+	Name              string            `yaml:"name"`
+	Message           string            `yaml:"message,omitempty"`  // Explanation added to the findings
+	Severity          string            `yaml:"severity,omitempty"` // Defaults to warning
+	Match             RuleMatch         `yaml:"match,omitempty"`
+	RequireParameters []string          `yaml:"requireParameters,omitempty"` // Parameters the artifact must set
+	ForbidParameters  []string          `yaml:"forbidParameters,omitempty"`  // Parameters the artifact must not set
+	ParameterValues   []ParameterValues `yaml:"parameterValues,omitempty"`   // Allowed values of parameters
+	Deploy            *bool             `yaml:"deploy,omitempty"`            // Required deploy flag of the artifact or its package
+}
+
+// RuleMatch selects the artifacts a custom rule applies to, by regular expressions and artifact type.
+// Empty selectors match all artifacts.
+type RuleMatch struct {
+	File       string `yaml:"file,omitempty"`     // Name of the configuration file
+	Package    string `yaml:"package,omitempty"`  // Package ID
+	Artifact   string `yaml:"artifact,omitempty"` // Artifact ID
+	Type       string `yaml:"type,omitempty"`     // Artifact type, e.g. Integration
+	Production bool   `yaml:"production,omitempty"`
+}
+
+// ParameterValues requires the values of the parameters with a key matching Key to match Value
+type ParameterValues struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+// LoadRules reads a rules file
+func LoadRules(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	rules := new(RuleSet)
+	if err := yaml.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+type customRule struct {
+	CustomRule
+	file, pkg, artifact *regexp.Regexp
+	values              []compiledValues
+}
+
+type compiledValues struct {
+	key, value *regexp.Regexp
+}
+
+func compileCustomRule(definition CustomRule) (*customRule, error) {
+	if definition.Name == "" {
+		return nil, fmt.Errorf("custom rule without name")
+	}
+	if definition.Severity == "" {
+		definition.Severity = SeverityWarning
+	}
+	if !slices.Contains(severities, definition.Severity) {
+		return nil, fmt.Errorf("custom rule %s: invalid severity %q (valid severities: %v)", definition.Name, definition.Severity, severities)
+	}
+	r := &customRule{CustomRule: definition}
+	var err error
+	compile := func(field string, expr string) *regexp.Regexp {
+		if expr == "" || err != nil {
+			return nil
+		}
+		var re *regexp.Regexp
+		if re, err = regexp.Compile(expr); err != nil {
+			err = fmt.Errorf("custom rule %s: invalid %s: %w", definition.Name, field, err)
+		}
+		return re
+	}
+	r.file = compile("match.file", definition.Match.File)
+	r.pkg = compile("match.package", definition.Match.Package)
+	r.artifact = compile("match.artifact", definition.Match.Artifact)
+	for _, pv := range definition.ParameterValues {
+		if pv.Key == "" {
+			return nil, fmt.Errorf("custom rule %s: parameterValues.key is required", definition.Name)
+		}
+		r.values = append(r.values, compiledValues{compile("parameterValues.key", pv.Key), compile("parameterValues.value", "^(?:"+pv.Value+")$")})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *customRule) matches(file string, production bool, pkg models.ConfigurePackage, artifact models.ConfigureArtifact) bool {
+	return (r.file == nil || r.file.MatchString(filepath.Base(file))) &&
+		(r.pkg == nil || r.pkg.MatchString(pkg.ID)) &&
+		(r.artifact == nil || r.artifact.MatchString(artifact.ID)) &&
+		(r.Match.Type == "" || r.Match.Type == artifact.Type) &&
+		(!r.Match.Production || production)
+}
+
+func (r *customRule) check(file string, production bool, pkg models.ConfigurePackage, artifact models.ConfigureArtifact, groups map[string][]models.ConfigurationParameter) []Finding {
+	if r.Severity == SeverityOff || !r.matches(file, production, pkg, artifact) {
+		return nil
+	}
+	var findings []Finding
+	add := func(param models.ConfigurationParameter, message string) {
+		if r.Message != "" {
+			message += ": " + r.Message
+		}
+		findings = append(findings, Finding{Rule: r.Name, Severity: r.Severity, File: file, Line: param.Line,
+			Package: pkg.ID, Artifact: artifact.ID, Parameter: param.Key, Message: message})
+	}
+
+	for _, key := range r.RequireParameters {
+		if !hasParameter(artifact, key, groups) {
+			add(models.ConfigurationParameter{Key: key}, fmt.Sprintf("required parameter %s is missing", key))
+		}
+	}
+	for _, param := range artifact.Parameters {
+		if slices.Contains(r.ForbidParameters, param.Key) {
+			add(param, fmt.Sprintf("parameter %s must not be set", param.Key))
+		}
+		for _, v := range r.values {
+			if v.key.MatchString(param.Key) && param.FromFile == "" && param.ValueFrom == nil && !isReference(param.Value) &&
+				!v.value.MatchString(param.Value) {
+				add(param, fmt.Sprintf("value %q does not match %s", param.Value, v.value))
+			}
+		}
+	}
+	if r.Deploy != nil && (pkg.Deploy || artifact.Deploy) != *r.Deploy {
+		add(models.ConfigurationParameter{}, fmt.Sprintf("artifact must have deploy set to %v", *r.Deploy))
+	}
+	return findings
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ascii replaces emoji and box-drawing characters in the output with plain ASCII markers
+var ascii bool
+
+// asciiReplacer replaces the symbols used in messages with markers of the same meaning
+var asciiReplacer = strings.NewReplacer(
+	"️", "", // Variation selector of emoji presentation, e.g. in ⚠️
+	"═", "=", "━", "=", "─", "-",
+	"✅", "[OK]", "✓", "[OK]", "❌", "[FAIL]", "✗", "[FAIL]", "⚠", "[WARN]",
+	"🏆", "[DONE]", "📢", "[INFO]", "💡", "[HINT]", "📊", "[STATS]", "📋", "[QUEUED]",
+	"📦", "[PKG]", "🚀", "[DEPLOY]", "🔄", "[RESTART]", "🔒", "[LOCKED]", "🔧", "[CONFIG]",
+	"⏸", "[PAUSE]", "⏭", "[SKIP]", "⏳", "[WAIT]", "↩", "[UNDO]", "💾", "[SAVE]",
+	"🪝", "[HOOK]", "🚦", "[GATE]", "🩺", "[CHECK]", "🌐", "[WEB]",
+	"▶", ">", "→", "->", "•", "*",
+)
+
+// SetASCII enables the replacement of emoji and box-drawing characters in the output with plain ASCII markers,
+// for terminals that do not render UTF-8. It applies to loggers initialised afterwards and to Plain.
+func SetASCII(enabled bool) {
+	ascii = enabled
+}
+
+// ASCIIAuto returns true if the locale of the environment is set to an encoding other than UTF-8, e.g. C or
+// ISO8859-1. An unset locale and Windows, where the console handles UTF-8, do not enable ASCII mode.
+func ASCIIAuto(getenv func(string) string) bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return !strings.Contains(locale, "utf-8") && !strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
+
+// Plain returns s with emoji and box-drawing characters replaced in ASCII mode, for output that is not logged
+func Plain(s string) string {
+	if !ascii {
+		return s
+	}
+	return toASCII(s)
+}
+
+// toASCII replaces the known symbols with their markers, and other symbols and box-drawing characters with * and -
+func toASCII(s string) string {
+	s = asciiReplacer.Replace(s)
+	if !strings.ContainsFunc(s, isSymbol) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 0x2500 && r <= 0x257f:
+			b.WriteByte('-')
+		case isSymbol(r):
+			b.WriteByte('*')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isSymbol returns true for box-drawing characters, arrows, technical and miscellaneous symbols, dingbats and
+// emoji, but not for letters of other scripts
+func isSymbol(r rune) bool {
+	return r >= 0x2190 && r <= 0x2bff || r >= 0x1f000 && r <= 0x1faff || r == 0xfe0f
+}
+
+// asciiWriter replaces the symbols in each message written
+type asciiWriter struct {
+	out io.Writer
+}
+
+func (w asciiWriter) Write(p []byte) (int, error) {
+	if !utf8.Valid(p) {
+		return w.out.Write(p)
+	}
+	if _, err := w.out.Write([]byte(toASCII(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Separator is a decorative line of the log output, drawn with box-drawing characters or in ASCII mode with = and -
+type Separator int
+
+const (
+	SeparatorDouble Separator = iota // ═══, around the phases and summaries of a run
+	SeparatorHeavy                   // ━━━, around the packages of the orchestrator
+	SeparatorLight                   // ───, within summaries
+	SeparatorDashed                  // ---, between the packages and artifacts of sync, snapshot and restore
+)
+
+func (s Separator) String() string {
+	switch s {
+	case SeparatorDouble:
+		return strings.Repeat(s.char("═", "="), 71)
+	case SeparatorHeavy:
+		return strings.Repeat(s.char("━", "="), 66)
+	case SeparatorLight:
+		return strings.Repeat(s.char("─", "-"), 71)
+	default:
+		return strings.Repeat("-", 81)
+	}
+}
+
+func (s Separator) char(unicode, plain string) string {
+	if ascii {
+		return plain
+	}
+	return unicode
+}
+
+// Rule logs a separator line
+func Rule(s Separator) {
+	log.Info().Msg(s.String())
+}
+
+// Banner logs a title between two separator lines, preceded by an empty line
+func Banner(s Separator, title string) {
+	log.Info().Msg("")
+	log.Info().Msg(s.String())
+	log.Info().Msg(title)
+	log.Info().Msg(s.String())
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlain(t *testing.T) {
+	defer SetASCII(false)
+	assert.Equal(t, "✅ Deployed", Plain("✅ Deployed"))
+	SetASCII(true)
+	assert.Equal(t, "[OK] Deployed", Plain("✅ Deployed"))
+	assert.Equal(t, "[WARN]  Parameter missing", Plain("⚠️  Parameter missing"))
+	assert.Equal(t, "qa -> prod", Plain("qa → prod"))
+	assert.Equal(t, "* Flow", Plain("🧩 Flow"), "Unknown symbols should be replaced")
+	assert.Equal(t, "Zürich", Plain("Zürich"), "Letters should be kept")
+}
+
+func TestASCIIWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := asciiWriter{out: &out}
+	n, err := w.Write([]byte("📦 Package: Orders ┃\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, len("📦 Package: Orders ┃\n"), n, "The length of the original message should be returned")
+	assert.Equal(t, "[PKG] Package: Orders -\n", out.String())
+}
+
+func TestASCIIAuto(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ASCII mode is not enabled automatically on Windows")
+	}
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	assert.False(t, ASCIIAuto(env(nil)))
+	assert.False(t, ASCIIAuto(env(map[string]string{"LANG": "en_US.UTF-8"})))
+	assert.False(t, ASCIIAuto(env(map[string]string{"LC_CTYPE": "de_DE.utf8", "LANG": "C"})))
+	assert.True(t, ASCIIAuto(env(map[string]string{"LANG": "C"})))
+	assert.True(t, ASCIIAuto(env(map[string]string{"LC_ALL": "en_US.ISO8859-1", "LANG": "en_US.UTF-8"})))
+}
+
+func TestSeparator(t *testing.T) {
+	defer SetASCII(false)
+	assert.Len(t, []rune(SeparatorDouble.String()), 71)
+	assert.Contains(t, SeparatorDouble.String(), "═")
+	SetASCII(true)
+	assert.Equal(t, "=====", SeparatorDouble.String()[:5])
+	assert.Equal(t, "-----", SeparatorLight.String()[:5])
+	assert.Len(t, SeparatorDashed.String(), 81)
+}
@@ -1,15 +1,90 @@
 package logger
 
 import (
+	"fmt"
+	"github.com/engswee/flashpipe/internal/runid"
 	"github.com/go-errors/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"io"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Formats of the timestamps of log messages
+const (
+	TimeFormatDefault = "default" // RFC822, minute precision
+	TimeFormatRFC3339 = "rfc3339" // RFC3339 with milliseconds, e.g. to correlate with the audit log of the tenant
+)
+
+// rfc3339Milli is RFC3339 with milliseconds and a fixed number of digits, so that log lines align
+const rfc3339Milli = "2006-01-02T15:04:05.000Z07:00"
+
+// output is the writer of the global logger, buffered messages are flushed to it
+var (
+	output      io.Writer = os.Stderr
+	outputMutex sync.Mutex
+	timeFormat  = time.RFC822
+	durations   bool
+)
+
+// SetTimeFormat sets the format of the timestamps of log messages, TimeFormatDefault or TimeFormatRFC3339.
+// It applies to loggers initialised afterwards.
+func SetTimeFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", TimeFormatDefault:
+		timeFormat = time.RFC822
+		zerolog.TimeFieldFormat = time.RFC3339
+	case TimeFormatRFC3339:
+		timeFormat = rfc3339Milli
+		zerolog.TimeFieldFormat = time.RFC3339Nano
+	default:
+		return fmt.Errorf("invalid log time format %v (valid values: %v, %v)", format, TimeFormatDefault, TimeFormatRFC3339)
+	}
+	return nil
+}
+
+// SetDurations enables the annotation of log messages of completed steps with their elapsed time, see Took
+func SetDurations(enabled bool) {
+	durations = enabled
+}
+
+// Took returns the annotation of the elapsed time of a step, e.g. " in 2.3s", to append to its log message.
+// It is empty unless enabled with SetDurations.
+func Took(d time.Duration) string {
+	if !durations {
+		return ""
+	}
+	return " in " + FormatDuration(d)
+}
+
+// FormatDuration rounds d to a precision suitable for the duration of a step: milliseconds below one
+// second, tenths of a second below one minute and seconds otherwise
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+// InitConsoleLogger initialises the global logger writing to stderr. Messages carry the run ID, if set, so that
+// they can be correlated with the requests of the run in the audit log of the tenant.
 func InitConsoleLogger(debug bool) {
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC822})
+	var out io.Writer = os.Stderr
+	if ascii {
+		out = asciiWriter{out: out}
+	}
+	output = zerolog.ConsoleWriter{Out: out, TimeFormat: timeFormat}
+	log.Logger = log.Output(lockedWriter{})
+	if id := runid.ID(); id != "" {
+		log.Logger = log.Logger.With().Str("runId", id).Logger()
+	}
 	if debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	} else {
@@ -17,6 +92,48 @@ func InitConsoleLogger(debug bool) {
 	}
 }
 
+// lockedWriter writes to output, waiting for buffers being flushed
+type lockedWriter struct{}
+
+func (lockedWriter) Write(p []byte) (int, error) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return output.Write(p)
+}
+
+// Buffer keeps the messages of a logger until they are flushed, so that the messages of work running
+// concurrently are written as contiguous blocks
+type Buffer struct {
+	mutex    sync.Mutex
+	messages [][]byte
+}
+
+// NewBuffered returns a logger writing to a new Buffer
+func NewBuffered() (zerolog.Logger, *Buffer) {
+	b := new(Buffer)
+	return log.Output(b), b
+}
+
+// Write keeps a message, zerolog writes each message with one call
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.messages = append(b.messages, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// Flush writes the kept messages to the output of the global logger without messages of other buffers in between
+func (b *Buffer) Flush() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	for _, message := range b.messages {
+		_, _ = output.Write(message)
+	}
+	b.messages = nil
+}
+
 func GetErrorDetails(err error) string {
 	switch err.(type) {
 	case *errors.Error:
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "816ms", FormatDuration(816400*time.Microsecond))
+	assert.Equal(t, "2.3s", FormatDuration(2345*time.Millisecond))
+	assert.Equal(t, "1m14s", FormatDuration(74400*time.Millisecond))
+}
+
+func TestTook(t *testing.T) {
+	defer SetDurations(false)
+	assert.Empty(t, Took(time.Second))
+	SetDurations(true)
+	assert.Equal(t, " in 1s", Took(time.Second))
+}
+
+func TestSetTimeFormat(t *testing.T) {
+	defer SetTimeFormat(TimeFormatDefault)
+	assert.NoError(t, SetTimeFormat("RFC3339"))
+	assert.Equal(t, rfc3339Milli, timeFormat)
+	assert.NoError(t, SetTimeFormat(""))
+	assert.Equal(t, time.RFC822, timeFormat)
+	assert.EqualError(t, SetTimeFormat("unix"), "invalid log time format unix (valid values: default, rfc3339)")
+}
@@ -0,0 +1,126 @@
+package mockcpi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// batchOperation is the request of a part of a $batch request and its response
+type batchOperation struct {
+	contentID string
+	response  *httptest.ResponseRecorder
+}
+
+// batch executes the operations of a $batch request. The operations of a changeset are rolled back if one of
+// them fails, which is answered with the response of the failed operation only, as done by the tenant.
+func (s *Server) batch(w http.ResponseWriter, r *http.Request, _ []string) {
+	boundary, err := multipartBoundary(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var out bytes.Buffer
+	mr := multipart.NewReader(r.Body, boundary)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid batch request: "+err.Error())
+			return
+		}
+		fmt.Fprintf(&out, "--batchresponse\r\n")
+		if changeset, err := multipartBoundary(part.Header.Get("Content-Type")); err == nil {
+			ops, err := s.changeset(multipart.NewReader(part, changeset))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid changeset: "+err.Error())
+				return
+			}
+			fmt.Fprintf(&out, "Content-Type: multipart/mixed; boundary=changesetresponse\r\n\r\n")
+			for _, op := range ops {
+				fmt.Fprintf(&out, "--changesetresponse\r\n")
+				writeOperation(&out, op)
+			}
+			fmt.Fprintf(&out, "--changesetresponse--\r\n\r\n")
+			continue
+		}
+		op, err := s.operation(part)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid batch operation: "+err.Error())
+			return
+		}
+		writeOperation(&out, op)
+	}
+	fmt.Fprintf(&out, "--batchresponse--\r\n")
+
+	w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresponse")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(out.Bytes())
+}
+
+// changeset executes the operations of a changeset atomically
+func (s *Server) changeset(mr *multipart.Reader) ([]batchOperation, error) {
+	snapshot := s.tenant.clone()
+	var ops []batchOperation
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return ops, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		op, err := s.operation(part)
+		if err != nil {
+			return nil, err
+		}
+		if op.response.Code >= http.StatusBadRequest {
+			s.tenant = snapshot
+			return []batchOperation{op}, nil
+		}
+		ops = append(ops, op)
+	}
+}
+
+// operation executes the HTTP request of a part of a $batch request
+func (s *Server) operation(part *multipart.Part) (batchOperation, error) {
+	req, err := http.ReadRequest(bufio.NewReader(part))
+	if err != nil {
+		return batchOperation{}, err
+	}
+	op := batchOperation{contentID: part.Header.Get("Content-ID"), response: httptest.NewRecorder()}
+	s.handle(op.response, req)
+	return op, nil
+}
+
+func writeOperation(out *bytes.Buffer, op batchOperation) {
+	fmt.Fprintf(out, "Content-Type: application/http\r\nContent-Transfer-Encoding: binary\r\n")
+	if op.contentID != "" {
+		fmt.Fprintf(out, "Content-ID: %s\r\n", op.contentID)
+	}
+	code := op.response.Code
+	fmt.Fprintf(out, "\r\nHTTP/1.1 %d %s\r\n", code, http.StatusText(code))
+	if contentType := op.response.Header().Get("Content-Type"); contentType != "" {
+		fmt.Fprintf(out, "Content-Type: %s\r\n", contentType)
+	}
+	fmt.Fprintf(out, "\r\n%s\r\n", strings.TrimSpace(op.response.Body.String()))
+}
+
+func multipartBoundary(contentType string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return "", fmt.Errorf("multipart content expected, got %s", contentType)
+	}
+	return params["boundary"], nil
+}
@@ -0,0 +1,605 @@
+// Package mockcpi is an HTTP test server that emulates the OData APIs of Cloud Integration used by FlashPipe,
+// including $batch requests with changesets. Its tenant is set up from a Fixture and keeps the changes of
+// requests, so that commands can be tested end to end without a tenant.
+package mockcpi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Fixture is the content of the mock tenant
+type Fixture struct {
+	Packages         []Package         `json:"packages"`
+	Runtime          []RuntimeArtifact `json:"runtime,omitempty"`
+	StringParameters []StringParameter `json:"stringParameters,omitempty"`
+	CustomTags       []string          `json:"customTags,omitempty"` // Custom tags defined in the settings of the tenant
+}
+
+// Package is an integration package of the mock tenant
+type Package struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Artifacts   []Artifact `json:"artifacts,omitempty"`
+}
+
+// Artifact is a designtime artifact of the mock tenant
+type Artifact struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"` // Integration, MessageMapping, ScriptCollection or ValueMapping
+	Version    string            `json:"version"`
+	Parameters map[string]string `json:"parameters,omitempty"` // Configuration parameters of an integration flow
+	// DeployError lets deployments of the artifact fail with the error, e.g. to test failed deployments
+	DeployError string `json:"deployError,omitempty"`
+}
+
+// RuntimeArtifact is a deployed artifact of the mock tenant
+type RuntimeArtifact struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	Status  string `json:"status"` // STARTED or ERROR
+	Error   string `json:"error,omitempty"`
+}
+
+// StringParameter is a string parameter of the Partner Directory of the mock tenant
+type StringParameter struct {
+	Pid   string `json:"pid"`
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+// DefaultFixture returns the tenant used by flashpipe selftest, with the package Sales
+func DefaultFixture() *Fixture {
+	data, err := fixtures.ReadFile("fixtures/tenant.json")
+	if err != nil {
+		panic(err)
+	}
+	fixture, err := parseFixture(data)
+	if err != nil {
+		panic(err)
+	}
+	return fixture
+}
+
+// LoadFixture reads a fixture from a JSON file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture, err := parseFixture(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixture %v: %w", path, err)
+	}
+	return fixture, nil
+}
+
+func parseFixture(data []byte) (*Fixture, error) {
+	fixture := new(Fixture)
+	if err := json.Unmarshal(data, fixture); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// FixtureFile returns the embedded file of the fixtures folder, e.g. the configuration of flashpipe selftest
+func FixtureFile(name string) ([]byte, error) {
+	return fixtures.ReadFile("fixtures/" + name)
+}
+
+// tenant is the state of the mock tenant
+type tenant struct {
+	packages     []string
+	descriptions map[string]string    // package descriptions by ID
+	artifacts    map[string]*Artifact // by ID
+	packageOf    map[string]string    // package ID by artifact ID
+	runtime      map[string]*RuntimeArtifact
+	strings      map[[2]string]string // values by Pid and ID
+	tagNames     []string             // custom tags defined in the settings
+	customTags   map[[2]string]string // values by package ID and tag name
+}
+
+func (t *tenant) clone() *tenant {
+	c := &tenant{
+		packages:     slices.Clone(t.packages),
+		descriptions: maps.Clone(t.descriptions),
+		artifacts:    map[string]*Artifact{},
+		packageOf:    maps.Clone(t.packageOf),
+		runtime:      map[string]*RuntimeArtifact{},
+		strings:      maps.Clone(t.strings),
+		tagNames:     slices.Clone(t.tagNames),
+		customTags:   maps.Clone(t.customTags),
+	}
+	for id, a := range t.artifacts {
+		artifact := *a
+		artifact.Parameters = maps.Clone(a.Parameters)
+		c.artifacts[id] = &artifact
+	}
+	for id, r := range t.runtime {
+		runtime := *r
+		c.runtime[id] = &runtime
+	}
+	return c
+}
+
+// Server is a running mock tenant
+type Server struct {
+	*httptest.Server
+	mu        sync.Mutex
+	tenant    *tenant
+	requests  []string
+	unhandled []string
+}
+
+// New starts a mock tenant with the content of fixture. It is stopped with Close.
+func New(fixture *Fixture) *Server {
+	t := &tenant{descriptions: map[string]string{}, artifacts: map[string]*Artifact{}, packageOf: map[string]string{}, runtime: map[string]*RuntimeArtifact{},
+		strings: map[[2]string]string{}, tagNames: fixture.CustomTags, customTags: map[[2]string]string{}}
+	for _, p := range fixture.Packages {
+		t.packages = append(t.packages, p.ID)
+		t.descriptions[p.ID] = p.Description
+		for _, a := range p.Artifacts {
+			artifact := a
+			artifact.Parameters = maps.Clone(a.Parameters)
+			t.artifacts[a.ID] = &artifact
+			t.packageOf[a.ID] = p.ID
+		}
+	}
+	for _, r := range fixture.Runtime {
+		runtime := r
+		t.runtime[r.ID] = &runtime
+	}
+	for _, p := range fixture.StringParameters {
+		t.strings[[2]string{p.Pid, p.ID}] = p.Value
+	}
+	s := &Server{tenant: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.handle(w, r)
+	}))
+	return s
+}
+
+// Executer returns an executer for the mock tenant with Basic Authentication
+func (s *Server) Executer() *httpclnt.HTTPExecuter {
+	host, port := httpclnt.GetHostPort(s.URL)
+	return httpclnt.New("", "", "", "", "mock", "mock", host, "http", port, true)
+}
+
+// Parameter returns the value of a configuration parameter of an integration flow
+func (s *Server) Parameter(artifactID string, key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.tenant.artifacts[artifactID]
+	if !ok {
+		return "", false
+	}
+	value, ok := a.Parameters[key]
+	return value, ok
+}
+
+// Runtime returns the deployed artifact, nil if it is not deployed
+func (s *Server) Runtime(artifactID string) *RuntimeArtifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.tenant.runtime[artifactID]; ok {
+		runtime := *r
+		return &runtime
+	}
+	return nil
+}
+
+// StringParameter returns the value of a string parameter of the Partner Directory
+func (s *Server) StringParameter(pid string, id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.tenant.strings[[2]string{pid, id}]
+	return value, ok
+}
+
+// PackageDescription returns the description of an integration package
+func (s *Server) PackageDescription(packageID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tenant.descriptions[packageID]
+}
+
+// CustomTag returns the value of a custom tag of an integration package
+func (s *Server) CustomTag(packageID string, name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.tenant.customTags[[2]string{packageID, name}]
+	return value, ok
+}
+
+// Requests returns the method and decoded path of the requests received, including the operations of $batch
+// requests
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// Unhandled returns the requests the mock tenant does not emulate, which were answered with 404. Tests should
+// check that it is empty, so that commands do not silently rely on missing endpoints.
+func (s *Server) Unhandled() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.unhandled)
+}
+
+// key matches a quoted key value, in which quotes are doubled
+const key = `'((?:[^']|'')*)'`
+
+// route is an endpoint of the mock tenant, handle is called with the submatches of pattern in the decoded path
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	handle  func(s *Server, w http.ResponseWriter, r *http.Request, m []string)
+}
+
+// routes are set in init, as the handler of $batch routes its operations
+var routes []route
+
+func init() {
+	routes = []route{
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/$`), (*Server).serviceDocument},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/\$batch$`), func(_ *Server, w http.ResponseWriter, _ *http.Request, _ []string) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}},
+		{http.MethodPost, regexp.MustCompile(`^/api/v1/\$batch$`), (*Server).batch},
+		// The mock tenant has no OData V4 APIs, which are probed by the feature detection
+		{http.MethodGet, regexp.MustCompile(`^/api/v4/$`), func(_ *Server, w http.ResponseWriter, _ *http.Request, _ []string) {
+			writeError(w, http.StatusNotFound, "Resource not found for the segment api/v4")
+		}},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/RuntimeLocations$`), func(_ *Server, w http.ResponseWriter, _ *http.Request, _ []string) {
+			writeResults(w, []map[string]string{{"Id": "cloudintegration"}})
+		}},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/IntegrationPackages$`), (*Server).listPackages},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/IntegrationPackages\(` + key + `\)$`), (*Server).getPackage},
+		{http.MethodPut, regexp.MustCompile(`^/api/v1/IntegrationPackages\(` + key + `\)$`), (*Server).updatePackage},
+		{http.MethodPut, regexp.MustCompile(`^/api/v1/IntegrationPackages\(` + key + `\)/\$links/CustomTags\(` + key + `\)$`), (*Server).updateCustomTag},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/IntegrationPackages\(` + key + `\)/(\w+)DesigntimeArtifacts$`), (*Server).listArtifacts},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/(\w+)DesigntimeArtifacts\(Id=` + key + `,Version=` + key + `\)$`), (*Server).getArtifact},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/IntegrationDesigntimeArtifacts\(Id=` + key + `,Version=` + key + `\)/Configurations$`), (*Server).getConfigurations},
+		{http.MethodPut, regexp.MustCompile(`^/api/v1/IntegrationDesigntimeArtifacts\(Id=` + key + `,Version=` + key + `\)/\$links/Configurations\(` + key + `\)$`), (*Server).updateConfiguration},
+		{http.MethodPost, regexp.MustCompile(`^/api/v1/Deploy(\w+)DesigntimeArtifact$`), (*Server).deploy},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/IntegrationRuntimeArtifacts$`), (*Server).listRuntime},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/IntegrationRuntimeArtifacts\(` + key + `\)$`), (*Server).getRuntime},
+		{http.MethodDelete, regexp.MustCompile(`^/api/v1/IntegrationRuntimeArtifacts\(` + key + `\)$`), (*Server).undeploy},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/IntegrationRuntimeArtifacts\(` + key + `\)/ErrorInformation/\$value$`), (*Server).getRuntimeError},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/StringParameters$`), (*Server).listStringParameters},
+		{http.MethodPost, regexp.MustCompile(`^/api/v1/StringParameters$`), (*Server).createStringParameter},
+		{http.MethodGet, regexp.MustCompile(`^/api/v1/StringParameters\(Pid=` + key + `,Id=` + key + `\)$`), (*Server).getStringParameter},
+		{http.MethodPut, regexp.MustCompile(`^/api/v1/StringParameters\(Pid=` + key + `,Id=` + key + `\)$`), (*Server).updateStringParameter},
+		{http.MethodDelete, regexp.MustCompile(`^/api/v1/StringParameters\(Pid=` + key + `,Id=` + key + `\)$`), (*Server).deleteStringParameter},
+	}
+}
+
+// handle routes a request, the caller holds the lock
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	request := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		request += "?" + r.URL.RawQuery
+	}
+	s.requests = append(s.requests, request)
+	for _, rt := range routes {
+		if m := rt.pattern.FindStringSubmatch(r.URL.Path); m != nil && rt.method == r.Method {
+			for i := range m {
+				m[i] = strings.ReplaceAll(m[i], "''", "'")
+			}
+			rt.handle(s, w, r, m)
+			return
+		}
+	}
+	s.unhandled = append(s.unhandled, request)
+	writeError(w, http.StatusNotFound, "Resource not found for the segment "+r.URL.Path)
+}
+
+func (s *Server) serviceDocument(w http.ResponseWriter, _ *http.Request, _ []string) {
+	w.Header().Set("x-csrf-token", "mock-token")
+	writeJSON(w, http.StatusOK, map[string]any{"d": map[string]any{"EntitySets": []string{
+		"IntegrationPackages", "IntegrationDesigntimeArtifacts", "MessageMappingDesigntimeArtifacts",
+		"ScriptCollectionDesigntimeArtifacts", "ValueMappingDesigntimeArtifacts", "IntegrationRuntimeArtifacts",
+		"StringParameters", "RuntimeLocations",
+	}}})
+}
+
+func (s *Server) listPackages(w http.ResponseWriter, _ *http.Request, _ []string) {
+	var results []map[string]any
+	for _, id := range s.tenant.packages {
+		results = append(results, s.packageData(id))
+	}
+	writeResults(w, results)
+}
+
+func (s *Server) getPackage(w http.ResponseWriter, _ *http.Request, m []string) {
+	if !slices.Contains(s.tenant.packages, m[1]) {
+		writeError(w, http.StatusNotFound, "Integration package "+m[1]+" not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"d": s.packageData(m[1])})
+}
+
+func (s *Server) updatePackage(w http.ResponseWriter, r *http.Request, m []string) {
+	if !slices.Contains(s.tenant.packages, m[1]) {
+		writeError(w, http.StatusNotFound, "Integration package "+m[1]+" not found")
+		return
+	}
+	var body struct {
+		Root struct {
+			Description string `json:"Description"`
+		} `json:"d"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.tenant.descriptions[m[1]] = body.Root.Description
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) updateCustomTag(w http.ResponseWriter, r *http.Request, m []string) {
+	if !slices.Contains(s.tenant.packages, m[1]) {
+		writeError(w, http.StatusNotFound, "Integration package "+m[1]+" not found")
+		return
+	}
+	if !slices.Contains(s.tenant.tagNames, m[2]) {
+		writeError(w, http.StatusNotFound, "Custom tag "+m[2]+" is not defined")
+		return
+	}
+	var body struct {
+		Value string `json:"Value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.tenant.customTags[[2]string{m[1], m[2]}] = body.Value
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) packageData(id string) map[string]any {
+	return map[string]any{"Id": id, "Name": id, "Description": s.tenant.descriptions[id], "Version": "1.0.0", "Mode": "EDIT_ALLOWED"}
+}
+
+func (s *Server) listArtifacts(w http.ResponseWriter, _ *http.Request, m []string) {
+	var results []map[string]any
+	for _, id := range slices.Sorted(maps.Keys(s.tenant.artifacts)) {
+		if a := s.tenant.artifacts[id]; s.tenant.packageOf[id] == m[1] && a.Type == m[2] {
+			results = append(results, s.artifactData(a))
+		}
+	}
+	writeResults(w, results)
+}
+
+func (s *Server) artifactData(a *Artifact) map[string]any {
+	return map[string]any{"Id": a.ID, "Name": a.ID, "Version": a.Version, "PackageId": s.tenant.packageOf[a.ID]}
+}
+
+// artifact returns the artifact of the type with the version, active being the current version
+func (s *Server) artifact(w http.ResponseWriter, artifactType string, id string, version string) *Artifact {
+	a, ok := s.tenant.artifacts[id]
+	if !ok || a.Type != artifactType || (version != "active" && version != a.Version) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s designtime artifact %s with version %s not found", artifactType, id, version))
+		return nil
+	}
+	return a
+}
+
+func (s *Server) getArtifact(w http.ResponseWriter, _ *http.Request, m []string) {
+	if a := s.artifact(w, m[1], m[2], m[3]); a != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"d": s.artifactData(a)})
+	}
+}
+
+func (s *Server) getConfigurations(w http.ResponseWriter, _ *http.Request, m []string) {
+	a := s.artifact(w, "Integration", m[1], m[2])
+	if a == nil {
+		return
+	}
+	var results []map[string]string
+	for _, k := range slices.Sorted(maps.Keys(a.Parameters)) {
+		results = append(results, map[string]string{"ParameterKey": k, "ParameterValue": a.Parameters[k], "DataType": "xsd:string"})
+	}
+	writeResults(w, results)
+}
+
+func (s *Server) updateConfiguration(w http.ResponseWriter, r *http.Request, m []string) {
+	a := s.artifact(w, "Integration", m[1], m[2])
+	if a == nil {
+		return
+	}
+	if _, ok := a.Parameters[m[3]]; !ok {
+		writeError(w, http.StatusNotFound, "Parameter "+m[3]+" not found")
+		return
+	}
+	var body struct {
+		ParameterValue *string `json:"ParameterValue"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ParameterValue == nil {
+		writeError(w, http.StatusBadRequest, "ParameterValue missing")
+		return
+	}
+	a.Parameters[m[3]] = *body.ParameterValue
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) deploy(w http.ResponseWriter, r *http.Request, m []string) {
+	query := r.URL.Query()
+	a := s.artifact(w, m[1], functionParameter(query.Get("Id")), functionParameter(query.Get("Version")))
+	if a == nil {
+		return
+	}
+	runtime := &RuntimeArtifact{ID: a.ID, Type: a.Type, Version: a.Version, Status: "STARTED"}
+	if a.DeployError != "" {
+		runtime.Status, runtime.Error = "ERROR", a.DeployError
+	}
+	s.tenant.runtime[a.ID] = runtime
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(a.ID))
+}
+
+// functionParameter returns the value of a quoted function import parameter
+func functionParameter(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		value = value[1 : len(value)-1]
+	}
+	return strings.ReplaceAll(value, "''", "'")
+}
+
+func (s *Server) listRuntime(w http.ResponseWriter, _ *http.Request, _ []string) {
+	var results []map[string]any
+	for _, id := range slices.Sorted(maps.Keys(s.tenant.runtime)) {
+		results = append(results, runtimeData(s.tenant.runtime[id]))
+	}
+	writeResults(w, results)
+}
+
+func (s *Server) getRuntime(w http.ResponseWriter, _ *http.Request, m []string) {
+	if r := s.runtime(w, m[1]); r != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"d": runtimeData(r)})
+	}
+}
+
+func (s *Server) runtime(w http.ResponseWriter, id string) *RuntimeArtifact {
+	r, ok := s.tenant.runtime[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Requested entity could not be found.")
+	}
+	return r
+}
+
+func runtimeData(r *RuntimeArtifact) map[string]any {
+	return map[string]any{"Id": r.ID, "Name": r.ID, "Version": r.Version, "Type": runtimeType(r.Type), "Status": r.Status}
+}
+
+// runtimeType returns the type of a runtime artifact, e.g. INTEGRATION_FLOW for Integration
+func runtimeType(artifactType string) string {
+	switch artifactType {
+	case "Integration":
+		return "INTEGRATION_FLOW"
+	case "MessageMapping":
+		return "MESSAGE_MAPPING"
+	case "ScriptCollection":
+		return "SCRIPT_COLLECTION"
+	case "ValueMapping":
+		return "VALUE_MAPPING"
+	}
+	return artifactType
+}
+
+func (s *Server) undeploy(w http.ResponseWriter, _ *http.Request, m []string) {
+	if s.runtime(w, m[1]) != nil {
+		delete(s.tenant.runtime, m[1])
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (s *Server) getRuntimeError(w http.ResponseWriter, _ *http.Request, m []string) {
+	if r := s.runtime(w, m[1]); r != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"parameter": []string{r.Error}})
+	}
+}
+
+func (s *Server) listStringParameters(w http.ResponseWriter, _ *http.Request, _ []string) {
+	var results []map[string]string
+	keys := slices.SortedFunc(maps.Keys(s.tenant.strings), func(a, b [2]string) int {
+		return strings.Compare(a[0]+"\x00"+a[1], b[0]+"\x00"+b[1])
+	})
+	for _, k := range keys {
+		results = append(results, map[string]string{"Pid": k[0], "Id": k[1], "Value": s.tenant.strings[k]})
+	}
+	writeResults(w, results)
+}
+
+func (s *Server) createStringParameter(w http.ResponseWriter, r *http.Request, _ []string) {
+	var body struct {
+		Pid   string `json:"Pid"`
+		ID    string `json:"Id"`
+		Value string `json:"Value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Pid == "" || body.ID == "" {
+		writeError(w, http.StatusBadRequest, "Pid and Id are required")
+		return
+	}
+	k := [2]string{body.Pid, body.ID}
+	if _, ok := s.tenant.strings[k]; ok {
+		writeError(w, http.StatusConflict, "String parameter already exists")
+		return
+	}
+	s.tenant.strings[k] = body.Value
+	writeJSON(w, http.StatusCreated, map[string]any{"d": map[string]string{"Pid": body.Pid, "Id": body.ID, "Value": body.Value}})
+}
+
+func (s *Server) getStringParameter(w http.ResponseWriter, _ *http.Request, m []string) {
+	k := [2]string{m[1], m[2]}
+	value, ok := s.tenant.strings[k]
+	if !ok {
+		writeError(w, http.StatusNotFound, "String parameter not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"d": map[string]string{"Pid": k[0], "Id": k[1], "Value": value}})
+}
+
+func (s *Server) updateStringParameter(w http.ResponseWriter, r *http.Request, m []string) {
+	k := [2]string{m[1], m[2]}
+	if _, ok := s.tenant.strings[k]; !ok {
+		writeError(w, http.StatusNotFound, "String parameter not found")
+		return
+	}
+	var body struct {
+		Value string `json:"Value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.tenant.strings[k] = body.Value
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteStringParameter(w http.ResponseWriter, _ *http.Request, m []string) {
+	k := [2]string{m[1], m[2]}
+	if _, ok := s.tenant.strings[k]; !ok {
+		writeError(w, http.StatusNotFound, "String parameter not found")
+		return
+	}
+	delete(s.tenant.strings, k)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeResults[T any](w http.ResponseWriter, results []T) {
+	if results == nil {
+		results = []T{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"d": map[string]any{"results": results}})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format of the OData APIs
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]any{"error": map[string]any{"code": "Error", "message": map[string]string{"lang": "en", "value": message}}})
+}
@@ -0,0 +1,105 @@
+package mockcpi
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration(t *testing.T) {
+	svr := New(DefaultFixture())
+	defer svr.Close()
+	c := api.NewConfiguration(svr.Executer())
+
+	params, err := c.Get("Orders", "active")
+	require.NoError(t, err)
+	require.Len(t, params.Root.Results, 3)
+	assert.Equal(t, &api.ParameterData{ParameterKey: "Receiver Host", ParameterValue: "dev.example.com", DataType: "xsd:string"}, params.Root.Results[0])
+
+	require.NoError(t, c.Update("Invoices", "active", "Company's Code", "2000"))
+	value, _ := svr.Parameter("Invoices", "Company's Code")
+	assert.Equal(t, "2000", value, "Quotes in keys should be unescaped")
+
+	assert.ErrorContains(t, c.Update("Orders", "active", "Unknown", "x"), "404")
+	_, err = c.Get("Orders", "2.0.0")
+	assert.Error(t, err, "Only the version of the artifact should be found")
+	assert.Empty(t, svr.Unhandled())
+}
+
+func TestBatch(t *testing.T) {
+	svr := New(DefaultFixture())
+	defer svr.Close()
+	exe := svr.Executer()
+
+	configurations, err := api.NewConfiguration(exe).GetBatch([]string{"Orders", "Missing"}, "active", []string{"ParameterKey", "ParameterValue"}, 90)
+	require.NoError(t, err)
+	assert.Len(t, configurations["Orders"].Parameters.Root.Results, 3)
+	assert.ErrorContains(t, configurations["Missing"].Err, "404")
+
+	// The changeset is rolled back as the second parameter does not exist
+	batch := exe.NewBatchRequest()
+	for i, key := range []string{"Timeout", "Unknown"} {
+		path, body, err := api.ConfigurationUpdateRequest("Orders", "active", key, "90")
+		require.NoError(t, err)
+		batch.AddOperation(httpclnt.BatchOperation{Method: "PUT", Path: path, Body: body, ContentID: string(rune('1' + i)),
+			Headers: map[string]string{"Content-Type": "application/json"}})
+	}
+	resp, err := batch.Execute()
+	require.NoError(t, err)
+	require.Len(t, resp.Operations, 1, "A failed changeset should be answered with the failed operation")
+	assert.Equal(t, 404, resp.Operations[0].StatusCode)
+	value, _ := svr.Parameter("Orders", "Timeout")
+	assert.Equal(t, "30", value, "Changeset should be rolled back")
+
+	batch = exe.NewBatchRequest()
+	httpclnt.AddUpdateStringParameterOp(batch, "Sales", "Region", "US", "1")
+	httpclnt.AddCreateStringParameterOp(batch, "Sales", "Currency", "USD", "2")
+	resp, err = batch.Execute()
+	require.NoError(t, err)
+	require.Len(t, resp.Operations, 2)
+	assert.Equal(t, 204, resp.Operations[0].StatusCode)
+	assert.Equal(t, 201, resp.Operations[1].StatusCode)
+	value, _ = svr.StringParameter("Sales", "Currency")
+	assert.Equal(t, "USD", value)
+	assert.Empty(t, svr.Unhandled())
+}
+
+func TestDeploy(t *testing.T) {
+	fixture := DefaultFixture()
+	fixture.Packages[0].Artifacts[1].DeployError = "Receiver Host is invalid"
+	svr := New(fixture)
+	defer svr.Close()
+	exe := svr.Executer()
+
+	require.NoError(t, api.NewDesigntimeArtifact("MessageMapping", exe).Deploy("Order_Mapping"))
+	assert.Equal(t, &RuntimeArtifact{ID: "Order_Mapping", Type: "MessageMapping", Version: "1.0.0", Status: "STARTED"}, svr.Runtime("Order_Mapping"))
+
+	rt := api.NewRuntime(exe)
+	require.NoError(t, api.NewDesigntimeArtifact("Integration", exe).Deploy("Invoices"))
+	_, status, err := rt.Get("Invoices")
+	require.NoError(t, err)
+	assert.Equal(t, "ERROR", status)
+	message, err := rt.GetErrorInfo("Invoices")
+	require.NoError(t, err)
+	assert.Equal(t, "Receiver Host is invalid", message)
+
+	require.NoError(t, rt.UnDeploy("Orders"))
+	version, _, err := rt.Get("Orders")
+	require.NoError(t, err)
+	assert.Equal(t, "NOT_DEPLOYED", version)
+	assert.Empty(t, svr.Unhandled())
+	assert.Contains(t, svr.Requests(), "POST /api/v1/DeployIntegrationDesigntimeArtifact?Id='Invoices'&Version='active'")
+}
+
+func TestUnhandled(t *testing.T) {
+	svr := New(&Fixture{})
+	defer svr.Close()
+	_, err := api.NewIntegrationPackage(svr.Executer()).GetPackagesList()
+	require.NoError(t, err)
+	_, _, err = api.NewMessageProcessingLog(svr.Executer()).Get("guid")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"GET /api/v1/MessageProcessingLogs('guid')"}, svr.Unhandled())
+}
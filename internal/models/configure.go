@@ -1,16 +1,142 @@
 package models
 
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
 // ConfigureConfig represents the complete configuration file structure
 type ConfigureConfig struct {
-	DeploymentPrefix string             `yaml:"deploymentPrefix,omitempty"`
-	Packages         []ConfigurePackage `yaml:"packages"`
+	DeploymentPrefix string                              `yaml:"deploymentPrefix,omitempty"`
+	Hooks            *ConfigureHooks                     `yaml:"hooks,omitempty"`                   // Hooks executed once per run
+	Targets          []ConfigureTarget                   `yaml:"targets,omitempty"`                 // Tenants the configuration is applied to
+	Rollout          *ConfigureRollout                   `yaml:"rollout,omitempty"`                 // Order in which the targets are configured
+	TypeAliases      map[string]string                   `yaml:"typeAliases,omitempty"`             // Custom artifact types mapped to a supported type
+	ParameterGroups  map[string][]ConfigurationParameter `yaml:"parameterGroups,omitempty"`         // Named parameters shared by artifacts with useGroups
+	CreateMissing    bool                                `yaml:"createMissingParameters,omitempty"` // Create parameters not found in the artifacts instead of skipping them
+	BatchFallback    string                              `yaml:"batchFallback,omitempty"`           // Handling of failed batch requests: none, failed-only or all (default)
+	Stamp            *ConfigureStamp                     `yaml:"stamp,omitempty"`                   // Records the last run on the packages it changed
+	TenantDefaults   []ConfigurationParameter            `yaml:"tenantDefaults,omitempty"`          // Parameters set on all artifacts that have them, unless set on the artifact
+	Packages         []ConfigurePackage                  `yaml:"packages"`
+	Conditions       *Conditions                         `yaml:"-"` // Context of the when conditions, set when the configuration is loaded
+}
+
+// Conditions is the context the when conditions of packages and artifacts are evaluated with, together with
+// the name and host of the tenant
+type Conditions struct {
+	Environment string
+	Values      map[string]interface{}
+}
+
+// ConfigureTarget is a tenant the configuration is applied to. Credentials can reference
+// environment variables as $VAR or ${VAR}.
+type ConfigureTarget struct {
+	Name             string                       `yaml:"name"`
+	Host             string                       `yaml:"host"`
+	OAuthHost        string                       `yaml:"oauthHost,omitempty"`
+	OAuthPath        string                       `yaml:"oauthPath,omitempty"`
+	ClientID         string                       `yaml:"clientId,omitempty"`
+	ClientSecret     string                       `yaml:"clientSecret,omitempty"`
+	UserID           string                       `yaml:"userId,omitempty"`
+	Password         string                       `yaml:"password,omitempty"`
+	ODataVersion     string                       `yaml:"odataVersion,omitempty"`     // Version of the OData APIs: auto (default), v2 or v4
+	DeploymentPrefix string                       `yaml:"deploymentPrefix,omitempty"` // Overrides the deployment prefix for this tenant
+	Parameters       map[string]map[string]string `yaml:"parameters,omitempty"`       // Parameter overrides by artifact ID and key
+	TenantDefaults   []ConfigurationParameter     `yaml:"tenantDefaults,omitempty"`   // Overrides the tenant defaults of the configuration by key
+}
+
+// ConfigureRollout defines the order in which the configuration is applied to the targets. With the canary
+// strategy, the tenants of the steps are configured one step after the other, followed by the remaining targets.
+type ConfigureRollout struct {
+	Strategy    string                 `yaml:"strategy"`              // all (default) or canary
+	Steps       []ConfigureRolloutStep `yaml:"steps,omitempty"`       // Canary steps
+	HealthCheck *ConfigureHealthCheck  `yaml:"healthCheck,omitempty"` // Health check after each canary step
+	Rollback    bool                   `yaml:"rollback,omitempty"`    // Restore previous parameter values when the rollout is aborted
+}
+
+// ConfigureStamp records the run ID, Git revision and time of the last run on the packages it changed, so that
+// tenant administrators can see in the UI when and by which pipeline their content was last changed
+type ConfigureStamp struct {
+	Target   string `yaml:"target"`             // description (a line of the package description) or tag (a custom tag)
+	Tag      string `yaml:"tag,omitempty"`      // Custom tag set with target tag, defaults to flashpipe
+	Revision string `yaml:"revision,omitempty"` // Git revision of the configuration, defaults to the commit of the CI/CD pipeline run
+}
+
+// ConfigureRolloutStep is a canary step
+type ConfigureRolloutStep struct {
+	Tenant       string `yaml:"tenant"`                 // Name of the target
+	PauseMinutes int    `yaml:"pauseMinutes,omitempty"` // Time to wait after deployment before the health check
+}
+
+// ConfigureHealthCheck checks the message processing logs of the deployed integration flows
+type ConfigureHealthCheck struct {
+	MaxFailedMessages int      `yaml:"maxFailedMessages,omitempty"` // Failed messages tolerated per integration flow
+	Artifacts         []string `yaml:"artifacts,omitempty"`         // Integration flows to check, defaults to deployed integration flows
+	OnFailure         string   `yaml:"onFailure,omitempty"`         // keep (default) or rollback to redeploy the previously running versions
+}
+
+// MaintenanceWindow restricts when artifacts may be deployed, given either as a daily time range or
+// as a cron expression for the opening of the window with a duration
+type MaintenanceWindow struct {
+	TimeRange string `yaml:"timeRange,omitempty"` // e.g. "22:00-02:00", may cross midnight
+	Cron      string `yaml:"cron,omitempty"`      // e.g. "0 6 * * 6"
+	Duration  string `yaml:"duration,omitempty"`  // e.g. "4h", required with cron
+	Timezone  string `yaml:"timezone,omitempty"`  // IANA timezone, defaults to UTC
+}
+
+// DrainCheck lists the JMS queues and data stores that must be empty before an artifact undeployed
+// with the stopStart strategy is deployed again
+type DrainCheck struct {
+	JMSQueues      []string `yaml:"jmsQueues,omitempty"`
+	DataStores     []string `yaml:"dataStores,omitempty"`
+	TimeoutMinutes int      `yaml:"timeoutMinutes,omitempty"` // Defaults to 10
+	Discover       bool     `yaml:"discover,omitempty"`       // Also check the JMS sender queues and data stores of the artifact
+}
+
+// BlueGreenSettings configure the temporary copy of an integration flow that is deployed and smoke tested
+// before the integration flow itself is redeployed
+type BlueGreenSettings struct {
+	TempSuffix       string     `yaml:"tempSuffix,omitempty"`       // Suffix of the ID of the copy, defaults to _BG
+	AddressParameter string     `yaml:"addressParameter,omitempty"` // Parameter with the HTTP address, suffixed for the copy
+	SmokeTest        *SmokeTest `yaml:"smokeTest,omitempty"`
+}
+
+// SmokeTest is a request sent to the HTTP endpoint of the temporary copy
+type SmokeTest struct {
+	Path              string               `yaml:"path,omitempty"`              // Appended to the endpoint URL
+	Method            string               `yaml:"method,omitempty"`            // Defaults to GET
+	Body              string               `yaml:"body,omitempty"`              // Request body
+	Headers           map[string]string    `yaml:"headers,omitempty"`           // Values can reference environment variables as $VAR or ${VAR}
+	ExpectedStatus    int                  `yaml:"expectedStatus,omitempty"`    // Defaults to 200, unless an assertion checks the status
+	Assertions        []SmokeTestAssertion `yaml:"assertions,omitempty"`        // Further checks of the response
+	Retries           int                  `yaml:"retries,omitempty"`           // Additional attempts if the request or an assertion fails
+	RetryDelaySeconds int                  `yaml:"retryDelaySeconds,omitempty"` // Defaults to 10
+}
+
+// SmokeTestAssertion checks the response of a smoke test. Exactly one of status, jsonPath, xpath, header and
+// maxResponseMs is set. jsonPath, xpath and header check that the value exists, or compare it with equals
+// or contains.
+type SmokeTestAssertion struct {
+	Status        int    `yaml:"status,omitempty"`        // Expected HTTP status
+	JSONPath      string `yaml:"jsonPath,omitempty"`      // Path of a value in a JSON response, e.g. $.items[0].status
+	XPath         string `yaml:"xpath,omitempty"`         // Path of an element or attribute in an XML response, e.g. //Order/@id
+	Header        string `yaml:"header,omitempty"`        // Name of a response header
+	MaxResponseMs int    `yaml:"maxResponseMs,omitempty"` // Response time budget in milliseconds
+	Equals        string `yaml:"equals,omitempty"`        // Expected value
+	Contains      string `yaml:"contains,omitempty"`      // Expected part of the value
 }
 
 // ConfigurePackage represents a package containing artifacts to configure
 type ConfigurePackage struct {
 	ID          string              `yaml:"integrationSuiteId"`
 	DisplayName string              `yaml:"displayName,omitempty"`
-	Deploy      bool                `yaml:"deploy"` // Deploy all artifacts in package after configuration
+	Deploy      bool                `yaml:"deploy"`          // Deploy all artifacts in package after configuration
+	When        string              `yaml:"when,omitempty"`  // Template condition, the package is skipped if it is false
+	Tags        []string            `yaml:"tags,omitempty"`  // Tags of all artifacts of the package, selected with --tags
+	Hooks       *ConfigureHooks     `yaml:"hooks,omitempty"` // Hooks executed for the package
+	Window      *MaintenanceWindow  `yaml:"maintenanceWindow,omitempty"`
+	MaxChanges  int                 `yaml:"maxChanges,omitempty"` // Maximum changed parameters of the package, 0 for no limit
 	Artifacts   []ConfigureArtifact `yaml:"artifacts"`
 }
 
@@ -31,13 +157,28 @@ func (p *ConfigurePackage) UnmarshalYAML(unmarshal func(interface{}) error) erro
 
 // ConfigureArtifact represents an artifact with its configuration parameters
 type ConfigureArtifact struct {
-	ID          string                   `yaml:"artifactId"`
-	DisplayName string                   `yaml:"displayName,omitempty"`
-	Type        string                   `yaml:"type"`                 // Integration, MessageMapping, ScriptCollection, ValueMapping
-	Version     string                   `yaml:"version,omitempty"`    // Artifact version, defaults to "active"
-	Deploy      bool                     `yaml:"deploy"`               // Deploy this specific artifact after configuration
-	Parameters  []ConfigurationParameter `yaml:"parameters,omitempty"` // List of configuration parameters to update
-	Batch       *BatchSettings           `yaml:"batch,omitempty"`      // Optional batch processing settings
+	ID             string                   `yaml:"artifactId"`
+	DisplayName    string                   `yaml:"displayName,omitempty"`
+	Type           string                   `yaml:"type"`                              // Integration, MessageMapping, ScriptCollection, ValueMapping or an alias
+	Version        string                   `yaml:"version,omitempty"`                 // Artifact version, defaults to "active"
+	Deploy         bool                     `yaml:"deploy"`                            // Deploy this specific artifact after configuration
+	DeployVersion  string                   `yaml:"deployVersion,omitempty"`           // Designtime version deployed instead of the active one, e.g. to roll back
+	When           string                   `yaml:"when,omitempty"`                    // Template condition, the artifact is skipped if it is false
+	Tags           []string                 `yaml:"tags,omitempty"`                    // Tags in addition to those of the package, selected with --tags
+	DeployAs       []DeployInstance         `yaml:"deployAs,omitempty"`                // Prefixed IDs the artifact is configured and deployed under instead of its own ID
+	Partners       *PartnerTable            `yaml:"partners,omitempty"`                // Partners the artifact is instantiated for, referenced as ${partner.<column>}
+	Parameters     []ConfigurationParameter `yaml:"parameters,omitempty"`              // List of configuration parameters to update
+	ParametersFrom []string                 `yaml:"parametersFrom,omitempty"`          // .properties or .env files with further parameters, inline parameters win
+	UseGroups      []string                 `yaml:"useGroups,omitempty"`               // Parameter groups with further parameters, inline parameters and parametersFrom win
+	Batch          *BatchSettings           `yaml:"batch,omitempty"`                   // Optional batch processing settings
+	Hooks          *ConfigureHooks          `yaml:"hooks,omitempty"`                   // Hooks executed for the artifact
+	Window         *MaintenanceWindow       `yaml:"maintenanceWindow,omitempty"`       // Overrides the window of the package
+	Strategy       string                   `yaml:"deployStrategy,omitempty"`          // inPlace (default), stopStart or blueGreen
+	Drain          *DrainCheck              `yaml:"drain,omitempty"`                   // Drain checks of the stopStart strategy
+	BlueGreen      *BlueGreenSettings       `yaml:"blueGreen,omitempty"`               // Settings of the blueGreen strategy
+	DraftHandling  string                   `yaml:"draftHandling,omitempty"`           // Overrides --draft-handling: error, deploy or versionFirst
+	CreateMissing  bool                     `yaml:"createMissingParameters,omitempty"` // Create parameters not found in the artifact instead of skipping them
+	SkipDefaults   bool                     `yaml:"skipTenantDefaults,omitempty"`      // Do not set the tenant defaults on the artifact
 }
 
 func (a *ConfigureArtifact) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -56,16 +197,105 @@ func (a *ConfigureArtifact) UnmarshalYAML(unmarshal func(interface{}) error) err
 	return nil
 }
 
-// ConfigurationParameter represents a single configuration parameter to update
+// DeployInstance is one of the prefixed IDs an artifact is configured and deployed under, e.g. for several clients
+type DeployInstance struct {
+	Prefix     string                   `yaml:"prefix"`               // Prefix of the artifact ID, applied after the deployment prefix
+	Parameters []ConfigurationParameter `yaml:"parameters,omitempty"` // Parameters of this instance, overriding those of the artifact with the same key
+}
+
+// PartnerTable lists the partners an artifact is instantiated for. The ID, name and parameter values of the artifact
+// reference the columns of a partner as ${partner.<column>}.
+type PartnerTable struct {
+	From string              `yaml:"from,omitempty"` // CSV file with a header row, or YAML or JSON file with a list of objects, relative to the configuration file
+	Rows []map[string]string `yaml:"rows,omitempty"` // Partners in addition to those of from
+}
+
+// ConfigurationParameter represents a single configuration parameter to update. YAML numbers, booleans
+// and multiline blocks are used as written.
 type ConfigurationParameter struct {
-	Key   string `yaml:"key"`
-	Value string `yaml:"value"`
+	Key       string           `yaml:"key"`
+	Value     string           `yaml:"value"`
+	ValueFrom *ValueFromSource `yaml:"valueFrom,omitempty"` // Optional external source of the value, resolved at apply time
+	FromFile  string           `yaml:"fromFile,omitempty"`  // File the value is read from, relative to the configuration file
+	Base64    bool             `yaml:"base64,omitempty"`    // Base64 encode the content of fromFile
+	Mode      string           `yaml:"mode,omitempty"`      // set (default), set-if-empty, append or delete
+	Separator string           `yaml:"separator,omitempty"` // Separator of list values for append, defaults to ","
+	Validate  string           `yaml:"validate,omitempty"`  // Validator of the value, e.g. url, hostport, number or regex:<pattern>
+	Line      int              `yaml:"-"`                   // Line in the configuration file, used in conflict reports
+	Source    *ValueSource     `yaml:"-"`                   // Where the value came from, recorded when the configuration is loaded
+	Optional  bool             `yaml:"-"`                   // Only set if the artifact has the parameter, e.g. a tenant default
+}
+
+func (c *ConfigurationParameter) UnmarshalYAML(node *yaml.Node) error {
+	type rawParameter ConfigurationParameter
+	var raw rawParameter
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*c = ConfigurationParameter(raw)
+	c.Line = node.Line
+	return nil
+}
+
+// ValueSource is where the value of a parameter came from, e.g. a line of a configuration file or a key of the
+// values files its template references
+type ValueSource struct {
+	File string `json:"file,omitempty"` // Configuration or parametersFrom file the parameter is set in
+	Line int    `json:"line,omitempty"` // Line in File, 0 if not known, e.g. for TOML files
+	Kind string `json:"kind"`           // config, fromFile, parametersFrom, group, tenantDefaults, values, env or destination
+	Ref  string `json:"ref,omitempty"`  // File of fromFile, parameter group, values keys, environment variables or destination
+}
+
+func (s ValueSource) String() string {
+	location := s.File
+	if s.Line > 0 {
+		location = fmt.Sprintf("%s:%d", s.File, s.Line)
+	}
+	if s.Kind == "config" {
+		return location
+	}
+	if s.Ref == "" {
+		return fmt.Sprintf("%s (%s)", location, s.Kind)
+	}
+	return fmt.Sprintf("%s (%s %s)", location, s.Kind, s.Ref)
+}
+
+// ValueFromSource references an external source for a parameter value
+type ValueFromSource struct {
+	Destination string `yaml:"destination,omitempty"` // BTP destination property in the format <name>#<property>
+}
+
+// ConfigureHooks lists local commands executed around the lifecycle phases
+type ConfigureHooks struct {
+	PreConfigure  []string `yaml:"preConfigure,omitempty"`
+	PostConfigure []string `yaml:"postConfigure,omitempty"`
+	PreDeploy     []string `yaml:"preDeploy,omitempty"`
+	PostDeploy    []string `yaml:"postDeploy,omitempty"`
+}
+
+// Commands returns the commands of a lifecycle phase
+func (h *ConfigureHooks) Commands(phase string) []string {
+	if h == nil {
+		return nil
+	}
+	switch phase {
+	case "preConfigure":
+		return h.PreConfigure
+	case "postConfigure":
+		return h.PostConfigure
+	case "preDeploy":
+		return h.PreDeploy
+	case "postDeploy":
+		return h.PostDeploy
+	}
+	return nil
 }
 
 // BatchSettings allows per-artifact batch configuration
 type BatchSettings struct {
-	Enabled   bool `yaml:"enabled"`             // Enable batch processing for this artifact
-	BatchSize int  `yaml:"batchSize,omitempty"` // Number of parameters per batch request
+	Enabled   bool   `yaml:"enabled"`             // Enable batch processing for this artifact
+	BatchSize int    `yaml:"batchSize,omitempty"` // Number of parameters per batch request
+	Fallback  string `yaml:"fallback,omitempty"`  // Overrides batchFallback of the configuration: none, failed-only or all
 }
 
 func (b *BatchSettings) UnmarshalYAML(unmarshal func(interface{}) error) error {
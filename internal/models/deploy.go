@@ -26,14 +26,15 @@ type DeployConfig struct {
 
 // Package represents a SAP CPI package
 type Package struct {
-	ID          string     `yaml:"integrationSuiteId"`
-	PackageDir  string     `yaml:"packageDir,omitempty"`
-	DisplayName string     `yaml:"displayName,omitempty"`
-	Description string     `yaml:"description,omitempty"`
-	ShortText   string     `yaml:"short_text,omitempty"`
-	Sync        bool       `yaml:"sync"`
-	Deploy      bool       `yaml:"deploy"`
-	Artifacts   []Artifact `yaml:"artifacts"`
+	ID          string             `yaml:"integrationSuiteId"`
+	PackageDir  string             `yaml:"packageDir,omitempty"`
+	DisplayName string             `yaml:"displayName,omitempty"`
+	Description string             `yaml:"description,omitempty"`
+	ShortText   string             `yaml:"short_text,omitempty"`
+	Sync        bool               `yaml:"sync"`
+	Deploy      bool               `yaml:"deploy"`
+	Window      *MaintenanceWindow `yaml:"maintenanceWindow,omitempty"`
+	Artifacts   []Artifact         `yaml:"artifacts"`
 }
 
 func (p *Package) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -60,7 +61,12 @@ type Artifact struct {
 	Type            string                 `yaml:"type"`
 	Sync            bool                   `yaml:"sync"`
 	Deploy          bool                   `yaml:"deploy"`
+	DeployVersion   string                 `yaml:"deployVersion,omitempty"` // Designtime version deployed instead of the active one, e.g. to roll back
 	ConfigOverrides map[string]interface{} `yaml:"configOverrides"`
+	Window          *MaintenanceWindow     `yaml:"maintenanceWindow,omitempty"` // Overrides the window of the package
+	Strategy        string                 `yaml:"deployStrategy,omitempty"`    // inPlace (default), stopStart or blueGreen
+	Drain           *DrainCheck            `yaml:"drain,omitempty"`             // Drain checks of the stopStart strategy
+	BlueGreen       *BlueGreenSettings     `yaml:"blueGreen,omitempty"`         // Settings of the blueGreen strategy
 }
 
 func (a *Artifact) UnmarshalYAML(unmarshal func(interface{}) error) error {
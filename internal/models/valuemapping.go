@@ -0,0 +1,23 @@
+package models
+
+// ValueMappingFile is the content of a value mapping managed as code
+type ValueMappingFile struct {
+	ID       string              `yaml:"valueMappingId"`
+	Version  string              `yaml:"version,omitempty"`
+	Mappings []ValueMappingGroup `yaml:"mappings"`
+}
+
+// ValueMappingGroup are the values mapped between a source and a target agency and identifier
+type ValueMappingGroup struct {
+	SourceAgency     string              `yaml:"sourceAgency"`
+	SourceIdentifier string              `yaml:"sourceIdentifier"`
+	TargetAgency     string              `yaml:"targetAgency"`
+	TargetIdentifier string              `yaml:"targetIdentifier"`
+	Values           []ValueMappingValue `yaml:"values"`
+}
+
+// ValueMappingValue maps a source value to a target value
+type ValueMappingValue struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
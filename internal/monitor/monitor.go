@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Counter counts the message processing logs of an integration flow with the status that ended after since
+type Counter interface {
+	Count(iflowID string, status string, since time.Time) (int, error)
+}
+
+// Alert is a rule that fired for an integration flow
+type Alert struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description,omitempty"`
+	Artifact    string `json:"artifact"`
+	Status      string `json:"status"`
+	Count       int    `json:"count"`
+	Condition   string `json:"condition"`
+	notify      []string
+}
+
+// Message returns the alert as text
+func (a Alert) Message() string {
+	message := fmt.Sprintf("%s: %d %s messages of %s, %s", a.Rule, a.Count, a.Status, a.Artifact, a.Condition)
+	if a.Description != "" {
+		message += " - " + a.Description
+	}
+	return message
+}
+
+// Evaluate counts the message processing logs of the deployed integration flows matching the rules and returns
+// the alerts of the rules that fired
+func Evaluate(rules []Rule, counter Counter, deployed []string, now time.Time) ([]Alert, error) {
+	var alerts []Alert
+	for _, rule := range rules {
+		var artifacts []string
+		for _, id := range deployed {
+			if matched, _ := path.Match(rule.Artifact, id); matched {
+				artifacts = append(artifacts, id)
+			}
+		}
+		// A rule for an integration flow that is not deployed is evaluated as well, e.g. to fire for missing messages
+		if len(artifacts) == 0 && !strings.ContainsAny(rule.Artifact, `*?[\`) {
+			artifacts = append(artifacts, rule.Artifact)
+		}
+		for _, id := range artifacts {
+			count, err := counter.Count(id, rule.Status, now.Add(-rule.window))
+			if err != nil {
+				return nil, fmt.Errorf("rule %s failed for %s: %w", rule.ID, id, err)
+			}
+			if (rule.Above != nil && count > *rule.Above) || (rule.Below != nil && count < *rule.Below) {
+				alerts = append(alerts, Alert{Rule: rule.ID, Description: rule.Description, Artifact: id, Status: rule.Status,
+					Count: count, Condition: rule.condition(), notify: rule.Notify})
+			}
+		}
+	}
+	return alerts, nil
+}
+
+// Notify sends the alerts to the notifications of their rules. All notifications are attempted, the failures are
+// returned together.
+func Notify(notifications []Notification, alerts []Alert, tenant string) error {
+	var failures []string
+	for _, n := range notifications {
+		var notified []Alert
+		for _, alert := range alerts {
+			if slices.Contains(alert.notify, n.Name) {
+				notified = append(notified, alert)
+			}
+		}
+		if len(notified) == 0 {
+			continue
+		}
+		if err := post(n, notified, tenant); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", n.Name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to send notifications: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func post(n Notification, alerts []Alert, tenant string) error {
+	var payload any
+	if n.Type == NotificationSlack {
+		lines := []string{fmt.Sprintf(":rotating_light: %d alert(s) on %s", len(alerts), tenant)}
+		for _, alert := range alerts {
+			lines = append(lines, "• "+alert.Message())
+		}
+		payload = map[string]string{"text": strings.Join(lines, "\n")}
+	} else {
+		payload = map[string]any{"tenant": tenant, "alerts": alerts}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(os.ExpandEnv(n.URL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		// Webhook URLs contain secrets, e.g. Slack tokens, so the URL is not part of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("response code = %d", resp.StatusCode)
+	}
+	return nil
+}
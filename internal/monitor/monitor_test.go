@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counts returns the number of messages by integration flow and status
+type counts map[string]int
+
+func (c counts) Count(iflowID string, status string, since time.Time) (int, error) {
+	return c[iflowID+"/"+status], nil
+}
+
+func writeRules(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "rules.yml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	return file
+}
+
+func TestMonitor(t *testing.T) {
+	var received []map[string]any
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		json.Unmarshal(body, &payload)
+		received = append(received, payload)
+	}))
+	defer svr.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", svr.URL+"/slack")
+
+	ruleSet, err := LoadRules(writeRules(t, `
+notifications:
+  - name: ops
+    type: slack
+    url: ${SLACK_WEBHOOK_URL}
+rules:
+  - id: order-failures
+    artifact: Orders_*
+    above: 5
+    window: 15m
+    notify: [ops]
+  - id: invoice-heartbeat
+    description: Invoices are replicated every 10 minutes
+    artifact: Invoices
+    status: COMPLETED
+    below: 1
+    window: 1h
+`))
+	require.NoError(t, err)
+
+	alerts, err := Evaluate(ruleSet.Rules, counts{"Orders_Create/FAILED": 6, "Orders_Cancel/FAILED": 5}, []string{"Orders_Create", "Orders_Cancel"}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, alerts, 2, "Invoices without messages should fire although it is not deployed")
+	assert.Equal(t, "order-failures: 6 FAILED messages of Orders_Create, more than 5 FAILED messages in 15m0s", alerts[0].Message())
+	assert.Equal(t, "Invoices", alerts[1].Artifact)
+
+	require.NoError(t, Notify(ruleSet.Notifications, alerts, "tenant"))
+	require.Len(t, received, 1, "Only alerts of rules notifying ops should be sent")
+	assert.Contains(t, received[0]["text"], "1 alert(s) on tenant")
+
+	ruleSet.Notifications[0].URL = svr.URL + "/missing"
+	svr.Config.Handler = http.NotFoundHandler()
+	assert.EqualError(t, Notify(ruleSet.Notifications, alerts, "tenant"), "failed to send notifications: ops: response code = 404")
+}
+
+func TestLoadRulesInvalid(t *testing.T) {
+	_, err := LoadRules(writeRules(t, "rules:\n  - id: r1\n    artifact: Orders\n    window: 15m\n"))
+	assert.ErrorContains(t, err, "rule r1 requires either above or below")
+	_, err = LoadRules(writeRules(t, "rules:\n  - id: r1\n    artifact: Orders\n    above: 0\n    window: 15\n"))
+	assert.ErrorContains(t, err, "rule r1 has invalid window")
+	_, err = LoadRules(writeRules(t, "rules:\n  - id: r1\n    artifact: Orders\n    above: 0\n    window: 15m\n    notify: [ops]\n"))
+	assert.ErrorContains(t, err, "rule r1 notifies unknown notification ops")
+}
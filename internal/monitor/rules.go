@@ -0,0 +1,126 @@
+// Package monitor evaluates alerting rules against the message processing logs of a tenant, e.g. more than five
+// failed messages of an integration flow in 15 minutes, and sends the alerts of fired rules to webhooks. Rule sets
+// are defined in YAML.
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Types of notifications
+const (
+	NotificationWebhook = "webhook" // Posts the alerts as JSON
+	NotificationSlack   = "slack"   // Posts the alerts as message to a Slack incoming webhook
+)
+
+// statuses are the statuses of message processing logs rules can count
+var statuses = []string{"COMPLETED", "FAILED", "RETRY", "ESCALATED", "PROCESSING", "CANCELLED", "DISCARDED", "ABANDONED"}
+
+// RuleSet is the content of a rules file
+type RuleSet struct {
+	Notifications []Notification `yaml:"notifications,omitempty"`
+	Rules         []Rule         `yaml:"rules"`
+}
+
+// Notification is a webhook the alerts of rules are sent to
+type Notification struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type,omitempty"` // webhook or slack, defaults to webhook
+	URL  string `yaml:"url"`            // Environment variables are expanded, e.g. ${SLACK_WEBHOOK_URL}
+}
+
+// Rule fires for each integration flow matching Artifact with more than Above, or fewer than Below, message
+// processing logs of the status in the window
+type Rule struct {
+	ID          string   `yaml:"id"`
+	Description string   `yaml:"description,omitempty"`
+	Artifact    string   `yaml:"artifact"`         // ID or pattern of the IDs of deployed integration flows, e.g. Orders_*
+	Status      string   `yaml:"status,omitempty"` // Defaults to FAILED
+	Above       *int     `yaml:"above,omitempty"`
+	Below       *int     `yaml:"below,omitempty"`
+	Window      string   `yaml:"window"` // e.g. 15m or 1h
+	Notify      []string `yaml:"notify,omitempty"`
+	window      time.Duration
+}
+
+// LoadRules reads and validates the rule set file
+func LoadRules(file string) (*RuleSet, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	ruleSet := new(RuleSet)
+	if err := yaml.Unmarshal(data, ruleSet); err != nil {
+		return nil, fmt.Errorf("failed to parse rules %s: %w", file, err)
+	}
+	if err := ruleSet.validate(); err != nil {
+		return nil, fmt.Errorf("invalid rules %s: %w", file, err)
+	}
+	return ruleSet, nil
+}
+
+func (s *RuleSet) validate() error {
+	var names []string
+	for i := range s.Notifications {
+		n := &s.Notifications[i]
+		if n.Name == "" || n.URL == "" {
+			return fmt.Errorf("notification %d requires name and url", i+1)
+		}
+		if n.Type == "" {
+			n.Type = NotificationWebhook
+		}
+		if n.Type != NotificationWebhook && n.Type != NotificationSlack {
+			return fmt.Errorf("notification %s has invalid type %s (valid types: %s, %s)", n.Name, n.Type, NotificationWebhook, NotificationSlack)
+		}
+		names = append(names, n.Name)
+	}
+	if len(s.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+	for i := range s.Rules {
+		rule := &s.Rules[i]
+		if rule.ID == "" {
+			return fmt.Errorf("rule %d requires id", i+1)
+		}
+		if rule.Artifact == "" {
+			return fmt.Errorf("rule %s requires artifact", rule.ID)
+		}
+		if _, err := path.Match(rule.Artifact, ""); err != nil {
+			return fmt.Errorf("rule %s has invalid artifact pattern %s: %w", rule.ID, rule.Artifact, err)
+		}
+		if rule.Status == "" {
+			rule.Status = "FAILED"
+		}
+		if !slices.Contains(statuses, rule.Status) {
+			return fmt.Errorf("rule %s has invalid status %s", rule.ID, rule.Status)
+		}
+		if (rule.Above == nil) == (rule.Below == nil) {
+			return fmt.Errorf("rule %s requires either above or below", rule.ID)
+		}
+		window, err := time.ParseDuration(rule.Window)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("rule %s has invalid window %q, e.g. 15m or 1h", rule.ID, rule.Window)
+		}
+		rule.window = window
+		for _, name := range rule.Notify {
+			if !slices.Contains(names, name) {
+				return fmt.Errorf("rule %s notifies unknown notification %s", rule.ID, name)
+			}
+		}
+	}
+	return nil
+}
+
+// condition returns the condition of the rule as text, e.g. more than 5 FAILED messages in 15m0s
+func (r *Rule) condition() string {
+	if r.Above != nil {
+		return fmt.Sprintf("more than %d %s messages in %v", *r.Above, r.Status, r.window)
+	}
+	return fmt.Sprintf("fewer than %d %s messages in %v", *r.Below, r.Status, r.window)
+}
@@ -0,0 +1,324 @@
+// Command gen generates the paths and request bodies of package odata from the OpenAPI specs in odata/spec.
+// It is run with go generate in internal/odata.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type spec struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components struct {
+		Parameters map[string]parameter `json:"parameters"`
+		Schemas    map[string]schema    `json:"schemas"`
+	} `json:"components"`
+}
+
+type pathItem struct {
+	GoName     string      `json:"x-go-name"`
+	Parameters []parameter `json:"parameters"`
+}
+
+type parameter struct {
+	Ref    string `json:"$ref"`
+	Name   string `json:"name"`
+	In     string `json:"in"`
+	Schema schema `json:"schema"`
+}
+
+type schema struct {
+	Type        string     `json:"type"`
+	Format      string     `json:"format"`
+	Description string     `json:"description"`
+	Enum        []string   `json:"enum"`
+	Required    []string   `json:"required"`
+	Properties  properties `json:"properties"`
+}
+
+// properties are the properties of a schema in the order of the spec, which is kept in the generated structs
+type properties struct {
+	names   []string
+	schemas map[string]schema
+}
+
+func (p *properties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &p.schemas); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		name, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		p.names = append(p.names, name.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathFunc is a generated function, or a constant if it has no parameters, returning the path of a resource
+type pathFunc struct {
+	Name     string
+	Template string
+	Params   []string // Go parameters, e.g. id string
+	Enums    []string // Documentation of enum parameters
+	Expr     string   // Go expression of the path
+}
+
+type bodyType struct {
+	Name   string
+	Schema string
+	Fields []string
+}
+
+var placeholderPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+func main() {
+	specDir := flag.String("spec", "spec", "directory of the OpenAPI specs")
+	out := flag.String("out", "api_gen.go", "generated file")
+	flag.Parse()
+
+	src, err := generate(*specDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the formatted source of the paths and bodies of all specs in specDir
+func generate(specDir string) ([]byte, error) {
+	files, err := filepath.Glob(filepath.Join(specDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var funcs []pathFunc
+	var bodies []bodyType
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var s spec
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if len(s.Servers) != 1 {
+			return nil, fmt.Errorf("%s: exactly one server expected", file)
+		}
+		for template, item := range s.Paths {
+			f, err := newPathFunc(s, s.Servers[0].URL, template, item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", file, template, err)
+			}
+			funcs = append(funcs, f)
+		}
+		for name, sc := range s.Components.Schemas {
+			bodies = append(bodies, newBodyType(name, sc))
+		}
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	sort.Slice(bodies, func(i, j int) bool { return bodies[i].Name < bodies[j].Name })
+	for i := 1; i < len(funcs); i++ {
+		if funcs[i].Name == funcs[i-1].Name {
+			return nil, fmt.Errorf("duplicate x-go-name %s", funcs[i].Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by odata/gen from the specs in odata/spec. DO NOT EDIT.\n\npackage odata\n\n")
+	if strings.Contains(strings.Join(exprs(funcs), ""), "strconv.") {
+		buf.WriteString("import \"strconv\"\n\n")
+	}
+	for _, f := range funcs {
+		if len(f.Params) == 0 {
+			fmt.Fprintf(&buf, "// %sPath is the path of %s\nconst %sPath = %s\n\n", f.Name, f.Template, f.Name, f.Expr)
+			continue
+		}
+		fmt.Fprintf(&buf, "// %sPath returns the path of %s", f.Name, f.Template)
+		for _, enum := range f.Enums {
+			fmt.Fprintf(&buf, "\n// %s", enum)
+		}
+		fmt.Fprintf(&buf, "\nfunc %sPath(%s) string {\n\treturn %s\n}\n\n", f.Name, strings.Join(f.Params, ", "), f.Expr)
+	}
+	for _, b := range bodies {
+		fmt.Fprintf(&buf, "// %s is the JSON body of the schema %s\ntype %s struct {\n%s\n}\n\n", b.Name, b.Schema, b.Name, strings.Join(b.Fields, "\n"))
+	}
+	return format.Source(buf.Bytes())
+}
+
+func exprs(funcs []pathFunc) []string {
+	var e []string
+	for _, f := range funcs {
+		e = append(e, f.Expr)
+	}
+	return e
+}
+
+func newPathFunc(s spec, server string, template string, item pathItem) (pathFunc, error) {
+	if item.GoName == "" {
+		return pathFunc{}, fmt.Errorf("x-go-name missing")
+	}
+	f := pathFunc{Name: item.GoName, Template: server + template}
+	params := map[string]parameter{}
+	var query []parameter
+	for _, p := range item.Parameters {
+		if p.Ref != "" {
+			ref, ok := s.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+			if !ok {
+				return pathFunc{}, fmt.Errorf("parameter %s not found", p.Ref)
+			}
+			p = ref
+		}
+		goType, err := goParamType(p.Schema)
+		if err != nil {
+			return pathFunc{}, fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		f.Params = append(f.Params, goParamName(p.Name)+" "+goType)
+		if len(p.Schema.Enum) > 0 {
+			f.Enums = append(f.Enums, fmt.Sprintf("%s is one of %s.", goParamName(p.Name), strings.Join(p.Schema.Enum, ", ")))
+		}
+		switch p.In {
+		case "path":
+			params[p.Name] = p
+		case "query":
+			if goType != "string" {
+				return pathFunc{}, fmt.Errorf("query parameter %s is not a string", p.Name)
+			}
+			query = append(query, p)
+		default:
+			return pathFunc{}, fmt.Errorf("parameter %s in %s not supported", p.Name, p.In)
+		}
+	}
+
+	var parts []string
+	literal := server
+	rest := template
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(template, -1) {
+		name := template[loc[2]:loc[3]]
+		p, ok := params[name]
+		if !ok {
+			return pathFunc{}, fmt.Errorf("path parameter %s not declared", name)
+		}
+		delete(params, name)
+		literal += rest[:loc[0]-(len(template)-len(rest))]
+		parts = append(parts, fmt.Sprintf("%q", literal), pathValue(p))
+		rest = template[loc[1]:]
+		literal = ""
+	}
+	literal += rest
+	for i, p := range query {
+		separator := "&"
+		if i == 0 {
+			separator = "?"
+		}
+		literal += separator + p.Name + "="
+		parts = append(parts, fmt.Sprintf("%q", literal), "Parameter("+goParamName(p.Name)+")")
+		literal = ""
+	}
+	if literal != "" {
+		parts = append(parts, fmt.Sprintf("%q", literal))
+	}
+	for name := range params {
+		return pathFunc{}, fmt.Errorf("path parameter %s not in path", name)
+	}
+	f.Expr = strings.Join(parts, " + ")
+	return f, nil
+}
+
+// pathValue returns the Go expression of the value of a path parameter. Strings are escaped as key values,
+// except for enums that are written as is.
+func pathValue(p parameter) string {
+	name := goParamName(p.Name)
+	switch {
+	case p.Schema.Type == "integer" && p.Schema.Format == "int64":
+		return "strconv.FormatInt(" + name + ", 10)"
+	case p.Schema.Type == "integer":
+		return "strconv.Itoa(" + name + ")"
+	case len(p.Schema.Enum) > 0:
+		return name
+	default:
+		return "Key(" + name + ")"
+	}
+}
+
+func goParamType(s schema) (string, error) {
+	switch {
+	case s.Type == "string":
+		return "string", nil
+	case s.Type == "integer" && s.Format == "int64":
+		return "int64", nil
+	case s.Type == "integer":
+		return "int", nil
+	default:
+		return "", fmt.Errorf("type %s not supported", s.Type)
+	}
+}
+
+// goParamName returns the name of a Go parameter, e.g. messageGUID for MessageGuid
+func goParamName(name string) string {
+	name = goName(name)
+	if name == "ID" {
+		return "id"
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// goName returns the exported Go name of a property or schema, e.g. SrcID for SrcId and StringParameterCreate
+// for StringParameter-create
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	name = b.String()
+	switch {
+	case strings.HasSuffix(name, "Id"):
+		name = strings.TrimSuffix(name, "Id") + "ID"
+	case strings.HasSuffix(name, "Guid"):
+		name = strings.TrimSuffix(name, "Guid") + "GUID"
+	}
+	return name
+}
+
+// newBodyType returns the struct of a schema. Properties that are not required are omitted when empty.
+func newBodyType(name string, s schema) bodyType {
+	b := bodyType{Name: goName(name), Schema: name}
+	for _, property := range s.Properties.names {
+		tag := property
+		if !slices.Contains(s.Required, property) {
+			tag += ",omitempty"
+		}
+		field := fmt.Sprintf("%s string `json:%q`", goName(property), tag)
+		if description := s.Properties.schemas[property].Description; description != "" {
+			field += " // " + description
+		}
+		b.Fields = append(b.Fields, field)
+	}
+	return b
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedCodeUpToDate(t *testing.T) {
+	src, err := generate("../spec")
+	require.NoError(t, err)
+	generated, err := os.ReadFile("../api_gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(src), string(generated), "api_gen.go is outdated, run go generate in internal/odata")
+}
+
+func TestGoName(t *testing.T) {
+	assert.Equal(t, "StringParameterCreate", goName("StringParameter-create"))
+	assert.Equal(t, "SrcID", goName("SrcId"))
+	assert.Equal(t, "messageGUID", goParamName("MessageGuid"))
+	assert.Equal(t, "id", goParamName("Id"))
+}
@@ -0,0 +1,22 @@
+// Package odata contains the paths and request bodies of the OData APIs of SAP Integration Suite. They are
+// generated from the OpenAPI specs in spec, so that the values in paths are escaped consistently. New endpoints
+// are added to a spec and generated with go generate.
+package odata
+
+import (
+	"net/url"
+	"strings"
+)
+
+//go:generate go run ./gen -spec spec -out api_gen.go
+
+// Key escapes a string value of a key predicate in a path, e.g. Receiver%20Host for Receiver Host. Single
+// quotes are doubled as required by OData, the enclosing quotes are part of the path.
+func Key(value string) string {
+	return url.PathEscape(strings.ReplaceAll(value, "'", "''"))
+}
+
+// Parameter quotes and escapes a string value of a function import parameter, e.g. 'Receiver%20Host'
+func Parameter(value string) string {
+	return "'" + strings.ReplaceAll(url.QueryEscape(strings.ReplaceAll(value, "'", "''")), "+", "%20") + "'"
+}
@@ -0,0 +1,18 @@
+package odata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaths(t *testing.T) {
+	assert.Equal(t, "/api/v1/IntegrationDesigntimeArtifacts(Id='Orders',Version='active')/$links/Configurations('Receiver%20Host')",
+		IntegrationDesigntimeArtifactConfigurationLinkPath("Orders", "active", "Receiver Host"))
+	assert.Equal(t, "/api/v1/StringParameters(Pid='SAP%2FOrders',Id='O%27%27Brien')", StringParameterPath("SAP/Orders", "O'Brien"))
+	assert.Equal(t, "/api/v1/DeployValueMappingDesigntimeArtifact?Id='Map'&Version='2.0.0'", DeployDesigntimeArtifactPath("ValueMapping", "Map", "2.0.0"))
+	assert.Equal(t, "/api/v1/IntegrationDesigntimeArtifactSaveAsVersion?Id='Orders%26Invoices'&SaveAsVersion='1.0.1'",
+		IntegrationDesigntimeArtifactSaveAsVersionPath("Orders&Invoices", "1.0.1"))
+	assert.Equal(t, "/api/v1/MessageProcessingLogRunSteps(RunId='run1',ChildCount=3)/TraceMessages", MessageProcessingLogRunStepTraceMessagesPath("run1", 3))
+	assert.Equal(t, "/api/v1/TraceMessages(42L)", TraceMessagePath("42"))
+}
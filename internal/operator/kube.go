@@ -0,0 +1,138 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// API group, version and resource of the CPIConfiguration custom resource
+const (
+	Group    = "flashpipe.io"
+	Version  = "v1alpha1"
+	Resource = "cpiconfigurations"
+)
+
+// serviceAccountDir holds the token, CA certificate and namespace of the service account of the pod
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubeClient reads CPIConfiguration resources and updates their status with the Kubernetes API
+type KubeClient struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+// NewKubeClient returns a client of the Kubernetes API at apiURL, e.g. http://127.0.0.1:8001 of kubectl proxy.
+// Without apiURL, the API server and the service account of the pod are used.
+func NewKubeClient(apiURL string) (*KubeClient, error) {
+	c := new(KubeClient)
+	c.httpClient = &http.Client{Timeout: time.Minute}
+	if apiURL != "" {
+		c.url = strings.TrimSuffix(apiURL, "/")
+		return c, nil
+	}
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid service account CA certificate")
+	}
+	c.url = "https://" + net.JoinHostPort(host, port)
+	c.token = strings.TrimSpace(string(token))
+	c.httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}
+	return c, nil
+}
+
+// InClusterNamespace returns the namespace of the pod, empty outside of a cluster
+func InClusterNamespace() string {
+	data, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// List returns the CPIConfiguration resources of namespace, of all namespaces if namespace is empty
+func (c *KubeClient) List(ctx context.Context, namespace string) ([]CPIConfiguration, error) {
+	path := fmt.Sprintf("/apis/%s/%s/%s", Group, Version, Resource)
+	if namespace != "" {
+		path = fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", Group, Version, namespace, Resource)
+	}
+	var list struct {
+		Items []CPIConfiguration `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, "", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// UpdateStatus replaces the status of a resource with a merge patch of the status subresource
+func (c *KubeClient) UpdateStatus(ctx context.Context, resource *CPIConfiguration) error {
+	path := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s/%s/status", Group, Version, resource.Metadata.Namespace, Resource, resource.Metadata.Name)
+	body, err := json.Marshal(map[string]interface{}{"status": resource.Status})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPatch, path, "application/merge-patch+json", body, nil)
+}
+
+func (c *KubeClient) do(ctx context.Context, method string, path string, contentType string, body []byte, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	if resp.StatusCode >= 300 {
+		// Errors of the API server are Status objects with a message
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &status) == nil && status.Message != "" {
+			return fmt.Errorf("%s %s failed: %s: %s", method, path, resp.Status, status.Message)
+		}
+		return fmt.Errorf("%s %s failed: %s", method, path, resp.Status)
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to read response of %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
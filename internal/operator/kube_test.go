@@ -0,0 +1,78 @@
+package operator
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubeClientMock(t *testing.T) {
+	var patch string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apis/flashpipe.io/v1alpha1/namespaces/cpi/cpiconfigurations", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sa-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"items": [{"metadata": {"name": "sales", "namespace": "cpi", "generation": 2},
+			"spec": {"packages": []}, "status": {"observedGeneration": 1}}]}`))
+	})
+	mux.HandleFunc("/apis/flashpipe.io/v1alpha1/namespaces/cpi/cpiconfigurations/sales/status", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		patch = string(body)
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/apis/flashpipe.io/v1alpha1/cpiconfigurations", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"kind": "Status", "message": "cpiconfigurations.flashpipe.io is forbidden"}`))
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	// Service account of the pod
+	serviceAccountDir = t.TempDir()
+	t.Cleanup(func() { serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount" })
+	require.NoError(t, os.WriteFile(filepath.Join(serviceAccountDir, "token"), []byte("sa-token\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(serviceAccountDir, "namespace"), []byte("cpi"), 0o600))
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(serviceAccountDir, "ca.crt"), ca, 0o600))
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+
+	assert.Equal(t, "cpi", InClusterNamespace())
+	client, err := NewKubeClient("")
+	require.NoError(t, err)
+
+	resources, err := client.List(context.Background(), "cpi")
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	assert.Equal(t, int64(2), resources[0].Metadata.Generation)
+	assert.JSONEq(t, `{"packages": []}`, string(resources[0].Spec))
+
+	resources[0].Status.ObservedGeneration = 2
+	require.NoError(t, client.UpdateStatus(context.Background(), &resources[0]))
+	assert.JSONEq(t, `{"status": {"observedGeneration": 2, "driftedParameters": 0, "drift": null, "parametersUpdated": 0, "artifactsDeployed": 0}}`, patch)
+
+	_, err = client.List(context.Background(), "")
+	assert.EqualError(t, err, "GET /apis/flashpipe.io/v1alpha1/cpiconfigurations failed: 403 Forbidden: cpiconfigurations.flashpipe.io is forbidden")
+}
+
+func TestNewKubeClientOutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, err := NewKubeClient("")
+	assert.ErrorContains(t, err, "not running in a Kubernetes cluster")
+
+	client, err := NewKubeClient("http://127.0.0.1:8001/")
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8001", client.url)
+}
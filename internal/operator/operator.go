@@ -0,0 +1,369 @@
+// Package operator reconciles CPIConfiguration custom resources against a tenant. Each resource embeds the
+// configuration of configure in its spec. The parameter values on the tenant are compared with the spec
+// continuously, drift is corrected in the apply mode and reported in the status conditions of the resource.
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Modes of a CPIConfiguration
+const (
+	ModeApply  = "apply"  // Apply the spec and correct drift
+	ModeDetect = "detect" // Only report drift
+)
+
+// Condition types and reasons of the status
+const (
+	ConditionReady   = "Ready"
+	ConditionDrifted = "Drifted"
+
+	ReasonApplied       = "Applied"
+	ReasonInSync        = "InSync"
+	ReasonDriftDetected = "DriftDetected"
+	ReasonApplyFailed   = "ApplyFailed"
+	ReasonTenantError   = "TenantError"
+	ReasonInvalidSpec   = "InvalidSpec"
+	ReasonSuspended     = "Suspended"
+)
+
+// notInArtifact marks drifted parameters that do not exist in the artifact
+const notInArtifact = " (not in artifact)"
+
+// maxDriftEntries limits the drifted parameters listed in the status
+const maxDriftEntries = 20
+
+// CPIConfiguration is the custom resource reconciled by the operator
+type CPIConfiguration struct {
+	APIVersion string          `json:"apiVersion,omitempty"`
+	Kind       string          `json:"kind,omitempty"`
+	Metadata   ObjectMeta      `json:"metadata"`
+	Spec       json.RawMessage `json:"spec"` // Decoded as YAML into Spec, as the models only have YAML tags
+	Status     Status          `json:"status"`
+}
+
+// ObjectMeta are the metadata of a resource used by the operator
+type ObjectMeta struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Generation int64  `json:"generation"`
+}
+
+// Spec is the configuration of configure with the settings of the reconciliation
+type Spec struct {
+	models.ConfigureConfig `yaml:",inline"`
+	Mode                   string `yaml:"mode,omitempty"`            // apply (default) or detect
+	IntervalSeconds        int    `yaml:"intervalSeconds,omitempty"` // Time between drift checks, defaults to the interval of the operator
+	Suspend                bool   `yaml:"suspend,omitempty"`         // Stop reconciling the resource
+}
+
+// Status is the outcome of the last reconciliation
+type Status struct {
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"` // Generation of the spec last applied or checked
+	LastReconcileTime  string      `json:"lastReconcileTime,omitempty"`
+	LastAppliedTime    string      `json:"lastAppliedTime,omitempty"` // Last time parameters were updated or artifacts deployed
+	DriftedParameters  int         `json:"driftedParameters"`
+	Drift              []string    `json:"drift"` // Drifted parameters as <artifact>/<key>, the first 20, null clears them with a merge patch
+	ParametersUpdated  int         `json:"parametersUpdated"`
+	ArtifactsDeployed  int         `json:"artifactsDeployed"`
+	Conditions         []Condition `json:"conditions,omitempty"`
+}
+
+// Condition is a status condition in the format of Kubernetes
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"` // True, False or Unknown
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	LastTransitionTime string `json:"lastTransitionTime"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+}
+
+// Resources lists the custom resources and updates their status. It is implemented by KubeClient.
+type Resources interface {
+	List(ctx context.Context, namespace string) ([]CPIConfiguration, error)
+	UpdateStatus(ctx context.Context, resource *CPIConfiguration) error
+}
+
+// Reconciler reconciles the CPIConfiguration resources of a namespace against a tenant
+type Reconciler struct {
+	Tenant       flashpipe.Tenant
+	Resources    Resources
+	Namespace    string        // Namespace of the resources, all namespaces if empty
+	Interval     time.Duration // Time between the checks of the resources
+	ApplyOptions flashpipe.ApplyOptions
+	now          func() time.Time
+}
+
+// NewReconciler returns a Reconciler of the resources against tenant
+func NewReconciler(tenant flashpipe.Tenant, resources Resources, namespace string, interval time.Duration, opts flashpipe.ApplyOptions) *Reconciler {
+	r := new(Reconciler)
+	r.Tenant = tenant
+	r.Resources = resources
+	r.Namespace = namespace
+	r.Interval = interval
+	r.ApplyOptions = opts
+	r.now = time.Now
+	return r
+}
+
+// Run reconciles the resources every interval until ctx is done
+func (r *Reconciler) Run(ctx context.Context) error {
+	for {
+		if err := r.ReconcileAll(ctx); err != nil {
+			log.Error().Msgf("Failed to reconcile: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.Interval):
+		}
+	}
+}
+
+// ReconcileAll reconciles the resources that changed or whose interval passed since their last reconciliation
+func (r *Reconciler) ReconcileAll(ctx context.Context) error {
+	resources, err := r.Resources.List(ctx, r.Namespace)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range resources {
+		resource := &resources[i]
+		if !r.due(resource) {
+			continue
+		}
+		r.Reconcile(ctx, resource)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := r.Resources.UpdateStatus(ctx, resource); err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", resource.Metadata.Namespace, resource.Metadata.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// due returns true if the spec changed or the interval of the resource passed since its last reconciliation
+func (r *Reconciler) due(resource *CPIConfiguration) bool {
+	if resource.Metadata.Generation != resource.Status.ObservedGeneration {
+		return true
+	}
+	last, err := time.Parse(time.RFC3339, resource.Status.LastReconcileTime)
+	if err != nil {
+		return true
+	}
+	interval := r.Interval
+	var spec Spec
+	if yaml.Unmarshal(resource.Spec, &spec) == nil && spec.IntervalSeconds > 0 {
+		interval = time.Duration(spec.IntervalSeconds) * time.Second
+	}
+	return !r.now().Before(last.Add(interval))
+}
+
+// Reconcile compares the tenant with the spec of the resource, applies the spec in the apply mode and updates
+// the status of the resource
+func (r *Reconciler) Reconcile(ctx context.Context, resource *CPIConfiguration) {
+	name := resource.Metadata.Namespace + "/" + resource.Metadata.Name
+	generation := resource.Metadata.Generation
+	status := &resource.Status
+	status.LastReconcileTime = r.now().UTC().Format(time.RFC3339)
+
+	spec, err := decodeSpec(resource.Spec)
+	if err != nil {
+		log.Error().Msgf("%s: invalid spec: %v", name, err)
+		status.ObservedGeneration = generation
+		r.setCondition(status, ConditionReady, "False", ReasonInvalidSpec, err.Error(), generation)
+		return
+	}
+	if spec.Suspend {
+		status.ObservedGeneration = generation
+		r.setCondition(status, ConditionReady, "False", ReasonSuspended, "Reconciliation is suspended", generation)
+		return
+	}
+
+	drift, driftedArtifacts, err := detectDrift(ctx, r.Tenant, &spec.ConfigureConfig)
+	if err != nil {
+		log.Error().Msgf("%s: failed to read the tenant: %v", name, err)
+		r.setCondition(status, ConditionReady, "False", ReasonTenantError, err.Error(), generation)
+		return
+	}
+	status.DriftedParameters = len(drift)
+	status.Drift = drift[:min(len(drift), maxDriftEntries)]
+	status.ParametersUpdated = 0
+	status.ArtifactsDeployed = 0
+	if len(drift) > 0 {
+		log.Warn().Msgf("%s: %d parameter(s) drifted", name, len(drift))
+		r.setCondition(status, ConditionDrifted, "True", ReasonDriftDetected,
+			fmt.Sprintf("%d parameter(s) differ from the spec", len(drift)), generation)
+	} else {
+		r.setCondition(status, ConditionDrifted, "False", ReasonInSync, "All parameters match the spec", generation)
+	}
+
+	changed := generation != status.ObservedGeneration
+	if spec.Mode == ModeDetect || (len(drift) == 0 && !changed) {
+		status.ObservedGeneration = generation
+		if len(drift) > 0 {
+			r.setCondition(status, ConditionReady, "False", ReasonDriftDetected, "Drift is not corrected in the detect mode", generation)
+		} else {
+			r.setCondition(status, ConditionReady, "True", ReasonInSync, "Tenant matches the spec", generation)
+		}
+		return
+	}
+
+	// Only drifted parameters are updated. A changed spec is applied to all artifacts, so that its deployments
+	// are made, drift only to the drifted artifacts.
+	opts := r.ApplyOptions
+	if !changed {
+		opts.ArtifactFilter = driftedArtifacts
+	}
+	log.Info().Msgf("%s: applying generation %d", name, generation)
+	stats, err := flashpipe.Apply(ctx, r.Tenant, driftedParameters(&spec.ConfigureConfig, drift), opts)
+	if stats != nil {
+		status.ParametersUpdated = stats.ParametersUpdated.Value()
+		status.ArtifactsDeployed = stats.ArtifactsDeployed.Value()
+		if stats.ParametersUpdated.Value() > 0 || stats.ArtifactsDeployed.Value() > 0 {
+			status.LastAppliedTime = r.now().UTC().Format(time.RFC3339)
+		}
+	}
+	if err != nil {
+		log.Error().Msgf("%s: failed to apply: %v", name, err)
+		r.setCondition(status, ConditionReady, "False", ReasonApplyFailed, err.Error(), generation)
+		return
+	}
+	status.ObservedGeneration = generation
+	if len(drift) > 0 {
+		r.setCondition(status, ConditionDrifted, "False", ReasonApplied,
+			fmt.Sprintf("Corrected %d drifted parameter(s)", len(drift)), generation)
+	}
+	r.setCondition(status, ConditionReady, "True", ReasonApplied,
+		fmt.Sprintf("Updated %d parameter(s), deployed %d artifact(s)", status.ParametersUpdated, status.ArtifactsDeployed), generation)
+}
+
+// decodeSpec decodes and validates the spec. Features that need local files or commands are not supported.
+func decodeSpec(raw json.RawMessage) (*Spec, error) {
+	spec := new(Spec)
+	// JSON is YAML, so that the YAML tags of the models apply
+	if err := yaml.Unmarshal(raw, spec); err != nil {
+		return nil, err
+	}
+	if spec.Mode != "" && spec.Mode != ModeApply && spec.Mode != ModeDetect {
+		return nil, fmt.Errorf("invalid mode %q (valid modes: %s, %s)", spec.Mode, ModeApply, ModeDetect)
+	}
+	cfg := &spec.ConfigureConfig
+	var errs []error
+	// Parameters of groups are checked like the parameters of the artifacts
+	if err := flashpipe.ResolveParameterGroups([]*flashpipe.ConfigFile{{Config: cfg, Source: "spec"}}); err != nil {
+		errs = append(errs, err)
+	}
+	if len(cfg.Targets) > 0 || cfg.Rollout != nil {
+		errs = append(errs, fmt.Errorf("targets and rollout are not supported, the tenant is set by the operator"))
+	}
+	if cfg.Hooks != nil {
+		errs = append(errs, fmt.Errorf("hooks are not supported"))
+	}
+	if flashpipe.HasConditions(cfg) {
+		errs = append(errs, fmt.Errorf("when conditions are not supported, each resource applies to the tenant of the operator"))
+	}
+	for _, pkg := range cfg.Packages {
+		if pkg.Hooks != nil {
+			errs = append(errs, fmt.Errorf("package %s: hooks are not supported", pkg.ID))
+		}
+		for _, artifact := range pkg.Artifacts {
+			if artifact.Hooks != nil || len(artifact.ParametersFrom) > 0 {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: hooks and parametersFrom are not supported", pkg.ID, artifact.ID))
+			}
+			for _, param := range artifact.Parameters {
+				if param.ValueFrom != nil || param.FromFile != "" || param.Mode == flashpipe.ParameterModeDelete {
+					errs = append(errs, fmt.Errorf("package %s, artifact %s, parameter %s: valueFrom, fromFile and mode delete are not supported", pkg.ID, artifact.ID, param.Key))
+				}
+			}
+		}
+	}
+	errs = append(errs, flashpipe.Validate(cfg)...)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return spec, flashpipe.ResolveArtifactTypes(cfg)
+}
+
+// detectDrift returns the parameters whose value on the tenant does not comply with the spec as
+// <artifact>/<key>, and the IDs of their artifacts without deployment prefix
+func detectDrift(ctx context.Context, tenant flashpipe.Tenant, cfg *flashpipe.ConfigureConfig) (drift []string, artifacts []string, err error) {
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			if len(artifact.Parameters) == 0 {
+				continue
+			}
+			artifactID := cfg.DeploymentPrefix + artifact.ID
+			version := artifact.Version
+			if version == "" {
+				version = "active"
+			}
+			current, err := tenant.Parameters(ctx, artifactID, version)
+			if err != nil {
+				return nil, nil, fmt.Errorf("artifact %s: %w", artifactID, err)
+			}
+			for _, param := range artifact.Parameters {
+				value, exists := current[param.Key]
+				if exists && flashpipe.ParameterSatisfied(param, value) {
+					continue
+				}
+				entry := artifactID + "/" + param.Key
+				if !exists {
+					entry += notInArtifact
+				}
+				drift = append(drift, entry)
+				if !slices.Contains(artifacts, artifact.ID) {
+					artifacts = append(artifacts, artifact.ID)
+				}
+			}
+		}
+	}
+	return drift, artifacts, nil
+}
+
+// driftedParameters returns a copy of cfg with only the parameters in drift
+func driftedParameters(cfg *flashpipe.ConfigureConfig, drift []string) *flashpipe.ConfigureConfig {
+	pruned := *cfg
+	pruned.Packages = slices.Clone(cfg.Packages)
+	for pi := range pruned.Packages {
+		pkg := &pruned.Packages[pi]
+		pkg.Artifacts = slices.Clone(pkg.Artifacts)
+		for ai := range pkg.Artifacts {
+			artifact := &pkg.Artifacts[ai]
+			prefix := cfg.DeploymentPrefix + artifact.ID + "/"
+			artifact.Parameters = slices.DeleteFunc(slices.Clone(artifact.Parameters), func(p flashpipe.ConfigurationParameter) bool {
+				return !slices.Contains(drift, prefix+p.Key) && !slices.Contains(drift, prefix+p.Key+notInArtifact)
+			})
+		}
+	}
+	return &pruned
+}
+
+// setCondition sets a condition of the status. The transition time only changes with the status.
+func (r *Reconciler) setCondition(status *Status, conditionType string, conditionStatus string, reason string, message string, generation int64) {
+	condition := Condition{Type: conditionType, Status: conditionStatus, Reason: reason,
+		Message: strings.TrimSpace(message), LastTransitionTime: r.now().UTC().Format(time.RFC3339), ObservedGeneration: generation}
+	for i, existing := range status.Conditions {
+		if existing.Type == conditionType {
+			if existing.Status == conditionStatus {
+				condition.LastTransitionTime = existing.LastTransitionTime
+			}
+			status.Conditions[i] = condition
+			return
+		}
+	}
+	status.Conditions = append(status.Conditions, condition)
+}
@@ -0,0 +1,167 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTenant struct {
+	parameters map[string]map[string]string
+	deployed   []string
+	updates    int
+}
+
+func (m *mockTenant) Parameters(_ context.Context, artifactID string, _ string) (map[string]string, error) {
+	return m.parameters[artifactID], nil
+}
+
+func (m *mockTenant) UpdateParameters(_ context.Context, artifactID string, _ string, parameters map[string]string) error {
+	for k, v := range parameters {
+		m.parameters[artifactID][k] = v
+		m.updates++
+	}
+	return nil
+}
+
+func (m *mockTenant) Deploy(_ context.Context, _ string, artifactID string) error {
+	m.deployed = append(m.deployed, artifactID)
+	return nil
+}
+
+func (m *mockTenant) Status(_ context.Context, _ string) (string, string, error) {
+	return "1.0.0", "STARTED", nil
+}
+
+type mockResources struct {
+	items   []CPIConfiguration
+	updated []CPIConfiguration
+}
+
+func (m *mockResources) List(_ context.Context, _ string) ([]CPIConfiguration, error) {
+	return m.items, nil
+}
+
+func (m *mockResources) UpdateStatus(_ context.Context, resource *CPIConfiguration) error {
+	m.updated = append(m.updated, *resource)
+	for i := range m.items {
+		if m.items[i].Metadata.Name == resource.Metadata.Name {
+			m.items[i].Status = resource.Status
+		}
+	}
+	return nil
+}
+
+const specJSON = `{"deploymentPrefix": "DEV_", "packages": [{"integrationSuiteId": "Sales", "artifacts": [
+	{"artifactId": "Orders", "type": "iflow", "deploy": true, "parameters": [{"key": "Host", "value": "erp.example.com"}, {"key": "Port", "value": 443}]},
+	{"artifactId": "Invoices", "type": "Integration", "parameters": [{"key": "Host", "value": "erp.example.com"}]}]}]}`
+
+func condition(status Status, conditionType string) Condition {
+	for _, c := range status.Conditions {
+		if c.Type == conditionType {
+			return c
+		}
+	}
+	return Condition{}
+}
+
+func TestReconcile(t *testing.T) {
+	tenant := &mockTenant{parameters: map[string]map[string]string{
+		"DEV_Orders":   {"Host": "old.example.com", "Port": "443"},
+		"DEV_Invoices": {"Host": "erp.example.com"},
+	}}
+	resources := &mockResources{items: []CPIConfiguration{{Metadata: ObjectMeta{Name: "sales", Namespace: "cpi", Generation: 1}, Spec: []byte(specJSON)}}}
+	r := NewReconciler(tenant, resources, "cpi", time.Minute, flashpipe.ApplyOptions{DeployDelay: time.Millisecond})
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	// New generation: drift is detected and the spec is applied completely
+	require.NoError(t, r.ReconcileAll(context.Background()))
+	require.Len(t, resources.updated, 1)
+	status := resources.updated[0].Status
+	assert.Equal(t, int64(1), status.ObservedGeneration)
+	assert.Equal(t, 1, status.DriftedParameters)
+	assert.Equal(t, []string{"DEV_Orders/Host"}, status.Drift)
+	assert.Equal(t, 1, status.ParametersUpdated)
+	assert.Equal(t, 1, status.ArtifactsDeployed)
+	assert.Equal(t, "2026-05-01T08:00:00Z", status.LastAppliedTime)
+	assert.Equal(t, "True", condition(status, ConditionReady).Status)
+	assert.Equal(t, ReasonApplied, condition(status, ConditionDrifted).Reason)
+	assert.Equal(t, "erp.example.com", tenant.parameters["DEV_Orders"]["Host"])
+	assert.Equal(t, []string{"DEV_Orders"}, tenant.deployed)
+
+	// Not due before the interval passed
+	now = now.Add(30 * time.Second)
+	require.NoError(t, r.ReconcileAll(context.Background()))
+	assert.Len(t, resources.updated, 1)
+
+	// In sync: nothing is applied or deployed
+	now = now.Add(time.Minute)
+	require.NoError(t, r.ReconcileAll(context.Background()))
+	require.Len(t, resources.updated, 2)
+	status = resources.updated[1].Status
+	assert.Equal(t, ReasonInSync, condition(status, ConditionReady).Reason)
+	assert.Equal(t, "False", condition(status, ConditionDrifted).Status)
+	assert.Nil(t, status.Drift)
+	assert.Equal(t, "2026-05-01T08:00:00Z", condition(status, ConditionReady).LastTransitionTime, "Status did not change")
+	assert.Equal(t, []string{"DEV_Orders"}, tenant.deployed)
+
+	// Drift of an unchanged spec: only the drifted artifact is applied, without deploying the others
+	tenant.parameters["DEV_Invoices"]["Host"] = "changed.example.com"
+	now = now.Add(time.Minute)
+	require.NoError(t, r.ReconcileAll(context.Background()))
+	status = resources.updated[2].Status
+	assert.Equal(t, []string{"DEV_Invoices/Host"}, status.Drift)
+	assert.Equal(t, 1, status.ParametersUpdated)
+	assert.Equal(t, 0, status.ArtifactsDeployed)
+	assert.Equal(t, "erp.example.com", tenant.parameters["DEV_Invoices"]["Host"])
+	assert.Equal(t, []string{"DEV_Orders"}, tenant.deployed)
+}
+
+func TestReconcileDetectMode(t *testing.T) {
+	tenant := &mockTenant{parameters: map[string]map[string]string{
+		"Orders": {"Host": "old.example.com"},
+	}}
+	resource := &CPIConfiguration{Metadata: ObjectMeta{Name: "sales", Namespace: "cpi", Generation: 3}, Spec: []byte(`
+mode: detect
+packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        parameters:
+          - key: Host
+            value: erp.example.com
+          - key: Missing
+            value: x
+`)}
+	r := NewReconciler(tenant, &mockResources{}, "", time.Minute, flashpipe.ApplyOptions{})
+	r.Reconcile(context.Background(), resource)
+
+	assert.Equal(t, []string{"Orders/Host", "Orders/Missing (not in artifact)"}, resource.Status.Drift)
+	assert.Equal(t, ReasonDriftDetected, condition(resource.Status, ConditionReady).Reason)
+	assert.Equal(t, "True", condition(resource.Status, ConditionDrifted).Status)
+	assert.Equal(t, int64(3), resource.Status.ObservedGeneration)
+	assert.Equal(t, 0, tenant.updates, "Nothing is applied in the detect mode")
+}
+
+func TestReconcileInvalidSpec(t *testing.T) {
+	r := NewReconciler(&mockTenant{}, &mockResources{}, "", time.Minute, flashpipe.ApplyOptions{})
+	for spec, message := range map[string]string{
+		`{"mode": "sync", "packages": []}`:                          `invalid mode "sync"`,
+		`{"hooks": {"preConfigure": ["rm -rf /"]}, "packages": []}`: "hooks are not supported",
+		`{"packages": [{"integrationSuiteId": "Sales", "artifacts": [{"artifactId": "Orders", "type": "Integration", "parameters": [{"key": "Cert", "fromFile": "cert.pem"}]}]}]}`: "fromFile",
+		`{"packages": [{"integrationSuiteId": "Sales", "artifacts": [{"artifactId": "Orders", "type": "Pipeline"}]}]}`:                                                             `invalid type "Pipeline"`,
+	} {
+		resource := &CPIConfiguration{Metadata: ObjectMeta{Name: "sales", Generation: 2}, Spec: []byte(spec)}
+		r.Reconcile(context.Background(), resource)
+		ready := condition(resource.Status, ConditionReady)
+		assert.Equal(t, ReasonInvalidSpec, ready.Reason, spec)
+		assert.Contains(t, ready.Message, message)
+		assert.Equal(t, int64(2), resource.Status.ObservedGeneration, "Invalid specs are not retried until they change")
+	}
+}
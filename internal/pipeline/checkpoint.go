@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Checkpoint records the IDs of completed tasks in a file, one per line, so that a run started again after it
+// was interrupted or failed skips the tasks already completed
+type Checkpoint struct {
+	path      string
+	mu        sync.Mutex
+	completed map[string]bool
+}
+
+// LoadCheckpoint returns the checkpoint of the file, with the tasks completed in the earlier run if the file exists
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, completed: map[string]bool{}}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %v: %w", path, err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			c.completed[id] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %v: %w", path, err)
+	}
+	return c, nil
+}
+
+// Done returns whether the task was completed
+func (c *Checkpoint) Done(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.completed[id]
+}
+
+// Len returns the number of tasks completed
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.completed)
+}
+
+// Mark records the task as completed. The file is appended to, so that it is up to date if the run is killed.
+func (c *Checkpoint) Mark(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.completed[id] {
+		return nil
+	}
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint %v: %w", c.path, err)
+	}
+	if _, err = fmt.Fprintln(f, id); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write checkpoint %v: %w", c.path, err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint %v: %w", c.path, err)
+	}
+	c.completed[id] = true
+	return nil
+}
+
+// Remove deletes the file once the run completed, so that the next run starts from the beginning
+func (c *Checkpoint) Remove() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed = map[string]bool{}
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint %v: %w", c.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package pipeline runs the steps of a command as tasks with dependencies on a pool of workers, so that the
+// commands share one way of running steps concurrently, retrying them, reporting their progress and resuming
+// an interrupted run.
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrCanceled is the error of tasks not started as an earlier task failed with Options.StopOnError
+var ErrCanceled = errors.New("canceled as an earlier task failed")
+
+// Task is a step of a run
+type Task struct {
+	ID        string
+	Group     string   // Tasks of the same group are limited by Options.GroupParallel, e.g. the artifacts of a package
+	DependsOn []string // IDs of the tasks that must succeed before this task runs
+	Finally   bool     // Runs once its dependencies are done, also if they failed, e.g. to clean up
+	Run       func() error
+}
+
+// Result is the outcome of a task
+type Result struct {
+	ID       string
+	Err      error
+	Duration time.Duration
+	Attempts int
+	Blocked  bool // Not run as a dependency failed, Err is the error of the dependency
+	Resumed  bool // Not run as it was completed in the run recorded in the checkpoint
+}
+
+// Options configure how the tasks are run
+type Options struct {
+	Parallel      int              // Tasks running at a time, 0 for no limit
+	GroupParallel int              // Tasks of the same group running at a time, 0 for no limit
+	Retries       int              // Further attempts of a failed task
+	RetryDelay    time.Duration    // Wait before each further attempt
+	Retryable     func(error) bool // Errors that are retried, all if nil
+	StopOnError   bool             // No further tasks are started once a task failed, they fail with ErrCanceled
+	Checkpoint    *Checkpoint      // Tasks completed in an earlier run are skipped, completed tasks are recorded
+	OnStart       func(Task)       // Called before a task is started
+	OnDone        func(Result)     // Called after a task is done, one at a time in the order the tasks finish
+}
+
+type state int
+
+const (
+	pending state = iota
+	running
+	done
+)
+
+type finished struct {
+	index  int
+	result Result
+}
+
+// Run runs the tasks and returns their results in the order of the tasks. Tasks are started in their order as
+// soon as their dependencies succeeded and the limits of Options allow. A panic of a task is recovered and
+// returned as its error. An error is only returned if the dependencies are invalid, then no task is run.
+func Run(tasks []Task, opts Options) ([]Result, error) {
+	index, err := validate(tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(tasks))
+	states := make([]state, len(tasks))
+	finishedCh := make(chan finished)
+	inFlight := 0
+	groupInFlight := map[string]int{}
+	failed := false
+	remaining := len(tasks)
+
+	finish := func(i int, r Result) {
+		results[i] = r
+		states[i] = done
+		remaining--
+		if r.Err != nil {
+			failed = true
+		}
+		if opts.OnDone != nil {
+			opts.OnDone(r)
+		}
+	}
+
+	for remaining > 0 {
+		// Finishing a task without running it can make later tasks ready, so repeat until nothing changes
+		for changed := true; changed; {
+			changed = false
+			for i, task := range tasks {
+				if states[i] != pending {
+					continue
+				}
+				ready, depErr := dependencies(task, index, states, results)
+				if !ready {
+					continue
+				}
+				switch {
+				case opts.Checkpoint != nil && opts.Checkpoint.Done(task.ID):
+					finish(i, Result{ID: task.ID, Resumed: true})
+				case depErr != nil && !task.Finally:
+					finish(i, Result{ID: task.ID, Err: depErr, Blocked: true})
+				case failed && opts.StopOnError && !task.Finally:
+					finish(i, Result{ID: task.ID, Err: ErrCanceled})
+				case (opts.Parallel <= 0 || inFlight < opts.Parallel) &&
+					(task.Group == "" || opts.GroupParallel <= 0 || groupInFlight[task.Group] < opts.GroupParallel):
+					states[i] = running
+					inFlight++
+					groupInFlight[task.Group]++
+					if opts.OnStart != nil {
+						opts.OnStart(task)
+					}
+					go func(i int, task Task) {
+						finishedCh <- finished{i, execute(task, opts)}
+					}(i, task)
+				default:
+					continue
+				}
+				changed = true
+			}
+		}
+		if remaining == 0 {
+			break
+		}
+		f := <-finishedCh
+		inFlight--
+		groupInFlight[tasks[f.index].Group]--
+		if f.result.Err == nil && opts.Checkpoint != nil {
+			if err := opts.Checkpoint.Mark(f.result.ID); err != nil {
+				f.result.Err = err
+			}
+		}
+		finish(f.index, f.result)
+	}
+	return results, nil
+}
+
+// Failed returns the results of the tasks that failed, including those blocked or canceled
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// FirstError returns the error of the first task that failed itself, nil if all succeeded
+func FirstError(results []Result) error {
+	var first error
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		if !r.Blocked && !errors.Is(r.Err, ErrCanceled) {
+			return r.Err
+		}
+		if first == nil {
+			first = r.Err
+		}
+	}
+	return first
+}
+
+// validate checks that the IDs are unique and the dependencies exist without cycles, and returns the index of
+// each ID
+func validate(tasks []Task) (map[string]int, error) {
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		if task.Run == nil {
+			return nil, fmt.Errorf("task %v has nothing to run", task.ID)
+		}
+		if _, exists := index[task.ID]; exists {
+			return nil, fmt.Errorf("duplicate task %v", task.ID)
+		}
+		index[task.ID] = i
+	}
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if _, exists := index[dep]; !exists {
+				return nil, fmt.Errorf("task %v depends on unknown task %v", task.ID, dep)
+			}
+		}
+	}
+
+	// Visit the tasks depth first, a task reached again while it is visited is part of a cycle
+	visiting := make([]bool, len(tasks))
+	visited := make([]bool, len(tasks))
+	var visit func(i int) error
+	visit = func(i int) error {
+		if visited[i] {
+			return nil
+		}
+		if visiting[i] {
+			return fmt.Errorf("task %v depends on itself", tasks[i].ID)
+		}
+		visiting[i] = true
+		for _, dep := range tasks[i].DependsOn {
+			if err := visit(index[dep]); err != nil {
+				return err
+			}
+		}
+		visiting[i] = false
+		visited[i] = true
+		return nil
+	}
+	for i := range tasks {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// dependencies returns whether all dependencies of the task are done, and the error of the first that failed
+func dependencies(task Task, index map[string]int, states []state, results []Result) (bool, error) {
+	var depErr error
+	for _, dep := range task.DependsOn {
+		i := index[dep]
+		if states[i] != done {
+			return false, nil
+		}
+		if depErr == nil && results[i].Err != nil {
+			depErr = results[i].Err
+		}
+	}
+	return true, depErr
+}
+
+// execute runs the task with the retries of the options
+func execute(task Task, opts Options) Result {
+	start := time.Now()
+	r := Result{ID: task.ID}
+	for {
+		r.Attempts++
+		r.Err = runSafely(task)
+		if r.Err == nil || r.Attempts > opts.Retries || (opts.Retryable != nil && !opts.Retryable(r.Err)) {
+			break
+		}
+		time.Sleep(opts.RetryDelay)
+	}
+	r.Duration = time.Since(start)
+	return r
+}
+
+// runSafely runs the task and returns a panic as error, so that one task cannot bring down the whole run
+func runSafely(task Task) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("task %v panicked: %v\n%s", task.ID, p, debug.Stack())
+		}
+	}()
+	return task.Run()
+}
@@ -0,0 +1,162 @@
+package pipeline
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noop() error { return nil }
+
+func TestRunDependencies(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(id string) func() error {
+		return func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, id)
+			return nil
+		}
+	}
+	results, err := Run([]Task{
+		{ID: "deploy", DependsOn: []string{"configure"}, Run: record("deploy")},
+		{ID: "configure", DependsOn: []string{"prefetch"}, Run: record("configure")},
+		{ID: "prefetch", Run: record("prefetch")},
+	}, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prefetch", "configure", "deploy"}, order)
+	assert.Equal(t, "deploy", results[0].ID)
+	assert.Equal(t, 1, results[0].Attempts)
+	assert.Nil(t, FirstError(results))
+}
+
+func TestRunParallelLimits(t *testing.T) {
+	var running, maxRunning, groupRunning, maxGroupRunning atomic.Int32
+	task := func(group string) func() error {
+		return func() error {
+			n := running.Add(1)
+			for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() {
+			}
+			if group == "a" {
+				g := groupRunning.Add(1)
+				for m := maxGroupRunning.Load(); g > m && !maxGroupRunning.CompareAndSwap(m, g); m = maxGroupRunning.Load() {
+				}
+				defer groupRunning.Add(-1)
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		}
+	}
+	var tasks []Task
+	for _, id := range []string{"a1", "a2", "a3", "a4", "b1", "b2"} {
+		tasks = append(tasks, Task{ID: id, Group: id[:1], Run: task(id[:1])})
+	}
+	_, err := Run(tasks, Options{Parallel: 3, GroupParallel: 2})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+	assert.Equal(t, int32(2), maxGroupRunning.Load())
+}
+
+func TestRunFailureBlocksDependents(t *testing.T) {
+	failure := errors.New("hook failed")
+	var finallyRan bool
+	var done []string
+	results, err := Run([]Task{
+		{ID: "pre", Run: func() error { return failure }},
+		{ID: "artifact", DependsOn: []string{"pre"}, Run: noop},
+		{ID: "post", DependsOn: []string{"pre", "artifact"}, Finally: true, Run: func() error { finallyRan = true; return nil }},
+	}, Options{OnDone: func(r Result) { done = append(done, r.ID) }})
+	require.NoError(t, err)
+	assert.Equal(t, failure, results[0].Err)
+	assert.True(t, results[1].Blocked)
+	assert.Equal(t, failure, results[1].Err)
+	assert.True(t, finallyRan)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, []string{"pre", "artifact", "post"}, done)
+	assert.Len(t, Failed(results), 2)
+	assert.Equal(t, failure, FirstError(results))
+}
+
+func TestRunStopOnError(t *testing.T) {
+	var ran []string
+	results, err := Run([]Task{
+		{ID: "1", Run: func() error { ran = append(ran, "1"); return errors.New("failed") }},
+		{ID: "2", Run: func() error { ran = append(ran, "2"); return nil }},
+	}, Options{Parallel: 1, StopOnError: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ran)
+	assert.ErrorIs(t, results[1].Err, ErrCanceled)
+	assert.EqualError(t, FirstError(results), "failed")
+}
+
+func TestRunRetries(t *testing.T) {
+	transient := errors.New("transient")
+	attempts := 0
+	results, err := Run([]Task{{ID: "deploy", Run: func() error {
+		attempts++
+		if attempts < 3 {
+			return transient
+		}
+		return nil
+	}}}, Options{Retries: 2, Retryable: func(err error) bool { return errors.Is(err, transient) }})
+	require.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 3, results[0].Attempts)
+
+	results, err = Run([]Task{{ID: "deploy", Run: func() error { return errors.New("permanent") }}},
+		Options{Retries: 2, Retryable: func(err error) bool { return errors.Is(err, transient) }})
+	require.NoError(t, err)
+	assert.Equal(t, 1, results[0].Attempts)
+}
+
+func TestRunRecoversPanic(t *testing.T) {
+	results, err := Run([]Task{{ID: "deploy", Run: func() error { panic("boom") }}}, Options{})
+	require.NoError(t, err)
+	assert.ErrorContains(t, results[0].Err, "task deploy panicked: boom")
+}
+
+func TestRunInvalid(t *testing.T) {
+	_, err := Run([]Task{{ID: "a", Run: noop}, {ID: "a", Run: noop}}, Options{})
+	assert.EqualError(t, err, "duplicate task a")
+	_, err = Run([]Task{{ID: "a", DependsOn: []string{"b"}, Run: noop}}, Options{})
+	assert.EqualError(t, err, "task a depends on unknown task b")
+	_, err = Run([]Task{{ID: "a", DependsOn: []string{"b"}, Run: noop}, {ID: "b", DependsOn: []string{"a"}, Run: noop}}, Options{})
+	assert.EqualError(t, err, "task a depends on itself")
+}
+
+func TestRunCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	checkpoint, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+	results, err := Run([]Task{
+		{ID: "1", Run: noop},
+		{ID: "2", Run: func() error { return errors.New("failed") }},
+	}, Options{Checkpoint: checkpoint})
+	require.NoError(t, err)
+	assert.Error(t, results[1].Err)
+
+	// The run started again only runs the task that failed
+	checkpoint, err = LoadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, checkpoint.Len())
+	var ran []string
+	results, err = Run([]Task{
+		{ID: "1", Run: func() error { ran = append(ran, "1"); return nil }},
+		{ID: "2", Run: func() error { ran = append(ran, "2"); return nil }},
+	}, Options{Checkpoint: checkpoint})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, ran)
+	assert.True(t, results[0].Resumed)
+
+	require.NoError(t, checkpoint.Remove())
+	assert.NoFileExists(t, path)
+	assert.False(t, checkpoint.Done("1"))
+}
@@ -0,0 +1,364 @@
+// Package remote fetches configuration files from Git repositories, HTTP(S) URLs and S3 buckets into a
+// temporary directory, so that they can be loaded like local files, and verifies their checksum or signature.
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/signature"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/rs/zerolog/log"
+)
+
+// TokenEnv is the environment variable with a token sent to HTTP(S) sources as bearer token and to Git
+// repositories over HTTPS as password
+const TokenEnv = "FLASHPIPE_CONFIG_TOKEN"
+
+// Options verify the fetched configuration. The checksum requires a single file, signatures are checked for
+// each configuration file of a folder.
+type Options struct {
+	Checksum  string // Expected SHA-256 checksum as hex, optionally prefixed with sha256:
+	PublicKey string // Public key file the signature is verified with, see signature.ParsePublicKey for the formats
+	Signature string // Location of the signature of a single file, defaults to the location of the file with .sig or .minisig appended
+	Recursive bool   // Check the signatures of the configuration files of subfolders as well
+}
+
+func (o Options) verify() bool {
+	return o.Checksum != "" || o.PublicKey != ""
+}
+
+// IsRemote returns true if location is a Git, HTTP(S) or S3 location instead of a local path
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "git::") || strings.HasPrefix(location, "http://") ||
+		strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "s3://")
+}
+
+// Fetch returns a local path of the configuration file or folder at location. Remote locations are
+// downloaded to a temporary directory that is removed by cleanup. Local paths are returned unchanged.
+//
+// Supported locations:
+//
+//	https://host/path/config.yml
+//	s3://bucket/key/config.yml
+//	git::https://host/org/repo.git//configs/prod?ref=v1.2.0
+//	git::ssh://git@host/org/repo.git//configs/prod.yml
+func Fetch(location string, opts Options) (localPath string, cleanup func(), err error) {
+	cleanup = func() {}
+	if !IsRemote(location) {
+		if opts.verify() {
+			err = verifyFile(location, location, opts)
+		}
+		return location, cleanup, err
+	}
+
+	dir, err := os.MkdirTemp("", "flashpipe-config-")
+	if err != nil {
+		return "", cleanup, err
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	log.Info().Msgf("Fetching %s", redact(location))
+	if strings.HasPrefix(location, "git::") {
+		localPath, err = fetchGit(strings.TrimPrefix(location, "git::"), dir)
+	} else {
+		localPath = filepath.Join(dir, fileName(location))
+		err = Download(location, localPath)
+	}
+	if err != nil {
+		return "", cleanup, err
+	}
+	if opts.verify() {
+		if err = verifyFile(location, localPath, opts); err != nil {
+			return "", cleanup, err
+		}
+	}
+	return localPath, cleanup, nil
+}
+
+// Download writes the content of an HTTP(S) or S3 location to target
+func Download(location string, target string) error {
+	var data []byte
+	var err error
+	if strings.HasPrefix(location, "s3://") {
+		data, err = getS3Object(location)
+	} else {
+		data, err = getHTTP(location)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0o600)
+}
+
+func getHTTP(location string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(TokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return do(req, redact(location))
+}
+
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+func do(req *http.Request, name string) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", name, resp.Status)
+	}
+	return data, nil
+}
+
+// fileName returns the name of the file of an HTTP(S) or S3 location, config.yml if it has no extension of
+// a configuration file
+func fileName(location string) string {
+	name := location
+	if u, err := url.Parse(location); err == nil {
+		name = u.Path
+	}
+	if name = path.Base(name); flashpipe.IsConfigFile(name) {
+		return name
+	}
+	return "config.yml"
+}
+
+var commitHash = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// fetchGit clones the repository of a Git location <url>//<path>?ref=<ref> into dir and returns the path of
+// the file or folder within. Branches and tags are cloned shallowly, commits require the full history.
+func fetchGit(location string, dir string) (string, error) {
+	repoURL, subPath, ref := splitGitLocation(location)
+	opts := &git.CloneOptions{URL: repoURL, Depth: 1, SingleBranch: true}
+	if token := os.Getenv(TokenEnv); token != "" && strings.HasPrefix(repoURL, "https://") {
+		username := "git"
+		if u, err := url.Parse(repoURL); err == nil && u.User != nil {
+			username = u.User.Username()
+		}
+		opts.Auth = &githttp.BasicAuth{Username: username, Password: token}
+	}
+
+	var repo *git.Repository
+	var err error
+	switch {
+	case ref == "":
+		repo, err = git.PlainClone(dir, false, opts)
+	case commitHash.MatchString(ref):
+		opts.Depth = 0
+		opts.SingleBranch = false
+		if repo, err = git.PlainClone(dir, false, opts); err == nil {
+			err = checkoutCommit(repo, ref)
+		}
+	default:
+		opts.ReferenceName = plumbing.NewBranchReferenceName(ref)
+		repo, err = git.PlainClone(dir, false, opts)
+		if err != nil {
+			_ = os.RemoveAll(filepath.Join(dir, ".git"))
+			opts.ReferenceName = plumbing.NewTagReferenceName(ref)
+			repo, err = git.PlainClone(dir, false, opts)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w", redact(repoURL), err)
+	}
+	if head, err := repo.Head(); err == nil {
+		log.Info().Msgf("Cloned %s at %s", redact(repoURL), head.Hash())
+	}
+
+	localPath := filepath.Join(dir, filepath.FromSlash(subPath))
+	if !strings.HasPrefix(localPath, filepath.Clean(dir)) {
+		return "", fmt.Errorf("path %s is outside of the repository", subPath)
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		return "", fmt.Errorf("path %s not found in %s", subPath, redact(repoURL))
+	}
+	return localPath, nil
+}
+
+func checkoutCommit(repo *git.Repository, ref string) error {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return fmt.Errorf("commit %s: %w", ref, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(&git.CheckoutOptions{Hash: *hash})
+}
+
+// splitGitLocation splits <url>//<path>?ref=<ref> into its parts. The path separator // is the first one
+// after the :// of the URL scheme.
+func splitGitLocation(location string) (repoURL string, subPath string, ref string) {
+	if i := strings.LastIndex(location, "?ref="); i >= 0 {
+		location, ref = location[:i], location[i+len("?ref="):]
+	}
+	start := 0
+	if i := strings.Index(location, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	repoURL = location
+	if i := strings.Index(location[start:], "//"); i >= 0 {
+		repoURL, subPath = location[:start+i], location[start+i+len("//"):]
+	}
+	return repoURL, subPath, ref
+}
+
+// verifyFile checks the checksum and signature of the file fetched from location to localPath. In folders,
+// the signature of each configuration file is checked.
+func verifyFile(location string, localPath string, opts Options) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	var publicKey *signature.PublicKey
+	if opts.PublicKey != "" {
+		data, err := os.ReadFile(opts.PublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+		if publicKey, err = signature.ParsePublicKey(data); err != nil {
+			return fmt.Errorf("public key %s: %w", opts.PublicKey, err)
+		}
+	}
+
+	if info.IsDir() {
+		if opts.Checksum != "" || opts.Signature != "" {
+			return fmt.Errorf("checksum and signature location require a single configuration file, %s is a folder", redact(location))
+		}
+		// Order files are configuration files as well, so that the selection and order of files is signed
+		return filepath.WalkDir(localPath, func(file string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if file != localPath && (!opts.Recursive || strings.HasPrefix(entry.Name(), ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !flashpipe.IsConfigFile(entry.Name()) {
+				return nil
+			}
+			return verifySignature(file, file, file+publicKey.SignatureExtension(), publicKey)
+		})
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	if opts.Checksum != "" {
+		expected := strings.ToLower(strings.TrimPrefix(opts.Checksum, "sha256:"))
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); actual != expected {
+			return fmt.Errorf("checksum mismatch for %s: expected sha256:%s, got sha256:%s", redact(location), expected, actual)
+		}
+		log.Info().Msgf("Checksum of %s verified", redact(location))
+	}
+	if publicKey != nil {
+		signatureLocation := opts.Signature
+		if signatureLocation == "" {
+			signatureLocation = location + publicKey.SignatureExtension()
+			if strings.HasPrefix(location, "git::") {
+				signatureLocation = localPath + publicKey.SignatureExtension()
+			}
+		}
+		return verifySignature(location, localPath, signatureLocation, publicKey)
+	}
+	return nil
+}
+
+// ReferencedFileVerifier returns the function that checks the files referenced by a configuration verified with
+// opts, e.g. with fromFile or parametersFrom, before they are read, nil if opts verify nothing. Each file must be
+// signed next to it like the configuration files of a folder. As a checksum only covers the configuration file,
+// files cannot be referenced without a public key.
+func ReferencedFileVerifier(opts Options) (func(path string) error, error) {
+	if !opts.verify() {
+		return nil, nil
+	}
+	if opts.PublicKey == "" {
+		return func(path string) error {
+			return fmt.Errorf("%s cannot be verified with the checksum of the configuration, sign it and verify with a public key", path)
+		}, nil
+	}
+	data, err := os.ReadFile(opts.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	publicKey, err := signature.ParsePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("public key %s: %w", opts.PublicKey, err)
+	}
+	return func(path string) error {
+		return verifySignature(path, path, path+publicKey.SignatureExtension(), publicKey)
+	}, nil
+}
+
+func verifySignature(location string, localPath string, signatureLocation string, publicKey *signature.PublicKey) error {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	sig, err := readSignature(signatureLocation)
+	if err != nil {
+		return fmt.Errorf("%s is not signed: %w", redact(location), err)
+	}
+	if err := publicKey.Verify(data, sig); err != nil {
+		return fmt.Errorf("signature of %s: %w", redact(location), err)
+	}
+	log.Info().Msgf("%s signature of %s verified", publicKey.Format(), redact(location))
+	return nil
+}
+
+// readSignature reads a signature from a local file or remote location
+func readSignature(location string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		return getS3Object(location)
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return getHTTP(location)
+	default:
+		return os.ReadFile(location)
+	}
+}
+
+// redact removes the password of a URL, so that locations can be logged
+func redact(location string) string {
+	u, err := url.Parse(strings.TrimPrefix(location, "git::"))
+	if err != nil || u.User == nil {
+		return location
+	}
+	if _, ok := u.User.Password(); !ok {
+		return location
+	}
+	return strings.Replace(location, u.User.String()+"@", u.User.Username()+":xxxxx@", 1)
+}
@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// CommitMessages returns the messages of the commits that changed files in dir since the last commit that
+// changed stopFile, newest first. dir and stopFile are relative to the root of the repository and use
+// forward slashes.
+func CommitMessages(gitRepoDir string, dir string, stopFile string) ([]string, error) {
+	repo, err := git.PlainOpen(gitRepoDir)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	commits, err := repo.Log(&git.LogOptions{From: head.Hash(), PathFilter: func(path string) bool {
+		return strings.HasPrefix(path, prefix)
+	}})
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+
+	var messages []string
+	err = commits.ForEach(func(c *object.Commit) error {
+		changed, err := fileChanged(c, stopFile)
+		if err != nil {
+			return err
+		}
+		if changed {
+			return storer.ErrStop
+		}
+		messages = append(messages, strings.TrimSpace(c.Message))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// fileChanged returns true if the file differs from the first parent of the commit
+func fileChanged(c *object.Commit, path string) (bool, error) {
+	hash, err := fileHash(c, path)
+	if err != nil {
+		return false, err
+	}
+	if c.NumParents() == 0 {
+		return !hash.IsZero(), nil
+	}
+	parent, err := c.Parent(0)
+	if err != nil {
+		return false, err
+	}
+	parentHash, err := fileHash(parent, path)
+	if err != nil {
+		return false, err
+	}
+	return hash != parentHash, nil
+}
+
+// fileHash returns the hash of the file in the commit, or the zero hash if it does not exist
+func fileHash(c *object.Commit, path string) (plumbing.Hash, error) {
+	f, err := c.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return f.Hash, nil
+}
@@ -0,0 +1,137 @@
+// Package requestbundle writes and reads request bundles: the API calls a command intends to make, serialized into
+// a tar file without executing them, so that they can be reviewed and executed later from a host with access to
+// the tenant.
+package requestbundle
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// Version is the version of the bundle format written by Write
+const Version = 1
+
+const manifestName = "manifest.json"
+
+// Manifest describes the requests of a bundle in the order they are executed
+type Manifest struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Command   string     `json:"command"`
+	Tenant    string     `json:"tenant,omitempty"` // Host of the tenant the requests are intended for, if known
+	Requests  []*Request `json:"requests"`
+}
+
+// Request is an API call of a bundle. The body is stored in its own file of the bundle.
+type Request struct {
+	Description  string `json:"description"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	ContentType  string `json:"contentType,omitempty"`
+	BodyFile     string `json:"bodyFile,omitempty"`
+	BodySHA256   string `json:"bodySha256,omitempty"`
+	SuccessCodes []int  `json:"successCodes"`
+	Body         []byte `json:"-"`
+}
+
+// Write writes the manifest and the bodies of its requests as tar to w
+func Write(w io.Writer, manifest *Manifest) error {
+	manifest.Version = Version
+	tw := tar.NewWriter(w)
+	for i, request := range manifest.Requests {
+		request.BodyFile, request.BodySHA256 = "", ""
+		if len(request.Body) == 0 {
+			continue
+		}
+		request.BodyFile = fmt.Sprintf("bodies/%04d%s", i+1, bodyExtension(request.ContentType))
+		sum := sha256.Sum256(request.Body)
+		request.BodySHA256 = hex.EncodeToString(sum[:])
+		if err := writeFile(tw, request.BodyFile, request.Body, manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(tw, manifestName, data, manifest.CreatedAt); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Read reads a bundle written by Write and verifies the checksums of the bodies
+func Read(r io.Reader) (*Manifest, error) {
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid request bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request bundle: %w", err)
+		}
+		files[path.Clean(header.Name)] = data
+	}
+
+	data, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("invalid request bundle: %s not found", manifestName)
+	}
+	manifest := new(Manifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid request bundle: %s: %w", manifestName, err)
+	}
+	if manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported request bundle version %d", manifest.Version)
+	}
+	for i, request := range manifest.Requests {
+		if request.BodyFile == "" {
+			continue
+		}
+		body, ok := files[path.Clean(request.BodyFile)]
+		if !ok {
+			return nil, fmt.Errorf("invalid request bundle: body %s of request %d not found", request.BodyFile, i+1)
+		}
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != request.BodySHA256 {
+			return nil, fmt.Errorf("invalid request bundle: body %s of request %d does not match its checksum", request.BodyFile, i+1)
+		}
+		request.Body = body
+	}
+	return manifest, nil
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func bodyExtension(contentType string) string {
+	switch contentType {
+	case "application/json":
+		return ".json"
+	case "application/xml":
+		return ".xml"
+	default:
+		return ".bin"
+	}
+}
@@ -0,0 +1,55 @@
+package requestbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	manifest := &Manifest{
+		CreatedAt: time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC),
+		Command:   "flashpipe configure package-requests",
+		Tenant:    "tenant.hana.ondemand.com",
+		Requests: []*Request{
+			{Description: "Update", Method: "PUT", Path: "/api/v1/x", ContentType: "application/json", Body: []byte(`{"ParameterValue":"a"}`), SuccessCodes: []int{202}},
+			{Description: "Deploy", Method: "POST", Path: "/api/v1/y", SuccessCodes: []int{200, 202}},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, manifest))
+
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, manifest, read)
+	assert.Equal(t, "bodies/0001.json", read.Requests[0].BodyFile)
+	assert.Empty(t, read.Requests[1].BodyFile)
+
+	// Replace the body of the first request
+	var tampered bytes.Buffer
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	tw := tar.NewWriter(&tampered)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		if header.Name == "bodies/0001.json" {
+			data = []byte(`{"ParameterValue":"b"}`)
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	_, err = Read(&tampered)
+	assert.EqualError(t, err, "invalid request bundle: body bodies/0001.json of request 1 does not match its checksum")
+}
@@ -0,0 +1,50 @@
+// Package runid identifies a run of flashpipe, so that its log lines, reports and the requests it sends to the
+// tenant, e.g. in the audit log of the tenant, can be correlated during incident investigations.
+package runid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Header is the header carrying the run ID on every request to the tenant
+const Header = "X-Flashpipe-Run-Id"
+
+// valid restricts run IDs to characters that are safe in headers, file names and log lines
+var valid = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+var (
+	mu sync.Mutex
+	id string
+)
+
+// New returns a new run ID made of the UTC start time and random hex digits, e.g. 20261016T101500Z-3f9a2c1b
+func New() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// Set sets the ID of the run, a new one if runID is empty, e.g. to use the ID of the CI/CD pipeline run
+func Set(runID string) error {
+	if runID == "" {
+		runID = New()
+	} else if !valid.MatchString(runID) {
+		return fmt.Errorf("invalid run ID %q, expected up to 128 letters, digits, '.', '_', ':' or '-'", runID)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	id = runID
+	return nil
+}
+
+// ID returns the ID of the run, empty if not set
+func ID() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return id
+}
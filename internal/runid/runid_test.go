@@ -0,0 +1,20 @@
+package runid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	defer Set("")
+	require.NoError(t, Set("gitlab-4711.2"))
+	assert.Equal(t, "gitlab-4711.2", ID())
+
+	require.NoError(t, Set(""))
+	assert.Regexp(t, `^\d{8}T\d{6}Z-[0-9a-f]{8}$`, ID())
+	assert.NotEqual(t, New(), New())
+
+	assert.EqualError(t, Set("run 1\r\nX-Injected: 1"), `invalid run ID "run 1\r\nX-Injected: 1", expected up to 128 letters, digits, '.', '_', ':' or '-'`)
+}
@@ -0,0 +1,174 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed standard 5-field cron expression (minute hour day-of-month month day-of-week)
+type Cron struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// Day-of-month and day-of-week are OR-ed when both are restricted, as in Vixie cron
+	domStar bool
+	dowStar bool
+}
+
+type fieldBounds struct {
+	name string
+	min  int
+	max  int
+}
+
+var (
+	minuteBounds     = fieldBounds{"minute", 0, 59}
+	hourBounds       = fieldBounds{"hour", 0, 23}
+	dayOfMonthBounds = fieldBounds{"day-of-month", 1, 31}
+	monthBounds      = fieldBounds{"month", 1, 12}
+	dayOfWeekBounds  = fieldBounds{"day-of-week", 0, 7}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression such as "0 3 * * *" or a descriptor such as "@daily"
+func Parse(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	c := new(Cron)
+	var err error
+	if c.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if c.dayOfMonth, err = parseField(fields[2], dayOfMonthBounds); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if c.dayOfWeek, err = parseField(fields[4], dayOfWeekBounds); err != nil {
+		return nil, err
+	}
+	// Sunday can be specified as 0 or 7
+	if c.dayOfWeek&(1<<7) != 0 {
+		c.dayOfWeek |= 1
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// Next returns the first time after t that matches the expression. The zero time is returned
+// if no matching time exists within the next five years (e.g. for "0 0 30 2 *").
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dayOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := c.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func parseField(field string, bounds fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %v field", stepPart, bounds.name)
+			}
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = bounds.min, bounds.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseValue(lo, bounds); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(hi, bounds); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in %v field", rangePart, bounds.name)
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangePart, bounds); err != nil {
+				return 0, err
+			}
+			end = start
+			// A single value with a step (e.g. 5/15) runs to the end of the range
+			if hasStep {
+				end = bounds.max
+			}
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, bounds fieldBounds) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < bounds.min || i > bounds.max {
+		return 0, fmt.Errorf("invalid value %q in %v field, expected %d-%d", value, bounds.name, bounds.min, bounds.max)
+	}
+	return i, nil
+}
@@ -0,0 +1,46 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, time.January, 10, 3, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 3 * * *", time.Date(2024, time.January, 11, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.January, 10, 3, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 5", time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.expected, c.Next(from), tt.expr)
+	}
+}
+
+func TestCronNextNoMatch(t *testing.T) {
+	c, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, c.Next(time.Now()).IsZero(), "February 30th should never match")
+}
+
+func TestCronParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
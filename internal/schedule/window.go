@@ -0,0 +1,108 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Timezones are also available in containers without zoneinfo
+)
+
+// Window is a recurring time window, either a daily time range such as "22:00-02:00" or
+// a cron expression for the opening of the window with a duration
+type Window struct {
+	cron     *Cron
+	duration time.Duration
+	// Daily time range in minutes of the day, the end is exclusive and may be before the start
+	start    int
+	end      int
+	location *time.Location
+	spec     string
+}
+
+// ParseWindow parses a maintenance window given either as timeRange or as cronExpr with duration
+// (e.g. "4h"). Times are evaluated in the IANA timezone, or in UTC if timezone is empty.
+func ParseWindow(timeRange string, cronExpr string, duration string, timezone string) (*Window, error) {
+	w := new(Window)
+	var err error
+	if w.location, err = time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+
+	switch {
+	case timeRange != "" && cronExpr != "":
+		return nil, fmt.Errorf("maintenance window requires either a time range or a cron expression, not both")
+	case timeRange != "":
+		from, to, found := strings.Cut(timeRange, "-")
+		if !found {
+			return nil, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", timeRange)
+		}
+		if w.start, err = parseTimeOfDay(from); err != nil {
+			return nil, err
+		}
+		if w.end, err = parseTimeOfDay(to); err != nil {
+			return nil, err
+		}
+		if w.start == w.end {
+			return nil, fmt.Errorf("invalid time range %q, start and end are equal", timeRange)
+		}
+		w.spec = timeRange
+	case cronExpr != "":
+		if w.cron, err = Parse(cronExpr); err != nil {
+			return nil, err
+		}
+		if w.duration, err = time.ParseDuration(duration); err != nil || w.duration <= 0 {
+			return nil, fmt.Errorf("invalid duration %q for cron maintenance window", duration)
+		}
+		w.spec = fmt.Sprintf("%v for %v", cronExpr, w.duration)
+	default:
+		return nil, fmt.Errorf("maintenance window requires a time range or a cron expression")
+	}
+	if timezone != "" {
+		w.spec += " " + timezone
+	}
+	return w, nil
+}
+
+// Contains returns true if t is within the window
+func (w *Window) Contains(t time.Time) bool {
+	t = t.In(w.location)
+	if w.cron != nil {
+		// The window is open if it was last opened less than the duration ago
+		opened := w.cron.Next(t.Add(-w.duration))
+		return !opened.IsZero() && !opened.After(t)
+	}
+	m := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// NextOpen returns t if it is within the window, otherwise the time the window opens next. The zero
+// time is returned if the window never opens.
+func (w *Window) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	t = t.In(w.location)
+	if w.cron != nil {
+		return w.cron.Next(t)
+	}
+	next := time.Date(t.Year(), t.Month(), t.Day(), w.start/60, w.start%60, 0, 0, w.location)
+	if !next.After(t) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, w.start/60, w.start%60, 0, 0, w.location)
+	}
+	return next
+}
+
+func (w *Window) String() string {
+	return w.spec
+}
+
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
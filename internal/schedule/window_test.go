@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowTimeRange(t *testing.T) {
+	w, err := ParseWindow("22:00-02:00", "", "", "Europe/Zurich")
+	require.NoError(t, err)
+	zurich, _ := time.LoadLocation("Europe/Zurich")
+
+	assert.True(t, w.Contains(time.Date(2024, time.January, 10, 23, 30, 0, 0, zurich)), "23:30 should be within window")
+	assert.True(t, w.Contains(time.Date(2024, time.January, 10, 1, 59, 0, 0, zurich)), "01:59 should be within window")
+	assert.False(t, w.Contains(time.Date(2024, time.January, 10, 2, 0, 0, 0, zurich)), "02:00 should be outside window")
+	// 21:30 UTC is 22:30 in Zurich
+	assert.True(t, w.Contains(time.Date(2024, time.January, 10, 21, 30, 0, 0, time.UTC)), "Timezone should be applied")
+
+	from := time.Date(2024, time.January, 10, 12, 0, 0, 0, zurich)
+	assert.Equal(t, time.Date(2024, time.January, 10, 22, 0, 0, 0, zurich), w.NextOpen(from), "Incorrect next opening")
+}
+
+func TestWindowCron(t *testing.T) {
+	// Saturdays from 06:00 for 3 hours
+	w, err := ParseWindow("", "0 6 * * 6", "3h", "")
+	require.NoError(t, err)
+
+	assert.True(t, w.Contains(time.Date(2024, time.January, 13, 8, 59, 0, 0, time.UTC)), "Saturday 08:59 should be within window")
+	assert.False(t, w.Contains(time.Date(2024, time.January, 13, 9, 0, 0, 0, time.UTC)), "Saturday 09:00 should be outside window")
+	assert.False(t, w.Contains(time.Date(2024, time.January, 12, 7, 0, 0, 0, time.UTC)), "Friday should be outside window")
+	assert.Equal(t, time.Date(2024, time.January, 13, 6, 0, 0, 0, time.UTC), w.NextOpen(time.Date(2024, time.January, 12, 7, 0, 0, 0, time.UTC)), "Incorrect next opening")
+}
+
+func TestParseWindowInvalid(t *testing.T) {
+	_, err := ParseWindow("22:00", "", "", "")
+	assert.Error(t, err, "Time range without end should be an error")
+	_, err = ParseWindow("", "0 6 * * 6", "", "")
+	assert.Error(t, err, "Cron without duration should be an error")
+	_, err = ParseWindow("22:00-02:00", "", "", "Mars/Olympus")
+	assert.Error(t, err, "Unknown timezone should be an error")
+}
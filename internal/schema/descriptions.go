@@ -0,0 +1,205 @@
+package schema
+
+// descriptions are shown by IDEs on hover, by struct and by struct and field as <struct>.<yaml key>
+var descriptions = map[string]string{
+	// configure
+	"ConfigureConfig.deploymentPrefix":        "Prefix added to the IDs of all packages and artifacts",
+	"ConfigureConfig.hooks":                   "Hooks executed once per run",
+	"ConfigureConfig.targets":                 "Tenants the configuration is applied to",
+	"ConfigureConfig.rollout":                 "Order in which the targets are configured",
+	"ConfigureConfig.typeAliases":             "Custom artifact types mapped to a supported type",
+	"ConfigureConfig.parameterGroups":         "Named parameters shared by artifacts with useGroups",
+	"ConfigureConfig.tenantDefaults":          "Parameters set on all artifacts that have them, e.g. the log level, unless set on the artifact or skipped with skipTenantDefaults",
+	"ConfigureConfig.packages":                "Packages with the artifacts to configure",
+	"ConfigureConfig.createMissingParameters": "Create parameters not found in the artifacts instead of skipping them, where the tenant supports it",
+	"ConfigureConfig.stamp":                   "Records the run ID, Git revision and time of the last run on the packages it changed",
+	"ConfigureConfig.batchFallback":           "Handling of batch requests that fail as a whole when parameters are not updated atomically: none (fail the artifact), failed-only (update the parameters of the failed requests individually) or all (update all parameters individually, default)",
+
+	"ConfigureTarget":                  "Tenant the configuration is applied to. Credentials can reference environment variables as $VAR or ${VAR}.",
+	"ConfigureTarget.name":             "Name of the target, used by --target and the rollout steps",
+	"ConfigureTarget.host":             "Host of the tenant management node",
+	"ConfigureTarget.oauthHost":        "Host of the OAuth token endpoint",
+	"ConfigureTarget.oauthPath":        "Path of the OAuth token endpoint",
+	"ConfigureTarget.clientId":         "OAuth client ID",
+	"ConfigureTarget.clientSecret":     "OAuth client secret",
+	"ConfigureTarget.userId":           "User ID of Basic Auth",
+	"ConfigureTarget.password":         "Password of Basic Auth",
+	"ConfigureTarget.odataVersion":     "Version of the OData APIs",
+	"ConfigureTarget.deploymentPrefix": "Overrides the deployment prefix for this tenant",
+	"ConfigureTarget.parameters":       "Parameter overrides by artifact ID and key",
+	"ConfigureTarget.tenantDefaults":   "Tenant defaults of this tenant, override the tenantDefaults of the configuration with the same key",
+
+	"ConfigureRollout":             "Order in which the configuration is applied to the targets. With the canary strategy, the tenants of the steps are configured one step after the other, followed by the remaining targets.",
+	"ConfigureRollout.strategy":    "Rollout strategy",
+	"ConfigureRollout.steps":       "Canary steps",
+	"ConfigureRollout.healthCheck": "Health check after each canary step",
+	"ConfigureRollout.rollback":    "Restore previous parameter values when the rollout is aborted",
+
+	"ConfigureStamp":          "Records the run ID, Git revision and time of the last run on the packages it changed, so that tenant administrators can see in the UI when and by which pipeline their content was last changed",
+	"ConfigureStamp.target":   "description (a managed line of the package description) or tag (a custom tag of the package)",
+	"ConfigureStamp.tag":      "Custom tag set with target tag, it must be defined in the settings of the tenant",
+	"ConfigureStamp.revision": "Git revision of the configuration, defaults to the commit of the CI/CD pipeline run",
+
+	"ConfigureRolloutStep.tenant":       "Name of the target",
+	"ConfigureRolloutStep.pauseMinutes": "Time to wait after deployment before the health check",
+
+	"ConfigureHealthCheck":                   "Checks the message processing logs of the deployed integration flows",
+	"ConfigureHealthCheck.maxFailedMessages": "Failed messages tolerated per integration flow",
+	"ConfigureHealthCheck.artifacts":         "Integration flows to check, defaults to deployed integration flows",
+	"ConfigureHealthCheck.onFailure":         "keep (default) or rollback to redeploy the previously running versions of an unhealthy tenant",
+
+	"ConfigurePackage":                    "Package containing artifacts to configure",
+	"ConfigurePackage.integrationSuiteId": "ID of the package",
+	"ConfigurePackage.displayName":        "Name of the package",
+	"ConfigurePackage.deploy":             "Deploy all artifacts of the package after configuration",
+	"ConfigurePackage.when":               "Template condition, e.g. eq .Environment \"prod\", the package is skipped if it is false",
+	"ConfigurePackage.tags":               "Tags of all artifacts of the package, e.g. billing, to select them with --tags and --exclude-tags",
+	"ConfigurePackage.hooks":              "Hooks executed for the package",
+	"ConfigurePackage.maintenanceWindow":  "Times in which the artifacts of the package may be deployed",
+	"ConfigurePackage.maxChanges":         "Maximum number of parameters of the package a run may change, the run is aborted before applying anything otherwise",
+	"ConfigurePackage.artifacts":          "Artifacts to configure",
+
+	"ConfigureArtifact.artifactId":              "ID of the artifact",
+	"ConfigureArtifact.displayName":             "Name of the artifact",
+	"ConfigureArtifact.type":                    "Integration, MessageMapping, ScriptCollection, ValueMapping or an alias",
+	"ConfigureArtifact.version":                 "Artifact version",
+	"ConfigureArtifact.deploy":                  "Deploy this artifact after configuration",
+	"ConfigureArtifact.deployVersion":           "Designtime version deployed instead of the active version, e.g. 1.0.3 to roll back",
+	"ConfigureArtifact.when":                    "Template condition, e.g. eq .Environment \"prod\", the artifact is skipped if it is false",
+	"ConfigureArtifact.tags":                    "Tags of the artifact in addition to those of its package, to select it with --tags and --exclude-tags",
+	"ConfigureArtifact.deployAs":                "Prefixed IDs the artifact is configured and deployed under instead of its own ID, e.g. for several clients",
+	"ConfigureArtifact.partners":                "Partners the artifact is instantiated for, the ID, name and parameter values reference the columns of a partner as ${partner.<column>}",
+	"ConfigureArtifact.parameters":              "Configuration parameters to update",
+	"ConfigureArtifact.parametersFrom":          ".properties or .env files with further parameters, inline parameters win",
+	"ConfigureArtifact.useGroups":               "Parameter groups with further parameters, inline parameters and parametersFrom win",
+	"ConfigureArtifact.batch":                   "Batch processing settings",
+	"ConfigureArtifact.hooks":                   "Hooks executed for the artifact",
+	"ConfigureArtifact.maintenanceWindow":       "Overrides the maintenance window of the package",
+	"ConfigureArtifact.deployStrategy":          "How the artifact is deployed",
+	"ConfigureArtifact.drain":                   "Drain checks of the stopStart strategy",
+	"ConfigureArtifact.blueGreen":               "Settings of the blueGreen strategy",
+	"ConfigureArtifact.draftHandling":           "Overrides --draft-handling for this artifact",
+	"ConfigureArtifact.createMissingParameters": "Create parameters not found in the artifact instead of skipping them, where the tenant supports it",
+	"ConfigureArtifact.skipTenantDefaults":      "Do not set the tenant defaults on the artifact",
+
+	"DeployInstance":            "Instance of an artifact deployed under a prefixed ID",
+	"DeployInstance.prefix":     "Prefix of the artifact ID, e.g. A_, applied after the deployment prefix",
+	"DeployInstance.parameters": "Parameters of the instance, overriding those of the artifact with the same key",
+
+	"PartnerTable":      "Partners an artifact is instantiated for, one artifact per partner",
+	"PartnerTable.from": "CSV file with a header row, or YAML or JSON file with a list of objects, relative to the configuration file",
+	"PartnerTable.rows": "Partners in addition to those of from, with a value per column",
+
+	"ConfigurationParameter":           "Configuration parameter to update. YAML numbers, booleans and multiline blocks are used as written.",
+	"ConfigurationParameter.key":       "Key of the parameter",
+	"ConfigurationParameter.value":     "Value of the parameter, can reference environment variables as $VAR or ${VAR}",
+	"ConfigurationParameter.valueFrom": "External source of the value, resolved at apply time",
+	"ConfigurationParameter.fromFile":  "File the value is read from, relative to the configuration file",
+	"ConfigurationParameter.base64":    "Base64 encode the content of fromFile",
+	"ConfigurationParameter.mode":      "How the value is applied to the current value on the tenant",
+	"ConfigurationParameter.separator": "Separator of list values for append",
+	"ConfigurationParameter.validate":  "Validator of the value, a named validator or regex:<pattern>",
+
+	"ValueFromSource.destination": "BTP destination property in the format <name>#<property>",
+
+	"ConfigureHooks":               "Local commands executed around the lifecycle phases",
+	"ConfigureHooks.preConfigure":  "Commands executed before the configuration is updated",
+	"ConfigureHooks.postConfigure": "Commands executed after the configuration is updated",
+	"ConfigureHooks.preDeploy":     "Commands executed before deployment",
+	"ConfigureHooks.postDeploy":    "Commands executed after deployment",
+
+	"BatchSettings.enabled":   "Enable batch processing for this artifact",
+	"BatchSettings.batchSize": "Number of parameters per batch request",
+	"BatchSettings.fallback":  "Handling of failed batch requests of this artifact, overrides batchFallback: none, failed-only or all",
+
+	"MaintenanceWindow":           "Restricts when artifacts may be deployed, given either as a daily time range or as a cron expression for the opening of the window with a duration",
+	"MaintenanceWindow.timeRange": "Daily time range, e.g. 22:00-02:00, may cross midnight",
+	"MaintenanceWindow.cron":      "Cron expression of the opening of the window, e.g. 0 6 * * 6",
+	"MaintenanceWindow.duration":  "Duration of the window, e.g. 4h, required with cron",
+	"MaintenanceWindow.timezone":  "IANA timezone",
+
+	"DrainCheck":                "JMS queues and data stores that must be empty before an artifact undeployed with the stopStart strategy is deployed again",
+	"DrainCheck.jmsQueues":      "JMS queues that must be empty",
+	"DrainCheck.dataStores":     "Data stores that must be empty",
+	"DrainCheck.timeoutMinutes": "Time to wait for the queues and data stores to be empty",
+	"DrainCheck.discover":       "Also check the queues of the JMS sender channels and the data stores of the integration flow",
+
+	"BlueGreenSettings":                  "Temporary copy of an integration flow that is deployed and smoke tested before the integration flow itself is redeployed",
+	"BlueGreenSettings.tempSuffix":       "Suffix of the ID of the copy",
+	"BlueGreenSettings.addressParameter": "Parameter with the HTTP address, suffixed for the copy",
+	"BlueGreenSettings.smokeTest":        "Request sent to the HTTP endpoint of the copy",
+
+	"SmokeTest.path":              "Appended to the endpoint URL",
+	"SmokeTest.method":            "HTTP method of the request",
+	"SmokeTest.body":              "Request body",
+	"SmokeTest.headers":           "Request headers, values can reference environment variables as $VAR or ${VAR}",
+	"SmokeTest.expectedStatus":    "Expected HTTP status of the response, unless an assertion checks the status",
+	"SmokeTest.assertions":        "Further checks of the response",
+	"SmokeTest.retries":           "Additional attempts if the request or an assertion fails",
+	"SmokeTest.retryDelaySeconds": "Time between attempts in seconds",
+
+	"SmokeTestAssertion":               "Check of the smoke test response, with exactly one of status, jsonPath, xpath, header and maxResponseMs",
+	"SmokeTestAssertion.status":        "Expected HTTP status",
+	"SmokeTestAssertion.jsonPath":      "Path of a value in a JSON response, e.g. $.items[0].status",
+	"SmokeTestAssertion.xpath":         "Path of an element or attribute in an XML response, e.g. //Order/@id",
+	"SmokeTestAssertion.header":        "Name of a response header",
+	"SmokeTestAssertion.maxResponseMs": "Response time budget in milliseconds",
+	"SmokeTestAssertion.equals":        "Expected value of the path or header",
+	"SmokeTestAssertion.contains":      "Expected part of the value of the path or header",
+
+	// deploy
+	"DeployConfig.deploymentPrefix": "Prefix added to the IDs of all packages and artifacts",
+	"DeployConfig.packages":         "Packages to update and deploy",
+	"DeployConfig.orchestrator":     "Settings of the orchestrator, overridden by its flags",
+
+	"OrchestratorConfig.packagesDir":         "Directory containing the packages",
+	"OrchestratorConfig.deployConfig":        "Deployment configuration as file, folder or URL",
+	"OrchestratorConfig.deploymentPrefix":    "Prefix added to the IDs of all packages and artifacts",
+	"OrchestratorConfig.packageFilter":       "Comma-separated packages to process",
+	"OrchestratorConfig.artifactFilter":      "Comma-separated artifacts to process",
+	"OrchestratorConfig.configPattern":       "File pattern of the config files of a folder",
+	"OrchestratorConfig.mergeConfigs":        "Merge the config files of a folder into a single deployment",
+	"OrchestratorConfig.keepTemp":            "Keep the temporary directory with the modified artifacts",
+	"OrchestratorConfig.mode":                "Operation mode",
+	"OrchestratorConfig.deployRetries":       "Number of status checks of each deployment",
+	"OrchestratorConfig.deployDelaySeconds":  "Delay between the status checks of a deployment",
+	"OrchestratorConfig.parallelDeployments": "Maximum number of parallel deployments per package",
+
+	"Package.integrationSuiteId": "ID of the package",
+	"Package.packageDir":         "Directory of the package within packagesDir",
+	"Package.displayName":        "Name of the package",
+	"Package.description":        "Description of the package",
+	"Package.short_text":         "Short text of the package",
+	"Package.sync":               "Update the artifacts of the package",
+	"Package.deploy":             "Deploy the artifacts of the package",
+	"Package.maintenanceWindow":  "Times in which the artifacts of the package may be deployed",
+	"Package.artifacts":          "Artifacts of the package",
+
+	"Artifact.artifactId":        "ID of the artifact",
+	"Artifact.artifactDir":       "Directory of the artifact within the package directory",
+	"Artifact.displayName":       "Name of the artifact",
+	"Artifact.type":              "Type of the artifact",
+	"Artifact.sync":              "Update the artifact",
+	"Artifact.deploy":            "Deploy the artifact",
+	"Artifact.deployVersion":     "Designtime version deployed instead of the active version, e.g. 1.0.3 to roll back",
+	"Artifact.configOverrides":   "Configuration parameters set before deployment, by key",
+	"Artifact.maintenanceWindow": "Overrides the maintenance window of the package",
+	"Artifact.deployStrategy":    "How the artifact is deployed",
+	"Artifact.drain":             "Drain checks of the stopStart strategy",
+	"Artifact.blueGreen":         "Settings of the blueGreen strategy",
+
+	// valuemapping
+	"ValueMappingFile.valueMappingId": "ID of the value mapping",
+	"ValueMappingFile.version":        "Version of the value mapping",
+	"ValueMappingFile.mappings":       "Values mapped between pairs of agencies and identifiers",
+
+	"ValueMappingGroup":                  "Values mapped between a source and a target agency and identifier",
+	"ValueMappingGroup.sourceAgency":     "Source agency",
+	"ValueMappingGroup.sourceIdentifier": "Source identifier",
+	"ValueMappingGroup.targetAgency":     "Target agency",
+	"ValueMappingGroup.targetIdentifier": "Target identifier",
+	"ValueMappingGroup.values":           "Mapped values, a source value can only be mapped once",
+
+	"ValueMappingValue.source": "Source value",
+	"ValueMappingValue.target": "Target value",
+}
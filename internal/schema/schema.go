@@ -0,0 +1,252 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/pkg/flashpipe"
+)
+
+// Draft is the JSON Schema version of the generated schemas, the version best supported by yaml-language-server
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+//go:embed examples
+var examples embed.FS
+
+// Kind is a kind of config file a schema and an annotated example are generated for
+type Kind struct {
+	Name        string // Name used on the command line and in the file names, e.g. configure
+	Title       string
+	Description string
+	model       reflect.Type
+}
+
+// Kinds are the supported kinds of config files. New kinds only need a model, an example in examples/ and
+// the descriptions of their fields.
+var Kinds = []Kind{
+	{Name: "configure", Title: "FlashPipe configure configuration",
+		Description: "Configuration parameters of artifacts applied by flashpipe configure",
+		model:       reflect.TypeOf(models.ConfigureConfig{})},
+	{Name: "deploy", Title: "FlashPipe orchestrator deployment configuration",
+		Description: "Packages and artifacts updated and deployed by flashpipe orchestrator",
+		model:       reflect.TypeOf(models.DeployConfig{})},
+	{Name: "valuemapping", Title: "FlashPipe value mapping",
+		Description: "Entries of a value mapping managed by flashpipe valuemapping",
+		model:       reflect.TypeOf(models.ValueMappingFile{})},
+}
+
+// KindNames returns the names of the supported kinds
+func KindNames() []string {
+	names := make([]string, 0, len(Kinds))
+	for _, kind := range Kinds {
+		names = append(names, kind.Name)
+	}
+	return names
+}
+
+// Find returns the kind with the name
+func Find(name string) (Kind, error) {
+	for _, kind := range Kinds {
+		if strings.EqualFold(kind.Name, name) {
+			return kind, nil
+		}
+	}
+	return Kind{}, fmt.Errorf("unknown kind %q (supported kinds: %s)", name, strings.Join(KindNames(), ", "))
+}
+
+// SchemaFile is the name of the schema file of the kind, e.g. configure.schema.json
+func (k Kind) SchemaFile() string {
+	return k.Name + ".schema.json"
+}
+
+// ExampleFile is the name of the example file of the kind, e.g. configure.example.yml
+func (k Kind) ExampleFile() string {
+	return k.Name + ".example.yml"
+}
+
+// Example returns the annotated example file of the kind
+func (k Kind) Example() ([]byte, error) {
+	return examples.ReadFile("examples/" + k.ExampleFile())
+}
+
+// Schema returns the JSON Schema of the kind. Structs are defined once in definitions and referenced.
+func (k Kind) Schema() ([]byte, error) {
+	g := &generator{definitions: map[string]interface{}{}}
+	root := g.object(k.model)
+	root["$schema"] = Draft
+	root["title"] = k.Title
+	root["description"] = k.Description
+	if len(g.definitions) > 0 {
+		root["definitions"] = g.definitions
+	}
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// generator builds the schema of a model from the yaml tags of its fields
+type generator struct {
+	definitions map[string]interface{}
+}
+
+// object returns the schema of a struct
+func (g *generator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		ref := t.Name() + "." + name
+		property := g.property(ref, field.Type)
+		if description, found := descriptions[ref]; found {
+			property["description"] = description
+		}
+		if values, found := enums[ref]; found {
+			property["enum"] = values
+		}
+		if values, found := suggestions[ref]; found {
+			property["examples"] = values
+		}
+		if value, found := defaults[ref]; found {
+			property["default"] = value
+		}
+		properties[name] = property
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if description, found := descriptions[t.Name()]; found {
+		schema["description"] = description
+	}
+	if fields := required[t.Name()]; len(fields) > 0 {
+		schema["required"] = fields
+	}
+	return schema
+}
+
+// property returns the schema of a field, referencing the definitions of structs
+func (g *generator) property(ref string, t reflect.Type) map[string]interface{} {
+	if types, found := scalarTypes[ref]; found {
+		return map[string]interface{}{"type": types}
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, found := g.definitions[t.Name()]; !found {
+			// Placeholder for recursive types
+			g.definitions[t.Name()] = nil
+			g.definitions[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.property("", t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.property("", t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float64, reflect.Float32:
+		return map[string]interface{}{"type": "number"}
+	}
+	// interface{} accepts any value
+	return map[string]interface{}{}
+}
+
+// nonEmpty returns the values without the empty value that stands for the default
+func nonEmpty(values []string) []string {
+	return slices.DeleteFunc(slices.Clone(values), func(v string) bool { return v == "" })
+}
+
+// scalarTypes are fields that accept more YAML types than their Go type
+var scalarTypes = map[string][]string{
+	// YAML numbers and booleans are used as written
+	"ConfigurationParameter.value": {"string", "number", "boolean"},
+}
+
+// required are the fields that must be set, by struct
+var required = map[string][]string{
+	"ConfigureConfig":        {"packages"},
+	"ConfigureTarget":        {"name", "host"},
+	"ConfigureRolloutStep":   {"tenant"},
+	"ConfigureStamp":         {"target"},
+	"ConfigurePackage":       {"integrationSuiteId"},
+	"ConfigureArtifact":      {"artifactId", "type"},
+	"ConfigurationParameter": {"key"},
+	"DeployConfig":           {"packages"},
+	"Package":                {"integrationSuiteId"},
+	"Artifact":               {"artifactId"},
+	"ValueMappingFile":       {"valueMappingId", "mappings"},
+	"ValueMappingGroup":      {"sourceAgency", "sourceIdentifier", "targetAgency", "targetIdentifier", "values"},
+	"ValueMappingValue":      {"source", "target"},
+}
+
+// enums are the allowed values of fields
+var enums = map[string][]string{
+	"ConfigureTarget.odataVersion":     {"auto", "v2", "v4"},
+	"ConfigureRollout.strategy":        {"all", "canary"},
+	"ConfigureHealthCheck.onFailure":   {"keep", "rollback"},
+	"ConfigureArtifact.deployStrategy": nonEmpty(flashpipe.DeployStrategies),
+	"ConfigureArtifact.draftHandling":  nonEmpty(flashpipe.DraftHandlings),
+	"ConfigureConfig.batchFallback":    nonEmpty(flashpipe.BatchFallbacks),
+	"ConfigureStamp.target":            flashpipe.StampTargets,
+	"BatchSettings.fallback":           nonEmpty(flashpipe.BatchFallbacks),
+	"ConfigurationParameter.mode":      nonEmpty(flashpipe.ParameterModes),
+	"Artifact.deployStrategy":          nonEmpty(flashpipe.DeployStrategies),
+	"OrchestratorConfig.mode":          {"update-and-deploy", "update-only", "deploy-only"},
+}
+
+// suggestions are values offered by IDEs for fields that also accept other values
+var suggestions = map[string][]string{
+	// Types are matched case-insensitively and can be aliases of typeAliases
+	"ConfigureArtifact.type":          slices.Concat(flashpipe.ArtifactTypes, flashpipe.ArtifactTypeAliases),
+	"Artifact.type":                   flashpipe.ArtifactTypes,
+	"ConfigurationParameter.validate": slices.Concat(flashpipe.ValidatorNames(), []string{"regex:<pattern>"}),
+	"SmokeTest.method":                {"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"},
+}
+
+// defaults are the values of fields that are not set
+var defaults = map[string]interface{}{
+	"ConfigureRollout.strategy":        "all",
+	"ConfigureTarget.odataVersion":     "auto",
+	"ConfigureHealthCheck.onFailure":   "keep",
+	"ConfigurePackage.deploy":          false,
+	"ConfigureArtifact.version":        "active",
+	"ConfigureArtifact.deploy":         false,
+	"ConfigureArtifact.deployStrategy": "inPlace",
+	"ConfigurationParameter.mode":      flashpipe.ParameterModeSet,
+	"ConfigurationParameter.separator": ",",
+	"BatchSettings.enabled":            true,
+	"BatchSettings.batchSize":          90,
+	"ConfigureConfig.batchFallback":    flashpipe.BatchFallbackAll,
+	"ConfigureStamp.tag":               flashpipe.DefaultStampTag,
+	"DrainCheck.timeoutMinutes":        10,
+	"BlueGreenSettings.tempSuffix":     "_BG",
+	"SmokeTest.method":                 "GET",
+	"SmokeTest.expectedStatus":         200,
+	"SmokeTest.retryDelaySeconds":      10,
+	"MaintenanceWindow.timezone":       "UTC",
+	"Package.sync":                     true,
+	"Package.deploy":                   true,
+	"Artifact.sync":                    true,
+	"Artifact.deploy":                  true,
+	"Artifact.deployStrategy":          "inPlace",
+	"OrchestratorConfig.mode":          "update-and-deploy",
+	"OrchestratorConfig.configPattern": "*.y*ml",
+	"ValueMappingFile.version":         "active",
+}
@@ -0,0 +1,162 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExamplesDecodeIntoModels(t *testing.T) {
+	for _, kind := range Kinds {
+		t.Run(kind.Name, func(t *testing.T) {
+			example, err := kind.Example()
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(example), "# yaml-language-server: $schema=./"+kind.SchemaFile()))
+
+			decoder := yaml.NewDecoder(bytes.NewReader(example))
+			decoder.KnownFields(true)
+			model := reflect.New(kind.model).Interface()
+			assert.NoError(t, decoder.Decode(model))
+		})
+	}
+}
+
+func TestExamplesMatchSchemas(t *testing.T) {
+	for _, kind := range Kinds {
+		t.Run(kind.Name, func(t *testing.T) {
+			data, err := kind.Schema()
+			require.NoError(t, err)
+			var schema map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &schema))
+			assert.Equal(t, Draft, schema["$schema"])
+
+			example, err := kind.Example()
+			require.NoError(t, err)
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal(example, &node))
+			assert.Empty(t, check(schema, schema, node.Content[0], "$"))
+		})
+	}
+}
+
+func TestSchemaRejectsUnknownFields(t *testing.T) {
+	kind, err := Find("configure")
+	require.NoError(t, err)
+	data, err := kind.Schema()
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        deployStrategy: rolling
+        parameters:
+          - kee: Host
+`), &node))
+	assert.ElementsMatch(t, []string{
+		"$.packages[0].artifacts[0].deployStrategy: value rolling not allowed",
+		"$.packages[0].artifacts[0].parameters[0]: unknown field kee",
+		"$.packages[0].artifacts[0].parameters[0]: missing field key",
+	}, check(schema, schema, node.Content[0], "$"))
+}
+
+func TestAllFieldsDescribed(t *testing.T) {
+	for _, kind := range Kinds {
+		data, err := kind.Schema()
+		require.NoError(t, err)
+		var schema map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &schema))
+		definitions, _ := schema["definitions"].(map[string]interface{})
+		objects := map[string]interface{}{kind.model.Name(): schema}
+		for name, definition := range definitions {
+			objects[name] = definition
+		}
+		for name, object := range objects {
+			for key, property := range object.(map[string]interface{})["properties"].(map[string]interface{}) {
+				assert.NotEmpty(t, property.(map[string]interface{})["description"], "%s.%s", name, key)
+			}
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	kind, err := Find("ValueMapping")
+	require.NoError(t, err)
+	assert.Equal(t, "valuemapping", kind.Name)
+	assert.Equal(t, "valuemapping.schema.json", kind.SchemaFile())
+
+	_, err = Find("partners")
+	assert.EqualError(t, err, `unknown kind "partners" (supported kinds: configure, deploy, valuemapping)`)
+}
+
+// check returns the problems of a YAML node against the subset of JSON Schema used by the generated schemas
+func check(root, schema map[string]interface{}, node *yaml.Node, path string) []string {
+	if ref, found := schema["$ref"].(string); found {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		return check(root, root["definitions"].(map[string]interface{})[name].(map[string]interface{}), node, path)
+	}
+	var problems []string
+	if values, found := schema["enum"].([]interface{}); found && !slices.Contains(values, interface{}(node.Value)) {
+		problems = append(problems, fmt.Sprintf("%s: value %s not allowed", path, node.Value))
+	}
+	switch schema["type"] {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			return append(problems, path+": expected an object")
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := map[string]bool{}
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			keys[key] = true
+			property, found := properties[key].(map[string]interface{})
+			if !found {
+				property, found = schema["additionalProperties"].(map[string]interface{})
+			}
+			if !found {
+				problems = append(problems, fmt.Sprintf("%s: unknown field %s", path, key))
+				continue
+			}
+			problems = append(problems, check(root, property, node.Content[i+1], path+"."+key)...)
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if !keys[key.(string)] {
+				problems = append(problems, fmt.Sprintf("%s: missing field %s", path, key))
+			}
+		}
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			return append(problems, path+": expected an array")
+		}
+		for i, item := range node.Content {
+			problems = append(problems, check(root, schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+			problems = append(problems, path+": expected a string")
+		}
+	case "boolean":
+		if node.Tag != "!!bool" {
+			problems = append(problems, path+": expected a boolean")
+		}
+	case "integer":
+		if node.Tag != "!!int" {
+			problems = append(problems, path+": expected an integer")
+		}
+	}
+	return problems
+}
@@ -0,0 +1,384 @@
+// Package selfupdate replaces the running binary with a release published on GitHub. The release asset is
+// verified with the SHA-256 checksums published with the release and, with a public key, the signature of
+// the checksums.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/signature"
+	"github.com/rs/zerolog/log"
+)
+
+// Release channels
+const (
+	ChannelStable = "stable" // Latest release that is not a pre-release
+	ChannelEdge   = "edge"   // Latest release, including pre-releases
+)
+
+// TokenEnv is the environment variable with a GitHub token sent to the GitHub API, e.g. to avoid its rate
+// limits on shared runners
+const TokenEnv = "GITHUB_TOKEN"
+
+// DefaultAPIURL is the URL of the GitHub API
+const DefaultAPIURL = "https://api.github.com"
+
+// Options select the release and how it is verified
+type Options struct {
+	Repository string // GitHub repository of the releases as <owner>/<name>
+	Channel    string // stable (default) or edge
+	PublicKey  string // Public key file the signature of the checksums is verified with, see signature.ParsePublicKey
+	APIURL     string // Defaults to DefaultAPIURL
+}
+
+// Release is a GitHub release
+type Release struct {
+	TagName    string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file of a GitHub release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the version of the release without the v prefix of the tag
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// Latest returns the latest release of the channel
+func Latest(opts Options) (*Release, error) {
+	apiURL := strings.TrimSuffix(opts.APIURL, "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	switch opts.Channel {
+	case "", ChannelStable:
+		release := new(Release)
+		if err := getJSON(fmt.Sprintf("%s/repos/%s/releases/latest", apiURL, opts.Repository), release); err != nil {
+			return nil, err
+		}
+		return release, nil
+	case ChannelEdge:
+		var releases []Release
+		if err := getJSON(fmt.Sprintf("%s/repos/%s/releases?per_page=20", apiURL, opts.Repository), &releases); err != nil {
+			return nil, err
+		}
+		// Releases are listed newest first
+		for _, release := range releases {
+			if !release.Draft {
+				return &release, nil
+			}
+		}
+		return nil, fmt.Errorf("no releases found in %s", opts.Repository)
+	}
+	return nil, fmt.Errorf("invalid channel %q (valid channels: %s, %s)", opts.Channel, ChannelStable, ChannelEdge)
+}
+
+// Install downloads the binary of the release for the current platform, verifies it and replaces executable
+func Install(release *Release, opts Options, executable string) error {
+	asset := binaryAsset(release.Assets, runtime.GOOS, runtime.GOARCH)
+	if asset == nil {
+		return fmt.Errorf("release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksums, err := checksums(release, asset.Name, opts)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Downloading %s", asset.URL)
+	data, err := download(asset.URL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, checksums[asset.Name]) {
+		return fmt.Errorf("checksum of %s is %s, expected %s", asset.Name, actual, checksums[asset.Name])
+	}
+	log.Info().Msgf("Checksum of %s verified", asset.Name)
+
+	binary, err := extract(asset.Name, data)
+	if err != nil {
+		return err
+	}
+	return replace(executable, binary)
+}
+
+// Newer returns true if version a is newer than version b. Versions are compared as semantic versions, a
+// pre-release is older than the release of the same version.
+func Newer(a string, b string) bool {
+	return compareVersions(a, b) > 0
+}
+
+func compareVersions(a string, b string) int {
+	a, preA, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	b, preB, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var numA, numB int
+		if i < len(partsA) {
+			numA, _ = strconv.Atoi(partsA[i])
+		}
+		if i < len(partsB) {
+			numB, _ = strconv.Atoi(partsB[i])
+		}
+		if numA != numB {
+			return numA - numB
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return strings.Compare(preA, preB)
+}
+
+// archAliases are other names of the architectures used in the names of release assets
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x86_64"},
+	"arm64": {"arm64", "aarch64"},
+}
+
+// binaryAsset returns the asset with the binary of the platform, e.g. flashpipe_3.8.0_linux_amd64.tar.gz or
+// flashpipex-linux-amd64. Checksums and signatures are not binaries.
+func binaryAsset(assets []Asset, goos string, goarch string) *Asset {
+	arches := archAliases[goarch]
+	if arches == nil {
+		arches = []string{goarch}
+	}
+	for i, asset := range assets {
+		name := strings.ToLower(asset.Name)
+		if !strings.HasPrefix(name, "flashpipe") || !strings.Contains(name, goos) || isVerification(name) {
+			continue
+		}
+		for _, arch := range arches {
+			if strings.Contains(name, arch) {
+				return &assets[i]
+			}
+		}
+	}
+	return nil
+}
+
+func isVerification(name string) bool {
+	for _, suffix := range []string{".sha256", ".sig", ".minisig", ".asc", ".pem", "checksums.txt"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checksums returns the checksums of the release by file name, read from checksums.txt or <asset>.sha256. With
+// a public key, the signature of the checksums file is verified.
+func checksums(release *Release, assetName string, opts Options) (map[string]string, error) {
+	var file *Asset
+	for i, asset := range release.Assets {
+		if strings.HasSuffix(strings.ToLower(asset.Name), "checksums.txt") || asset.Name == assetName+".sha256" {
+			file = &release.Assets[i]
+			break
+		}
+	}
+	if file == nil {
+		return nil, fmt.Errorf("release %s has no checksums.txt or %s.sha256, the binary cannot be verified", release.TagName, assetName)
+	}
+	data, err := download(file.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.PublicKey != "" {
+		keyData, err := os.ReadFile(opts.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		key, err := signature.ParsePublicKey(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("public key %s: %w", opts.PublicKey, err)
+		}
+		sigName := file.Name + key.SignatureExtension()
+		var sig []byte
+		for _, asset := range release.Assets {
+			if asset.Name == sigName {
+				if sig, err = download(asset.URL); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if sig == nil {
+			return nil, fmt.Errorf("release %s has no signature %s", release.TagName, sigName)
+		}
+		if err := key.Verify(data, sig); err != nil {
+			return nil, fmt.Errorf("signature of %s: %w", file.Name, err)
+		}
+		log.Info().Msgf("Signature of %s verified with %s key", file.Name, key.Format())
+	}
+
+	// Lines of sha256sum: <checksum>  <file name>, a .sha256 file may only contain the checksum
+	sums := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			sums[assetName] = fields[0]
+		case 2:
+			sums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+		}
+	}
+	if sums[assetName] == "" {
+		return nil, fmt.Errorf("%s has no checksum of %s", file.Name, assetName)
+	}
+	return sums, nil
+}
+
+// extract returns the binary of a .tar.gz or .zip archive. Other assets are the binary.
+func extract(name string, data []byte) ([]byte, error) {
+	isBinary := func(file string) bool {
+		base := strings.ToLower(path.Base(file))
+		return strings.HasPrefix(base, "flashpipe") && (path.Ext(base) == "" || path.Ext(base) == ".exe")
+	}
+	switch {
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			if header.Typeflag == tar.TypeReg && isBinary(header.Name) {
+				return io.ReadAll(tr)
+			}
+		}
+	case strings.HasSuffix(name, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if !f.FileInfo().IsDir() && isBinary(f.Name) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+	default:
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s contains no flashpipe binary", name)
+}
+
+// replace writes the binary next to executable and renames it over executable. The running binary is moved
+// to <executable>.old first, as Windows does not allow to replace it, and removed where possible.
+func replace(executable string, binary []byte) error {
+	info, err := os.Stat(executable)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(executable), ".flashpipe-update-")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	old := executable + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(executable, old); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", executable, err)
+	}
+	if err := os.Rename(tmp.Name(), executable); err != nil {
+		_ = os.Rename(old, executable)
+		return fmt.Errorf("failed to replace %s: %w", executable, err)
+	}
+	_ = os.Remove(old)
+	return nil
+}
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+func get(location string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(TokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: %s", location, resp.Status)
+	}
+	return resp, nil
+}
+
+func getJSON(location string, v interface{}) error {
+	resp, err := get(location)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	return nil
+}
+
+func download(location string) ([]byte, error) {
+	resp, err := get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,147 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0o644, Size: 2, Typeflag: tar.TypeReg}))
+	_, _ = tw.Write([]byte("hi"))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, _ = tw.Write(content)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// releaseServer serves a stable and an edge release with a signed checksums.txt
+func releaseServer(t *testing.T, archive []byte, checksum string, privateKey ed25519.PrivateKey) *httptest.Server {
+	assetName := fmt.Sprintf("flashpipe_3.8.0_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	checksums := []byte(fmt.Sprintf("%s  %s\n0000  flashpipe_3.8.0_plan9_mips.tar.gz\n", checksum, assetName))
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	release := func(tag string, prerelease bool) string {
+		return fmt.Sprintf(`{"tag_name": "%s", "prerelease": %t, "assets": [
+			{"name": "checksums.txt", "browser_download_url": "%[3]s/download/checksums.txt"},
+			{"name": "checksums.txt.sig", "browser_download_url": "%[3]s/download/checksums.txt.sig"},
+			{"name": "%[4]s", "browser_download_url": "%[3]s/download/binary"}]}`, tag, prerelease, srv.URL, assetName)
+	}
+	mux.HandleFunc("/repos/engswee/flashpipe/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(release("v3.8.0", false)))
+	})
+	mux.HandleFunc("/repos/engswee/flashpipe/releases", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"tag_name": "v4.0.0-rc.1", "draft": true}, ` + release("v3.9.0-rc.1", true) + `, ` + release("v3.8.0", false) + `]`))
+	})
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(checksums)
+	})
+	mux.HandleFunc("/download/checksums.txt.sig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(ed25519.Sign(privateKey, checksums))
+	})
+	mux.HandleFunc("/download/binary", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writePublicKey(t *testing.T, key ed25519.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "release.pub")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func TestLatestAndInstallMock(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	archive := tarGz(t, "flashpipe", []byte("new binary"))
+	sum := sha256.Sum256(archive)
+	srv := releaseServer(t, archive, hex.EncodeToString(sum[:]), privateKey)
+	opts := Options{Repository: "engswee/flashpipe", APIURL: srv.URL, PublicKey: writePublicKey(t, publicKey)}
+
+	release, err := Latest(opts)
+	require.NoError(t, err)
+	assert.Equal(t, "3.8.0", release.Version())
+
+	opts.Channel = ChannelEdge
+	edge, err := Latest(opts)
+	require.NoError(t, err)
+	assert.Equal(t, "3.9.0-rc.1", edge.Version(), "Drafts are skipped")
+
+	executable := filepath.Join(t.TempDir(), "flashpipe")
+	require.NoError(t, os.WriteFile(executable, []byte("old binary"), 0o755))
+	require.NoError(t, Install(release, opts, executable))
+	content, err := os.ReadFile(executable)
+	require.NoError(t, err)
+	assert.Equal(t, "new binary", string(content))
+	_, err = os.Stat(executable + ".old")
+	assert.True(t, os.IsNotExist(err), "Old binary should be removed")
+
+	_, err = Latest(Options{Repository: "engswee/flashpipe", APIURL: srv.URL, Channel: "nightly"})
+	assert.EqualError(t, err, `invalid channel "nightly" (valid channels: stable, edge)`)
+}
+
+func TestInstallRejectsUnverifiedBinaryMock(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	archive := tarGz(t, "flashpipe", []byte("tampered binary"))
+	srv := releaseServer(t, archive, "1234", privateKey)
+	opts := Options{Repository: "engswee/flashpipe", APIURL: srv.URL}
+	release, err := Latest(opts)
+	require.NoError(t, err)
+
+	executable := filepath.Join(t.TempDir(), "flashpipe")
+	require.NoError(t, os.WriteFile(executable, []byte("old binary"), 0o755))
+	assert.ErrorContains(t, Install(release, opts, executable), "expected 1234")
+
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	opts.PublicKey = writePublicKey(t, otherKey)
+	assert.ErrorContains(t, Install(release, opts, executable), "signature of checksums.txt")
+
+	content, err := os.ReadFile(executable)
+	require.NoError(t, err)
+	assert.Equal(t, "old binary", string(content), "Binary should not be replaced")
+}
+
+func TestNewer(t *testing.T) {
+	assert.True(t, Newer("3.8.0", "3.7.0"))
+	assert.True(t, Newer("v3.10.0", "3.9.1"))
+	assert.True(t, Newer("3.8.0", "3.8.0-rc.1"), "Release is newer than its pre-release")
+	assert.True(t, Newer("3.8.0-rc.2", "3.8.0-rc.1"))
+	assert.False(t, Newer("3.7.0", "3.7.0"))
+	assert.False(t, Newer("3.7.0", "3.7.1"))
+}
+
+func TestBinaryAsset(t *testing.T) {
+	assets := []Asset{{Name: "checksums.txt"}, {Name: "flashpipex-linux-amd64.sig"}, {Name: "flashpipex-darwin-arm64"},
+		{Name: "flashpipex-linux-amd64"}, {Name: "flashpipex-windows-amd64.exe"}}
+	assert.Equal(t, "flashpipex-linux-amd64", binaryAsset(assets, "linux", "amd64").Name)
+	assert.Equal(t, "flashpipex-windows-amd64.exe", binaryAsset(assets, "windows", "amd64").Name)
+	assert.Equal(t, "flashpipe_Linux_x86_64.tar.gz", binaryAsset([]Asset{{Name: "flashpipe_Linux_x86_64.tar.gz"}}, "linux", "amd64").Name)
+	assert.Nil(t, binaryAsset(assets, "linux", "arm64"))
+}
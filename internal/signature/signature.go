@@ -0,0 +1,180 @@
+// Package signature verifies detached signatures of configuration files made with cosign, minisign, GPG or
+// plain Ed25519 keys. The format is detected from the public key.
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Signature formats
+const (
+	FormatEd25519  = "ed25519"  // PEM Ed25519 public key, raw or base64 signature, e.g. openssl pkeyutl
+	FormatCosign   = "cosign"   // PEM ECDSA public key, base64 signature of cosign sign-blob
+	FormatMinisign = "minisign" // minisign public key and .minisig signature
+	FormatGPG      = "gpg"      // OpenPGP public key, armored or binary detached signature
+)
+
+// PublicKey verifies signatures in the format of the key
+type PublicKey struct {
+	format     string
+	ed25519    ed25519.PublicKey
+	ecdsa      *ecdsa.PublicKey
+	minisignID []byte
+	keyring    openpgp.EntityList
+}
+
+// ParsePublicKey detects the format of a public key and parses it
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	text := strings.TrimSpace(string(data))
+	k := new(PublicKey)
+	switch {
+	case strings.HasPrefix(text, "-----BEGIN PGP PUBLIC KEY BLOCK-----"):
+		keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GPG public key: %w", err)
+		}
+		k.format = FormatGPG
+		k.keyring = keyring
+	case strings.HasPrefix(text, "-----BEGIN"):
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("public key is not PEM encoded")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		switch key := key.(type) {
+		case ed25519.PublicKey:
+			k.format = FormatEd25519
+			k.ed25519 = key
+		case *ecdsa.PublicKey:
+			k.format = FormatCosign
+			k.ecdsa = key
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T, expected Ed25519 or ECDSA", key)
+		}
+	default:
+		// minisign public keys are a base64 line, optionally after an untrusted comment
+		lines := strings.Split(text, "\n")
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+		if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+			return nil, fmt.Errorf("unsupported public key, expected PEM, minisign or GPG public key")
+		}
+		k.format = FormatMinisign
+		k.minisignID = raw[2:10]
+		k.ed25519 = raw[10:]
+	}
+	return k, nil
+}
+
+// Format returns the signature format of the key
+func (k *PublicKey) Format() string {
+	return k.format
+}
+
+// SignatureExtension returns the extension of signature files of the format, appended to the signed file
+func (k *PublicKey) SignatureExtension() string {
+	if k.format == FormatMinisign {
+		return ".minisig"
+	}
+	return ".sig"
+}
+
+// Verify returns an error if signature is not a valid signature of data for the key
+func (k *PublicKey) Verify(data []byte, signature []byte) error {
+	switch k.format {
+	case FormatGPG:
+		return k.verifyGPG(data, signature)
+	case FormatMinisign:
+		return k.verifyMinisign(data, signature)
+	}
+
+	sig := signature
+	if len(sig) != ed25519.SignatureSize || k.format == FormatCosign {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+		if err != nil {
+			return fmt.Errorf("signature is not base64 encoded: %w", err)
+		}
+		sig = decoded
+	}
+	if k.format == FormatCosign {
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(k.ecdsa, digest[:], sig) {
+			return fmt.Errorf("invalid cosign signature")
+		}
+		return nil
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(k.ed25519, data, sig) {
+		return fmt.Errorf("invalid Ed25519 signature")
+	}
+	return nil
+}
+
+func (k *PublicKey) verifyGPG(data []byte, signature []byte) error {
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN PGP SIGNATURE-----")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	if _, err := check(k.keyring, bytes.NewReader(data), bytes.NewReader(signature), nil); err != nil {
+		return fmt.Errorf("invalid GPG signature: %w", err)
+	}
+	return nil
+}
+
+// verifyMinisign checks the signature of the file and the global signature of the trusted comment. Signatures
+// of prehashed files (ED, the default since minisign 0.10) sign the BLAKE2b-512 hash of the file.
+func (k *PublicKey) verifyMinisign(data []byte, signature []byte) error {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(signature), "\r\n", "\n")), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("invalid minisign signature file, expected 4 lines")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	algorithm, keyID, sig := string(raw[:2]), raw[2:10], raw[10:]
+	if !bytes.Equal(keyID, k.minisignID) {
+		return fmt.Errorf("minisign signature was made with key %X, not with the public key %X", reverse(keyID), reverse(k.minisignID))
+	}
+	message := data
+	switch algorithm {
+	case "Ed":
+	case "ED":
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", algorithm)
+	}
+	if !ed25519.Verify(k.ed25519, message, sig) {
+		return fmt.Errorf("invalid minisign signature")
+	}
+
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || !ed25519.Verify(k.ed25519, slices.Concat(sig, []byte(trustedComment)), globalSig) {
+		return fmt.Errorf("invalid minisign signature of the trusted comment")
+	}
+	return nil
+}
+
+// reverse returns the key ID in the byte order minisign prints it in
+func reverse(id []byte) []byte {
+	r := make([]byte, len(id))
+	for i, b := range id {
+		r[len(id)-1-i] = b
+	}
+	return r
+}
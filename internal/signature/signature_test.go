@@ -0,0 +1,107 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"slices"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+var config = []byte("packages:\n  - integrationSuiteId: Orders\n")
+var tampered = []byte("packages:\n  - integrationSuiteId: Billing\n")
+
+func pemPublicKey(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func assertVerifies(t *testing.T, publicKey []byte, format string, signature []byte) {
+	t.Helper()
+	key, err := ParsePublicKey(publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, format, key.Format())
+	assert.NoError(t, key.Verify(config, signature), "Signed file should verify")
+	assert.Error(t, key.Verify(tampered, signature), "Tampered file should not verify")
+}
+
+func TestEd25519(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sig := ed25519.Sign(privateKey, config)
+
+	assertVerifies(t, pemPublicKey(t, publicKey), FormatEd25519, sig)
+	assertVerifies(t, pemPublicKey(t, publicKey), FormatEd25519, []byte(base64.StdEncoding.EncodeToString(sig)+"\n"))
+}
+
+func TestCosign(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	digest := sha256.Sum256(config)
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+
+	assertVerifies(t, pemPublicKey(t, &privateKey.PublicKey), FormatCosign, []byte(base64.StdEncoding.EncodeToString(sig)))
+}
+
+func TestMinisign(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	publicKeyFile := []byte("untrusted comment: minisign public key 0807060504030201\n" +
+		base64.StdEncoding.EncodeToString(slices.Concat([]byte("Ed"), keyID, publicKey)) + "\n")
+
+	sign := func(algorithm string, message []byte) []byte {
+		sig := ed25519.Sign(privateKey, message)
+		trustedComment := "timestamp:1700000000\tfile:config.yml"
+		globalSig := ed25519.Sign(privateKey, slices.Concat(sig, []byte(trustedComment)))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(slices.Concat([]byte(algorithm), keyID, sig)) + "\n" +
+			"trusted comment: " + trustedComment + "\n" +
+			base64.StdEncoding.EncodeToString(globalSig) + "\n")
+	}
+	hash := blake2b.Sum512(config)
+	assertVerifies(t, publicKeyFile, FormatMinisign, sign("ED", hash[:]))
+	assertVerifies(t, publicKeyFile, FormatMinisign, sign("Ed", config))
+
+	key, err := ParsePublicKey(publicKeyFile)
+	require.NoError(t, err)
+	assert.Equal(t, ".minisig", key.SignatureExtension())
+	forged := bytes.Replace(sign("Ed", config), []byte("timestamp:1700000000"), []byte("timestamp:1800000000"), 1)
+	assert.ErrorContains(t, key.Verify(config, forged), "trusted comment")
+}
+
+func TestGPG(t *testing.T) {
+	entity, err := openpgp.NewEntity("Config Signer", "", "signer@example.com", nil)
+	require.NoError(t, err)
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	var armored, binary bytes.Buffer
+	require.NoError(t, openpgp.ArmoredDetachSign(&armored, entity, bytes.NewReader(config), nil))
+	require.NoError(t, openpgp.DetachSign(&binary, entity, bytes.NewReader(config), nil))
+
+	assertVerifies(t, publicKey.Bytes(), FormatGPG, armored.Bytes())
+	assertVerifies(t, publicKey.Bytes(), FormatGPG, binary.Bytes())
+}
+
+func TestParsePublicKeyUnsupported(t *testing.T) {
+	_, err := ParsePublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
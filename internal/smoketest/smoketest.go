@@ -0,0 +1,319 @@
+// Package smoketest sends the smoke test request of a deployment and checks the response with the assertions
+// of the smoke test: HTTP status, values by JSONPath or XPath, headers and response time.
+package smoketest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/beevik/etree"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultRetryDelay is the time between attempts of smoke tests without retryDelaySeconds
+var DefaultRetryDelay = 10 * time.Second
+
+// snippetLength is the maximum number of bytes of the response body recorded in the result
+const snippetLength = 512
+
+// Result is the outcome of the last attempt of a smoke test, recorded in the report of the run
+type Result struct {
+	URL        string   `json:"url"`
+	Attempts   int      `json:"attempts"`
+	Status     int      `json:"status,omitempty"`
+	DurationMs int64    `json:"durationMs"`
+	Snippet    string   `json:"snippet,omitempty"`  // Beginning of the response body
+	Failures   []string `json:"failures,omitempty"` // Assertions that failed
+}
+
+// Validate returns an error if an assertion of the smoke test does not set exactly one check or has an
+// invalid path
+func Validate(test *models.SmokeTest) error {
+	for i, a := range test.Assertions {
+		checks := 0
+		for _, set := range []bool{a.Status != 0, a.JSONPath != "", a.XPath != "", a.Header != "", a.MaxResponseMs != 0} {
+			if set {
+				checks++
+			}
+		}
+		if checks != 1 {
+			return fmt.Errorf("smoke test assertion %d must set exactly one of status, jsonPath, xpath, header and maxResponseMs", i+1)
+		}
+		if a.JSONPath != "" {
+			if _, err := parseJSONPath(a.JSONPath); err != nil {
+				return fmt.Errorf("smoke test assertion %d: %w", i+1, err)
+			}
+		}
+		if a.XPath != "" {
+			if _, err := etree.CompilePath(strings.TrimSuffix(a.XPath, "/@"+attributeName(a.XPath))); err != nil {
+				return fmt.Errorf("smoke test assertion %d: invalid xpath %s: %w", i+1, a.XPath, err)
+			}
+		}
+	}
+	if test.Retries < 0 || test.RetryDelaySeconds < 0 {
+		return fmt.Errorf("smoke test retries and retryDelaySeconds must not be negative")
+	}
+	return nil
+}
+
+// Run sends the smoke test request to the endpoint and checks the response, retrying failed attempts as
+// configured. The result of the last attempt is returned together with an error if it failed.
+func Run(endpointURL string, test *models.SmokeTest) (*Result, error) {
+	delay := DefaultRetryDelay
+	if test.RetryDelaySeconds > 0 {
+		delay = time.Duration(test.RetryDelaySeconds) * time.Second
+	}
+	var result *Result
+	var err error
+	for attempt := 1; attempt <= test.Retries+1; attempt++ {
+		if attempt > 1 {
+			log.Warn().Msgf("    Smoke test attempt %d/%d failed: %v", attempt-1, test.Retries+1, err)
+			time.Sleep(delay)
+		}
+		result, err = send(endpointURL, test)
+		result.Attempts = attempt
+		if err == nil {
+			return result, nil
+		}
+	}
+	return result, err
+}
+
+// send sends the request once and checks the response
+func send(endpointURL string, test *models.SmokeTest) (*Result, error) {
+	method := test.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	result := &Result{URL: strings.TrimSuffix(endpointURL, "/") + test.Path}
+	req, err := http.NewRequest(method, result.URL, strings.NewReader(test.Body))
+	if err != nil {
+		return result, err
+	}
+	for k, v := range test.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	log.Info().Msgf("    Smoke testing %s %s", method, req.URL)
+	start := time.Now()
+	resp, err := (&http.Client{Timeout: 60 * time.Second}).Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	elapsed := time.Since(start)
+	result.Status = resp.StatusCode
+	result.DurationMs = elapsed.Milliseconds()
+	result.Snippet = snippet(body)
+	if err != nil {
+		return result, err
+	}
+
+	result.Failures = check(test, resp, body, elapsed)
+	if len(result.Failures) > 0 {
+		return result, fmt.Errorf("%s", strings.Join(result.Failures, ", "))
+	}
+	return result, nil
+}
+
+// check returns the failed assertions of the response. The status is compared with expectedStatus unless an
+// assertion checks it.
+func check(test *models.SmokeTest, resp *http.Response, body []byte, elapsed time.Duration) []string {
+	var failures []string
+	statusChecked := false
+	for _, a := range test.Assertions {
+		if a.Status != 0 {
+			statusChecked = true
+		}
+		if failure := checkAssertion(a, resp, body, elapsed); failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+	if !statusChecked {
+		expected := test.ExpectedStatus
+		if expected == 0 {
+			expected = http.StatusOK
+		}
+		if resp.StatusCode != expected {
+			failures = append([]string{fmt.Sprintf("response code = %d, expected %d", resp.StatusCode, expected)}, failures...)
+		}
+	}
+	return failures
+}
+
+// checkAssertion returns the failure of an assertion, empty if it passed
+func checkAssertion(a models.SmokeTestAssertion, resp *http.Response, body []byte, elapsed time.Duration) string {
+	switch {
+	case a.Status != 0:
+		if resp.StatusCode != a.Status {
+			return fmt.Sprintf("response code = %d, expected %d", resp.StatusCode, a.Status)
+		}
+	case a.MaxResponseMs != 0:
+		if elapsed.Milliseconds() > int64(a.MaxResponseMs) {
+			return fmt.Sprintf("response time = %d ms, expected at most %d ms", elapsed.Milliseconds(), a.MaxResponseMs)
+		}
+	case a.Header != "":
+		values, found := resp.Header[http.CanonicalHeaderKey(a.Header)]
+		return compare("header "+a.Header, strings.Join(values, ", "), found, a)
+	case a.JSONPath != "":
+		value, found, err := evaluateJSONPath(body, a.JSONPath)
+		if err != nil {
+			return fmt.Sprintf("jsonPath %s: %v", a.JSONPath, err)
+		}
+		return compare("jsonPath "+a.JSONPath, value, found, a)
+	case a.XPath != "":
+		value, found, err := evaluateXPath(body, a.XPath)
+		if err != nil {
+			return fmt.Sprintf("xpath %s: %v", a.XPath, err)
+		}
+		return compare("xpath "+a.XPath, value, found, a)
+	}
+	return ""
+}
+
+// compare returns the failure of comparing the value found by an assertion with its equals and contains
+func compare(name string, value string, found bool, a models.SmokeTestAssertion) string {
+	switch {
+	case !found:
+		return name + " not found"
+	case a.Equals != "" && value != a.Equals:
+		return fmt.Sprintf("%s = %q, expected %q", name, value, a.Equals)
+	case a.Contains != "" && !strings.Contains(value, a.Contains):
+		return fmt.Sprintf("%s = %q, expected to contain %q", name, value, a.Contains)
+	}
+	return ""
+}
+
+// snippet returns the beginning of the body, cut at a valid UTF-8 character
+func snippet(body []byte) string {
+	if len(body) <= snippetLength {
+		return string(body)
+	}
+	cut := body[:snippetLength]
+	for len(cut) > 0 && !utf8.Valid(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return string(cut) + "…"
+}
+
+// evaluateJSONPath returns the value of the path in the JSON body. Strings are returned as is, other values
+// as JSON.
+func evaluateJSONPath(body []byte, path string) (string, bool, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return "", false, err
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", false, fmt.Errorf("response is not JSON: %w", err)
+	}
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]any:
+			child, found := v[step]
+			if !found {
+				return "", false, nil
+			}
+			value = child
+		case []any:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", false, nil
+			}
+			value = v[i]
+		default:
+			return "", false, nil
+		}
+	}
+	if s, isString := value.(string); isString {
+		return s, true, nil
+	}
+	content, err := json.Marshal(value)
+	return string(content), true, err
+}
+
+// parseJSONPath returns the member names and array indexes of a JSONPath of the form $.a.b[0]['c d']
+func parseJSONPath(path string) ([]string, error) {
+	rest, found := strings.CutPrefix(path, "$")
+	if !found {
+		return nil, fmt.Errorf("invalid jsonPath %s, it must start with $", path)
+	}
+	var steps []string
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid jsonPath %s, empty member name", path)
+			}
+			steps = append(steps, rest[1:end+1])
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonPath %s, missing ']", path)
+			}
+			steps = append(steps, rest[2:end])
+			rest = rest[end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonPath %s, missing ]", path)
+			}
+			if _, err := strconv.Atoi(rest[1:end]); err != nil {
+				return nil, fmt.Errorf("invalid jsonPath %s, invalid index %s", path, rest[1:end])
+			}
+			steps = append(steps, rest[1:end])
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid jsonPath %s at %s", path, rest)
+		}
+	}
+	return steps, nil
+}
+
+// evaluateXPath returns the trimmed text of the first element of the path in the XML body, or the value of
+// the attribute if the path ends with /@name. The path syntax is the one of etree, e.g. //Order[@type='B2B'].
+func evaluateXPath(body []byte, path string) (string, bool, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(body); err != nil {
+		return "", false, fmt.Errorf("response is not XML: %w", err)
+	}
+	attribute := attributeName(path)
+	elementPath, err := etree.CompilePath(strings.TrimSuffix(path, "/@"+attribute))
+	if err != nil {
+		return "", false, err
+	}
+	element := doc.FindElementPath(elementPath)
+	if element == nil {
+		return "", false, nil
+	}
+	if attribute == "" {
+		return strings.TrimSpace(element.Text()), true, nil
+	}
+	attr := element.SelectAttr(attribute)
+	if attr == nil {
+		return "", false, nil
+	}
+	return attr.Value, true, nil
+}
+
+// attributeName returns the name of the attribute a path ends with, empty if it selects an element
+func attributeName(path string) string {
+	i := strings.LastIndex(path, "/@")
+	if i < 0 || strings.ContainsAny(path[i+2:], "/[]") {
+		return ""
+	}
+	return path[i+2:]
+}
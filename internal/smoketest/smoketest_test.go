@@ -0,0 +1,125 @@
+package smoketest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMock(t *testing.T) {
+	t.Setenv("SMOKE_TOKEN", "secret")
+
+	// Set up local server with mock HTTP responses
+	mux := http.NewServeMux()
+	mux.HandleFunc("/http/orders_BG/ping", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	test := &models.SmokeTest{Path: "/ping", Method: http.MethodPost, Body: "{}",
+		Headers: map[string]string{"Authorization": "Bearer $SMOKE_TOKEN"}, ExpectedStatus: http.StatusAccepted}
+	result, err := Run(svr.URL+"/http/orders_BG", test)
+	assert.NoError(t, err)
+	assert.Equal(t, svr.URL+"/http/orders_BG/ping", result.URL)
+	assert.Equal(t, http.StatusAccepted, result.Status)
+
+	test.Headers = nil
+	_, err = Run(svr.URL+"/http/orders_BG", test)
+	assert.EqualError(t, err, "response code = 401, expected 202", "Unexpected response code should be an error")
+}
+
+func TestRunAssertionsMock(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "UP", "items": [{"id": 42, "tags": ["a"]}], "odd key": true}`))
+	})
+	mux.HandleFunc("/xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<Orders><Order id="4711"><Status> OPEN </Status></Order></Orders>`))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	result, err := Run(svr.URL, &models.SmokeTest{Path: "/json", Assertions: []models.SmokeTestAssertion{
+		{Status: http.StatusOK},
+		{JSONPath: "$.status", Equals: "UP"},
+		{JSONPath: "$.items[0].id", Equals: "42"},
+		{JSONPath: "$.items[0].tags", Contains: `"a"`},
+		{JSONPath: "$['odd key']"},
+		{Header: "content-type", Contains: "json"},
+		{MaxResponseMs: 10000},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Contains(t, result.Snippet, `"status": "UP"`)
+
+	_, err = Run(svr.URL, &models.SmokeTest{Path: "/xml", Assertions: []models.SmokeTestAssertion{
+		{XPath: "//Order/Status", Equals: "OPEN"},
+		{XPath: "//Order/@id", Equals: "4711"},
+		{XPath: "/Orders/Order[@id='4711']"},
+	}})
+	assert.NoError(t, err)
+
+	result, err = Run(svr.URL, &models.SmokeTest{Path: "/json", Assertions: []models.SmokeTestAssertion{
+		{Status: http.StatusAccepted},
+		{JSONPath: "$.status", Equals: "DOWN"},
+		{JSONPath: "$.items[3]"},
+		{Header: "X-Missing"},
+		{XPath: "//Status"},
+	}})
+	assert.EqualError(t, err, `response code = 200, expected 202, jsonPath $.status = "UP", expected "DOWN", jsonPath $.items[3] not found, `+
+		"header X-Missing not found, xpath //Status not found")
+	assert.Len(t, result.Failures, 5)
+}
+
+func TestRunRetriesMock(t *testing.T) {
+	DefaultRetryDelay = time.Millisecond
+	defer func() { DefaultRetryDelay = 10 * time.Second }()
+
+	requests := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(strings.Repeat("ü", 300)))
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer svr.Close()
+
+	result, err := Run(svr.URL, &models.SmokeTest{Retries: 1})
+	assert.EqualError(t, err, "response code = 503, expected 200")
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, strings.Repeat("ü", 256)+"…", result.Snippet, "The snippet should be cut at a character")
+
+	result, err = Run(svr.URL, &models.SmokeTest{Retries: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, "OK", result.Snippet)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(&models.SmokeTest{Assertions: []models.SmokeTestAssertion{{JSONPath: "$.a['b c'][0]"}, {XPath: "//a/@b"}}}))
+	assert.EqualError(t, Validate(&models.SmokeTest{Assertions: []models.SmokeTestAssertion{{Status: 200, Header: "ETag"}}}),
+		"smoke test assertion 1 must set exactly one of status, jsonPath, xpath, header and maxResponseMs")
+	assert.EqualError(t, Validate(&models.SmokeTest{Assertions: []models.SmokeTestAssertion{{Equals: "UP"}}}),
+		"smoke test assertion 1 must set exactly one of status, jsonPath, xpath, header and maxResponseMs")
+	assert.EqualError(t, Validate(&models.SmokeTest{Assertions: []models.SmokeTestAssertion{{JSONPath: "status"}}}),
+		"smoke test assertion 1: invalid jsonPath status, it must start with $")
+	assert.EqualError(t, Validate(&models.SmokeTest{Assertions: []models.SmokeTestAssertion{{JSONPath: "$.items[x]"}}}),
+		"smoke test assertion 1: invalid jsonPath $.items[x], invalid index x")
+	assert.Error(t, Validate(&models.SmokeTest{Retries: -1}))
+}
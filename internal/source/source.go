@@ -0,0 +1,262 @@
+// Package source provides the contents of designtime artifacts to the commands that upload them, from local
+// directories, Git repositories, zip files at HTTP(S) or S3 locations and other tenants, so that content can be
+// promoted from tenant to tenant without an intermediate checkout.
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/remote"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/engswee/flashpipe/internal/sync"
+	"github.com/rs/zerolog/log"
+)
+
+// TenantPrefix starts the locations of another tenant, followed by the name its credentials are stored under
+// with login
+const TenantPrefix = "tenant:"
+
+// ArtifactSource provides the contents of designtime artifacts in local directories
+type ArtifactSource interface {
+	// Artifact returns the directory with the contents of the artifact
+	Artifact(id string, artifactType string) (string, error)
+	// Packages returns the directory with a subdirectory per integration package, containing the package details
+	// <id>.json and a subdirectory per artifact, as written by snapshot
+	Packages(includedIds []string, excludedIds []string) (string, error)
+	// Close removes the files fetched by the source
+	Close()
+}
+
+// TenantResolver returns the HTTP executer of the tenant whose credentials are stored under name
+type TenantResolver func(name string) (*httpclnt.HTTPExecuter, error)
+
+// Parse returns the source of location:
+//   - tenant:<name> for the tenant whose credentials are stored under name with login
+//   - git::<url>//<path>?ref=<ref> for a folder of a Git repository
+//   - http(s):// or s3:// locations of zip files, optionally followed by //<path> for a folder within
+//   - local directories otherwise
+func Parse(location string, resolve TenantResolver) (ArtifactSource, error) {
+	switch {
+	case strings.HasPrefix(location, TenantPrefix):
+		name := strings.TrimPrefix(location, TenantPrefix)
+		if name == "" {
+			return nil, fmt.Errorf("tenant name missing in source %s", location)
+		}
+		exe, err := resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		return NewTenant(name, exe), nil
+	case strings.HasPrefix(location, "git::"):
+		return &fetched{location: location, fetch: fetchGit}, nil
+	case remote.IsRemote(location):
+		return &fetched{location: location, fetch: fetchZip}, nil
+	default:
+		return NewDirectory(location), nil
+	}
+}
+
+// IsDirectory returns true if location is a local directory instead of a remote source
+func IsDirectory(location string) bool {
+	return !strings.HasPrefix(location, TenantPrefix) && !remote.IsRemote(location)
+}
+
+type directory struct {
+	path string
+}
+
+// NewDirectory returns the source of a local directory containing the artifact or the packages
+func NewDirectory(path string) ArtifactSource {
+	return &directory{path: path}
+}
+
+func (d *directory) Artifact(string, string) (string, error) {
+	return d.path, nil
+}
+
+func (d *directory) Packages([]string, []string) (string, error) {
+	return d.path, nil
+}
+
+func (d *directory) Close() {}
+
+// fetched is the source of a Git repository or zip file, fetched on first use
+type fetched struct {
+	location string
+	fetch    func(location string) (string, func(), error)
+	path     string
+	cleanup  func()
+}
+
+func (f *fetched) get() (string, error) {
+	if f.path != "" {
+		return f.path, nil
+	}
+	path, cleanup, err := f.fetch(f.location)
+	if err != nil {
+		return "", err
+	}
+	f.path, f.cleanup = path, cleanup
+	return path, nil
+}
+
+func (f *fetched) Artifact(string, string) (string, error) {
+	return f.get()
+}
+
+func (f *fetched) Packages([]string, []string) (string, error) {
+	return f.get()
+}
+
+func (f *fetched) Close() {
+	if f.cleanup != nil {
+		f.cleanup()
+	}
+}
+
+func fetchGit(location string) (string, func(), error) {
+	return remote.Fetch(location, remote.Options{})
+}
+
+// fetchZip downloads the zip file of location <url>//<path> and returns the path within its extracted content
+func fetchZip(location string) (string, func(), error) {
+	zipURL, subPath := splitSubPath(location)
+	dir, err := os.MkdirTemp("", "flashpipe-source-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	log.Info().Msgf("Fetching %s", zipURL)
+	zipFile := filepath.Join(dir, "source.zip")
+	if err := remote.Download(zipURL, zipFile); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	contentDir := filepath.Join(dir, "content")
+	if err := file.UnzipSource(zipFile, contentDir); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to extract %s: %w", zipURL, err)
+	}
+	path := filepath.Join(contentDir, filepath.FromSlash(subPath))
+	if !strings.HasPrefix(path, contentDir) {
+		cleanup()
+		return "", nil, fmt.Errorf("path %s is outside of the zip file", subPath)
+	}
+	if _, err := os.Stat(path); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("path %s not found in %s", subPath, zipURL)
+	}
+	return path, cleanup, nil
+}
+
+// splitSubPath splits <url>//<path> into its parts. The path separator // is the first one after the :// of the
+// URL scheme.
+func splitSubPath(location string) (string, string) {
+	start := 0
+	if i := strings.Index(location, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if i := strings.Index(location[start:], "//"); i >= 0 {
+		return location[:start+i], location[start+i+len("//"):]
+	}
+	return location, ""
+}
+
+type tenant struct {
+	name string
+	exe  *httpclnt.HTTPExecuter
+	dir  string
+}
+
+// NewTenant returns the source of the tenant of exe. Artifacts and packages are downloaded to a temporary
+// directory.
+func NewTenant(name string, exe *httpclnt.HTTPExecuter) ArtifactSource {
+	return &tenant{name: name, exe: exe}
+}
+
+func (t *tenant) tempDir() (string, error) {
+	if t.dir == "" {
+		dir, err := os.MkdirTemp("", "flashpipe-source-")
+		if err != nil {
+			return "", err
+		}
+		t.dir = dir
+	}
+	return t.dir, nil
+}
+
+func (t *tenant) Artifact(id string, artifactType string) (string, error) {
+	dt := api.NewDesigntimeArtifact(artifactType, t.exe)
+	if dt == nil {
+		return "", fmt.Errorf("invalid artifact type %v", artifactType)
+	}
+	dir, err := t.tempDir()
+	if err != nil {
+		return "", err
+	}
+	log.Info().Msgf("Downloading %v artifact %v from tenant %v", artifactType, id, t.name)
+	zipFile := filepath.Join(dir, id+".zip")
+	if err := dt.Download(zipFile, id); err != nil {
+		return "", err
+	}
+	artifactDir := filepath.Join(dir, "artifacts", id)
+	if err := file.UnzipSource(zipFile, artifactDir); err != nil {
+		return "", err
+	}
+	return artifactDir, nil
+}
+
+// Packages downloads the editable packages of the tenant like snapshot, skipping artifacts in draft version
+func (t *tenant) Packages(includedIds []string, excludedIds []string) (string, error) {
+	dir, err := t.tempDir()
+	if err != nil {
+		return "", err
+	}
+	log.Info().Msgf("Downloading integration packages from tenant %v", t.name)
+	ids, err := api.NewIntegrationPackage(t.exe).GetPackagesList()
+	if err != nil {
+		return "", err
+	}
+	packagesDir := filepath.Join(dir, "packages")
+	if err := os.MkdirAll(packagesDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	synchroniser := sync.New(t.exe)
+	for _, id := range ids {
+		if str.FilterIDs(id, includedIds, excludedIds) {
+			continue
+		}
+		packageData, readOnly, _, err := synchroniser.VerifyDownloadablePackage(id)
+		if err != nil {
+			return "", err
+		}
+		if readOnly {
+			continue
+		}
+		workDir := filepath.Join(dir, "work", id)
+		packageDir := filepath.Join(packagesDir, id)
+		if err := os.MkdirAll(packageDir, os.ModePerm); err != nil {
+			return "", err
+		}
+		if err := synchroniser.PackageToGit(packageData, id, workDir, packageDir); err != nil {
+			return "", err
+		}
+		if err := synchroniser.ArtifactsToGit(id, workDir, packageDir, nil, nil, "SKIP", "ID", nil); err != nil {
+			return "", err
+		}
+	}
+	return packagesDir, nil
+}
+
+func (t *tenant) Close() {
+	if t.dir != "" {
+		_ = os.RemoveAll(t.dir)
+	}
+}
@@ -0,0 +1,132 @@
+package source
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipContent(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestSplitSubPath(t *testing.T) {
+	tests := []struct {
+		location, url, subPath string
+	}{
+		{"https://host/archive.zip", "https://host/archive.zip", ""},
+		{"https://host/archive.zip//repo-main/packages", "https://host/archive.zip", "repo-main/packages"},
+		{"s3://bucket/content/Orders.zip//Orders", "s3://bucket/content/Orders.zip", "Orders"},
+	}
+	for _, tt := range tests {
+		url, subPath := splitSubPath(tt.location)
+		assert.Equal(t, []string{tt.url, tt.subPath}, []string{url, subPath}, tt.location)
+	}
+}
+
+func TestParse(t *testing.T) {
+	resolved := ""
+	resolve := func(name string) (*httpclnt.HTTPExecuter, error) {
+		resolved = name
+		return httpclnt.New("", "", "", "", "dummy", "dummy", "localhost", "http", 80, true), nil
+	}
+
+	src, err := Parse("tenant:dev", resolve)
+	require.NoError(t, err)
+	assert.IsType(t, &tenant{}, src)
+	assert.Equal(t, "dev", resolved)
+
+	_, err = Parse("tenant:", resolve)
+	assert.EqualError(t, err, "tenant name missing in source tenant:")
+
+	src, err = Parse("git::https://host/org/repo.git//Orders?ref=main", resolve)
+	require.NoError(t, err)
+	assert.IsType(t, &fetched{}, src)
+
+	src, err = Parse("./packages", resolve)
+	require.NoError(t, err)
+	dir, err := src.Packages(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "./packages", dir)
+
+	assert.True(t, IsDirectory("./packages"))
+	assert.False(t, IsDirectory("tenant:dev"))
+	assert.False(t, IsDirectory("https://host/archive.zip"))
+}
+
+func TestZipSource(t *testing.T) {
+	content := zipContent(t, map[string]string{
+		"repo-main/Orders/Orders.json":                     `{"d":{"Id":"Orders"}}`,
+		"repo-main/Orders/Order_Sync/META-INF/MANIFEST.MF": "Bundle-SymbolicName: Order_Sync\n",
+	})
+	requests := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/archive.zip", r.URL.Path)
+		w.Write(content)
+	}))
+	defer svr.Close()
+
+	src, err := Parse(svr.URL+"/archive.zip//repo-main", nil)
+	require.NoError(t, err)
+	dir, err := src.Packages(nil, nil)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "Orders", "Orders.json"))
+	assert.FileExists(t, filepath.Join(dir, "Orders", "Order_Sync", "META-INF", "MANIFEST.MF"))
+
+	// The zip file is only downloaded once
+	artifactDir, err := src.Artifact("Orders", "Integration")
+	require.NoError(t, err)
+	assert.Equal(t, dir, artifactDir)
+	assert.Equal(t, 1, requests)
+
+	src.Close()
+	assert.NoDirExists(t, dir)
+
+	src, err = Parse(svr.URL+"/archive.zip//missing", nil)
+	require.NoError(t, err)
+	_, err = src.Packages(nil, nil)
+	assert.ErrorContains(t, err, "path missing not found")
+}
+
+func TestTenantArtifact(t *testing.T) {
+	content := zipContent(t, map[string]string{
+		"META-INF/MANIFEST.MF":                   "Bundle-SymbolicName: Order_Sync\n",
+		"src/main/resources/parameters.prop":     "Receiver=https://qa.example.com\n",
+		"src/main/resources/scenarioflows/a.xml": "<xml/>",
+	})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/IntegrationDesigntimeArtifacts(Id='Order_Sync',Version='active')/$value", r.URL.Path)
+		w.Write(content)
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true)
+
+	src := NewTenant("dev", exe)
+	dir, err := src.Artifact("Order_Sync", "Integration")
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "src", "main", "resources", "parameters.prop"))
+	require.NoError(t, err)
+	assert.Equal(t, "Receiver=https://qa.example.com\n", string(data))
+
+	src.Close()
+	assert.NoDirExists(t, dir)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/file"
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/engswee/flashpipe/internal/str"
 	"github.com/go-errors/errors"
 	"github.com/rs/zerolog/log"
@@ -91,7 +92,7 @@ func (s *APIProxyGitSynchroniser) Exec(request Request) error {
 
 	// Process through the artifacts
 	for _, artifact := range artifacts {
-		log.Info().Msg("---------------------------------------------------------------------------------")
+		logger.Rule(logger.SeparatorDashed)
 		log.Info().Msgf("📢 Begin processing for APIProxy %v", artifact.Name)
 
 		// Filter in/out artifacts
@@ -132,7 +133,7 @@ func (s *APIProxyGitSynchroniser) Exec(request Request) error {
 		}
 	}
 
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msgf("🏆 Completed processing of APIProxies")
 
 	return nil
@@ -179,7 +180,7 @@ func (s *APIProxyTenantSynchroniser) Exec(request Request) error {
 			artifactDirFound = true
 			gitArtifactDir := fmt.Sprintf("%v/%v", baseSourceDir, artifactId)
 
-			log.Info().Msg("---------------------------------------------------------------------------------")
+			logger.Rule(logger.SeparatorDashed)
 			log.Info().Msgf("Processing directory %v", gitArtifactDir)
 
 			// Filter in/out artifacts
@@ -229,7 +230,7 @@ func (s *APIProxyTenantSynchroniser) Exec(request Request) error {
 	if !artifactDirFound {
 		log.Warn().Msgf("No directory with APIProxy contents found in %v", baseSourceDir)
 	}
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msgf("🏆 Completed processing of APIProxies")
 	return nil
 }
@@ -314,7 +315,7 @@ func (s *APIProductGitSynchroniser) Exec(request Request) error {
 
 	// Process through the artifacts
 	for _, artifact := range artifacts {
-		log.Info().Msg("---------------------------------------------------------------------------------")
+		logger.Rule(logger.SeparatorDashed)
 		log.Info().Msgf("📢 Begin processing for APIProduct %v", artifact.Name)
 
 		// Filter in/out artifacts
@@ -355,7 +356,7 @@ func (s *APIProductGitSynchroniser) Exec(request Request) error {
 		}
 	}
 
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msgf("🏆 Completed processing of APIProducts")
 
 	return nil
@@ -401,7 +402,7 @@ func (s *APIProductTenantSynchroniser) Exec(request Request) error {
 			artifactFileFound = true
 			gitArtifactPath := fmt.Sprintf("%v/%v", baseSourceDir, artifactFileName)
 
-			log.Info().Msg("---------------------------------------------------------------------------------")
+			logger.Rule(logger.SeparatorDashed)
 			log.Info().Msgf("Processing file %v", gitArtifactPath)
 
 			// Strip .json from the file name
@@ -434,7 +435,7 @@ func (s *APIProductTenantSynchroniser) Exec(request Request) error {
 	if !artifactFileFound {
 		log.Warn().Msgf("No directory with APIProduct contents found in %v", baseSourceDir)
 	}
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msgf("🏆 Completed processing of APIProducts")
 	return nil
 }
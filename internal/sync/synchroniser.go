@@ -9,10 +9,12 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/file"
 	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/engswee/flashpipe/internal/str"
 	"github.com/go-errors/errors"
 	"github.com/magiconair/properties"
@@ -20,8 +22,9 @@ import (
 )
 
 type Synchroniser struct {
-	exe *httpclnt.HTTPExecuter
-	ip  *api.IntegrationPackage
+	exe         *httpclnt.HTTPExecuter
+	ip          *api.IntegrationPackage
+	versionBump *VersionBump
 }
 
 func New(exe *httpclnt.HTTPExecuter) *Synchroniser {
@@ -31,6 +34,11 @@ func New(exe *httpclnt.HTTPExecuter) *Synchroniser {
 	return s
 }
 
+// SetVersionBump enables the version bump of changed artifacts uploaded to the tenant
+func (s *Synchroniser) SetVersionBump(vb *VersionBump) {
+	s.versionBump = vb
+}
+
 func (s *Synchroniser) PackageToGit(packageDataFromTenant *api.PackageSingleData, packageId string, workDir string, artifactsDir string) error {
 	// Create temp directory in working dir
 	err := os.MkdirAll(workDir+"/from_tenant", os.ModePerm)
@@ -126,7 +134,7 @@ func (s *Synchroniser) ArtifactsToGit(packageId string, workDir string, artifact
 
 	// Process through the artifacts
 	for _, artifact := range filtered {
-		log.Info().Msg("---------------------------------------------------------------------------------")
+		logger.Rule(logger.SeparatorDashed)
 		log.Info().Msgf("📢 Begin processing for artifact %v", artifact.Id)
 		// Check if artifact is in draft version
 		if artifact.IsDraft {
@@ -208,7 +216,7 @@ func (s *Synchroniser) ArtifactsToGit(packageId string, workDir string, artifact
 		return errors.Wrap(err, 0)
 	}
 
-	log.Info().Msg("---------------------------------------------------------------------------------")
+	logger.Rule(logger.SeparatorDashed)
 	log.Info().Msgf("🏆 Completed processing of artifacts in integration package %v", packageId)
 	return nil
 }
@@ -294,7 +302,7 @@ func (s *Synchroniser) ArtifactsToTenant(packageId string, workDir string, artif
 		if entry.IsDir() && file.Exists(manifestPath) {
 			artifactDirFound = true
 			artifactDir := fmt.Sprintf("%v/%v", baseSourceDir, entry.Name())
-			log.Info().Msg("---------------------------------------------------------------------------------")
+			logger.Rule(logger.SeparatorDashed)
 			log.Info().Msgf("Processing directory %v", artifactDir)
 			paramFile := fmt.Sprintf("%v/src/main/resouces/parameters/prop", artifactDir)
 
@@ -360,6 +368,7 @@ func GetManifestHeaders(manifestPath string) (textproto.MIMEHeader, error) {
 
 func (s *Synchroniser) SingleArtifactToTenant(artifactId, artifactName, artifactType, packageId, artifactDir, workDir, parametersFile string, scriptMap []string) error {
 	dt := api.NewDesigntimeArtifact(artifactType, s.exe)
+	start := time.Now()
 
 	exists, err := artifactExists(artifactId, artifactType, packageId, dt, s.ip)
 	if err != nil {
@@ -385,7 +394,7 @@ func (s *Synchroniser) SingleArtifactToTenant(artifactId, artifactName, artifact
 			return err
 		}
 
-		log.Info().Msg("🏆 Designtime artifact created successfully")
+		log.Info().Msgf("🏆 Designtime artifact created successfully%v", logger.Took(time.Since(start)))
 	} else {
 		log.Info().Msg("Checking if designtime artifact needs to be updated")
 
@@ -402,6 +411,12 @@ func (s *Synchroniser) SingleArtifactToTenant(artifactId, artifactName, artifact
 
 		if changesFound {
 			log.Info().Msg("Changes found in designtime artifact. Designtime artifact will be updated in CPI tenant")
+			if s.versionBump != nil {
+				err = s.versionBump.bumpVersion(artifactId, artifactDir)
+				if err != nil {
+					return err
+				}
+			}
 			err = prepareUploadDir(workDir, artifactDir, dt)
 			if err != nil {
 				return err
@@ -428,7 +443,7 @@ func (s *Synchroniser) SingleArtifactToTenant(artifactId, artifactName, artifact
 				}
 			}
 
-			log.Info().Msg("🏆 Designtime artifact updated successfully")
+			log.Info().Msgf("🏆 Designtime artifact updated successfully%v", logger.Took(time.Since(start)))
 		} else {
 			log.Info().Msg("🏆 No changes detected. Designtime artifact does not need to be updated")
 		}
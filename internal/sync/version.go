@@ -0,0 +1,164 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/repo"
+	"github.com/go-errors/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Levels of a version bump
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// VersionBump increments the Bundle-Version of changed artifacts before they are uploaded, according to the
+// messages of the commits since the last change of the version
+type VersionBump struct {
+	GitRepoDir string // Repository the commit messages are read from
+	Changelog  bool   // Add an entry to CHANGELOG.md in the directory of the artifact
+}
+
+// breakingPattern matches Conventional Commits with a ! after the type or a BREAKING CHANGE footer
+var breakingPattern = regexp.MustCompile(`(?m)^\w+(\([^)]*\))?!:|^BREAKING[ -]CHANGE:`)
+
+// featurePattern matches Conventional Commits of type feat
+var featurePattern = regexp.MustCompile(`^feat(\([^)]*\))?:`)
+
+// manifestVersionPattern matches the Bundle-Version header of MANIFEST.MF
+var manifestVersionPattern = regexp.MustCompile(`(?m)^Bundle-Version:[ \t]*\S+`)
+
+// BumpLevel returns the level of the version bump for Conventional Commits messages: major for breaking
+// changes, minor for features and patch otherwise
+func BumpLevel(messages []string) string {
+	level := BumpPatch
+	for _, message := range messages {
+		if breakingPattern.MatchString(message) {
+			return BumpMajor
+		}
+		if featurePattern.MatchString(message) {
+			level = BumpMinor
+		}
+	}
+	return level
+}
+
+// BumpVersion increments a major.minor.patch version, resetting the lower parts
+func BumpVersion(version string, level string) (string, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("version %q is not in the format major.minor.patch", version)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return "", fmt.Errorf("version %q is not in the format major.minor.patch", version)
+		}
+		numbers[i] = n
+	}
+	switch level {
+	case BumpMajor:
+		numbers = []int{numbers[0] + 1, 0, 0}
+	case BumpMinor:
+		numbers = []int{numbers[0], numbers[1] + 1, 0}
+	default:
+		numbers[2]++
+	}
+	return fmt.Sprintf("%d.%d.%d", numbers[0], numbers[1], numbers[2]), nil
+}
+
+// bumpVersion updates the Bundle-Version of the artifact in its source directory, and adds the commit
+// messages to its changelog
+func (vb *VersionBump) bumpVersion(artifactId string, artifactDir string) error {
+	manifestPath := filepath.Join(artifactDir, "META-INF", "MANIFEST.MF")
+	headers, err := file.ReadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	current := headers["Bundle-Version"]
+
+	dir, err := filepath.Rel(vb.GitRepoDir, artifactDir)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	dir = filepath.ToSlash(dir)
+	messages, err := repo.CommitMessages(vb.GitRepoDir, dir, dir+"/META-INF/MANIFEST.MF")
+	if err != nil {
+		return fmt.Errorf("failed to read commit messages of %v: %w", dir, err)
+	}
+	level := BumpLevel(messages)
+	next, err := BumpVersion(current, level)
+	if err != nil {
+		return err
+	}
+	if err = setManifestVersion(manifestPath, next); err != nil {
+		return err
+	}
+	log.Info().Msgf("Bumped version of artifact %v from %v to %v (%v, %d commits)", artifactId, current, next, level, len(messages))
+
+	if vb.Changelog {
+		return addChangelogEntry(filepath.Join(artifactDir, "CHANGELOG.md"), next, messages, time.Now())
+	}
+	return nil
+}
+
+// setManifestVersion replaces the Bundle-Version of a MANIFEST.MF, keeping the other headers as they are
+func setManifestVersion(manifestPath string, version string) error {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	if !manifestVersionPattern.Match(content) {
+		return fmt.Errorf("no Bundle-Version in %v", manifestPath)
+	}
+	content = manifestVersionPattern.ReplaceAll(content, []byte("Bundle-Version: "+version))
+	if err = os.WriteFile(manifestPath, content, 0644); err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
+
+// addChangelogEntry adds a section for the version with the first line of each commit message to the top of
+// the changelog
+func addChangelogEntry(changelogPath string, version string, messages []string, date time.Time) error {
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "## %v (%v)\n\n", version, date.Format("2006-01-02"))
+	for _, message := range messages {
+		fmt.Fprintf(&entry, "- %v\n", strings.SplitN(message, "\n", 2)[0])
+	}
+	if len(messages) == 0 {
+		entry.WriteString("- No commit messages found\n")
+	}
+	entry.WriteString("\n")
+
+	existing := []byte("# Changelog\n\n")
+	if file.Exists(changelogPath) {
+		content, err := os.ReadFile(changelogPath)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		existing = content
+	}
+	// Keep the title of the changelog above the new entry
+	var content string
+	if title, rest, found := strings.Cut(string(existing), "\n\n"); found && strings.HasPrefix(title, "# ") {
+		content = title + "\n\n" + entry.String() + rest
+	} else {
+		content = entry.String() + string(existing)
+	}
+	if err := os.WriteFile(changelogPath, []byte(content), 0644); err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
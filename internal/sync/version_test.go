@@ -0,0 +1,68 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpLevel(t *testing.T) {
+	assert.Equal(t, BumpPatch, BumpLevel([]string{"fix: correct mapping", "Update script"}))
+	assert.Equal(t, BumpPatch, BumpLevel(nil), "No commits should be a patch")
+	assert.Equal(t, BumpMinor, BumpLevel([]string{"fix: correct mapping", "feat(orders): add retry"}))
+	assert.Equal(t, BumpMajor, BumpLevel([]string{"feat!: remove SOAP sender"}))
+	assert.Equal(t, BumpMajor, BumpLevel([]string{"refactor: new payload\n\nBREAKING CHANGE: field renamed"}))
+}
+
+func TestBumpVersion(t *testing.T) {
+	for _, tc := range []struct{ version, level, want string }{
+		{"1.2.3", BumpPatch, "1.2.4"},
+		{"1.2.3", BumpMinor, "1.3.0"},
+		{"1.2.3", BumpMajor, "2.0.0"},
+	} {
+		got, err := BumpVersion(tc.version, tc.level)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%s bump of %s", tc.level, tc.version)
+	}
+	_, err := BumpVersion("1.0", BumpPatch)
+	assert.Error(t, err, "Version without patch should be an error")
+}
+
+func TestVersionBumpFromCommits(t *testing.T) {
+	repoDir := t.TempDir()
+	artifactDir := filepath.Join(repoDir, "Orders", "OrderFlow")
+	r, err := git.PlainInit(repoDir, false)
+	require.NoError(t, err)
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	commit := func(path, content, message string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, path)), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, path), []byte(content), 0644))
+		_, err := w.Add(path)
+		require.NoError(t, err)
+		_, err = w.Commit(message, &git.CommitOptions{Author: &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()}})
+		require.NoError(t, err)
+	}
+	commit("Orders/OrderFlow/META-INF/MANIFEST.MF", "Manifest-Version: 1.0\r\nBundle-SymbolicName: OrderFlow\r\nBundle-Version: 1.0.3\r\n", "Release 1.0.3")
+	commit("Orders/OrderFlow/src/main/resources/script.groovy", "v1", "fix: handle empty order")
+	commit("Orders/Other/script.groovy", "other", "feat!: unrelated artifact")
+	commit("Orders/OrderFlow/src/main/resources/script.groovy", "v2", "feat: add retry\n\nDetails")
+
+	vb := &VersionBump{GitRepoDir: repoDir, Changelog: true}
+	require.NoError(t, vb.bumpVersion("OrderFlow", artifactDir))
+
+	manifest, err := os.ReadFile(filepath.Join(artifactDir, "META-INF", "MANIFEST.MF"))
+	require.NoError(t, err)
+	assert.Equal(t, "Manifest-Version: 1.0\r\nBundle-SymbolicName: OrderFlow\r\nBundle-Version: 1.1.0\r\n", string(manifest),
+		"Feature since the last version should be a minor bump, other headers should be kept")
+
+	changelog, err := os.ReadFile(filepath.Join(artifactDir, "CHANGELOG.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Changelog\n\n## 1.1.0 ("+time.Now().Format("2006-01-02")+")\n\n- feat: add retry\n- fix: handle empty order\n\n", string(changelog))
+}
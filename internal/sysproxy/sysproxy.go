@@ -0,0 +1,274 @@
+// Package sysproxy discovers the proxy configured in the operating system: the Internet settings of the current
+// user on Windows, the network settings on macOS (scutil) and the GNOME proxy settings (gsettings) on Linux.
+// Proxy environment variables take precedence over the system proxy.
+package sysproxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Settings are the proxy settings of the operating system
+type Settings struct {
+	HTTP   *url.URL // Proxy of http requests, and of https requests without HTTPS proxy
+	HTTPS  *url.URL // Proxy of https requests
+	Bypass []string // Hosts reached without proxy, exact or with wildcards, e.g. *.corp.example.com or <local>
+}
+
+// Discoverer reads the proxy settings of the operating system
+type Discoverer interface {
+	// Discover returns the proxy settings, nil if no proxy is configured
+	Discover() (*Settings, error)
+}
+
+// Default is the discoverer of the operating system
+var Default Discoverer = platformDiscoverer{}
+
+// proxy is the proxy of all requests, set by Install
+var (
+	mu    sync.RWMutex
+	proxy = http.ProxyFromEnvironment
+)
+
+// Proxy returns the proxy of the request, nil for a direct connection. Before Install it only uses the proxy
+// environment variables.
+func Proxy(req *http.Request) (*url.URL, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return proxy(req)
+}
+
+// Install discovers the system proxy with Default and sends the requests of the default HTTP transport, used by
+// all clients without their own transport, through Proxy. The system proxy is only discovered and used if no proxy
+// environment variable is set. It returns the discovered settings, nil without system proxy.
+func Install() (*Settings, error) {
+	var settings *Settings
+	if !environmentConfigured() {
+		var err error
+		if settings, err = Default.Discover(); err != nil {
+			return nil, err
+		}
+	}
+	mu.Lock()
+	proxy = func(req *http.Request) (*url.URL, error) {
+		if settings == nil {
+			return http.ProxyFromEnvironment(req)
+		}
+		return settings.Proxy(req)
+	}
+	mu.Unlock()
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.Proxy = Proxy
+	}
+	return settings, nil
+}
+
+// environmentConfigured returns whether a proxy environment variable is set
+func environmentConfigured() bool {
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Proxy returns the proxy of the request according to the settings, nil for a direct connection
+func (s *Settings) Proxy(req *http.Request) (*url.URL, error) {
+	if s.bypassed(req.URL.Hostname()) {
+		return nil, nil
+	}
+	if req.URL.Scheme == "https" && s.HTTPS != nil {
+		return s.HTTPS, nil
+	}
+	return s.HTTP, nil
+}
+
+// bypassed returns whether the host is reached without proxy. Loopback addresses are never sent through the proxy,
+// and <local> matches the hosts without domain.
+func (s *Settings) bypassed(host string) bool {
+	host = strings.ToLower(host)
+	if host == "localhost" || net.ParseIP(host) != nil && net.ParseIP(host).IsLoopback() {
+		return true
+	}
+	for _, pattern := range s.Bypass {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "<local>":
+			if !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.HasPrefix(pattern, "."):
+			if strings.HasSuffix(host, pattern) || host == pattern[1:] {
+				return true
+			}
+		default:
+			if matched, _ := path.Match(pattern, host); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// proxyURL returns the URL of a proxy given as host:port or URL, nil for an empty value
+func proxyURL(value string) (*url.URL, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q", value)
+	}
+	return u, nil
+}
+
+// parseWindows returns the settings of the Internet settings of Windows: ProxyServer is either host:port for all
+// protocols or a list like http=host:port;https=host:port, and ProxyOverride a list of hosts separated by
+// semicolons
+func parseWindows(enabled bool, server string, override string) (*Settings, error) {
+	if !enabled || strings.TrimSpace(server) == "" {
+		return nil, nil
+	}
+	settings := &Settings{}
+	var err error
+	if !strings.Contains(server, "=") {
+		if settings.HTTP, err = proxyURL(server); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, entry := range strings.Split(server, ";") {
+			protocol, value, _ := strings.Cut(entry, "=")
+			switch strings.ToLower(strings.TrimSpace(protocol)) {
+			case "http":
+				settings.HTTP, err = proxyURL(value)
+			case "https":
+				settings.HTTPS, err = proxyURL(value)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, host := range strings.Split(override, ";") {
+		if host = strings.TrimSpace(host); host != "" {
+			settings.Bypass = append(settings.Bypass, host)
+		}
+	}
+	return settings, nil
+}
+
+// parseScutil returns the settings of the output of scutil --proxy on macOS
+func parseScutil(output string) (*Settings, error) {
+	values := map[string]string{}
+	var exceptions []string
+	inExceptions := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "ExceptionsList"):
+			inExceptions = true
+		case inExceptions && line == "}":
+			inExceptions = false
+		case inExceptions:
+			if _, host, ok := strings.Cut(line, " : "); ok {
+				exceptions = append(exceptions, host)
+			}
+		default:
+			if key, value, ok := strings.Cut(line, " : "); ok {
+				values[key] = value
+			}
+		}
+	}
+
+	settings := &Settings{Bypass: exceptions}
+	if values["ExcludeSimpleHostnames"] == "1" {
+		settings.Bypass = append(settings.Bypass, "<local>")
+	}
+	for _, protocol := range []string{"HTTP", "HTTPS"} {
+		if values[protocol+"Enable"] != "1" || values[protocol+"Proxy"] == "" {
+			continue
+		}
+		u, err := proxyURL(net.JoinHostPort(values[protocol+"Proxy"], values[protocol+"Port"]))
+		if err != nil {
+			return nil, err
+		}
+		if protocol == "HTTP" {
+			settings.HTTP = u
+		} else {
+			settings.HTTPS = u
+		}
+	}
+	if settings.HTTP == nil && settings.HTTPS == nil {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+// parseGnome returns the settings of the GNOME proxy settings as printed by gsettings, e.g. 'manual' for the mode
+// and ['localhost', '*.corp'] for the ignored hosts
+func parseGnome(mode string, httpHost string, httpPort string, httpsHost string, httpsPort string, ignoreHosts string) (*Settings, error) {
+	if unquote(mode) != "manual" {
+		return nil, nil
+	}
+	settings := &Settings{}
+	for _, p := range []struct {
+		host, port string
+		target     **url.URL
+	}{{httpHost, httpPort, &settings.HTTP}, {httpsHost, httpsPort, &settings.HTTPS}} {
+		host := unquote(p.host)
+		port, _ := strconv.Atoi(strings.TrimSpace(p.port))
+		if host == "" || port == 0 {
+			continue
+		}
+		u, err := proxyURL(net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return nil, err
+		}
+		*p.target = u
+	}
+	list := strings.Trim(strings.TrimSpace(ignoreHosts), "[]")
+	for _, host := range strings.Split(list, ",") {
+		if host = unquote(host); host != "" {
+			settings.Bypass = append(settings.Bypass, host)
+		}
+	}
+	if settings.HTTP == nil && settings.HTTPS == nil {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+// unquote removes the quotes of a string printed by gsettings
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), "'")
+}
+
+// run runs a command of the operating system and returns its output
+func run(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", err
+		}
+		return "", fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
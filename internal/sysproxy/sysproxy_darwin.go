@@ -0,0 +1,13 @@
+package sysproxy
+
+// platformDiscoverer reads the proxy of the network settings with scutil. Automatic proxy configuration (PAC) is not
+// evaluated.
+type platformDiscoverer struct{}
+
+func (platformDiscoverer) Discover() (*Settings, error) {
+	out, err := run("scutil", "--proxy")
+	if err != nil {
+		return nil, err
+	}
+	return parseScutil(out)
+}
@@ -0,0 +1,40 @@
+package sysproxy
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// platformDiscoverer reads the manual proxy of the GNOME proxy settings with gsettings. Without gsettings, e.g. on
+// build servers, there is no system proxy and only the proxy environment variables apply.
+type platformDiscoverer struct{}
+
+func (platformDiscoverer) Discover() (*Settings, error) {
+	get := func(schema, key string) (string, error) {
+		return run("gsettings", "get", schema, key)
+	}
+	mode, err := get("org.gnome.system.proxy", "mode")
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if unquote(mode) != "manual" {
+		return nil, nil
+	}
+
+	var values [5]string
+	for i, setting := range [][2]string{
+		{"org.gnome.system.proxy.http", "host"},
+		{"org.gnome.system.proxy.http", "port"},
+		{"org.gnome.system.proxy.https", "host"},
+		{"org.gnome.system.proxy.https", "port"},
+		{"org.gnome.system.proxy", "ignore-hosts"},
+	} {
+		if values[i], err = get(setting[0], setting[1]); err != nil {
+			return nil, err
+		}
+	}
+	return parseGnome(mode, values[0], values[1], values[2], values[3], values[4])
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !linux && !windows
+
+package sysproxy
+
+// platformDiscoverer reports no system proxy, only the proxy environment variables apply
+type platformDiscoverer struct{}
+
+func (platformDiscoverer) Discover() (*Settings, error) {
+	return nil, nil
+}
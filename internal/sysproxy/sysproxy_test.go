@@ -0,0 +1,77 @@
+package sysproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func proxyOf(t *testing.T, s *Settings, target string) string {
+	t.Helper()
+	u, err := s.Proxy(httptest.NewRequest(http.MethodGet, target, nil))
+	require.NoError(t, err)
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func TestParseWindows(t *testing.T) {
+	s, err := parseWindows(true, "proxy.corp.example.com:8080", "*.corp.example.com;<local>")
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.corp.example.com:8080", proxyOf(t, s, "https://tenant.it-cpi018.cfapps.eu10-003.hana.ondemand.com/api/v1"))
+	assert.Equal(t, "", proxyOf(t, s, "https://git.corp.example.com/"), "Override should bypass the proxy")
+	assert.Equal(t, "", proxyOf(t, s, "http://intranet/"), "<local> should bypass hosts without domain")
+	assert.Equal(t, "", proxyOf(t, s, "http://127.0.0.1:8080/"), "Loopback should bypass the proxy")
+
+	s, err = parseWindows(true, "http=http-proxy:3128;https=https-proxy:3129", "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://https-proxy:3129", proxyOf(t, s, "https://example.com/"))
+	assert.Equal(t, "http://http-proxy:3128", proxyOf(t, s, "http://example.com/"))
+
+	s, err = parseWindows(false, "proxy:8080", "")
+	require.NoError(t, err)
+	assert.Nil(t, s, "Disabled proxy should not be used")
+}
+
+func TestParseScutil(t *testing.T) {
+	s, err := parseScutil(`<dictionary> {
+  ExceptionsList : <array> {
+    0 : *.local
+    1 : .corp.example.com
+  }
+  ExcludeSimpleHostnames : 1
+  HTTPEnable : 1
+  HTTPPort : 8080
+  HTTPProxy : proxy.corp.example.com
+  HTTPSEnable : 1
+  HTTPSPort : 8443
+  HTTPSProxy : proxy.corp.example.com
+}
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.corp.example.com:8443", proxyOf(t, s, "https://example.com/"))
+	assert.Equal(t, "http://proxy.corp.example.com:8080", proxyOf(t, s, "http://example.com/"))
+	assert.Equal(t, "", proxyOf(t, s, "https://printer.local/"))
+	assert.Equal(t, "", proxyOf(t, s, "https://git.corp.example.com/"))
+	assert.Equal(t, "", proxyOf(t, s, "https://corp.example.com/"))
+	assert.Equal(t, "", proxyOf(t, s, "http://intranet/"))
+
+	s, err = parseScutil("<dictionary> {\n  HTTPEnable : 0\n}\n")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestParseGnome(t *testing.T) {
+	s, err := parseGnome("'manual'\n", "'proxy.corp.example.com'\n", "3128\n", "''\n", "0\n", "['localhost', '127.0.0.0/8', '*.corp.example.com']\n")
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.corp.example.com:3128", proxyOf(t, s, "https://example.com/"), "HTTP proxy should be used without HTTPS proxy")
+	assert.Equal(t, "", proxyOf(t, s, "https://git.corp.example.com/"))
+
+	s, err = parseGnome("'none'\n", "", "", "", "", "")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
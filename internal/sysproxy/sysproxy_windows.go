@@ -0,0 +1,40 @@
+package sysproxy
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// internetSettings is the registry key of the Internet settings of the current user, as set in the proxy settings
+// of Windows
+const internetSettings = `Software\Microsoft\Windows\CurrentVersion\Internet Settings`
+
+// platformDiscoverer reads the manual proxy of the Internet settings of the current user. Automatic configuration
+// scripts (PAC) are not evaluated.
+type platformDiscoverer struct{}
+
+func (platformDiscoverer) Discover() (*Settings, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, internetSettings, registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+
+	enable, _, err := key.GetIntegerValue("ProxyEnable")
+	if err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return nil, err
+	}
+	server, _, err := key.GetStringValue("ProxyServer")
+	if err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return nil, err
+	}
+	override, _, err := key.GetStringValue("ProxyOverride")
+	if err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return nil, err
+	}
+	return parseWindows(enable == 1, server, override)
+}
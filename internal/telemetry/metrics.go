@@ -0,0 +1,127 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+	typeSummary = "summary"
+)
+
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples map[string]*sample
+}
+
+type sample struct {
+	labels string
+	value  float64
+	count  uint64
+}
+
+var registry = struct {
+	mu       sync.Mutex
+	families map[string]*metricFamily
+}{families: map[string]*metricFamily{}}
+
+// IncCounter adds delta to the counter with the given labels, passed as key/value pairs
+func IncCounter(name string, help string, delta float64, labels ...string) {
+	s := getSample(name, help, typeCounter, labels)
+	s.value += delta
+	registry.mu.Unlock()
+}
+
+// SetGauge sets the gauge with the given labels, passed as key/value pairs
+func SetGauge(name string, help string, value float64, labels ...string) {
+	s := getSample(name, help, typeGauge, labels)
+	s.value = value
+	registry.mu.Unlock()
+}
+
+// Observe adds an observation (e.g. a latency or batch size) to the summary with the given labels
+func Observe(name string, help string, value float64, labels ...string) {
+	s := getSample(name, help, typeSummary, labels)
+	s.value += value
+	s.count++
+	registry.mu.Unlock()
+}
+
+// getSample returns the sample with the registry lock held, the caller must unlock it
+func getSample(name string, help string, kind string, labels []string) *sample {
+	registry.mu.Lock()
+	family, ok := registry.families[name]
+	if !ok {
+		family = &metricFamily{name: name, help: help, kind: kind, samples: map[string]*sample{}}
+		registry.families[name] = family
+	}
+	key := formatLabels(labels)
+	s, ok := family.samples[key]
+	if !ok {
+		s = &sample{labels: key}
+		family.samples[key] = s
+	}
+	return s
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%v=%q", labels[i], labels[i+1]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format
+func WritePrometheus(w io.Writer) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	names := make([]string, 0, len(registry.families))
+	for name := range registry.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := registry.families[name]
+		if _, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", name, family.help, name, family.kind); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(family.samples))
+		for key := range family.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := family.samples[key]
+			var err error
+			if family.kind == typeSummary {
+				_, err = fmt.Fprintf(w, "%v_sum%v %g\n%v_count%v %d\n", name, key, s.value, name, key, s.count)
+			} else {
+				_, err = fmt.Fprintf(w, "%v%v %g\n", name, key, s.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reset removes all recorded metrics
+func Reset() {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.families = map[string]*metricFamily{}
+}
@@ -0,0 +1,208 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Options configures the exporters for metrics and traces. Exporters with an empty setting are disabled.
+type Options struct {
+	// MetricsTextfile is written in Prometheus text format, e.g. for the node_exporter textfile collector
+	MetricsTextfile string
+	// MetricsPushgateway is the base URL of a Prometheus Pushgateway
+	MetricsPushgateway string
+	// OTLPEndpoint is the base URL of an OTLP/HTTP receiver, e.g. http://localhost:4318
+	OTLPEndpoint string
+	// ServiceName is reported as service.name resource attribute of the spans
+	ServiceName string
+}
+
+var (
+	optionsMu sync.Mutex
+	options   Options
+	client    = &http.Client{Timeout: 10 * time.Second}
+)
+
+// Init sets the exporters. Tracing is only enabled when an OTLP endpoint is set.
+func Init(opts Options) {
+	if opts.ServiceName == "" {
+		opts.ServiceName = "flashpipe"
+	}
+	optionsMu.Lock()
+	options = opts
+	optionsMu.Unlock()
+
+	tracer.mu.Lock()
+	tracer.enabled = opts.OTLPEndpoint != ""
+	tracer.mu.Unlock()
+}
+
+// Flush exports the current metrics and all finished spans to the configured exporters
+func Flush() error {
+	optionsMu.Lock()
+	opts := options
+	optionsMu.Unlock()
+
+	var errs []string
+	if opts.MetricsTextfile != "" {
+		if err := writeTextfile(opts.MetricsTextfile); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if opts.MetricsPushgateway != "" {
+		if err := pushMetrics(opts.MetricsPushgateway); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if opts.OTLPEndpoint != "" {
+		if err := exportSpans(opts.OTLPEndpoint, opts.ServiceName, takeFinishedSpans()); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("telemetry export failed: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func writeTextfile(path string) error {
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that collectors never read a partial file
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func pushMetrics(gatewayURL string) error {
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf); err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/flashpipe"
+	log.Debug().Msgf("Pushing metrics to %v", url)
+	req, err := http.NewRequest(http.MethodPut, url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	return send(req, "Push metrics")
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+func toOTLPAttributes(attributes map[string]string) []otlpAttribute {
+	list := make([]otlpAttribute, 0, len(attributes))
+	for k, v := range attributes {
+		a := otlpAttribute{Key: k}
+		a.Value.StringValue = v
+		list = append(list, a)
+	}
+	return list
+}
+
+func exportSpans(endpoint string, serviceName string, spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              1, // SPAN_KIND_INTERNAL
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        toOTLPAttributes(s.attributes),
+		}
+		if strings.HasPrefix(s.name, "HTTP ") {
+			o.Kind = 3 // SPAN_KIND_CLIENT
+		}
+		if s.err != nil {
+			o.Status.Code = 2 // STATUS_CODE_ERROR
+			o.Status.Message = s.err.Error()
+		} else {
+			o.Status.Code = 1 // STATUS_CODE_OK
+		}
+		otlpSpans = append(otlpSpans, o)
+	}
+
+	payload := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toOTLPAttributes(map[string]string{"service.name": serviceName}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": "github.com/engswee/flashpipe"},
+						"spans": otlpSpans,
+					},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	log.Debug().Msgf("Exporting %d span(s) to %v", len(otlpSpans), url)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return send(req, "Export spans")
+}
+
+func send(req *http.Request, callType string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%v call failed with response code = %d", callType, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	Reset()
+	IncCounter("flashpipe_test_total", "Test counter.", 1, "result", "success")
+	IncCounter("flashpipe_test_total", "Test counter.", 2, "result", "success")
+	Observe("flashpipe_test_seconds", "Test summary.", 0.5)
+	Observe("flashpipe_test_seconds", "Test summary.", 1.5)
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePrometheus(&buf))
+	expected := `# HELP flashpipe_test_seconds Test summary.
+# TYPE flashpipe_test_seconds summary
+flashpipe_test_seconds_sum 2
+flashpipe_test_seconds_count 2
+# HELP flashpipe_test_total Test counter.
+# TYPE flashpipe_test_total counter
+flashpipe_test_total{result="success"} 3
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestFlushMock(t *testing.T) {
+	Reset()
+	var pushed string
+	var exported map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics/job/flashpipe", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		pushed = string(body)
+	})
+	mux.HandleFunc("/v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&exported)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	Init(Options{MetricsPushgateway: svr.URL, OTLPEndpoint: svr.URL})
+	defer Init(Options{})
+
+	StartRun("configure")
+	span := StartSpan("artifact MyFlow")
+	span.End(errors.New("failed"))
+	EndRun(nil)
+	IncCounter("flashpipe_test_total", "Test counter.", 1)
+
+	require.NoError(t, Flush())
+	assert.Contains(t, pushed, "flashpipe_test_total 1")
+
+	spans := exported["resourceSpans"].([]interface{})[0].(map[string]interface{})["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	require.Equal(t, 2, len(spans), "Incorrect number of spans")
+	child := spans[0].(map[string]interface{})
+	root := spans[1].(map[string]interface{})
+	assert.Equal(t, root["spanId"], child["parentSpanId"], "Artifact span should be child of run span")
+	assert.Equal(t, root["traceId"], child["traceId"], "Spans should belong to the same trace")
+	assert.Equal(t, float64(2), child["status"].(map[string]interface{})["code"], "Failed span should have error status")
+}
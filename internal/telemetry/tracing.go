@@ -0,0 +1,129 @@
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Span is a unit of work exported as an OpenTelemetry span. All methods are safe to call on a
+// nil Span, which is returned when tracing is disabled.
+type Span struct {
+	traceID    string
+	spanID     string
+	parentID   string
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	err        error
+}
+
+var tracer = struct {
+	mu       sync.Mutex
+	enabled  bool
+	runs     []*Span
+	finished []*Span
+}{}
+
+// StartRun starts the root span of a new trace. Spans started with StartSpan until the matching
+// EndRun are children of this span.
+func StartRun(name string, attributes ...string) *Span {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if !tracer.enabled {
+		return nil
+	}
+	span := newSpan(randomHex(16), "", name, attributes)
+	tracer.runs = append(tracer.runs, span)
+	return span
+}
+
+// EndRun ends the root span started by the last StartRun
+func EndRun(err error) {
+	tracer.mu.Lock()
+	if len(tracer.runs) == 0 {
+		tracer.mu.Unlock()
+		return
+	}
+	span := tracer.runs[len(tracer.runs)-1]
+	tracer.runs = tracer.runs[:len(tracer.runs)-1]
+	tracer.mu.Unlock()
+	span.End(err)
+}
+
+// StartSpan starts a span as child of the current run
+func StartSpan(name string, attributes ...string) *Span {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if !tracer.enabled {
+		return nil
+	}
+	traceID, parentID := randomHex(16), ""
+	if len(tracer.runs) > 0 {
+		run := tracer.runs[len(tracer.runs)-1]
+		traceID, parentID = run.traceID, run.spanID
+	}
+	return newSpan(traceID, parentID, name, attributes)
+}
+
+func newSpan(traceID string, parentID string, name string, attributes []string) *Span {
+	span := &Span{
+		traceID:    traceID,
+		spanID:     randomHex(8),
+		parentID:   parentID,
+		name:       name,
+		start:      time.Now(),
+		attributes: map[string]string{},
+	}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		span.attributes[attributes[i]] = attributes[i+1]
+	}
+	return span
+}
+
+// SetAttribute adds an attribute to the span
+func (s *Span) SetAttribute(key string, value string) {
+	if s == nil {
+		return
+	}
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// TraceParent returns the W3C traceparent header value of the span
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%v-%v-01", s.traceID, s.spanID)
+}
+
+// End ends the span, a non-nil error marks the span as failed
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	s.end = time.Now()
+	s.err = err
+	tracer.finished = append(tracer.finished, s)
+}
+
+func takeFinishedSpans() []*Span {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	spans := tracer.finished
+	tracer.finished = nil
+	return spans
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
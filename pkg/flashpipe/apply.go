@@ -0,0 +1,180 @@
+package flashpipe
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ApplyOptions controls how Apply processes a configuration
+type ApplyOptions struct {
+	// PackageFilter and ArtifactFilter restrict processing to the given IDs (without deployment prefix)
+	PackageFilter  []string
+	ArtifactFilter []string
+	// DryRun only counts what would be changed, without calling the tenant
+	DryRun bool
+	// DeployRetries is the number of deployment status checks, defaults to 5
+	DeployRetries int
+	// DeployDelay is the delay between deployment status checks, defaults to 15 seconds
+	DeployDelay time.Duration
+}
+
+func (o ApplyOptions) withDefaults() ApplyOptions {
+	if o.DeployRetries == 0 {
+		o.DeployRetries = 5
+	}
+	if o.DeployDelay == 0 {
+		o.DeployDelay = 15 * time.Second
+	}
+	return o
+}
+
+// Apply updates the configuration parameters of all artifacts in cfg and deploys the artifacts
+// flagged with deploy. Parameters that do not exist in an artifact are counted as failed. Hooks,
+// valueFrom references and the delete mode of parameters are not supported by Apply. An error is
+// returned when any artifact or deployment failed, together with the stats of the run. Apply stops
+// when ctx is done.
+func Apply(ctx context.Context, tenant Tenant, cfg *ConfigureConfig, opts ApplyOptions) (*Stats, error) {
+	opts = opts.withDefaults()
+	stats := &Stats{}
+	start := time.Now()
+	defer func() { stats.Timings.Total = time.Since(start) }()
+	type deployment struct{ artifactID, artifactType string }
+	var deployments []deployment
+
+	for _, pkg := range cfg.Packages {
+		if len(opts.PackageFilter) > 0 && !slices.Contains(opts.PackageFilter, pkg.ID) {
+			continue
+		}
+		stats.PackagesProcessed.Inc()
+		packageHasError := false
+
+		for _, artifact := range pkg.Artifacts {
+			if len(opts.ArtifactFilter) > 0 && !slices.Contains(opts.ArtifactFilter, artifact.ID) {
+				continue
+			}
+			stats.ArtifactsProcessed.Inc()
+			artifactID := cfg.DeploymentPrefix + artifact.ID
+			version := artifact.Version
+			if version == "" {
+				version = "active"
+			}
+
+			if err := configureArtifact(ctx, tenant, artifactID, version, artifact.Parameters, opts.DryRun, stats); err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return stats, ctxErr
+				}
+				stats.ArtifactsFailed.Inc()
+				packageHasError = true
+				continue
+			}
+			stats.ArtifactsConfigured.Inc()
+
+			if artifact.Deploy || pkg.Deploy {
+				stats.DeploymentTasksQueued.Inc()
+				artifactType := artifact.Type
+				if resolved, err := resolveArtifactType(cfg.TypeAliases, artifact.Type); err == nil {
+					artifactType = resolved
+				}
+				deployments = append(deployments, deployment{artifactID, artifactType})
+			}
+		}
+		if packageHasError {
+			stats.PackagesWithErrors.Inc()
+		}
+	}
+
+	stats.SetConfigureDuration(time.Since(start))
+
+	if !opts.DryRun {
+		deployStart := time.Now()
+		defer func() { stats.Timings.Deploy = time.Since(deployStart) }()
+		for _, d := range deployments {
+			if err := Deploy(ctx, tenant, d.artifactType, d.artifactID, opts); err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return stats, ctxErr
+				}
+				stats.DeploymentTasksFailed.Inc()
+				continue
+			}
+			stats.DeploymentTasksSuccessful.Inc()
+			stats.ArtifactsDeployed.Inc()
+		}
+	}
+
+	if stats.ArtifactsFailed.Value() > 0 || stats.DeploymentTasksFailed.Value() > 0 {
+		return stats, fmt.Errorf("%d artifact(s) failed to be configured, %d deployment(s) failed", stats.ArtifactsFailed.Value(), stats.DeploymentTasksFailed.Value())
+	}
+	return stats, nil
+}
+
+func configureArtifact(ctx context.Context, tenant Tenant, artifactID string, version string,
+	parameters []ConfigurationParameter, dryRun bool, stats *Stats) error {
+
+	if dryRun {
+		stats.ParametersUpdated.Add(len(parameters))
+		return nil
+	}
+	if len(parameters) == 0 {
+		return nil
+	}
+
+	current, err := tenant.Parameters(ctx, artifactID, version)
+	if err != nil {
+		return err
+	}
+	updates := map[string]string{}
+	failed := 0
+	for _, p := range parameters {
+		value, exists := current[p.Key]
+		if !exists && p.Optional {
+			continue
+		}
+		if !exists || p.Mode == ParameterModeDelete {
+			stats.ParametersFailed.Inc()
+			failed++
+			continue
+		}
+		if newValue, update := ParameterValue(p, value, ""); update {
+			updates[p.Key] = newValue
+		}
+	}
+	if len(updates) > 0 {
+		if err := tenant.UpdateParameters(ctx, artifactID, version, updates); err != nil {
+			stats.ParametersFailed.Add(len(updates))
+			return err
+		}
+		stats.IndividualRequestsUsed.Add(len(updates))
+		stats.ParametersUpdated.Add(len(updates))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d parameter(s) not found in artifact %s or with unsupported mode %s", failed, artifactID, ParameterModeDelete)
+	}
+	return nil
+}
+
+// Deploy deploys an artifact and polls its runtime status until it is started, using the
+// DeployRetries and DeployDelay of opts
+func Deploy(ctx context.Context, tenant Tenant, artifactType string, artifactID string, opts ApplyOptions) error {
+	opts = opts.withDefaults()
+	if err := tenant.Deploy(ctx, artifactType, artifactID); err != nil {
+		return err
+	}
+	for i := 0; i < opts.DeployRetries; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.DeployDelay):
+		}
+		version, status, err := tenant.Status(ctx, artifactID)
+		if err != nil || version == "NOT_DEPLOYED" || status == "STARTING" {
+			continue
+		}
+		if status == "STARTED" {
+			return nil
+		}
+		return fmt.Errorf("deployment of %s failed with status %s", artifactID, status)
+	}
+	return fmt.Errorf("deployment status check of %s timed out after %d attempts", artifactID, opts.DeployRetries)
+}
@@ -0,0 +1,66 @@
+package flashpipe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTenant struct {
+	parameters map[string]map[string]string
+	deployed   []string
+}
+
+func (m *mockTenant) Parameters(_ context.Context, artifactID string, _ string) (map[string]string, error) {
+	return m.parameters[artifactID], nil
+}
+
+func (m *mockTenant) UpdateParameters(_ context.Context, artifactID string, _ string, parameters map[string]string) error {
+	for k, v := range parameters {
+		m.parameters[artifactID][k] = v
+	}
+	return nil
+}
+
+func (m *mockTenant) Deploy(_ context.Context, _ string, artifactID string) error {
+	m.deployed = append(m.deployed, artifactID)
+	return nil
+}
+
+func (m *mockTenant) Status(_ context.Context, _ string) (string, string, error) {
+	return "1.0.0", "STARTED", nil
+}
+
+func TestApply(t *testing.T) {
+	tenant := &mockTenant{parameters: map[string]map[string]string{
+		"DEV_FlowA": {"Host": "old"},
+		"DEV_FlowB": {"Host": "old"},
+	}}
+	cfg := &ConfigureConfig{
+		DeploymentPrefix: "DEV_",
+		Packages: []ConfigurePackage{{
+			ID: "Package",
+			Artifacts: []ConfigureArtifact{
+				{ID: "FlowA", Type: "Integration", Deploy: true, Parameters: []ConfigurationParameter{{Key: "Host", Value: "new"}}},
+				{ID: "FlowB", Type: "Integration", Deploy: true, Parameters: []ConfigurationParameter{{Key: "Port", Value: "443"}}},
+			},
+		}},
+	}
+
+	stats, err := Apply(context.Background(), tenant, cfg, ApplyOptions{DeployDelay: time.Millisecond})
+	require.Error(t, err, "Missing parameter should fail the artifact")
+	assert.Equal(t, "new", tenant.parameters["DEV_FlowA"]["Host"], "Parameter not updated")
+	assert.Equal(t, []string{"DEV_FlowA"}, tenant.deployed, "Only the configured artifact should be deployed")
+	assert.Equal(t, 1, stats.ArtifactsConfigured.Value(), "Incorrect number of configured artifacts")
+	assert.Equal(t, 1, stats.ArtifactsFailed.Value(), "Incorrect number of failed artifacts")
+	assert.Equal(t, 1, stats.ParametersFailed.Value(), "Incorrect number of failed parameters")
+	assert.Equal(t, 1, stats.DeploymentTasksSuccessful.Value(), "Incorrect number of deployments")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Apply(ctx, &mockTenant{parameters: map[string]map[string]string{}}, cfg, ApplyOptions{})
+	assert.ErrorIs(t, err, context.Canceled, "Cancelled context should stop Apply")
+}
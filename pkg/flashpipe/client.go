@@ -0,0 +1,108 @@
+package flashpipe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+)
+
+// Tenant is the set of tenant operations used by Apply. It is implemented by Client and can be
+// substituted, e.g. in tests of tools that embed flashpipe.
+type Tenant interface {
+	// Parameters returns the configuration parameters of a designtime artifact by key
+	Parameters(ctx context.Context, artifactID string, version string) (map[string]string, error)
+	// UpdateParameters updates configuration parameters of a designtime artifact
+	UpdateParameters(ctx context.Context, artifactID string, version string, parameters map[string]string) error
+	// Deploy triggers the deployment of a designtime artifact
+	Deploy(ctx context.Context, artifactType string, artifactID string) error
+	// Status returns the version and status of a runtime artifact, version is NOT_DEPLOYED if it is not deployed
+	Status(ctx context.Context, artifactID string) (version string, status string, err error)
+}
+
+// ServiceDetails are the connection details of a tenant. Either UserID and Password (Basic Auth),
+// or OAuthHost, ClientID and ClientSecret (OAuth client credentials) must be set.
+type ServiceDetails struct {
+	Host         string
+	UserID       string
+	Password     string
+	OAuthHost    string
+	OAuthPath    string // Defaults to /oauth/token
+	ClientID     string
+	ClientSecret string
+}
+
+// Client performs operations on a Cloud Integration tenant
+type Client struct {
+	exe *httpclnt.HTTPExecuter
+}
+
+// NewClient returns a Client for the tenant
+func NewClient(details ServiceDetails) *Client {
+	oauthPath := details.OAuthPath
+	if details.OAuthHost != "" && oauthPath == "" {
+		oauthPath = "/oauth/token"
+	}
+	return newClient(api.InitHTTPExecuter(&api.ServiceDetails{
+		Host:              details.Host,
+		Userid:            details.UserID,
+		Password:          details.Password,
+		OauthHost:         details.OAuthHost,
+		OauthPath:         oauthPath,
+		OauthClientId:     details.ClientID,
+		OauthClientSecret: details.ClientSecret,
+	}))
+}
+
+func newClient(exe *httpclnt.HTTPExecuter) *Client {
+	c := new(Client)
+	c.exe = exe
+	return c
+}
+
+func (c *Client) Parameters(ctx context.Context, artifactID string, version string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := api.NewConfigurationService(c.exe).Get(artifactID, version)
+	if err != nil {
+		return nil, err
+	}
+	parameters := map[string]string{}
+	for _, p := range data.Root.Results {
+		parameters[p.ParameterKey] = p.ParameterValue
+	}
+	return parameters, nil
+}
+
+func (c *Client) UpdateParameters(ctx context.Context, artifactID string, version string, parameters map[string]string) error {
+	configuration := api.NewConfigurationService(c.exe)
+	for key, value := range parameters {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := configuration.Update(artifactID, version, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) Deploy(ctx context.Context, artifactType string, artifactID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dt := api.NewDesigntimeArtifact(artifactType, c.exe)
+	if dt == nil {
+		return fmt.Errorf("unsupported artifact type: %s (valid types: Integration, MessageMapping, ScriptCollection, ValueMapping)", artifactType)
+	}
+	return dt.Deploy(artifactID)
+}
+
+func (c *Client) Status(ctx context.Context, artifactID string) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	return api.NewRuntime(c.exe).Get(artifactID)
+}
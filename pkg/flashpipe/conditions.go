@@ -0,0 +1,77 @@
+package flashpipe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"text/template"
+)
+
+// ApplyConditions returns a copy of the configuration without the packages and artifacts whose when condition is
+// false for the tenant. Conditions are the pipelines of Go templates, like the condition of {{ if }}, with the
+// functions of --template and the fields .Environment and .Values of cfg.Conditions, .Target and .Host. Packages
+// left without artifacts are skipped as well.
+func ApplyConditions(cfg *ConfigureConfig, target string, host string) (*ConfigureConfig, error) {
+	data := map[string]interface{}{"Environment": "", "Values": map[string]interface{}{}, "Target": target, "Host": host}
+	if cfg.Conditions != nil {
+		data["Environment"] = cfg.Conditions.Environment
+		if cfg.Conditions.Values != nil {
+			data["Values"] = cfg.Conditions.Values
+		}
+	}
+
+	result := *cfg
+	result.Packages = nil
+	var errs []error
+	for _, pkg := range cfg.Packages {
+		include, err := evaluateCondition(pkg.When, data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("when of package %s: %w", pkg.ID, err))
+			continue
+		}
+		if !include {
+			continue
+		}
+		artifacts := pkg.Artifacts
+		pkg.Artifacts = nil
+		for _, artifact := range artifacts {
+			include, err := evaluateCondition(artifact.When, data)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("when of artifact %s: %w", artifact.ID, err))
+			} else if include {
+				pkg.Artifacts = append(pkg.Artifacts, artifact)
+			}
+		}
+		if len(pkg.Artifacts) > 0 || len(artifacts) == 0 {
+			result.Packages = append(result.Packages, pkg)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// evaluateCondition returns true for an empty condition or a condition with a value that is not empty
+func evaluateCondition(condition string, data map[string]interface{}) (bool, error) {
+	if condition == "" {
+		return true, nil
+	}
+	tmpl, err := template.New("when").Option("missingkey=error").Funcs(templateFuncs).Parse("{{ if " + condition + " }}true{{ end }}")
+	if err != nil {
+		return false, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return false, err
+	}
+	return buf.String() == "true", nil
+}
+
+// HasConditions returns true if a package or artifact of the configuration has a when condition
+func HasConditions(cfg *ConfigureConfig) bool {
+	return slices.ContainsFunc(cfg.Packages, func(pkg ConfigurePackage) bool {
+		return pkg.When != "" || slices.ContainsFunc(pkg.Artifacts, func(a ConfigureArtifact) bool { return a.When != "" })
+	})
+}
@@ -0,0 +1,61 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConditions(t *testing.T) {
+	cfg, err := ParseConfig("config.yml", []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+      - artifactId: Orders_Archive
+        when: eq .Environment "prod"
+      - artifactId: Orders_Audit
+        when: .Values.audit
+  - integrationSuiteId: Sales_EU
+    when: contains "eu10" .Host
+    artifacts:
+      - artifactId: Invoices
+  - integrationSuiteId: Testing
+    artifacts:
+      - artifactId: Mock
+        when: ne .Target "prod"
+`), nil)
+	require.NoError(t, err)
+	assert.True(t, HasConditions(cfg))
+
+	ids := func(cfg *ConfigureConfig) []string {
+		var result []string
+		for _, pkg := range cfg.Packages {
+			for _, artifact := range pkg.Artifacts {
+				result = append(result, pkg.ID+"/"+artifact.ID)
+			}
+		}
+		return result
+	}
+
+	cfg.Conditions = &Conditions{Environment: "prod", Values: map[string]interface{}{"audit": false}}
+	prod, err := ApplyConditions(cfg, "prod", "prod.eu10.hana.ondemand.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Sales/Orders", "Sales/Orders_Archive", "Sales_EU/Invoices"}, ids(prod))
+	assert.Len(t, prod.Packages, 2, "Packages left without artifacts are skipped")
+	assert.Len(t, cfg.Packages[0].Artifacts, 3, "The configuration is not changed")
+
+	cfg.Conditions = &Conditions{Environment: "dev", Values: map[string]interface{}{"audit": true}}
+	dev, err := ApplyConditions(cfg, "", "dev.us10.hana.ondemand.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Sales/Orders", "Sales/Orders_Audit", "Testing/Mock"}, ids(dev))
+
+	cfg.Conditions = nil
+	_, err = ApplyConditions(cfg, "", "")
+	assert.ErrorContains(t, err, "when of artifact Orders_Audit:", "Missing values are an error")
+
+	cfg.Packages[0].Artifacts[0].When = "eq .Environment"
+	cfg.Conditions = &Conditions{Values: map[string]interface{}{"audit": true}}
+	_, err = ApplyConditions(cfg, "", "")
+	assert.ErrorContains(t, err, "when of artifact Orders:")
+}
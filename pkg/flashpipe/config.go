@@ -0,0 +1,743 @@
+package flashpipe
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is a configuration file loaded by LoadConfigFiles
+type ConfigFile struct {
+	Config   *ConfigureConfig
+	Source   string
+	FileName string
+
+	dir string // Directory the files referenced by the configuration are resolved against
+}
+
+// OrderFiles are the names of the file that lists the configuration files and folders of a folder in the order
+// they are loaded, like the resources of a kustomization
+var OrderFiles = []string{"flashpipe-order.yml", "flashpipe-order.yaml"}
+
+// LoadOptions are the options of LoadConfigFilesWithOptions
+type LoadOptions struct {
+	// Recursive loads the configuration files of subfolders as well. Folders starting with a dot, e.g. .git,
+	// are skipped.
+	Recursive bool
+	// Template renders the files with Go text/template and the sprig-compatible functions of templateFuncs before
+	// they are parsed, e.g. to generate repetitive artifacts with range. .Values is empty without values.
+	Template bool
+	// NoFileReferences rejects parameters with fromFile, parametersFrom and partner tables from files instead of
+	// reading them, for configurations from untrusted sources, e.g. the requests of serve.
+	NoFileReferences bool
+	// VerifyFile is called with the path of every file referenced by a configuration, with fromFile,
+	// parametersFrom or a partner table, before it is read, e.g. to check its signature. An error aborts loading.
+	VerifyFile func(path string) error
+}
+
+// verify checks a referenced file with VerifyFile, if set
+func (o LoadOptions) verify(path string) error {
+	if o.VerifyFile == nil {
+		return nil
+	}
+	return o.VerifyFile(path)
+}
+
+// LoadConfigFiles loads a configuration file, or all *.yml, *.yaml, *.json and *.toml files of a folder. When values
+// are provided, {{ .Values.<key> }} references in the files are resolved.
+func LoadConfigFiles(path string, values map[string]interface{}) ([]*ConfigFile, error) {
+	return LoadConfigFilesWithOptions(path, values, LoadOptions{})
+}
+
+// LoadConfigFilesWithOptions loads a configuration file or the files of a folder like LoadConfigFiles. The files of
+// a folder are loaded in the order of its order file, otherwise sorted by name, followed by the files of the
+// subfolders sorted by name if Recursive is set.
+func LoadConfigFilesWithOptions(path string, values map[string]interface{}, opts LoadOptions) ([]*ConfigFile, error) {
+	// Check if path is a file or directory
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access path: %w", err)
+	}
+
+	if info.IsDir() {
+		return loadConfigFilesFromFolder(path, values, opts)
+	}
+	return loadConfigFile(path, values, opts)
+}
+
+func loadConfigFile(path string, values map[string]interface{}, opts LoadOptions) ([]*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	cfg, err := parseConfig(path, data, values, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*ConfigFile{
+		{
+			Config:   cfg,
+			Source:   path,
+			FileName: filepath.Base(path),
+		},
+	}, nil
+}
+
+func loadConfigFilesFromFolder(folderPath string, values map[string]interface{}, opts LoadOptions) ([]*ConfigFile, error) {
+	var configFiles []*ConfigFile
+
+	// Files are read with absolute paths, which Go prefixes with \\?\ on Windows when they exceed MAX_PATH
+	root, err := filepath.Abs(folderPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access path: %w", err)
+	}
+	paths, err := folderConfigFiles(root, root, opts.Recursive, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		// Files of subfolders are named by their path relative to the folder
+		rel, _ := filepath.Rel(root, path)
+		name := filepath.ToSlash(rel)
+		filePath := filepath.Join(folderPath, rel)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Warn().Msgf("Failed to read config file %s: %v", name, err)
+			continue
+		}
+
+		// Template errors (e.g. missing values) are not skipped as the file would be applied incompletely
+		rendered, err := renderTemplate(filePath, data, values, opts.Template)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := DecodeConfig(name, rendered)
+		if err != nil {
+			log.Warn().Msgf("Failed to parse config file %s: %v", name, err)
+			continue
+		}
+		setValueSources(cfg, filePath, data, rendered)
+
+		configFiles = append(configFiles, &ConfigFile{
+			Config:   cfg,
+			Source:   filePath,
+			FileName: name,
+			dir:      filepath.Dir(path),
+		})
+	}
+
+	if len(configFiles) == 0 {
+		return nil, fmt.Errorf("no valid configuration files found in folder: %s", folderPath)
+	}
+	if err := resolveConfigFiles(configFiles, opts); err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Loaded %d configuration file(s) from folder", len(configFiles))
+	return configFiles, nil
+}
+
+// folderConfigFiles returns the paths of the configuration files of dir in load order. seen holds the files
+// returned already, so that a file listed twice in order files is rejected.
+func folderConfigFiles(root string, dir string, recursive bool, seen map[string]bool) ([]string, error) {
+	order, orderFile, err := readOrderFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if orderFile != "" {
+		return orderedConfigFiles(root, dir, orderFile, order, recursive, seen)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	// Entries are sorted by name, files come before the files of subfolders
+	var paths []string
+	var subfolders []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			if recursive && !strings.HasPrefix(name, ".") {
+				subfolders = append(subfolders, filepath.Join(dir, name))
+			}
+			continue
+		}
+		if !IsConfigFile(name) || isOrderFile(name) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if seen[path] {
+			rel, _ := filepath.Rel(root, path)
+			return nil, fmt.Errorf("%s is loaded more than once, check the order files", filepath.ToSlash(rel))
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	for _, subfolder := range subfolders {
+		subPaths, err := folderConfigFiles(root, subfolder, recursive, seen)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, subPaths...)
+	}
+	return paths, nil
+}
+
+// orderedConfigFiles returns the files and folders listed in the order file of dir. Configuration files of dir
+// that are not listed are not loaded.
+func orderedConfigFiles(root string, dir string, orderFile string, order []string, recursive bool, seen map[string]bool) ([]string, error) {
+	listed := map[string]bool{}
+	var paths []string
+	for _, resource := range order {
+		rel := filepath.Clean(filepath.FromSlash(resource))
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s: %s is not inside the folder", orderFile, resource)
+		}
+		path := filepath.Join(dir, rel)
+		listed[path] = true
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", orderFile, err)
+		}
+		if info.IsDir() {
+			subPaths, err := folderConfigFiles(root, path, recursive, seen)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, subPaths...)
+			continue
+		}
+		if !IsConfigFile(path) {
+			return nil, fmt.Errorf("%s: %s is not a .yml, .yaml, .json or .toml file", orderFile, resource)
+		}
+		if seen[path] {
+			return nil, fmt.Errorf("%s: %s is loaded more than once", orderFile, resource)
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+
+	// Files added to the folder but not to the order file are likely forgotten
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && IsConfigFile(entry.Name()) && !isOrderFile(entry.Name()) && !listed[path] {
+			rel, _ := filepath.Rel(root, path)
+			log.Warn().Msgf("Config file %s is not listed in %s, skipped", filepath.ToSlash(rel), orderFile)
+		}
+	}
+	return paths, nil
+}
+
+// readOrderFile returns the resources of the order file of dir and its name, empty without order file
+func readOrderFile(dir string) ([]string, string, error) {
+	for _, name := range OrderFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		var order struct {
+			Resources []string `yaml:"resources"`
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&order); err != nil && err != io.EOF {
+			return nil, "", fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, name), err)
+		}
+		return order.Resources, filepath.Join(dir, name), nil
+	}
+	return nil, "", nil
+}
+
+func isOrderFile(name string) bool {
+	for _, orderFile := range OrderFiles {
+		if name == orderFile {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseConfig parses the content of a configuration file, name is used in error messages and fromFile
+// parameters are read relative to its directory. When values are provided, {{ .Values.<key> }}
+// references are resolved. Artifacts with deployAs are replaced by one artifact per instance.
+func ParseConfig(name string, data []byte, values map[string]interface{}) (*ConfigureConfig, error) {
+	return parseConfig(name, data, values, LoadOptions{})
+}
+
+// ParseConfigWithOptions parses the content of a configuration file like ParseConfig with the Template and
+// NoFileReferences options. Recursive does not apply to a single file.
+func ParseConfigWithOptions(name string, data []byte, values map[string]interface{}, opts LoadOptions) (*ConfigureConfig, error) {
+	return parseConfig(name, data, values, opts)
+}
+
+func parseConfig(name string, data []byte, values map[string]interface{}, opts LoadOptions) (*ConfigureConfig, error) {
+	rendered, err := renderTemplate(name, data, values, opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := DecodeConfig(name, rendered)
+	if err != nil {
+		return nil, err
+	}
+	setValueSources(cfg, name, data, rendered)
+	if err := resolveConfigFiles([]*ConfigFile{{Config: cfg, Source: name, dir: filepath.Dir(name)}}, opts); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// resolveConfigFiles applies the steps that follow decoding to the files of a configuration, so that a file
+// loaded on its own and in a folder result in the same configuration: the files referenced with fromFile and
+// parametersFrom are read, or rejected with NoFileReferences, the parameter groups of all files are resolved
+// and artifacts with partners and deployAs are expanded.
+func resolveConfigFiles(configFiles []*ConfigFile, opts LoadOptions) error {
+	for _, configFile := range configFiles {
+		cfg := configFile.Config
+		if opts.NoFileReferences {
+			if err := rejectFileReferences(cfg); err != nil {
+				return fmt.Errorf("%s: %w", configFile.Source, err)
+			}
+		}
+		if err := resolveFileValues(cfg, configFile.dir, opts); err != nil {
+			return fmt.Errorf("%s: %w", configFile.Source, err)
+		}
+		if err := resolveParametersFrom(cfg, configFile.dir, opts); err != nil {
+			return fmt.Errorf("%s: %w", configFile.Source, err)
+		}
+	}
+	// Groups are resolved before the expansion, so that every partner and instance gets their parameters
+	if err := ResolveParameterGroups(configFiles); err != nil {
+		return err
+	}
+	for _, configFile := range configFiles {
+		if err := expandPartners(configFile.Config, configFile.dir, opts); err != nil {
+			return fmt.Errorf("%s: %w", configFile.Source, err)
+		}
+		if err := ExpandDeployAs(configFile.Config); err != nil {
+			return fmt.Errorf("%s: %w", configFile.Source, err)
+		}
+	}
+	return nil
+}
+
+// IsConfigFile returns true if the extension of name is one of a configuration file: .yml, .yaml, .json or .toml
+func IsConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yml", ".yaml", ".json", ".toml":
+		return true
+	}
+	return false
+}
+
+// DecodeConfig decodes a configuration file in the format of the extension of name. Files ending in .toml
+// are TOML, all others YAML, which includes JSON. Templates and fromFile parameters are not resolved.
+func DecodeConfig(name string, data []byte) (*ConfigureConfig, error) {
+	var cfg ConfigureConfig
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".toml":
+		// TOML is converted to YAML to apply the same field names and defaults. Lines of the converted
+		// document do not match the file, so parameters have no line.
+		var doc map[string]interface{}
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+		converted, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+		if err := yaml.Unmarshal(converted, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+		clearLines(&cfg)
+	case ".json":
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	}
+	return &cfg, nil
+}
+
+func clearLines(cfg *ConfigureConfig) {
+	for name := range cfg.ParameterGroups {
+		for i := range cfg.ParameterGroups[name] {
+			cfg.ParameterGroups[name][i].Line = 0
+		}
+	}
+	for i := range cfg.TenantDefaults {
+		cfg.TenantDefaults[i].Line = 0
+	}
+	for ti := range cfg.Targets {
+		for i := range cfg.Targets[ti].TenantDefaults {
+			cfg.Targets[ti].TenantDefaults[i].Line = 0
+		}
+	}
+	for pi := range cfg.Packages {
+		for ai := range cfg.Packages[pi].Artifacts {
+			for i := range cfg.Packages[pi].Artifacts[ai].Parameters {
+				cfg.Packages[pi].Artifacts[ai].Parameters[i].Line = 0
+			}
+			for _, instance := range cfg.Packages[pi].Artifacts[ai].DeployAs {
+				for i := range instance.Parameters {
+					instance.Parameters[i].Line = 0
+				}
+			}
+		}
+	}
+}
+
+// resolveFileValues sets the value of parameters with fromFile to the content of the file, base64
+// encoded if requested. Relative paths are resolved against dir.
+func resolveFileValues(cfg *ConfigureConfig, dir string, opts LoadOptions) error {
+	for _, name := range slices.Sorted(maps.Keys(cfg.ParameterGroups)) {
+		if err := readFileValues(cfg.ParameterGroups[name], "group "+name, dir, opts); err != nil {
+			return err
+		}
+	}
+	if err := readFileValues(cfg.TenantDefaults, "tenantDefaults", dir, opts); err != nil {
+		return err
+	}
+	for _, target := range cfg.Targets {
+		if err := readFileValues(target.TenantDefaults, "tenantDefaults of target "+target.Name, dir, opts); err != nil {
+			return err
+		}
+	}
+	for pi := range cfg.Packages {
+		for ai := range cfg.Packages[pi].Artifacts {
+			artifact := &cfg.Packages[pi].Artifacts[ai]
+			if err := readFileValues(artifact.Parameters, "artifact "+artifact.ID, dir, opts); err != nil {
+				return err
+			}
+			for _, instance := range artifact.DeployAs {
+				if err := readFileValues(instance.Parameters, "artifact "+instance.Prefix+artifact.ID, dir, opts); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readFileValues sets the values of the parameters with fromFile of the artifact or group owner
+func readFileValues(parameters []ConfigurationParameter, owner string, dir string, opts LoadOptions) error {
+	for i := range parameters {
+		param := &parameters[i]
+		if param.FromFile == "" {
+			continue
+		}
+		if param.Value != "" {
+			return fmt.Errorf("parameter %s of %s: value and fromFile cannot be used together", param.Key, owner)
+		}
+		path := param.FromFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if err := opts.verify(path); err != nil {
+			return fmt.Errorf("parameter %s of %s: %w", param.Key, owner, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("parameter %s of %s: %w", param.Key, owner, err)
+		}
+		if param.Base64 {
+			param.Value = base64.StdEncoding.EncodeToString(data)
+		} else {
+			param.Value = string(data)
+		}
+	}
+	return nil
+}
+
+// rejectFileReferences returns an error for the first parameter with fromFile, parametersFrom or partner table
+// from a file, which would be read from the local file system
+func rejectFileReferences(cfg *ConfigureConfig) error {
+	fromFile := func(parameters []ConfigurationParameter, owner string) error {
+		for _, param := range parameters {
+			if param.FromFile != "" {
+				return fmt.Errorf("parameter %s of %s: fromFile is not allowed", param.Key, owner)
+			}
+		}
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.ParameterGroups)) {
+		if err := fromFile(cfg.ParameterGroups[name], "group "+name); err != nil {
+			return err
+		}
+	}
+	if err := fromFile(cfg.TenantDefaults, "tenantDefaults"); err != nil {
+		return err
+	}
+	for _, target := range cfg.Targets {
+		if err := fromFile(target.TenantDefaults, "tenantDefaults of target "+target.Name); err != nil {
+			return err
+		}
+	}
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			if err := fromFile(artifact.Parameters, "artifact "+artifact.ID); err != nil {
+				return err
+			}
+			for _, instance := range artifact.DeployAs {
+				if err := fromFile(instance.Parameters, "artifact "+instance.Prefix+artifact.ID); err != nil {
+					return err
+				}
+			}
+			if len(artifact.ParametersFrom) > 0 {
+				return fmt.Errorf("parametersFrom of artifact %s is not allowed", artifact.ID)
+			}
+			if artifact.Partners != nil && artifact.Partners.From != "" {
+				return fmt.Errorf("partners from a file of artifact %s are not allowed", artifact.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveParametersFrom adds the parameters of the parametersFrom files of each artifact to its parameters.
+// Keys of later files override those of earlier files, and inline parameters override all files. Relative
+// paths are resolved against dir.
+func resolveParametersFrom(cfg *ConfigureConfig, dir string, opts LoadOptions) error {
+	for pi := range cfg.Packages {
+		for ai := range cfg.Packages[pi].Artifacts {
+			artifact := &cfg.Packages[pi].Artifacts[ai]
+			if len(artifact.ParametersFrom) == 0 {
+				continue
+			}
+			var keys []string
+			values := map[string]string{}
+			sources := map[string]*ValueSource{}
+			for _, path := range artifact.ParametersFrom {
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(dir, path)
+				}
+				if err := opts.verify(path); err != nil {
+					return fmt.Errorf("parametersFrom of artifact %s: %w", artifact.ID, err)
+				}
+				fileKeys, fileValues, fileLines, err := readParametersFile(path)
+				if err != nil {
+					return fmt.Errorf("parametersFrom of artifact %s: %w", artifact.ID, err)
+				}
+				for _, key := range fileKeys {
+					if _, exists := values[key]; !exists {
+						keys = append(keys, key)
+					}
+					values[key] = fileValues[key]
+					sources[key] = &ValueSource{File: path, Line: fileLines[key], Kind: ValueSourceParametersFrom}
+				}
+			}
+			for _, key := range keys {
+				if findParameter(artifact.Parameters, key) == nil {
+					artifact.Parameters = append(artifact.Parameters, ConfigurationParameter{Key: key, Value: values[key], Source: sources[key]})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func findParameter(parameters []ConfigurationParameter, key string) *ConfigurationParameter {
+	for i := range parameters {
+		if parameters[i].Key == key {
+			return &parameters[i]
+		}
+	}
+	return nil
+}
+
+// ReadParametersFile reads the key=value pairs of a Java .properties or .env file and returns the keys in the
+// order of the file. Comments starting with # or !, key: value pairs, escaped spaces in keys, the export prefix
+// and quoted values of .env files are supported, line continuations are not.
+func ReadParametersFile(path string) ([]string, map[string]string, error) {
+	keys, values, _, err := readParametersFile(path)
+	return keys, values, err
+}
+
+// readParametersFile reads a file like ReadParametersFile and also returns the line of each key
+func readParametersFile(path string) ([]string, map[string]string, map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var keys []string
+	values := map[string]string{}
+	lines := map[string]int{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		separator := strings.IndexAny(line, "=:")
+		if separator <= 0 {
+			return nil, nil, nil, fmt.Errorf("%s line %d: expected key=value", path, i+1)
+		}
+		key := strings.ReplaceAll(strings.TrimSpace(line[:separator]), `\ `, " ")
+		value := strings.TrimSpace(line[separator+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		}
+		values[key] = value
+		lines[key] = i + 1
+	}
+	return keys, values, lines, nil
+}
+
+// MergeConfigs merges the packages, targets, type aliases and run level hooks of all configuration files. The
+// deployment prefix of the first file is used unless overridePrefix is set, and the first rollout, batch fallback and
+// stamp defined are used. createMissingParameters of a file is applied to the artifacts of that file. The tenant defaults
+// of all files are merged, keys of later files overriding those of earlier files, and applied to all artifacts.
+func MergeConfigs(configFiles []*ConfigFile, overridePrefix string) *ConfigureConfig {
+	merged := &ConfigureConfig{
+		Packages: []ConfigurePackage{},
+	}
+
+	// Use override prefix if provided, otherwise use first config's prefix
+	if overridePrefix != "" {
+		merged.DeploymentPrefix = overridePrefix
+	} else if len(configFiles) > 0 && configFiles[0].Config.DeploymentPrefix != "" {
+		merged.DeploymentPrefix = configFiles[0].Config.DeploymentPrefix
+	}
+
+	// Merge all packages from all config files
+	for _, configFile := range configFiles {
+		log.Info().Msgf("  Merging packages from: %s", configFile.FileName)
+		if configFile.Config.CreateMissing {
+			for i := range configFile.Config.Packages {
+				for j := range configFile.Config.Packages[i].Artifacts {
+					configFile.Config.Packages[i].Artifacts[j].CreateMissing = true
+				}
+			}
+		}
+		merged.Packages = append(merged.Packages, configFile.Config.Packages...)
+		merged.Hooks = mergeHooks(merged.Hooks, configFile.Config.Hooks)
+		merged.Targets = append(merged.Targets, configFile.Config.Targets...)
+		for alias, artifactType := range configFile.Config.TypeAliases {
+			if merged.TypeAliases == nil {
+				merged.TypeAliases = map[string]string{}
+			}
+			merged.TypeAliases[alias] = artifactType
+		}
+		if merged.Rollout == nil {
+			merged.Rollout = configFile.Config.Rollout
+		}
+		if merged.BatchFallback == "" {
+			merged.BatchFallback = configFile.Config.BatchFallback
+		}
+		if merged.Stamp == nil {
+			merged.Stamp = configFile.Config.Stamp
+		}
+		merged.TenantDefaults = mergeTenantDefaults(merged.TenantDefaults, configFile.Config.TenantDefaults)
+	}
+	for pi := range merged.Packages {
+		for ai := range merged.Packages[pi].Artifacts {
+			ApplyTenantDefaults(&merged.Packages[pi].Artifacts[ai], merged.TenantDefaults)
+		}
+	}
+
+	return merged
+}
+
+// LoadValuesFiles reads and merges values files. Keys of later files override those of earlier files.
+func LoadValuesFiles(paths []string) (map[string]interface{}, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	values := map[string]interface{}{}
+	for _, path := range str.TrimSlice(paths) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+		var fileValues map[string]interface{}
+		if err := yaml.Unmarshal(data, &fileValues); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+		}
+		log.Info().Msgf("Loaded values file: %s", path)
+		mergeValues(values, fileValues)
+	}
+	return values, nil
+}
+
+// mergeValues deep merges src into dst, nested maps are merged while other values are replaced
+func mergeValues(dst map[string]interface{}, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// renderTemplate resolves {{ .Values.<key> }} references in a configuration file.
+// Files are only rendered when values are provided or funcs is set, and missing keys are an error.
+// With funcs, the sprig-compatible functions of templateFuncs are available.
+func renderTemplate(name string, data []byte, values map[string]interface{}, funcs bool) ([]byte, error) {
+	if values == nil && !funcs {
+		return data, nil
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	tmpl := template.New(filepath.Base(name)).Option("missingkey=error")
+	if funcs {
+		tmpl = tmpl.Funcs(templateFuncs)
+	}
+	tmpl, err := tmpl.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template in %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{"Values": values}); err != nil {
+		return nil, fmt.Errorf("failed to resolve template in %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeHooks appends the commands of each phase of other to hooks
+func mergeHooks(hooks *ConfigureHooks, other *ConfigureHooks) *ConfigureHooks {
+	if other == nil {
+		return hooks
+	}
+	if hooks == nil {
+		hooks = &ConfigureHooks{}
+	}
+	hooks.PreConfigure = append(hooks.PreConfigure, other.PreConfigure...)
+	hooks.PostConfigure = append(hooks.PostConfigure, other.PostConfigure...)
+	hooks.PreDeploy = append(hooks.PreDeploy, other.PreDeploy...)
+	hooks.PostDeploy = append(hooks.PostDeploy, other.PostDeploy...)
+	return hooks
+}
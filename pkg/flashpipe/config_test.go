@@ -0,0 +1,270 @@
+package flashpipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]interface{}{}
+	mergeValues(values, map[string]interface{}{
+		"sql": map[string]interface{}{"host": "dev-db", "port": 1433},
+	})
+	mergeValues(values, map[string]interface{}{
+		"sql": map[string]interface{}{"host": "prod-db"},
+	})
+
+	data := []byte(`value: "{{ .Values.sql.host }}:{{ .Values.sql.port }}"`)
+	rendered, err := renderTemplate("config.yml", data, values, false)
+	require.NoError(t, err)
+	assert.Equal(t, `value: "prod-db:1433"`, string(rendered))
+
+	_, err = renderTemplate("config.yml", []byte(`value: "{{ .Values.sql.user }}"`), values, false)
+	assert.Error(t, err, "Missing key should result in an error")
+
+	unchanged, err := renderTemplate("config.yml", data, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, data, unchanged, "Configuration should not be rendered without values")
+}
+
+func TestParseConfigTypedValues(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0644))
+
+	cfg, err := ParseConfig(filepath.Join(dir, "config.yml"), []byte(`packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: OrderFlow
+        type: Integration
+        parameters:
+          - key: Port
+            value: 8080
+          - key: Enabled
+            value: true
+          - key: Query
+            value: |-
+              SELECT *
+              FROM ORDERS
+          - key: Certificate
+            fromFile: cert.pem
+          - key: CertificateBase64
+            fromFile: ./cert.pem
+            base64: true
+`), nil)
+	require.NoError(t, err)
+
+	var values []string
+	for _, param := range cfg.Packages[0].Artifacts[0].Parameters {
+		values = append(values, param.Value)
+	}
+	assert.Equal(t, []string{"8080", "true", "SELECT *\nFROM ORDERS", "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		"LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUIKLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQo="}, values)
+
+	_, err = ParseConfig(filepath.Join(dir, "config.yml"), []byte(`packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: OrderFlow
+        parameters:
+          - key: Certificate
+            fromFile: missing.pem
+`), nil)
+	assert.Error(t, err, "Missing file should be an error")
+}
+
+func TestLoadConfigFilesJSONAndTOML(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders.json"), []byte(`{
+	"deploymentPrefix": "DEV_",
+	"packages": [
+		{
+			"integrationSuiteId": "Orders",
+			"artifacts": [
+				{"artifactId": "OrderFlow", "type": "Integration", "deploy": true,
+				 "parameters": [{"key": "Port", "value": 8080}, {"key": "Host", "value": "{{ .Values.host }}"}]}
+			]
+		}
+	]
+}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "billing.toml"), []byte(`[[packages]]
+integrationSuiteId = "Billing"
+
+[[packages.artifacts]]
+artifactId = "InvoiceFlow"
+type = "Integration"
+
+[[packages.artifacts.parameters]]
+key = "Retries"
+value = 3
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a configuration file"), 0644))
+
+	files, err := LoadConfigFiles(dir, map[string]interface{}{"host": "dev-host"})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	billing := files[0].Config.Packages[0].Artifacts[0]
+	assert.Equal(t, "InvoiceFlow", billing.ID)
+	assert.Equal(t, "active", billing.Version, "Defaults should apply to TOML")
+	assert.Equal(t, "3", billing.Parameters[0].Value)
+	assert.Equal(t, 0, billing.Parameters[0].Line, "TOML parameters have no line")
+
+	orders := files[1].Config
+	assert.Equal(t, "DEV_", orders.DeploymentPrefix)
+	artifact := orders.Packages[0].Artifacts[0]
+	assert.True(t, artifact.Deploy)
+	assert.Equal(t, "8080", artifact.Parameters[0].Value)
+	assert.Equal(t, "dev-host", artifact.Parameters[1].Value, "Templates should be resolved in JSON")
+	assert.Equal(t, 8, artifact.Parameters[0].Line, "JSON parameters have the line")
+
+	_, err = DecodeConfig("broken.toml", []byte("packages = ["))
+	assert.Error(t, err)
+}
+
+func TestParseConfigParametersFrom(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "props"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "props", "orders.properties"), []byte(`# Orders receiver
+Receiver\ Host=erp.example.com
+ReceiverPort: 443
+Query=$filter=Status eq 'OPEN'
+Timeout=60000
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "props", "common.env"), []byte("export Timeout=30000\r\nProxyType=\"Internet\"\n"), 0644))
+
+	cfg, err := ParseConfig(filepath.Join(dir, "config.yml"), []byte(`packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: OrderFlow
+        type: Integration
+        parametersFrom: [./props/orders.properties, props/common.env]
+        parameters:
+          - key: ReceiverPort
+            value: 8443
+`), nil)
+	require.NoError(t, err)
+	orders, common := filepath.Join(dir, "props", "orders.properties"), filepath.Join(dir, "props", "common.env")
+	assert.Equal(t, []ConfigurationParameter{
+		{Key: "ReceiverPort", Value: "8443", Line: 8, Source: &ValueSource{File: filepath.Join(dir, "config.yml"), Line: 8, Kind: ValueSourceConfig}},
+		{Key: "Receiver Host", Value: "erp.example.com", Source: &ValueSource{File: orders, Line: 2, Kind: ValueSourceParametersFrom}},
+		{Key: "Query", Value: "$filter=Status eq 'OPEN'", Source: &ValueSource{File: orders, Line: 4, Kind: ValueSourceParametersFrom}},
+		{Key: "Timeout", Value: "30000", Source: &ValueSource{File: common, Line: 1, Kind: ValueSourceParametersFrom}},
+		{Key: "ProxyType", Value: "Internet", Source: &ValueSource{File: common, Line: 2, Kind: ValueSourceParametersFrom}},
+	}, cfg.Packages[0].Artifacts[0].Parameters, "Inline parameters and later files should win")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.properties"), []byte("Receiver\n"), 0644))
+	_, err = ParseConfig(filepath.Join(dir, "config.yml"), []byte(`packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: OrderFlow
+        parametersFrom: [invalid.properties]
+`), nil)
+	assert.ErrorContains(t, err, "line 1: expected key=value")
+}
+
+func TestLoadConfigFilesFolderParametersFrom(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"props/orders.properties": "Host=fromprops\nTimeout=60000\n",
+		"orders.yml": `packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: OrderFlow
+        parametersFrom: [props/orders.properties]
+        useGroups: [timeouts]
+`,
+		"groups.yml": `parameterGroups:
+  timeouts:
+    - key: Timeout
+      value: 30000
+`,
+	})
+
+	files, err := LoadConfigFiles(dir, nil)
+	require.NoError(t, err)
+	_, err = LoadConfigFiles(filepath.Join(dir, "orders.yml"), nil)
+	assert.ErrorContains(t, err, "undefined parameter group timeouts", "The group is defined in another file of the folder")
+
+	require.Len(t, files, 2)
+	assert.Equal(t, "orders.yml", files[1].FileName)
+	assert.Equal(t, []string{"Host=fromprops", "Timeout=60000"}, parameterValues(files[1].Config.Packages[0].Artifacts[0].Parameters),
+		"The parameters of parametersFrom should be read for the files of a folder and override groups")
+}
+
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func fileNames(files []*ConfigFile) []string {
+	var names []string
+	for _, file := range files {
+		names = append(names, file.FileName)
+	}
+	return names
+}
+
+func TestLoadConfigFilesRecursive(t *testing.T) {
+	dir := t.TempDir()
+	config := "packages:\n  - integrationSuiteId: Sales\n"
+	writeConfigFiles(t, dir, map[string]string{
+		"b.yml":                  config,
+		"a.yml":                  config,
+		"team-b/orders.yml":      config,
+		"team-a/z.yml":           config,
+		"team-a/nested/cert.yml": "packages:\n  - integrationSuiteId: Sales\n    artifacts:\n      - artifactId: Flow\n        parameters:\n          - key: Cert\n            fromFile: cert.pem\n",
+		"team-a/nested/cert.pem": "CERT",
+		".git/config.yml":        config,
+	})
+
+	files, err := LoadConfigFiles(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.yml", "b.yml"}, fileNames(files), "Subfolders are ignored without recursion")
+
+	files, err = LoadConfigFilesWithOptions(dir, nil, LoadOptions{Recursive: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.yml", "b.yml", "team-a/z.yml", "team-a/nested/cert.yml", "team-b/orders.yml"}, fileNames(files))
+	assert.Equal(t, filepath.Join(dir, "team-a", "z.yml"), files[2].Source)
+	assert.Equal(t, "CERT", files[3].Config.Packages[0].Artifacts[0].Parameters[0].Value, "fromFile is relative to the file")
+}
+
+func TestLoadConfigFilesOrderFile(t *testing.T) {
+	dir := t.TempDir()
+	config := "packages:\n  - integrationSuiteId: Sales\n"
+	writeConfigFiles(t, dir, map[string]string{
+		"flashpipe-order.yml": "resources:\n  - base.yml\n  - teams/\n  - overrides.yml\n",
+		"overrides.yml":       config,
+		"base.yml":            config,
+		"forgotten.yml":       config,
+		"teams/b.yml":         config,
+		"teams/a.yml":         config,
+		"teams/legacy/c.yml":  config,
+	})
+
+	files, err := LoadConfigFiles(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base.yml", "teams/a.yml", "teams/b.yml", "overrides.yml"}, fileNames(files), "Order of the order file, unlisted files are skipped")
+
+	files, err = LoadConfigFilesWithOptions(dir, nil, LoadOptions{Recursive: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base.yml", "teams/a.yml", "teams/b.yml", "teams/legacy/c.yml", "overrides.yml"}, fileNames(files))
+
+	for order, message := range map[string]string{
+		"resources:\n  - ../outside.yml\n":           "../outside.yml is not inside the folder",
+		"resources:\n  - missing.yml\n":              "missing.yml",
+		"resources:\n  - base.yml\n  - base.yml\n":   "base.yml is loaded more than once",
+		"resources:\n  - teams/a.yml\n  - teams\n":   "teams/a.yml is loaded more than once",
+		"resource:\n  - base.yml\n":                  "field resource not found",
+		"resources:\n  - teams/legacy/../../x.txt\n": "x.txt is not a .yml, .yaml, .json or .toml file",
+	} {
+		writeConfigFiles(t, dir, map[string]string{"flashpipe-order.yml": order, "x.txt": "text"})
+		_, err = LoadConfigFiles(dir, nil)
+		assert.ErrorContains(t, err, message, order)
+	}
+}
@@ -0,0 +1,154 @@
+package flashpipe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Handling of parameters set to different values for the same artifact in several configuration files
+const (
+	ConflictLastWins  = "last-wins"
+	ConflictFirstWins = "first-wins"
+	ConflictError     = "error"
+)
+
+// ParameterSource is a parameter value and the location in a configuration file it is set at
+type ParameterSource struct {
+	File  string
+	Line  int
+	Value string
+}
+
+func (s ParameterSource) String() string {
+	if s.Line == 0 {
+		return fmt.Sprintf("%s (%q)", s.File, s.Value)
+	}
+	return fmt.Sprintf("%s:%d (%q)", s.File, s.Line, s.Value)
+}
+
+// Conflict is a parameter of an artifact that is set to different values in several configuration files
+type Conflict struct {
+	ArtifactID string
+	Key        string
+	Sources    []ParameterSource // In file order
+}
+
+func (c Conflict) String() string {
+	sources := make([]string, len(c.Sources))
+	for i, source := range c.Sources {
+		sources[i] = source.String()
+	}
+	return fmt.Sprintf("artifact %s, parameter %s: %s", c.ArtifactID, c.Key, strings.Join(sources, ", "))
+}
+
+type parameterRef struct {
+	file     int
+	pkg      int
+	artifact int
+	param    int
+}
+
+// FindConflicts returns the parameters that are set to different values for the same artifact ID in
+// more than one configuration file. Parameters repeated within a file are not considered conflicts.
+func FindConflicts(configFiles []*ConfigFile) []Conflict {
+	conflicts, _ := findConflicts(configFiles)
+	return conflicts
+}
+
+func findConflicts(configFiles []*ConfigFile) ([]Conflict, [][]parameterRef) {
+	type paramID struct{ artifactID, key string }
+	refs := map[paramID][]parameterRef{}
+	var order []paramID
+	for fi, configFile := range configFiles {
+		for pi, pkg := range configFile.Config.Packages {
+			for ai, artifact := range pkg.Artifacts {
+				for ki, param := range artifact.Parameters {
+					id := paramID{artifact.ID, param.Key}
+					if _, ok := refs[id]; !ok {
+						order = append(order, id)
+					}
+					refs[id] = append(refs[id], parameterRef{fi, pi, ai, ki})
+				}
+			}
+		}
+	}
+
+	var conflicts []Conflict
+	var conflictRefs [][]parameterRef
+	for _, id := range order {
+		files := map[int]bool{}
+		values := map[string]bool{}
+		var sources []ParameterSource
+		for _, ref := range refs[id] {
+			param := parameterAt(configFiles, ref)
+			files[ref.file] = true
+			values[param.Value] = true
+			sources = append(sources, ParameterSource{File: configFiles[ref.file].Source, Line: param.Line, Value: param.Value})
+		}
+		if len(files) > 1 && len(values) > 1 {
+			conflicts = append(conflicts, Conflict{ArtifactID: id.artifactID, Key: id.key, Sources: sources})
+			conflictRefs = append(conflictRefs, refs[id])
+		}
+	}
+	return conflicts, conflictRefs
+}
+
+func parameterAt(configFiles []*ConfigFile, ref parameterRef) *ConfigurationParameter {
+	return &configFiles[ref.file].Config.Packages[ref.pkg].Artifacts[ref.artifact].Parameters[ref.param]
+}
+
+// ResolveConflicts handles conflicting parameters of the configuration files according to policy. With
+// last-wins and first-wins, all occurrences of a conflicting parameter except the winning one are removed
+// from the configuration files, with error a report of all conflicts is returned.
+func ResolveConflicts(configFiles []*ConfigFile, policy string) error {
+	switch policy {
+	case "", ConflictLastWins, ConflictFirstWins, ConflictError:
+	default:
+		return fmt.Errorf("invalid conflict handling %q (valid values: %s, %s, %s)", policy, ConflictLastWins, ConflictFirstWins, ConflictError)
+	}
+
+	conflicts, conflictRefs := findConflicts(configFiles)
+	if len(conflicts) == 0 {
+		return nil
+	}
+	if policy == ConflictError {
+		report := make([]string, len(conflicts))
+		for i, conflict := range conflicts {
+			report[i] = "  " + conflict.String()
+		}
+		return fmt.Errorf("%d conflicting parameter(s) in configuration files:\n%s", len(conflicts), strings.Join(report, "\n"))
+	}
+
+	removed := map[parameterRef]bool{}
+	for i, conflict := range conflicts {
+		refs := conflictRefs[i]
+		winner := len(refs) - 1
+		if policy == ConflictFirstWins {
+			winner = 0
+		}
+		log.Warn().Msgf("Conflicting parameter, using %s: %s", conflict.Sources[winner], conflict)
+		for j, ref := range refs {
+			if j != winner {
+				removed[ref] = true
+			}
+		}
+	}
+
+	for fi, configFile := range configFiles {
+		for pi := range configFile.Config.Packages {
+			artifacts := configFile.Config.Packages[pi].Artifacts
+			for ai := range artifacts {
+				var kept []ConfigurationParameter
+				for ki, param := range artifacts[ai].Parameters {
+					if !removed[parameterRef{fi, pi, ai, ki}] {
+						kept = append(kept, param)
+					}
+				}
+				artifacts[ai].Parameters = kept
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadConflictingFiles(t *testing.T) []*ConfigFile {
+	base, err := ParseConfig("base.yml", []byte(`packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: OrderFlow
+        type: Integration
+        parameters:
+          - key: Host
+            value: dev-host
+          - key: Port
+            value: "443"
+`), nil)
+	require.NoError(t, err)
+	override, err := ParseConfig("override.yml", []byte(`packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: OrderFlow
+        type: Integration
+        parameters:
+          - key: Port
+            value: "443"
+          - key: Host
+            value: prod-host
+`), nil)
+	require.NoError(t, err)
+	return []*ConfigFile{{Config: base, Source: "base.yml"}, {Config: override, Source: "override.yml"}}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	conflicts := FindConflicts(loadConflictingFiles(t))
+	require.Len(t, conflicts, 1, "Same values in several files should not be a conflict")
+	assert.Equal(t, `artifact OrderFlow, parameter Host: base.yml:7 ("dev-host"), override.yml:9 ("prod-host")`, conflicts[0].String())
+
+	err := ResolveConflicts(loadConflictingFiles(t), ConflictError)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "override.yml:9")
+
+	files := loadConflictingFiles(t)
+	require.NoError(t, ResolveConflicts(files, ConflictFirstWins))
+	merged := MergeConfigs(files, "")
+	var hosts []string
+	for _, pkg := range merged.Packages {
+		for _, param := range pkg.Artifacts[0].Parameters {
+			if param.Key == "Host" {
+				hosts = append(hosts, param.Value)
+			}
+		}
+	}
+	assert.Equal(t, []string{"dev-host"}, hosts, "Only the first value should be applied")
+
+	assert.Error(t, ResolveConflicts(files, "newest"), "Unknown policy should be an error")
+}
@@ -0,0 +1,38 @@
+package flashpipe
+
+// mergeTenantDefaults returns the tenant defaults with the parameters of other added. Keys of other override
+// those of defaults.
+func mergeTenantDefaults(defaults []ConfigurationParameter, other []ConfigurationParameter) []ConfigurationParameter {
+	for _, param := range other {
+		if existing := findParameter(defaults, param.Key); existing != nil {
+			*existing = param
+		} else {
+			defaults = append(defaults, param)
+		}
+	}
+	return defaults
+}
+
+// ApplyTenantDefaults adds the tenant defaults to the parameters of the artifact unless it has skipTenantDefaults.
+// The defaults are optional, so that they are only set if the artifact has the parameter. Parameters set for the
+// artifact are kept, while tenant defaults added before are replaced, e.g. by the tenant defaults of a target.
+func ApplyTenantDefaults(artifact *ConfigureArtifact, defaults []ConfigurationParameter) {
+	if artifact.SkipDefaults {
+		return
+	}
+	for _, param := range defaults {
+		param.Optional = true
+		if param.Source != nil {
+			source := *param.Source
+			source.Kind = ValueSourceTenantDefaults
+			param.Source = &source
+		}
+		existing := findParameter(artifact.Parameters, param.Key)
+		switch {
+		case existing == nil:
+			artifact.Parameters = append(artifact.Parameters, param)
+		case existing.Optional:
+			*existing = param
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConfigsTenantDefaults(t *testing.T) {
+	common, err := parseConfig("common.yml", []byte(`tenantDefaults:
+  - key: LogLevel
+    value: INFO
+  - key: ProxyType
+    value: Internet
+packages: []
+`), nil, LoadOptions{})
+	require.NoError(t, err)
+	sales, err := parseConfig("sales.yml", []byte(`tenantDefaults:
+  - key: LogLevel
+    value: ERROR
+packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        parameters:
+          - key: ProxyType
+            value: OnPremise
+      - artifactId: Invoices
+        skipTenantDefaults: true
+`), nil, LoadOptions{})
+	require.NoError(t, err)
+
+	merged := MergeConfigs([]*ConfigFile{{Config: common, Source: "common.yml", FileName: "common.yml"}, {Config: sales, Source: "sales.yml", FileName: "sales.yml"}}, "")
+	assert.Equal(t, []ConfigurationParameter{
+		{Key: "ProxyType", Value: "OnPremise", Line: 9, Source: &ValueSource{File: "sales.yml", Line: 9, Kind: ValueSourceConfig}},
+		{Key: "LogLevel", Value: "ERROR", Line: 2, Source: &ValueSource{File: "sales.yml", Line: 2, Kind: ValueSourceTenantDefaults}, Optional: true},
+	}, merged.Packages[0].Artifacts[0].Parameters, "Later files should override defaults and parameters of the artifact should win")
+	assert.Empty(t, merged.Packages[0].Artifacts[1].Parameters, "Artifacts with skipTenantDefaults should not get defaults")
+}
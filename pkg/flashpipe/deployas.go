@@ -0,0 +1,75 @@
+package flashpipe
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ExpandDeployAs replaces each artifact with deployAs by one artifact per instance, whose ID is the prefix of the
+// instance followed by the ID of the artifact. The parameters of an instance override those of the artifact with the
+// same key. An error is returned if a prefix is empty or an ID occurs twice in a package.
+func ExpandDeployAs(cfg *ConfigureConfig) error {
+	for pi := range cfg.Packages {
+		pkg := &cfg.Packages[pi]
+		if !slices.ContainsFunc(pkg.Artifacts, func(artifact ConfigureArtifact) bool { return len(artifact.DeployAs) > 0 }) {
+			continue
+		}
+		err := expandArtifacts(pkg, "check the prefixes of deployAs", func(artifact ConfigureArtifact) ([]ConfigureArtifact, error) {
+			if len(artifact.DeployAs) == 0 {
+				return []ConfigureArtifact{artifact}, nil
+			}
+			var instances []ConfigureArtifact
+			for _, instance := range artifact.DeployAs {
+				if instance.Prefix == "" {
+					return nil, fmt.Errorf("package %s: deployAs of artifact %s has an instance without prefix", pkg.ID, artifact.ID)
+				}
+				expanded := artifact
+				expanded.ID = instance.Prefix + artifact.ID
+				expanded.DeployAs = nil
+				expanded.Parameters = overrideParameters(artifact.Parameters, instance.Parameters)
+				instances = append(instances, expanded)
+			}
+			return instances, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandArtifacts replaces each artifact of the package by the artifacts returned by expand. An error naming hint is
+// returned if an ID occurs twice in the package afterwards.
+func expandArtifacts(pkg *ConfigurePackage, hint string, expand func(ConfigureArtifact) ([]ConfigureArtifact, error)) error {
+	var artifacts []ConfigureArtifact
+	seen := map[string]bool{}
+	for _, artifact := range pkg.Artifacts {
+		instances, err := expand(artifact)
+		if err != nil {
+			return err
+		}
+		for _, instance := range instances {
+			if seen[instance.ID] {
+				return fmt.Errorf("package %s: artifact %s is configured more than once, %s", pkg.ID, instance.ID, hint)
+			}
+			seen[instance.ID] = true
+			artifacts = append(artifacts, instance)
+		}
+	}
+	pkg.Artifacts = artifacts
+	return nil
+}
+
+// overrideParameters returns a copy of parameters in which the parameters of overrides replace those with the
+// same key, further overrides are appended
+func overrideParameters(parameters []ConfigurationParameter, overrides []ConfigurationParameter) []ConfigurationParameter {
+	result := slices.Clone(parameters)
+	for _, override := range overrides {
+		if i := slices.IndexFunc(result, func(param ConfigurationParameter) bool { return param.Key == override.Key }); i >= 0 {
+			result[i] = override
+		} else {
+			result = append(result, override)
+		}
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandDeployAs(t *testing.T) {
+	cfg, err := ParseConfig("config.yml", []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        deploy: true
+        parameters:
+          - key: Receiver Host
+            value: erp.example.com
+          - key: Client
+            value: "000"
+        deployAs:
+          - prefix: A_
+            parameters:
+              - key: Client
+                value: "100"
+          - prefix: B_
+            parameters:
+              - key: Client
+                value: "200"
+              - key: Queue
+                value: B_ORDERS
+      - artifactId: Invoices
+        type: Integration
+`), nil)
+	require.NoError(t, err)
+
+	artifacts := cfg.Packages[0].Artifacts
+	if assert.Len(t, artifacts, 3) {
+		assert.Equal(t, "A_Orders", artifacts[0].ID)
+		assert.True(t, artifacts[0].Deploy)
+		assert.Nil(t, artifacts[0].DeployAs)
+		assert.Equal(t, []string{"Receiver Host=erp.example.com", "Client=100"}, parameterValues(artifacts[0].Parameters))
+		assert.Equal(t, "B_Orders", artifacts[1].ID)
+		assert.Equal(t, []string{"Receiver Host=erp.example.com", "Client=200", "Queue=B_ORDERS"}, parameterValues(artifacts[1].Parameters))
+		assert.Equal(t, "Invoices", artifacts[2].ID)
+	}
+	if assert.NotNil(t, artifacts[1].Parameters[1].Source) {
+		assert.Equal(t, 19, artifacts[1].Parameters[1].Source.Line, "Overrides should keep the line of the instance")
+	}
+}
+
+func TestExpandDeployAsFolder(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"groups.yml": `parameterGroups:
+  erp:
+    - key: Client
+      value: "000"
+`,
+		"orders.yml": `packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        useGroups: [erp]
+        deployAs:
+          - prefix: A_
+            parameters:
+              - key: Client
+                value: "100"
+          - prefix: B_
+`,
+	})
+
+	files, err := LoadConfigFiles(dir, nil)
+	require.NoError(t, err)
+
+	require.Len(t, files, 2)
+	artifacts := files[1].Config.Packages[0].Artifacts
+	if assert.Len(t, artifacts, 2, "The instances of deployAs should be expanded for the files of a folder") {
+		assert.Equal(t, "A_Orders", artifacts[0].ID)
+		assert.Equal(t, []string{"Client=100"}, parameterValues(artifacts[0].Parameters))
+		assert.Equal(t, "B_Orders", artifacts[1].ID)
+		assert.Equal(t, []string{"Client=000"}, parameterValues(artifacts[1].Parameters), "Every instance should get the parameters of its groups")
+	}
+}
+
+func TestExpandDeployAsInvalid(t *testing.T) {
+	for name, config := range map[string]string{
+		"empty prefix": `packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        type: Integration
+        deployAs:
+          - prefix: ""
+`,
+		"duplicate ID": `packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: A_Orders
+        type: Integration
+      - artifactId: Orders
+        type: Integration
+        deployAs:
+          - prefix: A_
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig("config.yml", []byte(config), nil)
+			assert.ErrorContains(t, err, "config.yml: package Sales")
+		})
+	}
+}
+
+func parameterValues(parameters []ConfigurationParameter) []string {
+	var result []string
+	for _, param := range parameters {
+		result = append(result, param.Key+"="+param.Value)
+	}
+	return result
+}
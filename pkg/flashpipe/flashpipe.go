@@ -0,0 +1,532 @@
+// Package flashpipe exposes the core functionality of the FlashPipe CLI for programmatic use, so
+// that other Go tools can embed it instead of invoking the CLI.
+//
+// A typical use loads the configuration files, then applies them to a tenant:
+//
+//	files, err := flashpipe.LoadConfigFiles("./config/prod", nil)
+//	if err != nil {
+//		return err
+//	}
+//	client := flashpipe.NewClient(flashpipe.ServiceDetails{Host: host, OAuthHost: oauthHost, ClientID: id, ClientSecret: secret})
+//	stats, err := flashpipe.Apply(ctx, client, flashpipe.MergeConfigs(files, ""), flashpipe.ApplyOptions{})
+package flashpipe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/smoketest"
+)
+
+// Types of the configuration files used by Apply and the configure command
+type (
+	ConfigureConfig        = models.ConfigureConfig
+	ConfigurePackage       = models.ConfigurePackage
+	ConfigureArtifact      = models.ConfigureArtifact
+	ConfigurationParameter = models.ConfigurationParameter
+	ValueFromSource        = models.ValueFromSource
+	ValueSource            = models.ValueSource
+	ConfigureHooks         = models.ConfigureHooks
+	ConfigureTarget        = models.ConfigureTarget
+	ConfigureRollout       = models.ConfigureRollout
+	MaintenanceWindow      = models.MaintenanceWindow
+	DrainCheck             = models.DrainCheck
+	BlueGreenSettings      = models.BlueGreenSettings
+	BatchSettings          = models.BatchSettings
+	ConfigureStamp         = models.ConfigureStamp
+	DeployInstance         = models.DeployInstance
+	PartnerTable           = models.PartnerTable
+	Conditions             = models.Conditions
+	SmokeTestResult        = smoketest.Result
+)
+
+// Stats tracks configuration processing statistics. It is safe for concurrent use, e.g. by packages configured in
+// parallel: the counters are atomic and the records of artifacts, unknown parameters and warnings are added by its
+// methods under a mutex. The records may be read directly once the run is done.
+type Stats struct {
+	PackagesProcessed         Counter             `json:"packagesProcessed"`
+	PackagesWithErrors        Counter             `json:"packagesWithErrors"`
+	ArtifactsProcessed        Counter             `json:"artifactsProcessed"`
+	ArtifactsConfigured       Counter             `json:"artifactsConfigured"`
+	ArtifactsDeployed         Counter             `json:"artifactsDeployed"`
+	ArtifactsFailed           Counter             `json:"artifactsFailed"`
+	ArtifactsLocked           Counter             `json:"artifactsLocked"` // Skipped as locked by another user
+	ParametersUpdated         Counter             `json:"parametersUpdated"`
+	ParametersFailed          Counter             `json:"parametersFailed"`
+	ParametersUnchanged       Counter             `json:"parametersUnchanged"` // Skipped as already set to the value
+	BatchRequestsExecuted     Counter             `json:"batchRequestsExecuted"`
+	IndividualRequestsUsed    Counter             `json:"individualRequestsUsed"`
+	DeploymentTasksQueued     Counter             `json:"deploymentTasksQueued"`
+	DeploymentTasksSuccessful Counter             `json:"deploymentTasksSuccessful"`
+	DeploymentTasksFailed     Counter             `json:"deploymentTasksFailed"`
+	DeploymentsUpToDate       Counter             `json:"deploymentsUpToDate"` // Skipped as the version was already running
+	HooksFailed               Counter             `json:"hooksFailed"`
+	UnknownParameters         map[string][]string `json:"unknownParameters,omitempty"` // Keys not found in the artifact by artifact ID
+	Timings                   Timings             `json:"timings"`
+	Artifacts                 []ArtifactResult    `json:"artifacts,omitempty"` // Outcome of each artifact configured or deployed
+	Warnings                  []string            `json:"warnings,omitempty"`  // Warnings issued during the run, e.g. skipped parameters
+	BatchFallbacks            []BatchFallback     `json:"batchFallbacks,omitempty"`
+	ParameterErrors           []ParameterError    `json:"parameterErrors,omitempty"` // Failed individual parameter updates
+	PrefixCheck               *PrefixCheck        `json:"prefixCheck,omitempty"`     // Configured artifacts reconciled with the tenant, see --prefix-check
+
+	mu sync.Mutex // Guards UnknownParameters, Artifacts, Warnings, BatchFallbacks and ParameterErrors
+}
+
+// Counter is a counter of Stats that can be incremented concurrently. It is written to JSON as number.
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add adds n to the counter
+func (c *Counter) Add(n int) {
+	c.value.Add(int64(n))
+}
+
+// Value returns the current value of the counter
+func (c *Counter) Value() int {
+	return int(c.value.Load())
+}
+
+// MarshalJSON writes the value of the counter
+func (c *Counter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Value())
+}
+
+// UnmarshalJSON reads the value written by MarshalJSON
+func (c *Counter) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	c.value.Store(n)
+	return nil
+}
+
+// Handling of parameters in the configuration that do not exist in the artifact
+const (
+	UnknownParametersWarn   = "warn"
+	UnknownParametersError  = "error"
+	UnknownParametersIgnore = "ignore"
+)
+
+// Handling of batch requests that fail as a whole, e.g. with a server error, when the parameters are not updated
+// atomically
+const (
+	BatchFallbackNone       = "none"        // Fail the artifact
+	BatchFallbackFailedOnly = "failed-only" // Update the parameters of the failed batch requests individually
+	BatchFallbackAll        = "all"         // Update all parameters of the artifact individually
+)
+
+// Targets of the stamp of the last run on the packages it changed
+const (
+	StampTargetDescription = "description" // A managed line of the package description
+	StampTargetTag         = "tag"         // A custom tag of the package
+	DefaultStampTag        = "flashpipe"   // Custom tag set if the stamp has no tag
+)
+
+// BatchFallback records that the parameters of an artifact were updated with individual requests after a batch
+// request failed
+type BatchFallback struct {
+	ArtifactID string `json:"artifactId"`
+	Policy     string `json:"policy"`     // failed-only or all
+	Parameters int    `json:"parameters"` // Parameters updated individually
+	Reason     string `json:"reason"`     // Error of the batch request
+}
+
+// Classes of errors of individual parameter updates
+const (
+	ParameterErrorRetryable = "retryable" // Timeouts, connection errors, 429 and 503, the update is retried with backoff
+	ParameterErrorPermanent = "permanent" // E.g. 400 for an invalid value or 404 for an unknown key, not retried
+)
+
+// ParameterError records an individual parameter update that failed, with the class of its error and whether a
+// retry succeeded
+type ParameterError struct {
+	ArtifactID string `json:"artifactId"`
+	Key        string `json:"key"`
+	Class      string `json:"class"`               // retryable or permanent
+	Attempts   int    `json:"attempts"`            // Requests sent for the parameter
+	Error      string `json:"error"`               // Error of the last failed attempt
+	Recovered  bool   `json:"recovered,omitempty"` // Updated by a retry
+}
+
+// PrefixCheck is the reconciliation of the artifact IDs of the configuration, with the deployment prefix, and the
+// artifacts on the tenant before anything is applied
+type PrefixCheck struct {
+	Expected int                   `json:"expected"`          // Configured artifacts that pass the filters
+	Present  int                   `json:"present"`           // Configured artifacts found on the tenant
+	Missing  []PrefixCheckArtifact `json:"missing,omitempty"` // Configured artifacts not found on the tenant
+	Extra    []PrefixCheckArtifact `json:"extra,omitempty"`   // Artifacts with the prefix in the configured packages that are not configured
+}
+
+// PrefixCheckArtifact is an artifact missing on the tenant or not configured
+type PrefixCheckArtifact struct {
+	PackageID  string `json:"packageId"`
+	ArtifactID string `json:"artifactId"`
+	Candidate  string `json:"candidate,omitempty"` // Tenant artifact with the same ID after another prefix, e.g. DEV-Orders for DEV_Orders
+}
+
+// Phases of a run that artifact results are recorded for
+const (
+	PhaseConfigure = "configure"
+	PhaseDeploy    = "deploy"
+)
+
+// ArtifactResult is the outcome of configuring or deploying an artifact
+type ArtifactResult struct {
+	PackageID    string `json:"packageId"`
+	ArtifactID   string `json:"artifactId"`
+	ArtifactType string `json:"artifactType,omitempty"` // Only recorded for deployments
+	Phase        string `json:"phase"`
+	Error        string `json:"error,omitempty"`
+	Category     string `json:"category,omitempty"` // Category of a failed deployment, see deploy.ClassifyError
+	Hint         string `json:"hint,omitempty"`     // Remediation of the deployment error
+	DurationMs   int64  `json:"durationMs"`
+	Changed      bool   `json:"changed,omitempty"` // Configured with values other than the current ones, or deployed
+	// Smoke test of a blue-green deployment, with the beginning of the response
+	SmokeTest *SmokeTestResult `json:"smokeTest,omitempty"`
+	// Parameters of the configuration and where their values came from, without the values
+	Parameters []ParameterValueSource `json:"parameters,omitempty"`
+	// Start of configuring or deploying the artifact, to correlate the result with the audit log of the tenant
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// AddArtifactResult records the outcome of configuring or deploying an artifact
+func (s *Stats) AddArtifactResult(packageID, artifactID, phase string, duration time.Duration, err error) {
+	s.addResult(newArtifactResult(packageID, artifactID, phase, duration, err))
+}
+
+// AddConfigurationResult records the outcome of configuring an artifact, with whether any of its parameters was
+// set to a value other than the current one
+func (s *Stats) AddConfigurationResult(packageID, artifactID string, changed bool, duration time.Duration, err error) {
+	result := newArtifactResult(packageID, artifactID, PhaseConfigure, duration, err)
+	result.Changed = changed && err == nil
+	s.addResult(result)
+}
+
+// AddDeploymentResult records the outcome of deploying an artifact, with the type of the artifact so that
+// failed deployments can be re-attempted from the report
+func (s *Stats) AddDeploymentResult(packageID, artifactID, artifactType string, duration time.Duration, err error) {
+	result := newArtifactResult(packageID, artifactID, PhaseDeploy, duration, err)
+	result.ArtifactType = artifactType
+	result.Changed = err == nil
+	s.addResult(result)
+}
+
+// AddSkippedDeployment records an artifact whose deployment was skipped as its version was already running
+func (s *Stats) AddSkippedDeployment(packageID, artifactID, artifactType string) {
+	result := newArtifactResult(packageID, artifactID, PhaseDeploy, 0, nil)
+	result.ArtifactType = artifactType
+	s.addResult(result)
+}
+
+// AddSmokeTestResult records the smoke test of the last deployment of an artifact
+func (s *Stats) AddSmokeTestResult(packageID, artifactID string, result *SmokeTestResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.Artifacts) - 1; i >= 0; i-- {
+		if a := &s.Artifacts[i]; a.PackageID == packageID && a.ArtifactID == artifactID && a.Phase == PhaseDeploy {
+			a.SmokeTest = result
+			return
+		}
+	}
+}
+
+// ParameterValueSource is a parameter configured on an artifact and where its value came from
+type ParameterValueSource struct {
+	Key    string       `json:"key"`
+	Source *ValueSource `json:"source,omitempty"`
+}
+
+// AddParameterSources records where the values of the parameters of the last configuration of an artifact came
+// from
+func (s *Stats) AddParameterSources(packageID, artifactID string, parameters []ConfigurationParameter) {
+	if len(parameters) == 0 {
+		return
+	}
+	sources := make([]ParameterValueSource, len(parameters))
+	for i, param := range parameters {
+		sources[i] = ParameterValueSource{Key: param.Key, Source: param.Source}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.Artifacts) - 1; i >= 0; i-- {
+		if a := &s.Artifacts[i]; a.PackageID == packageID && a.ArtifactID == artifactID && a.Phase == PhaseConfigure {
+			a.Parameters = sources
+			return
+		}
+	}
+}
+
+func (s *Stats) addResult(result ArtifactResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Artifacts = append(s.Artifacts, result)
+}
+
+func newArtifactResult(packageID, artifactID, phase string, duration time.Duration, err error) ArtifactResult {
+	result := ArtifactResult{PackageID: packageID, ArtifactID: artifactID, Phase: phase, DurationMs: duration.Milliseconds(),
+		StartedAt: time.Now().Add(-duration).UTC().Truncate(time.Millisecond)}
+	if err != nil {
+		result.Error = err.Error()
+		var deployErr *deploy.Error
+		if errors.As(err, &deployErr) {
+			result.Category, result.Hint = deployErr.Category, deployErr.Hint
+		} else {
+			result.Category, result.Hint = classifyAPIError(err)
+		}
+	}
+	return result
+}
+
+// classifyAPIError returns the category and hint of errors of failed calls of the tenant API
+func classifyAPIError(err error) (string, string) {
+	switch {
+	case errors.Is(err, api.ErrLocked):
+		return deploy.ErrorCategoryLocked, "Close the artifact in the editor of the other user, or retry with --lock-retry"
+	case errors.Is(err, api.ErrUnauthorized):
+		return deploy.ErrorCategoryUnauthorized, "Check the credentials of the tenant, or the client ID and secret of the service key"
+	case errors.Is(err, api.ErrRateLimited):
+		return deploy.ErrorCategoryRateLimited, "Too many requests were sent to the tenant, retry later or reduce --parallel-packages"
+	case errors.Is(err, api.ErrNotFound):
+		return deploy.ErrorCategoryNotFound, "Check the IDs in the configuration, the artifact or package does not exist on the tenant"
+	case errors.Is(err, api.ErrBadRequest):
+		return deploy.ErrorCategoryBadRequest, "Check the parameter keys and values in the configuration against the artifact"
+	}
+	return "", ""
+}
+
+// ArtifactResults returns a copy of the artifact results recorded so far
+func (s *Stats) ArtifactResults() []ArtifactResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.Artifacts)
+}
+
+// PackageResult summarizes the results of the artifacts of a package
+type PackageResult struct {
+	PackageID  string `json:"packageId"`
+	Configured int    `json:"configured"`
+	Failed     int    `json:"failed"` // Artifacts that failed to be configured or deployed, except locked ones
+	Deployed   int    `json:"deployed"`
+	DurationMs int64  `json:"durationMs"` // Time spent on the artifacts of the package
+}
+
+// PackageResults summarizes the artifact results by package. Packages with failed artifacts come first,
+// then packages are ordered by ID.
+func (s *Stats) PackageResults() []PackageResult {
+	var results []PackageResult
+	index := map[string]int{}
+	for _, artifact := range s.ArtifactResults() {
+		i, found := index[artifact.PackageID]
+		if !found {
+			i = len(results)
+			index[artifact.PackageID] = i
+			results = append(results, PackageResult{PackageID: artifact.PackageID})
+		}
+		result := &results[i]
+		result.DurationMs += artifact.DurationMs
+		switch {
+		case artifact.Category == deploy.ErrorCategoryLocked:
+		case artifact.Error != "":
+			result.Failed++
+		case artifact.Phase == PhaseConfigure:
+			result.Configured++
+		case artifact.Phase == PhaseDeploy:
+			result.Deployed++
+		}
+	}
+	slices.SortFunc(results, func(a, b PackageResult) int {
+		if (a.Failed > 0) != (b.Failed > 0) {
+			if a.Failed > 0 {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.PackageID, b.PackageID)
+	})
+	return results
+}
+
+// FailedArtifacts returns the results of the artifacts that failed to be configured or deployed, except
+// locked ones, ordered by package and artifact ID
+func (s *Stats) FailedArtifacts() []ArtifactResult {
+	var failed []ArtifactResult
+	for _, artifact := range s.ArtifactResults() {
+		if artifact.Error != "" && artifact.Category != deploy.ErrorCategoryLocked {
+			failed = append(failed, artifact)
+		}
+	}
+	slices.SortStableFunc(failed, func(a, b ArtifactResult) int {
+		if c := strings.Compare(a.PackageID, b.PackageID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ArtifactID, b.ArtifactID)
+	})
+	return failed
+}
+
+// ChangedArtifacts returns the results of the artifacts that were changed, by configuring or deploying them,
+// once per artifact in the order they were first changed
+func (s *Stats) ChangedArtifacts() []ArtifactResult {
+	var changed []ArtifactResult
+	seen := map[string]bool{}
+	for _, artifact := range s.ArtifactResults() {
+		key := artifact.PackageID + "/" + artifact.ArtifactID
+		if artifact.Changed && !seen[key] {
+			seen[key] = true
+			changed = append(changed, artifact)
+		}
+	}
+	return changed
+}
+
+// AddUnknownParameters records the keys of parameters that do not exist in the artifact
+func (s *Stats) AddUnknownParameters(artifactID string, keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addUnknownParameters(artifactID, keys)
+}
+
+func (s *Stats) addUnknownParameters(artifactID string, keys []string) {
+	if s.UnknownParameters == nil {
+		s.UnknownParameters = map[string][]string{}
+	}
+	s.UnknownParameters[artifactID] = append(s.UnknownParameters[artifactID], keys...)
+}
+
+// AddWarning records a warning issued during the run, to be listed in the summary
+func (s *Stats) AddWarning(format string, args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Warnings = append(s.Warnings, fmt.Sprintf(format, args...))
+}
+
+// AddBatchFallback records that parameters of an artifact were updated individually after a batch request failed
+func (s *Stats) AddBatchFallback(fallback BatchFallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.BatchFallbacks = append(s.BatchFallbacks, fallback)
+}
+
+// AddParameterError records an individual parameter update that failed
+func (s *Stats) AddParameterError(parameterError ParameterError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ParameterErrors = append(s.ParameterErrors, parameterError)
+}
+
+// Merge adds the counters, artifact results, unknown parameters, warnings, batch fallbacks and parameter errors of
+// other, e.g. of an attempt that is only counted if it succeeds, and its prefix check if s has none. Timings are not
+// merged.
+func (s *Stats) Merge(other *Stats) {
+	s.PackagesProcessed.Add(other.PackagesProcessed.Value())
+	s.PackagesWithErrors.Add(other.PackagesWithErrors.Value())
+	s.ArtifactsProcessed.Add(other.ArtifactsProcessed.Value())
+	s.ArtifactsConfigured.Add(other.ArtifactsConfigured.Value())
+	s.ArtifactsDeployed.Add(other.ArtifactsDeployed.Value())
+	s.ArtifactsFailed.Add(other.ArtifactsFailed.Value())
+	s.ArtifactsLocked.Add(other.ArtifactsLocked.Value())
+	s.ParametersUpdated.Add(other.ParametersUpdated.Value())
+	s.ParametersFailed.Add(other.ParametersFailed.Value())
+	s.ParametersUnchanged.Add(other.ParametersUnchanged.Value())
+	s.BatchRequestsExecuted.Add(other.BatchRequestsExecuted.Value())
+	s.IndividualRequestsUsed.Add(other.IndividualRequestsUsed.Value())
+	s.DeploymentTasksQueued.Add(other.DeploymentTasksQueued.Value())
+	s.DeploymentTasksSuccessful.Add(other.DeploymentTasksSuccessful.Value())
+	s.DeploymentTasksFailed.Add(other.DeploymentTasksFailed.Value())
+	s.DeploymentsUpToDate.Add(other.DeploymentsUpToDate.Value())
+	s.HooksFailed.Add(other.HooksFailed.Value())
+
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for artifactID, keys := range other.UnknownParameters {
+		s.addUnknownParameters(artifactID, keys)
+	}
+	s.Artifacts = append(s.Artifacts, other.Artifacts...)
+	s.Warnings = append(s.Warnings, other.Warnings...)
+	s.BatchFallbacks = append(s.BatchFallbacks, other.BatchFallbacks...)
+	s.ParameterErrors = append(s.ParameterErrors, other.ParameterErrors...)
+	if s.PrefixCheck == nil {
+		s.PrefixCheck = other.PrefixCheck
+	}
+}
+
+// Timings are the durations of a configuration run
+type Timings struct {
+	Total              time.Duration // Whole run including hooks and approval
+	Configure          time.Duration // Phase 1, configuration of all artifacts
+	Deploy             time.Duration // Phase 2, deployment of the configured artifacts
+	AveragePerArtifact time.Duration // Configure phase divided by the artifacts configured or failed
+	APIRequests        int           // Number of requests sent to the tenant
+	APILatencyP95      time.Duration // 95th percentile of the latency of the requests
+}
+
+// MarshalJSON writes the durations in milliseconds
+func (t Timings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(timingsJSON{t.Total.Milliseconds(), t.Configure.Milliseconds(), t.Deploy.Milliseconds(),
+		t.AveragePerArtifact.Milliseconds(), t.APIRequests, t.APILatencyP95.Milliseconds()})
+}
+
+type timingsJSON struct {
+	TotalMs              int64 `json:"totalMs"`
+	ConfigureMs          int64 `json:"configureMs"`
+	DeployMs             int64 `json:"deployMs"`
+	AveragePerArtifactMs int64 `json:"averagePerArtifactMs"`
+	APIRequests          int   `json:"apiRequests"`
+	APILatencyP95Ms      int64 `json:"apiLatencyP95Ms"`
+}
+
+// SetConfigureDuration sets the duration of the configure phase and the average duration per
+// artifact configured or failed
+func (s *Stats) SetConfigureDuration(d time.Duration) {
+	s.Timings.Configure = d
+	if artifacts := s.ArtifactsConfigured.Value() + s.ArtifactsFailed.Value(); artifacts > 0 {
+		s.Timings.AveragePerArtifact = d / time.Duration(artifacts)
+	}
+}
+
+// UnmarshalJSON reads the durations written by MarshalJSON
+func (t *Timings) UnmarshalJSON(data []byte) error {
+	var v timingsJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t = Timings{
+		Total:              time.Duration(v.TotalMs) * time.Millisecond,
+		Configure:          time.Duration(v.ConfigureMs) * time.Millisecond,
+		Deploy:             time.Duration(v.DeployMs) * time.Millisecond,
+		AveragePerArtifact: time.Duration(v.AveragePerArtifactMs) * time.Millisecond,
+		APIRequests:        v.APIRequests,
+		APILatencyP95:      time.Duration(v.APILatencyP95Ms) * time.Millisecond,
+	}
+	return nil
+}
+
+// Percentile returns the p-th percentile (0-100) of the durations using the nearest-rank method
+func Percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
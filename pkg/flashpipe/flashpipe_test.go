@@ -0,0 +1,157 @@
+package flashpipe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/deploy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 20; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 19*time.Millisecond, Percentile(latencies, 95), "95th percentile of 1-20 ms")
+	assert.Equal(t, 1*time.Millisecond, Percentile(latencies, 0), "0th percentile is the minimum")
+	assert.Equal(t, 20*time.Millisecond, Percentile(latencies, 100), "100th percentile is the maximum")
+	assert.Equal(t, time.Duration(0), Percentile(nil, 95), "Percentile of no latencies")
+	assert.Equal(t, 20*time.Millisecond, latencies[0], "Durations should not be sorted in place")
+}
+
+func TestStatsTimingsJSON(t *testing.T) {
+	stats := &Stats{}
+	stats.ArtifactsConfigured.Add(3)
+	stats.ArtifactsFailed.Inc()
+	stats.SetConfigureDuration(2 * time.Second)
+	stats.Timings.APILatencyP95 = 150 * time.Millisecond
+
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+	var report map[string]any
+	require.NoError(t, json.Unmarshal(data, &report))
+	timings := report["timings"].(map[string]any)
+	assert.Equal(t, 2000.0, timings["configureMs"], "Configure phase in milliseconds")
+	assert.Equal(t, 500.0, timings["averagePerArtifactMs"], "Average of configured and failed artifacts")
+	assert.Equal(t, 150.0, timings["apiLatencyP95Ms"], "p95 latency in milliseconds")
+	assert.Equal(t, 3.0, report["artifactsConfigured"])
+}
+
+func TestStatsMerge(t *testing.T) {
+	stats := &Stats{}
+	stats.PackagesProcessed.Add(2)
+	stats.ArtifactsConfigured.Inc()
+	stats.AddArtifactResult("Pkg1", "Flow1", PhaseConfigure, time.Second, nil)
+	stats.AddUnknownParameters("Flow1", []string{"Old"})
+
+	other := &Stats{}
+	other.ArtifactsConfigured.Add(2)
+	other.ArtifactsFailed.Inc()
+	other.ParametersUpdated.Add(5)
+	other.AddArtifactResult("Pkg2", "Flow2", PhaseConfigure, time.Second, nil)
+	other.AddUnknownParameters("Flow2", []string{"Renamed"})
+	other.AddWarning("Parameter %s not found in artifact %s, skipped", "Renamed", "Flow2")
+	stats.Merge(other)
+
+	assert.Equal(t, 2, stats.PackagesProcessed.Value())
+	assert.Equal(t, 3, stats.ArtifactsConfigured.Value(), "Counters should be added")
+	assert.Equal(t, 1, stats.ArtifactsFailed.Value())
+	assert.Equal(t, 5, stats.ParametersUpdated.Value())
+	require.Len(t, stats.Artifacts, 2)
+	assert.Equal(t, "Flow2", stats.Artifacts[1].ArtifactID, "Results of other should be appended")
+	assert.Equal(t, map[string][]string{"Flow1": {"Old"}, "Flow2": {"Renamed"}}, stats.UnknownParameters)
+	assert.Equal(t, []string{"Parameter Renamed not found in artifact Flow2, skipped"}, stats.Warnings)
+}
+
+func TestStatsConcurrent(t *testing.T) {
+	stats := &Stats{}
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats.ArtifactsConfigured.Inc()
+			stats.ParametersUpdated.Add(2)
+			stats.AddArtifactResult("Pkg", fmt.Sprintf("Flow%d", i), PhaseConfigure, time.Millisecond, nil)
+			stats.AddDeploymentResult("Pkg", fmt.Sprintf("Flow%d", i), "Integration", time.Millisecond, nil)
+			stats.AddUnknownParameters("Flow", []string{"Old"})
+			stats.AddWarning("Warning %d", i)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, stats.ArtifactsConfigured.Value())
+	assert.Equal(t, 100, stats.ParametersUpdated.Value())
+	assert.Len(t, stats.ArtifactResults(), 100)
+	assert.Len(t, stats.UnknownParameters["Flow"], 50)
+	assert.Len(t, stats.Warnings, 50)
+	assert.Equal(t, []PackageResult{{PackageID: "Pkg", Configured: 50, Deployed: 50, DurationMs: 100}}, stats.PackageResults())
+
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+	read := &Stats{}
+	require.NoError(t, json.Unmarshal(data, read))
+	assert.Equal(t, 100, read.ParametersUpdated.Value(), "Counters should be read back")
+}
+
+func TestStatsPackageResults(t *testing.T) {
+	stats := &Stats{}
+	stats.AddArtifactResult("Orders", "Orders_Replicate", PhaseConfigure, 200*time.Millisecond, nil)
+	stats.AddArtifactResult("Orders", "Orders_Replicate", PhaseDeploy, 2*time.Second, nil)
+	stats.AddArtifactResult("Billing", "Billing_Export", PhaseConfigure, 100*time.Millisecond, nil)
+	stats.AddArtifactResult("Sales", "Sales_Quote", PhaseConfigure, 300*time.Millisecond, errors.New("parameter not found"))
+	stats.AddArtifactResult("Sales", "Sales_Order", PhaseConfigure, 100*time.Millisecond, nil)
+	stats.AddArtifactResult("Sales", "Sales_Order", PhaseDeploy, time.Second, errors.New("deployment failed"))
+	stats.AddArtifactResult("Sales", "Sales_Lead", PhaseConfigure, 0, &deploy.Error{Category: deploy.ErrorCategoryLocked})
+
+	assert.Equal(t, []PackageResult{
+		{PackageID: "Sales", Configured: 1, Failed: 2, Deployed: 0, DurationMs: 1400},
+		{PackageID: "Billing", Configured: 1, DurationMs: 100},
+		{PackageID: "Orders", Configured: 1, Deployed: 1, DurationMs: 2200},
+	}, stats.PackageResults())
+
+	failed := stats.FailedArtifacts()
+	require.Len(t, failed, 2)
+	assert.Equal(t, "Sales_Order", failed[0].ArtifactID)
+	assert.Equal(t, PhaseDeploy, failed[0].Phase)
+	assert.Equal(t, "Sales_Quote", failed[1].ArtifactID)
+}
+
+func TestArtifactResultCategory(t *testing.T) {
+	stats := &Stats{}
+	stats.AddArtifactResult("Pkg", "Flow1", PhaseConfigure, 0, fmt.Errorf("update failed: %w", api.ErrNotFound))
+	stats.AddArtifactResult("Pkg", "Flow2", PhaseConfigure, 0, fmt.Errorf("update failed: %w", api.ErrRateLimited))
+	stats.AddArtifactResult("Pkg", "Flow3", PhaseConfigure, 0, errors.New("other"))
+
+	results := stats.ArtifactResults()
+	assert.Equal(t, deploy.ErrorCategoryNotFound, results[0].Category)
+	assert.NotEmpty(t, results[0].Hint)
+	assert.Equal(t, deploy.ErrorCategoryRateLimited, results[1].Category)
+	assert.Empty(t, results[2].Category)
+}
+
+func TestStatsChangedArtifacts(t *testing.T) {
+	stats := &Stats{}
+	stats.AddConfigurationResult("Orders", "Orders_Replicate", true, time.Second, nil)
+	stats.AddDeploymentResult("Orders", "Orders_Replicate", "Integration", time.Second, nil)
+	stats.AddConfigurationResult("Orders", "Orders_Status", false, time.Second, nil)
+	stats.AddSkippedDeployment("Orders", "Orders_Status", "Integration")
+	stats.AddConfigurationResult("Sales", "Sales_Quote", false, time.Second, nil)
+	stats.AddDeploymentResult("Sales", "Sales_Quote", "Integration", time.Second, nil)
+	stats.AddConfigurationResult("Sales", "Sales_Order", true, time.Second, errors.New("parameter not found"))
+
+	changed := stats.ChangedArtifacts()
+	require.Len(t, changed, 2, "Unchanged, skipped and failed artifacts should be left out")
+	assert.Equal(t, "Orders_Replicate", changed[0].ArtifactID)
+	assert.Equal(t, PhaseConfigure, changed[0].Phase)
+	assert.Equal(t, "Sales_Quote", changed[1].ArtifactID)
+	assert.Equal(t, PhaseDeploy, changed[1].Phase)
+}
@@ -0,0 +1,60 @@
+package flashpipe
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ResolveParameterGroups adds the parameters of the groups in useGroups of each artifact to its parameters.
+// Groups defined in any of the files can be used in all files. Keys of later groups override those of earlier
+// groups, and parameters of the artifact, inline or from parametersFrom, override all groups. LoadConfigFiles
+// resolves the groups of the loaded files, configurations decoded otherwise can be resolved with it.
+func ResolveParameterGroups(configFiles []*ConfigFile) error {
+	groups := map[string][]ConfigurationParameter{}
+	sources := map[string]string{}
+	for _, configFile := range configFiles {
+		for name, parameters := range configFile.Config.ParameterGroups {
+			if source, exists := sources[name]; exists {
+				return fmt.Errorf("parameter group %s is defined in %s and %s", name, source, configFile.Source)
+			}
+			groups[name] = parameters
+			sources[name] = configFile.Source
+		}
+	}
+
+	var errs []error
+	for _, configFile := range configFiles {
+		for pi := range configFile.Config.Packages {
+			for ai := range configFile.Config.Packages[pi].Artifacts {
+				artifact := &configFile.Config.Packages[pi].Artifacts[ai]
+				var keys []string
+				parameters := map[string]ConfigurationParameter{}
+				for _, name := range artifact.UseGroups {
+					group, found := groups[name]
+					if !found {
+						errs = append(errs, fmt.Errorf("%s: artifact %s uses undefined parameter group %s", configFile.Source, artifact.ID, name))
+						continue
+					}
+					for _, param := range group {
+						if _, exists := parameters[param.Key]; !exists {
+							keys = append(keys, param.Key)
+						}
+						// The value is reported as set in the group, at the line of its definition
+						if param.Source != nil {
+							source := *param.Source
+							source.Kind, source.Ref = ValueSourceGroup, name
+							param.Source = &source
+						}
+						parameters[param.Key] = param
+					}
+				}
+				for _, key := range keys {
+					if findParameter(artifact.Parameters, key) == nil {
+						artifact.Parameters = append(artifact.Parameters, parameters[key])
+					}
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package flashpipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParameterGroups(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db.password"), []byte("secret"), 0644))
+
+	cfg, err := ParseConfig(filepath.Join(dir, "config.yml"), []byte(`parameterGroups:
+  common-db:
+    - key: DB_Host
+      value: db.example.com
+    - key: DB_Port
+      value: 5432
+    - key: DB_Password
+      fromFile: db.password
+  reporting-db:
+    - key: DB_Host
+      value: reporting.example.com
+packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        useGroups: [common-db]
+        parameters:
+          - key: DB_Port
+            value: 6432
+      - artifactId: Reports
+        useGroups: [common-db, reporting-db]
+`), nil)
+	require.NoError(t, err)
+
+	orders := cfg.Packages[0].Artifacts[0]
+	require.Len(t, orders.Parameters, 3)
+	assert.Equal(t, "6432", orders.Parameters[0].Value, "Inline parameters win")
+	assert.Equal(t, "DB_Host", orders.Parameters[1].Key)
+	assert.Equal(t, "db.example.com", orders.Parameters[1].Value)
+	assert.Equal(t, "secret", orders.Parameters[2].Value, "fromFile is resolved relative to the file of the group")
+
+	reports := cfg.Packages[0].Artifacts[1]
+	require.Len(t, reports.Parameters, 3)
+	assert.Equal(t, "reporting.example.com", reports.Parameters[0].Value, "Later groups win")
+
+	_, err = ParseConfig("config.yml", []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        useGroups: [common-db]
+`), nil)
+	assert.EqualError(t, err, "config.yml: artifact Orders uses undefined parameter group common-db")
+}
+
+func TestParameterGroupsAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00-groups.yml"), []byte(`parameterGroups:
+  common-db:
+    - key: DB_Host
+      value: db.example.com
+packages: []
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sales.yml"), []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        useGroups: [common-db]
+`), 0644))
+
+	configFiles, err := LoadConfigFiles(dir, nil)
+	require.NoError(t, err)
+	merged := MergeConfigs(configFiles, "")
+	assert.Equal(t, "db.example.com", merged.Packages[0].Artifacts[0].Parameters[0].Value)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "finance.yml"), []byte(`parameterGroups:
+  common-db:
+    - key: DB_Host
+      value: finance.example.com
+packages: []
+`), 0644))
+	_, err = LoadConfigFiles(dir, nil)
+	assert.ErrorContains(t, err, "parameter group common-db is defined in")
+}
@@ -0,0 +1,75 @@
+package flashpipe
+
+import (
+	"slices"
+	"strings"
+)
+
+// Update modes of configuration parameters
+const (
+	ParameterModeSet        = "set"
+	ParameterModeSetIfEmpty = "set-if-empty"
+	ParameterModeAppend     = "append"
+	ParameterModeDelete     = "delete"
+)
+
+// ParameterModes are the supported update modes, empty defaults to set
+var ParameterModes = []string{"", ParameterModeSet, ParameterModeSetIfEmpty, ParameterModeAppend, ParameterModeDelete}
+
+// ParameterValue returns the value a parameter is updated to according to its mode, given the current value
+// on the tenant and, for the delete mode, the design-time default. update is false if the current value is kept.
+func ParameterValue(param ConfigurationParameter, current string, defaultValue string) (value string, update bool) {
+	switch param.Mode {
+	case ParameterModeSetIfEmpty:
+		if current != "" {
+			return current, false
+		}
+		return param.Value, param.Value != ""
+	case ParameterModeAppend:
+		sep := separator(param)
+		var items []string
+		if current != "" {
+			items = strings.Split(current, sep)
+		}
+		update = false
+		for _, item := range strings.Split(param.Value, sep) {
+			if item != "" && !slices.Contains(items, item) {
+				items = append(items, item)
+				update = true
+			}
+		}
+		return strings.Join(items, sep), update
+	case ParameterModeDelete:
+		return defaultValue, current != defaultValue
+	default:
+		return param.Value, true
+	}
+}
+
+// ParameterSatisfied returns true if the current value on the tenant complies with the parameter. Parameters
+// with the delete mode are always satisfied as the design-time default is not known here.
+func ParameterSatisfied(param ConfigurationParameter, current string) bool {
+	switch param.Mode {
+	case ParameterModeSetIfEmpty:
+		return current != "" || param.Value == ""
+	case ParameterModeAppend:
+		sep := separator(param)
+		for _, item := range strings.Split(param.Value, sep) {
+			if item != "" && !slices.Contains(strings.Split(current, sep), item) {
+				return false
+			}
+		}
+		return true
+	case ParameterModeDelete:
+		return true
+	default:
+		return current == param.Value
+	}
+}
+
+func separator(param ConfigurationParameter) string {
+	if param.Separator == "" {
+		return ","
+	}
+	return param.Separator
+}
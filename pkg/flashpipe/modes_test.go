@@ -0,0 +1,33 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterValue(t *testing.T) {
+	tests := []struct {
+		param   ConfigurationParameter
+		current string
+		value   string
+		update  bool
+	}{
+		{ConfigurationParameter{Value: "new"}, "old", "new", true},
+		{ConfigurationParameter{Value: "new", Mode: ParameterModeSetIfEmpty}, "old", "old", false},
+		{ConfigurationParameter{Value: "new", Mode: ParameterModeSetIfEmpty}, "", "new", true},
+		{ConfigurationParameter{Value: "b;c", Mode: ParameterModeAppend, Separator: ";"}, "a;b", "a;b;c", true},
+		{ConfigurationParameter{Value: "a", Mode: ParameterModeAppend}, "a,b", "a,b", false},
+		{ConfigurationParameter{Value: "a", Mode: ParameterModeAppend}, "", "a", true},
+		{ConfigurationParameter{Mode: ParameterModeDelete}, "custom", "default", true},
+		{ConfigurationParameter{Mode: ParameterModeDelete}, "default", "default", false},
+	}
+	for _, tt := range tests {
+		value, update := ParameterValue(tt.param, tt.current, "default")
+		assert.Equal(t, tt.value, value, "Incorrect value for mode %q and current value %q", tt.param.Mode, tt.current)
+		assert.Equal(t, tt.update, update, "Incorrect update flag for mode %q and current value %q", tt.param.Mode, tt.current)
+		if !tt.update && tt.param.Mode != ParameterModeDelete {
+			assert.True(t, ParameterSatisfied(tt.param, tt.current), "Unchanged value should satisfy mode %q", tt.param.Mode)
+		}
+	}
+}
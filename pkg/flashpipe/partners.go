@@ -0,0 +1,138 @@
+package flashpipe
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// partnerPattern matches the references to a column of a partner, e.g. ${partner.id}
+var partnerPattern = regexp.MustCompile(`\$\{partner\.([^}]+)\}`)
+
+// expandPartners replaces each artifact with partners by one artifact per partner, in which the references to the
+// columns of the partner in the ID, display name and parameter values are substituted. Partners of the from file
+// come first, followed by the inline rows. Relative paths are resolved against dir. An error is returned if a
+// column is unknown or an ID occurs twice in a package.
+func expandPartners(cfg *ConfigureConfig, dir string, opts LoadOptions) error {
+	for pi := range cfg.Packages {
+		pkg := &cfg.Packages[pi]
+		if !slices.ContainsFunc(pkg.Artifacts, func(artifact ConfigureArtifact) bool { return artifact.Partners != nil }) {
+			continue
+		}
+		err := expandArtifacts(pkg, "check that the artifact ID references a unique column of partners", func(artifact ConfigureArtifact) ([]ConfigureArtifact, error) {
+			if artifact.Partners == nil {
+				return []ConfigureArtifact{artifact}, nil
+			}
+			rows := artifact.Partners.Rows
+			if artifact.Partners.From != "" {
+				path := artifact.Partners.From
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(dir, path)
+				}
+				if err := opts.verify(path); err != nil {
+					return nil, fmt.Errorf("package %s: partners of artifact %s: %w", pkg.ID, artifact.ID, err)
+				}
+				fileRows, err := readPartnerTable(path)
+				if err != nil {
+					return nil, fmt.Errorf("package %s: partners of artifact %s: %w", pkg.ID, artifact.ID, err)
+				}
+				rows = append(fileRows, rows...)
+			}
+			var instances []ConfigureArtifact
+			for i, row := range rows {
+				instance, err := instantiatePartner(artifact, row)
+				if err != nil {
+					return nil, fmt.Errorf("package %s: partner %d of artifact %s: %w", pkg.ID, i+1, artifact.ID, err)
+				}
+				instances = append(instances, instance)
+			}
+			return instances, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instantiatePartner returns a copy of the artifact for a single partner
+func instantiatePartner(artifact ConfigureArtifact, row map[string]string) (ConfigureArtifact, error) {
+	var err error
+	substitute := func(value string) string {
+		return partnerPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			column := partnerPattern.FindStringSubmatch(ref)[1]
+			cell, ok := row[column]
+			if !ok && err == nil {
+				err = fmt.Errorf("unknown column %s", column)
+			}
+			return cell
+		})
+	}
+	substituteParameters := func(parameters []ConfigurationParameter) []ConfigurationParameter {
+		result := slices.Clone(parameters)
+		for i := range result {
+			result[i].Value = substitute(result[i].Value)
+		}
+		return result
+	}
+
+	instance := artifact
+	instance.Partners = nil
+	instance.ID = substitute(artifact.ID)
+	instance.DisplayName = substitute(artifact.DisplayName)
+	instance.Parameters = substituteParameters(artifact.Parameters)
+	instance.DeployAs = slices.Clone(artifact.DeployAs)
+	for i := range instance.DeployAs {
+		instance.DeployAs[i].Parameters = substituteParameters(instance.DeployAs[i].Parameters)
+	}
+	return instance, err
+}
+
+// readPartnerTable reads the partners of a CSV file with a header row, or of a YAML or JSON file with a list of
+// objects. Values are trimmed and empty CSV rows are skipped.
+func readPartnerTable(path string) ([]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml", ".json":
+		var rows []map[string]string
+		if err := yaml.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return rows, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		if !slices.ContainsFunc(record, func(value string) bool { return strings.TrimSpace(value) != "" }) {
+			continue
+		}
+		row := map[string]string{}
+		for i, column := range header {
+			row[column] = strings.TrimSpace(record[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
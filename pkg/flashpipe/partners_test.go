@@ -0,0 +1,167 @@
+package flashpipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPartners(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partners.csv"), []byte("id, name, endpoint\nACME, Acme Corp, https://acme.example.com/orders\n,,\nGLOBEX,\"Globex, Inc.\",https://globex.example.com\n"), 0644))
+	name := filepath.Join(dir, "config.yml")
+
+	cfg, err := ParseConfig(name, []byte(`packages:
+  - integrationSuiteId: Partners
+    artifacts:
+      - artifactId: Orders_${partner.id}
+        displayName: Orders ${partner.name}
+        type: Integration
+        deploy: true
+        partners:
+          from: partners.csv
+          rows:
+            - id: 4711
+              name: Initech
+              endpoint: https://initech.example.com
+        parameters:
+          - key: Endpoint
+            value: ${partner.endpoint}
+          - key: Header
+            value: ${header.SAP_Sender}
+`), nil)
+	require.NoError(t, err)
+
+	artifacts := cfg.Packages[0].Artifacts
+	if assert.Len(t, artifacts, 3) {
+		assert.Equal(t, "Orders_ACME", artifacts[0].ID)
+		assert.Equal(t, "Orders Acme Corp", artifacts[0].DisplayName)
+		assert.True(t, artifacts[0].Deploy)
+		assert.Nil(t, artifacts[0].Partners)
+		assert.Equal(t, []string{"Endpoint=https://acme.example.com/orders", "Header=${header.SAP_Sender}"}, parameterValues(artifacts[0].Parameters))
+		assert.Equal(t, "Orders_GLOBEX", artifacts[1].ID)
+		assert.Equal(t, "Orders Globex, Inc.", artifacts[1].DisplayName)
+		assert.Equal(t, "Orders_4711", artifacts[2].ID)
+		assert.Equal(t, []string{"Endpoint=https://initech.example.com", "Header=${header.SAP_Sender}"}, parameterValues(artifacts[2].Parameters))
+	}
+}
+
+func TestExpandPartnersYAML(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partners.yml"), []byte("- id: ACME\n  client: 100\n- id: GLOBEX\n  client: 200\n"), 0644))
+
+	cfg, err := ParseConfig(filepath.Join(dir, "config.yml"), []byte(`packages:
+  - integrationSuiteId: Partners
+    artifacts:
+      - artifactId: Orders_${partner.id}
+        type: Integration
+        partners:
+          from: partners.yml
+        deployAs:
+          - prefix: DEV_
+            parameters:
+              - key: Client
+                value: ${partner.client}
+`), nil)
+	require.NoError(t, err)
+
+	artifacts := cfg.Packages[0].Artifacts
+	if assert.Len(t, artifacts, 2) {
+		assert.Equal(t, "DEV_Orders_ACME", artifacts[0].ID)
+		assert.Equal(t, []string{"Client=100"}, parameterValues(artifacts[0].Parameters))
+		assert.Equal(t, "DEV_Orders_GLOBEX", artifacts[1].ID)
+		assert.Equal(t, []string{"Client=200"}, parameterValues(artifacts[1].Parameters))
+	}
+}
+
+func TestExpandPartnersFolder(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"partners/orders.csv": "id,client\nACME,100\nGLOBEX,200\n",
+		"orders.yml": `packages:
+  - integrationSuiteId: Partners
+    artifacts:
+      - artifactId: Orders_${partner.id}
+        type: Integration
+        partners:
+          from: partners/orders.csv
+        parameters:
+          - key: Client
+            value: ${partner.client}
+`,
+	})
+
+	files, err := LoadConfigFiles(dir, nil)
+	require.NoError(t, err)
+
+	require.Len(t, files, 1)
+	artifacts := files[0].Config.Packages[0].Artifacts
+	if assert.Len(t, artifacts, 2, "The partners should be expanded for the files of a folder") {
+		assert.Equal(t, "Orders_ACME", artifacts[0].ID)
+		assert.Equal(t, []string{"Client=100"}, parameterValues(artifacts[0].Parameters))
+		assert.Equal(t, "Orders_GLOBEX", artifacts[1].ID)
+		assert.Equal(t, []string{"Client=200"}, parameterValues(artifacts[1].Parameters))
+	}
+}
+
+func TestLoadConfigFilesFolderNoFileReferences(t *testing.T) {
+	for name, config := range map[string]string{
+		"fromFile":       "packages:\n  - integrationSuiteId: Package\n    artifacts:\n      - artifactId: Flow\n        parameters:\n          - key: Secret\n            fromFile: secret.txt\n",
+		"parametersFrom": "packages:\n  - integrationSuiteId: Package\n    artifacts:\n      - artifactId: Flow\n        parametersFrom:\n          - secret.txt\n",
+		"partners":       "packages:\n  - integrationSuiteId: Package\n    artifacts:\n      - artifactId: Flow_${partner.id}\n        partners:\n          from: secret.txt\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigFiles(t, dir, map[string]string{"secret.txt": "id=secret\n", "config.yml": config})
+
+			_, err := LoadConfigFilesWithOptions(dir, nil, LoadOptions{NoFileReferences: true})
+			assert.ErrorContains(t, err, "not allowed", "The files of a folder should not reference files")
+		})
+	}
+}
+
+func TestExpandPartnersInvalid(t *testing.T) {
+	for name, test := range map[string]struct {
+		config string
+		err    string
+	}{
+		"unknown column": {`packages:
+  - integrationSuiteId: Partners
+    artifacts:
+      - artifactId: Orders_${partner.id}
+        type: Integration
+        partners:
+          rows:
+            - id: ACME
+        parameters:
+          - key: Endpoint
+            value: ${partner.url}
+`, "config.yml: package Partners: partner 1 of artifact Orders_${partner.id}: unknown column url"},
+		"duplicate ID": {`packages:
+  - integrationSuiteId: Partners
+    artifacts:
+      - artifactId: Orders_${partner.id}
+        type: Integration
+        partners:
+          rows:
+            - id: ACME
+            - id: ACME
+`, "config.yml: package Partners: artifact Orders_ACME is configured more than once"},
+		"missing file": {`packages:
+  - integrationSuiteId: Partners
+    artifacts:
+      - artifactId: Orders_${partner.id}
+        type: Integration
+        partners:
+          from: missing.csv
+`, "config.yml: package Partners: partners of artifact Orders_${partner.id}"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig("config.yml", []byte(test.config), nil)
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
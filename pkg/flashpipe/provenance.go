@@ -0,0 +1,96 @@
+package flashpipe
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Kinds of ValueSource
+const (
+	ValueSourceConfig         = "config"         // Set in the configuration file
+	ValueSourceFromFile       = "fromFile"       // Read from the file of fromFile
+	ValueSourceParametersFrom = "parametersFrom" // Read from a file of parametersFrom
+	ValueSourceGroup          = "group"          // Set in a parameter group used by the artifact
+	ValueSourceTenantDefaults = "tenantDefaults" // Set in the tenant defaults of the configuration or target
+	ValueSourceValues         = "values"         // Rendered from keys of the values files
+	ValueSourceEnv            = "env"            // Rendered from environment variables
+	ValueSourceDestination    = "destination"    // Read from a BTP destination with valueFrom
+)
+
+var (
+	// valuesPattern matches the values keys referenced in a template, e.g. {{ .Values.erp.host }}
+	valuesPattern = regexp.MustCompile(`\.Values\.([A-Za-z0-9_.]+)`)
+	// envPattern matches the environment variables read in a template, e.g. {{ env "ERP_HOST" }}
+	envPattern = regexp.MustCompile(`\benv\s+"([^"]+)"`)
+)
+
+// setValueSources records the configuration file and line of the parameters of cfg. If the file was rendered
+// from a template with the same lines, the values keys and environment variables referenced on the line of a
+// parameter in the template are recorded as well.
+func setValueSources(cfg *ConfigureConfig, source string, template []byte, rendered []byte) {
+	var lines []string
+	if !bytes.Equal(template, rendered) && bytes.Count(template, []byte("\n")) == bytes.Count(rendered, []byte("\n")) {
+		lines = strings.Split(string(template), "\n")
+	}
+	set := func(parameters []ConfigurationParameter) {
+		for i := range parameters {
+			param := &parameters[i]
+			s := ValueSource{File: source, Line: param.Line, Kind: ValueSourceConfig}
+			switch {
+			case param.FromFile != "":
+				s.Kind, s.Ref = ValueSourceFromFile, param.FromFile
+			case param.Line > 0 && param.Line <= len(lines):
+				s.Kind, s.Ref = templateReferences(lines[param.Line-1:])
+			}
+			param.Source = &s
+		}
+	}
+	for name := range cfg.ParameterGroups {
+		set(cfg.ParameterGroups[name])
+	}
+	set(cfg.TenantDefaults)
+	for ti := range cfg.Targets {
+		set(cfg.Targets[ti].TenantDefaults)
+	}
+	for pi := range cfg.Packages {
+		for ai := range cfg.Packages[pi].Artifacts {
+			set(cfg.Packages[pi].Artifacts[ai].Parameters)
+			for _, instance := range cfg.Packages[pi].Artifacts[ai].DeployAs {
+				set(instance.Parameters)
+			}
+		}
+	}
+}
+
+// templateReferences returns the values keys, or else the environment variables, referenced in the template
+// of a parameter that starts at lines[0], up to the next line that is not indented further
+func templateReferences(lines []string) (string, string) {
+	indent := len(lines[0]) - len(strings.TrimLeft(lines[0], " "))
+	text := lines[0]
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) != "" && len(line)-len(strings.TrimLeft(line, " ")) <= indent {
+			break
+		}
+		text += "\n" + line
+	}
+	if keys := submatches(valuesPattern, text); len(keys) > 0 {
+		return ValueSourceValues, strings.Join(keys, ", ")
+	}
+	if names := submatches(envPattern, text); len(names) > 0 {
+		return ValueSourceEnv, strings.Join(names, ", ")
+	}
+	return ValueSourceConfig, ""
+}
+
+// submatches returns the distinct first submatches of pattern in text, in the order of text
+func submatches(pattern *regexp.Regexp, text string) []string {
+	var matches []string
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		if !slices.Contains(matches, m[1]) {
+			matches = append(matches, m[1])
+		}
+	}
+	return matches
+}
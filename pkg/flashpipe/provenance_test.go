@@ -0,0 +1,79 @@
+package flashpipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueSources(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("certificate"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders.properties"), []byte("# Orders\nTimeout=60\nRetries=3\n"), 0644))
+	t.Setenv("ERP_USER", "erp")
+	path := filepath.Join(dir, "prod.yml")
+
+	cfg, err := parseConfig(path, []byte(`parameterGroups:
+  erp:
+    - key: ERP_Client
+      value: "100"
+packages:
+  - integrationSuiteId: Sales
+    artifacts:
+      - artifactId: Orders
+        useGroups: [erp]
+        parametersFrom: [orders.properties]
+        parameters:
+          - key: ERP_Host
+            value: "{{ .Values.erp.host }}:{{ .Values.erp.port }}"
+          - key: ERP_User
+            value: '{{ env "ERP_USER" }}'
+          - key: Certificate
+            fromFile: ca.pem
+          - key: Retries
+            value: "5"
+        deploy: {{ .Values.deploy }}
+`), map[string]interface{}{"erp": map[string]interface{}{"host": "erp.example.com", "port": 443}, "deploy": true}, LoadOptions{Template: true})
+	require.NoError(t, err)
+
+	sources := map[string]string{}
+	for _, param := range cfg.Packages[0].Artifacts[0].Parameters {
+		require.NotNil(t, param.Source, param.Key)
+		sources[param.Key] = param.Source.String()
+	}
+	assert.Equal(t, map[string]string{
+		"ERP_Host":    path + ":12 (values erp.host, erp.port)",
+		"ERP_User":    path + ":14 (env ERP_USER)",
+		"Certificate": path + ":16 (fromFile ca.pem)",
+		"Retries":     path + ":18",
+		"Timeout":     filepath.Join(dir, "orders.properties") + ":2 (parametersFrom)",
+		"ERP_Client":  path + ":3 (group erp)",
+	}, sources, "Inline parameters should win and the values key of deploy should not be attributed to Retries")
+
+	// Lines of TOML files are not known
+	cfg, err = parseConfig("prod.toml", []byte(`[[packages]]
+integrationSuiteId = "Sales"
+[[packages.artifacts]]
+artifactId = "Orders"
+[[packages.artifacts.parameters]]
+key = "Timeout"
+value = "60"
+`), nil, LoadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &ValueSource{File: "prod.toml", Kind: ValueSourceConfig}, cfg.Packages[0].Artifacts[0].Parameters[0].Source)
+}
+
+func TestAddParameterSources(t *testing.T) {
+	stats := &Stats{}
+	stats.AddConfigurationResult("Sales", "Orders", true, time.Second, nil)
+	stats.AddDeploymentResult("Sales", "Orders", "Integration", time.Second, nil)
+	source := &ValueSource{File: "prod.yml", Line: 12, Kind: ValueSourceValues, Ref: "erp.host"}
+	stats.AddParameterSources("Sales", "Orders", []ConfigurationParameter{{Key: "ERP_Host", Value: "secret", Source: source}})
+
+	assert.Equal(t, []ParameterValueSource{{Key: "ERP_Host", Source: source}}, stats.Artifacts[0].Parameters)
+	assert.Empty(t, stats.Artifacts[1].Parameters, "Sources should only be recorded for the configuration")
+}
@@ -0,0 +1,51 @@
+package flashpipe
+
+import "slices"
+
+// ArtifactTags returns the tags of an artifact, which are its own tags and those of its package
+func ArtifactTags(pkg ConfigurePackage, artifact ConfigureArtifact) []string {
+	tags := slices.Clone(pkg.Tags)
+	for _, tag := range artifact.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// SelectTags returns a copy of the configuration with the artifacts that have at least one of the tags, all
+// artifacts if tags is empty, and none of the excluded tags. Packages without artifacts are selected by their own
+// tags, packages left without artifacts are skipped.
+func SelectTags(cfg *ConfigureConfig, tags []string, excludeTags []string) *ConfigureConfig {
+	if len(tags) == 0 && len(excludeTags) == 0 {
+		return cfg
+	}
+	selected := func(itemTags []string) bool {
+		if slices.ContainsFunc(itemTags, func(tag string) bool { return slices.Contains(excludeTags, tag) }) {
+			return false
+		}
+		return len(tags) == 0 || slices.ContainsFunc(itemTags, func(tag string) bool { return slices.Contains(tags, tag) })
+	}
+
+	result := *cfg
+	result.Packages = nil
+	for _, pkg := range cfg.Packages {
+		if len(pkg.Artifacts) == 0 {
+			if selected(pkg.Tags) {
+				result.Packages = append(result.Packages, pkg)
+			}
+			continue
+		}
+		artifacts := pkg.Artifacts
+		pkg.Artifacts = nil
+		for _, artifact := range artifacts {
+			if selected(ArtifactTags(pkg, artifact)) {
+				pkg.Artifacts = append(pkg.Artifacts, artifact)
+			}
+		}
+		if len(pkg.Artifacts) > 0 {
+			result.Packages = append(result.Packages, pkg)
+		}
+	}
+	return &result
+}
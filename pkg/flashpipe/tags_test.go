@@ -0,0 +1,55 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectTags(t *testing.T) {
+	cfg, err := ParseConfig("config.yml", []byte(`packages:
+  - integrationSuiteId: Payroll
+    tags: [payroll]
+    artifacts:
+      - artifactId: Payroll_Export
+        tags: [critical]
+      - artifactId: Payroll_Preview
+        tags: [experimental]
+  - integrationSuiteId: Billing
+    artifacts:
+      - artifactId: Invoices
+        tags: [billing, critical]
+      - artifactId: Credit_Notes
+        tags: [billing]
+  - integrationSuiteId: Common
+    tags: [payroll]
+    deploy: true
+    artifacts: []
+`), nil)
+	require.NoError(t, err)
+
+	ids := func(cfg *ConfigureConfig) []string {
+		var result []string
+		for _, pkg := range cfg.Packages {
+			if len(pkg.Artifacts) == 0 {
+				result = append(result, pkg.ID)
+			}
+			for _, artifact := range pkg.Artifacts {
+				result = append(result, pkg.ID+"/"+artifact.ID)
+			}
+		}
+		return result
+	}
+
+	assert.Same(t, cfg, SelectTags(cfg, nil, nil), "Without tags the configuration is used as is")
+	assert.Equal(t, []string{"Payroll/Payroll_Export", "Payroll/Payroll_Preview", "Common"}, ids(SelectTags(cfg, []string{"payroll"}, nil)),
+		"Artifacts should inherit the tags of the package")
+	assert.Equal(t, []string{"Payroll/Payroll_Export", "Billing/Invoices", "Billing/Credit_Notes"}, ids(SelectTags(cfg, []string{"billing", "critical"}, nil)))
+	assert.Equal(t, []string{"Payroll/Payroll_Export", "Common"}, ids(SelectTags(cfg, []string{"payroll"}, []string{"experimental"})))
+	assert.Equal(t, []string{"Payroll/Payroll_Export", "Billing/Invoices", "Billing/Credit_Notes", "Common"}, ids(SelectTags(cfg, nil, []string{"experimental"})))
+	assert.Empty(t, ids(SelectTags(cfg, []string{"unknown"}, nil)))
+	assert.Len(t, cfg.Packages[0].Artifacts, 2, "The configuration is not changed")
+
+	assert.Equal(t, []string{"payroll", "critical"}, ArtifactTags(cfg.Packages[0], cfg.Packages[0].Artifacts[0]))
+}
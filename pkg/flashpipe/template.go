@@ -0,0 +1,417 @@
+package flashpipe
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions of configuration files rendered with LoadOptions.Template. They are a subset of
+// the sprig functions with the same names and arguments, so that templates stay compatible with Helm and
+// other sprig users.
+var templateFuncs = template.FuncMap{
+	// Strings
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      title,
+	"trim":       strings.TrimSpace,
+	"trimAll":    func(cutset string, s string) string { return strings.Trim(s, cutset) },
+	"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+	"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+	"contains":   func(substr string, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+	"trunc":      trunc,
+	"quote":      quote,
+	"squote":     squote,
+	"cat":        cat,
+	"indent":     indent,
+	"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+	"join":       join,
+	"splitList":  func(sep string, s string) []interface{} { return toInterfaces(strings.Split(s, sep)) },
+	"toString":   toString,
+
+	// Defaults and conditions
+	"default":  defaultValue,
+	"empty":    empty,
+	"coalesce": coalesce,
+	"ternary": func(vt interface{}, vf interface{}, condition bool) interface{} {
+		if condition {
+			return vt
+		}
+		return vf
+	},
+	"fail": func(message string) (string, error) { return "", errors.New(message) },
+
+	// Lists
+	"list":      func(items ...interface{}) []interface{} { return items },
+	"first":     first,
+	"last":      last,
+	"rest":      rest,
+	"append":    func(list interface{}, v interface{}) ([]interface{}, error) { return appendList(list, v) },
+	"concat":    concat,
+	"has":       has,
+	"uniq":      uniq,
+	"sortAlpha": sortAlpha,
+	"until":     func(count int) []int { return untilStep(0, count, 1) },
+	"untilStep": untilStep,
+
+	// Dicts
+	"dict": dict,
+	"get":  func(d map[string]interface{}, key string) interface{} { return d[key] },
+	"set": func(d map[string]interface{}, key string, value interface{}) map[string]interface{} {
+		d[key] = value
+		return d
+	},
+	"hasKey": func(d map[string]interface{}, key string) bool { _, ok := d[key]; return ok },
+	"keys":   keys,
+
+	// Encoding and environment
+	"toJson":    toJSON,
+	"b64enc":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec":    b64dec,
+	"sha256sum": func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+	"env":       os.Getenv,
+	"expandenv": os.ExpandEnv,
+
+	// Numbers
+	"atoi":  func(s string) int { i, _ := strconv.Atoi(s); return i },
+	"int":   func(v interface{}) int { return int(toInt64(v)) },
+	"int64": toInt64,
+	"add":   func(a, b interface{}) int64 { return toInt64(a) + toInt64(b) },
+	"add1":  func(a interface{}) int64 { return toInt64(a) + 1 },
+	"sub":   func(a, b interface{}) int64 { return toInt64(a) - toInt64(b) },
+	"mul":   func(a, b interface{}) int64 { return toInt64(a) * toInt64(b) },
+	"div":   div,
+	"mod":   mod,
+	"max":   func(a interface{}, others ...interface{}) int64 { return extreme(a, others, true) },
+	"min":   func(a interface{}, others ...interface{}) int64 { return extreme(a, others, false) },
+}
+
+func title(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func trunc(length int, s string) string {
+	if length < 0 && len(s)+length > 0 {
+		return s[len(s)+length:]
+	}
+	if length >= 0 && len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+func quote(values ...interface{}) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			quoted = append(quoted, strconv.Quote(toString(v)))
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func squote(values ...interface{}) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			quoted = append(quoted, "'"+toString(v)+"'")
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func cat(values ...interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			parts = append(parts, toString(v))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func join(sep string, v interface{}) (string, error) {
+	list, err := toList(v)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(list))
+	for _, item := range list {
+		parts = append(parts, toString(item))
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toInterfaces(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return list
+}
+
+// empty returns true for nil, false, 0, empty strings and empty collections, like sprig
+func empty(v interface{}) bool {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return value.IsNil()
+	}
+	return false
+}
+
+func defaultValue(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || empty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+func coalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !empty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// toList converts slices and arrays of any type to a list
+func toList(v interface{}) ([]interface{}, error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return nil, nil
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%T is not a list", v)
+	}
+	list := make([]interface{}, value.Len())
+	for i := range list {
+		list[i] = value.Index(i).Interface()
+	}
+	return list, nil
+}
+
+func first(v interface{}) (interface{}, error) {
+	list, err := toList(v)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+func last(v interface{}) (interface{}, error) {
+	list, err := toList(v)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[len(list)-1], nil
+}
+
+func rest(v interface{}) ([]interface{}, error) {
+	list, err := toList(v)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[1:], nil
+}
+
+func appendList(v interface{}, item interface{}) ([]interface{}, error) {
+	list, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]interface{}{}, list...), item), nil
+}
+
+func concat(lists ...interface{}) ([]interface{}, error) {
+	var result []interface{}
+	for _, v := range lists {
+		list, err := toList(v)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, list...)
+	}
+	return result, nil
+}
+
+func has(needle interface{}, haystack interface{}) (bool, error) {
+	list, err := toList(haystack)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range list {
+		if reflect.DeepEqual(item, needle) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func uniq(v interface{}) ([]interface{}, error) {
+	list, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, item := range list {
+		if found, _ := has(item, result); !found {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func sortAlpha(v interface{}) ([]string, error) {
+	list, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(list))
+	for i, item := range list {
+		result[i] = toString(item)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func untilStep(start int, stop int, step int) []int {
+	var result []int
+	if step == 0 || (step > 0 && start >= stop) || (step < 0 && start <= stop) {
+		return result
+	}
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		result = append(result, i)
+	}
+	return result
+}
+
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict requires pairs of key and value")
+	}
+	d := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		d[toString(pairs[i])] = pairs[i+1]
+	}
+	return d, nil
+}
+
+// keys returns the keys of the dicts sorted, so that ranges over them are deterministic
+func keys(dicts ...map[string]interface{}) []string {
+	var result []string
+	for _, d := range dicts {
+		for key := range d {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func b64dec(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	return string(data), err
+}
+
+func toInt64(v interface{}) int64 {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(value.Float())
+	case reflect.String:
+		i, _ := strconv.ParseInt(value.String(), 10, 64)
+		return i
+	case reflect.Bool:
+		if value.Bool() {
+			return 1
+		}
+	}
+	return 0
+}
+
+func div(a, b interface{}) (int64, error) {
+	if toInt64(b) == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+	return toInt64(a) / toInt64(b), nil
+}
+
+func mod(a, b interface{}) (int64, error) {
+	if toInt64(b) == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+	return toInt64(a) % toInt64(b), nil
+}
+
+func extreme(a interface{}, others []interface{}, max bool) int64 {
+	result := toInt64(a)
+	for _, v := range others {
+		if i := toInt64(v); (max && i > result) || (!max && i < result) {
+			result = i
+		}
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package flashpipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFilesTemplate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(file, []byte(`packages:
+  - integrationSuiteId: Sales
+    artifacts:
+{{- range list "orders" "invoices" "returns" }}
+      - artifactId: {{ . | upper | printf "DEV_%s" | quote }}
+        parameters:
+          - key: Endpoint
+            value: {{ printf "https://%s/%s" (get $.Values "host" | default "api.example.com") . | quote }}
+{{- end }}
+`), 0644))
+
+	configFiles, err := LoadConfigFilesWithOptions(file, nil, LoadOptions{Template: true})
+	require.NoError(t, err)
+	artifacts := configFiles[0].Config.Packages[0].Artifacts
+	require.Len(t, artifacts, 3)
+	assert.Equal(t, "DEV_ORDERS", artifacts[0].ID)
+	assert.Equal(t, "DEV_RETURNS", artifacts[2].ID)
+	assert.Equal(t, "https://api.example.com/invoices", artifacts[1].Parameters[0].Value)
+
+	configFiles, err = LoadConfigFilesWithOptions(dir, map[string]interface{}{"host": "prod.example.com"}, LoadOptions{Template: true})
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com/orders", configFiles[0].Config.Packages[0].Artifacts[0].Parameters[0].Value)
+
+	_, err = LoadConfigFiles(file, nil)
+	assert.Error(t, err, "Templates are not rendered without the option")
+}
+
+func TestRenderTemplateFuncs(t *testing.T) {
+	render := func(text string) string {
+		rendered, err := renderTemplate("config.yml", []byte(text), nil, true)
+		require.NoError(t, err)
+		return string(rendered)
+	}
+
+	assert.Equal(t, "0,1,2", render(`{{ until 3 | join "," }}`))
+	assert.Equal(t, "DEV_Orders-2", render(`{{ $d := dict "env" "DEV" "id" "Orders" }}{{ get $d "env" }}_{{ $d.id }}-{{ add1 1 }}`))
+	assert.Equal(t, "a b", render(`{{ keys (dict "b" 1 "a" 2) | sortAlpha | join " " }}`))
+	assert.Equal(t, "fallback", render(`{{ "" | default "fallback" }}`))
+	assert.Equal(t, "  a\n  b", render(`{{ "a\nb" | indent 2 }}`))
+	assert.Equal(t, "true", render(`{{ has "b" (list "a" "b") }}`))
+	assert.Equal(t, "no", render(`{{ ternary "yes" "no" false }}`))
+
+	_, err := renderTemplate("config.yml", []byte(`{{ fail "region is required" }}`), nil, true)
+	assert.ErrorContains(t, err, "region is required")
+}
@@ -0,0 +1,159 @@
+package flashpipe
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/schedule"
+	"github.com/engswee/flashpipe/internal/smoketest"
+)
+
+// ArtifactTypes are the artifact types supported in configuration files
+var ArtifactTypes = []string{"Integration", "MessageMapping", "ScriptCollection", "ValueMapping"}
+
+// ArtifactTypeAliases are the short aliases of the artifact types. Types and aliases are matched case-insensitively,
+// e.g. IFlow for Integration.
+var ArtifactTypeAliases = []string{"iflow", "mm", "sc", "vm"}
+
+// DeployStrategies are the deployment strategies supported for artifacts, empty defaults to inPlace
+var DeployStrategies = []string{"", "inPlace", "stopStart", "blueGreen"}
+
+// DraftHandlings are the handlings of artifacts in draft version, empty defaults to the handling of the run
+var DraftHandlings = []string{"", "error", "deploy", "versionFirst"}
+
+// BatchFallbacks are the handlings of failed batch requests, empty defaults to the handling of the configuration
+var BatchFallbacks = []string{"", BatchFallbackNone, BatchFallbackFailedOnly, BatchFallbackAll}
+
+// StampTargets are where the last run is recorded on the packages it changed
+var StampTargets = []string{StampTargetDescription, StampTargetTag}
+
+// Validate checks a configuration for missing IDs, unsupported artifact types and type aliases, deployment
+// strategies, smoke test assertions, draft handlings, batch fallbacks and stamp targets, parameters without key, with an unsupported mode or a value rejected by their
+// validator and invalid maintenance windows. All problems found are returned.
+func Validate(cfg *ConfigureConfig) []error {
+	var errs []error
+	if !slices.Contains(BatchFallbacks, cfg.BatchFallback) {
+		errs = append(errs, fmt.Errorf("invalid batchFallback %q", cfg.BatchFallback))
+	}
+	if cfg.Stamp != nil && !slices.Contains(StampTargets, cfg.Stamp.Target) {
+		errs = append(errs, fmt.Errorf("invalid stamp target %q", cfg.Stamp.Target))
+	}
+	for _, alias := range sortedKeys(cfg.TypeAliases) {
+		if _, err := resolveArtifactType(nil, cfg.TypeAliases[alias]); err != nil {
+			errs = append(errs, fmt.Errorf("typeAliases %s: %w", alias, err))
+		}
+	}
+	for pi, pkg := range cfg.Packages {
+		if pkg.ID == "" {
+			errs = append(errs, fmt.Errorf("package %d: integrationSuiteId is required", pi+1))
+		}
+		if err := validateWindow(pkg.Window); err != nil {
+			errs = append(errs, fmt.Errorf("package %s: %w", pkg.ID, err))
+		}
+		for ai, artifact := range pkg.Artifacts {
+			ref := artifact.ID
+			if ref == "" {
+				ref = fmt.Sprintf("%d", ai+1)
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: artifactId is required", pkg.ID, ref))
+			}
+			artifactType, err := resolveArtifactType(cfg.TypeAliases, artifact.Type)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: %w", pkg.ID, ref, err))
+			}
+			if !slices.Contains(DeployStrategies, artifact.Strategy) {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: invalid deployStrategy %q", pkg.ID, ref, artifact.Strategy))
+			}
+			if artifact.Strategy == "blueGreen" && (artifactType != "Integration" || artifact.BlueGreen == nil || artifact.BlueGreen.AddressParameter == "") {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: deployStrategy blueGreen requires type Integration and blueGreen.addressParameter", pkg.ID, ref))
+			}
+			if artifact.BlueGreen != nil && artifact.BlueGreen.SmokeTest != nil {
+				if err := smoketest.Validate(artifact.BlueGreen.SmokeTest); err != nil {
+					errs = append(errs, fmt.Errorf("package %s, artifact %s: %w", pkg.ID, ref, err))
+				}
+			}
+			if !slices.Contains(DraftHandlings, artifact.DraftHandling) {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: invalid draftHandling %q", pkg.ID, ref, artifact.DraftHandling))
+			}
+			if artifact.Batch != nil && !slices.Contains(BatchFallbacks, artifact.Batch.Fallback) {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: invalid batch fallback %q", pkg.ID, ref, artifact.Batch.Fallback))
+			}
+			if err := validateWindow(artifact.Window); err != nil {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: %w", pkg.ID, ref, err))
+			}
+			for _, param := range artifact.Parameters {
+				if param.Key == "" {
+					errs = append(errs, fmt.Errorf("package %s, artifact %s: parameter key is required", pkg.ID, ref))
+				}
+				if !slices.Contains(ParameterModes, param.Mode) {
+					errs = append(errs, fmt.Errorf("package %s, artifact %s, parameter %s: invalid mode %q", pkg.ID, ref, param.Key, param.Mode))
+				}
+				// Values from external sources are only known when they are resolved
+				validateErr := checkValidator(param.Validate)
+				if param.ValueFrom == nil {
+					validateErr = ValidateParameter(param)
+				}
+				if validateErr != nil {
+					errs = append(errs, fmt.Errorf("package %s, artifact %s, parameter %s: %w", pkg.ID, ref, param.Key, validateErr))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func validateWindow(w *MaintenanceWindow) error {
+	if w == nil {
+		return nil
+	}
+	_, err := schedule.ParseWindow(w.TimeRange, w.Cron, w.Duration, w.Timezone)
+	return err
+}
+
+// ResolveArtifactTypes replaces the type of each artifact with the supported type it stands for, so that
+// types can be written in any case, as an alias or as a custom type of typeAliases. All types that cannot be
+// resolved are returned in one error.
+func ResolveArtifactTypes(cfg *ConfigureConfig) error {
+	var errs []error
+	for pi := range cfg.Packages {
+		for ai := range cfg.Packages[pi].Artifacts {
+			artifact := &cfg.Packages[pi].Artifacts[ai]
+			artifactType, err := resolveArtifactType(cfg.TypeAliases, artifact.Type)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("package %s, artifact %s: %w", cfg.Packages[pi].ID, artifact.ID, err))
+				continue
+			}
+			artifact.Type = artifactType
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveArtifactType returns the supported type for a type, an alias or a custom type of aliases
+func resolveArtifactType(aliases map[string]string, artifactType string) (string, error) {
+	for alias, target := range aliases {
+		if strings.EqualFold(alias, artifactType) {
+			artifactType = target
+			break
+		}
+	}
+	if canonical := api.CanonicalArtifactType(artifactType); canonical != "" {
+		return canonical, nil
+	}
+	if hint := api.UnsupportedArtifactTypeHint(artifactType); hint != "" {
+		return "", fmt.Errorf("unsupported type %q: %s", artifactType, hint)
+	}
+	allowed := slices.Concat(ArtifactTypes, ArtifactTypeAliases, sortedKeys(aliases))
+	return "", fmt.Errorf("invalid type %q (allowed values: %s)", artifactType, strings.Join(allowed, ", "))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,57 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveArtifactTypes(t *testing.T) {
+	cfg, err := DecodeConfig("config.yml", []byte(`typeAliases:
+  flow: iflow
+packages:
+  - integrationSuiteId: Orders
+    artifacts:
+      - artifactId: Orders_Replicate
+        type: IFlow
+      - artifactId: Orders_Mapping
+        type: mm
+      - artifactId: Orders_Scripts
+        type: scriptcollection
+      - artifactId: Orders_Custom
+        type: Flow
+`))
+	require.NoError(t, err)
+	assert.Empty(t, Validate(cfg))
+
+	require.NoError(t, ResolveArtifactTypes(cfg))
+	var types []string
+	for _, artifact := range cfg.Packages[0].Artifacts {
+		types = append(types, artifact.Type)
+	}
+	assert.Equal(t, []string{"Integration", "MessageMapping", "ScriptCollection", "Integration"}, types)
+}
+
+func TestResolveArtifactTypesInvalid(t *testing.T) {
+	cfg := &ConfigureConfig{
+		TypeAliases: map[string]string{"flow": "Workflow"},
+		Packages: []ConfigurePackage{{ID: "Orders", Artifacts: []ConfigureArtifact{
+			{ID: "Orders_Replicate", Type: "iflows"},
+			{ID: "Orders_Mapping", Type: "mm"},
+		}}},
+	}
+	err := ResolveArtifactTypes(cfg)
+	assert.EqualError(t, err, `package Orders, artifact Orders_Replicate: invalid type "iflows" (allowed values: Integration, MessageMapping, ScriptCollection, ValueMapping, iflow, mm, sc, vm, flow)`)
+	assert.Equal(t, "MessageMapping", cfg.Packages[0].Artifacts[1].Type)
+
+	errs := Validate(cfg)
+	require.Len(t, errs, 2)
+	assert.ErrorContains(t, errs[0], `typeAliases flow: invalid type "Workflow"`)
+}
+
+func TestResolveArtifactTypesIntegrationAdvisor(t *testing.T) {
+	cfg := &ConfigureConfig{Packages: []ConfigurePackage{{ID: "B2B", Artifacts: []ConfigureArtifact{{ID: "Orders_X12_to_IDoc", Type: "MAG"}}}}}
+	err := ResolveArtifactTypes(cfg)
+	assert.ErrorContains(t, err, `unsupported type "MAG": Integration Advisor MAGs are not part of packages, promote them with flashpipe advisor export and advisor import`)
+}
@@ -0,0 +1,189 @@
+package flashpipe
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// validatorRegexPrefix starts a validator that matches values against a regular expression
+const validatorRegexPrefix = "regex:"
+
+// ParameterValidators are the named validators of parameter values, e.g. validate: url. Values are checked
+// before anything is written to the tenant.
+var ParameterValidators = map[string]func(value string) error{
+	"url":      validateURL,
+	"https":    validateHTTPS,
+	"host":     validateHost,
+	"hostport": validateHostPort,
+	"port":     validatePort,
+	"number":   validateNumber,
+	"integer":  validateInteger,
+	"boolean":  validateBoolean,
+	"email":    validateEmail,
+}
+
+// ValidatorNames returns the names of the validators in alphabetical order
+func ValidatorNames() []string {
+	names := make([]string, 0, len(ParameterValidators))
+	for name := range ParameterValidators {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// checkValidator returns an error if the validator of a parameter is neither a named validator nor a valid
+// regex:<pattern>
+func checkValidator(validator string) error {
+	if validator == "" || ParameterValidators[validator] != nil {
+		return nil
+	}
+	if pattern, found := strings.CutPrefix(validator, validatorRegexPrefix); found {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid validate %q: %w", validator, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid validate %q (valid validators: %s, %s<pattern>)", validator,
+		strings.Join(ValidatorNames(), ", "), validatorRegexPrefix)
+}
+
+// ValidateParameter checks the value of a parameter with its validator. With the append mode, each item of
+// the value is checked. Parameters without validator and with the delete mode are not checked.
+func ValidateParameter(param ConfigurationParameter) error {
+	if param.Validate == "" || param.Mode == ParameterModeDelete {
+		return nil
+	}
+	if err := checkValidator(param.Validate); err != nil {
+		return err
+	}
+	validate := ParameterValidators[param.Validate]
+	if pattern, found := strings.CutPrefix(param.Validate, validatorRegexPrefix); found {
+		re := regexp.MustCompile("^(?:" + pattern + ")$")
+		validate = func(value string) error {
+			if !re.MatchString(value) {
+				return fmt.Errorf("does not match %s", pattern)
+			}
+			return nil
+		}
+	}
+	values := []string{param.Value}
+	if param.Mode == ParameterModeAppend {
+		values = strings.Split(param.Value, separator(param))
+	}
+	for _, value := range values {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("value %q is not valid for %s: %w", value, param.Validate, err)
+		}
+	}
+	return nil
+}
+
+// ValidateParameterValues checks the values of all parameters with a validator and returns all problems found
+func ValidateParameterValues(cfg *ConfigureConfig) []error {
+	var errs []error
+	for _, pkg := range cfg.Packages {
+		for _, artifact := range pkg.Artifacts {
+			for _, param := range artifact.Parameters {
+				if err := ValidateParameter(param); err != nil {
+					errs = append(errs, fmt.Errorf("package %s, artifact %s, parameter %s: %w", pkg.ID, artifact.ID, param.Key, err))
+				}
+			}
+		}
+	}
+	for _, target := range cfg.Targets {
+		for _, param := range target.TenantDefaults {
+			if err := ValidateParameter(param); err != nil {
+				errs = append(errs, fmt.Errorf("target %s, tenant default %s: %w", target.Name, param.Key, err))
+			}
+		}
+	}
+	return errs
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.Unwrap(err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return errors.New("expected an absolute http or https URL")
+	}
+	return validateHostPort(u.Host)
+}
+
+func validateHTTPS(value string) error {
+	if err := validateURL(value); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(value, "https://") {
+		return errors.New("expected an https URL")
+	}
+	return nil
+}
+
+// hostPattern matches DNS names, including names of the SAP Cloud Connector virtual hosts
+var hostPattern = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+func validateHost(value string) error {
+	if net.ParseIP(strings.Trim(value, "[]")) != nil || hostPattern.MatchString(value) {
+		return nil
+	}
+	return errors.New("expected a host name or IP address")
+}
+
+// validateHostPort accepts a host with an optional port
+func validateHostPort(value string) error {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		// Without port
+		return validateHost(value)
+	}
+	if err := validateHost(host); err != nil {
+		return err
+	}
+	return validatePort(port)
+}
+
+func validatePort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return errors.New("expected a port between 1 and 65535")
+	}
+	return nil
+}
+
+func validateNumber(value string) error {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return errors.New("expected a number")
+	}
+	return nil
+}
+
+func validateInteger(value string) error {
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return errors.New("expected an integer")
+	}
+	return nil
+}
+
+func validateBoolean(value string) error {
+	if value != "true" && value != "false" {
+		return errors.New("expected true or false")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+		return errors.New("expected an e-mail address")
+	}
+	return nil
+}
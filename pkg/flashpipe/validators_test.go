@@ -0,0 +1,67 @@
+package flashpipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateParameter(t *testing.T) {
+	valid := []ConfigurationParameter{
+		{Validate: "url", Value: "https://erp.example.com:44300/sap/opu/odata"},
+		{Validate: "url", Value: "http://virtualhost:8080"},
+		{Validate: "https", Value: "https://tenant.authentication.eu10.hana.ondemand.com/oauth/token"},
+		{Validate: "host", Value: "10.0.0.1"},
+		{Validate: "hostport", Value: "erp.example.com:443"},
+		{Validate: "hostport", Value: "erp.example.com"},
+		{Validate: "port", Value: "8443"},
+		{Validate: "number", Value: "1.5"},
+		{Validate: "integer", Value: "60000"},
+		{Validate: "boolean", Value: "true"},
+		{Validate: "email", Value: "ops@example.com"},
+		{Validate: "regex:[A-Z]{3}", Value: "EUR"},
+		{Validate: "hostport", Value: "a.example.com:443,b.example.com:443", Mode: ParameterModeAppend},
+		{Validate: "url", Value: "", Mode: ParameterModeDelete},
+		{Value: "anything"},
+	}
+	for _, param := range valid {
+		assert.NoError(t, ValidateParameter(param), "%s should accept %q", param.Validate, param.Value)
+	}
+
+	invalid := []ConfigurationParameter{
+		{Validate: "url", Value: "erp.example.com/sap"},
+		{Validate: "url", Value: "ftp://erp.example.com"},
+		{Validate: "https", Value: "http://erp.example.com"},
+		{Validate: "host", Value: "erp_example.com"},
+		{Validate: "hostport", Value: "erp.example.com:0"},
+		{Validate: "port", Value: "https"},
+		{Validate: "number", Value: "1,5"},
+		{Validate: "integer", Value: "60s"},
+		{Validate: "boolean", Value: "yes"},
+		{Validate: "email", Value: "Ops <ops@example.com>"},
+		{Validate: "regex:[A-Z]{3}", Value: "EURO"},
+		{Validate: "hostport", Value: "a.example.com:443,b.example.com:x", Mode: ParameterModeAppend},
+	}
+	for _, param := range invalid {
+		assert.Error(t, ValidateParameter(param), "%s should reject %q", param.Validate, param.Value)
+	}
+
+	assert.EqualError(t, ValidateParameter(ConfigurationParameter{Validate: "uri", Value: "x"}),
+		`invalid validate "uri" (valid validators: boolean, email, host, hostport, https, integer, number, port, url, regex:<pattern>)`)
+	assert.ErrorContains(t, ValidateParameter(ConfigurationParameter{Validate: "regex:[", Value: "x"}), "missing closing ]")
+}
+
+func TestValidateParameterValues(t *testing.T) {
+	cfg := &ConfigureConfig{Packages: []ConfigurePackage{{ID: "Orders", Artifacts: []ConfigureArtifact{{ID: "Orders_Replicate", Type: "Integration",
+		Parameters: []ConfigurationParameter{
+			{Key: "ReceiverURL", Value: "erp.example.com", Validate: "url"},
+			{Key: "ReceiverPort", Value: "443", Validate: "port"},
+			{Key: "TokenURL", ValueFrom: &ValueFromSource{Destination: "OAuth#URL"}, Validate: "https"},
+		}}}}}}
+	errs := ValidateParameterValues(cfg)
+	assert.Len(t, errs, 2, "Unresolved value from an external source should be rejected at apply time")
+	assert.EqualError(t, errs[0], `package Orders, artifact Orders_Replicate, parameter ReceiverURL: value "erp.example.com" is not valid for url: expected an absolute http or https URL`)
+
+	errs = Validate(cfg)
+	assert.Len(t, errs, 1, "Values from external sources should not be validated before they are resolved")
+}